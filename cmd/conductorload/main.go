@@ -0,0 +1,284 @@
+// Command conductorload is a synthetic load generator for the auth conductor. It
+// registers thousands of in-memory auths backed by an instant executor and drives
+// Manager.Execute or Manager.ExecuteStream from many goroutines, so the reported
+// latency is selection and bookkeeping overhead rather than upstream time.
+//
+// Usage:
+//
+//	go run ./cmd/conductorload [flags]
+//
+// Flags:
+//
+//	--auths            <n>     Number of auths to register           (default: 5000)
+//	--providers        <n>     Spread auths over this many providers (default: 1)
+//	--concurrency      <n>     Concurrent callers                    (default: 64)
+//	--duration         <d>     Load duration                         (default: 10s)
+//	--stream                   Use ExecuteStream instead of Execute  (default: false)
+//	--failure-rate     <f>     Fraction of calls answered with 429   (default: 0)
+//	--executor-latency <d>     Simulated upstream latency            (default: 0)
+//	--selector         <name>  round-robin or fill-first             (default: "round-robin")
+//	--mutexprofile     <path>  Write a mutex contention profile
+//	--cpuprofile       <path>  Write a CPU profile
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/metrics"
+	"runtime/pprof"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	loadModel          = "load-model"
+	mutexWaitMetricKey = "/sync/mutex/wait/total:seconds"
+)
+
+func init() {
+	logging.SetupBaseLogger()
+	log.SetLevel(log.ErrorLevel)
+}
+
+// loadStatusError is the simulated upstream failure.
+type loadStatusError struct{ code int }
+
+func (e loadStatusError) Error() string   { return http.StatusText(e.code) }
+func (e loadStatusError) StatusCode() int { return e.code }
+
+// loadExecutor answers after an optional delay and fails a configurable fraction of calls.
+type loadExecutor struct {
+	provider    string
+	latency     time.Duration
+	failureRate float64
+}
+
+func (e loadExecutor) Identifier() string { return e.provider }
+
+func (e loadExecutor) wait(ctx context.Context) error {
+	if e.latency > 0 {
+		timer := time.NewTimer(e.latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if e.failureRate > 0 && rand.Float64() < e.failureRate {
+		return loadStatusError{code: http.StatusTooManyRequests}
+	}
+	return nil
+}
+
+func (e loadExecutor) Execute(ctx context.Context, _ *coreauth.Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if err := e.wait(ctx); err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	return cliproxyexecutor.Response{Payload: []byte(`{"ok":true}`)}, nil
+}
+
+func (e loadExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	if err := e.wait(ctx); err != nil {
+		return nil, err
+	}
+	chunks := make(chan cliproxyexecutor.StreamChunk, 2)
+	chunks <- cliproxyexecutor.StreamChunk{Payload: []byte(`data: {"ok":true}`)}
+	chunks <- cliproxyexecutor.StreamChunk{Payload: []byte(`data: [DONE]`)}
+	close(chunks)
+	return &cliproxyexecutor.StreamResult{Headers: http.Header{}, Chunks: chunks}, nil
+}
+
+func (e loadExecutor) CountTokens(ctx context.Context, auth *coreauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return e.Execute(ctx, auth, req, opts)
+}
+
+func (e loadExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e loadExecutor) HttpRequest(_ context.Context, _ *coreauth.Auth, _ *http.Request) (*http.Response, error) {
+	return nil, loadStatusError{code: http.StatusNotImplemented}
+}
+
+func main() {
+	var (
+		authCount       int
+		providerCount   int
+		concurrency     int
+		duration        time.Duration
+		stream          bool
+		failureRate     float64
+		executorLatency time.Duration
+		selectorName    string
+		mutexProfile    string
+		cpuProfile      string
+	)
+	flag.IntVar(&authCount, "auths", 5000, "number of auths to register")
+	flag.IntVar(&providerCount, "providers", 1, "spread auths over this many providers")
+	flag.IntVar(&concurrency, "concurrency", 64, "concurrent callers")
+	flag.DurationVar(&duration, "duration", 10*time.Second, "load duration")
+	flag.BoolVar(&stream, "stream", false, "use ExecuteStream instead of Execute")
+	flag.Float64Var(&failureRate, "failure-rate", 0, "fraction of calls answered with 429")
+	flag.DurationVar(&executorLatency, "executor-latency", 0, "simulated upstream latency")
+	flag.StringVar(&selectorName, "selector", "round-robin", "round-robin or fill-first")
+	flag.StringVar(&mutexProfile, "mutexprofile", "", "write a mutex contention profile to this file")
+	flag.StringVar(&cpuProfile, "cpuprofile", "", "write a CPU profile to this file")
+	flag.Parse()
+
+	if authCount <= 0 || providerCount <= 0 || concurrency <= 0 || duration <= 0 {
+		fmt.Fprintln(os.Stderr, "auths, providers, concurrency and duration must be positive")
+		os.Exit(2)
+	}
+
+	var selector coreauth.Selector
+	switch selectorName {
+	case "round-robin":
+		selector = &coreauth.RoundRobinSelector{}
+	case "fill-first":
+		selector = &coreauth.FillFirstSelector{}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown selector %q\n", selectorName)
+		os.Exit(2)
+	}
+
+	ctx := context.Background()
+	manager := coreauth.NewManager(nil, selector, nil)
+	reg := registry.GetGlobalRegistry()
+	providers := make([]string, providerCount)
+	for i := range providers {
+		providers[i] = fmt.Sprintf("load-%d", i)
+		manager.RegisterExecutor(loadExecutor{provider: providers[i], latency: executorLatency, failureRate: failureRate})
+	}
+	setupStart := time.Now()
+	for i := 0; i < authCount; i++ {
+		provider := providers[i%providerCount]
+		auth := &coreauth.Auth{ID: fmt.Sprintf("%s-%05d", provider, i), Provider: provider, Status: coreauth.StatusActive}
+		if _, errRegister := manager.Register(ctx, auth); errRegister != nil {
+			fmt.Fprintf(os.Stderr, "register %s: %v\n", auth.ID, errRegister)
+			os.Exit(1)
+		}
+		reg.RegisterClient(auth.ID, provider, []*registry.ModelInfo{{ID: loadModel}})
+	}
+	fmt.Printf("registered %d auths across %d providers in %s\n", authCount, providerCount, time.Since(setupStart).Round(time.Millisecond))
+
+	if mutexProfile != "" {
+		runtime.SetMutexProfileFraction(1)
+	}
+	if cpuProfile != "" {
+		file, errCreate := os.Create(cpuProfile)
+		if errCreate != nil {
+			fmt.Fprintf(os.Stderr, "create cpu profile: %v\n", errCreate)
+			os.Exit(1)
+		}
+		defer func() { _ = file.Close() }()
+		if errStart := pprof.StartCPUProfile(file); errStart != nil {
+			fmt.Fprintf(os.Stderr, "start cpu profile: %v\n", errStart)
+			os.Exit(1)
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	mutexWaitBefore := readMutexWait()
+	loadCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	var failures atomic.Int64
+	perWorker := make([][]time.Duration, concurrency)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for worker := 0; worker < concurrency; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			req := cliproxyexecutor.Request{Model: loadModel}
+			for loadCtx.Err() == nil {
+				callStart := time.Now()
+				var errCall error
+				if stream {
+					var result *cliproxyexecutor.StreamResult
+					result, errCall = manager.ExecuteStream(loadCtx, providers, req, cliproxyexecutor.Options{Stream: true})
+					if errCall == nil && result != nil {
+						for chunk := range result.Chunks {
+							if chunk.Err != nil {
+								errCall = chunk.Err
+							}
+						}
+					}
+				} else {
+					_, errCall = manager.Execute(loadCtx, providers, req, cliproxyexecutor.Options{})
+				}
+				if loadCtx.Err() != nil {
+					return
+				}
+				perWorker[worker] = append(perWorker[worker], time.Since(callStart))
+				if errCall != nil {
+					failures.Add(1)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	mutexWait := readMutexWait() - mutexWaitBefore
+
+	var latencies []time.Duration
+	for _, samples := range perWorker {
+		latencies = append(latencies, samples...)
+	}
+	if len(latencies) == 0 {
+		fmt.Println("no calls completed")
+		return
+	}
+	slices.Sort(latencies)
+	percentile := func(p int) time.Duration { return latencies[(len(latencies)-1)*p/100] }
+
+	mode := "execute"
+	if stream {
+		mode = "execute-stream"
+	}
+	fmt.Printf("mode=%s selector=%s concurrency=%d duration=%s\n", mode, selectorName, concurrency, elapsed.Round(time.Millisecond))
+	fmt.Printf("calls=%d failures=%d throughput=%.0f/s\n", len(latencies), failures.Load(), float64(len(latencies))/elapsed.Seconds())
+	fmt.Printf("latency p50=%s p90=%s p99=%s max=%s\n", percentile(50), percentile(90), percentile(99), latencies[len(latencies)-1])
+	fmt.Printf("mutex wait=%s (%.1fµs/call)\n", mutexWait.Round(time.Microsecond), float64(mutexWait.Microseconds())/float64(len(latencies)))
+
+	if mutexProfile != "" {
+		if errWrite := writeProfile("mutex", mutexProfile); errWrite != nil {
+			fmt.Fprintf(os.Stderr, "write mutex profile: %v\n", errWrite)
+			os.Exit(1)
+		}
+	}
+}
+
+// readMutexWait returns the cumulative time goroutines spent blocked on sync.Mutex and
+// sync.RWMutex, as reported by the runtime.
+func readMutexWait() time.Duration {
+	sample := []metrics.Sample{{Name: mutexWaitMetricKey}}
+	metrics.Read(sample)
+	if sample[0].Value.Kind() != metrics.KindFloat64 {
+		return 0
+	}
+	return time.Duration(sample[0].Value.Float64() * float64(time.Second))
+}
+
+func writeProfile(name, path string) error {
+	file, errCreate := os.Create(path)
+	if errCreate != nil {
+		return errCreate
+	}
+	defer func() { _ = file.Close() }()
+	return pprof.Lookup(name).WriteTo(file, 0)
+}
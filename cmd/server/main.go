@@ -98,6 +98,7 @@ func main() {
 	var gitlabLogin bool
 	var gitlabTokenLogin bool
 	var noBrowser bool
+	var headless bool
 	var oauthCallbackPort int
 	var antigravityLogin bool
 	var kimiLogin bool
@@ -118,6 +119,16 @@ func main() {
 	var projectID string
 	var vertexImport string
 	var vertexImportPrefix string
+	var encryptAuthFiles bool
+	var validateModelMappings bool
+	var decryptAuthFiles bool
+	var exportAuth string
+	var exportAuthFiles string
+	var importAuth string
+	var importAuthConflict string
+	var authBundlePassphrase string
+	var importProviderKeys string
+	var importProviderKeysNoValidate bool
 	var configPath string
 	var password string
 	var homeJWT string
@@ -139,6 +150,7 @@ func main() {
 	flag.BoolVar(&gitlabLogin, "gitlab-login", false, "Login to GitLab Duo using OAuth")
 	flag.BoolVar(&gitlabTokenLogin, "gitlab-token-login", false, "Login to GitLab Duo using a personal access token")
 	flag.BoolVar(&noBrowser, "no-browser", false, "Don't open browser automatically for OAuth")
+	flag.BoolVar(&headless, "headless", false, "Complete OAuth logins without a local browser: use device-code flows where available, else paste the redirected URL")
 	flag.IntVar(&oauthCallbackPort, "oauth-callback-port", 0, "Override OAuth callback port (defaults to provider-specific port)")
 	flag.BoolVar(&useIncognito, "incognito", false, "Open browser in incognito/private mode for OAuth (useful for multiple accounts)")
 	flag.BoolVar(&noIncognito, "no-incognito", false, "Force disable incognito mode (uses existing browser session)")
@@ -162,6 +174,16 @@ func main() {
 	flag.StringVar(&configPath, "config", DefaultConfigPath, "Configure File Path")
 	flag.StringVar(&vertexImport, "vertex-import", "", "Import Vertex service account key JSON file")
 	flag.StringVar(&vertexImportPrefix, "vertex-import-prefix", "", "Prefix for Vertex model namespacing (use with -vertex-import)")
+	flag.BoolVar(&encryptAuthFiles, "encrypt-auth-files", false, "Encrypt plaintext auth files in the auth directory in place and exit")
+	flag.BoolVar(&decryptAuthFiles, "decrypt-auth-files", false, "Decrypt encrypted auth files in the auth directory in place and exit")
+	flag.StringVar(&exportAuth, "export-auth", "", "Export auth files to a bundle file for moving credentials to another machine and exit")
+	flag.StringVar(&exportAuthFiles, "export-auth-files", "", "Comma-separated auth file names to export (use with -export-auth; default all)")
+	flag.StringVar(&importAuth, "import-auth", "", "Import auth files from a bundle created with -export-auth and exit")
+	flag.StringVar(&importAuthConflict, "import-auth-conflict", cmd.AuthImportSkip, "What to do when an imported auth file already exists: skip, overwrite or rename")
+	flag.StringVar(&authBundlePassphrase, "auth-bundle-passphrase", "", "Passphrase to encrypt or decrypt the auth bundle (or set "+cmd.AuthBundlePassphraseEnv+")")
+	flag.StringVar(&importProviderKeys, "import-provider-keys", "", "Register provider API keys from a CSV or JSON manifest in the config file and exit")
+	flag.BoolVar(&importProviderKeysNoValidate, "import-provider-keys-no-validate", false, "Skip the models-list call that validates each key (use with -import-provider-keys)")
+	flag.BoolVar(&validateModelMappings, "validate-model-mappings", false, "Check model-mappings for invalid and shadowed rules and exit")
 	flag.StringVar(&password, "password", "", "")
 	flag.StringVar(&homeJWT, "home-jwt", "", "Home control plane JWT for mTLS certificate bootstrap and connection")
 	flag.BoolVar(&homeDisableClusterDiscovery, "home-disable-cluster-discovery", false, "Disable Home CLUSTER NODES discovery and keep using the configured -home-jwt address")
@@ -639,11 +661,12 @@ func main() {
 
 	// Create login options to be used in authentication flows.
 	options := &cmd.LoginOptions{
-		NoBrowser:    noBrowser,
+		NoBrowser:    noBrowser || headless,
+		Headless:     headless,
 		CallbackPort: oauthCallbackPort,
 	}
 
-	commandMode := vertexImport != "" || login || antigravityLogin || codexLogin || codexDeviceLogin || claudeLogin || kimiLogin || xaiLogin
+	commandMode := vertexImport != "" || encryptAuthFiles || decryptAuthFiles || exportAuth != "" || importAuth != "" || importProviderKeys != "" || validateModelMappings || login || antigravityLogin || codexLogin || codexDeviceLogin || claudeLogin || kimiLogin || xaiLogin
 	cloudConfigMissing := isCloudDeploy && !configFileExists
 	homeMode := configLoadedFromHome || (cfg != nil && cfg.Home.Enabled)
 	exampleAPIKeySafeMode := shouldEnableExampleAPIKeySafeMode(cfg, commandMode, tuiMode, standalone, cloudConfigMissing, homeMode)
@@ -655,15 +678,31 @@ func main() {
 	}
 
 	// Register the shared token store once so all components use the same persistence backend.
+	var tokenStore coreauth.Store
 	if usePostgresStore {
-		sdkAuth.RegisterTokenStore(pgStoreInst)
+		tokenStore = pgStoreInst
 	} else if useObjectStore {
-		sdkAuth.RegisterTokenStore(objectStoreInst)
+		tokenStore = objectStoreInst
 	} else if useGitStore {
-		sdkAuth.RegisterTokenStore(gitStoreInst)
+		tokenStore = gitStoreInst
 	} else {
-		sdkAuth.RegisterTokenStore(sdkAuth.NewFileTokenStore())
+		tokenStore = sdkAuth.NewFileTokenStore()
 	}
+	if cfg.AuthEncryption.Enabled {
+		metadataCipher, errCipher := cmd.AuthEncryptionCipher(cfg)
+		if errCipher != nil {
+			log.Errorf("failed to initialize auth encryption: %v", errCipher)
+			return
+		}
+		encryptingStore, errWrap := coreauth.NewEncryptingStore(tokenStore, metadataCipher)
+		if errWrap != nil {
+			log.Errorf("failed to initialize auth encryption: %v", errWrap)
+			return
+		}
+		coreauth.RegisterMetadataCipher(metadataCipher)
+		tokenStore = encryptingStore
+	}
+	sdkAuth.RegisterTokenStore(tokenStore)
 
 	// Register built-in access providers before constructing services.
 	configaccess.Register(&cfg.SDKConfig)
@@ -695,6 +734,27 @@ func main() {
 	if vertexImport != "" {
 		// Handle Vertex service account import
 		cmd.DoVertexImport(cfg, vertexImport, vertexImportPrefix)
+	} else if encryptAuthFiles || decryptAuthFiles {
+		// Convert existing auth files to or from encrypted form
+		cmd.DoMigrateAuthEncryption(cfg, decryptAuthFiles)
+	} else if exportAuth != "" {
+		// Bundle auth files for another machine
+		var names []string
+		for _, name := range strings.Split(exportAuthFiles, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		cmd.DoExportAuthFiles(cfg, exportAuth, names, cmd.AuthBundlePassphrase(authBundlePassphrase))
+	} else if importAuth != "" {
+		// Add auth files from a bundle
+		cmd.DoImportAuthFiles(cfg, importAuth, cmd.AuthBundlePassphrase(authBundlePassphrase), strings.ToLower(strings.TrimSpace(importAuthConflict)))
+	} else if importProviderKeys != "" {
+		// Register provider API keys in bulk
+		cmd.DoImportProviderKeys(cfg, configFilePath, importProviderKeys, importProviderKeysNoValidate)
+	} else if validateModelMappings {
+		// Report invalid and shadowed model mapping rules
+		cmd.DoValidateModelMappings(cfg)
 	} else if login {
 		// Handle Google/Gemini login
 		cmd.DoLogin(cfg, projectID, options)
@@ -0,0 +1,88 @@
+package configaccess
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tenant"
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+// tenantProviderName identifies the tenant provider in access results.
+const tenantProviderName = "tenant"
+
+// registerTenants points the default tenant store at the configured file and registers
+// the tenant provider while the subsystem is enabled.
+func registerTenants(cfg *sdkconfig.SDKConfig) {
+	if cfg == nil || !cfg.Tenants.Enabled {
+		sdkaccess.UnregisterProvider(sdkaccess.AccessProviderTypeTenant)
+		return
+	}
+	store := tenant.Default()
+	store.SetPath(cfg.Tenants.StorePath)
+	sdkaccess.RegisterProvider(sdkaccess.AccessProviderTypeTenant, &tenantProvider{store: store})
+}
+
+type tenantKeyCandidate struct {
+	value  string
+	source string
+}
+
+// tenantProvider authenticates tenant API keys and rejects disabled or over-quota tenants.
+type tenantProvider struct {
+	store *tenant.Store
+}
+
+func (p *tenantProvider) Identifier() string {
+	return tenantProviderName
+}
+
+func (p *tenantProvider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.Result, *sdkaccess.AuthError) {
+	if p == nil || p.store == nil || p.store.Len() == 0 {
+		return nil, sdkaccess.NewNotHandledError()
+	}
+	candidates := []tenantKeyCandidate{
+		{extractBearerToken(r.Header.Get("Authorization")), "authorization"},
+		{r.Header.Get("X-Goog-Api-Key"), "x-goog-api-key"},
+		{r.Header.Get("X-Api-Key"), "x-api-key"},
+	}
+	if r.URL != nil {
+		candidates = append(candidates,
+			tenantKeyCandidate{r.URL.Query().Get("key"), "query-key"},
+			tenantKeyCandidate{r.URL.Query().Get("auth_token"), "query-auth-token"},
+		)
+	}
+
+	seen := false
+	for _, candidate := range candidates {
+		if candidate.value == "" {
+			continue
+		}
+		seen = true
+		t, found, errAuthorize := p.store.Authorize(candidate.value)
+		if !found {
+			continue
+		}
+		switch {
+		case errors.Is(errAuthorize, tenant.ErrQuotaExceeded):
+			return nil, sdkaccess.NewQuotaExceededErrorForProvider(errAuthorize.Error(), sdkaccess.AccessProviderTypeTenant)
+		case errAuthorize != nil:
+			return nil, sdkaccess.NewForbiddenErrorForProvider(errAuthorize.Error(), sdkaccess.AccessProviderTypeTenant)
+		}
+		return &sdkaccess.Result{
+			Provider:     p.Identifier(),
+			ProviderType: sdkaccess.AccessProviderTypeTenant,
+			Principal:    candidate.value,
+			Metadata: map[string]string{
+				"source":    candidate.source,
+				"tenant_id": t.ID,
+			},
+		}, nil
+	}
+	if !seen {
+		return nil, sdkaccess.NewNoCredentialsError()
+	}
+	return nil, sdkaccess.NewInvalidCredentialErrorForProvider(sdkaccess.AccessProviderTypeTenant)
+}
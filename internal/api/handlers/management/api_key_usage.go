@@ -53,13 +53,23 @@ func apiKeyUsageProviderKey(auth *coreauth.Auth) string {
 	return provider
 }
 
+// apiKeyUsageSortFields lists the fields accepted by the sort parameter of paginated usage listings.
+var apiKeyUsageSortFields = []string{"key", "provider", "success", "failed"}
+
 // GetAPIKeyUsage returns recent request buckets for all in-memory api_key auths,
 // grouped by provider and keyed by "base_url|api_key".
+// The provider and q filters narrow the result. When limit, cursor, or sort is
+// supplied the response becomes a flat page: {"items": [...], "total": n, "next_cursor": "..."}.
 func (h *Handler) GetAPIKeyUsage(c *gin.Context) {
 	if h == nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler not initialized"})
 		return
 	}
+	query, errQuery := parseListQuery(c, "key", apiKeyUsageSortFields...)
+	if errQuery != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errQuery.Error()})
+		return
+	}
 
 	h.mu.Lock()
 	manager := h.authManager
@@ -113,5 +123,45 @@ func (h *Handler) GetAPIKeyUsage(c *gin.Context) {
 		}
 	}
 
-	c.JSON(http.StatusOK, out)
+	paged := query.limit > 0 || query.cursor != nil || strings.TrimSpace(c.Query("sort")) != ""
+	if !paged && len(query.providers) == 0 && query.search == "" {
+		c.JSON(http.StatusOK, out)
+		return
+	}
+
+	items := make([]gin.H, 0)
+	for provider, bucket := range out {
+		for key, entry := range bucket {
+			items = append(items, gin.H{
+				"id":              provider + "\x00" + key,
+				"provider":        provider,
+				"key":             key,
+				"success":         entry.Success,
+				"failed":          entry.Failed,
+				"recent_requests": entry.RecentRequests,
+			})
+		}
+	}
+	page, total, next := query.paginate(items, "id")
+	if !paged {
+		filtered := make(map[string]map[string]apiKeyUsageEntry)
+		for _, item := range page {
+			provider, _ := item["provider"].(string)
+			key, _ := item["key"].(string)
+			if filtered[provider] == nil {
+				filtered[provider] = make(map[string]apiKeyUsageEntry)
+			}
+			filtered[provider][key] = out[provider][key]
+		}
+		c.JSON(http.StatusOK, filtered)
+		return
+	}
+	for _, item := range page {
+		delete(item, "id")
+	}
+	body := gin.H{"items": page, "total": total}
+	if next != "" {
+		body["next_cursor"] = next
+	}
+	c.JSON(http.StatusOK, body)
 }
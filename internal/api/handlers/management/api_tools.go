@@ -598,7 +598,7 @@ func resolveAPIKeyConfig[T apiKeyConfigEntry](entries []T, auth *coreauth.Auth)
 	}
 	attrKey, attrBase := "", ""
 	if auth.Attributes != nil {
-		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrKey = auth.ConfigAPIKey()
 		attrBase = strings.TrimSpace(auth.Attributes["base_url"])
 	}
 	for i := range entries {
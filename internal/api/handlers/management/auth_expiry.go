@@ -0,0 +1,43 @@
+package management
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/authexpiry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// GetAuthExpiryReport returns the credentials that need a re-login: those without a refresh
+// token expiring within the configured days and those whose refresh keeps failing. The
+// days and threshold query parameters override the expiry-report settings.
+func (h *Handler) GetAuthExpiryReport(c *gin.Context) {
+	var settings config.ExpiryReportConfig
+	h.mu.Lock()
+	if h.cfg != nil {
+		settings = h.cfg.ExpiryReport
+	}
+	h.mu.Unlock()
+	opts := authexpiry.OptionsFromConfig(settings)
+	for name, target := range map[string]*int{"days": &opts.WithinDays, "threshold": &opts.FailureThreshold} {
+		raw := strings.TrimSpace(c.Query(name))
+		if raw == "" {
+			continue
+		}
+		value, errParse := strconv.Atoi(raw)
+		if errParse != nil || value <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + name + " value"})
+			return
+		}
+		*target = value
+	}
+	var auths []*coreauth.Auth
+	if h.authManager != nil {
+		auths = h.authManager.List()
+	}
+	c.JSON(http.StatusOK, authexpiry.Build(auths, opts, time.Now()))
+}
@@ -0,0 +1,56 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/authexpiry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestGetAuthExpiryReport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	manager := coreauth.NewManager(nil, nil, nil)
+	for _, auth := range []*coreauth.Auth{
+		{ID: "failing", Provider: "codex", RefreshFailures: 2},
+		{ID: "healthy", Provider: "codex"},
+	} {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register auth: %v", errRegister)
+		}
+	}
+	h := &Handler{cfg: &config.Config{ExpiryReport: config.ExpiryReportConfig{FailureThreshold: 3}}, authManager: manager}
+
+	for _, tc := range []struct {
+		query string
+		code  int
+		count int
+	}{
+		{query: "", code: http.StatusOK, count: 0},
+		{query: "?threshold=2", code: http.StatusOK, count: 1},
+		{query: "?days=abc", code: http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		c.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-expiry-report"+tc.query, nil)
+		h.GetAuthExpiryReport(c)
+		if rec.Code != tc.code {
+			t.Fatalf("%q: status = %d, want %d: %s", tc.query, rec.Code, tc.code, rec.Body.String())
+		}
+		if tc.code != http.StatusOK {
+			continue
+		}
+		var report authexpiry.Report
+		if errDecode := json.Unmarshal(rec.Body.Bytes(), &report); errDecode != nil {
+			t.Fatalf("decode report: %v", errDecode)
+		}
+		if len(report.Entries) != tc.count {
+			t.Fatalf("%q: entries = %+v, want %d", tc.query, report.Entries, tc.count)
+		}
+	}
+}
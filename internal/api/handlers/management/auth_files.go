@@ -327,6 +327,11 @@ func (h *Handler) ListAuthFiles(c *gin.Context) {
 		h.listAuthFilesFromDisk(c)
 		return
 	}
+	query, errQuery := parseListQuery(c, "name", authFileSortFields...)
+	if errQuery != nil {
+		c.JSON(400, gin.H{"error": errQuery.Error()})
+		return
+	}
 	nameFilter := strings.TrimSpace(c.Query("name"))
 	authIndexFilter := strings.TrimSpace(c.Query("auth_index"))
 	auths := h.authManager.List()
@@ -339,12 +344,23 @@ func (h *Handler) ListAuthFiles(c *gin.Context) {
 			files = append(files, entry)
 		}
 	}
-	sort.Slice(files, func(i, j int) bool {
-		nameI, _ := files[i]["name"].(string)
-		nameJ, _ := files[j]["name"].(string)
-		return strings.ToLower(nameI) < strings.ToLower(nameJ)
-	})
-	c.JSON(200, gin.H{"files": files})
+	page, total, next := query.paginate(files, "id")
+	writeAuthFilesPage(c, page, total, next)
+}
+
+// authFileSortFields lists the auth file entry fields accepted by the sort parameter.
+var authFileSortFields = []string{
+	"name", "provider", "type", "status", "label", "email", "priority",
+	"created_at", "updated_at", "modtime", "last_refresh", "next_retry_after",
+	"success", "failed",
+}
+
+func writeAuthFilesPage(c *gin.Context, files []gin.H, total int, next string) {
+	body := gin.H{"files": files, "total": total}
+	if next != "" {
+		body["next_cursor"] = next
+	}
+	c.JSON(200, body)
 }
 
 func lockedAuthIndex(auth *coreauth.Auth) string {
@@ -507,6 +523,11 @@ func (h *Handler) GetAuthFileModels(c *gin.Context) {
 
 // List auth files from disk when the auth manager is unavailable.
 func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
+	query, errQuery := parseListQuery(c, "name", authFileSortFields...)
+	if errQuery != nil {
+		c.JSON(400, gin.H{"error": errQuery.Error()})
+		return
+	}
 	nameFilter := strings.TrimSpace(c.Query("name"))
 	authIndexFilter := strings.TrimSpace(c.Query("auth_index"))
 	entries, err := os.ReadDir(h.cfg.AuthDir)
@@ -516,7 +537,7 @@ func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 	}
 	files := make([]gin.H, 0)
 	if authIndexFilter != "" {
-		c.JSON(200, gin.H{"files": files})
+		writeAuthFilesPage(c, files, 0, "")
 		return
 	}
 	type antigravityDiskEntry struct {
@@ -560,6 +581,11 @@ func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 						}
 					}
 				}
+				if tv := gjson.GetBytes(data, "tags"); tv.Exists() {
+					if tags := parseAuthTags(tv.Value()); len(tags) > 0 {
+						fileData["tags"] = tags
+					}
+				}
 				if nv := gjson.GetBytes(data, "note"); nv.Exists() && nv.Type == gjson.String {
 					if trimmed := strings.TrimSpace(nv.String()); trimmed != "" {
 						fileData["note"] = trimmed
@@ -650,7 +676,8 @@ func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 			files[idx]["primary_info"] = pi
 		}
 	}
-	c.JSON(200, gin.H{"files": files})
+	page, total, next := query.paginate(files, "name")
+	writeAuthFilesPage(c, page, total, next)
 }
 
 func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
@@ -720,6 +747,21 @@ func (h *Handler) buildAuthFileEntryLocked(auth *coreauth.Auth) gin.H {
 	}
 	if !auth.NextRetryAfter.IsZero() {
 		entry["next_retry_after"] = auth.NextRetryAfter
+		if remaining := time.Until(auth.NextRetryAfter); remaining > 0 {
+			entry["cooldown_remaining_seconds"] = int64(remaining.Seconds())
+		}
+	}
+	if !auth.NextRefreshAfter.IsZero() {
+		entry["next_refresh_after"] = auth.NextRefreshAfter
+	}
+	if auth.Quota.Exceeded || auth.Quota.BackoffLevel > 0 {
+		entry["quota"] = auth.Quota
+	}
+	if auth.LastError != nil {
+		entry["last_error"] = auth.LastError
+	}
+	if len(auth.ModelStates) > 0 {
+		entry["model_states"] = auth.ModelStates
 	}
 	if path != "" {
 		entry["path"] = path
@@ -771,6 +813,24 @@ func (h *Handler) buildAuthFileEntryLocked(auth *coreauth.Auth) gin.H {
 			}
 		}
 	}
+	// Expose tags from Attributes (comma-separated) or Metadata (string or array).
+	if tags := parseAuthTags(authAttribute(auth, "tags")); len(tags) > 0 {
+		entry["tags"] = tags
+	} else if auth.Metadata != nil {
+		if tags := parseAuthTags(auth.Metadata["tags"]); len(tags) > 0 {
+			entry["tags"] = tags
+		}
+	}
+	if len(auth.Groups) > 0 {
+		entry["groups"] = append([]string(nil), auth.Groups...)
+	}
+	if auth.Availability != nil {
+		entry["availability"] = auth.Availability.Specs()
+		if timezone := auth.Availability.Timezone(); timezone != "" {
+			entry["availability_timezone"] = timezone
+		}
+		entry["outside_availability_window"] = !auth.Availability.Allows(time.Now())
+	}
 	if websockets, ok := authWebsocketsValue(auth); ok {
 		entry["websockets"] = websockets
 	}
@@ -1207,7 +1267,12 @@ func (h *Handler) writeAuthFile(ctx context.Context, name string, data []byte) e
 			}
 		}
 	}
-	if errWrite := os.WriteFile(dst, data, 0o600); errWrite != nil {
+	if encrypting, ok := h.tokenStoreWithBaseDir().(*coreauth.EncryptingStore); ok && auth.Metadata != nil {
+		// Encrypted auth directories must never hold the uploaded plaintext.
+		if _, errSave := encrypting.Save(ctx, auth); errSave != nil {
+			return fmt.Errorf("failed to write file: %w", errSave)
+		}
+	} else if errWrite := os.WriteFile(dst, data, 0o600); errWrite != nil {
 		return fmt.Errorf("failed to write file: %w", errWrite)
 	}
 	if err := h.upsertAuthRecord(ctx, auth); err != nil {
@@ -1509,6 +1574,7 @@ func (h *Handler) PatchAuthFileStatus(c *gin.Context) {
 		Name      string `json:"name"`
 		AuthIndex string `json:"auth_index"`
 		Disabled  *bool  `json:"disabled"`
+		Reason    string `json:"reason"`
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
@@ -1583,9 +1649,19 @@ func (h *Handler) PatchAuthFileStatus(c *gin.Context) {
 		return
 	}
 
-	applyAuthDisabledState(targetAuth, *req.Disabled)
-	if _, err := h.authManager.Update(ctx, targetAuth); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to update auth: %v", err)})
+	var errUpdate error
+	if *req.Disabled {
+		reason := strings.TrimSpace(req.Reason)
+		if reason == "" {
+			reason = "disabled via management API"
+		}
+		_, errUpdate = h.authManager.Disable(ctx, targetAuth.ID, reason)
+	} else {
+		// Enable keeps any cooldown or quota state the auth still carries.
+		_, errUpdate = h.authManager.Enable(ctx, targetAuth.ID)
+	}
+	if errUpdate != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to update auth: %v", errUpdate)})
 		return
 	}
 
@@ -1763,6 +1839,12 @@ func (h *Handler) PatchAuthFileFields(c *gin.Context) {
 		changed = true
 	}
 	if changed {
+		if touchesAuthFileAvailability(touchedRoots) {
+			if _, errAvailability := coreauth.AvailabilityFromMetadata(targetAuth.Metadata); errAvailability != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": errAvailability.Error()})
+				return
+			}
+		}
 		syncAuthFileMetadataFields(targetAuth, touchedRoots)
 	}
 
@@ -1911,6 +1993,14 @@ func syncAuthFileMetadataFields(auth *coreauth.Auth, touchedRoots map[string]str
 	if _, ok := touchedRoots["note"]; ok {
 		syncAuthFileNoteAttribute(auth)
 	}
+	if _, ok := touchedRoots["groups"]; ok {
+		auth.Groups = coreauth.ParseGroups(auth.Metadata["groups"])
+	}
+	if touchesAuthFileAvailability(touchedRoots) {
+		if availability, errAvailability := coreauth.AvailabilityFromMetadata(auth.Metadata); errAvailability == nil {
+			auth.Availability = availability
+		}
+	}
 	if _, ok := touchedRoots["websockets"]; ok {
 		syncAuthFileWebsocketsAttribute(auth)
 	}
@@ -1925,6 +2015,12 @@ func syncAuthFileMetadataFields(auth *coreauth.Auth, touchedRoots map[string]str
 	}
 }
 
+func touchesAuthFileAvailability(touchedRoots map[string]struct{}) bool {
+	_, windows := touchedRoots["availability"]
+	_, timezone := touchedRoots["availability_timezone"]
+	return windows || timezone
+}
+
 func syncAuthFileHeaderAttributes(auth *coreauth.Auth) {
 	if auth == nil {
 		return
@@ -6,10 +6,14 @@ import (
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v7/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 )
 
@@ -67,3 +71,60 @@ func TestUploadAuthFile_PreservesPriorityAttributes(t *testing.T) {
 		t.Fatalf("priority metadata = %#v, want 98", got)
 	}
 }
+
+func TestUploadAuthFile_EncryptsThroughEncryptingStore(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+	gin.SetMode(gin.TestMode)
+
+	metadataCipher, errCipher := coreauth.NewMetadataCipher("upload passphrase")
+	if errCipher != nil {
+		t.Fatalf("NewMetadataCipher: %v", errCipher)
+	}
+	coreauth.RegisterMetadataCipher(metadataCipher)
+	t.Cleanup(func() { coreauth.RegisterMetadataCipher(nil) })
+	store, errStore := coreauth.NewEncryptingStore(sdkAuth.NewFileTokenStore(), metadataCipher)
+	if errStore != nil {
+		t.Fatalf("NewEncryptingStore: %v", errStore)
+	}
+
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(nil, nil, nil)
+	h := NewHandlerWithoutConfigFilePath(&config.Config{AuthDir: authDir}, manager)
+	h.tokenStore = store
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "codex-upload.json")
+	if err != nil {
+		t.Fatalf("failed to create multipart file: %v", err)
+	}
+	if _, err = part.Write([]byte(`{"type":"codex","email":"upload@example.com","access_token":"plain-secret"}`)); err != nil {
+		t.Fatalf("failed to write multipart content: %v", err)
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatalf("failed to close multipart writer: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	req := httptest.NewRequest(http.MethodPost, "/v0/management/auth-files", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	ctx.Request = req
+
+	h.UploadAuthFile(ctx)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected upload status %d, got %d with body %s", http.StatusOK, rec.Code, rec.Body.String())
+	}
+	raw, errRead := os.ReadFile(filepath.Join(authDir, "codex-upload.json"))
+	if errRead != nil {
+		t.Fatalf("read uploaded file: %v", errRead)
+	}
+	if strings.Contains(string(raw), "plain-secret") || !strings.Contains(string(raw), coreauth.EncryptedMetadataKey) {
+		t.Fatalf("uploaded auth file should be encrypted on disk, got %s", raw)
+	}
+	auth, ok := manager.GetByID("codex-upload.json")
+	if !ok || auth.Metadata["access_token"] != "plain-secret" {
+		t.Fatalf("registered auth should carry the decrypted metadata, got %+v", auth)
+	}
+}
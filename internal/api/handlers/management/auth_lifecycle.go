@@ -0,0 +1,80 @@
+package management
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// authFileLifecycleRequest identifies an auth file by name, optionally narrowed by auth index.
+type authFileLifecycleRequest struct {
+	Name      string `json:"name"`
+	AuthIndex string `json:"auth_index"`
+	Model     string `json:"model"`
+}
+
+// RefreshAuthFile forces an immediate credential refresh.
+func (h *Handler) RefreshAuthFile(c *gin.Context) {
+	_, target, ok := h.bindAuthFileLifecycleTarget(c)
+	if !ok {
+		return
+	}
+	updated, errRefresh := h.authManager.ForceRefresh(c.Request.Context(), target.ID)
+	if errRefresh != nil {
+		if errors.Is(errRefresh, coreauth.ErrAuthNotFound) {
+			writeAuthLifecycleError(c, "refresh auth", errRefresh)
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("failed to refresh auth: %v", errRefresh)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "file": h.buildAuthFileEntry(updated)})
+}
+
+// ClearAuthFileCooldown lifts cooldown state for an auth file, optionally for a single model.
+func (h *Handler) ClearAuthFileCooldown(c *gin.Context) {
+	req, target, ok := h.bindAuthFileLifecycleTarget(c)
+	if !ok {
+		return
+	}
+	updated, errClear := h.authManager.ClearCooldown(c.Request.Context(), target.ID, req.Model)
+	if errClear != nil {
+		writeAuthLifecycleError(c, "clear cooldown", errClear)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "file": h.buildAuthFileEntry(updated)})
+}
+
+func (h *Handler) bindAuthFileLifecycleTarget(c *gin.Context) (authFileLifecycleRequest, *coreauth.Auth, bool) {
+	var req authFileLifecycleRequest
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return req, nil, false
+	}
+	if errBind := c.ShouldBindJSON(&req); errBind != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return req, nil, false
+	}
+	if strings.TrimSpace(req.Name) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+		return req, nil, false
+	}
+	target, found := h.lookupAuthFile(req.Name, req.AuthIndex)
+	if !found || target == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+		return req, nil, false
+	}
+	return req, target, true
+}
+
+func writeAuthLifecycleError(c *gin.Context, action string, err error) {
+	if errors.Is(err, coreauth.ErrAuthNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth file not found"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to %s: %v", action, err)})
+}
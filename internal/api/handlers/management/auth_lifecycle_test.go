@@ -0,0 +1,86 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestAuthFileLifecycleEndpoints(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+
+	authDir := t.TempDir()
+	authPath := filepath.Join(authDir, "lifecycle-auth")
+	if errWrite := os.WriteFile(authPath, []byte(`{"type":"claude"}`), 0o600); errWrite != nil {
+		t.Fatalf("failed to write auth file: %v", errWrite)
+	}
+	manager := coreauth.NewManager(nil, nil, nil)
+	next := time.Now().Add(time.Hour)
+	auth := &coreauth.Auth{
+		ID:             "lifecycle-auth",
+		Provider:       "claude",
+		Attributes:     map[string]string{"path": authPath},
+		Status:         coreauth.StatusError,
+		Unavailable:    true,
+		NextRetryAfter: next,
+		ModelStates: map[string]*coreauth.ModelState{
+			"claude-lifecycle": {Status: coreauth.StatusError, Unavailable: true, NextRetryAfter: next},
+		},
+	}
+	if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("failed to register auth record: %v", errRegister)
+	}
+	h := NewHandlerWithoutConfigFilePath(&config.Config{AuthDir: authDir}, manager)
+
+	call := func(handler gin.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(rec)
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		ctx.Request = req
+		handler(ctx)
+		return rec
+	}
+
+	rec := call(h.ListAuthFiles, http.MethodGet, "/v0/management/auth-files?provider=claude&limit=10", "")
+	var listing struct {
+		Files []map[string]any `json:"files"`
+	}
+	if errDecode := json.Unmarshal(rec.Body.Bytes(), &listing); errDecode != nil || rec.Code != http.StatusOK {
+		t.Fatalf("list = %d %s (%v)", rec.Code, rec.Body.String(), errDecode)
+	}
+	if len(listing.Files) != 1 || listing.Files[0]["model_states"] == nil || listing.Files[0]["cooldown_remaining_seconds"] == nil {
+		t.Fatalf("list auth files = %+v, want live state", listing.Files)
+	}
+
+	if rec = call(h.PatchAuthFileStatus, http.MethodPatch, "/v0/management/auth-files/status", `{"name":"lifecycle-auth","disabled":true}`); rec.Code != http.StatusOK {
+		t.Fatalf("disable = %d %s", rec.Code, rec.Body.String())
+	}
+	if rec = call(h.PatchAuthFileStatus, http.MethodPatch, "/v0/management/auth-files/status", `{"name":"lifecycle-auth","disabled":false}`); rec.Code != http.StatusOK {
+		t.Fatalf("enable = %d %s", rec.Code, rec.Body.String())
+	}
+	if updated, _ := manager.GetByID("lifecycle-auth"); updated.Disabled || updated.Status != coreauth.StatusError || !updated.Unavailable {
+		t.Fatalf("auth after re-enable = %+v, want its cooldown kept", updated)
+	}
+
+	if rec = call(h.ClearAuthFileCooldown, http.MethodPost, "/v0/management/auth-files/clear-cooldown", `{"name":"lifecycle-auth"}`); rec.Code != http.StatusOK {
+		t.Fatalf("clear cooldown = %d %s", rec.Code, rec.Body.String())
+	}
+	if updated, _ := manager.GetByID("lifecycle-auth"); updated.Unavailable || !updated.NextRetryAfter.IsZero() {
+		t.Fatalf("auth after clear = %+v, want available", updated)
+	}
+
+	if rec = call(h.RefreshAuthFile, http.MethodPost, "/v0/management/auth-files/refresh", `{"name":"unknown"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("refresh unknown = %d, want 404", rec.Code)
+	}
+}
@@ -336,6 +336,8 @@ func normalizeRoutingStrategy(strategy string) (string, bool) {
 		return "fill-first", true
 	case "weight-robin", "weightrobin", "wr":
 		return "weight-robin", true
+	case "cost", "cost-aware", "cheapest":
+		return "cost", true
 	default:
 		return "", false
 	}
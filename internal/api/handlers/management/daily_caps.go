@@ -0,0 +1,48 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/dailycap"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// GetDailyCaps returns the current quota day usage of every credential with a daily cap.
+func (h *Handler) GetDailyCaps(c *gin.Context) {
+	enabled := false
+	if h != nil && h.cfg != nil {
+		enabled = h.cfg.DailyCaps.Enabled
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": enabled, "caps": dailycap.Default().Statuses()})
+}
+
+type dailyCapResetRequest struct {
+	AuthID string `json:"auth_id"`
+}
+
+// ResetDailyCap clears a credential's daily usage and lifts its bench before the reset.
+func (h *Handler) ResetDailyCap(c *gin.Context) {
+	var req dailyCapResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	id := strings.TrimSpace(req.AuthID)
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing auth_id"})
+		return
+	}
+	err := dailycap.Default().Reset(c.Request.Context(), id)
+	switch {
+	case errors.Is(err, coreauth.ErrAuthNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true, "auth_id": id})
+}
@@ -5,7 +5,9 @@ package management
 import (
 	"context"
 	"crypto/subtle"
+	"encoding/base64"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -148,8 +150,7 @@ func (h *Handler) SetConfig(cfg *config.Config) {
 			fallbackMaxDepth = cfg.Routing.FallbackMaxDepth
 		}
 		manager.SetOAuthModelAlias(aliases)
-		manager.SetFallbackModels(fallbackModels)
-		manager.SetFallbackChain(fallbackChain, fallbackMaxDepth)
+		manager.SetFallbackConfig(fallbackModels, fallbackChain, fallbackMaxDepth)
 	}
 }
 
@@ -174,8 +175,7 @@ func (h *Handler) SetAuthManager(manager *coreauth.Manager) {
 			fallbackMaxDepth = cfg.Routing.FallbackMaxDepth
 		}
 		manager.SetOAuthModelAlias(aliases)
-		manager.SetFallbackModels(fallbackModels)
-		manager.SetFallbackChain(fallbackChain, fallbackMaxDepth)
+		manager.SetFallbackConfig(fallbackModels, fallbackChain, fallbackMaxDepth)
 	}
 }
 
@@ -316,7 +316,9 @@ func (h *Handler) SetPostAuthPersistHook(hook coreauth.PostAuthHook) {
 
 // Middleware enforces access control for management endpoints.
 // All requests (local and remote) require a valid management key.
-// Additionally, remote access requires allow-remote-management=true.
+// Additionally, remote access requires allow-remote-management=true and, when configured,
+// an address inside remote-management.allowed-cidrs. Basic credentials from
+// remote-management.basic-auth are accepted in place of the key.
 func (h *Handler) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Header("X-CPA-VERSION", buildinfo.Version)
@@ -324,7 +326,8 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 		c.Header("X-CPA-BUILD-DATE", buildinfo.BuildDate)
 		c.Header("X-CPA-SUPPORT-PLUGIN", pluginhost.SupportPluginHeaderValue())
 
-		clientIP := c.ClientIP()
+		// RemoteIP ignores X-Forwarded-For/X-Real-IP so the allowlist cannot be spoofed.
+		clientIP := c.RemoteIP()
 		localClient := clientIP == "127.0.0.1" || clientIP == "::1"
 
 		// Accept either Authorization: Bearer <key> or X-Management-Key
@@ -399,6 +402,9 @@ func (h *Handler) AuthenticateManagementKey(clientIP string, localClient bool, p
 	if !localClient && !allowRemote {
 		return false, http.StatusForbidden, "remote management disabled"
 	}
+	if !localClient && cfg != nil && !managementClientAllowed(cfg.RemoteManagement.AllowedCIDRs, clientIP) {
+		return false, http.StatusForbidden, "client address not allowed"
+	}
 
 	fail := func() {
 		h.attemptsMu.Lock()
@@ -425,7 +431,17 @@ func (h *Handler) AuthenticateManagementKey(clientIP string, localClient bool, p
 		h.attemptsMu.Unlock()
 	}
 
-	if secretHash == "" && envSecret == "" {
+	basicConfigured := cfg != nil && cfg.RemoteManagement.BasicAuth.Username != ""
+	if username, password, isBasic := parseBasicCredentials(provided); isBasic && basicConfigured {
+		if !basicCredentialsMatch(cfg.RemoteManagement.BasicAuth, username, password) {
+			fail()
+			return false, http.StatusUnauthorized, "invalid management credentials"
+		}
+		reset()
+		return true, 0, ""
+	}
+
+	if secretHash == "" && envSecret == "" && !basicConfigured {
 		return false, http.StatusForbidden, "remote management key not set"
 	}
 
@@ -458,6 +474,55 @@ func (h *Handler) AuthenticateManagementKey(clientIP string, localClient bool, p
 	return true, 0, ""
 }
 
+// managementClientAllowed reports whether clientIP is inside the allowlist. An empty
+// allowlist allows every client; unparsable entries are ignored.
+func managementClientAllowed(allowed []string, clientIP string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	ip := net.ParseIP(strings.TrimSpace(clientIP))
+	if ip == nil {
+		return false
+	}
+	for _, entry := range allowed {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			if allowedIP := net.ParseIP(entry); allowedIP != nil && allowedIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+		if _, network, errParse := net.ParseCIDR(entry); errParse == nil && network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBasicCredentials decodes an "Authorization: Basic" header value.
+func parseBasicCredentials(header string) (string, string, bool) {
+	scheme, encoded, found := strings.Cut(strings.TrimSpace(header), " ")
+	if !found || !strings.EqualFold(scheme, "basic") {
+		return "", "", false
+	}
+	decoded, errDecode := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if errDecode != nil {
+		return "", "", false
+	}
+	username, password, found := strings.Cut(string(decoded), ":")
+	return username, password, found
+}
+
+func basicCredentialsMatch(expected config.ManagementBasicAuth, username, password string) bool {
+	if subtle.ConstantTimeCompare([]byte(username), []byte(expected.Username)) != 1 {
+		return false
+	}
+	if strings.HasPrefix(expected.Password, "$2") {
+		return bcrypt.CompareHashAndPassword([]byte(expected.Password), []byte(password)) == nil
+	}
+	return expected.Password != "" && subtle.ConstantTimeCompare([]byte(password), []byte(expected.Password)) == 1
+}
+
 // persist saves the current in-memory config to disk.
 func (h *Handler) persist(c *gin.Context) bool {
 	h.mu.Lock()
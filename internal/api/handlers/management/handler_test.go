@@ -1,6 +1,7 @@
 package management
 
 import (
+	"encoding/base64"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -40,6 +41,82 @@ func TestAuthenticateManagementKey_LocalhostIPBan_BlocksCorrectKeyDuringBan(t *t
 	}
 }
 
+func TestAuthenticateManagementKey_AllowedCIDRsAndBasicAuth(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.RemoteManagement.AllowRemote = true
+	cfg.RemoteManagement.AllowedCIDRs = []string{"10.0.0.0/8", "192.0.2.7"}
+	cfg.RemoteManagement.BasicAuth = config.ManagementBasicAuth{Username: "admin", Password: "hunter2"}
+	h := &Handler{cfg: cfg, failedAttempts: make(map[string]*attemptInfo), envSecret: "test-secret"}
+	basic := func(user, pass string) string {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(user+":"+pass))
+	}
+
+	cases := []struct {
+		name     string
+		clientIP string
+		local    bool
+		provided string
+		status   int
+	}{
+		{name: "key inside range", clientIP: "10.1.2.3", provided: "test-secret"},
+		{name: "single address", clientIP: "192.0.2.7", provided: "test-secret"},
+		{name: "outside range", clientIP: "198.51.100.1", provided: "test-secret", status: http.StatusForbidden},
+		{name: "loopback bypasses allowlist", clientIP: "127.0.0.1", local: true, provided: "test-secret"},
+		{name: "basic credentials", clientIP: "10.1.2.3", provided: basic("admin", "hunter2")},
+		{name: "wrong basic password", clientIP: "10.9.9.9", provided: basic("admin", "nope"), status: http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			allowed, status, _ := h.AuthenticateManagementKey(tc.clientIP, tc.local, tc.provided)
+			if tc.status == 0 && !allowed {
+				t.Fatalf("denied with status %d, want allowed", status)
+			}
+			if tc.status != 0 && (allowed || status != tc.status) {
+				t.Fatalf("allowed=%t status=%d, want status %d", allowed, status, tc.status)
+			}
+		})
+	}
+}
+
+func TestAuthenticateManagementKey_BasicAuthWithoutSecretKey(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.RemoteManagement.AllowRemote = true
+	cfg.RemoteManagement.BasicAuth = config.ManagementBasicAuth{Username: "admin", Password: "hunter2"}
+	h := &Handler{cfg: cfg, failedAttempts: make(map[string]*attemptInfo)}
+
+	provided := "Basic " + base64.StdEncoding.EncodeToString([]byte("admin:hunter2"))
+	if allowed, status, errMsg := h.AuthenticateManagementKey("10.1.2.3", false, provided); !allowed {
+		t.Fatalf("basic auth denied: status=%d msg=%q", status, errMsg)
+	}
+	if allowed, status, _ := h.AuthenticateManagementKey("10.1.2.3", false, "some-key"); allowed || status != http.StatusUnauthorized {
+		t.Fatalf("bearer key allowed=%t status=%d, want 401", allowed, status)
+	}
+}
+
+func TestMiddlewareIgnoresForwardedForInAllowlist(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.RemoteManagement.AllowRemote = true
+	cfg.RemoteManagement.AllowedCIDRs = []string{"10.0.0.0/8"}
+	h := &Handler{cfg: cfg, failedAttempts: make(map[string]*attemptInfo), envSecret: "test-secret"}
+
+	engine := gin.New()
+	engine.GET("/v0/management/config", h.Middleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/v0/management/config", nil)
+	req.RemoteAddr = "198.51.100.1:12345"
+	req.Header.Set("X-Forwarded-For", "10.1.2.3")
+	req.Header.Set("X-Real-IP", "10.1.2.3")
+	req.Header.Set("X-Management-Key", "test-secret")
+	engine.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+}
+
 func TestMiddlewareSetsSupportPluginHeader(t *testing.T) {
 
 	h := &Handler{
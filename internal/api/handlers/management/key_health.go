@@ -0,0 +1,202 @@
+package management
+
+import (
+	_ "embed"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+//go:embed key_health.html
+var keyHealthDashboardHTML []byte
+
+// keyHealthModel is the per-model view shown by the key health dashboard.
+type keyHealthModel struct {
+	Model           string          `json:"model"`
+	Status          coreauth.Status `json:"status"`
+	StatusMessage   string          `json:"status_message,omitempty"`
+	Unavailable     bool            `json:"unavailable"`
+	CooldownUntil   *time.Time      `json:"cooldown_until,omitempty"`
+	CooldownSeconds int64           `json:"cooldown_remaining_seconds,omitempty"`
+	QuotaExceeded   bool            `json:"quota_exceeded,omitempty"`
+	LastError       string          `json:"last_error,omitempty"`
+	LastErrorStatus int             `json:"last_error_status,omitempty"`
+	UpdatedAt       *time.Time      `json:"updated_at,omitempty"`
+}
+
+// keyHealthAuth is the per-auth view shown by the key health dashboard.
+type keyHealthAuth struct {
+	ID              string                   `json:"id"`
+	AuthIndex       string                   `json:"auth_index"`
+	Provider        string                   `json:"provider"`
+	Label           string                   `json:"label,omitempty"`
+	Health          string                   `json:"health"`
+	Status          coreauth.Status          `json:"status"`
+	StatusMessage   string                   `json:"status_message,omitempty"`
+	Disabled        bool                     `json:"disabled"`
+	CooldownUntil   *time.Time               `json:"cooldown_until,omitempty"`
+	CooldownSeconds int64                    `json:"cooldown_remaining_seconds,omitempty"`
+	LastError       string                   `json:"last_error,omitempty"`
+	LastErrorStatus int                      `json:"last_error_status,omitempty"`
+	Success         int64                    `json:"success"`
+	Failed          int64                    `json:"failed"`
+	UsageToday      *coreusage.CounterValues `json:"usage_today,omitempty"`
+	Availability    []string                 `json:"availability,omitempty"`
+	OpensAt         *time.Time               `json:"opens_at,omitempty"`
+	OpensInSeconds  int64                    `json:"opens_in_seconds,omitempty"`
+	Models          []keyHealthModel         `json:"models"`
+}
+
+// Key health classifications, from best to worst.
+const (
+	keyHealthHealthy       = "healthy"
+	keyHealthDegraded      = "degraded"
+	keyHealthCooling       = "cooling_down"
+	keyHealthOutsideWindow = "outside_window"
+	keyHealthError         = "error"
+	keyHealthDisabled      = "disabled"
+)
+
+// GetKeyHealth returns the JSON status consumed by the key health dashboard: each
+// auth's provider, status, per-model cooldown timers, latest errors and today's usage.
+func (h *Handler) GetKeyHealth(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	now := time.Now()
+	counters := coreusage.DefaultManager().Counters()
+	today := now.UTC().Format("2006-01-02")
+
+	auths := h.authManager.List()
+	entries := make([]keyHealthAuth, 0, len(auths))
+	summary := map[string]int{}
+	for _, auth := range auths {
+		if auth == nil {
+			continue
+		}
+		entry := buildKeyHealthAuth(auth, now)
+		if counters != nil {
+			totals := counters.Totals(coreusage.CounterFilter{AuthID: auth.ID, From: today, To: today})
+			entry.UsageToday = &totals
+		}
+		summary[entry.Health]++
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Provider != entries[j].Provider {
+			return entries[i].Provider < entries[j].Provider
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	c.JSON(http.StatusOK, gin.H{
+		"generated_at":     now.UTC(),
+		"total":            len(entries),
+		"summary":          summary,
+		"counters_enabled": counters != nil,
+		"auths":            entries,
+	})
+}
+
+func buildKeyHealthAuth(auth *coreauth.Auth, now time.Time) keyHealthAuth {
+	auth.EnsureIndex()
+	entry := keyHealthAuth{
+		ID:            auth.ID,
+		AuthIndex:     auth.Index,
+		Provider:      strings.TrimSpace(auth.Provider),
+		Label:         auth.Label,
+		Status:        auth.Status,
+		StatusMessage: auth.StatusMessage,
+		Disabled:      auth.Disabled,
+		Success:       auth.Success,
+		Failed:        auth.Failed,
+		Models:        make([]keyHealthModel, 0, len(auth.ModelStates)),
+	}
+	cooldownUntil := auth.NextRetryAfter
+	if auth.BenchedUntil.After(cooldownUntil) {
+		cooldownUntil = auth.BenchedUntil
+		if entry.StatusMessage == "" {
+			entry.StatusMessage = auth.BenchReason
+		}
+	}
+	entry.CooldownUntil, entry.CooldownSeconds = keyHealthCooldown(cooldownUntil, now)
+	if auth.LastError != nil {
+		entry.LastError = auth.LastError.Message
+		entry.LastErrorStatus = auth.LastError.HTTPStatus
+	}
+	outsideWindow := !auth.Availability.Allows(now)
+	if auth.Availability != nil {
+		entry.Availability = auth.Availability.Specs()
+		if outsideWindow {
+			entry.OpensAt, entry.OpensInSeconds = keyHealthCooldown(auth.Availability.NextOpen(now), now)
+		}
+	}
+
+	coolingModels := 0
+	for model, state := range auth.ModelStates {
+		if state == nil {
+			continue
+		}
+		view := keyHealthModel{
+			Model:         model,
+			Status:        state.Status,
+			StatusMessage: state.StatusMessage,
+			Unavailable:   state.Unavailable,
+			QuotaExceeded: state.Quota.Exceeded,
+		}
+		view.CooldownUntil, view.CooldownSeconds = keyHealthCooldown(state.NextRetryAfter, now)
+		if state.LastError != nil {
+			view.LastError = state.LastError.Message
+			view.LastErrorStatus = state.LastError.HTTPStatus
+		}
+		if !state.UpdatedAt.IsZero() {
+			updated := state.UpdatedAt.UTC()
+			view.UpdatedAt = &updated
+		}
+		if view.CooldownSeconds > 0 {
+			coolingModels++
+		}
+		entry.Models = append(entry.Models, view)
+	}
+	sort.Slice(entry.Models, func(i, j int) bool { return entry.Models[i].Model < entry.Models[j].Model })
+
+	switch {
+	case auth.Disabled || auth.Status == coreauth.StatusDisabled:
+		entry.Health = keyHealthDisabled
+	case outsideWindow:
+		entry.Health = keyHealthOutsideWindow
+	case auth.Status == coreauth.StatusError && entry.CooldownSeconds == 0:
+		entry.Health = keyHealthError
+	case entry.CooldownSeconds > 0 || (coolingModels > 0 && coolingModels == len(entry.Models)):
+		entry.Health = keyHealthCooling
+	case coolingModels > 0:
+		entry.Health = keyHealthDegraded
+	default:
+		entry.Health = keyHealthHealthy
+	}
+	return entry
+}
+
+func keyHealthCooldown(until, now time.Time) (*time.Time, int64) {
+	if until.IsZero() || !until.After(now) {
+		return nil, 0
+	}
+	utc := until.UTC()
+	seconds := int64(until.Sub(now).Seconds())
+	if seconds < 1 {
+		seconds = 1
+	}
+	return &utc, seconds
+}
+
+// ServeKeyHealthDashboard serves the embedded key health dashboard page. The page
+// itself holds no data; it polls GetKeyHealth with the operator's management key.
+func ServeKeyHealthDashboard(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", keyHealthDashboardHTML)
+}
@@ -0,0 +1,63 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestGetKeyHealthClassifiesAuths(t *testing.T) {
+	manager := coreauth.NewManager(nil, nil, nil)
+	next := time.Now().Add(10 * time.Minute)
+	auths := []*coreauth.Auth{
+		{ID: "a-healthy", Provider: "claude", Status: coreauth.StatusActive},
+		{ID: "b-degraded", Provider: "claude", Status: coreauth.StatusActive, ModelStates: map[string]*coreauth.ModelState{
+			"m1": {Status: coreauth.StatusError, Unavailable: true, NextRetryAfter: next, LastError: &coreauth.Error{Message: "rate limited", HTTPStatus: 429}},
+			"m2": {Status: coreauth.StatusActive},
+		}},
+		{ID: "c-disabled", Provider: "codex", Status: coreauth.StatusDisabled, Disabled: true},
+	}
+	for _, auth := range auths {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("Register(%s) error = %v", auth.ID, errRegister)
+		}
+	}
+	h := NewHandlerWithoutConfigFilePath(&config.Config{}, manager)
+
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/v0/management/key-health", nil)
+	h.GetKeyHealth(ctx)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", rec.Code, rec.Body.String())
+	}
+
+	var payload struct {
+		Summary map[string]int  `json:"summary"`
+		Auths   []keyHealthAuth `json:"auths"`
+	}
+	if errDecode := json.Unmarshal(rec.Body.Bytes(), &payload); errDecode != nil {
+		t.Fatalf("decode: %v", errDecode)
+	}
+	health := map[string]string{}
+	for _, entry := range payload.Auths {
+		health[entry.ID] = entry.Health
+	}
+	if health["a-healthy"] != keyHealthHealthy || health["b-degraded"] != keyHealthDegraded || health["c-disabled"] != keyHealthDisabled {
+		t.Fatalf("health = %v", health)
+	}
+	degraded := payload.Auths[1]
+	if len(degraded.Models) != 2 || degraded.Models[0].CooldownSeconds <= 0 || degraded.Models[0].LastErrorStatus != 429 {
+		t.Fatalf("degraded models = %+v", degraded.Models)
+	}
+	if payload.Summary[keyHealthHealthy] != 1 || payload.Summary[keyHealthDisabled] != 1 {
+		t.Fatalf("summary = %v", payload.Summary)
+	}
+}
@@ -0,0 +1,383 @@
+package management
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+const maxListPageLimit = 1000
+
+// listQuery holds the pagination, filter, and sort options shared by list endpoints.
+//
+// Supported query parameters:
+//   - limit: page size (1..1000); omitted returns every matching entry.
+//   - cursor: opaque next_cursor value from a previous page.
+//   - sort: entry field to sort by; order: "asc" (default) or "desc".
+//   - provider, status, tag, group: comma-separated filters (case-insensitive, any match).
+//   - q: case-insensitive substring match on name, email, label, and note.
+type listQuery struct {
+	limit     int
+	cursor    *listCursor
+	sortField string
+	desc      bool
+	providers map[string]struct{}
+	statuses  map[string]struct{}
+	tags      map[string]struct{}
+	groups    map[string]struct{}
+	search    string
+}
+
+// listCursor marks the last entry of a page. Resuming after the sort key and
+// identifier (rather than an offset) keeps pages stable while entries change.
+type listCursor struct {
+	Sort  string        `json:"s"`
+	Desc  bool          `json:"d,omitempty"`
+	Key   listSortValue `json:"k"`
+	ID    string        `json:"id"`
+	Query string        `json:"q,omitempty"`
+}
+
+// listSortValue is a comparable sort key; numeric keys order before text keys.
+type listSortValue struct {
+	Number  int64  `json:"n,omitempty"`
+	Text    string `json:"t,omitempty"`
+	Present bool   `json:"p,omitempty"`
+}
+
+func parseListQuery(c *gin.Context, defaultSort string, allowedSorts ...string) (listQuery, error) {
+	query := listQuery{
+		sortField: defaultSort,
+		providers: parseListFilter(c.Query("provider")),
+		statuses:  parseListFilter(c.Query("status")),
+		tags:      parseListFilter(c.Query("tag")),
+		groups:    parseListFilter(c.Query("group")),
+		search:    strings.ToLower(strings.TrimSpace(c.Query("q"))),
+	}
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		limit, errLimit := strconv.Atoi(raw)
+		if errLimit != nil || limit <= 0 {
+			return listQuery{}, errors.New("limit must be a positive integer")
+		}
+		if limit > maxListPageLimit {
+			limit = maxListPageLimit
+		}
+		query.limit = limit
+	}
+	if raw := strings.ToLower(strings.TrimSpace(c.Query("sort"))); raw != "" {
+		allowed := false
+		for _, field := range allowedSorts {
+			if raw == field {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return listQuery{}, fmt.Errorf("unsupported sort field %q", raw)
+		}
+		query.sortField = raw
+	}
+	switch strings.ToLower(strings.TrimSpace(c.Query("order"))) {
+	case "", "asc":
+	case "desc":
+		query.desc = true
+	default:
+		return listQuery{}, errors.New("order must be asc or desc")
+	}
+	if raw := strings.TrimSpace(c.Query("cursor")); raw != "" {
+		cursor, errCursor := decodeListCursor(raw)
+		if errCursor != nil {
+			return listQuery{}, errCursor
+		}
+		if cursor.Sort != query.sortField || cursor.Desc != query.desc || cursor.Query != query.filterSignature() {
+			return listQuery{}, errors.New("cursor does not match the requested sort or filters")
+		}
+		query.cursor = cursor
+	}
+	return query, nil
+}
+
+func parseListFilter(raw string) map[string]struct{} {
+	values := make(map[string]struct{})
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			values[part] = struct{}{}
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return values
+}
+
+// filterSignature binds cursors to the filters they were issued for.
+func (q listQuery) filterSignature() string {
+	join := func(set map[string]struct{}) string {
+		values := make([]string, 0, len(set))
+		for value := range set {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		return strings.Join(values, ",")
+	}
+	return strings.Join([]string{join(q.providers), join(q.statuses), join(q.tags), join(q.groups), q.search}, "|")
+}
+
+func encodeListCursor(cursor listCursor) string {
+	data, errMarshal := json.Marshal(cursor)
+	if errMarshal != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeListCursor(raw string) (*listCursor, error) {
+	data, errDecode := base64.RawURLEncoding.DecodeString(raw)
+	if errDecode != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var cursor listCursor
+	if errUnmarshal := json.Unmarshal(data, &cursor); errUnmarshal != nil || cursor.ID == "" {
+		return nil, errors.New("invalid cursor")
+	}
+	return &cursor, nil
+}
+
+// matches reports whether entry passes the provider, status, tag, group, and search filters.
+func (q listQuery) matches(entry gin.H) bool {
+	if len(q.providers) > 0 {
+		provider := strings.ToLower(listEntryString(entry, "provider"))
+		entryType := strings.ToLower(listEntryString(entry, "type"))
+		_, providerOK := q.providers[provider]
+		_, typeOK := q.providers[entryType]
+		if !providerOK && !typeOK {
+			return false
+		}
+	}
+	if len(q.statuses) > 0 {
+		matched := false
+		for _, status := range listEntryStatuses(entry) {
+			if _, ok := q.statuses[status]; ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(q.tags) > 0 {
+		matched := false
+		tags, _ := entry["tags"].([]string)
+		for _, tag := range tags {
+			if _, ok := q.tags[strings.ToLower(tag)]; ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(q.groups) > 0 {
+		matched := false
+		groups, _ := entry["groups"].([]string)
+		for _, group := range groups {
+			if _, ok := q.groups[strings.ToLower(group)]; ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if q.search != "" {
+		matched := false
+		for _, field := range []string{"name", "email", "label", "note", "key"} {
+			if strings.Contains(strings.ToLower(listEntryString(entry, field)), q.search) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// listEntryStatuses returns the lower-cased statuses an entry answers to.
+// Besides the lifecycle status, flags such as disabled and unavailable are exposed as statuses.
+func listEntryStatuses(entry gin.H) []string {
+	var statuses []string
+	if status := strings.ToLower(listEntryString(entry, "status")); status != "" {
+		statuses = append(statuses, status)
+	}
+	if disabled, _ := entry["disabled"].(bool); disabled {
+		statuses = append(statuses, "disabled")
+	}
+	if unavailable, _ := entry["unavailable"].(bool); unavailable {
+		statuses = append(statuses, "unavailable")
+	}
+	return statuses
+}
+
+func listEntryString(entry gin.H, field string) string {
+	switch value := entry[field].(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case coreauth.Status:
+		return strings.TrimSpace(string(value))
+	case fmt.Stringer:
+		return strings.TrimSpace(value.String())
+	default:
+		return ""
+	}
+}
+
+func listSortValueOf(value any) listSortValue {
+	switch v := value.(type) {
+	case nil:
+		return listSortValue{}
+	case string:
+		return listSortValue{Text: strings.ToLower(v), Present: v != ""}
+	case coreauth.Status:
+		return listSortValue{Text: strings.ToLower(string(v)), Present: v != ""}
+	case bool:
+		if v {
+			return listSortValue{Number: 1, Present: true}
+		}
+		return listSortValue{Present: true}
+	case int:
+		return listSortValue{Number: int64(v), Present: true}
+	case int64:
+		return listSortValue{Number: v, Present: true}
+	case float64:
+		return listSortValue{Number: int64(v), Present: true}
+	case time.Time:
+		if v.IsZero() {
+			return listSortValue{}
+		}
+		return listSortValue{Number: v.UnixNano(), Present: true}
+	default:
+		return listSortValue{Text: strings.ToLower(fmt.Sprint(v)), Present: true}
+	}
+}
+
+// compareListSortValues compares two sort keys; presence is handled by the caller.
+func compareListSortValues(a, b listSortValue) int {
+	if a.Number != b.Number {
+		if a.Number < b.Number {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a.Text, b.Text)
+}
+
+// paginate filters, sorts, and slices entries. idField names the unique, stable
+// identifier used as the sort tie-breaker and cursor anchor.
+// It returns the page, the total number of matching entries, and the next cursor ("" on the last page).
+func (q listQuery) paginate(entries []gin.H, idField string) ([]gin.H, int, string) {
+	type keyed struct {
+		entry gin.H
+		key   listSortValue
+		id    string
+	}
+	matched := make([]keyed, 0, len(entries))
+	for _, entry := range entries {
+		if entry == nil || !q.matches(entry) {
+			continue
+		}
+		matched = append(matched, keyed{
+			entry: entry,
+			key:   listSortValueOf(entry[q.sortField]),
+			id:    listEntryString(entry, idField),
+		})
+	}
+	less := func(a, b keyed) bool {
+		if a.key.Present != b.key.Present {
+			// Entries without the sort field always trail.
+			return a.key.Present
+		}
+		if cmp := compareListSortValues(a.key, b.key); cmp != 0 {
+			if q.desc {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return a.id < b.id
+	}
+	sort.SliceStable(matched, func(i, j int) bool { return less(matched[i], matched[j]) })
+
+	total := len(matched)
+	start := 0
+	if q.cursor != nil {
+		anchor := keyed{key: q.cursor.Key, id: q.cursor.ID}
+		start = sort.Search(len(matched), func(i int) bool { return less(anchor, matched[i]) })
+	}
+	end := len(matched)
+	if q.limit > 0 && start+q.limit < end {
+		end = start + q.limit
+	}
+
+	page := make([]gin.H, 0, end-start)
+	for _, item := range matched[start:end] {
+		page = append(page, item.entry)
+	}
+	next := ""
+	if end < len(matched) && end > start {
+		last := matched[end-1]
+		next = encodeListCursor(listCursor{
+			Sort:  q.sortField,
+			Desc:  q.desc,
+			Key:   last.key,
+			ID:    last.id,
+			Query: q.filterSignature(),
+		})
+	}
+	return page, total, next
+}
+
+// parseAuthTags normalizes tag values stored as a comma-separated string or a JSON array.
+func parseAuthTags(value any) []string {
+	var raw []string
+	switch v := value.(type) {
+	case string:
+		raw = strings.Split(v, ",")
+	case []string:
+		raw = v
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				raw = append(raw, s)
+			}
+		}
+	}
+	tags := make([]string, 0, len(raw))
+	seen := make(map[string]struct{}, len(raw))
+	for _, tag := range raw {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if _, dup := seen[strings.ToLower(tag)]; dup {
+			continue
+		}
+		seen[strings.ToLower(tag)] = struct{}{}
+		tags = append(tags, tag)
+	}
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
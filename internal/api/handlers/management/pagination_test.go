@@ -0,0 +1,110 @@
+package management
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+type authFilesPage struct {
+	Files      []map[string]any `json:"files"`
+	Total      int              `json:"total"`
+	NextCursor string           `json:"next_cursor"`
+}
+
+func listAuthFilesPage(t *testing.T, h *Handler, query url.Values) (int, authFilesPage) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/v0/management/auth-files?"+query.Encode(), nil)
+	h.ListAuthFiles(ctx)
+	var page authFilesPage
+	if rec.Code == http.StatusOK {
+		if errDecode := json.Unmarshal(rec.Body.Bytes(), &page); errDecode != nil {
+			t.Fatalf("decode response: %v", errDecode)
+		}
+	}
+	return rec.Code, page
+}
+
+func TestListAuthFilesPaginatesWithStableCursor(t *testing.T) {
+	t.Setenv("MANAGEMENT_PASSWORD", "")
+
+	authDir := t.TempDir()
+	manager := coreauth.NewManager(nil, nil, nil)
+	for i, provider := range []string{"codex", "claude", "codex", "gemini", "codex"} {
+		name := fmt.Sprintf("auth-%d.json", i)
+		path := filepath.Join(authDir, name)
+		if errWrite := os.WriteFile(path, []byte(`{"type":"`+provider+`"}`), 0o600); errWrite != nil {
+			t.Fatalf("failed to write auth file: %v", errWrite)
+		}
+		auth := &coreauth.Auth{
+			ID:         name,
+			FileName:   name,
+			Provider:   provider,
+			Status:     coreauth.StatusActive,
+			Attributes: map[string]string{"path": path},
+		}
+		if i == 4 {
+			auth.Attributes["tags"] = "team-a, prod"
+		}
+		if i == 1 {
+			auth.Groups = []string{"work"}
+		}
+		registerAuthForLookupTest(t, manager, auth)
+	}
+	h := NewHandlerWithoutConfigFilePath(&config.Config{AuthDir: authDir}, manager)
+
+	var names []string
+	query := url.Values{"provider": {"codex"}, "limit": {"2"}, "order": {"desc"}}
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatalf("pagination did not terminate")
+		}
+		code, page := listAuthFilesPage(t, h, query)
+		if code != http.StatusOK {
+			t.Fatalf("status = %d, want %d", code, http.StatusOK)
+		}
+		if page.Total != 3 {
+			t.Fatalf("total = %d, want 3", page.Total)
+		}
+		for _, file := range page.Files {
+			names = append(names, file["name"].(string))
+		}
+		if page.NextCursor == "" {
+			break
+		}
+		query.Set("cursor", page.NextCursor)
+	}
+	want := []string{"auth-4.json", "auth-2.json", "auth-0.json"}
+	if fmt.Sprint(names) != fmt.Sprint(want) {
+		t.Fatalf("names = %v, want %v", names, want)
+	}
+
+	// A cursor cannot be reused with different filters.
+	query.Set("provider", "claude")
+	if code, _ := listAuthFilesPage(t, h, query); code != http.StatusBadRequest {
+		t.Fatalf("mismatched cursor status = %d, want %d", code, http.StatusBadRequest)
+	}
+
+	_, tagged := listAuthFilesPage(t, h, url.Values{"tag": {"PROD"}})
+	if len(tagged.Files) != 1 || tagged.Files[0]["name"] != "auth-4.json" {
+		t.Fatalf("tag filter files = %#v, want only auth-4.json", tagged.Files)
+	}
+	_, grouped := listAuthFilesPage(t, h, url.Values{"group": {"Work"}})
+	if len(grouped.Files) != 1 || grouped.Files[0]["name"] != "auth-1.json" {
+		t.Fatalf("group filter files = %#v, want only auth-1.json", grouped.Files)
+	}
+	if code, _ := listAuthFilesPage(t, h, url.Values{"sort": {"secret"}}); code != http.StatusBadRequest {
+		t.Fatalf("unsupported sort status = %d, want %d", code, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,78 @@
+package management
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/keyimport"
+)
+
+// providerKeyProbe builds the validator used for imported keys; tests replace it.
+var providerKeyProbe = keyimport.Probe
+
+// ImportProviderKeys registers many provider API keys at once from a CSV or JSON manifest,
+// sent as the request body or as a multipart "file". Each row is probed with a models-list
+// call unless validate=false, and the response reports the outcome of every row.
+func (h *Handler) ImportProviderKeys(c *gin.Context) {
+	var data []byte
+	var errRead error
+	if strings.HasPrefix(c.ContentType(), "multipart/") {
+		fileHeader, errFile := c.FormFile("file")
+		if errFile != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "file required"})
+			return
+		}
+		file, errOpen := fileHeader.Open()
+		if errOpen != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read file"})
+			return
+		}
+		data, errRead = io.ReadAll(file)
+		_ = file.Close()
+	} else {
+		data, errRead = c.GetRawData()
+	}
+	if errRead != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read body"})
+		return
+	}
+	rows, errParse := keyimport.Parse(data)
+	if errParse != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid manifest", "message": errParse.Error()})
+		return
+	}
+
+	var validate keyimport.Validator
+	if raw := strings.TrimSpace(c.Query("validate")); raw == "" || raw == "1" || strings.EqualFold(raw, "true") {
+		h.mu.Lock()
+		validate = providerKeyProbe(h.cfg)
+		h.mu.Unlock()
+	} else if _, errBool := strconv.ParseBool(raw); errBool != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid validate value"})
+		return
+	}
+	results := keyimport.Validate(c.Request.Context(), rows, validate)
+
+	h.mu.Lock()
+	added := keyimport.Apply(h.cfg, rows, results)
+	if added == 0 {
+		h.mu.Unlock()
+		c.JSON(http.StatusOK, gin.H{"added": 0, "results": results})
+		return
+	}
+	h.cfg.SanitizeGeminiKeys()
+	h.cfg.SanitizeClaudeKeys()
+	h.cfg.SanitizeCodexKeys()
+	h.cfg.SanitizeXAIKeys()
+	h.cfg.SanitizeMistralKeys()
+	snapshot, ok := h.saveConfigAndSnapshotLocked(c)
+	h.mu.Unlock()
+	if !ok {
+		return
+	}
+	h.reloadConfigAfterManagementSaveAsync(c.Request.Context(), snapshot)
+	c.JSON(http.StatusOK, gin.H{"added": added, "results": results})
+}
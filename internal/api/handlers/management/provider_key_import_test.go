@@ -0,0 +1,78 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/keyimport"
+)
+
+func TestImportProviderKeysReportsPerRowResults(t *testing.T) {
+	previous := providerKeyProbe
+	providerKeyProbe = func(*config.Config) keyimport.Validator {
+		return func(_ context.Context, row keyimport.Row) error {
+			if row.Key == "sk-bad" {
+				return errors.New("unexpected status 401")
+			}
+			return nil
+		}
+	}
+	t.Cleanup(func() { providerKeyProbe = previous })
+
+	h := &Handler{
+		cfg:            &config.Config{ClaudeKey: []config.ClaudeKey{{APIKey: "sk-existing"}}},
+		configFilePath: writeTestConfigFile(t),
+	}
+	manifest := "provider,key,label,weight,proxy\n" +
+		"claude,sk-new,team-a,3,socks5://127.0.0.1:1080\n" +
+		"claude,sk-existing,,,\n" +
+		"openai,sk-bad,,,\n" +
+		"unknown,sk-x,,,\n"
+
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/v0/management/provider-keys/import", strings.NewReader(manifest))
+	ctx.Request.Header.Set("Content-Type", "text/csv")
+
+	h.ImportProviderKeys(ctx)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d; body=%s", rec.Code, http.StatusOK, rec.Body.String())
+	}
+	var body struct {
+		Added   int                `json:"added"`
+		Results []keyimport.Result `json:"results"`
+	}
+	if errUnmarshal := json.Unmarshal(rec.Body.Bytes(), &body); errUnmarshal != nil {
+		t.Fatalf("unmarshal response: %v", errUnmarshal)
+	}
+	want := []string{keyimport.StatusAdded, keyimport.StatusDuplicate, keyimport.StatusFailed, keyimport.StatusInvalid}
+	if body.Added != 1 || len(body.Results) != len(want) {
+		t.Fatalf("added = %d, results = %+v", body.Added, body.Results)
+	}
+	for i, status := range want {
+		if body.Results[i].Status != status {
+			t.Fatalf("row %d status = %q, want %q", i+1, body.Results[i].Status, status)
+		}
+	}
+	if strings.Contains(rec.Body.String(), "sk-new") {
+		t.Fatalf("response leaks an API key: %s", rec.Body.String())
+	}
+	if len(h.cfg.ClaudeKey) != 2 {
+		t.Fatalf("claude keys = %+v, want the new key appended", h.cfg.ClaudeKey)
+	}
+	added := h.cfg.ClaudeKey[1]
+	if added.APIKey != "sk-new" || added.Comment != "team-a" || added.Priority != 3 || added.ProxyURL != "socks5://127.0.0.1:1080" {
+		t.Fatalf("added entry = %+v", added)
+	}
+	if len(h.cfg.CodexKey) != 0 {
+		t.Fatalf("codex keys = %+v, want the failed key skipped", h.cfg.CodexKey)
+	}
+}
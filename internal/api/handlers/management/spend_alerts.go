@@ -0,0 +1,54 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/spendalert"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tenant"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// GetSpendAlerts returns today's estimated spend per tracked credential and tenant.
+func (h *Handler) GetSpendAlerts(c *gin.Context) {
+	enabled := false
+	if h != nil && h.cfg != nil {
+		enabled = h.cfg.SpendAlerts.Enabled
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": enabled, "budgets": spendalert.Default().Statuses()})
+}
+
+type spendAlertRearmRequest struct {
+	Scope      string `json:"scope"`
+	ID         string `json:"id"`
+	ResetSpend bool   `json:"reset_spend"`
+}
+
+// RearmSpendAlert re-enables a credential or tenant stopped by the spend kill-switch.
+func (h *Handler) RearmSpendAlert(c *gin.Context) {
+	var req spendAlertRearmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	id := strings.TrimSpace(req.ID)
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing id"})
+		return
+	}
+	err := spendalert.Default().Rearm(c.Request.Context(), req.Scope, id, req.ResetSpend)
+	switch {
+	case errors.Is(err, spendalert.ErrUnknownScope):
+		c.JSON(http.StatusBadRequest, gin.H{"error": "scope must be auth or tenant"})
+		return
+	case errors.Is(err, coreauth.ErrAuthNotFound), errors.Is(err, tenant.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true, "scope": strings.ToLower(strings.TrimSpace(req.Scope)), "id": id})
+}
@@ -0,0 +1,146 @@
+package management
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tenant"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// tenantView is a tenant together with its consumption for the current UTC day.
+type tenantView struct {
+	tenant.Tenant
+	Usage tenant.Usage `json:"usage"`
+}
+
+type tenantRequest struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	APIKey         string `json:"api_key"`
+	RequestsPerDay int64  `json:"requests_per_day"`
+	TokensPerDay   int64  `json:"tokens_per_day"`
+	Disabled       bool   `json:"disabled"`
+
+	AllowedProviders []string          `json:"allowed_providers"`
+	AuthGroups       []string          `json:"auth_groups"`
+	ModelAliases     map[string]string `json:"model_aliases"`
+}
+
+func (r tenantRequest) tenant() tenant.Tenant {
+	t := tenant.Tenant{
+		ID:             strings.TrimSpace(r.ID),
+		Name:           strings.TrimSpace(r.Name),
+		APIKey:         strings.TrimSpace(r.APIKey),
+		RequestsPerDay: r.RequestsPerDay,
+		TokensPerDay:   r.TokensPerDay,
+		Disabled:       r.Disabled,
+		AuthGroups:     coreauth.ParseGroups(r.AuthGroups),
+	}
+	for _, provider := range r.AllowedProviders {
+		if provider = strings.ToLower(strings.TrimSpace(provider)); provider != "" {
+			t.AllowedProviders = append(t.AllowedProviders, provider)
+		}
+	}
+	for alias, target := range r.ModelAliases {
+		alias, target = strings.TrimSpace(alias), strings.TrimSpace(target)
+		if alias == "" || target == "" {
+			continue
+		}
+		if t.ModelAliases == nil {
+			t.ModelAliases = make(map[string]string, len(r.ModelAliases))
+		}
+		t.ModelAliases[alias] = target
+	}
+	return t
+}
+
+func (r tenantRequest) validate() string {
+	if r.RequestsPerDay < 0 || r.TokensPerDay < 0 {
+		return "quotas must not be negative"
+	}
+	return ""
+}
+
+func tenantStoreView(store *tenant.Store, t tenant.Tenant) tenantView {
+	return tenantView{Tenant: t, Usage: store.UsageOf(t.ID)}
+}
+
+// ListTenants returns all tenants with today's consumption.
+func (h *Handler) ListTenants(c *gin.Context) {
+	store := tenant.Default()
+	tenants := store.List()
+	out := make([]tenantView, 0, len(tenants))
+	for _, t := range tenants {
+		out = append(out, tenantStoreView(store, t))
+	}
+	c.JSON(http.StatusOK, gin.H{"tenants": out})
+}
+
+// GetTenant returns one tenant with today's consumption.
+func (h *Handler) GetTenant(c *gin.Context) {
+	store := tenant.Default()
+	t, ok := store.Get(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+		return
+	}
+	c.JSON(http.StatusOK, tenantStoreView(store, t))
+}
+
+// CreateTenant issues a tenant API key. The key is generated when api_key is omitted.
+func (h *Handler) CreateTenant(c *gin.Context) {
+	var req tenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	store := tenant.Default()
+	created, err := store.Create(req.tenant())
+	if err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, tenantStoreView(store, created))
+}
+
+// UpdateTenant replaces a tenant's name, quotas, routing rules and disabled flag. A
+// non-empty api_key rotates the key.
+func (h *Handler) UpdateTenant(c *gin.Context) {
+	var req tenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request body"})
+		return
+	}
+	if msg := req.validate(); msg != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": msg})
+		return
+	}
+	store := tenant.Default()
+	updated, err := store.Update(c.Param("id"), req.tenant())
+	switch {
+	case errors.Is(err, tenant.ErrNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+		return
+	case err != nil:
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, tenantStoreView(store, updated))
+}
+
+// DeleteTenant revokes a tenant and its API key.
+func (h *Handler) DeleteTenant(c *gin.Context) {
+	id := c.Param("id")
+	if err := tenant.Default().Delete(id); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tenant not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ok": true, "id": id})
+}
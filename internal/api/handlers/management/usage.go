@@ -6,9 +6,12 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/redisqueue"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
 )
 
 const maxUsageQueueDrainCount = 500
@@ -91,3 +94,50 @@ func parseUsageQueueCount(value string) (int, error) {
 	}
 	return count, nil
 }
+
+// GetUsageCounters returns persisted per-auth, per-model, per-day usage counters.
+// Optional query filters: auth_id, model, provider, from and to (inclusive YYYY-MM-DD UTC days).
+func (h *Handler) GetUsageCounters(c *gin.Context) {
+	counters := coreusage.DefaultManager().Counters()
+	if counters == nil {
+		c.JSON(http.StatusOK, gin.H{"enabled": false, "rows": []coreusage.CounterRow{}, "totals": coreusage.CounterValues{}})
+		return
+	}
+	filter := coreusage.CounterFilter{
+		AuthID:   strings.TrimSpace(c.Query("auth_id")),
+		Model:    strings.TrimSpace(c.Query("model")),
+		Provider: strings.TrimSpace(c.Query("provider")),
+		From:     strings.TrimSpace(c.Query("from")),
+		To:       strings.TrimSpace(c.Query("to")),
+	}
+	for _, day := range []string{filter.From, filter.To} {
+		if day == "" {
+			continue
+		}
+		if _, errParse := time.Parse("2006-01-02", day); errParse != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be YYYY-MM-DD dates"})
+			return
+		}
+	}
+	rows := counters.Query(filter)
+	var totals coreusage.CounterValues
+	for _, row := range rows {
+		totals.Add(row.CounterValues)
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": true, "rows": rows, "totals": totals})
+}
+
+// GetPromptCacheStats returns per-provider prompt-prefix caching counters: requests that
+// received an automatic cache hint and the cache read/write tokens reported upstream.
+func (h *Handler) GetPromptCacheStats(c *gin.Context) {
+	enabled := h.cfg != nil && h.cfg.PromptCacheHints.Enabled
+	providers := cache.PromptCacheStatsSnapshot()
+	var totals cache.PromptCacheStats
+	for _, stats := range providers {
+		totals.HintedRequests += stats.HintedRequests
+		totals.HintedPrefixTokens += stats.HintedPrefixTokens
+		totals.CacheHitTokens += stats.CacheHitTokens
+		totals.CacheWriteTokens += stats.CacheWriteTokens
+	}
+	c.JSON(http.StatusOK, gin.H{"enabled": enabled, "providers": providers, "totals": totals})
+}
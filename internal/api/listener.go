@@ -0,0 +1,34 @@
+package api
+
+import (
+	"context"
+	"net"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Environment variables passing the listening socket to a replacement process.
+const (
+	// handoffListenFDEnv names the inherited listener descriptor.
+	handoffListenFDEnv = "CLIPROXY_LISTEN_FD"
+	// handoffReadyFDEnv names the pipe the replacement writes to once it is serving.
+	handoffReadyFDEnv = "CLIPROXY_READY_FD"
+)
+
+// listen returns the API listener: a socket inherited from systemd socket activation or
+// from the previous process when present, otherwise a new socket bound to addr.
+func (s *Server) listen(addr string) (net.Listener, error) {
+	listener, inherited, errInherit := inheritedListener()
+	if errInherit != nil {
+		return nil, errInherit
+	}
+	if inherited {
+		log.Infof("serving on inherited listener %s", listener.Addr())
+		return listener, nil
+	}
+	var lc net.ListenConfig
+	if s.cfg != nil && s.cfg.ReusePort {
+		lc.Control = reusePortControl
+	}
+	return lc.Listen(context.Background(), "tcp", addr)
+}
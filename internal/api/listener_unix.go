@@ -0,0 +1,134 @@
+//go:build !windows
+
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+// systemdListenFDsStart is the first descriptor passed by systemd socket activation.
+const systemdListenFDsStart = 3
+
+// inheritedListener returns the listener passed by a previous process or by systemd.
+func inheritedListener() (net.Listener, bool, error) {
+	fd := -1
+	if raw := os.Getenv(handoffListenFDEnv); raw != "" {
+		_ = os.Unsetenv(handoffListenFDEnv)
+		parsed, errParse := strconv.Atoi(raw)
+		if errParse != nil {
+			return nil, false, fmt.Errorf("invalid %s %q", handoffListenFDEnv, raw)
+		}
+		fd = parsed
+	} else if os.Getenv("LISTEN_PID") == strconv.Itoa(os.Getpid()) {
+		if count, _ := strconv.Atoi(os.Getenv("LISTEN_FDS")); count >= 1 {
+			fd = systemdListenFDsStart
+		}
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}
+	if fd < 0 {
+		return nil, false, nil
+	}
+	file := os.NewFile(uintptr(fd), "inherited-listener")
+	listener, errListener := net.FileListener(file)
+	_ = file.Close()
+	if errListener != nil {
+		return nil, false, fmt.Errorf("failed to use inherited listener fd %d: %w", fd, errListener)
+	}
+	return listener, true, nil
+}
+
+func reusePortControl(_, _ string, conn syscall.RawConn) error {
+	var errOpt error
+	if errControl := conn.Control(func(fd uintptr) {
+		errOpt = unix.SetsockoptInt(int(fd), unix.SOL_SOCKET, unix.SO_REUSEPORT, 1)
+	}); errControl != nil {
+		return errControl
+	}
+	return errOpt
+}
+
+// notifyHandoffReady tells the previous process that this one is serving.
+func notifyHandoffReady() {
+	raw := os.Getenv(handoffReadyFDEnv)
+	if raw == "" {
+		return
+	}
+	_ = os.Unsetenv(handoffReadyFDEnv)
+	fd, errParse := strconv.Atoi(raw)
+	if errParse != nil {
+		return
+	}
+	ready := os.NewFile(uintptr(fd), "handoff-ready")
+	if _, errWrite := ready.Write([]byte{1}); errWrite != nil {
+		log.Warnf("failed to signal handoff readiness: %v", errWrite)
+	}
+	_ = ready.Close()
+}
+
+// Handoff starts a new copy of the running binary with the same arguments, passes it the
+// listening socket and waits until it serves. The caller then drains this process; no
+// connection is refused in between because the socket stays open throughout.
+func (s *Server) Handoff(ctx context.Context) error {
+	tcpListener, ok := s.listener.(*net.TCPListener)
+	if !ok || tcpListener == nil {
+		return errors.New("listener handoff requires a TCP listener")
+	}
+	listenerFile, errFile := tcpListener.File()
+	if errFile != nil {
+		return fmt.Errorf("failed to duplicate listener: %w", errFile)
+	}
+	defer func() { _ = listenerFile.Close() }()
+	readyReader, readyWriter, errPipe := os.Pipe()
+	if errPipe != nil {
+		return fmt.Errorf("failed to create readiness pipe: %w", errPipe)
+	}
+	defer func() { _ = readyReader.Close() }()
+	executable, errExecutable := os.Executable()
+	if errExecutable != nil {
+		_ = readyWriter.Close()
+		return fmt.Errorf("failed to resolve executable: %w", errExecutable)
+	}
+
+	cmd := exec.Command(executable, os.Args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	// ExtraFiles[i] becomes descriptor 3+i in the child.
+	cmd.ExtraFiles = []*os.File{listenerFile, readyWriter}
+	cmd.Env = append(os.Environ(), handoffListenFDEnv+"=3", handoffReadyFDEnv+"=4")
+	errStart := cmd.Start()
+	_ = readyWriter.Close()
+	if errStart != nil {
+		return fmt.Errorf("failed to start replacement process: %w", errStart)
+	}
+
+	ready := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 1)
+		_, errRead := readyReader.Read(buf)
+		ready <- errRead
+	}()
+	select {
+	case errRead := <-ready:
+		if errRead != nil {
+			_ = cmd.Wait()
+			return fmt.Errorf("replacement process exited before serving: %w", errRead)
+		}
+		log.Infof("replacement process %d is serving", cmd.Process.Pid)
+		return cmd.Process.Release()
+	case <-ctx.Done():
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return fmt.Errorf("replacement process not ready: %w", ctx.Err())
+	}
+}
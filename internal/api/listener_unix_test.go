@@ -0,0 +1,56 @@
+//go:build !windows
+
+package api
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestListenInheritsHandoffDescriptor(t *testing.T) {
+	original, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer func() { _ = original.Close() }()
+	file, err := original.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatalf("file: %v", err)
+	}
+	defer func() { _ = file.Close() }()
+	t.Setenv(handoffListenFDEnv, strconv.Itoa(int(file.Fd())))
+
+	s := &Server{cfg: &config.Config{}}
+	inherited, err := s.listen("127.0.0.1:1")
+	if err != nil {
+		t.Fatalf("listen with inherited fd: %v", err)
+	}
+	defer func() { _ = inherited.Close() }()
+	if inherited.Addr().String() != original.Addr().String() {
+		t.Fatalf("inherited addr = %s, want %s", inherited.Addr(), original.Addr())
+	}
+}
+
+func TestListenReusePort(t *testing.T) {
+	s := &Server{cfg: &config.Config{ReusePort: true}}
+	first, err := s.listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("first listen: %v", err)
+	}
+	defer func() { _ = first.Close() }()
+	second, err := s.listen(first.Addr().String())
+	if err != nil {
+		t.Fatalf("second listen on %s with reuse-port: %v", first.Addr(), err)
+	}
+	_ = second.Close()
+
+	var plain net.ListenConfig
+	if l, errPlain := plain.Listen(context.Background(), "tcp", first.Addr().String()); errPlain == nil {
+		_ = l.Close()
+		t.Fatal("expected a plain listener to conflict with the bound port")
+	}
+}
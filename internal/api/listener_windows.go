@@ -0,0 +1,22 @@
+//go:build windows
+
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+)
+
+func inheritedListener() (net.Listener, bool, error) { return nil, false, nil }
+
+// reusePortControl is a no-op: Windows has no SO_REUSEPORT.
+func reusePortControl(_, _ string, _ syscall.RawConn) error { return nil }
+
+func notifyHandoffReady() {}
+
+// Handoff is not supported on Windows.
+func (s *Server) Handoff(context.Context) error {
+	return errors.New("listener handoff is not supported on windows")
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// isManagementPath reports whether path belongs to the management plane: the management
+// API, the control panel and the resources it loads.
+func isManagementPath(path string) bool {
+	switch {
+	case path == "/v0/management" || strings.HasPrefix(path, "/v0/management/"):
+		return true
+	case strings.HasPrefix(path, "/v0/resource/plugins/"):
+		return true
+	case path == "/management.html" || path == "/key-health.html":
+		return true
+	}
+	return false
+}
+
+// planeHandler splits the management plane from the data plane once a dedicated
+// management listener is running: each listener answers 404 for the other's paths.
+func (s *Server) planeHandler(next http.Handler, managementListener bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.managementListenerActive.Load() && isManagementPath(r.URL.Path) != managementListener {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// startManagementListener serves the management plane on remote-management.listen, using
+// the main server's TLS configuration when HTTPS is enabled.
+func (s *Server) startManagementListener(tlsConfig *tls.Config) error {
+	if s.cfg == nil {
+		return nil
+	}
+	addr := strings.TrimSpace(s.cfg.RemoteManagement.Listen)
+	if addr == "" {
+		return nil
+	}
+	listener, errListen := net.Listen("tcp", addr)
+	if errListen != nil {
+		return errListen
+	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+	s.managementServer = &http.Server{
+		Addr:              addr,
+		Handler:           s.planeHandler(s.engine, true),
+		ReadHeaderTimeout: s.server.ReadHeaderTimeout,
+	}
+	s.managementListenerActive.Store(true)
+	go func(server *http.Server) {
+		if errServe := server.Serve(listener); errServe != nil && !errors.Is(errServe, http.ErrServerClosed) {
+			log.Errorf("management listener on %s stopped: %v", addr, errServe)
+		}
+	}(s.managementServer)
+	log.Infof("management API listening on %s", addr)
+	return nil
+}
+
+func (s *Server) stopManagementListener(ctx context.Context) {
+	if s.managementServer == nil {
+		return
+	}
+	if errShutdown := s.managementServer.Shutdown(ctx); errShutdown != nil {
+		_ = s.managementServer.Close()
+	}
+	s.managementServer = nil
+}
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// requestMessageFields lists the top-level array fields holding conversation turns in the
+// OpenAI/Claude (messages), Gemini (contents) and Responses (input) request formats.
+var requestMessageFields = map[string]struct{}{
+	"messages": {},
+	"contents": {},
+	"input":    {},
+}
+
+var errRequestBodyTooLarge = errors.New("request body too large")
+
+// RequestLimitsMiddleware enforces request-limits on requests whose path satisfies applies.
+// Bodies are read incrementally so oversized or malformed payloads are rejected as soon as
+// the violation is seen; accepted bodies are replayed to the handlers unchanged.
+func RequestLimitsMiddleware(load func() *config.RequestLimitsConfig, applies func(path string) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limits := load()
+		if limits == nil || (limits.MaxBodyBytes <= 0 && limits.MaxMessages <= 0 && !limits.ValidateJSON) ||
+			c.Request.Body == nil || c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead ||
+			(applies != nil && !applies(c.Request.URL.Path)) {
+			c.Next()
+			return
+		}
+
+		maxBytes := limits.MaxBodyBytes
+		if maxBytes > 0 && c.Request.ContentLength > maxBytes {
+			abortRequestLimit(c, http.StatusRequestEntityTooLarge, "request_too_large",
+				fmt.Sprintf("request body of %d bytes exceeds the limit of %d bytes", c.Request.ContentLength, maxBytes))
+			return
+		}
+
+		scanJSON := (limits.ValidateJSON || limits.MaxMessages > 0) && isPlainJSONRequest(c.Request)
+		if !scanJSON && (maxBytes <= 0 || c.Request.ContentLength >= 0) {
+			if maxBytes > 0 {
+				c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+			}
+			c.Next()
+			return
+		}
+
+		reader := &limitedCaptureReader{src: c.Request.Body, limit: maxBytes}
+		var status int
+		var code, message string
+		if scanJSON {
+			status, code, message = scanRequestJSON(reader, limits)
+		}
+		if status == 0 {
+			// Capture whatever the scan left unread, e.g. after tolerated malformed JSON.
+			if _, errRead := io.Copy(io.Discard, reader); errRead != nil {
+				status, code, message = readErrorStatus(errRead, maxBytes)
+			}
+		}
+		_ = c.Request.Body.Close()
+		if status != 0 {
+			abortRequestLimit(c, status, code, message)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(reader.buf.Bytes()))
+		c.Request.ContentLength = int64(reader.buf.Len())
+		c.Next()
+	}
+}
+
+// isPlainJSONRequest reports whether the body is uncompressed JSON (or untyped).
+func isPlainJSONRequest(req *http.Request) bool {
+	if encoding := strings.TrimSpace(req.Header.Get("Content-Encoding")); encoding != "" && !strings.EqualFold(encoding, "identity") {
+		return false
+	}
+	contentType := strings.ToLower(req.Header.Get("Content-Type"))
+	return contentType == "" || strings.Contains(contentType, "json")
+}
+
+// limitedCaptureReader keeps every byte read and fails once more than limit bytes arrive.
+// The failure is sticky so the source is never read past the limit.
+type limitedCaptureReader struct {
+	src   io.Reader
+	limit int64
+	buf   bytes.Buffer
+	err   error
+}
+
+func (r *limitedCaptureReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	n, err := r.src.Read(p)
+	r.buf.Write(p[:n])
+	if r.limit > 0 && int64(r.buf.Len()) > r.limit {
+		r.err = errRequestBodyTooLarge
+		return n, r.err
+	}
+	return n, err
+}
+
+func readErrorStatus(err error, maxBytes int64) (int, string, string) {
+	if errors.Is(err, errRequestBodyTooLarge) {
+		return http.StatusRequestEntityTooLarge, "request_too_large", fmt.Sprintf("request body exceeds the limit of %d bytes", maxBytes)
+	}
+	return http.StatusBadRequest, "invalid_request_body", fmt.Sprintf("failed to read request body: %v", err)
+}
+
+// jsonFrame is one open object or array while walking the token stream.
+type jsonFrame struct {
+	object    bool
+	expectKey bool
+	key       string
+	// counted marks the top-level message array whose entries are limited.
+	counted bool
+	count   int
+}
+
+// scanRequestJSON walks the body token by token, enforcing the message limit and (when
+// enabled) JSON validity. It returns a zero status when the body is acceptable.
+func scanRequestJSON(reader *limitedCaptureReader, limits *config.RequestLimitsConfig) (int, string, string) {
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+	var stack []*jsonFrame
+	started := false
+	for {
+		token, errToken := decoder.Token()
+		if errToken != nil {
+			if errors.Is(errToken, errRequestBodyTooLarge) {
+				return readErrorStatus(errToken, limits.MaxBodyBytes)
+			}
+			if errors.Is(errToken, io.EOF) && !started {
+				// Empty bodies are left to the handlers.
+				return 0, "", ""
+			}
+			return invalidJSONStatus(limits, errToken)
+		}
+		started = true
+
+		var top *jsonFrame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		delim, isDelim := token.(json.Delim)
+		if isDelim && (delim == '}' || delim == ']') {
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 && stack[len(stack)-1].object {
+				stack[len(stack)-1].expectKey = true
+			}
+		} else if top != nil && top.object && top.expectKey {
+			top.key, _ = token.(string)
+			top.expectKey = false
+			continue
+		} else {
+			if top != nil && top.counted {
+				top.count++
+				if limits.MaxMessages > 0 && top.count > limits.MaxMessages {
+					return http.StatusRequestEntityTooLarge, "too_many_messages",
+						fmt.Sprintf("%s has more than %d entries", stack[0].key, limits.MaxMessages)
+				}
+			}
+			if isDelim {
+				frame := &jsonFrame{object: delim == '{', expectKey: delim == '{'}
+				if delim == '[' && len(stack) == 1 && stack[0].object {
+					_, frame.counted = requestMessageFields[stack[0].key]
+				}
+				stack = append(stack, frame)
+				continue
+			}
+			if top != nil && top.object {
+				top.expectKey = true
+			}
+		}
+		if len(stack) == 0 {
+			break
+		}
+	}
+
+	rest, errRest := io.ReadAll(io.MultiReader(decoder.Buffered(), reader))
+	if errRest != nil {
+		return readErrorStatus(errRest, limits.MaxBodyBytes)
+	}
+	if len(bytes.TrimSpace(rest)) > 0 {
+		return invalidJSONStatus(limits, errors.New("unexpected data after the top-level value"))
+	}
+	return 0, "", ""
+}
+
+func invalidJSONStatus(limits *config.RequestLimitsConfig, err error) (int, string, string) {
+	if !limits.ValidateJSON {
+		// Without validation malformed bodies still reach the handlers, which report
+		// them in their own format.
+		return 0, "", ""
+	}
+	return http.StatusBadRequest, "invalid_json", fmt.Sprintf("invalid JSON request body: %v", err)
+}
+
+func abortRequestLimit(c *gin.Context, status int, code, message string) {
+	c.Header("Connection", "close")
+	c.AbortWithStatusJSON(status, gin.H{"error": gin.H{
+		"message": message,
+		"type":    "invalid_request_error",
+		"code":    code,
+	}})
+}
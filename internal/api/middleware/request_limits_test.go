@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+// endlessReader fails the test if it is read past limit bytes.
+type endlessReader struct {
+	t     *testing.T
+	read  int
+	limit int
+	chunk string
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	if r.read > r.limit {
+		r.t.Fatalf("body read past %d bytes", r.limit)
+	}
+	n := copy(p, r.chunk)
+	r.read += n
+	return n, nil
+}
+
+func TestRequestLimitsMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limits := &config.RequestLimitsConfig{MaxBodyBytes: 1 << 20, MaxMessages: 2, ValidateJSON: true}
+	engine := gin.New()
+	engine.Use(RequestLimitsMiddleware(func() *config.RequestLimitsConfig { return limits }, func(path string) bool {
+		return strings.HasPrefix(path, "/v1/")
+	}))
+	var received string
+	handler := func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		received = string(body)
+		c.Status(http.StatusOK)
+	}
+	engine.POST("/v1/chat/completions", handler)
+	engine.POST("/other", handler)
+
+	cases := []struct {
+		name   string
+		path   string
+		body   io.Reader
+		length int64
+		status int
+		code   string
+	}{
+		{name: "accepted", path: "/v1/chat/completions", body: strings.NewReader(`{"messages":[{"role":"user","content":"hi"}],"input":"x"}`), status: http.StatusOK},
+		{name: "content length over limit", path: "/v1/chat/completions", body: strings.NewReader("{}"), length: 2 << 20, status: http.StatusRequestEntityTooLarge, code: "request_too_large"},
+		{name: "too many messages", path: "/v1/chat/completions", body: strings.NewReader(`{"messages":[{},{"content":[1,2,3]},{}]}`), status: http.StatusRequestEntityTooLarge, code: "too_many_messages"},
+		{name: "nested arrays are not counted", path: "/v1/chat/completions", body: strings.NewReader(`{"tools":[{"messages":[1,2,3]}],"contents":[1,2]}`), status: http.StatusOK},
+		{name: "trailing data", path: "/v1/chat/completions", body: strings.NewReader(`{} {}`), status: http.StatusBadRequest, code: "invalid_json"},
+		{name: "other paths are untouched", path: "/other", body: strings.NewReader(`{"messages":[{},{},{}]}`), status: http.StatusOK},
+		{
+			name:   "streamed body over limit",
+			path:   "/v1/chat/completions",
+			body:   io.MultiReader(strings.NewReader(`{"messages":["`), &endlessReader{t: t, limit: 2 << 20, chunk: strings.Repeat("a", 4096)}),
+			length: -1,
+			status: http.StatusRequestEntityTooLarge,
+			code:   "request_too_large",
+		},
+		{
+			name:   "invalid JSON rejected early",
+			path:   "/v1/chat/completions",
+			body:   io.MultiReader(strings.NewReader(`{"messages":]`), &endlessReader{t: t, limit: 64 << 10, chunk: strings.Repeat(" ", 4096)}),
+			length: -1,
+			status: http.StatusBadRequest,
+			code:   "invalid_json",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			received = ""
+			req := httptest.NewRequest(http.MethodPost, tc.path, tc.body)
+			if tc.length != 0 {
+				req.ContentLength = tc.length
+			}
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, req)
+			if rec.Code != tc.status {
+				t.Fatalf("status = %d, want %d (%s)", rec.Code, tc.status, rec.Body.String())
+			}
+			if tc.code != "" && gjson.Get(rec.Body.String(), "error.code").String() != tc.code {
+				t.Fatalf("error code = %s", rec.Body.String())
+			}
+			if tc.status == http.StatusOK && received == "" {
+				t.Fatal("handler did not receive the body")
+			}
+		})
+	}
+}
@@ -48,6 +48,7 @@ import (
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/pluginapi"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/acme/autocert"
 	"golang.org/x/net/http2"
 	"gopkg.in/yaml.v3"
 )
@@ -58,6 +59,7 @@ const codexAlphaSearchSourceFormat = "codex-alpha-search"
 
 var corsExposedResponseHeaders = []string{
 	logging.CPATraceIDHeader,
+	logging.RequestIDHeader,
 	"X-CPA-VERSION",
 	"X-CPA-COMMIT",
 	"X-CPA-BUILD-DATE",
@@ -87,6 +89,7 @@ type serverOptionConfig struct {
 	postAuthHook          auth.PostAuthHook
 	postAuthPersistHook   auth.PostAuthHook
 	pluginHost            *pluginhost.Host
+	transformMiddlewares  []handlers.TransformMiddleware
 	configReloadHook      func(context.Context, *config.Config)
 	exampleAPIKeySafeMode bool
 }
@@ -181,6 +184,13 @@ func WithPluginHost(host *pluginhost.Host) ServerOption {
 	}
 }
 
+// WithTransformMiddleware registers in-process request/response transformation middlewares.
+func WithTransformMiddleware(middlewares ...handlers.TransformMiddleware) ServerOption {
+	return func(cfg *serverOptionConfig) {
+		cfg.transformMiddlewares = append(cfg.transformMiddlewares, middlewares...)
+	}
+}
+
 // WithConfigReloadHook registers a callback used after management saves config changes.
 func WithConfigReloadHook(hook func(context.Context, *config.Config)) ServerOption {
 	return func(cfg *serverOptionConfig) {
@@ -204,12 +214,30 @@ type Server struct {
 	// server is the underlying HTTP server.
 	server *http.Server
 
+	// listener is the raw TCP listener, kept so it can be handed to a replacement process.
+	listener net.Listener
+
 	// muxBaseListener is the shared TCP listener used to serve both HTTP and Redis protocol traffic.
 	muxBaseListener net.Listener
 
 	// muxHTTPListener receives HTTP connections selected by the multiplexer.
 	muxHTTPListener *muxListener
 
+	// certReloader serves the tls.cert/tls.key pair and reloads it when the files change.
+	certReloader *certReloader
+
+	// acmeManager obtains certificates automatically when tls.acme is enabled.
+	acmeManager *autocert.Manager
+
+	// acmeHTTPServer answers ACME HTTP-01 challenges on tls.acme.http-challenge-addr.
+	acmeHTTPServer *http.Server
+
+	// managementServer serves the management plane on remote-management.listen.
+	managementServer *http.Server
+
+	// managementListenerActive is set while managementServer owns the management routes.
+	managementListenerActive atomic.Bool
+
 	// handlers contains the API handlers for processing requests.
 	handlers *handlers.BaseAPIHandler
 
@@ -239,6 +267,12 @@ type Server struct {
 	wsAuthChanged func(bool, bool)
 	wsAuthEnabled atomic.Bool
 
+	// draining is set once shutdown begins; new requests are then refused with 503.
+	draining atomic.Bool
+
+	// requestLimits holds the active request-limits settings read by the limits middleware.
+	requestLimits *atomic.Pointer[config.RequestLimitsConfig]
+
 	// management handler
 	mgmt *managementHandlers.Handler
 
@@ -297,6 +331,10 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	engine.Use(logging.GinLogrusLogger(cfg))
 	engine.Use(logging.GinLogrusRecovery())
 	engine.Use(logging.CPATraceIDMiddleware())
+	// Request limits run before request logging so rejected bodies are never buffered whole.
+	requestLimits := &atomic.Pointer[config.RequestLimitsConfig]{}
+	requestLimits.Store(&cfg.RequestLimits)
+	engine.Use(middleware.RequestLimitsMiddleware(requestLimits.Load, isProxyAPIPath))
 	for _, mw := range optionState.extraMiddleware {
 		engine.Use(mw)
 	}
@@ -339,6 +377,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		currentPath:         wd,
 		envManagementSecret: envManagementSecret,
 		wsRoutes:            make(map[string]struct{}),
+		requestLimits:       requestLimits,
 		pluginHost:          optionState.pluginHost,
 
 		exampleAPIKeySafeModeEnabled: optionState.exampleAPIKeySafeMode,
@@ -346,6 +385,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
 	s.exampleAPIKeySafeModeActive.Store(s.exampleAPIKeySafeModeRequired(cfg))
 	s.handlers.SetPluginHost(optionState.pluginHost)
+	s.handlers.UseTransformMiddleware(optionState.transformMiddlewares...)
 	if optionState.pluginHost != nil {
 		optionState.pluginHost.SetModelExecutor(s.handlers)
 		optionState.pluginHost.SetAuthManager(authManager)
@@ -379,6 +419,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 
 	// Home heartbeat gate: when home is enabled, block all endpoints with 503 until the
 	// subscribe-config heartbeat connection is healthy.
+	engine.Use(s.drainMiddleware())
 	engine.Use(s.homeHeartbeatMiddleware())
 	engine.Use(s.exampleAPIKeySafeModeMiddleware())
 
@@ -408,12 +449,26 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	// Create HTTP server
 	s.server = &http.Server{
 		Addr:    fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
-		Handler: engine,
+		Handler: s.planeHandler(engine, false),
 	}
 
 	return s
 }
 
+// drainMiddleware refuses requests that arrive while the server is draining, e.g. on
+// kept-alive connections, so only requests already in flight run to completion.
+func (s *Server) drainMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if s == nil || !s.draining.Load() {
+			c.Next()
+			return
+		}
+		c.Header("Connection", "close")
+		c.Header("Retry-After", "1")
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "server is shutting down"})
+	}
+}
+
 func (s *Server) homeHeartbeatMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		if s == nil || s.cfg == nil || !s.cfg.Home.Enabled {
@@ -456,7 +511,7 @@ func (s *Server) exampleAPIKeySafeModeMiddleware() gin.HandlerFunc {
 			s.serveExampleAPIKeyWarningPage(c)
 			return
 		}
-		if !isExampleAPIKeySafeModeProxyPath(path) {
+		if !isProxyAPIPath(path) {
 			c.Next()
 			return
 		}
@@ -486,7 +541,7 @@ func (s *Server) serveExampleAPIKeyWarningPage(c *gin.Context) {
 	c.Abort()
 }
 
-func isExampleAPIKeySafeModeProxyPath(path string) bool {
+func isProxyAPIPath(path string) bool {
 	switch {
 	case path == "/v1" || strings.HasPrefix(path, "/v1/"):
 		return true
@@ -516,6 +571,7 @@ func (s *Server) setupRoutes() {
 	s.engine.HEAD("/healthz", healthzHandler)
 
 	s.engine.GET("/management.html", s.serveManagementControlPanel)
+	s.engine.GET("/key-health.html", s.serveKeyHealthDashboard)
 	openaiHandlers := openai.NewOpenAIAPIHandler(s.handlers)
 	geminiHandlers := gemini.NewGeminiAPIHandler(s.handlers)
 	claudeCodeHandlers := claude.NewClaudeCodeAPIHandler(s.handlers)
@@ -528,6 +584,19 @@ func (s *Server) setupRoutes() {
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
 		v1.POST("/completions", openaiHandlers.Completions)
+		v1.POST("/embeddings", openaiHandlers.Embeddings)
+		v1.POST("/files", openaiHandlers.UploadFile)
+		v1.GET("/files/:file_id", openaiHandlers.RetrieveFile)
+		v1.GET("/files/:file_id/content", openaiHandlers.RetrieveFileContent)
+		v1.POST("/batches", openaiHandlers.CreateBatch)
+		v1.GET("/batches", openaiHandlers.ListBatches)
+		v1.GET("/batches/:batch_id", openaiHandlers.RetrieveBatch)
+		v1.POST("/batches/:batch_id/cancel", openaiHandlers.CancelBatch)
+		v1.POST("/sessions", openaiHandlers.CreateSession)
+		v1.GET("/sessions/:session_id", openaiHandlers.RetrieveSession)
+		v1.DELETE("/sessions/:session_id", openaiHandlers.DeleteSession)
+		v1.POST("/sessions/:session_id/messages", openaiHandlers.AppendSessionMessages)
+		v1.POST("/sessions/:session_id/run", openaiHandlers.RunSession)
 		v1.POST("/images/generations", openaiHandlers.ImagesGenerations)
 		v1.POST("/images/edits", openaiHandlers.ImagesEdits)
 		v1.POST("/videos", openaiHandlers.XAIVideosGenerations)
@@ -578,6 +647,8 @@ func (s *Server) setupRoutes() {
 			"endpoints": []string{
 				"POST /v1/chat/completions",
 				"POST /v1/completions",
+				"POST /v1/embeddings",
+				"POST /v1/batches",
 				"GET /v1/models",
 			},
 		})
@@ -987,12 +1058,16 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PATCH("/quota-exceeded/switch-preview-model", s.mgmt.PutSwitchPreviewModel)
 		mgmt.POST("/reset-quota", s.mgmt.ResetQuota)
 
+		mgmt.GET("/key-health", s.mgmt.GetKeyHealth)
+
 		mgmt.GET("/api-keys", s.mgmt.GetAPIKeys)
 		mgmt.PUT("/api-keys", s.mgmt.PutAPIKeys)
 		mgmt.PATCH("/api-keys", s.mgmt.PatchAPIKeys)
 		mgmt.DELETE("/api-keys", s.mgmt.DeleteAPIKeys)
 		mgmt.GET("/api-key-usage", s.mgmt.GetAPIKeyUsage)
 		mgmt.GET("/usage-queue", s.mgmt.GetUsageQueue)
+		mgmt.GET("/usage-counters", s.mgmt.GetUsageCounters)
+		mgmt.GET("/prompt-cache-stats", s.mgmt.GetPromptCacheStats)
 		mgmt.GET("/weight-robin-queue", s.mgmt.GetWeightRobinQueue)
 
 		mgmt.GET("/gemini-api-key", s.mgmt.GetGeminiKeys)
@@ -1057,6 +1132,21 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.POST("/api-key-ip-blacklist", s.mgmt.PostAPIKeyIPBlacklist)
 		mgmt.DELETE("/api-key-ip-blacklist", s.mgmt.DeleteAPIKeyIPBlacklist)
 
+		mgmt.GET("/tenants", s.mgmt.ListTenants)
+		mgmt.POST("/tenants", s.mgmt.CreateTenant)
+		mgmt.GET("/tenants/:id", s.mgmt.GetTenant)
+		mgmt.PUT("/tenants/:id", s.mgmt.UpdateTenant)
+		mgmt.PATCH("/tenants/:id", s.mgmt.UpdateTenant)
+		mgmt.DELETE("/tenants/:id", s.mgmt.DeleteTenant)
+
+		mgmt.GET("/spend-alerts", s.mgmt.GetSpendAlerts)
+		mgmt.POST("/spend-alerts/rearm", s.mgmt.RearmSpendAlert)
+
+		mgmt.GET("/auth-expiry-report", s.mgmt.GetAuthExpiryReport)
+
+		mgmt.GET("/daily-caps", s.mgmt.GetDailyCaps)
+		mgmt.POST("/daily-caps/reset", s.mgmt.ResetDailyCap)
+
 		mgmt.GET("/copilot-quota", s.mgmt.GetCopilotQuota)
 
 		mgmt.GET("/claude-api-key", s.mgmt.GetClaudeKeys)
@@ -1083,6 +1173,7 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PUT("/xai-api-key", s.mgmt.PutXAIKeys)
 		mgmt.PATCH("/xai-api-key", s.mgmt.PatchXAIKey)
 		mgmt.DELETE("/xai-api-key", s.mgmt.DeleteXAIKey)
+		mgmt.POST("/provider-keys/import", s.mgmt.ImportProviderKeys)
 
 		mgmt.GET("/openai-compatibility", s.mgmt.GetOpenAICompat)
 		mgmt.PUT("/openai-compatibility", s.mgmt.PutOpenAICompat)
@@ -1111,6 +1202,8 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.POST("/auth-files", s.mgmt.UploadAuthFile)
 		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
 		mgmt.PATCH("/auth-files/status", s.mgmt.PatchAuthFileStatus)
+		mgmt.POST("/auth-files/refresh", s.mgmt.RefreshAuthFile)
+		mgmt.POST("/auth-files/clear-cooldown", s.mgmt.ClearAuthFileCooldown)
 		mgmt.PATCH("/auth-files/fields", s.mgmt.PatchAuthFileFields)
 		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
 		mgmt.POST("/gitlab-pat", s.mgmt.RequestGitLabPATToken)
@@ -1261,6 +1354,17 @@ func (s *Server) serveManagementControlPanel(c *gin.Context) {
 	c.File(filePath)
 }
 
+// serveKeyHealthDashboard serves the embedded key health page. It follows the control
+// panel switches; the data endpoint it polls stays behind management authentication.
+func (s *Server) serveKeyHealthDashboard(c *gin.Context) {
+	cfg := s.cfg
+	if cfg == nil || cfg.Home.Enabled || cfg.RemoteManagement.DisableControlPanel {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	managementHandlers.ServeKeyHealthDashboard(c)
+}
+
 func (s *Server) enableKeepAlive(timeout time.Duration, onTimeout func()) {
 	if timeout <= 0 || onTimeout == nil {
 		return
@@ -1809,42 +1913,37 @@ func (s *Server) Start() error {
 	}
 
 	addr := s.server.Addr
-	listener, errListen := net.Listen("tcp", addr)
+	listener, errListen := s.listen(addr)
 	if errListen != nil {
 		return fmt.Errorf("failed to start HTTP server: %v", errListen)
 	}
+	s.listener = listener
 
 	useTLS := s.cfg != nil && s.cfg.TLS.Enable
 	if useTLS {
-		certPath := strings.TrimSpace(s.cfg.TLS.Cert)
-		keyPath := strings.TrimSpace(s.cfg.TLS.Key)
-		if certPath == "" || keyPath == "" {
+		tlsConfig, errTLS := s.buildServerTLSConfig(s.cfg)
+		if errTLS != nil {
 			if errClose := listener.Close(); errClose != nil {
-				log.Errorf("failed to close listener after TLS validation failure: %v", errClose)
+				log.Errorf("failed to close listener after TLS setup failure: %v", errClose)
 			}
-			return fmt.Errorf("failed to start HTTPS server: tls.cert or tls.key is empty")
-		}
-		certPair, errLoad := tls.LoadX509KeyPair(certPath, keyPath)
-		if errLoad != nil {
-			if errClose := listener.Close(); errClose != nil {
-				log.Errorf("failed to close listener after TLS key pair load failure: %v", errClose)
-			}
-			return fmt.Errorf("failed to start HTTPS server: %v", errLoad)
-		}
-
-		tlsConfig := &tls.Config{
-			Certificates: []tls.Certificate{certPair},
-			NextProtos:   []string{"h2", "http/1.1"},
+			return fmt.Errorf("failed to start HTTPS server: %v", errTLS)
 		}
 		s.server.TLSConfig = tlsConfig
 		if errHTTP2 := http2.ConfigureServer(s.server, &http2.Server{}); errHTTP2 != nil {
 			log.Warnf("failed to configure HTTP/2: %v", errHTTP2)
 		}
 		listener = tls.NewListener(listener, tlsConfig)
+		s.startACMEChallengeServer(s.cfg.TLS.ACME.HTTPChallengeAddr)
 		log.Debugf("Starting API server on %s with TLS", addr)
 	} else {
 		log.Debugf("Starting API server on %s", addr)
 	}
+	if errManagement := s.startManagementListener(s.server.TLSConfig); errManagement != nil {
+		if errClose := listener.Close(); errClose != nil {
+			log.Errorf("failed to close listener after management listener failure: %v", errClose)
+		}
+		return fmt.Errorf("failed to start management listener: %v", errManagement)
+	}
 
 	httpListener := newMuxListener(listener.Addr(), 1024)
 	s.muxBaseListener = listener
@@ -1859,6 +1958,7 @@ func (s *Server) Start() error {
 	go func() {
 		acceptErrCh <- s.acceptMuxConnections(listener, httpListener)
 	}()
+	notifyHandoffReady()
 
 	select {
 	case errServe := <-httpErrCh:
@@ -1902,11 +2002,11 @@ func (s *Server) Start() error {
 	}
 }
 
-// Stop gracefully shuts down the API server without interrupting any
-// active connections.
+// Stop gracefully shuts down the API server: new requests are refused while active
+// connections finish. Connections still open when ctx is done are closed.
 //
 // Parameters:
-//   - ctx: The context for graceful shutdown
+//   - ctx: The context bounding the drain
 //
 // Returns:
 //   - error: An error if the server fails to stop
@@ -1929,8 +2029,17 @@ func (s *Server) Stop(ctx context.Context) error {
 		}
 	}
 
-	// Shutdown the HTTP server.
+	s.stopACMEChallengeServer(ctx)
+	s.stopManagementListener(ctx)
+
+	// Shutdown waits for in-flight requests, SSE streams included, until ctx is done.
+	// Connections still active after that are cut.
+	s.draining.Store(true)
 	if err := s.server.Shutdown(ctx); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			log.Warn("drain deadline reached, closing remaining connections")
+			_ = s.server.Close()
+		}
 		return fmt.Errorf("failed to shutdown HTTP server: %v", err)
 	}
 
@@ -2099,11 +2208,19 @@ func (s *Server) UpdateClientsContext(ctx context.Context, cfg *config.Config) b
 		s.exampleAPIKeySafeModeActive.Store(true)
 	}
 	accessConfigApplied := s.applyAccessConfig(oldCfg, cfg)
+	s.applyTLSConfig(oldCfg, cfg)
+	if oldCfg != nil && strings.TrimSpace(oldCfg.RemoteManagement.Listen) != strings.TrimSpace(cfg.RemoteManagement.Listen) {
+		log.Warn("remote-management.listen changed; restart the server to apply")
+	}
 	if accessConfigApplied || exampleAPIKeySafeModeRequired {
 		s.exampleAPIKeySafeModeActive.Store(exampleAPIKeySafeModeRequired)
 	}
 	s.cfg = cfg
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
+	if s.requestLimits != nil {
+		limits := cfg.RequestLimits
+		s.requestLimits.Store(&limits)
+	}
 	if oldCfg != nil && s.wsAuthChanged != nil && oldCfg.WebsocketAuth != cfg.WebsocketAuth {
 		s.wsAuthChanged(oldCfg.WebsocketAuth, cfg.WebsocketAuth)
 	}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -1844,3 +1845,95 @@ func TestInteractionsRouteRegistered(t *testing.T) {
 		t.Fatalf("status = %d, want route registered; body=%s", rr.Code, rr.Body.String())
 	}
 }
+
+func TestServerStopDrainsInFlightRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	s := &Server{engine: engine}
+	engine.Use(s.drainMiddleware())
+	started := make(chan struct{})
+	release := make(chan struct{})
+	engine.GET("/slow", func(c *gin.Context) {
+		close(started)
+		<-release
+		c.String(http.StatusOK, "done")
+	})
+	engine.GET("/fast", func(c *gin.Context) { c.String(http.StatusOK, "ok") })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s.server = &http.Server{Handler: engine}
+	go func() { _ = s.server.Serve(listener) }()
+	baseURL := "http://" + listener.Addr().String()
+
+	slowResult := make(chan string, 1)
+	go func() {
+		resp, errGet := http.Get(baseURL + "/slow")
+		if errGet != nil {
+			slowResult <- errGet.Error()
+			return
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, _ := io.ReadAll(resp.Body)
+		slowResult <- string(body)
+	}()
+	<-started
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- s.Stop(context.Background()) }()
+	time.Sleep(50 * time.Millisecond)
+
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/fast", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Fatalf("request during drain: status %d, want 503", recorder.Code)
+	}
+	select {
+	case errStop := <-stopped:
+		t.Fatalf("Stop returned before the in-flight request finished: %v", errStop)
+	default:
+	}
+
+	close(release)
+	if got := <-slowResult; got != "done" {
+		t.Fatalf("in-flight request result = %q, want done", got)
+	}
+	if errStop := <-stopped; errStop != nil {
+		t.Fatalf("Stop: %v", errStop)
+	}
+}
+
+func TestPlaneHandlerSplitsManagementRoutes(t *testing.T) {
+	s := &Server{}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusNoContent) })
+	dataPlane, managementPlane := s.planeHandler(ok, false), s.planeHandler(ok, true)
+
+	serve := func(handler http.Handler, path string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec.Code
+	}
+	if got := serve(dataPlane, "/v0/management/config"); got != http.StatusNoContent {
+		t.Fatalf("without a management listener the main port must serve management routes, got %d", got)
+	}
+
+	s.managementListenerActive.Store(true)
+	cases := []struct {
+		handler http.Handler
+		path    string
+		want    int
+	}{
+		{dataPlane, "/v0/management/config", http.StatusNotFound},
+		{dataPlane, "/management.html", http.StatusNotFound},
+		{dataPlane, "/v1/chat/completions", http.StatusNoContent},
+		{managementPlane, "/v0/management/config", http.StatusNoContent},
+		{managementPlane, "/v1/chat/completions", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		if got := serve(tc.handler, tc.path); got != tc.want {
+			t.Fatalf("%s = %d, want %d", tc.path, got, tc.want)
+		}
+	}
+}
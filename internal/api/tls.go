@@ -0,0 +1,223 @@
+package api
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// certReloadCheckInterval bounds how often the certificate files are checked for changes.
+const certReloadCheckInterval = 5 * time.Second
+
+// certReloader serves a certificate pair from disk and reloads it when the files change,
+// so renewed certificates are picked up without a restart.
+type certReloader struct {
+	mu       sync.Mutex
+	certPath string
+	keyPath  string
+	cert     *tls.Certificate
+	certMod  time.Time
+	keyMod   time.Time
+	checked  time.Time
+}
+
+func newCertReloader(certPath, keyPath string) (*certReloader, error) {
+	r := &certReloader{}
+	if errLoad := r.setPaths(certPath, keyPath); errLoad != nil {
+		return nil, errLoad
+	}
+	return r, nil
+}
+
+// setPaths switches to a new certificate pair. The current certificate is kept when the
+// new pair cannot be loaded.
+func (r *certReloader) setPaths(certPath, keyPath string) error {
+	certPath, keyPath = strings.TrimSpace(certPath), strings.TrimSpace(keyPath)
+	if certPath == "" || keyPath == "" {
+		return fmt.Errorf("tls.cert or tls.key is empty")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cert != nil && certPath == r.certPath && keyPath == r.keyPath {
+		return nil
+	}
+	return r.loadLocked(certPath, keyPath)
+}
+
+func (r *certReloader) loadLocked(certPath, keyPath string) error {
+	certInfo, errCert := os.Stat(certPath)
+	if errCert != nil {
+		return errCert
+	}
+	keyInfo, errKey := os.Stat(keyPath)
+	if errKey != nil {
+		return errKey
+	}
+	pair, errLoad := tls.LoadX509KeyPair(certPath, keyPath)
+	if errLoad != nil {
+		return errLoad
+	}
+	r.certPath, r.keyPath = certPath, keyPath
+	r.cert = &pair
+	r.certMod, r.keyMod = certInfo.ModTime(), keyInfo.ModTime()
+	r.checked = time.Now()
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if time.Since(r.checked) >= certReloadCheckInterval {
+		r.checked = time.Now()
+		r.reloadIfChangedLocked()
+	}
+	return r.cert, nil
+}
+
+func (r *certReloader) reloadIfChangedLocked() {
+	certInfo, errCert := os.Stat(r.certPath)
+	keyInfo, errKey := os.Stat(r.keyPath)
+	if errCert != nil || errKey != nil {
+		return
+	}
+	if certInfo.ModTime().Equal(r.certMod) && keyInfo.ModTime().Equal(r.keyMod) {
+		return
+	}
+	if errLoad := r.loadLocked(r.certPath, r.keyPath); errLoad != nil {
+		// The files may be mid-rotation; keep serving the old pair and retry later.
+		log.Warnf("failed to reload TLS certificate, keeping the current one: %v", errLoad)
+		return
+	}
+	log.Infof("reloaded TLS certificate from %s", r.certPath)
+}
+
+// newACMEManager builds the autocert manager for cfg.
+func newACMEManager(cfg config.ACMEConfig, authDir string) (*autocert.Manager, error) {
+	domains := make([]string, 0, len(cfg.Domains))
+	for _, domain := range cfg.Domains {
+		if domain = strings.TrimSpace(domain); domain != "" {
+			domains = append(domains, domain)
+		}
+	}
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("tls.acme.domains is empty")
+	}
+	cacheDir := strings.TrimSpace(cfg.CacheDir)
+	if cacheDir == "" {
+		resolved, errResolve := util.ResolveAuthDir(authDir)
+		if errResolve != nil || strings.TrimSpace(resolved) == "" {
+			return nil, fmt.Errorf("tls.acme.cache-dir is empty and the auth dir cannot be resolved")
+		}
+		cacheDir = filepath.Join(resolved, "acme")
+	}
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      strings.TrimSpace(cfg.Email),
+	}
+	if directory := strings.TrimSpace(cfg.DirectoryURL); directory != "" {
+		manager.Client = &acme.Client{DirectoryURL: directory}
+	}
+	return manager, nil
+}
+
+// buildServerTLSConfig prepares the HTTPS configuration from cfg.TLS. With ACME enabled,
+// configured cert/key files still serve host names outside the ACME domains.
+func (s *Server) buildServerTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	certPath, keyPath := strings.TrimSpace(cfg.TLS.Cert), strings.TrimSpace(cfg.TLS.Key)
+	if certPath != "" || keyPath != "" || !cfg.TLS.ACME.Enable {
+		reloader, errReloader := newCertReloader(certPath, keyPath)
+		if errReloader != nil {
+			return nil, errReloader
+		}
+		s.certReloader = reloader
+	}
+	if !cfg.TLS.ACME.Enable {
+		return &tls.Config{
+			GetCertificate: s.certReloader.GetCertificate,
+			NextProtos:     []string{"h2", "http/1.1"},
+		}, nil
+	}
+
+	manager, errManager := newACMEManager(cfg.TLS.ACME, cfg.AuthDir)
+	if errManager != nil {
+		return nil, errManager
+	}
+	s.acmeManager = manager
+	tlsConfig := manager.TLSConfig()
+	if s.certReloader != nil {
+		reloader := s.certReloader
+		tlsConfig.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, errACME := manager.GetCertificate(hello)
+			if errACME == nil {
+				return cert, nil
+			}
+			return reloader.GetCertificate(hello)
+		}
+	}
+	return tlsConfig, nil
+}
+
+// startACMEChallengeServer serves HTTP-01 challenges when an address is configured.
+func (s *Server) startACMEChallengeServer(addr string) {
+	addr = strings.TrimSpace(addr)
+	if s.acmeManager == nil || addr == "" {
+		return
+	}
+	s.acmeHTTPServer = &http.Server{
+		Addr:              addr,
+		Handler:           s.acmeManager.HTTPHandler(nil),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func(server *http.Server) {
+		if errServe := server.ListenAndServe(); errServe != nil && !errors.Is(errServe, http.ErrServerClosed) {
+			log.Errorf("ACME HTTP challenge server on %s stopped: %v", addr, errServe)
+		}
+	}(s.acmeHTTPServer)
+	log.Infof("ACME HTTP challenge server listening on %s", addr)
+}
+
+func (s *Server) stopACMEChallengeServer(ctx context.Context) {
+	if s.acmeHTTPServer == nil {
+		return
+	}
+	if errShutdown := s.acmeHTTPServer.Shutdown(ctx); errShutdown != nil {
+		_ = s.acmeHTTPServer.Close()
+	}
+	s.acmeHTTPServer = nil
+}
+
+// applyTLSConfig follows certificate path changes at runtime. Switching between HTTP and
+// HTTPS, or toggling ACME, needs a restart.
+func (s *Server) applyTLSConfig(oldCfg, newCfg *config.Config) {
+	if oldCfg == nil || newCfg == nil {
+		return
+	}
+	if oldCfg.TLS.Enable != newCfg.TLS.Enable || oldCfg.TLS.ACME.Enable != newCfg.TLS.ACME.Enable {
+		log.Warn("tls.enable or tls.acme.enable changed; restart the server to apply")
+		return
+	}
+	if s.certReloader == nil || (oldCfg.TLS.Cert == newCfg.TLS.Cert && oldCfg.TLS.Key == newCfg.TLS.Key) {
+		return
+	}
+	if errPaths := s.certReloader.setPaths(newCfg.TLS.Cert, newCfg.TLS.Key); errPaths != nil {
+		log.Errorf("failed to load the new TLS certificate, keeping the current one: %v", errPaths)
+		return
+	}
+	log.Infof("TLS certificate switched to %s", strings.TrimSpace(newCfg.TLS.Cert))
+}
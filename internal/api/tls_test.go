@@ -0,0 +1,129 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// writeTestCertificate writes a self-signed certificate for commonName and returns its
+// certificate and key paths.
+func writeTestCertificate(t *testing.T, dir, commonName string) (string, string) {
+	t.Helper()
+
+	key, errKey := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if errKey != nil {
+		t.Fatalf("generate key: %v", errKey)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, errCert := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if errCert != nil {
+		t.Fatalf("create certificate: %v", errCert)
+	}
+	keyDER, errMarshal := x509.MarshalECPrivateKey(key)
+	if errMarshal != nil {
+		t.Fatalf("marshal key: %v", errMarshal)
+	}
+	certPath, keyPath := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if errWrite := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); errWrite != nil {
+		t.Fatalf("write certificate: %v", errWrite)
+	}
+	if errWrite := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); errWrite != nil {
+		t.Fatalf("write key: %v", errWrite)
+	}
+	return certPath, keyPath
+}
+
+func servedCommonName(t *testing.T, r *certReloader) string {
+	t.Helper()
+
+	cert, errCert := r.GetCertificate(nil)
+	if errCert != nil || cert == nil {
+		t.Fatalf("GetCertificate = %v, %v", cert, errCert)
+	}
+	leaf, errParse := x509.ParseCertificate(cert.Certificate[0])
+	if errParse != nil {
+		t.Fatalf("parse certificate: %v", errParse)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestCertReloaderPicksUpRenewedFiles(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := writeTestCertificate(t, dir, "first")
+	reloader, errReloader := newCertReloader(certPath, keyPath)
+	if errReloader != nil {
+		t.Fatalf("newCertReloader returned error: %v", errReloader)
+	}
+	if name := servedCommonName(t, reloader); name != "first" {
+		t.Fatalf("served %q, want first", name)
+	}
+
+	writeTestCertificate(t, dir, "second")
+	future := time.Now().Add(time.Minute)
+	for _, path := range []string{certPath, keyPath} {
+		if errTouch := os.Chtimes(path, future, future); errTouch != nil {
+			t.Fatalf("chtimes: %v", errTouch)
+		}
+	}
+	reloader.checked = time.Time{}
+	if name := servedCommonName(t, reloader); name != "second" {
+		t.Fatalf("served %q after renewal, want second", name)
+	}
+
+	// A broken pair is ignored and the current certificate stays in place.
+	if errWrite := os.WriteFile(certPath, []byte("garbage"), 0o600); errWrite != nil {
+		t.Fatalf("write: %v", errWrite)
+	}
+	past := future.Add(time.Minute)
+	_ = os.Chtimes(certPath, past, past)
+	reloader.checked = time.Time{}
+	if name := servedCommonName(t, reloader); name != "second" {
+		t.Fatalf("served %q after a failed reload, want second", name)
+	}
+	if errPaths := reloader.setPaths(certPath, filepath.Join(dir, "missing.pem")); errPaths == nil {
+		t.Fatal("setPaths accepted a missing key")
+	}
+}
+
+func TestBuildServerTLSConfigACME(t *testing.T) {
+	s := &Server{}
+	cfg := &config.Config{}
+	cfg.TLS.Enable = true
+	cfg.TLS.ACME.Enable = true
+	if _, errTLS := s.buildServerTLSConfig(cfg); errTLS == nil {
+		t.Fatal("expected an error without ACME domains")
+	}
+
+	cfg.TLS.ACME.Domains = []string{"proxy.example.com"}
+	cfg.TLS.ACME.CacheDir = t.TempDir()
+	tlsConfig, errTLS := s.buildServerTLSConfig(cfg)
+	if errTLS != nil {
+		t.Fatalf("buildServerTLSConfig returned error: %v", errTLS)
+	}
+	if s.acmeManager == nil || s.certReloader != nil || tlsConfig.GetCertificate == nil {
+		t.Fatal("ACME-only configuration should not load certificate files")
+	}
+	foundALPN := false
+	for _, proto := range tlsConfig.NextProtos {
+		foundALPN = foundALPN || proto == "acme-tls/1"
+	}
+	if !foundALPN {
+		t.Fatalf("NextProtos = %v, want the TLS-ALPN-01 protocol", tlsConfig.NextProtos)
+	}
+}
@@ -2,14 +2,13 @@ package codex
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"net"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/oauthcallback"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -17,14 +16,16 @@ import (
 // It listens for the authorization code response from the OAuth provider
 // and captures the necessary parameters to complete the authentication flow.
 type OAuthServer struct {
-	// server is the underlying HTTP server instance
-	server *http.Server
+	// registration is the login's place on the shared callback listener
+	registration *oauthcallback.Registration
+	// settings configure the shared callback listener
+	settings oauthcallback.Settings
+	// state is the state parameter of the login the server waits for
+	state string
 	// port is the port number on which the server listens
 	port int
 	// resultChan is a channel for sending OAuth results
 	resultChan chan *OAuthResult
-	// errorChan is a channel for sending OAuth errors
-	errorChan chan error
 	// mu is a mutex for protecting server state
 	mu sync.Mutex
 	// running indicates whether the server is currently running
@@ -56,13 +57,20 @@ func NewOAuthServer(port int) *OAuthServer {
 	return &OAuthServer{
 		port:       port,
 		resultChan: make(chan *OAuthResult, 1),
-		errorChan:  make(chan error, 1),
 	}
 }
 
+// Configure applies the callback listener settings and the state of the login the server
+// waits for, so concurrent logins sharing the listener each receive their own callback.
+func (s *OAuthServer) Configure(settings oauthcallback.Settings, state string) *OAuthServer {
+	s.settings = settings
+	s.state = state
+	return s
+}
+
 // Start starts the OAuth callback server.
-// It sets up the HTTP handlers for the callback and success endpoints,
-// and begins listening on the specified port.
+// It registers the callback handler on the shared callback listener for the
+// specified port, starting the listener when no other login uses it.
 //
 // Returns:
 //   - error: An error if the server fails to start
@@ -74,34 +82,13 @@ func (s *OAuthServer) Start() error {
 		return fmt.Errorf("server is already running")
 	}
 
-	// Check if port is available
-	if !s.isPortAvailable() {
-		return fmt.Errorf("port %d is already in use", s.port)
-	}
-
-	mux := http.NewServeMux()
-	mux.HandleFunc("/auth/callback", s.handleCallback)
-	mux.HandleFunc("/success", s.handleSuccess)
-
-	s.server = &http.Server{
-		Addr:         fmt.Sprintf(":%d", s.port),
-		Handler:      mux,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
+	registration, err := oauthcallback.Register(s.settings, s.port, "/auth/callback", s.state, http.HandlerFunc(s.handleCallback))
+	if err != nil {
+		return err
 	}
-
+	s.registration = registration
 	s.running = true
 
-	// Start server in goroutine
-	go func() {
-		if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-			s.errorChan <- fmt.Errorf("server failed to start: %w", err)
-		}
-	}()
-
-	// Give server a moment to start
-	time.Sleep(100 * time.Millisecond)
-
 	return nil
 }
 
@@ -117,7 +104,7 @@ func (s *OAuthServer) Stop(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	if !s.running || s.server == nil {
+	if !s.running || s.registration == nil {
 		return nil
 	}
 
@@ -127,9 +114,9 @@ func (s *OAuthServer) Stop(ctx context.Context) error {
 	shutdownCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
 	defer cancel()
 
-	err := s.server.Shutdown(shutdownCtx)
+	err := s.registration.Close(shutdownCtx)
 	s.running = false
-	s.server = nil
+	s.registration = nil
 
 	return err
 }
@@ -145,10 +132,16 @@ func (s *OAuthServer) Stop(ctx context.Context) error {
 //   - *OAuthResult: The OAuth result if successful
 //   - error: An error if the callback times out or an error occurs
 func (s *OAuthServer) WaitForCallback(timeout time.Duration) (*OAuthResult, error) {
+	var errCh <-chan error
+	s.mu.Lock()
+	if s.registration != nil {
+		errCh = s.registration.Err()
+	}
+	s.mu.Unlock()
 	select {
 	case result := <-s.resultChan:
 		return result, nil
-	case err := <-s.errorChan:
+	case err := <-errCh:
 		return nil, err
 	case <-time.After(timeout):
 		return nil, fmt.Errorf("timeout waiting for OAuth callback")
@@ -215,8 +208,8 @@ func (s *OAuthServer) handleCallback(w http.ResponseWriter, r *http.Request) {
 	}
 	s.sendResult(result)
 
-	// Redirect to success page
-	http.Redirect(w, r, "/success", http.StatusFound)
+	// Show the success page
+	s.handleSuccess(w, r)
 }
 
 // handleSuccess handles the success page endpoint.
@@ -228,6 +221,9 @@ func (s *OAuthServer) handleCallback(w http.ResponseWriter, r *http.Request) {
 func (s *OAuthServer) handleSuccess(w http.ResponseWriter, r *http.Request) {
 	log.Debug("Serving success page")
 
+	if s.settings.WriteSuccessPage(w) {
+		return
+	}
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
 
@@ -300,21 +296,14 @@ func (s *OAuthServer) sendResult(result *OAuthResult) {
 	}
 }
 
-// isPortAvailable checks if the specified port is available.
-// It attempts to listen on the port to determine availability.
-//
-// Returns:
-//   - bool: True if the port is available, false otherwise
-func (s *OAuthServer) isPortAvailable() bool {
-	addr := fmt.Sprintf(":%d", s.port)
-	listener, err := net.Listen("tcp", addr)
-	if err != nil {
-		return false
+// Port returns the port the server listens on, or the configured port before Start.
+func (s *OAuthServer) Port() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.registration != nil {
+		return s.registration.Port()
 	}
-	defer func() {
-		_ = listener.Close()
-	}()
-	return true
+	return s.port
 }
 
 // IsRunning returns whether the server is currently running.
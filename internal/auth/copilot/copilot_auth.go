@@ -286,6 +286,48 @@ func (e *CopilotModelEntry) Limits() *CopilotModelLimits {
 	return result
 }
 
+// CopilotModelSupports holds the feature flags returned by the Copilot /models API under
+// capabilities.supports.
+type CopilotModelSupports struct {
+	ToolCalls         bool
+	Vision            bool
+	StructuredOutputs bool
+	Streaming         bool
+}
+
+// Supports extracts the feature flags from the model's capabilities map.
+// Returns nil if the model does not report capabilities.supports.
+//
+// Expected Copilot API shape:
+//
+//	"capabilities": {
+//	    "supports": {
+//	        "tool_calls": true,
+//	        "vision": true,
+//	        "structured_outputs": true,
+//	        "streaming": true
+//	    }
+//	}
+func (e *CopilotModelEntry) Supports() *CopilotModelSupports {
+	if e.Capabilities == nil {
+		return nil
+	}
+	supportsMap, ok := e.Capabilities["supports"].(map[string]any)
+	if !ok {
+		return nil
+	}
+	flag := func(key string) bool {
+		value, _ := supportsMap[key].(bool)
+		return value
+	}
+	return &CopilotModelSupports{
+		ToolCalls:         flag("tool_calls"),
+		Vision:            flag("vision"),
+		StructuredOutputs: flag("structured_outputs"),
+		Streaming:         flag("streaming"),
+	}
+}
+
 // anyToInt converts a JSON-decoded numeric value to int.
 // Go's encoding/json decodes numbers into float64 when the target is any/interface{}.
 func anyToInt(v any) int {
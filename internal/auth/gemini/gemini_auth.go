@@ -7,13 +7,13 @@ package gemini
 import (
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/codex"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/oauthcallback"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/browser"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
@@ -52,6 +52,10 @@ type WebLoginOptions struct {
 	NoBrowser    bool
 	CallbackPort int
 	Prompt       func(string) (string, error)
+	// Headless prompts for the redirected URL at once instead of after a grace period.
+	Headless bool
+	// CallbackSettings configure the shared callback listener.
+	CallbackSettings oauthcallback.Settings
 }
 
 // NewGeminiAuth creates a new instance of GeminiAuth.
@@ -256,16 +260,23 @@ func (g *GeminiAuth) getTokenFromWeb(ctx context.Context, config *oauth2.Config,
 	}
 	callbackURL := fmt.Sprintf("http://localhost:%d/oauth2callback", callbackPort)
 
+	// Tie the callback to this login attempt and bind the code to a PKCE verifier.
+	state, errState := misc.GenerateRandomState()
+	if errState != nil {
+		return nil, fmt.Errorf("gemini state generation failed: %w", errState)
+	}
+	verifier := oauth2.GenerateVerifier()
+
 	// Use a channel to pass the authorization code from the HTTP handler to the main function.
 	codeChan := make(chan string, 1)
 	errChan := make(chan error, 1)
-
-	// Create a new HTTP server with its own multiplexer.
-	mux := http.NewServeMux()
-	server := &http.Server{Addr: fmt.Sprintf(":%d", callbackPort), Handler: mux}
 	config.RedirectURL = callbackURL
 
-	mux.HandleFunc("/oauth2callback", func(w http.ResponseWriter, r *http.Request) {
+	var callbackSettings oauthcallback.Settings
+	if opts != nil {
+		callbackSettings = opts.CallbackSettings
+	}
+	registration, errRegister := oauthcallback.Register(callbackSettings, callbackPort, "/oauth2callback", state, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if err := r.URL.Query().Get("error"); err != "" {
 			_, _ = fmt.Fprintf(w, "Authentication failed: %s", err)
 			select {
@@ -274,6 +285,11 @@ func (g *GeminiAuth) getTokenFromWeb(ctx context.Context, config *oauth2.Config,
 			}
 			return
 		}
+		if r.URL.Query().Get("state") != state {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = fmt.Fprint(w, "Authentication failed: state mismatch.")
+			return
+		}
 		code := r.URL.Query().Get("code")
 		if code == "" {
 			_, _ = fmt.Fprint(w, "Authentication failed: code not found.")
@@ -283,26 +299,28 @@ func (g *GeminiAuth) getTokenFromWeb(ctx context.Context, config *oauth2.Config,
 			}
 			return
 		}
-		_, _ = fmt.Fprint(w, "<html><body><h1>Authentication successful!</h1><p>You can close this window.</p></body></html>")
+		if !callbackSettings.WriteSuccessPage(w) {
+			_, _ = fmt.Fprint(w, "<html><body><h1>Authentication successful!</h1><p>You can close this window.</p></body></html>")
+		}
 		select {
 		case codeChan <- code:
 		default:
 		}
-	})
-
-	// Start the server in a goroutine.
-	go func() {
-		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
-			log.Errorf("ListenAndServe(): %v", err)
-			select {
-			case errChan <- err:
-			default:
-			}
+	}))
+	if errRegister != nil {
+		return nil, fmt.Errorf("failed to start callback server: %w", errRegister)
+	}
+	defer func() {
+		stopCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if errClose := registration.Close(stopCtx); errClose != nil {
+			log.Errorf("Failed to shut down callback server: %v", errClose)
 		}
 	}()
+	listenerErr := registration.Err()
 
 	// Open the authorization URL in the user's browser.
-	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline, oauth2.SetAuthURLParam("prompt", "consent"))
+	authURL := config.AuthCodeURL(state, oauth2.AccessTypeOffline, oauth2.SetAuthURLParam("prompt", "consent"), oauth2.S256ChallengeOption(verifier))
 
 	noBrowser := false
 	if opts != nil {
@@ -346,7 +364,11 @@ func (g *GeminiAuth) getTokenFromWeb(ctx context.Context, config *oauth2.Config,
 	var manualPromptTimer *time.Timer
 	var manualPromptC <-chan time.Time
 	if opts != nil && opts.Prompt != nil {
-		manualPromptTimer = time.NewTimer(15 * time.Second)
+		manualDelay := 15 * time.Second
+		if opts.Headless {
+			manualDelay = 0
+		}
+		manualPromptTimer = time.NewTimer(manualDelay)
 		manualPromptC = manualPromptTimer.C
 		defer manualPromptTimer.Stop()
 	}
@@ -362,6 +384,8 @@ waitForCallback:
 			break waitForCallback
 		case err := <-errChan:
 			return nil, err
+		case err := <-listenerErr:
+			return nil, err
 		case <-manualPromptC:
 			manualPromptC = nil
 			if manualPromptTimer != nil {
@@ -393,6 +417,9 @@ waitForCallback:
 			if parsed.Code == "" {
 				return nil, fmt.Errorf("code not found in callback")
 			}
+			if parsed.State != state {
+				return nil, fmt.Errorf("authentication failed: state mismatch")
+			}
 			authCode = parsed.Code
 			break waitForCallback
 		case errManual := <-manualInputErrCh:
@@ -402,13 +429,8 @@ waitForCallback:
 		}
 	}
 
-	// Shutdown the server.
-	if err := server.Shutdown(ctx); err != nil {
-		log.Errorf("Failed to shut down server: %v", err)
-	}
-
 	// Exchange the authorization code for a token.
-	token, err := config.Exchange(ctx, authCode)
+	token, err := config.Exchange(ctx, authCode, oauth2.VerifierOption(verifier))
 	if err != nil {
 		return nil, fmt.Errorf("failed to exchange token: %w", err)
 	}
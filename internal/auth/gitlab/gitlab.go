@@ -8,7 +8,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"net/url"
 	"strconv"
@@ -16,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/oauthcallback"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	log "github.com/sirupsen/logrus"
@@ -39,12 +39,13 @@ type OAuthResult struct {
 }
 
 type OAuthServer struct {
-	server     *http.Server
-	port       int
-	resultChan chan *OAuthResult
-	errorChan  chan error
-	mu         sync.Mutex
-	running    bool
+	registration *oauthcallback.Registration
+	settings     oauthcallback.Settings
+	state        string
+	port         int
+	resultChan   chan *OAuthResult
+	mu           sync.Mutex
+	running      bool
 }
 
 type TokenResponse struct {
@@ -144,10 +145,32 @@ func NewOAuthServer(port int) *OAuthServer {
 	return &OAuthServer{
 		port:       port,
 		resultChan: make(chan *OAuthResult, 1),
-		errorChan:  make(chan error, 1),
 	}
 }
 
+// Configure applies the callback listener settings and the state of the login the server
+// waits for. A port of 0 binds a free port; read it back with Port after Start.
+func (s *OAuthServer) Configure(settings oauthcallback.Settings, state string) *OAuthServer {
+	s.settings = settings
+	s.state = state
+	return s
+}
+
+// Port returns the port the server listens on, or the configured port before Start.
+func (s *OAuthServer) Port() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.registration != nil {
+		return s.registration.Port()
+	}
+	return s.port
+}
+
+// Path returns the callback path the server handles.
+func (s *OAuthServer) Path() string {
+	return s.settings.Path("gitlab", "/auth/callback")
+}
+
 func (s *OAuthServer) Start() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -155,49 +178,39 @@ func (s *OAuthServer) Start() error {
 	if s.running {
 		return fmt.Errorf("gitlab oauth server already running")
 	}
-	if !s.isPortAvailable() {
-		return fmt.Errorf("port %d is already in use", s.port)
-	}
-
-	mux := http.NewServeMux()
-	mux.HandleFunc("/auth/callback", s.handleCallback)
-
-	s.server = &http.Server{
-		Addr:         fmt.Sprintf(":%d", s.port),
-		Handler:      mux,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
+	registration, err := oauthcallback.Register(s.settings, s.port, s.Path(), s.state, http.HandlerFunc(s.handleCallback))
+	if err != nil {
+		return err
 	}
+	s.registration = registration
 	s.running = true
-
-	go func() {
-		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			s.errorChan <- err
-		}
-	}()
-
-	time.Sleep(100 * time.Millisecond)
 	return nil
 }
 
 func (s *OAuthServer) Stop(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if !s.running || s.server == nil {
+	if !s.running || s.registration == nil {
 		return nil
 	}
 	defer func() {
 		s.running = false
-		s.server = nil
+		s.registration = nil
 	}()
-	return s.server.Shutdown(ctx)
+	return s.registration.Close(ctx)
 }
 
 func (s *OAuthServer) WaitForCallback(timeout time.Duration) (*OAuthResult, error) {
+	var errCh <-chan error
+	s.mu.Lock()
+	if s.registration != nil {
+		errCh = s.registration.Err()
+	}
+	s.mu.Unlock()
 	select {
 	case result := <-s.resultChan:
 		return result, nil
-	case err := <-s.errorChan:
+	case err := <-errCh:
 		return nil, err
 	case <-time.After(timeout):
 		return nil, fmt.Errorf("timeout waiting for OAuth callback")
@@ -223,6 +236,9 @@ func (s *OAuthServer) handleCallback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	s.sendResult(&OAuthResult{Code: code, State: state})
+	if s.settings.WriteSuccessPage(w) {
+		return
+	}
 	_, _ = w.Write([]byte("GitLab authentication received. You can close this tab."))
 }
 
@@ -234,17 +250,12 @@ func (s *OAuthServer) sendResult(result *OAuthResult) {
 	}
 }
 
-func (s *OAuthServer) isPortAvailable() bool {
-	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", s.port))
-	if err != nil {
-		return false
-	}
-	_ = listener.Close()
-	return true
+func RedirectURL(port int) string {
+	return RedirectURLWithPath(port, "/auth/callback")
 }
 
-func RedirectURL(port int) string {
-	return fmt.Sprintf("http://localhost:%d/auth/callback", port)
+func RedirectURLWithPath(port int, path string) string {
+	return fmt.Sprintf("http://localhost:%d%s", port, path)
 }
 
 func (c *AuthClient) GenerateAuthURL(baseURL, clientID, redirectURI, state string, pkce *PKCECodes) (string, error) {
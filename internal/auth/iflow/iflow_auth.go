@@ -67,7 +67,12 @@ func NewIFlowAuth(cfg *config.Config) *IFlowAuth {
 // AuthorizationURL builds the authorization URL and matching redirect URI.
 // Parameter order matches official iFlow CLI: loginMethod, type, redirect, state, client_id
 func (ia *IFlowAuth) AuthorizationURL(state string, port int) (authURL, redirectURI string) {
-	redirectURI = fmt.Sprintf("http://localhost:%d/oauth2callback", port)
+	return ia.AuthorizationURLWithPath(state, port, "/oauth2callback")
+}
+
+// AuthorizationURLWithPath is AuthorizationURL for a callback served on path.
+func (ia *IFlowAuth) AuthorizationURLWithPath(state string, port int, path string) (authURL, redirectURI string) {
+	redirectURI = fmt.Sprintf("http://localhost:%d%s", port, path)
 
 	// Build URL with explicit parameter order to match iFlow CLI
 	params := fmt.Sprintf("loginMethod=phone&type=phone&redirect=%s&state=%s&client_id=%s",
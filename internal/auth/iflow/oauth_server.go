@@ -3,12 +3,12 @@ package iflow
 import (
 	"context"
 	"fmt"
-	"net"
 	"net/http"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/oauthcallback"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -23,23 +23,46 @@ type OAuthResult struct {
 
 // OAuthServer provides a minimal HTTP server for handling the iFlow OAuth callback.
 type OAuthServer struct {
-	server  *http.Server
-	port    int
-	result  chan *OAuthResult
-	errChan chan error
-	mu      sync.Mutex
-	running bool
+	registration *oauthcallback.Registration
+	settings     oauthcallback.Settings
+	state        string
+	port         int
+	result       chan *OAuthResult
+	mu           sync.Mutex
+	running      bool
 }
 
 // NewOAuthServer constructs a new OAuthServer bound to the provided port.
 func NewOAuthServer(port int) *OAuthServer {
 	return &OAuthServer{
-		port:    port,
-		result:  make(chan *OAuthResult, 1),
-		errChan: make(chan error, 1),
+		port:   port,
+		result: make(chan *OAuthResult, 1),
 	}
 }
 
+// Configure applies the callback listener settings and the state of the login the server
+// waits for. A port of 0 binds a free port; read it back with Port after Start.
+func (s *OAuthServer) Configure(settings oauthcallback.Settings, state string) *OAuthServer {
+	s.settings = settings
+	s.state = state
+	return s
+}
+
+// Port returns the port the server listens on, or the configured port before Start.
+func (s *OAuthServer) Port() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.registration != nil {
+		return s.registration.Port()
+	}
+	return s.port
+}
+
+// Path returns the callback path the server handles.
+func (s *OAuthServer) Path() string {
+	return s.settings.Path("iflow", "/oauth2callback")
+}
+
 // Start launches the callback listener.
 func (s *OAuthServer) Start() error {
 	s.mu.Lock()
@@ -47,29 +70,12 @@ func (s *OAuthServer) Start() error {
 	if s.running {
 		return fmt.Errorf("iflow oauth server already running")
 	}
-	if !s.isPortAvailable() {
-		return fmt.Errorf("port %d is already in use", s.port)
-	}
-
-	mux := http.NewServeMux()
-	mux.HandleFunc("/oauth2callback", s.handleCallback)
-
-	s.server = &http.Server{
-		Addr:         fmt.Sprintf(":%d", s.port),
-		Handler:      mux,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
+	registration, err := oauthcallback.Register(s.settings, s.port, s.Path(), s.state, http.HandlerFunc(s.handleCallback))
+	if err != nil {
+		return err
 	}
-
+	s.registration = registration
 	s.running = true
-
-	go func() {
-		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			s.errChan <- err
-		}
-	}()
-
-	time.Sleep(100 * time.Millisecond)
 	return nil
 }
 
@@ -77,22 +83,28 @@ func (s *OAuthServer) Start() error {
 func (s *OAuthServer) Stop(ctx context.Context) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	if !s.running || s.server == nil {
+	if !s.running || s.registration == nil {
 		return nil
 	}
 	defer func() {
 		s.running = false
-		s.server = nil
+		s.registration = nil
 	}()
-	return s.server.Shutdown(ctx)
+	return s.registration.Close(ctx)
 }
 
 // WaitForCallback blocks until a callback result, server error, or timeout occurs.
 func (s *OAuthServer) WaitForCallback(timeout time.Duration) (*OAuthResult, error) {
+	var errCh <-chan error
+	s.mu.Lock()
+	if s.registration != nil {
+		errCh = s.registration.Err()
+	}
+	s.mu.Unlock()
 	select {
 	case res := <-s.result:
 		return res, nil
-	case err := <-s.errChan:
+	case err := <-errCh:
 		return nil, err
 	case <-time.After(timeout):
 		return nil, fmt.Errorf("timeout waiting for OAuth callback")
@@ -121,6 +133,9 @@ func (s *OAuthServer) handleCallback(w http.ResponseWriter, r *http.Request) {
 
 	state := query.Get("state")
 	s.sendResult(&OAuthResult{Code: code, State: state})
+	if s.settings.WriteSuccessPage(w) {
+		return
+	}
 	http.Redirect(w, r, SuccessRedirectURL, http.StatusFound)
 }
 
@@ -131,13 +146,3 @@ func (s *OAuthServer) sendResult(res *OAuthResult) {
 		log.Debug("iflow oauth result channel full, dropping result")
 	}
 }
-
-func (s *OAuthServer) isPortAvailable() bool {
-	addr := fmt.Sprintf(":%d", s.port)
-	listener, err := net.Listen("tcp", addr)
-	if err != nil {
-		return false
-	}
-	_ = listener.Close()
-	return true
-}
@@ -0,0 +1,257 @@
+// Package oauthcallback runs the local HTTP listeners that receive OAuth redirects. A
+// listener is shared by every login flow bound to the same address, so flows of several
+// providers can run at once; each redirect goes to the flow registered for its path and
+// state.
+package oauthcallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Settings configure the callback listeners of OAuth logins.
+type Settings struct {
+	// Host is the bind address; empty listens on all interfaces.
+	Host string
+	// RandomPort lets logins whose redirect URI follows the listener use a free port.
+	RandomPort bool
+	// Paths overrides the callback path per provider, for providers whose redirect URI
+	// follows the listener.
+	Paths map[string]string
+	// SuccessTitle and SuccessMessage replace the provider page shown after a login.
+	SuccessTitle   string
+	SuccessMessage string
+}
+
+// FromConfig returns the callback settings of cfg.
+func FromConfig(cfg *config.Config) Settings {
+	if cfg == nil {
+		return Settings{}
+	}
+	return Settings{
+		Host:           strings.TrimSpace(cfg.OAuthCallback.Host),
+		RandomPort:     cfg.OAuthCallback.RandomPort,
+		Paths:          cfg.OAuthCallback.Paths,
+		SuccessTitle:   strings.TrimSpace(cfg.OAuthCallback.SuccessTitle),
+		SuccessMessage: strings.TrimSpace(cfg.OAuthCallback.SuccessMessage),
+	}
+}
+
+// Path returns the callback path configured for provider, or fallback.
+func (s Settings) Path(provider, fallback string) string {
+	path := strings.TrimSpace(s.Paths[provider])
+	if path == "" {
+		return fallback
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+// WriteSuccessPage writes the configured success page and reports whether one is configured.
+func (s Settings) WriteSuccessPage(w http.ResponseWriter) bool {
+	if s.SuccessTitle == "" && s.SuccessMessage == "" {
+		return false
+	}
+	title := s.SuccessTitle
+	if title == "" {
+		title = "Authentication Successful"
+	}
+	message := s.SuccessMessage
+	if message == "" {
+		message = "You can close this window and return to the terminal."
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprintf(w, "<!DOCTYPE html><html lang=\"en\"><head><meta charset=\"UTF-8\"><title>%s</title></head><body><h2>%s</h2><p>%s</p></body></html>",
+		html.EscapeString(title), html.EscapeString(title), html.EscapeString(message))
+	return true
+}
+
+// Registration is one login flow waiting on a shared listener.
+type Registration struct {
+	listener *listener
+	path     string
+	state    string
+	handler  http.Handler
+	errs     chan error
+}
+
+// Port returns the port the listener of r is bound to.
+func (r *Registration) Port() int {
+	return r.listener.port
+}
+
+// Err delivers a failure of the listener serving r.
+func (r *Registration) Err() <-chan error {
+	return r.errs
+}
+
+// Close removes r from its listener and shuts the listener down once no flow uses it.
+func (r *Registration) Close(ctx context.Context) error {
+	return r.listener.unregister(ctx, r)
+}
+
+type listener struct {
+	keys   []string
+	port   int
+	server *http.Server
+
+	mu     sync.Mutex
+	routes map[string][]*Registration
+}
+
+var (
+	listenersMu sync.Mutex
+	listeners   = make(map[string]*listener)
+)
+
+// Register adds a login flow to the listener on host:port of settings, starting one when
+// none runs. A port of 0 binds a free port, shared by every flow asking for one. The flow
+// receives requests on path whose state parameter matches state; requests without a
+// matching state go to the only flow on path, if there is exactly one.
+func Register(settings Settings, port int, path, state string, handler http.Handler) (*Registration, error) {
+	if handler == nil {
+		return nil, errors.New("oauth callback handler is required")
+	}
+	if path == "" || !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("invalid oauth callback path %q", path)
+	}
+	host := strings.TrimSpace(settings.Host)
+	key := net.JoinHostPort(host, strconv.Itoa(port))
+
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	l := listeners[key]
+	if l == nil {
+		started, errStart := startListener(host, port)
+		if errStart != nil {
+			return nil, errStart
+		}
+		l = started
+		l.keys = []string{key}
+		if port == 0 {
+			l.keys = append(l.keys, net.JoinHostPort(host, strconv.Itoa(l.port)))
+		}
+		for _, k := range l.keys {
+			listeners[k] = l
+		}
+	}
+
+	reg := &Registration{listener: l, path: path, state: state, handler: handler, errs: make(chan error, 1)}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, existing := range l.routes[path] {
+		if existing.state == state {
+			return nil, fmt.Errorf("oauth callback %s on port %d is already waiting for this login", path, l.port)
+		}
+	}
+	l.routes[path] = append(l.routes[path], reg)
+	return reg, nil
+}
+
+func startListener(host string, port int) (*listener, error) {
+	netListener, errListen := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if errListen != nil {
+		return nil, fmt.Errorf("failed to listen on port %d: %w", port, errListen)
+	}
+	l := &listener{
+		port:   netListener.Addr().(*net.TCPAddr).Port,
+		routes: make(map[string][]*Registration),
+	}
+	l.server = &http.Server{
+		Handler:      l,
+		ReadTimeout:  10 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+	go func() {
+		if errServe := l.server.Serve(netListener); errServe != nil && !errors.Is(errServe, http.ErrServerClosed) {
+			l.fail(errServe)
+		}
+	}()
+	return l, nil
+}
+
+func (l *listener) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	state := r.URL.Query().Get("state")
+	l.mu.Lock()
+	candidates := l.routes[r.URL.Path]
+	var target *Registration
+	for _, reg := range candidates {
+		if state != "" && reg.state == state {
+			target = reg
+			break
+		}
+	}
+	if target == nil && len(candidates) == 1 {
+		target = candidates[0]
+	}
+	l.mu.Unlock()
+	if target == nil {
+		http.Error(w, "No login is waiting for this callback", http.StatusNotFound)
+		return
+	}
+	target.handler.ServeHTTP(w, r)
+}
+
+func (l *listener) fail(err error) {
+	log.Errorf("oauth callback server on port %d failed: %v", l.port, err)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, regs := range l.routes {
+		for _, reg := range regs {
+			select {
+			case reg.errs <- fmt.Errorf("server failed: %w", err):
+			default:
+			}
+		}
+	}
+}
+
+func (l *listener) unregister(ctx context.Context, reg *Registration) error {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	l.mu.Lock()
+	regs := l.routes[reg.path]
+	for i := range regs {
+		if regs[i] == reg {
+			regs = append(regs[:i], regs[i+1:]...)
+			break
+		}
+	}
+	if len(regs) == 0 {
+		delete(l.routes, reg.path)
+	} else {
+		l.routes[reg.path] = regs
+	}
+	idle := len(l.routes) == 0
+	l.mu.Unlock()
+	if !idle {
+		return nil
+	}
+	for _, key := range l.keys {
+		if listeners[key] == l {
+			delete(listeners, key)
+		}
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return l.server.Shutdown(ctx)
+}
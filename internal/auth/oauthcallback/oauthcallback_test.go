@@ -0,0 +1,90 @@
+package oauthcallback
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegisterSharesListenerAcrossLogins(t *testing.T) {
+	settings := Settings{Host: "127.0.0.1"}
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = io.WriteString(w, name)
+		})
+	}
+
+	first, errFirst := Register(settings, 0, "/callback", "state-a", handler("a"))
+	if errFirst != nil {
+		t.Fatalf("Register(a) error = %v", errFirst)
+	}
+	second, errSecond := Register(settings, 0, "/callback", "state-b", handler("b"))
+	if errSecond != nil {
+		t.Fatalf("Register(b) error = %v", errSecond)
+	}
+	other, errOther := Register(settings, first.Port(), "/auth/callback", "state-c", handler("c"))
+	if errOther != nil {
+		t.Fatalf("Register(c) error = %v", errOther)
+	}
+	if second.Port() != first.Port() || other.Port() != first.Port() {
+		t.Fatalf("ports = %d, %d, %d; want one shared listener", first.Port(), second.Port(), other.Port())
+	}
+	if _, errDup := Register(settings, first.Port(), "/callback", "state-a", handler("dup")); errDup == nil {
+		t.Fatal("Register() accepted a second login with the same path and state")
+	}
+
+	get := func(path string) (int, string) {
+		t.Helper()
+		resp, errGet := http.Get(fmt.Sprintf("http://127.0.0.1:%d%s", first.Port(), path))
+		if errGet != nil {
+			t.Fatalf("GET %s: %v", path, errGet)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+	if _, body := get("/callback?code=x&state=state-b"); body != "b" {
+		t.Fatalf("state-b callback reached %q", body)
+	}
+	if _, body := get("/callback?code=x&state=state-a"); body != "a" {
+		t.Fatalf("state-a callback reached %q", body)
+	}
+	if _, body := get("/auth/callback?error=denied"); body != "c" {
+		t.Fatalf("callback without state on a single-login path reached %q", body)
+	}
+	if status, _ := get("/callback?code=x&state=unknown"); status != http.StatusNotFound {
+		t.Fatalf("unmatched callback status = %d, want 404", status)
+	}
+
+	port := first.Port()
+	for _, reg := range []*Registration{first, second, other} {
+		if errClose := reg.Close(context.Background()); errClose != nil {
+			t.Fatalf("Close() error = %v", errClose)
+		}
+	}
+	if _, errGet := http.Get(fmt.Sprintf("http://127.0.0.1:%d/callback", port)); errGet == nil {
+		t.Fatal("listener still serving after the last login closed")
+	}
+}
+
+func TestSettingsPathAndSuccessPage(t *testing.T) {
+	settings := Settings{Paths: map[string]string{"gitlab": "oauth/gitlab"}, SuccessTitle: "Signed in <ok>"}
+	if got := settings.Path("gitlab", "/auth/callback"); got != "/oauth/gitlab" {
+		t.Fatalf("Path(gitlab) = %q", got)
+	}
+	if got := settings.Path("iflow", "/oauth2callback"); got != "/oauth2callback" {
+		t.Fatalf("Path(iflow) = %q, want the provider default", got)
+	}
+
+	rec := httptest.NewRecorder()
+	if !settings.WriteSuccessPage(rec) || !strings.Contains(rec.Body.String(), "Signed in &lt;ok&gt;") {
+		t.Fatalf("WriteSuccessPage() body = %q", rec.Body.String())
+	}
+	if (Settings{}).WriteSuccessPage(httptest.NewRecorder()) {
+		t.Fatal("WriteSuccessPage() wrote a page without branding")
+	}
+}
@@ -0,0 +1,287 @@
+// Package authexpiry reports credentials that need a re-login: credentials without a
+// refresh token that expire soon, and credentials whose refresh keeps failing. A scheduled
+// job logs the report and POSTs it to a webhook so operators can act before an outage.
+package authexpiry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// Reasons a credential appears in a report.
+const (
+	ReasonExpired        = "expired"
+	ReasonExpiring       = "expiring"
+	ReasonRefreshFailing = "refresh_failing"
+)
+
+const (
+	defaultInterval         = 6 * time.Hour
+	defaultWithinDays       = 3
+	defaultFailureThreshold = 3
+	webhookTimeout          = 10 * time.Second
+)
+
+// Options select the credentials listed in a report.
+type Options struct {
+	// WithinDays lists credentials expiring within this many days.
+	WithinDays int
+	// FailureThreshold lists credentials with at least this many consecutive failed refreshes.
+	FailureThreshold int
+}
+
+// OptionsFromConfig returns the report options of cfg with defaults applied.
+func OptionsFromConfig(cfg config.ExpiryReportConfig) Options {
+	opts := Options{WithinDays: cfg.WithinDays, FailureThreshold: cfg.FailureThreshold}
+	if opts.WithinDays <= 0 {
+		opts.WithinDays = defaultWithinDays
+	}
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = defaultFailureThreshold
+	}
+	return opts
+}
+
+// Entry is one credential that needs attention.
+type Entry struct {
+	AuthID          string     `json:"auth_id"`
+	Provider        string     `json:"provider,omitempty"`
+	Label           string     `json:"label,omitempty"`
+	Reasons         []string   `json:"reasons"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	RefreshFailures int        `json:"refresh_failures,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+}
+
+// Report lists the credentials to re-login, most urgent first.
+type Report struct {
+	GeneratedAt      time.Time `json:"generated_at"`
+	WithinDays       int       `json:"within_days"`
+	FailureThreshold int       `json:"failure_threshold"`
+	Entries          []Entry   `json:"entries"`
+}
+
+// Build inspects auths and returns the report of those needing a re-login at now.
+// Disabled credentials are skipped.
+func Build(auths []*coreauth.Auth, opts Options, now time.Time) Report {
+	report := Report{
+		GeneratedAt:      now.UTC(),
+		WithinDays:       opts.WithinDays,
+		FailureThreshold: opts.FailureThreshold,
+		Entries:          []Entry{},
+	}
+	horizon := now.Add(time.Duration(opts.WithinDays) * 24 * time.Hour)
+	for _, auth := range auths {
+		if auth == nil || auth.Disabled || auth.Status == coreauth.StatusDisabled {
+			continue
+		}
+		entry := Entry{AuthID: auth.ID, Provider: auth.Provider, Label: auth.Label}
+		if expiry, ok := auth.ExpirationTime(); ok && !hasRefreshToken(auth) {
+			switch {
+			case !expiry.After(now):
+				entry.Reasons = append(entry.Reasons, ReasonExpired)
+			case opts.WithinDays > 0 && expiry.Before(horizon):
+				entry.Reasons = append(entry.Reasons, ReasonExpiring)
+			}
+			if len(entry.Reasons) > 0 {
+				expiresAt := expiry.UTC()
+				entry.ExpiresAt = &expiresAt
+			}
+		}
+		if opts.FailureThreshold > 0 && auth.RefreshFailures >= opts.FailureThreshold {
+			entry.Reasons = append(entry.Reasons, ReasonRefreshFailing)
+			entry.RefreshFailures = auth.RefreshFailures
+			if auth.LastError != nil {
+				entry.LastError = auth.LastError.Message
+			}
+		}
+		if len(entry.Reasons) > 0 {
+			report.Entries = append(report.Entries, entry)
+		}
+	}
+	sort.Slice(report.Entries, func(i, j int) bool {
+		a, b := report.Entries[i], report.Entries[j]
+		if (a.ExpiresAt == nil) != (b.ExpiresAt == nil) {
+			return a.ExpiresAt != nil
+		}
+		if a.ExpiresAt != nil && !a.ExpiresAt.Equal(*b.ExpiresAt) {
+			return a.ExpiresAt.Before(*b.ExpiresAt)
+		}
+		if a.RefreshFailures != b.RefreshFailures {
+			return a.RefreshFailures > b.RefreshFailures
+		}
+		return a.AuthID < b.AuthID
+	})
+	return report
+}
+
+// hasRefreshToken reports whether auth can renew its access token without a re-login.
+func hasRefreshToken(auth *coreauth.Auth) bool {
+	for _, meta := range []map[string]any{auth.Metadata, nestedToken(auth.Metadata)} {
+		for _, key := range []string{"refresh_token", "refreshToken"} {
+			if value, ok := meta[key].(string); ok && strings.TrimSpace(value) != "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func nestedToken(meta map[string]any) map[string]any {
+	if token, ok := meta["token"].(map[string]any); ok {
+		return token
+	}
+	return nil
+}
+
+// Reporter runs the scheduled report against an auth manager.
+type Reporter struct {
+	mu      sync.Mutex
+	cfg     config.ExpiryReportConfig
+	applied bool
+	auths   *coreauth.Manager
+	cancel  context.CancelFunc
+
+	client *http.Client
+	now    func() time.Time
+}
+
+var defaultReporter = NewReporter()
+
+// Default returns the process-wide reporter.
+func Default() *Reporter { return defaultReporter }
+
+// NewReporter creates a stopped reporter.
+func NewReporter() *Reporter {
+	return &Reporter{client: &http.Client{Timeout: webhookTimeout}}
+}
+
+// Configure applies the expiry-report settings, starting, restarting or stopping the
+// scheduled job when they or the manager change.
+func (r *Reporter) Configure(cfg config.ExpiryReportConfig, auths *coreauth.Manager) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.applied && r.auths == auths && reflect.DeepEqual(r.cfg, cfg) {
+		return
+	}
+	r.cfg, r.auths, r.applied = cfg, auths, true
+	if r.cancel != nil {
+		r.cancel()
+		r.cancel = nil
+		log.Info("expiry report stopped")
+	}
+	if !cfg.Enabled || auths == nil {
+		return
+	}
+	interval := defaultInterval
+	if raw := strings.TrimSpace(cfg.Interval); raw != "" {
+		parsed, errParse := time.ParseDuration(raw)
+		if errParse != nil || parsed <= 0 {
+			log.Warnf("invalid expiry-report.interval %q, using %s", raw, defaultInterval)
+		} else {
+			interval = parsed
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	go r.run(ctx, interval)
+	log.Infof("expiry report started (interval=%s)", interval)
+}
+
+func (r *Reporter) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		r.RunOnce()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Reporter) currentTime() time.Time {
+	if r.now != nil {
+		return r.now()
+	}
+	return time.Now()
+}
+
+// RunOnce builds a report from the configured manager, logs it and delivers it to the
+// webhook when it lists any credential.
+func (r *Reporter) RunOnce() Report {
+	if r == nil {
+		return Report{}
+	}
+	r.mu.Lock()
+	auths := r.auths
+	opts := OptionsFromConfig(r.cfg)
+	webhookURL := strings.TrimSpace(r.cfg.WebhookURL)
+	headers := r.cfg.WebhookHeaders
+	r.mu.Unlock()
+
+	var list []*coreauth.Auth
+	if auths != nil {
+		list = auths.List()
+	}
+	report := Build(list, opts, r.currentTime())
+
+	if len(report.Entries) == 0 {
+		log.Debug("expiry report: no credential needs a re-login")
+		return report
+	}
+	for _, entry := range report.Entries {
+		fields := log.Fields{"auth": entry.AuthID, "provider": entry.Provider, "reasons": strings.Join(entry.Reasons, ",")}
+		if entry.ExpiresAt != nil {
+			fields["expires_at"] = entry.ExpiresAt.Format(time.RFC3339)
+		}
+		if entry.RefreshFailures > 0 {
+			fields["refresh_failures"] = entry.RefreshFailures
+		}
+		log.WithFields(fields).Warn("expiry report: credential needs a re-login")
+	}
+	if webhookURL != "" {
+		go r.deliver(report, webhookURL, headers)
+	}
+	return report
+}
+
+func (r *Reporter) deliver(report Report, webhookURL string, headers map[string]string) {
+	body, errMarshal := json.Marshal(report)
+	if errMarshal != nil {
+		return
+	}
+	req, errReq := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(body))
+	if errReq != nil {
+		log.Warnf("expiry report: invalid webhook url: %v", errReq)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, errDo := r.client.Do(req)
+	if errDo != nil {
+		log.Warnf("expiry report: webhook delivery failed: %v", errDo)
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		log.Warnf("expiry report: webhook returned status %d", resp.StatusCode)
+	}
+}
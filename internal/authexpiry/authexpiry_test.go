@@ -0,0 +1,94 @@
+package authexpiry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestBuildListsExpiringAndFailingCredentials(t *testing.T) {
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	auths := []*coreauth.Auth{
+		{ID: "expiring", Provider: "kiro", Metadata: map[string]any{"expires_at": now.Add(48 * time.Hour).Format(time.RFC3339)}},
+		{ID: "expired", Provider: "kiro", Metadata: map[string]any{"expires_at": now.Add(-time.Hour).Format(time.RFC3339)}},
+		{ID: "later", Provider: "kiro", Metadata: map[string]any{"expires_at": now.Add(10 * 24 * time.Hour).Format(time.RFC3339)}},
+		{ID: "refreshable", Provider: "claude", Metadata: map[string]any{"expires_at": now.Add(time.Hour).Format(time.RFC3339), "refresh_token": "rt"}},
+		{ID: "failing", Provider: "codex", RefreshFailures: 4, LastError: &coreauth.Error{Message: "invalid_grant"}},
+		{ID: "flaky", Provider: "codex", RefreshFailures: 1},
+		{ID: "disabled", Provider: "kiro", Disabled: true, Metadata: map[string]any{"expires_at": now.Format(time.RFC3339)}},
+	}
+
+	report := Build(auths, Options{WithinDays: 3, FailureThreshold: 3}, now)
+
+	var ids []string
+	for _, entry := range report.Entries {
+		ids = append(ids, entry.AuthID)
+	}
+	want := []string{"expired", "expiring", "failing"}
+	if len(ids) != len(want) {
+		t.Fatalf("entries = %v, want %v", ids, want)
+	}
+	for i := range want {
+		if ids[i] != want[i] {
+			t.Fatalf("entries = %v, want %v", ids, want)
+		}
+	}
+	if got := report.Entries[0].Reasons; len(got) != 1 || got[0] != ReasonExpired {
+		t.Fatalf("expired reasons = %v", got)
+	}
+	if got := report.Entries[1].Reasons; len(got) != 1 || got[0] != ReasonExpiring {
+		t.Fatalf("expiring reasons = %v", got)
+	}
+	failing := report.Entries[2]
+	if failing.RefreshFailures != 4 || failing.LastError != "invalid_grant" || failing.Reasons[0] != ReasonRefreshFailing {
+		t.Fatalf("failing entry = %+v", failing)
+	}
+}
+
+func TestOptionsFromConfigDefaults(t *testing.T) {
+	opts := OptionsFromConfig(config.ExpiryReportConfig{})
+	if opts.WithinDays != defaultWithinDays || opts.FailureThreshold != defaultFailureThreshold {
+		t.Fatalf("options = %+v", opts)
+	}
+}
+
+func TestRunOnceDeliversWebhook(t *testing.T) {
+	received := make(chan Report, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		var report Report
+		if errDecode := json.NewDecoder(r.Body).Decode(&report); errDecode != nil {
+			t.Errorf("decode report: %v", errDecode)
+		}
+		received <- report
+	}))
+	defer server.Close()
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	if _, errRegister := manager.Register(context.Background(), &coreauth.Auth{ID: "failing", Provider: "codex", RefreshFailures: 5}); errRegister != nil {
+		t.Fatalf("register auth: %v", errRegister)
+	}
+	reporter := NewReporter()
+	reporter.auths = manager
+	reporter.cfg = config.ExpiryReportConfig{WebhookURL: server.URL, WebhookHeaders: map[string]string{"Authorization": "Bearer token"}}
+
+	if report := reporter.RunOnce(); len(report.Entries) != 1 {
+		t.Fatalf("entries = %+v, want one", report.Entries)
+	}
+	select {
+	case report := <-received:
+		if len(report.Entries) != 1 || report.Entries[0].AuthID != "failing" {
+			t.Fatalf("webhook report = %+v", report)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+}
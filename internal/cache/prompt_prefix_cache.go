@@ -0,0 +1,185 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// PromptPrefixTTL is how long a prompt prefix stays eligible for matching after it was last seen.
+	PromptPrefixTTL = 10 * time.Minute
+
+	// promptPrefixMaxEntries bounds the prefix hashes tracked per scope.
+	promptPrefixMaxEntries = 4096
+)
+
+// promptPrefixScope tracks cumulative prefix hashes seen in one scope (provider, credential, model).
+type promptPrefixScope struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// promptPrefixScopes maps scope -> *promptPrefixScope.
+var promptPrefixScopes sync.Map
+
+// geminiCachedContents maps a prefix key to the cachedContents resource created for it.
+var (
+	geminiCachedContents   = make(map[string]geminiCachedContent)
+	geminiCachedContentsMu sync.Mutex
+)
+
+type geminiCachedContent struct {
+	name   string
+	expire time.Time
+}
+
+// PromptPrefixKey returns a stable key for the first n blocks within scope.
+func PromptPrefixKey(scope string, blocks [][]byte, n int) string {
+	hashes := promptPrefixHashes(scope, blocks[:n])
+	if len(hashes) == 0 {
+		return ""
+	}
+	return hashes[len(hashes)-1]
+}
+
+// SharedPromptPrefix records the prefixes of blocks for scope and returns how many leading
+// blocks match a prefix seen in an earlier request of the same scope (0 when none).
+func SharedPromptPrefix(scope string, blocks [][]byte) int {
+	if scope == "" || len(blocks) == 0 {
+		return 0
+	}
+	hashes := promptPrefixHashes(scope, blocks)
+	value, _ := promptPrefixScopes.LoadOrStore(scope, &promptPrefixScope{seen: make(map[string]time.Time)})
+	tracked := value.(*promptPrefixScope)
+
+	now := time.Now()
+	tracked.mu.Lock()
+	defer tracked.mu.Unlock()
+	shared := 0
+	for i, hash := range hashes {
+		expire, ok := tracked.seen[hash]
+		if !ok || !expire.After(now) {
+			break
+		}
+		shared = i + 1
+	}
+	if len(tracked.seen)+len(hashes) > promptPrefixMaxEntries {
+		for hash, expire := range tracked.seen {
+			if !expire.After(now) {
+				delete(tracked.seen, hash)
+			}
+		}
+		if len(tracked.seen)+len(hashes) > promptPrefixMaxEntries {
+			tracked.seen = make(map[string]time.Time)
+		}
+	}
+	for _, hash := range hashes {
+		tracked.seen[hash] = now.Add(PromptPrefixTTL)
+	}
+	return shared
+}
+
+// promptPrefixHashes returns the chained hash of every prefix blocks[:i+1].
+func promptPrefixHashes(scope string, blocks [][]byte) []string {
+	hashes := make([]string, 0, len(blocks))
+	prev := sha256.Sum256([]byte(scope))
+	for _, block := range blocks {
+		hasher := sha256.New()
+		hasher.Write(prev[:])
+		hasher.Write(block)
+		copy(prev[:], hasher.Sum(nil))
+		hashes = append(hashes, hex.EncodeToString(prev[:]))
+	}
+	return hashes
+}
+
+// GetGeminiCachedContent returns the cachedContents name stored for key, if not expired.
+func GetGeminiCachedContent(key string) (string, bool) {
+	geminiCachedContentsMu.Lock()
+	defer geminiCachedContentsMu.Unlock()
+	entry, ok := geminiCachedContents[key]
+	if !ok {
+		return "", false
+	}
+	if !entry.expire.After(time.Now()) {
+		delete(geminiCachedContents, key)
+		return "", false
+	}
+	return entry.name, true
+}
+
+// SetGeminiCachedContent stores the cachedContents name created for key until expire.
+func SetGeminiCachedContent(key, name string, expire time.Time) {
+	geminiCachedContentsMu.Lock()
+	defer geminiCachedContentsMu.Unlock()
+	now := time.Now()
+	for k, entry := range geminiCachedContents {
+		if !entry.expire.After(now) {
+			delete(geminiCachedContents, k)
+		}
+	}
+	geminiCachedContents[key] = geminiCachedContent{name: name, expire: expire}
+}
+
+// PromptCacheStats reports prompt-prefix caching activity for one provider.
+type PromptCacheStats struct {
+	Provider string `json:"provider"`
+	// HintedRequests counts requests that received an automatic cache hint.
+	HintedRequests int64 `json:"hinted_requests"`
+	// HintedPrefixTokens is the estimated size of the prefixes marked for caching.
+	HintedPrefixTokens int64 `json:"hinted_prefix_tokens"`
+	// CacheHitTokens are input tokens the provider reported as served from its cache.
+	CacheHitTokens int64 `json:"cache_hit_tokens"`
+	// CacheWriteTokens are input tokens the provider reported as written to its cache.
+	CacheWriteTokens int64 `json:"cache_write_tokens"`
+}
+
+var (
+	promptCacheStats   = make(map[string]*PromptCacheStats)
+	promptCacheStatsMu sync.Mutex
+)
+
+func promptCacheStatsLocked(provider string) *PromptCacheStats {
+	stats, ok := promptCacheStats[provider]
+	if !ok {
+		stats = &PromptCacheStats{Provider: provider}
+		promptCacheStats[provider] = stats
+	}
+	return stats
+}
+
+// RecordPromptCacheHint counts a request that received a cache hint covering prefixTokens.
+func RecordPromptCacheHint(provider string, prefixTokens int64) {
+	promptCacheStatsMu.Lock()
+	stats := promptCacheStatsLocked(provider)
+	stats.HintedRequests++
+	stats.HintedPrefixTokens += prefixTokens
+	promptCacheStatsMu.Unlock()
+}
+
+// RecordPromptCacheUsage accumulates provider-reported cache read and write tokens.
+func RecordPromptCacheUsage(provider string, hitTokens, writeTokens int64) {
+	if hitTokens <= 0 && writeTokens <= 0 {
+		return
+	}
+	promptCacheStatsMu.Lock()
+	stats := promptCacheStatsLocked(provider)
+	stats.CacheHitTokens += max(hitTokens, 0)
+	stats.CacheWriteTokens += max(writeTokens, 0)
+	promptCacheStatsMu.Unlock()
+}
+
+// PromptCacheStatsSnapshot returns per-provider prompt cache counters sorted by provider.
+func PromptCacheStatsSnapshot() []PromptCacheStats {
+	promptCacheStatsMu.Lock()
+	out := make([]PromptCacheStats, 0, len(promptCacheStats))
+	for _, stats := range promptCacheStats {
+		out = append(out, *stats)
+	}
+	promptCacheStatsMu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Provider < out[j].Provider })
+	return out
+}
@@ -0,0 +1,36 @@
+package cache
+
+import "testing"
+
+func TestSharedPromptPrefix(t *testing.T) {
+	scope := "test\x00shared-prefix"
+	first := [][]byte{[]byte("system"), []byte("a"), []byte("b")}
+	if got := SharedPromptPrefix(scope, first); got != 0 {
+		t.Fatalf("first request shared = %d, want 0", got)
+	}
+	second := [][]byte{[]byte("system"), []byte("a"), []byte("c")}
+	if got := SharedPromptPrefix(scope, second); got != 2 {
+		t.Fatalf("second request shared = %d, want 2", got)
+	}
+	if got := SharedPromptPrefix("other", second); got != 0 {
+		t.Fatalf("prefix leaked across scopes: %d", got)
+	}
+	if PromptPrefixKey(scope, first, 2) != PromptPrefixKey(scope, second, 2) {
+		t.Fatal("prefix keys differ for identical prefixes")
+	}
+}
+
+func TestRecordPromptCacheUsage(t *testing.T) {
+	RecordPromptCacheHint("test-provider", 100)
+	RecordPromptCacheUsage("test-provider", 80, 20)
+	for _, stats := range PromptCacheStatsSnapshot() {
+		if stats.Provider != "test-provider" {
+			continue
+		}
+		if stats.HintedRequests != 1 || stats.HintedPrefixTokens != 100 || stats.CacheHitTokens != 80 || stats.CacheWriteTokens != 20 {
+			t.Fatalf("unexpected stats: %+v", stats)
+		}
+		return
+	}
+	t.Fatal("provider missing from snapshot")
+}
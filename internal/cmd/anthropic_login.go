@@ -33,6 +33,7 @@ func DoClaudeLogin(cfg *config.Config, options *LoginOptions) {
 
 	authOpts := &sdkAuth.LoginOptions{
 		NoBrowser:    options.NoBrowser,
+		Headless:     options.Headless,
 		CallbackPort: options.CallbackPort,
 		Metadata:     map[string]string{},
 		Prompt:       promptFn,
@@ -23,6 +23,7 @@ func DoAntigravityLogin(cfg *config.Config, options *LoginOptions) {
 	manager := newAuthManager()
 	authOpts := &sdkAuth.LoginOptions{
 		NoBrowser:    options.NoBrowser,
+		Headless:     options.Headless,
 		CallbackPort: options.CallbackPort,
 		Metadata:     map[string]string{},
 		Prompt:       promptFn,
@@ -0,0 +1,424 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/scrypt"
+)
+
+// AuthBundlePassphraseEnv holds the bundle passphrase when -auth-bundle-passphrase is not given.
+const AuthBundlePassphraseEnv = "CLIPROXY_AUTH_BUNDLE_PASSPHRASE"
+
+// Import conflict modes for auth files that already exist in the auth directory.
+const (
+	AuthImportSkip      = "skip"
+	AuthImportOverwrite = "overwrite"
+	AuthImportRename    = "rename"
+)
+
+const (
+	authBundleVersion = 1
+	authBundleKDF     = "scrypt"
+	authBundleScryptN = 1 << 15
+	authBundleScryptR = 8
+	authBundleScryptP = 1
+)
+
+// authBundle is the portable export format: plaintext auth files keyed by file name.
+type authBundle struct {
+	Version   int              `json:"version"`
+	CreatedAt string           `json:"created_at"`
+	Files     []authBundleFile `json:"files"`
+}
+
+type authBundleFile struct {
+	Name    string          `json:"name"`
+	Content json.RawMessage `json:"content"`
+}
+
+// sealedAuthBundle is an authBundle encrypted with a key derived from a passphrase.
+type sealedAuthBundle struct {
+	Version    int    `json:"version"`
+	Encrypted  bool   `json:"encrypted"`
+	KDF        string `json:"kdf"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// AuthBundlePassphrase returns flagValue, or the passphrase from AuthBundlePassphraseEnv.
+func AuthBundlePassphrase(flagValue string) string {
+	if strings.TrimSpace(flagValue) != "" {
+		return flagValue
+	}
+	return os.Getenv(AuthBundlePassphraseEnv)
+}
+
+// DoExportAuthFiles writes the selected auth files (all when names is empty) of the auth
+// directory to a bundle at outPath, encrypted when passphrase is set. Files encrypted at
+// rest are exported in plaintext form so the bundle does not depend on the local key.
+func DoExportAuthFiles(cfg *config.Config, outPath string, names []string, passphrase string) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	authDir, errResolve := util.ResolveAuthDir(cfg.AuthDir)
+	if errResolve != nil {
+		log.Errorf("auth export: resolve auth dir: %v", errResolve)
+		return
+	}
+	var metadataCipher coreauth.MetadataCipher
+	if cfg.AuthEncryption.Enabled {
+		c, errCipher := AuthEncryptionCipher(cfg)
+		if errCipher != nil {
+			log.Errorf("auth export: %v", errCipher)
+			return
+		}
+		metadataCipher = c
+	}
+	data, count, errBuild := buildAuthBundle(authDir, names, passphrase, metadataCipher)
+	if errBuild != nil {
+		log.Errorf("auth export: %v", errBuild)
+		return
+	}
+	if errWrite := os.WriteFile(outPath, data, 0o600); errWrite != nil {
+		log.Errorf("auth export: write %s: %v", outPath, errWrite)
+		return
+	}
+	state := "unencrypted"
+	if passphrase != "" {
+		state = "encrypted"
+	}
+	log.Infof("auth export: wrote %d auth file(s) to %s (%s)", count, outPath, state)
+}
+
+// DoImportAuthFiles adds the auth files of the bundle at inPath to the auth directory.
+// Existing files are handled per conflict (skip, overwrite or rename); identical files are
+// left alone. Files are encrypted at rest when auth-encryption is enabled.
+func DoImportAuthFiles(cfg *config.Config, inPath string, passphrase string, conflict string) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	authDir, errResolve := util.ResolveAuthDir(cfg.AuthDir)
+	if errResolve != nil {
+		log.Errorf("auth import: resolve auth dir: %v", errResolve)
+		return
+	}
+	var metadataCipher coreauth.MetadataCipher
+	if cfg.AuthEncryption.Enabled {
+		c, errCipher := AuthEncryptionCipher(cfg)
+		if errCipher != nil {
+			log.Errorf("auth import: %v", errCipher)
+			return
+		}
+		metadataCipher = c
+	}
+	data, errRead := os.ReadFile(inPath)
+	if errRead != nil {
+		log.Errorf("auth import: read %s: %v", inPath, errRead)
+		return
+	}
+	results, errImport := importAuthBundle(authDir, data, passphrase, conflict, metadataCipher)
+	if errImport != nil {
+		log.Errorf("auth import: %v", errImport)
+		return
+	}
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.action]++
+		if result.err != nil {
+			log.Errorf("auth import: %s: %v", result.name, result.err)
+			continue
+		}
+		if result.action == "renamed" {
+			log.Infof("auth import: %s: renamed to %s", result.name, result.target)
+			continue
+		}
+		log.Infof("auth import: %s: %s", result.name, result.action)
+	}
+	log.Infof("auth import: %d imported, %d overwritten, %d renamed, %d skipped, %d unchanged, %d failed in %s",
+		counts["imported"], counts["overwritten"], counts["renamed"], counts["skipped"], counts["unchanged"], counts["failed"], authDir)
+}
+
+// readAuthFileMetadata returns the plaintext metadata of an auth file, or nil when the
+// file is not an auth file.
+func readAuthFileMetadata(path string, metadataCipher coreauth.MetadataCipher) (map[string]any, error) {
+	data, errRead := os.ReadFile(path)
+	if errRead != nil {
+		return nil, errRead
+	}
+	var metadata map[string]any
+	if errUnmarshal := json.Unmarshal(data, &metadata); errUnmarshal != nil {
+		return nil, nil
+	}
+	if _, ok := metadata["type"].(string); !ok {
+		return nil, nil
+	}
+	if !coreauth.IsEncryptedMetadata(metadata) {
+		return metadata, nil
+	}
+	if metadataCipher == nil {
+		return nil, errors.New("file is encrypted but auth-encryption is not enabled")
+	}
+	return coreauth.DecryptMetadata(metadataCipher, metadata)
+}
+
+func buildAuthBundle(authDir string, names []string, passphrase string, metadataCipher coreauth.MetadataCipher) ([]byte, int, error) {
+	explicit := len(names) > 0
+	if !explicit {
+		entries, errRead := os.ReadDir(authDir)
+		if errRead != nil {
+			return nil, 0, errRead
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(strings.ToLower(entry.Name()), ".json") {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+	bundle := authBundle{Version: authBundleVersion, CreatedAt: time.Now().UTC().Format(time.RFC3339)}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if !validAuthBundleName(name) {
+			return nil, 0, fmt.Errorf("invalid auth file name %q", name)
+		}
+		metadata, errMeta := readAuthFileMetadata(filepath.Join(authDir, name), metadataCipher)
+		if errMeta != nil {
+			return nil, 0, fmt.Errorf("%s: %w", name, errMeta)
+		}
+		if metadata == nil {
+			if explicit {
+				log.Warnf("auth export: %s is not an auth file, skipped", name)
+			}
+			continue
+		}
+		content, errMarshal := json.Marshal(metadata)
+		if errMarshal != nil {
+			return nil, 0, fmt.Errorf("%s: %w", name, errMarshal)
+		}
+		bundle.Files = append(bundle.Files, authBundleFile{Name: name, Content: content})
+	}
+	if len(bundle.Files) == 0 {
+		return nil, 0, errors.New("no auth files to export")
+	}
+	data, errMarshal := json.MarshalIndent(bundle, "", "  ")
+	if errMarshal != nil {
+		return nil, 0, errMarshal
+	}
+	if passphrase == "" {
+		return data, len(bundle.Files), nil
+	}
+	sealed, errSeal := sealAuthBundle(data, passphrase)
+	if errSeal != nil {
+		return nil, 0, errSeal
+	}
+	return sealed, len(bundle.Files), nil
+}
+
+type authImportResult struct {
+	name   string
+	action string
+	target string
+	err    error
+}
+
+func importAuthBundle(authDir string, data []byte, passphrase string, conflict string, metadataCipher coreauth.MetadataCipher) ([]authImportResult, error) {
+	switch conflict {
+	case "":
+		conflict = AuthImportSkip
+	case AuthImportSkip, AuthImportOverwrite, AuthImportRename:
+	default:
+		return nil, fmt.Errorf("unknown conflict mode %q (want skip, overwrite or rename)", conflict)
+	}
+	var sealed sealedAuthBundle
+	if errUnmarshal := json.Unmarshal(data, &sealed); errUnmarshal != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", errUnmarshal)
+	}
+	if sealed.Encrypted {
+		if passphrase == "" {
+			return nil, errors.New("bundle is encrypted; a passphrase is required")
+		}
+		opened, errOpen := openAuthBundle(sealed, passphrase)
+		if errOpen != nil {
+			return nil, errOpen
+		}
+		data = opened
+	}
+	var bundle authBundle
+	if errUnmarshal := json.Unmarshal(data, &bundle); errUnmarshal != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", errUnmarshal)
+	}
+	if bundle.Version != authBundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", bundle.Version)
+	}
+	if errMkdir := os.MkdirAll(authDir, 0o700); errMkdir != nil {
+		return nil, errMkdir
+	}
+
+	results := make([]authImportResult, 0, len(bundle.Files))
+	for _, file := range bundle.Files {
+		action, target, errFile := importAuthBundleFile(authDir, file, conflict, metadataCipher)
+		if errFile != nil {
+			action = "failed"
+		}
+		results = append(results, authImportResult{name: file.Name, action: action, target: target, err: errFile})
+	}
+	return results, nil
+}
+
+// importAuthBundleFile writes one bundled auth file and returns what was done and the
+// name of the file written.
+func importAuthBundleFile(authDir string, file authBundleFile, conflict string, metadataCipher coreauth.MetadataCipher) (string, string, error) {
+	if !validAuthBundleName(file.Name) {
+		return "", "", fmt.Errorf("invalid file name")
+	}
+	var metadata map[string]any
+	if errUnmarshal := json.Unmarshal(file.Content, &metadata); errUnmarshal != nil {
+		return "", "", fmt.Errorf("invalid auth json: %w", errUnmarshal)
+	}
+	if authType, ok := metadata["type"].(string); !ok || strings.TrimSpace(authType) == "" {
+		return "", "", errors.New("auth json has no type")
+	}
+
+	target := filepath.Join(authDir, file.Name)
+	action := "imported"
+	if _, errStat := os.Stat(target); errStat == nil {
+		existing, errExisting := readAuthFileMetadata(target, metadataCipher)
+		if errExisting == nil && existing != nil && authMetadataEqual(existing, metadata) {
+			return "unchanged", file.Name, nil
+		}
+		switch conflict {
+		case AuthImportSkip:
+			return "skipped", file.Name, nil
+		case AuthImportOverwrite:
+			action = "overwritten"
+		case AuthImportRename:
+			target = availableAuthFileName(authDir, file.Name)
+			action = "renamed"
+		}
+	} else if !errors.Is(errStat, os.ErrNotExist) {
+		return "", "", errStat
+	}
+
+	out := metadata
+	if metadataCipher != nil {
+		encrypted, errEncrypt := coreauth.EncryptMetadata(metadataCipher, metadata)
+		if errEncrypt != nil {
+			return "", "", errEncrypt
+		}
+		out = encrypted
+	}
+	raw, errMarshal := json.Marshal(out)
+	if errMarshal != nil {
+		return "", "", errMarshal
+	}
+	tmp := target + ".tmp"
+	if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
+		return "", "", errWrite
+	}
+	if errRename := os.Rename(tmp, target); errRename != nil {
+		_ = os.Remove(tmp)
+		return "", "", errRename
+	}
+	return action, filepath.Base(target), nil
+}
+
+// validAuthBundleName accepts plain .json file names so a bundle cannot write outside the auth directory.
+func validAuthBundleName(name string) bool {
+	return name != "" && filepath.Base(name) == name && !strings.ContainsAny(name, `/\`) &&
+		name != "." && name != ".." && strings.HasSuffix(strings.ToLower(name), ".json")
+}
+
+func availableAuthFileName(authDir, name string) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	for i := 2; ; i++ {
+		candidate := filepath.Join(authDir, base+"-"+strconv.Itoa(i)+ext)
+		if _, errStat := os.Stat(candidate); errors.Is(errStat, os.ErrNotExist) {
+			return candidate
+		}
+	}
+}
+
+func authMetadataEqual(a, b map[string]any) bool {
+	rawA, errA := json.Marshal(a)
+	rawB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(rawA, rawB)
+}
+
+func authBundleKey(passphrase string, salt []byte) ([]byte, error) {
+	return scrypt.Key([]byte(passphrase), salt, authBundleScryptN, authBundleScryptR, authBundleScryptP, 32)
+}
+
+func sealAuthBundle(plaintext []byte, passphrase string) ([]byte, error) {
+	salt := make([]byte, 16)
+	if _, errRand := rand.Read(salt); errRand != nil {
+		return nil, errRand
+	}
+	key, errKey := authBundleKey(passphrase, salt)
+	if errKey != nil {
+		return nil, errKey
+	}
+	block, errBlock := aes.NewCipher(key)
+	if errBlock != nil {
+		return nil, errBlock
+	}
+	gcm, errGCM := cipher.NewGCM(block)
+	if errGCM != nil {
+		return nil, errGCM
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, errRand := rand.Read(nonce); errRand != nil {
+		return nil, errRand
+	}
+	return json.MarshalIndent(sealedAuthBundle{
+		Version:    authBundleVersion,
+		Encrypted:  true,
+		KDF:        authBundleKDF,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+}
+
+func openAuthBundle(sealed sealedAuthBundle, passphrase string) ([]byte, error) {
+	if sealed.KDF != authBundleKDF {
+		return nil, fmt.Errorf("unsupported bundle kdf %q", sealed.KDF)
+	}
+	key, errKey := authBundleKey(passphrase, sealed.Salt)
+	if errKey != nil {
+		return nil, errKey
+	}
+	block, errBlock := aes.NewCipher(key)
+	if errBlock != nil {
+		return nil, errBlock
+	}
+	gcm, errGCM := cipher.NewGCM(block)
+	if errGCM != nil {
+		return nil, errGCM
+	}
+	if len(sealed.Nonce) != gcm.NonceSize() {
+		return nil, errors.New("invalid bundle nonce")
+	}
+	plaintext, errOpen := gcm.Open(nil, sealed.Nonce, sealed.Ciphertext, nil)
+	if errOpen != nil {
+		return nil, errors.New("wrong passphrase or corrupted bundle")
+	}
+	return plaintext, nil
+}
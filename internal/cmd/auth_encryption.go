@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// AuthEncryptionCipher builds the auth metadata cipher from the key or passphrase held
+// in the environment variable named by cfg.AuthEncryption.KeyEnv.
+func AuthEncryptionCipher(cfg *config.Config) (coreauth.MetadataCipher, error) {
+	keyEnv := config.DefaultAuthEncryptionKeyEnv
+	if cfg != nil && strings.TrimSpace(cfg.AuthEncryption.KeyEnv) != "" {
+		keyEnv = strings.TrimSpace(cfg.AuthEncryption.KeyEnv)
+	}
+	raw, ok := os.LookupEnv(keyEnv)
+	if !ok || strings.TrimSpace(raw) == "" {
+		return nil, fmt.Errorf("auth encryption: environment variable %s is not set", keyEnv)
+	}
+	return coreauth.NewMetadataCipher(raw)
+}
+
+// DoMigrateAuthEncryption encrypts every plaintext auth file in the auth directory in
+// place, or with decrypt set, converts encrypted files back to plaintext. Files already
+// in the target form are left untouched.
+func DoMigrateAuthEncryption(cfg *config.Config, decrypt bool) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	authDir, errResolve := util.ResolveAuthDir(cfg.AuthDir)
+	if errResolve != nil {
+		log.Errorf("auth encryption: resolve auth dir: %v", errResolve)
+		return
+	}
+	c, errCipher := AuthEncryptionCipher(cfg)
+	if errCipher != nil {
+		log.Errorf("%v", errCipher)
+		return
+	}
+
+	var converted, skipped, failed int
+	errWalk := filepath.WalkDir(authDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
+			return nil
+		}
+		changed, errFile := migrateAuthFile(path, c, decrypt)
+		switch {
+		case errFile != nil:
+			failed++
+			log.Errorf("auth encryption: %s: %v", path, errFile)
+		case changed:
+			converted++
+		default:
+			skipped++
+		}
+		return nil
+	})
+	if errWalk != nil {
+		log.Errorf("auth encryption: walk %s: %v", authDir, errWalk)
+		return
+	}
+	action := "encrypted"
+	if decrypt {
+		action = "decrypted"
+	}
+	log.Infof("auth encryption: %s %d file(s), %d unchanged, %d failed in %s", action, converted, skipped, failed, authDir)
+}
+
+func migrateAuthFile(path string, c coreauth.MetadataCipher, decrypt bool) (bool, error) {
+	data, errRead := os.ReadFile(path)
+	if errRead != nil {
+		return false, errRead
+	}
+	var metadata map[string]any
+	if errUnmarshal := json.Unmarshal(data, &metadata); errUnmarshal != nil {
+		return false, nil
+	}
+	if _, ok := metadata["type"].(string); !ok {
+		return false, nil
+	}
+	if coreauth.IsEncryptedMetadata(metadata) != decrypt {
+		return false, nil
+	}
+	var out map[string]any
+	var errConvert error
+	if decrypt {
+		out, errConvert = coreauth.DecryptMetadata(c, metadata)
+	} else {
+		out, errConvert = coreauth.EncryptMetadata(c, metadata)
+	}
+	if errConvert != nil {
+		return false, errConvert
+	}
+	raw, errMarshal := json.Marshal(out)
+	if errMarshal != nil {
+		return false, errMarshal
+	}
+	tmp := path + ".tmp"
+	if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
+		return false, errWrite
+	}
+	if errRename := os.Rename(tmp, path); errRename != nil {
+		_ = os.Remove(tmp)
+		return false, errRename
+	}
+	return true, nil
+}
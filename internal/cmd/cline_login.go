@@ -35,6 +35,7 @@ func DoClineLogin(cfg *config.Config, options *LoginOptions) {
 
 	authOpts := &sdkAuth.LoginOptions{
 		NoBrowser:    options.NoBrowser,
+		Headless:     options.Headless,
 		CallbackPort: options.CallbackPort,
 		Metadata:     map[string]string{},
 		Prompt:       promptFn,
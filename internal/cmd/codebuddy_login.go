@@ -24,6 +24,7 @@ func DoCodeBuddyLogin(cfg *config.Config, options *LoginOptions) {
 	manager := newAuthManager()
 	authOpts := &sdkAuth.LoginOptions{
 		NoBrowser: options.NoBrowser,
+		Headless:  options.Headless,
 		Metadata:  map[string]string{},
 	}
 
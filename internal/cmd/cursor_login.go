@@ -17,6 +17,7 @@ func DoCursorLogin(cfg *config.Config, options *LoginOptions) {
 	manager := newAuthManager()
 	authOpts := &sdkAuth.LoginOptions{
 		NoBrowser: options.NoBrowser,
+		Headless:  options.Headless,
 		Metadata:  map[string]string{},
 		Prompt:    options.Prompt,
 	}
@@ -21,6 +21,7 @@ func DoGitLabLogin(cfg *config.Config, options *LoginOptions) {
 	manager := newAuthManager()
 	authOpts := &sdkAuth.LoginOptions{
 		NoBrowser:    options.NoBrowser,
+		Headless:     options.Headless,
 		CallbackPort: options.CallbackPort,
 		Metadata: map[string]string{
 			"login_mode": "oauth",
@@ -0,0 +1,46 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy"
+	log "github.com/sirupsen/logrus"
+)
+
+// handoffTimeout bounds how long the replacement process may take to start serving.
+const handoffTimeout = 60 * time.Second
+
+// watchHandoffSignal hands the listening socket to a freshly started copy of the binary
+// on SIGUSR2. Once the replacement serves, stop is called so this process drains its
+// in-flight requests and exits; on failure this process keeps serving.
+func watchHandoffSignal(ctx context.Context, service *cliproxy.Service, stop context.CancelFunc) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR2)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+			}
+			log.Info("SIGUSR2 received, handing the listener to a new process")
+			handoffCtx, cancel := context.WithTimeout(ctx, handoffTimeout)
+			err := service.Handoff(handoffCtx)
+			cancel()
+			if err != nil {
+				log.Errorf("listener handoff failed, keeping this process: %v", err)
+				continue
+			}
+			log.Info("new process is serving, draining this one")
+			stop()
+			return
+		}
+	}()
+}
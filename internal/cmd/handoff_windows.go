@@ -0,0 +1,12 @@
+//go:build windows
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy"
+)
+
+// watchHandoffSignal is a no-op: listener handoff is not supported on Windows.
+func watchHandoffSignal(context.Context, *cliproxy.Service, context.CancelFunc) {}
@@ -25,6 +25,7 @@ func DoIFlowLogin(cfg *config.Config, options *LoginOptions) {
 
 	authOpts := &sdkAuth.LoginOptions{
 		NoBrowser:    options.NoBrowser,
+		Headless:     options.Headless,
 		CallbackPort: options.CallbackPort,
 		Metadata:     map[string]string{},
 		Prompt:       promptFn,
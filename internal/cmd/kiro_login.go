@@ -40,6 +40,7 @@ func DoKiroGoogleLogin(cfg *config.Config, options *LoginOptions) {
 	authenticator := sdkAuth.NewKiroAuthenticator()
 	record, err := authenticator.LoginWithGoogle(context.Background(), cfg, &sdkAuth.LoginOptions{
 		NoBrowser: options.NoBrowser,
+		Headless:  options.Headless,
 		Metadata:  map[string]string{},
 		Prompt:    options.Prompt,
 	})
@@ -88,6 +89,7 @@ func DoKiroAWSLogin(cfg *config.Config, options *LoginOptions) {
 	authenticator := sdkAuth.NewKiroAuthenticator()
 	record, err := authenticator.Login(context.Background(), cfg, &sdkAuth.LoginOptions{
 		NoBrowser: options.NoBrowser,
+		Headless:  options.Headless,
 		Metadata:  map[string]string{},
 		Prompt:    options.Prompt,
 	})
@@ -136,6 +138,7 @@ func DoKiroAWSAuthCodeLogin(cfg *config.Config, options *LoginOptions) {
 	authenticator := sdkAuth.NewKiroAuthenticator()
 	record, err := authenticator.LoginWithAuthCode(context.Background(), cfg, &sdkAuth.LoginOptions{
 		NoBrowser: options.NoBrowser,
+		Headless:  options.Headless,
 		Metadata:  map[string]string{},
 		Prompt:    options.Prompt,
 	})
@@ -229,6 +232,7 @@ func DoKiroIDCLogin(cfg *config.Config, options *LoginOptions, startURL, region,
 
 	record, err := authenticator.Login(context.Background(), cfg, &sdkAuth.LoginOptions{
 		NoBrowser: options.NoBrowser,
+		Headless:  options.Headless,
 		Metadata:  metadata,
 		Prompt:    options.Prompt,
 	})
@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/gemini"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/oauthcallback"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
@@ -59,12 +60,13 @@ func DoLogin(cfg *config.Config, projectID string, options *LoginOptions) {
 
 	trimmedProjectID := strings.TrimSpace(projectID)
 	callbackPrompt := promptFn
-	if trimmedProjectID == "" {
+	if trimmedProjectID == "" && !options.Headless {
 		callbackPrompt = nil
 	}
 
 	loginOpts := &sdkAuth.LoginOptions{
 		NoBrowser:    options.NoBrowser,
+		Headless:     options.Headless,
 		ProjectID:    trimmedProjectID,
 		CallbackPort: options.CallbackPort,
 		Metadata:     map[string]string{},
@@ -86,9 +88,11 @@ func DoLogin(cfg *config.Config, projectID string, options *LoginOptions) {
 
 	geminiAuth := gemini.NewGeminiAuth()
 	httpClient, errClient := geminiAuth.GetAuthenticatedClient(ctx, storage, cfg, &gemini.WebLoginOptions{
-		NoBrowser:    options.NoBrowser,
-		CallbackPort: options.CallbackPort,
-		Prompt:       callbackPrompt,
+		NoBrowser:        options.NoBrowser,
+		CallbackPort:     options.CallbackPort,
+		Prompt:           callbackPrompt,
+		Headless:         options.Headless,
+		CallbackSettings: oauthcallback.FromConfig(cfg),
 	})
 	if errClient != nil {
 		log.Errorf("Gemini authentication failed: %v", errClient)
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+)
+
+// DoValidateModelMappings prints the model-mappings rules in evaluation order and reports
+// invalid rules and rules shadowed by an earlier one. It exits with status 1 when any
+// problem is found so it can gate config changes in CI.
+func DoValidateModelMappings(cfg *config.Config) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	if problems := writeModelMappingsReport(os.Stdout, cfg.ModelMappings); problems > 0 {
+		os.Exit(1)
+	}
+}
+
+// writeModelMappingsReport writes the validation report and returns the number of problems.
+func writeModelMappingsReport(w io.Writer, mappings []config.ModelMapping) int {
+	if len(mappings) == 0 {
+		_, _ = fmt.Fprintln(w, "model-mappings: no rules configured")
+		return 0
+	}
+	_, _ = fmt.Fprintf(w, "model-mappings: %d rules, first match wins\n", len(mappings))
+	for i, mapping := range mappings {
+		kind := "exact"
+		switch {
+		case mapping.Regex:
+			kind = "regex"
+		case strings.Contains(mapping.From, "*"):
+			kind = "glob"
+		}
+		_, _ = fmt.Fprintf(w, "  [%d] %-5s %s -> %s\n", i, kind, mapping.From, mapping.To)
+	}
+
+	_, errs := util.NewModelMapper(mappings)
+	shadows := util.FindShadowedModelMappings(mappings)
+	for _, err := range errs {
+		_, _ = fmt.Fprintf(w, "error: %v\n", err)
+	}
+	for _, shadow := range shadows {
+		_, _ = fmt.Fprintf(w, "shadowed: [%d] %s is never reached, [%d] %s matches every name it matches\n",
+			shadow.Rule, mappings[shadow.Rule].From, shadow.ShadowedBy, mappings[shadow.ShadowedBy].From)
+	}
+	problems := len(errs) + len(shadows)
+	if problems == 0 {
+		_, _ = fmt.Fprintln(w, "model-mappings: OK")
+	}
+	return problems
+}
@@ -19,6 +19,10 @@ type LoginOptions struct {
 	// NoBrowser indicates whether to skip opening the browser automatically.
 	NoBrowser bool
 
+	// Headless completes logins over SSH: device-code flows where available, otherwise
+	// pasting the redirected URL without depending on the local callback server.
+	Headless bool
+
 	// CallbackPort overrides the local OAuth callback port when set (>0).
 	CallbackPort int
 
@@ -52,6 +56,7 @@ func DoCodexLogin(cfg *config.Config, options *LoginOptions) {
 
 	authOpts := &sdkAuth.LoginOptions{
 		NoBrowser:    options.NoBrowser,
+		Headless:     options.Headless,
 		CallbackPort: options.CallbackPort,
 		Metadata:     map[string]string{},
 		Prompt:       promptFn,
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/keyimport"
+	log "github.com/sirupsen/logrus"
+)
+
+// DoImportProviderKeys registers the provider API keys of a CSV or JSON manifest in the
+// config file, probing each key with a models-list call unless skipValidation is set.
+func DoImportProviderKeys(cfg *config.Config, configFilePath string, manifestPath string, skipValidation bool) {
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	data, errRead := os.ReadFile(manifestPath)
+	if errRead != nil {
+		log.Errorf("provider key import: read %s: %v", manifestPath, errRead)
+		return
+	}
+	rows, errParse := keyimport.Parse(data)
+	if errParse != nil {
+		log.Errorf("provider key import: %v", errParse)
+		return
+	}
+	var validate keyimport.Validator
+	if !skipValidation {
+		validate = keyimport.Probe(cfg)
+	}
+	results := keyimport.Validate(context.Background(), rows, validate)
+	added := keyimport.Apply(cfg, rows, results)
+
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Status]++
+		if result.Error != "" {
+			log.Errorf("provider key import: row %d (%s %s): %s: %s", result.Row, result.Provider, result.Key, result.Status, result.Error)
+			continue
+		}
+		log.Infof("provider key import: row %d (%s %s): %s", result.Row, result.Provider, result.Key, result.Status)
+	}
+	if added > 0 {
+		cfg.SanitizeGeminiKeys()
+		cfg.SanitizeClaudeKeys()
+		cfg.SanitizeCodexKeys()
+		cfg.SanitizeXAIKeys()
+		cfg.SanitizeMistralKeys()
+		if errSave := config.SaveConfigPreserveComments(configFilePath, cfg); errSave != nil {
+			log.Errorf("provider key import: save config: %v", errSave)
+			return
+		}
+	}
+	log.Infof("provider key import: %d added, %d duplicate, %d invalid, %d failed validation",
+		counts[keyimport.StatusAdded], counts[keyimport.StatusDuplicate], counts[keyimport.StatusInvalid], counts[keyimport.StatusFailed])
+}
@@ -61,6 +61,8 @@ func StartServiceWithPluginHost(cfg *config.Config, configPath string, localPass
 		return
 	}
 
+	watchHandoffSignal(runCtx, service, cancel)
+
 	err = service.Run(runCtx)
 	if err != nil && !errors.Is(err, context.Canceled) {
 		log.Errorf("proxy service exited with error: %v", err)
@@ -35,6 +35,9 @@ type Config struct {
 	Host string `yaml:"host" json:"-"`
 	// Port is the network port on which the API server will listen.
 	Port int `yaml:"port" json:"-"`
+	// ReusePort sets SO_REUSEPORT on the listener so a new process can bind the same port
+	// while the old one drains. Read at startup only; ignored on Windows.
+	ReusePort bool `yaml:"reuse-port,omitempty" json:"-"`
 
 	// TLS config controls HTTPS server settings.
 	TLS TLSConfig `yaml:"tls" json:"tls"`
@@ -57,6 +60,9 @@ type Config struct {
 	// AuthDir is the directory where authentication token files are stored.
 	AuthDir string `yaml:"auth-dir" json:"-"`
 
+	// OAuthCallback configures the local listener that receives OAuth login redirects.
+	OAuthCallback OAuthCallbackConfig `yaml:"oauth-callback,omitempty" json:"oauth-callback,omitempty"`
+
 	// Debug enables or disables debug-level logging and other debug features.
 	Debug bool `yaml:"debug" json:"debug"`
 
@@ -80,6 +86,9 @@ type Config struct {
 	// UsageStatisticsEnabled toggles in-memory usage aggregation; when false, usage data is discarded.
 	UsageStatisticsEnabled bool `yaml:"usage-statistics-enabled" json:"usage-statistics-enabled"`
 
+	// UsageCounters configures persistent per-auth, per-model, per-day usage counters.
+	UsageCounters UsageCountersConfig `yaml:"usage-counters,omitempty" json:"usage-counters,omitempty"`
+
 	// RedisUsageQueueRetentionSeconds controls how long usage queue items are retained
 	// in memory for Management API consumers.
 	// Default: 60. Max: 3600.
@@ -95,10 +104,86 @@ type Config struct {
 	// 0 keeps the legacy default cooldown. Negative values disable these cooldowns.
 	TransientErrorCooldownSeconds int `yaml:"transient-error-cooldown-seconds" json:"transient-error-cooldown-seconds"`
 
+	// QuotaResets overrides, per provider, when an exhausted quota comes back. Cooldowns
+	// after 429s without a Retry-After never outlast the next reset.
+	QuotaResets map[string]QuotaResetRule `yaml:"quota-resets,omitempty" json:"quota-resets,omitempty"`
+
 	// AuthAutoRefreshWorkers overrides the size of the core auth auto-refresh worker pool.
 	// When <= 0, the default worker count is used.
 	AuthAutoRefreshWorkers int `yaml:"auth-auto-refresh-workers" json:"auth-auto-refresh-workers"`
 
+	// AuthAutoRefreshJitter delays each scheduled refresh by a random duration up to this
+	// value (e.g. "30s") so restarts do not refresh every credential at once.
+	AuthAutoRefreshJitter string `yaml:"auth-auto-refresh-jitter,omitempty" json:"auth-auto-refresh-jitter,omitempty"`
+
+	// AuthAutoRefreshProviderLimit caps concurrent background refreshes per provider (0 = unlimited).
+	AuthAutoRefreshProviderLimit int `yaml:"auth-auto-refresh-provider-limit,omitempty" json:"auth-auto-refresh-provider-limit,omitempty"`
+
+	// BackgroundLane paces internal traffic (validation, warmup, probes, model list refreshes)
+	// so it does not compete with client requests.
+	BackgroundLane BackgroundLaneConfig `yaml:"background-lane,omitempty" json:"background-lane,omitempty"`
+
+	// RequestPriority limits concurrent upstream attempts of client requests and admits
+	// waiting requests by priority class.
+	RequestPriority RequestPriorityConfig `yaml:"request-priority,omitempty" json:"request-priority,omitempty"`
+
+	// HeaderProfiles override the client identification headers (User-Agent, IDE and
+	// version headers) executors send to a provider.
+	HeaderProfiles []HeaderProfile `yaml:"header-profiles,omitempty" json:"header-profiles,omitempty"`
+
+	// SelectionAudit writes a JSONL record of credential selection decisions per request.
+	SelectionAudit SelectionAuditConfig `yaml:"selection-audit,omitempty" json:"selection-audit,omitempty"`
+
+	// HealthProbe periodically probes blocked auths shortly before their cooldown ends.
+	HealthProbe HealthProbeConfig `yaml:"health-probe,omitempty" json:"health-probe,omitempty"`
+
+	// Warmup periodically sends tiny requests through OAuth auths to keep sessions warm.
+	Warmup WarmupConfig `yaml:"warmup,omitempty" json:"warmup,omitempty"`
+
+	// AuthPersistence controls how per-request auth state is written to the auth store.
+	AuthPersistence AuthPersistenceConfig `yaml:"auth-persistence,omitempty" json:"auth-persistence,omitempty"`
+
+	// HookDispatch controls how SDK auth hooks receive request results.
+	HookDispatch HookDispatchConfig `yaml:"hook-dispatch,omitempty" json:"hook-dispatch,omitempty"`
+
+	// ModelRefresh periodically re-fetches dynamic model lists and reports added or removed models.
+	ModelRefresh ModelRefreshConfig `yaml:"model-refresh,omitempty" json:"model-refresh,omitempty"`
+
+	// AuthWebhook POSTs auth lifecycle events to an external URL.
+	AuthWebhook AuthWebhookConfig `yaml:"auth-webhook,omitempty" json:"auth-webhook,omitempty"`
+
+	// SpendAlerts tracks daily spend per credential and tenant against budgets, alerting
+	// near the limit and disabling routing once it is reached.
+	SpendAlerts SpendAlertsConfig `yaml:"spend-alerts,omitempty" json:"spend-alerts,omitempty"`
+
+	// ExpiryReport periodically reports credentials that expire soon or keep failing to
+	// refresh, so they can be re-logged before they stop serving.
+	ExpiryReport ExpiryReportConfig `yaml:"expiry-report,omitempty" json:"expiry-report,omitempty"`
+
+	// DailyCaps benches credentials that reach their daily request or token cap until the
+	// provider's quota resets, instead of discovering the quota through 429s.
+	DailyCaps DailyCapsConfig `yaml:"daily-caps,omitempty" json:"daily-caps,omitempty"`
+
+	// AuthEncryption encrypts auth file metadata at rest. Read at startup only.
+	AuthEncryption AuthEncryptionConfig `yaml:"auth-encryption,omitempty" json:"auth-encryption,omitempty"`
+
+	// ProviderBaseURLs overrides the built-in upstream host per provider (e.g. "cline",
+	// "kilo") for self-hosted gateways, regional endpoints and test doubles. An auth's
+	// base_url attribute takes precedence.
+	ProviderBaseURLs map[string]string `yaml:"provider-base-urls,omitempty" json:"provider-base-urls,omitempty"`
+
+	// PromptCacheHints detects long prompt prefixes shared across requests and marks them
+	// for provider-side caching (Claude cache_control, Gemini cachedContent).
+	PromptCacheHints PromptCacheHintsConfig `yaml:"prompt-cache-hints,omitempty" json:"prompt-cache-hints,omitempty"`
+
+	// Secrets configures the backends that resolve provider API keys declared by
+	// reference (vault://... or arn:aws:secretsmanager:...).
+	Secrets SecretsConfig `yaml:"secrets,omitempty" json:"secrets,omitempty"`
+
+	// ForbiddenRules classify 403 response bodies into "key blocked" (cool the key down)
+	// and "request rejected" (fail the request only). They extend the built-in patterns.
+	ForbiddenRules []ForbiddenRule `yaml:"forbidden-rules,omitempty" json:"forbidden-rules,omitempty"`
+
 	// RequestRetry defines the retry times when the request failed.
 	RequestRetry int `yaml:"request-retry" json:"request-retry"`
 	// MaxRetryCredentials defines the maximum number of credentials to try for a failed request.
@@ -106,6 +191,10 @@ type Config struct {
 	MaxRetryCredentials int `yaml:"max-retry-credentials" json:"max-retry-credentials"`
 	// MaxRetryInterval defines the maximum wait time in seconds before retrying a cooled-down credential.
 	MaxRetryInterval int `yaml:"max-retry-interval" json:"max-retry-interval"`
+	// RetryBackoff delays, per provider, the retry of a request that failed with a transient
+	// error (408/5xx or a network timeout) by a jittered exponential backoff. The key "*"
+	// applies to providers without their own entry.
+	RetryBackoff map[string]RetryBackoffRule `yaml:"retry-backoff,omitempty" json:"retry-backoff,omitempty"`
 
 	// QuotaExceeded defines the behavior when a quota is exceeded.
 	QuotaExceeded QuotaExceeded `yaml:"quota-exceeded" json:"quota-exceeded"`
@@ -122,6 +211,13 @@ type Config struct {
 	// WebsocketAuth enables or disables authentication for the WebSocket API.
 	WebsocketAuth bool `yaml:"ws-auth" json:"ws-auth"`
 
+	// ShutdownDrainTimeout bounds how long shutdown waits for in-flight requests and
+	// streams to finish after new requests stop being accepted (default "30s").
+	ShutdownDrainTimeout string `yaml:"shutdown-drain-timeout,omitempty" json:"shutdown-drain-timeout,omitempty"`
+
+	// RequestLimits bounds the size and message count of proxy API request bodies.
+	RequestLimits RequestLimitsConfig `yaml:"request-limits,omitempty" json:"request-limits,omitempty"`
+
 	// APIKeyIPBlacklist configures automatic temporary IP blocking for repeated
 	// invalid inline API key attempts on the main API surface.
 	APIKeyIPBlacklist APIKeyIPBlacklistConfig `yaml:"api-key-ip-blacklist,omitempty" json:"api-key-ip-blacklist,omitempty"`
@@ -191,6 +287,9 @@ type Config struct {
 	// MistralKey defines a list of Mistral API key configurations.
 	MistralKey []MistralKey `yaml:"mistral-api-key" json:"mistral-api-key"`
 
+	// MockProvider enables the scripted "mock" provider used by integration tests.
+	MockProvider MockProviderConfig `yaml:"mock-provider,omitempty" json:"mock-provider,omitempty"`
+
 	// AmpCode contains Amp CLI upstream configuration, management restrictions, and model mappings.
 	AmpCode AmpCode `yaml:"ampcode" json:"ampcode"`
 
@@ -341,6 +440,26 @@ type TLSConfig struct {
 	Cert string `yaml:"cert" json:"cert"`
 	// Key is the path to the TLS private key file.
 	Key string `yaml:"key" json:"key"`
+	// ACME obtains and renews certificates automatically instead of (or in addition to)
+	// the cert/key files. Files are reloaded when they change on disk either way.
+	ACME ACMEConfig `yaml:"acme,omitempty" json:"acme,omitempty"`
+}
+
+// ACMEConfig configures automatic certificates from an ACME CA such as Let's Encrypt.
+type ACMEConfig struct {
+	// Enable toggles ACME certificate management.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Domains lists the host names certificates may be issued for.
+	Domains []string `yaml:"domains" json:"domains"`
+	// Email is the optional account contact used for expiry notices.
+	Email string `yaml:"email,omitempty" json:"email,omitempty"`
+	// CacheDir stores the account key and certificates. Defaults to "acme" in the auth dir.
+	CacheDir string `yaml:"cache-dir,omitempty" json:"cache-dir,omitempty"`
+	// DirectoryURL overrides the ACME directory, e.g. the Let's Encrypt staging endpoint.
+	DirectoryURL string `yaml:"directory-url,omitempty" json:"directory-url,omitempty"`
+	// HTTPChallengeAddr serves HTTP-01 challenges and redirects plain HTTP to HTTPS, e.g.
+	// ":80". When empty only the TLS-ALPN-01 challenge on the main port is used.
+	HTTPChallengeAddr string `yaml:"http-challenge-addr,omitempty" json:"http-challenge-addr,omitempty"`
 }
 
 // PprofConfig holds pprof HTTP server settings.
@@ -365,6 +484,21 @@ type RemoteManagement struct {
 	// PanelGitHubRepository overrides the GitHub repository used to fetch the management panel asset.
 	// Accepts either a repository URL (https://github.com/org/repo) or an API releases endpoint.
 	PanelGitHubRepository string `yaml:"panel-github-repository"`
+	// Listen moves the management API and control panel to a separate host:port. The main
+	// port then answers 404 for them, and the management port serves nothing else.
+	Listen string `yaml:"listen,omitempty"`
+	// AllowedCIDRs limits remote management clients to these networks (IPs or CIDRs).
+	// Loopback clients are always allowed.
+	AllowedCIDRs []string `yaml:"allowed-cidrs,omitempty"`
+	// BasicAuth adds HTTP basic credentials accepted in place of the management key.
+	BasicAuth ManagementBasicAuth `yaml:"basic-auth,omitempty"`
+}
+
+// ManagementBasicAuth holds HTTP basic credentials for the management API.
+type ManagementBasicAuth struct {
+	Username string `yaml:"username"`
+	// Password is plaintext or a bcrypt hash.
+	Password string `yaml:"password"`
 }
 
 // QuotaExceeded defines the behavior when API quota limits are exceeded.
@@ -385,7 +519,7 @@ type QuotaExceeded struct {
 // RoutingConfig configures how credentials are selected for requests.
 type RoutingConfig struct {
 	// Strategy selects the credential selection strategy.
-	// Supported values: "round-robin" (default), "fill-first", "weight-robin".
+	// Supported values: "round-robin" (default), "fill-first", "weight-robin", "cost".
 	Strategy string `yaml:"strategy,omitempty" json:"strategy,omitempty"`
 
 	// Mode configures the routing mode.
@@ -418,6 +552,365 @@ type RoutingConfig struct {
 	// TokenThresholdRules defines routing rules that filter eligible credentials
 	// by billing class when the estimated input token count is at or below a threshold.
 	TokenThresholdRules []TokenThresholdRule `yaml:"token-threshold-rules,omitempty" json:"token-threshold-rules,omitempty"`
+
+	// ModelGroups maps model names or patterns (e.g. "claude-opus-*") to the auth groups
+	// allowed to serve them. Credentials outside those groups are skipped for the model.
+	ModelGroups map[string][]string `yaml:"model-groups,omitempty" json:"model-groups,omitempty"`
+
+	// Pricing lists per provider/model token prices used by the "cost" strategy.
+	Pricing []ModelPricing `yaml:"pricing,omitempty" json:"pricing,omitempty"`
+
+	// AuthDailyBudget caps the estimated USD spend of each credential per UTC day
+	// when the "cost" strategy is active. Zero disables the ceiling. Individual
+	// credentials may override it with the "daily_budget" attribute.
+	AuthDailyBudget float64 `yaml:"auth-daily-budget,omitempty" json:"auth-daily-budget,omitempty"`
+}
+
+// UsageCountersConfig configures the persistent usage counters aggregator.
+type UsageCountersConfig struct {
+	// Enabled turns on aggregation of request and token counts per auth, model, and UTC day.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// PersistPath is the JSON snapshot file. Empty keeps counters in memory only.
+	PersistPath string `yaml:"persist-path,omitempty" json:"persist-path,omitempty"`
+	// FlushInterval bounds how long updates stay unpersisted (default "30s").
+	FlushInterval string `yaml:"flush-interval,omitempty" json:"flush-interval,omitempty"`
+	// RetentionDays drops buckets older than this many days. 0 keeps everything.
+	RetentionDays int `yaml:"retention-days,omitempty" json:"retention-days,omitempty"`
+}
+
+// BackgroundLaneConfig configures the low-priority lane for internal traffic.
+type BackgroundLaneConfig struct {
+	// MaxConcurrency bounds concurrent internal requests. <= 0 uses the default (2).
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+	// MinInterval spaces internal request starts (default "200ms"; "0s" disables pacing).
+	MinInterval string `yaml:"min-interval,omitempty" json:"min-interval,omitempty"`
+}
+
+// RequestPriorityConfig configures priority scheduling of client requests. A request's
+// class comes from its API key policy, else the X-CLIProxy-Priority header, else "normal".
+type RequestPriorityConfig struct {
+	// MaxConcurrency bounds concurrent upstream attempts of client requests. Requests over
+	// the limit, and requests retrying after a cooldown, wait and are admitted interactive
+	// first, then normal, then batch. <= 0 disables the limit.
+	MaxConcurrency int `yaml:"max-concurrency,omitempty" json:"max-concurrency,omitempty"`
+	// AgingInterval promotes a waiting request one class per interval waited so batch work
+	// is not starved (default "10s").
+	AgingInterval string `yaml:"aging-interval,omitempty" json:"aging-interval,omitempty"`
+}
+
+// HeaderProfile is a set of headers sent on every upstream request of a provider. A
+// profile without a name applies to every credential of the provider; a named profile
+// applies to credentials whose header_profile attribute or metadata names it. Headers
+// set on the credential itself ("header:<name>" attributes) still take precedence.
+type HeaderProfile struct {
+	// Name selects the profile from a credential. Empty makes it the provider default.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+	// Provider is the provider the profile applies to (e.g. "codebuddy", "github-copilot").
+	Provider string `yaml:"provider" json:"provider"`
+	// Headers are set on upstream requests, replacing the values executors send.
+	Headers map[string]string `yaml:"headers" json:"headers"`
+}
+
+// OAuthCallbackConfig configures the local OAuth callback listener of CLI logins. Logins
+// running at the same time share one listener per address. Providers with a fixed
+// registered redirect URI (Claude, Codex) keep their port and path; the port and path of
+// the others (GitLab, iFlow) follow this configuration.
+type OAuthCallbackConfig struct {
+	// Host is the bind address, e.g. "127.0.0.1". Empty listens on all interfaces.
+	Host string `yaml:"host,omitempty" json:"host,omitempty"`
+	// RandomPort binds a free port instead of the provider default when no port is given.
+	RandomPort bool `yaml:"random-port,omitempty" json:"random-port,omitempty"`
+	// Paths overrides the callback path per provider (e.g. gitlab: /oauth/gitlab).
+	Paths map[string]string `yaml:"paths,omitempty" json:"paths,omitempty"`
+	// SuccessTitle and SuccessMessage replace the page shown after a successful login.
+	SuccessTitle   string `yaml:"success-title,omitempty" json:"success-title,omitempty"`
+	SuccessMessage string `yaml:"success-message,omitempty" json:"success-message,omitempty"`
+}
+
+// SelectionAuditConfig configures the credential selection audit log.
+type SelectionAuditConfig struct {
+	// Enabled turns on the audit log.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Path is the JSONL file. Empty writes selection-audit.jsonl in the log directory.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// MaxSizeMB rotates the file once it reaches this size (default 50).
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+	// MaxBackups bounds how many rotated files are kept (0 keeps all).
+	MaxBackups int `yaml:"max-backups,omitempty" json:"max-backups,omitempty"`
+	// MaxAgeDays removes rotated files older than this many days (0 keeps all).
+	MaxAgeDays int `yaml:"max-age-days,omitempty" json:"max-age-days,omitempty"`
+}
+
+// HealthProbeConfig configures the background prober for blocked auths.
+type HealthProbeConfig struct {
+	// Enabled turns on the probe loop.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Interval is the scan period (default "30s").
+	Interval string `yaml:"interval,omitempty" json:"interval,omitempty"`
+	// Lead probes auths whose cooldown ends within this window (default "1m").
+	Lead string `yaml:"lead,omitempty" json:"lead,omitempty"`
+	// Timeout bounds a single probe (default "15s").
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// Extend pushes the cooldown out by this much when a probe still fails (default "5m").
+	Extend string `yaml:"extend,omitempty" json:"extend,omitempty"`
+	// Providers limits probing to these providers; empty probes every provider.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+}
+
+// WarmupConfig configures the OAuth warmup scheduler.
+type WarmupConfig struct {
+	// Enabled turns on the scheduler.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Timeout bounds a single warmup request (default "30s").
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// Schedules lists the provider/model pairs to warm.
+	Schedules []WarmupSchedule `yaml:"schedules,omitempty" json:"schedules,omitempty"`
+}
+
+// WarmupSchedule warms every OAuth auth of Provider with Model, either every Every or
+// daily at the local "HH:MM" times in At.
+type WarmupSchedule struct {
+	Provider string   `yaml:"provider" json:"provider"`
+	Model    string   `yaml:"model" json:"model"`
+	Every    string   `yaml:"every,omitempty" json:"every,omitempty"`
+	At       []string `yaml:"at,omitempty" json:"at,omitempty"`
+	// Prompt overrides the default "hi" message.
+	Prompt string `yaml:"prompt,omitempty" json:"prompt,omitempty"`
+}
+
+// Auth persistence modes.
+const (
+	AuthPersistenceModeAsync = "async"
+	AuthPersistenceModeSync  = "sync"
+)
+
+// AuthPersistenceConfig configures how request results (counters, model states, cooldowns)
+// are saved to the auth store. Credential changes such as refreshed tokens are always
+// written immediately.
+type AuthPersistenceConfig struct {
+	// Mode is "async" (default) to queue writes, coalesce them per auth and flush them in
+	// the background, or "sync" to write on every request.
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+	// FlushInterval is how often queued writes are flushed in async mode (default "1s").
+	FlushInterval string `yaml:"flush-interval,omitempty" json:"flush-interval,omitempty"`
+}
+
+// Hook dispatch modes.
+const (
+	HookDispatchModeAsync = "async"
+	HookDispatchModeSync  = "sync"
+)
+
+// HookDispatchConfig configures delivery of Hook.OnResult callbacks.
+type HookDispatchConfig struct {
+	// Mode is "sync" (default) to call OnResult inline, or "async" to call it from a bounded
+	// worker pool that may reorder results across auths and drops the oldest queued ones when it
+	// falls behind.
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+	// Workers is the number of delivery goroutines (default 4).
+	Workers int `yaml:"workers,omitempty" json:"workers,omitempty"`
+	// QueueSize bounds queued results (default 1024).
+	QueueSize int `yaml:"queue-size,omitempty" json:"queue-size,omitempty"`
+	// ErrorPolicy handles hook panics: "log" (default), "ignore" or "disable".
+	ErrorPolicy string `yaml:"error-policy,omitempty" json:"error-policy,omitempty"`
+}
+
+// ModelRefreshConfig configures periodic re-fetching of provider model lists that are
+// discovered at runtime rather than compiled in.
+type ModelRefreshConfig struct {
+	// Enabled turns on the refresher.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// TTL is how long a fetched model list is trusted before it is fetched again (default "1h").
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+	// Providers limits refreshing to these providers; empty refreshes kilo, cursor and github-copilot.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+}
+
+// AuthWebhookConfig configures delivery of auth lifecycle events (registered, refreshed,
+// refresh_failed, blocked, recovered) to a webhook.
+type AuthWebhookConfig struct {
+	// Enabled turns on delivery.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// URL receives one JSON POST per event.
+	URL string `yaml:"url" json:"url"`
+	// Headers are added to every request.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// Timeout bounds a single POST (default "10s").
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// Events limits delivery to these events; empty delivers all of them.
+	Events []string `yaml:"events,omitempty" json:"events,omitempty"`
+}
+
+// RequestLimitsConfig bounds proxy API request bodies. Violations are rejected with 413
+// before the body reaches a handler.
+type RequestLimitsConfig struct {
+	// MaxBodyBytes caps the request body size. <= 0 disables the cap.
+	MaxBodyBytes int64 `yaml:"max-body-bytes,omitempty" json:"max-body-bytes,omitempty"`
+	// MaxMessages caps the entries of the top-level messages, contents or input array.
+	// <= 0 disables the cap.
+	MaxMessages int `yaml:"max-messages,omitempty" json:"max-messages,omitempty"`
+	// ValidateJSON rejects malformed JSON bodies with 400 while they are read, so invalid
+	// payloads are refused without buffering them whole.
+	ValidateJSON bool `yaml:"validate-json,omitempty" json:"validate-json,omitempty"`
+}
+
+// SpendAlertsConfig configures daily USD budgets per credential and per tenant. Spend is
+// estimated from the routing pricing table; models without a price are not counted.
+type SpendAlertsConfig struct {
+	// Enabled turns on spend tracking and alerts.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// WarnRatio is the fraction of a budget that triggers the warning alert (default 0.8).
+	WarnRatio float64 `yaml:"warn-ratio,omitempty" json:"warn-ratio,omitempty"`
+	// AuthDailyBudget is the default budget of every credential. <= 0 disables it.
+	AuthDailyBudget float64 `yaml:"auth-daily-budget,omitempty" json:"auth-daily-budget,omitempty"`
+	// AuthBudgets overrides AuthDailyBudget per auth ID.
+	AuthBudgets map[string]float64 `yaml:"auth-budgets,omitempty" json:"auth-budgets,omitempty"`
+	// TenantDailyBudget is the default budget of every tenant. <= 0 disables it.
+	TenantDailyBudget float64 `yaml:"tenant-daily-budget,omitempty" json:"tenant-daily-budget,omitempty"`
+	// TenantBudgets overrides TenantDailyBudget per tenant ID.
+	TenantBudgets map[string]float64 `yaml:"tenant-budgets,omitempty" json:"tenant-budgets,omitempty"`
+	// WebhookURL receives one JSON POST per alert. Alerts are always logged.
+	WebhookURL string `yaml:"webhook-url,omitempty" json:"webhook-url,omitempty"`
+	// WebhookHeaders are added to every webhook request.
+	WebhookHeaders map[string]string `yaml:"webhook-headers,omitempty" json:"webhook-headers,omitempty"`
+}
+
+// ExpiryReportConfig configures the scheduled expiring-credentials report. Expiry only
+// counts for credentials without a refresh token, since the others renew on their own.
+type ExpiryReportConfig struct {
+	// Enabled turns on the scheduled report.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Interval is the time between reports (default "6h").
+	Interval string `yaml:"interval,omitempty" json:"interval,omitempty"`
+	// WithinDays reports credentials expiring within this many days (default 3).
+	WithinDays int `yaml:"within-days,omitempty" json:"within-days,omitempty"`
+	// FailureThreshold reports credentials whose refresh failed this many times in a row
+	// (default 3).
+	FailureThreshold int `yaml:"failure-threshold,omitempty" json:"failure-threshold,omitempty"`
+	// WebhookURL receives the report as a JSON POST when it lists any credential. Reports
+	// are always logged.
+	WebhookURL string `yaml:"webhook-url,omitempty" json:"webhook-url,omitempty"`
+	// WebhookHeaders are added to every webhook request.
+	WebhookHeaders map[string]string `yaml:"webhook-headers,omitempty" json:"webhook-headers,omitempty"`
+}
+
+// QuotaResetRule describes when a provider's quota resets: a rolling window, or a daily
+// reset at a wall-clock time.
+type QuotaResetRule struct {
+	// Window is the length of a rolling quota window, e.g. "5h".
+	Window string `yaml:"window,omitempty" json:"window,omitempty"`
+	// DailyAt is the "HH:MM" of a daily reset.
+	DailyAt string `yaml:"daily-at,omitempty" json:"daily-at,omitempty"`
+	// Timezone is the IANA time zone of DailyAt (default "UTC").
+	Timezone string `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+	// Disabled drops the built-in schedule of the provider, leaving plain backoff.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+}
+
+// RetryBackoffRule is a jittered exponential backoff policy: retry n waits
+// Base * Multiplier^n, randomised by up to Jitter of that delay and bounded by Cap.
+type RetryBackoffRule struct {
+	// Base is the delay before the first retry, e.g. "500ms".
+	Base string `yaml:"base,omitempty" json:"base,omitempty"`
+	// Multiplier grows the delay per retry (default 2).
+	Multiplier float64 `yaml:"multiplier,omitempty" json:"multiplier,omitempty"`
+	// Jitter is the fraction (0-1) of each delay that is randomised (default 0.2).
+	Jitter float64 `yaml:"jitter,omitempty" json:"jitter,omitempty"`
+	// Cap bounds a single delay, e.g. "10s". Delays never exceed max-retry-interval either.
+	Cap string `yaml:"cap,omitempty" json:"cap,omitempty"`
+}
+
+// DailyCapsConfig configures per-credential daily request and token caps. A credential that
+// reaches a cap is benched until the next daily reset of its provider.
+type DailyCapsConfig struct {
+	// Enabled turns on cap tracking.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Requests caps the successful requests of every credential per day. 0 disables it.
+	Requests int64 `yaml:"requests,omitempty" json:"requests,omitempty"`
+	// Tokens caps the total tokens of every credential per day. 0 disables it.
+	Tokens int64 `yaml:"tokens,omitempty" json:"tokens,omitempty"`
+	// ResetTime is the "HH:MM" at which daily quotas reset (default "00:00").
+	ResetTime string `yaml:"reset-time,omitempty" json:"reset-time,omitempty"`
+	// Timezone is the IANA time zone of ResetTime (default "UTC").
+	Timezone string `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+	// Providers overrides the caps and reset schedule per provider.
+	Providers map[string]DailyCapRule `yaml:"providers,omitempty" json:"providers,omitempty"`
+}
+
+// DailyCapRule overrides the daily caps of one provider. Zero fields inherit the defaults.
+type DailyCapRule struct {
+	Requests  int64  `yaml:"requests,omitempty" json:"requests,omitempty"`
+	Tokens    int64  `yaml:"tokens,omitempty" json:"tokens,omitempty"`
+	ResetTime string `yaml:"reset-time,omitempty" json:"reset-time,omitempty"`
+	Timezone  string `yaml:"timezone,omitempty" json:"timezone,omitempty"`
+}
+
+// DefaultAuthEncryptionKeyEnv names the environment variable holding the auth
+// encryption key when AuthEncryptionConfig.KeyEnv is empty.
+const DefaultAuthEncryptionKeyEnv = "CLIPROXY_AUTH_ENCRYPTION_KEY"
+
+// AuthEncryptionConfig configures AES-256-GCM encryption of auth metadata at rest.
+type AuthEncryptionConfig struct {
+	// Enabled encrypts auth records on save and decrypts them on load.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// KeyEnv names the environment variable holding the key: a base64 32-byte key or a
+	// passphrase. Defaults to CLIPROXY_AUTH_ENCRYPTION_KEY.
+	KeyEnv string `yaml:"key-env,omitempty" json:"key-env,omitempty"`
+}
+
+// PromptCacheHintsConfig configures automatic prompt-prefix caching hints.
+type PromptCacheHintsConfig struct {
+	// Enabled turns on shared-prefix detection and hint injection.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// MinPrefixTokens is the estimated prefix size below which no hint is added (default 1024).
+	MinPrefixTokens int `yaml:"min-prefix-tokens,omitempty" json:"min-prefix-tokens,omitempty"`
+	// GeminiCacheTTL is the lifetime of Gemini cachedContents created for shared prefixes (default "5m").
+	GeminiCacheTTL string `yaml:"gemini-cache-ttl,omitempty" json:"gemini-cache-ttl,omitempty"`
+}
+
+// SecretsConfig configures resolution of API keys declared by reference.
+type SecretsConfig struct {
+	// RefreshInterval re-resolves referenced secrets periodically (default "5m"; "0" disables).
+	RefreshInterval string `yaml:"refresh-interval,omitempty" json:"refresh-interval,omitempty"`
+	// Vault configures vault://path#field references.
+	Vault VaultSecretsConfig `yaml:"vault,omitempty" json:"vault,omitempty"`
+	// AWS configures arn:aws:secretsmanager:... references.
+	AWS AWSSecretsConfig `yaml:"aws,omitempty" json:"aws,omitempty"`
+}
+
+// VaultSecretsConfig configures the HashiCorp Vault backend. Empty fields fall back to
+// VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE.
+type VaultSecretsConfig struct {
+	Address string `yaml:"address,omitempty" json:"address,omitempty"`
+	// TokenEnv names the environment variable holding the Vault token (default VAULT_TOKEN).
+	TokenEnv  string `yaml:"token-env,omitempty" json:"token-env,omitempty"`
+	Namespace string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+}
+
+// AWSSecretsConfig configures the AWS Secrets Manager backend. Credentials are read from
+// AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN.
+type AWSSecretsConfig struct {
+	// Endpoint overrides the regional Secrets Manager endpoint (e.g. a VPC endpoint).
+	Endpoint string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+}
+
+// ForbiddenRule lists case-insensitive substrings matched against 403 error bodies.
+type ForbiddenRule struct {
+	// Provider limits the rule to one provider; empty matches every provider.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+	// KeyBlocked patterns mean the credential itself is blocked; they win over RequestRejected.
+	KeyBlocked []string `yaml:"key-blocked,omitempty" json:"key-blocked,omitempty"`
+	// RequestRejected patterns mean the request content was refused (e.g. content policy).
+	RequestRejected []string `yaml:"request-rejected,omitempty" json:"request-rejected,omitempty"`
+}
+
+// ModelPricing defines the USD price per million tokens for a provider/model.
+// An empty Provider matches every provider; a trailing "*" in Model matches by prefix.
+type ModelPricing struct {
+	Provider             string  `yaml:"provider,omitempty" json:"provider,omitempty"`
+	Model                string  `yaml:"model" json:"model"`
+	PromptPerMillion     float64 `yaml:"prompt-per-million,omitempty" json:"prompt-per-million,omitempty"`
+	CompletionPerMillion float64 `yaml:"completion-per-million,omitempty" json:"completion-per-million,omitempty"`
 }
 
 // APIKeyIPBlacklistConfig defines the automatic IP blacklist policy applied to
@@ -648,6 +1141,10 @@ type ClaudeKey struct {
 	// ProxyURL overrides the global proxy setting for this API key if provided.
 	ProxyURL string `yaml:"proxy-url" json:"proxy-url"`
 
+	// SourceAddress binds outbound connections for this key to a local IP address or network
+	// interface name, so upstreams see a distinct origin per credential.
+	SourceAddress string `yaml:"source-address,omitempty" json:"source-address,omitempty"`
+
 	// BillingClass classifies this credential for threshold-based routing policies.
 	BillingClass BillingClass `yaml:"billing-class,omitempty" json:"billing-class,omitempty"`
 
@@ -723,6 +1220,10 @@ type CodexKey struct {
 	// ProxyURL overrides the global proxy setting for this API key if provided.
 	ProxyURL string `yaml:"proxy-url" json:"proxy-url"`
 
+	// SourceAddress binds outbound connections for this key to a local IP address or network
+	// interface name, so upstreams see a distinct origin per credential.
+	SourceAddress string `yaml:"source-address,omitempty" json:"source-address,omitempty"`
+
 	// BillingClass classifies this credential for threshold-based routing policies.
 	BillingClass BillingClass `yaml:"billing-class,omitempty" json:"billing-class,omitempty"`
 
@@ -784,6 +1285,10 @@ type CommandCodeKey struct {
 	// ProxyURL overrides the global proxy setting for this API key if provided.
 	ProxyURL string `yaml:"proxy-url" json:"proxy-url"`
 
+	// SourceAddress binds outbound connections for this key to a local IP address or network
+	// interface name, so upstreams see a distinct origin per credential.
+	SourceAddress string `yaml:"source-address,omitempty" json:"source-address,omitempty"`
+
 	// BillingClass classifies this credential for threshold-based routing policies.
 	BillingClass BillingClass `yaml:"billing-class,omitempty" json:"billing-class,omitempty"`
 
@@ -841,6 +1346,10 @@ type MistralKey struct {
 	// ProxyURL overrides the global proxy setting for this API key if provided.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
 
+	// SourceAddress binds outbound connections for this key to a local IP address or network
+	// interface name, so upstreams see a distinct origin per credential.
+	SourceAddress string `yaml:"source-address,omitempty" json:"source-address,omitempty"`
+
 	// BillingClass classifies this credential for threshold-based routing policies.
 	BillingClass BillingClass `yaml:"billing-class,omitempty" json:"billing-class,omitempty"`
 
@@ -904,6 +1413,10 @@ type GeminiKey struct {
 	// ProxyURL optionally overrides the global proxy for this API key.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
 
+	// SourceAddress binds outbound connections for this key to a local IP address or network
+	// interface name, so upstreams see a distinct origin per credential.
+	SourceAddress string `yaml:"source-address,omitempty" json:"source-address,omitempty"`
+
 	// BillingClass classifies this credential for threshold-based routing policies.
 	BillingClass BillingClass `yaml:"billing-class,omitempty" json:"billing-class,omitempty"`
 
@@ -969,6 +1482,10 @@ type KiroKey struct {
 	// ProxyURL optionally overrides the global proxy for this configuration.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
 
+	// SourceAddress binds outbound connections for this key to a local IP address or network
+	// interface name, so upstreams see a distinct origin per credential.
+	SourceAddress string `yaml:"source-address,omitempty" json:"source-address,omitempty"`
+
 	// AgentTaskType sets the Kiro API task type. Known values: "vibe", "dev", "chat".
 	// Leave empty to let API use defaults. Different values may inject different system prompts.
 	AgentTaskType string `yaml:"agent-task-type,omitempty" json:"agent-task-type,omitempty"`
@@ -1036,6 +1553,10 @@ type OpenAICompatibilityAPIKey struct {
 
 	// ProxyURL overrides the global proxy setting for this API key if provided.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
+
+	// SourceAddress binds outbound connections for this key to a local IP address or network
+	// interface name, so upstreams see a distinct origin per credential.
+	SourceAddress string `yaml:"source-address,omitempty" json:"source-address,omitempty"`
 }
 
 // OpenAICompatibilityModel represents a model configuration for OpenAI compatibility,
@@ -0,0 +1,53 @@
+package config
+
+// MockProviderConfig configures the built-in "mock" provider. It answers from scripts
+// instead of a real upstream so fallback, cooldown and retry behavior can be exercised
+// end-to-end without credentials.
+type MockProviderConfig struct {
+	// Enabled registers the mock provider and its credentials.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// Credentials is the number of mock credentials to create (default 1). Several
+	// credentials let rotation and per-credential cooldown be observed.
+	Credentials int `yaml:"credentials,omitempty" json:"credentials,omitempty"`
+
+	// Prefix optionally namespaces the mock models.
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+
+	// Models lists the mock models and their scripts.
+	Models []MockModel `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// MockModel scripts the responses of one mock model.
+type MockModel struct {
+	// Name is the model ID clients request.
+	Name string `yaml:"name" json:"name"`
+
+	// Script lists the outcomes of successive requests to the model. Once exhausted the
+	// script starts over. An empty script always succeeds immediately.
+	Script []MockStep `yaml:"script,omitempty" json:"script,omitempty"`
+}
+
+// MockStep is one scripted outcome.
+type MockStep struct {
+	// Status is the HTTP status returned; 0 or 2xx succeeds.
+	Status int `yaml:"status,omitempty" json:"status,omitempty"`
+
+	// Message is the response text on success or the error message on failure.
+	Message string `yaml:"message,omitempty" json:"message,omitempty"`
+
+	// LatencyMs delays the response (or the first stream chunk).
+	LatencyMs int `yaml:"latency-ms,omitempty" json:"latency-ms,omitempty"`
+
+	// RetryAfterSeconds attaches a retry hint to a failure.
+	RetryAfterSeconds int `yaml:"retry-after-seconds,omitempty" json:"retry-after-seconds,omitempty"`
+
+	// Chunks splits a streamed response into this many content chunks (default 1).
+	Chunks int `yaml:"chunks,omitempty" json:"chunks,omitempty"`
+
+	// ChunkDelayMs waits between streamed chunks.
+	ChunkDelayMs int `yaml:"chunk-delay-ms,omitempty" json:"chunk-delay-ms,omitempty"`
+
+	// FailAfterChunks ends a successful stream with an error after this many chunks.
+	FailAfterChunks int `yaml:"fail-after-chunks,omitempty" json:"fail-after-chunks,omitempty"`
+}
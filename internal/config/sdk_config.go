@@ -9,6 +9,15 @@ type SDKConfig struct {
 	// ProxyURL is the URL of an optional proxy server to use for outbound requests.
 	ProxyURL string `yaml:"proxy-url" json:"proxy-url"`
 
+	// ProxyPools defines named pools of outbound proxies. Any proxy-url (global, per key or
+	// per auth file) set to "pool://<name>" rotates connections across the pool's members
+	// and skips members that recently failed to connect.
+	ProxyPools []ProxyPool `yaml:"proxy-pools,omitempty" json:"proxy-pools,omitempty"`
+
+	// OutboundTLS adds trusted CAs and client certificates to outbound HTTPS requests, for
+	// TLS-intercepting corporate proxies and gateways that require mutual TLS.
+	OutboundTLS OutboundTLSConfig `yaml:"outbound-tls,omitempty" json:"outbound-tls,omitempty"`
+
 	// DisableImageGeneration controls whether the built-in image_generation tool is injected/allowed.
 	//
 	// Supported values:
@@ -68,6 +77,250 @@ type SDKConfig struct {
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// Idempotency configures replay of non-streaming responses for requests carrying an Idempotency-Key header.
+	Idempotency IdempotencyConfig `yaml:"idempotency,omitempty" json:"idempotency,omitempty"`
+
+	// CostCeiling caps the estimated USD cost of a single request.
+	CostCeiling CostCeilingConfig `yaml:"cost-ceiling,omitempty" json:"cost-ceiling,omitempty"`
+
+	// RequestDeadline bounds the total time a request may spend on retries, cooldown waits
+	// and model fallbacks.
+	RequestDeadline RequestDeadlineConfig `yaml:"request-deadline,omitempty" json:"request-deadline,omitempty"`
+
+	// RequestCoalescing shares one upstream call among identical in-flight non-streaming requests.
+	RequestCoalescing RequestCoalescingConfig `yaml:"request-coalescing,omitempty" json:"request-coalescing,omitempty"`
+
+	// ResponseCache caches deterministic (temperature 0) non-streaming responses.
+	ResponseCache ResponseCacheConfig `yaml:"response-cache,omitempty" json:"response-cache,omitempty"`
+
+	// Batch configures the OpenAI-compatible /v1/files and /v1/batches endpoints.
+	Batch BatchConfig `yaml:"batch,omitempty" json:"batch,omitempty"`
+
+	// Sessions configures the /v1/sessions endpoints that keep conversation state in the proxy.
+	Sessions SessionsConfig `yaml:"sessions,omitempty" json:"sessions,omitempty"`
+
+	// ReasoningPolicy controls how reasoning output reaches OpenAI Chat Completions clients.
+	ReasoningPolicy ReasoningPolicyConfig `yaml:"reasoning-policy,omitempty" json:"reasoning-policy,omitempty"`
+
+	// APIKeyPolicies restricts what individual client API keys may request.
+	APIKeyPolicies map[string]APIKeyPolicy `yaml:"api-key-policies,omitempty" json:"api-key-policies,omitempty"`
+
+	// Tenants enables proxy API keys issued through the management API with daily quotas.
+	Tenants TenantsConfig `yaml:"tenants,omitempty" json:"tenants,omitempty"`
+
+	// RequestID controls propagation of the per-request ID to upstream providers.
+	RequestID RequestIDConfig `yaml:"request-id,omitempty" json:"request-id,omitempty"`
+
+	// Cassette records upstream provider traffic to cassette files or replays it from them.
+	Cassette CassetteConfig `yaml:"cassette,omitempty" json:"cassette,omitempty"`
+
+	// ModelMappings rewrites requested model names before routing. Rules are evaluated in
+	// order and the first match wins.
+	ModelMappings []ModelMapping `yaml:"model-mappings,omitempty" json:"model-mappings,omitempty"`
+
+	// ModelResolution maps near-miss model names onto registered models.
+	ModelResolution ModelResolutionConfig `yaml:"model-resolution,omitempty" json:"model-resolution,omitempty"`
+
+	// RoutingHeaders lets clients pin a request to a provider or credential, or disable
+	// model fallback, through X-CLIProxy-* request headers.
+	RoutingHeaders RoutingHeadersConfig `yaml:"routing-headers,omitempty" json:"routing-headers,omitempty"`
+
+	// ContextPreflight refuses requests whose estimated prompt cannot fit the model's
+	// context window before any upstream call is made.
+	ContextPreflight ContextPreflightConfig `yaml:"context-preflight,omitempty" json:"context-preflight,omitempty"`
+
+	// ContextTruncation drops the oldest turns of conversations that exceed the model's
+	// context window.
+	ContextTruncation ContextTruncationConfig `yaml:"context-truncation,omitempty" json:"context-truncation,omitempty"`
+
+	// HistoryCompression replaces the older turns of long conversations with a rolling
+	// summary written by a cheaper model.
+	HistoryCompression HistoryCompressionConfig `yaml:"history-compression,omitempty" json:"history-compression,omitempty"`
+}
+
+// RequestIDConfig configures upstream request ID propagation.
+type RequestIDConfig struct {
+	// UpstreamHeader names the header that carries the request ID on upstream requests
+	// (e.g. "X-Request-ID"). Empty disables propagation.
+	UpstreamHeader string `yaml:"upstream-header,omitempty" json:"upstream-header,omitempty"`
+
+	// Providers limits propagation to these providers. Empty applies to all providers.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+}
+
+// Cassette modes.
+const (
+	CassetteModeRecord = "record"
+	CassetteModeReplay = "replay"
+)
+
+// CassetteConfig configures deterministic record/replay of upstream traffic.
+type CassetteConfig struct {
+	// Mode is "record" to capture upstream exchanges, "replay" to serve them back without
+	// network access, or empty to disable.
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+
+	// Dir is the cassette directory. Defaults to "cassettes" in the working directory.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// Providers limits recording or replay to these providers. Empty applies to all providers.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+}
+
+// TenantsConfig configures the tenant subsystem.
+type TenantsConfig struct {
+	// Enabled accepts tenant API keys on the client endpoints and exposes tenant management.
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+
+	// StorePath is the JSON file holding tenants and their daily consumption.
+	// Empty keeps tenants in memory only.
+	StorePath string `yaml:"store-path,omitempty" json:"store-path,omitempty"`
+}
+
+// APIKeyPolicy limits the requests of one client API key. Policies are enforced before
+// credential selection; empty fields impose no restriction.
+type APIKeyPolicy struct {
+	// MaxTokens caps the output token limit of each request. <= 0 disables the cap.
+	MaxTokens int64 `yaml:"max-tokens,omitempty" json:"max-tokens,omitempty"`
+
+	// RejectOverMaxTokens rejects requests asking for more than MaxTokens instead of
+	// clamping them to the cap.
+	RejectOverMaxTokens bool `yaml:"reject-over-max-tokens,omitempty" json:"reject-over-max-tokens,omitempty"`
+
+	// AllowedModels lists the models the key may use. "*" in an entry matches any run of characters.
+	AllowedModels []string `yaml:"allowed-models,omitempty" json:"allowed-models,omitempty"`
+
+	// AllowedProviders lists the providers (e.g. "claude", "codex") that may serve the key.
+	AllowedProviders []string `yaml:"allowed-providers,omitempty" json:"allowed-providers,omitempty"`
+
+	// AuthGroups limits the credentials serving the key to these auth groups
+	// (e.g. "work"). Empty allows every credential.
+	AuthGroups []string `yaml:"auth-groups,omitempty" json:"auth-groups,omitempty"`
+
+	// Priority is the request priority class of the key: "interactive", "normal" or
+	// "batch". It takes precedence over the X-CLIProxy-Priority header.
+	Priority string `yaml:"priority,omitempty" json:"priority,omitempty"`
+
+	// ModelAliases maps client model names to the models routed for this key, resolved
+	// before global routing. Keys may use "*" wildcards; the longest match wins.
+	ModelAliases map[string]string `yaml:"model-aliases,omitempty" json:"model-aliases,omitempty"`
+}
+
+// Reasoning policies accepted by ReasoningPolicyConfig.
+const (
+	// ReasoningPolicyExpose forwards reasoning exactly as the provider returned it.
+	ReasoningPolicyExpose = "expose"
+	// ReasoningPolicyStrip removes reasoning_content fields and <think> blocks.
+	ReasoningPolicyStrip = "strip"
+	// ReasoningPolicyConvert moves <think> blocks from content into reasoning_content.
+	ReasoningPolicyConvert = "convert"
+)
+
+// ReasoningPolicyConfig selects a reasoning policy per client API key and per model.
+// A per-key policy wins over a per-model policy, which wins over Default.
+type ReasoningPolicyConfig struct {
+	// Default applies when no key or model entry matches. Empty means "expose".
+	Default string `yaml:"default,omitempty" json:"default,omitempty"`
+
+	// Models maps model names to a policy. "*" in a name matches any run of characters.
+	Models map[string]string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// APIKeys maps client API keys to a policy.
+	APIKeys map[string]string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+}
+
+// BatchConfig holds asynchronous batch processing configuration.
+type BatchConfig struct {
+	// Enabled exposes the /v1/files and /v1/batches endpoints.
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+
+	// Dir stores uploaded files, batch state, and results so batches survive restarts.
+	// Empty keeps everything in memory only.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// Concurrency bounds how many lines of one batch run at the same time. <= 0 uses the default (4).
+	Concurrency int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+
+	// MaxRequests caps the number of lines accepted in one input file. <= 0 uses the default (50000).
+	MaxRequests int `yaml:"max-requests,omitempty" json:"max-requests,omitempty"`
+
+	// MaxFileBytes caps the size of one uploaded input file. <= 0 uses the default (200MB).
+	MaxFileBytes int64 `yaml:"max-file-bytes,omitempty" json:"max-file-bytes,omitempty"`
+}
+
+// SessionsConfig configures server-side conversation sessions.
+type SessionsConfig struct {
+	// Enabled exposes the /v1/sessions endpoints.
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+
+	// RedisURL stores sessions in Redis (e.g. "redis://localhost:6379/0") so they are shared
+	// between instances and survive restarts. Empty keeps sessions in memory.
+	RedisURL string `yaml:"redis-url,omitempty" json:"redis-url,omitempty"`
+
+	// TTL is how long an idle session is kept, as a Go duration (default "24h").
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+}
+
+// ResponseCacheConfig holds response cache configuration.
+// Only non-streaming requests that explicitly set temperature to 0 are cached.
+type ResponseCacheConfig struct {
+	// Enabled turns on the response cache.
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+
+	// TTL controls how long a cached response is served. Accepts duration strings like "30m" or "24h".
+	// Empty or invalid values use the default 1h.
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+
+	// MaxEntries bounds the number of in-memory entries (least recently used are evicted). <= 0 uses the default (512).
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+
+	// Dir optionally stores cached responses on disk, one file per entry. Empty keeps entries in memory only.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+}
+
+// CostCeilingConfig holds request-level cost ceiling configuration.
+// Costs are estimated from the routing pricing table; models without a price are never limited.
+type CostCeilingConfig struct {
+	// MaxCost is the default ceiling in USD applied to every request. <= 0 disables the default.
+	MaxCost float64 `yaml:"max-cost,omitempty" json:"max-cost,omitempty"`
+
+	// APIKeys overrides MaxCost for specific client API keys.
+	APIKeys map[string]float64 `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+}
+
+// IdempotencyConfig holds Idempotency-Key replay configuration.
+type IdempotencyConfig struct {
+	// Enabled turns on response replay for non-streaming requests with an Idempotency-Key header.
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+
+	// TTL controls how long a stored response is replayed. Accepts duration strings like "10m" or "24h".
+	// Empty or invalid values use the default 24h.
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+
+	// MaxEntries bounds the number of stored responses. <= 0 uses the default (1024).
+	MaxEntries int `yaml:"max-entries,omitempty" json:"max-entries,omitempty"`
+
+	// PersistPath optionally points to a JSON snapshot file so stored responses survive restarts.
+	// Empty keeps entries in memory only.
+	PersistPath string `yaml:"persist-path,omitempty" json:"persist-path,omitempty"`
+}
+
+// RequestDeadlineConfig holds the overall per-request deadline configuration.
+type RequestDeadlineConfig struct {
+	// Timeout is the default budget for every request, e.g. "2m". Empty disables the default;
+	// clients may still set a budget with the X-CLIProxy-Deadline or X-Stainless-Timeout header.
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// RequestCoalescingConfig holds in-flight request coalescing configuration.
+type RequestCoalescingConfig struct {
+	// Enabled lets identical non-streaming requests of the same client key share one upstream call.
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled,omitempty"`
+
+	// Window bounds how long after the first request an identical one may still join it,
+	// e.g. "10s". Empty or invalid values use the default 10s.
+	Window string `yaml:"window,omitempty" json:"window,omitempty"`
 }
 
 // StreamingConfig holds server streaming behavior configuration.
@@ -76,8 +329,154 @@ type StreamingConfig struct {
 	// <= 0 disables keep-alives. Default is 0.
 	KeepAliveSeconds int `yaml:"keepalive-seconds,omitempty" json:"keepalive-seconds,omitempty"`
 
+	// KeepAliveComment is the SSE comment text sent as heartbeat, e.g. "ping" for ": ping".
+	// Empty uses "keep-alive".
+	KeepAliveComment string `yaml:"keepalive-comment,omitempty" json:"keepalive-comment,omitempty"`
+
+	// IdleTimeoutSeconds fails an upstream stream that produces no chunk for this long with a
+	// retryable error, so stalled providers are failed over (before the first byte) or ended
+	// cleanly instead of holding the connection. <= 0 disables the timeout. Default is 0.
+	IdleTimeoutSeconds int `yaml:"idle-timeout-seconds,omitempty" json:"idle-timeout-seconds,omitempty"`
+
+	// FirstChunkTimeoutSeconds bounds the wait for the first chunk of an upstream stream.
+	// When it elapses nothing has reached the client yet, so the request fails over to the
+	// next credential. <= 0 falls back to IdleTimeoutSeconds. Default is 0.
+	FirstChunkTimeoutSeconds int `yaml:"first-chunk-timeout-seconds,omitempty" json:"first-chunk-timeout-seconds,omitempty"`
+
+	// MaxEventBytes bounds a single line or event read from an upstream stream. Larger
+	// events end the stream with a descriptive error chunk. <= 0 uses 50 MiB.
+	MaxEventBytes int `yaml:"max-event-bytes,omitempty" json:"max-event-bytes,omitempty"`
+
 	// BootstrapRetries controls how many times the server may retry a streaming request before any bytes are sent,
 	// to allow auth rotation / transient recovery.
 	// <= 0 disables bootstrap retries. Default is 0.
 	BootstrapRetries int `yaml:"bootstrap-retries,omitempty" json:"bootstrap-retries,omitempty"`
+
+	// ResumeAttempts controls how many times a stream that fails after bytes were sent is
+	// continued on another credential, with the text already sent passed back as context.
+	// Supported for OpenAI chat, Claude and Gemini streams. <= 0 disables resume. Default is 0.
+	ResumeAttempts int `yaml:"resume-attempts,omitempty" json:"resume-attempts,omitempty"`
+}
+
+// ModelMapping rewrites a requested model name. From is matched against the whole name,
+// case-insensitively: literally, as a glob when it contains "*", or as a regular expression
+// when Regex is set. For glob and regex rules To may reference the captured text, "$1" being
+// the first "*" or capture group (e.g. "claude-(.*)" -> "anthropic/$1").
+type ModelMapping struct {
+	From  string `yaml:"from" json:"from"`
+	To    string `yaml:"to" json:"to"`
+	Regex bool   `yaml:"regex,omitempty" json:"regex,omitempty"`
+}
+
+// ModelResolutionConfig configures resolution of requested model names that match no
+// registered model, provider or alias.
+type ModelResolutionConfig struct {
+	// Enabled turns on near-miss matching ("gemini-2.5pro" -> "gemini-2.5-pro") and
+	// "-latest" resolution to the newest registered model of a family.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// LatestPins fixes what a "-latest" name resolves to instead of picking the newest
+	// registered model, e.g. {"claude-sonnet-latest": "claude-sonnet-4-5-20250929"}.
+	LatestPins map[string]string `yaml:"latest-pins,omitempty" json:"latest-pins,omitempty"`
+}
+
+// RoutingHeadersConfig configures per-request routing overrides sent as request headers.
+// Overrides that are not allowed are ignored and the request is routed normally.
+type RoutingHeadersConfig struct {
+	// Enabled turns on X-CLIProxy-Provider, X-CLIProxy-Auth-ID and X-CLIProxy-No-Fallback.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// APIKeys limits the overrides to these client API keys. Empty allows every client.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+
+	// Providers limits X-CLIProxy-Provider to these providers. Empty allows every provider.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+
+	// AllowAuthID permits X-CLIProxy-Auth-ID to pin a request to one credential.
+	AllowAuthID bool `yaml:"allow-auth-id,omitempty" json:"allow-auth-id,omitempty"`
+
+	// AllowNoFallback permits X-CLIProxy-No-Fallback to disable model fallback.
+	AllowNoFallback bool `yaml:"allow-no-fallback,omitempty" json:"allow-no-fallback,omitempty"`
+}
+
+// ContextPreflightConfig configures the pre-dispatch context window check. Prompt tokens
+// are estimated locally; requests whose model has no known context window are not checked.
+type ContextPreflightConfig struct {
+	// Enabled turns on the check. Oversized requests get a 400 with code
+	// "context_length_exceeded".
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// ClampMaxTokens lowers an output token limit that does not fit next to the prompt
+	// instead of refusing the request.
+	ClampMaxTokens bool `yaml:"clamp-max-tokens,omitempty" json:"clamp-max-tokens,omitempty"`
+}
+
+// ContextTruncationConfig configures the sliding context window for OpenAI and Claude
+// conversations. System messages and the latest turn are always kept, and whole turns are
+// dropped so tool calls stay paired with their results.
+type ContextTruncationConfig struct {
+	// Enabled turns on truncation. The number of dropped items is returned in the
+	// X-CLIProxy-Context-Truncated response header.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+}
+
+// HistoryCompressionConfig configures conversation summarization for OpenAI and Claude
+// requests. Summaries are cached per client API key and conversation prefix.
+type HistoryCompressionConfig struct {
+	// Enabled turns on summarization. The number of replaced items is returned in the
+	// X-CLIProxy-History-Summarized response header.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// Model is the summarizer model, called through the proxy like any client request.
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+
+	// ThresholdTokens is the estimated prompt size above which history is summarized
+	// (default 32000).
+	ThresholdTokens int `yaml:"threshold-tokens,omitempty" json:"threshold-tokens,omitempty"`
+
+	// KeepTurns is the number of latest turns forwarded verbatim (default 2).
+	KeepTurns int `yaml:"keep-turns,omitempty" json:"keep-turns,omitempty"`
+
+	// APIKeys limits summarization to these client API keys. Empty applies to all clients.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+
+	// CacheTTL is how long a summary is reused, as a Go duration (default "1h").
+	CacheTTL string `yaml:"cache-ttl,omitempty" json:"cache-ttl,omitempty"`
+}
+
+// ProxyPool is a named set of outbound proxies used round-robin.
+type ProxyPool struct {
+	// Name is referenced as "pool://<name>" from proxy-url settings.
+	Name string `yaml:"name" json:"name"`
+
+	// Proxies lists the member proxy URLs (socks5, http, https or "direct").
+	Proxies []string `yaml:"proxies" json:"proxies"`
+
+	// HealthCheckURL is fetched through every member periodically; members failing it are
+	// skipped until they pass again. Empty disables active checks, in which case a member
+	// that failed to connect is retried after the interval.
+	HealthCheckURL string `yaml:"health-check-url,omitempty" json:"health-check-url,omitempty"`
+
+	// HealthCheckIntervalSeconds is the check period and the time a failed member is
+	// skipped. <= 0 uses 30 seconds.
+	HealthCheckIntervalSeconds int `yaml:"health-check-interval-seconds,omitempty" json:"health-check-interval-seconds,omitempty"`
+}
+
+// OutboundTLSOptions locates the PEM files used for outbound TLS.
+type OutboundTLSOptions struct {
+	// CAFile is a CA bundle trusted in addition to the system roots.
+	CAFile string `yaml:"ca-file,omitempty" json:"ca-file,omitempty"`
+
+	// CertFile and KeyFile are the client certificate and key presented for mutual TLS.
+	CertFile string `yaml:"cert-file,omitempty" json:"cert-file,omitempty"`
+	KeyFile  string `yaml:"key-file,omitempty" json:"key-file,omitempty"`
+}
+
+// OutboundTLSConfig holds the global outbound TLS options and per-provider overrides.
+type OutboundTLSConfig struct {
+	OutboundTLSOptions `yaml:",inline"`
+
+	// Providers overrides the global options per provider (e.g. "claude", "gemini"). Unset
+	// fields inherit the global values.
+	Providers map[string]OutboundTLSOptions `yaml:"providers,omitempty" json:"providers,omitempty"`
 }
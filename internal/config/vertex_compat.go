@@ -27,6 +27,10 @@ type VertexCompatKey struct {
 	// ProxyURL optionally overrides the global proxy for this API key.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
 
+	// SourceAddress binds outbound connections for this key to a local IP address or network
+	// interface name, so upstreams see a distinct origin per credential.
+	SourceAddress string `yaml:"source-address,omitempty" json:"source-address,omitempty"`
+
 	// BillingClass classifies this credential for threshold-based routing policies.
 	BillingClass BillingClass `yaml:"billing-class,omitempty" json:"billing-class,omitempty"`
 
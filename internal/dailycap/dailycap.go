@@ -0,0 +1,406 @@
+// Package dailycap enforces per-credential daily request and token caps. Usage records are
+// counted per credential and quota day; a credential that reaches a cap is benched until its
+// provider's next daily reset, so selection moves on before the provider answers with 429s.
+package dailycap
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// Attributes (or auth file keys) overriding the configured caps of one credential.
+const (
+	RequestCapAttribute = "daily_request_cap"
+	TokenCapAttribute   = "daily_token_cap"
+)
+
+// Status is the current quota day of one tracked credential.
+type Status struct {
+	AuthID      string    `json:"auth_id"`
+	Provider    string    `json:"provider,omitempty"`
+	PeriodStart time.Time `json:"period_start"`
+	ResetAt     time.Time `json:"reset_at"`
+	Requests    int64     `json:"requests"`
+	Tokens      int64     `json:"tokens"`
+	RequestCap  int64     `json:"request_cap,omitempty"`
+	TokenCap    int64     `json:"token_cap,omitempty"`
+	Benched     bool      `json:"benched,omitempty"`
+}
+
+// rule holds the caps and daily reset of a provider.
+type rule struct {
+	requests int64
+	tokens   int64
+	hour     int
+	minute   int
+	location *time.Location
+}
+
+// periodStart returns the start of the quota day containing t.
+func (r rule) periodStart(t time.Time) time.Time {
+	local := t.In(r.location)
+	start := time.Date(local.Year(), local.Month(), local.Day(), r.hour, r.minute, 0, 0, r.location)
+	if local.Before(start) {
+		start = time.Date(local.Year(), local.Month(), local.Day()-1, r.hour, r.minute, 0, 0, r.location)
+	}
+	return start
+}
+
+// resetAfter returns the reset ending the quota day that starts at start.
+func (r rule) resetAfter(start time.Time) time.Time {
+	return time.Date(start.Year(), start.Month(), start.Day()+1, r.hour, r.minute, 0, 0, r.location)
+}
+
+type usageEntry struct {
+	provider string
+	period   time.Time
+	requests int64
+	tokens   int64
+	benched  bool
+}
+
+// Tracker is a usage plugin counting daily usage per credential and benching the
+// credentials that reach their caps.
+type Tracker struct {
+	mu        sync.Mutex
+	cfg       config.DailyCapsConfig
+	defaults  rule
+	providers map[string]rule
+	auths     *coreauth.Manager
+	usage     map[string]*usageEntry
+
+	// counters seeds usage after a restart; nil uses the default usage manager's counters.
+	counters *coreusage.Counters
+	now      func() time.Time
+}
+
+var defaultTracker = NewTracker()
+
+func init() {
+	coreusage.RegisterPlugin(defaultTracker)
+}
+
+// Default returns the process-wide tracker fed by the default usage manager.
+func Default() *Tracker { return defaultTracker }
+
+// NewTracker creates a disabled tracker.
+func NewTracker() *Tracker {
+	return &Tracker{
+		defaults: rule{location: time.UTC},
+		usage:    make(map[string]*usageEntry),
+	}
+}
+
+// Configure applies the daily-caps settings and the manager used to bench credentials.
+// Usage counted so far is kept across reloads. When the quota day matches the UTC days of
+// the persisted usage counters, credentials not counted yet are seeded from them.
+func (t *Tracker) Configure(cfg config.DailyCapsConfig, auths *coreauth.Manager) {
+	if t == nil {
+		return
+	}
+	defaults := parseRule(rule{location: time.UTC}, cfg.Requests, cfg.Tokens, cfg.ResetTime, cfg.Timezone, "daily-caps")
+	providers := make(map[string]rule, len(cfg.Providers))
+	for name, override := range cfg.Providers {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if key == "" {
+			continue
+		}
+		providers[key] = parseRule(defaults, override.Requests, override.Tokens, override.ResetTime, override.Timezone, "daily-caps.providers."+key)
+	}
+
+	t.mu.Lock()
+	t.cfg = cfg
+	t.defaults = defaults
+	t.providers = providers
+	t.auths = auths
+	t.mu.Unlock()
+
+	if cfg.Enabled && auths != nil {
+		t.seed(auths.List())
+	}
+}
+
+// parseRule applies the non-zero settings over base, logging and ignoring invalid ones.
+func parseRule(base rule, requests, tokens int64, resetTime, timezone, path string) rule {
+	r := base
+	if requests > 0 {
+		r.requests = requests
+	}
+	if tokens > 0 {
+		r.tokens = tokens
+	}
+	if raw := strings.TrimSpace(resetTime); raw != "" {
+		parsed, errParse := time.Parse("15:04", raw)
+		if errParse != nil {
+			log.Warnf("invalid %s.reset-time %q, ignoring", path, raw)
+		} else {
+			r.hour, r.minute = parsed.Hour(), parsed.Minute()
+		}
+	}
+	if raw := strings.TrimSpace(timezone); raw != "" {
+		location, errLoad := time.LoadLocation(raw)
+		if errLoad != nil {
+			log.Warnf("invalid %s.timezone %q, ignoring", path, raw)
+		} else {
+			r.location = location
+		}
+	}
+	return r
+}
+
+func (t *Tracker) currentTime() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
+
+func (t *Tracker) countersStore() *coreusage.Counters {
+	if t.counters != nil {
+		return t.counters
+	}
+	return coreusage.DefaultManager().Counters()
+}
+
+// ruleLocked returns the caps and reset schedule of auth: its provider's rule with the
+// credential's own cap attributes applied.
+func (t *Tracker) ruleLocked(auth *coreauth.Auth) rule {
+	r, ok := t.providers[strings.ToLower(strings.TrimSpace(auth.Provider))]
+	if !ok {
+		r = t.defaults
+	}
+	if limit, ok := authCap(auth, RequestCapAttribute); ok {
+		r.requests = limit
+	}
+	if limit, ok := authCap(auth, TokenCapAttribute); ok {
+		r.tokens = limit
+	}
+	return r
+}
+
+// authCap reads a cap from the auth attributes or, failing that, its metadata.
+func authCap(auth *coreauth.Auth, key string) (int64, bool) {
+	if raw := strings.TrimSpace(auth.Attributes[key]); raw != "" {
+		if parsed, errParse := strconv.ParseInt(raw, 10, 64); errParse == nil && parsed >= 0 {
+			return parsed, true
+		}
+	}
+	switch v := auth.Metadata[key].(type) {
+	case float64:
+		if v >= 0 {
+			return int64(v), true
+		}
+	case int:
+		if v >= 0 {
+			return int64(v), true
+		}
+	case int64:
+		if v >= 0 {
+			return v, true
+		}
+	case json.Number:
+		if parsed, errParse := v.Int64(); errParse == nil && parsed >= 0 {
+			return parsed, true
+		}
+	case string:
+		if parsed, errParse := strconv.ParseInt(strings.TrimSpace(v), 10, 64); errParse == nil && parsed >= 0 {
+			return parsed, true
+		}
+	}
+	return 0, false
+}
+
+func (r rule) exceeded(entry *usageEntry) bool {
+	return (r.requests > 0 && entry.requests >= r.requests) || (r.tokens > 0 && entry.tokens >= r.tokens)
+}
+
+// HandleUsage implements usage.Plugin and counts successful requests against the caps of
+// their credential.
+func (t *Tracker) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if t == nil || record.AuthID == "" || record.Failed {
+		return
+	}
+	t.mu.Lock()
+	enabled, auths := t.cfg.Enabled, t.auths
+	t.mu.Unlock()
+	if !enabled || auths == nil {
+		return
+	}
+	auth, ok := auths.GetByID(record.AuthID)
+	if !ok || auth == nil {
+		return
+	}
+	at := record.RequestedAt
+	if at.IsZero() {
+		at = t.currentTime()
+	}
+	tokens := record.Detail.TotalTokens
+	if tokens <= 0 {
+		tokens = record.Detail.InputTokens + record.Detail.OutputTokens
+	}
+
+	t.mu.Lock()
+	r := t.ruleLocked(auth)
+	if r.requests <= 0 && r.tokens <= 0 {
+		t.mu.Unlock()
+		return
+	}
+	period := r.periodStart(at)
+	entry := t.usage[auth.ID]
+	if entry == nil || entry.period.Before(period) {
+		entry = &usageEntry{provider: auth.Provider, period: period}
+		t.usage[auth.ID] = entry
+	} else if entry.period.After(period) {
+		// A late record for a past quota day does not count against the current one.
+		t.mu.Unlock()
+		return
+	}
+	entry.requests++
+	entry.tokens += tokens
+	bench := !entry.benched && r.exceeded(entry)
+	if bench {
+		entry.benched = true
+	}
+	snapshot := *entry
+	t.mu.Unlock()
+
+	if bench {
+		t.bench(ctx, auths, auth.ID, r, &snapshot)
+	}
+}
+
+func (t *Tracker) bench(ctx context.Context, auths *coreauth.Manager, authID string, r rule, entry *usageEntry) {
+	resetAt := r.resetAfter(entry.period)
+	reason := fmt.Sprintf("daily cap reached (%d requests, %d tokens)", entry.requests, entry.tokens)
+	if _, errBench := auths.Bench(context.WithoutCancel(ctx), authID, resetAt, reason); errBench != nil {
+		log.Warnf("daily cap: failed to bench auth %s: %v", authID, errBench)
+		return
+	}
+	log.WithFields(log.Fields{
+		"auth":     authID,
+		"provider": entry.provider,
+		"requests": entry.requests,
+		"tokens":   entry.tokens,
+		"reset_at": resetAt.Format(time.RFC3339),
+	}).Warn("daily cap: credential benched until quota reset")
+}
+
+// seed loads today's usage of credentials not counted yet from the persisted usage
+// counters. Only quota days aligned with UTC days match the counter buckets.
+func (t *Tracker) seed(list []*coreauth.Auth) {
+	counters := t.countersStore()
+	if counters == nil {
+		return
+	}
+	now := t.currentTime()
+	type pending struct {
+		auth  *coreauth.Auth
+		rule  rule
+		entry *usageEntry
+	}
+	var benches []pending
+	t.mu.Lock()
+	auths := t.auths
+	for _, auth := range list {
+		if auth == nil {
+			continue
+		}
+		r := t.ruleLocked(auth)
+		if r.requests <= 0 && r.tokens <= 0 {
+			continue
+		}
+		period := r.periodStart(now)
+		utc := period.UTC()
+		if utc.Hour() != 0 || utc.Minute() != 0 {
+			continue
+		}
+		if entry := t.usage[auth.ID]; entry != nil && !entry.period.Before(period) {
+			continue
+		}
+		day := utc.Format("2006-01-02")
+		totals := counters.Totals(coreusage.CounterFilter{AuthID: auth.ID, From: day, To: day})
+		entry := &usageEntry{provider: auth.Provider, period: period, requests: totals.Requests - totals.Failed, tokens: totals.TotalTokens}
+		t.usage[auth.ID] = entry
+		if r.exceeded(entry) && !auth.BenchedUntil.After(now) {
+			entry.benched = true
+			snapshot := *entry
+			benches = append(benches, pending{auth: auth, rule: r, entry: &snapshot})
+		}
+	}
+	t.mu.Unlock()
+
+	for _, p := range benches {
+		t.bench(context.Background(), auths, p.auth.ID, p.rule, p.entry)
+	}
+}
+
+// Reset clears the current quota day of authID and lifts its bench.
+func (t *Tracker) Reset(ctx context.Context, authID string) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	delete(t.usage, authID)
+	auths := t.auths
+	t.mu.Unlock()
+	if auths == nil {
+		return coreauth.ErrAuthNotFound
+	}
+	_, errUnbench := auths.Unbench(ctx, authID)
+	return errUnbench
+}
+
+// Statuses returns the current quota day of every tracked credential.
+func (t *Tracker) Statuses() []Status {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	auths := t.auths
+	t.mu.Unlock()
+	if auths == nil {
+		return []Status{}
+	}
+	now := t.currentTime()
+	list := auths.List()
+	t.mu.Lock()
+	out := make([]Status, 0, len(t.usage))
+	for _, auth := range list {
+		if auth == nil {
+			continue
+		}
+		entry := t.usage[auth.ID]
+		if entry == nil {
+			continue
+		}
+		r := t.ruleLocked(auth)
+		period := r.periodStart(now)
+		status := Status{
+			AuthID:      auth.ID,
+			Provider:    auth.Provider,
+			PeriodStart: period.UTC(),
+			ResetAt:     r.resetAfter(period).UTC(),
+			RequestCap:  r.requests,
+			TokenCap:    r.tokens,
+			Benched:     auth.BenchedUntil.After(now),
+		}
+		if entry.period.Equal(period) {
+			status.Requests, status.Tokens = entry.requests, entry.tokens
+		}
+		out = append(out, status)
+	}
+	t.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].AuthID < out[j].AuthID })
+	return out
+}
@@ -0,0 +1,121 @@
+package dailycap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func newTestTracker(t *testing.T, cfg config.DailyCapsConfig, now time.Time, auths ...*coreauth.Auth) (*Tracker, *coreauth.Manager) {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	for _, auth := range auths {
+		if _, err := manager.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register auth: %v", err)
+		}
+	}
+	tracker := NewTracker()
+	tracker.counters = coreusage.NewCounters(coreusage.CountersOptions{})
+	tracker.now = func() time.Time { return now }
+	tracker.Configure(cfg, manager)
+	return tracker, manager
+}
+
+func capRecord(authID string, at time.Time) coreusage.Record {
+	return coreusage.Record{Provider: "gemini-cli", AuthID: authID, RequestedAt: at, Detail: coreusage.Detail{TotalTokens: 100}}
+}
+
+func TestTrackerBenchesAuthUntilProviderReset(t *testing.T) {
+	now := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC) // 12:00 in Los Angeles
+	cfg := config.DailyCapsConfig{
+		Enabled:   true,
+		Providers: map[string]config.DailyCapRule{"gemini-cli": {Requests: 3, Timezone: "America/Los_Angeles"}},
+	}
+	tracker, manager := newTestTracker(t, cfg, now, &coreauth.Auth{ID: "auth-1", Provider: "gemini-cli"})
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		tracker.HandleUsage(ctx, capRecord("auth-1", now))
+	}
+	if auth, _ := manager.GetByID("auth-1"); !auth.BenchedUntil.IsZero() {
+		t.Fatal("auth benched before reaching its cap")
+	}
+	failed := capRecord("auth-1", now)
+	failed.Failed = true
+	tracker.HandleUsage(ctx, failed)
+	if auth, _ := manager.GetByID("auth-1"); !auth.BenchedUntil.IsZero() {
+		t.Fatal("failed requests must not count against the cap")
+	}
+
+	tracker.HandleUsage(ctx, capRecord("auth-1", now))
+	auth, _ := manager.GetByID("auth-1")
+	want := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC) // midnight in Los Angeles
+	if !auth.BenchedUntil.Equal(want) {
+		t.Fatalf("BenchedUntil = %v, want %v", auth.BenchedUntil, want)
+	}
+	statuses := tracker.Statuses()
+	if len(statuses) != 1 || statuses[0].Requests != 3 || statuses[0].Tokens != 300 || !statuses[0].Benched || !statuses[0].ResetAt.Equal(want) {
+		t.Fatalf("statuses = %+v", statuses)
+	}
+
+	if err := tracker.Reset(ctx, "auth-1"); err != nil {
+		t.Fatalf("Reset: %v", err)
+	}
+	if auth, _ = manager.GetByID("auth-1"); !auth.BenchedUntil.IsZero() {
+		t.Fatal("Reset should lift the bench")
+	}
+}
+
+func TestTrackerStartsNewQuotaDayAtReset(t *testing.T) {
+	now := time.Date(2026, 3, 1, 6, 30, 0, 0, time.UTC)
+	cfg := config.DailyCapsConfig{Enabled: true, Tokens: 250, ResetTime: "07:00"}
+	tracker, manager := newTestTracker(t, cfg, now, &coreauth.Auth{ID: "auth-1", Provider: "claude"})
+	ctx := context.Background()
+
+	tracker.HandleUsage(ctx, capRecord("auth-1", now))
+	tracker.HandleUsage(ctx, capRecord("auth-1", now))
+	tracker.HandleUsage(ctx, capRecord("auth-1", now.Add(time.Hour)))
+	if auth, _ := manager.GetByID("auth-1"); !auth.BenchedUntil.IsZero() {
+		t.Fatal("usage of the previous quota day must not count after the reset")
+	}
+	tracker.HandleUsage(ctx, capRecord("auth-1", now.Add(-time.Hour)))
+	if auth, _ := manager.GetByID("auth-1"); !auth.BenchedUntil.IsZero() {
+		t.Fatal("late records of a past quota day must not count")
+	}
+}
+
+func TestTrackerHonoursPerAuthCapAttribute(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cfg := config.DailyCapsConfig{Enabled: true, Requests: 100}
+	auth := &coreauth.Auth{ID: "auth-1", Provider: "claude", Attributes: map[string]string{RequestCapAttribute: "1"}}
+	tracker, manager := newTestTracker(t, cfg, now, auth)
+
+	tracker.HandleUsage(context.Background(), capRecord("auth-1", now))
+	if got, _ := manager.GetByID("auth-1"); got.BenchedUntil.IsZero() {
+		t.Fatal("per-auth cap should bench the auth after one request")
+	}
+}
+
+func TestConfigureSeedsUsageFromCounters(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	counters := coreusage.NewCounters(coreusage.CountersOptions{})
+	for i := 0; i < 2; i++ {
+		counters.HandleUsage(context.Background(), coreusage.Record{AuthID: "auth-1", Model: "m", RequestedAt: now.Add(-time.Hour)})
+	}
+	manager := coreauth.NewManager(nil, nil, nil)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: "auth-1", Provider: "claude"}); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	tracker := NewTracker()
+	tracker.counters = counters
+	tracker.now = func() time.Time { return now }
+	tracker.Configure(config.DailyCapsConfig{Enabled: true, Requests: 2}, manager)
+
+	if auth, _ := manager.GetByID("auth-1"); auth.BenchedUntil.IsZero() {
+		t.Fatal("auth at its cap according to the usage counters should be benched on configure")
+	}
+}
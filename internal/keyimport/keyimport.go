@@ -0,0 +1,379 @@
+// Package keyimport registers provider API keys in bulk from a CSV or JSON manifest.
+// Every row is checked, optionally probed with a lightweight models-list call, and then
+// appended to the matching API key list of the configuration, with a result per row.
+package keyimport
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/httpfetch"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/proxyutil"
+)
+
+// Row statuses reported in a Result.
+const (
+	StatusAdded     = "added"
+	StatusDuplicate = "duplicate"
+	StatusInvalid   = "invalid"
+	StatusFailed    = "failed"
+)
+
+const (
+	probeTimeout     = 15 * time.Second
+	probeMaxBody     = 4 << 20
+	probeConcurrency = 8
+)
+
+// Row is one API key of a manifest.
+type Row struct {
+	Provider string `json:"provider"`
+	Key      string `json:"key"`
+	Label    string `json:"label,omitempty"`
+	Weight   int    `json:"weight,omitempty"`
+	Proxy    string `json:"proxy,omitempty"`
+	BaseURL  string `json:"base-url,omitempty"`
+
+	// invalid records a problem found while parsing the row.
+	invalid string
+}
+
+// Result reports what happened to one manifest row. Row is 1-based and Key is masked.
+type Result struct {
+	Row      int    `json:"row"`
+	Provider string `json:"provider,omitempty"`
+	Key      string `json:"key,omitempty"`
+	Label    string `json:"label,omitempty"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Validator checks that the key of row works, typically with a call to the provider.
+type Validator func(ctx context.Context, row Row) error
+
+// providers maps the accepted provider names to the provider of the config list they fill.
+var providers = map[string]string{
+	"gemini":    "gemini",
+	"claude":    "claude",
+	"anthropic": "claude",
+	"codex":     "codex",
+	"openai":    "codex",
+	"xai":       "xai",
+	"grok":      "xai",
+	"mistral":   "mistral",
+}
+
+// defaultBaseURLs are stored for providers whose config entries require a base URL and are
+// used by the probe for the others.
+var defaultBaseURLs = map[string]string{
+	"gemini":  "https://generativelanguage.googleapis.com",
+	"claude":  "https://api.anthropic.com",
+	"codex":   "https://api.openai.com/v1",
+	"xai":     "https://api.x.ai/v1",
+	"mistral": "https://api.mistral.ai",
+}
+
+// Parse reads a manifest: a JSON array of rows, a JSON object with an "items" array, or
+// CSV with a header line naming the columns provider, key, label, weight, proxy and base-url.
+func Parse(data []byte) ([]Row, error) {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return nil, errors.New("manifest is empty")
+	}
+	switch trimmed[0] {
+	case '[':
+		var rows []Row
+		if errUnmarshal := json.Unmarshal(trimmed, &rows); errUnmarshal != nil {
+			return nil, fmt.Errorf("invalid json manifest: %w", errUnmarshal)
+		}
+		return rows, nil
+	case '{':
+		var body struct {
+			Items []Row `json:"items"`
+		}
+		if errUnmarshal := json.Unmarshal(trimmed, &body); errUnmarshal != nil {
+			return nil, fmt.Errorf("invalid json manifest: %w", errUnmarshal)
+		}
+		return body.Items, nil
+	}
+	return parseCSV(trimmed)
+}
+
+func parseCSV(data []byte) ([]Row, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	header, errHeader := reader.Read()
+	if errHeader != nil {
+		return nil, fmt.Errorf("invalid csv manifest: %w", errHeader)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		name = strings.ReplaceAll(name, "_", "-")
+		switch name {
+		case "api-key":
+			name = "key"
+		case "proxy-url":
+			name = "proxy"
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["provider"]; !ok {
+		return nil, errors.New("csv manifest header must name a provider column")
+	}
+	if _, ok := columns["key"]; !ok {
+		return nil, errors.New("csv manifest header must name a key column")
+	}
+
+	var rows []Row
+	for {
+		record, errRead := reader.Read()
+		if errors.Is(errRead, io.EOF) {
+			break
+		}
+		if errRead != nil {
+			return nil, fmt.Errorf("invalid csv manifest: %w", errRead)
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		row := Row{
+			Provider: field("provider"),
+			Key:      field("key"),
+			Label:    field("label"),
+			Proxy:    field("proxy"),
+			BaseURL:  field("base-url"),
+		}
+		if raw := field("weight"); raw != "" {
+			weight, errAtoi := strconv.Atoi(raw)
+			if errAtoi != nil {
+				row.invalid = fmt.Sprintf("invalid weight %q", raw)
+			}
+			row.Weight = weight
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// normalizeRow trims row, resolves its provider and fills the base URL of providers that
+// require one. It returns why the row cannot be imported, or "".
+func normalizeRow(row *Row) string {
+	if row.invalid != "" {
+		return row.invalid
+	}
+	row.Key = strings.TrimSpace(row.Key)
+	row.Label = strings.TrimSpace(row.Label)
+	row.Proxy = strings.TrimSpace(row.Proxy)
+	row.BaseURL = strings.TrimRight(strings.TrimSpace(row.BaseURL), "/")
+	provider, ok := providers[strings.ToLower(strings.TrimSpace(row.Provider))]
+	if !ok {
+		return fmt.Sprintf("unsupported provider %q", row.Provider)
+	}
+	row.Provider = provider
+	if row.Key == "" {
+		return "key is required"
+	}
+	if row.Weight < 0 {
+		return "weight must not be negative"
+	}
+	if row.BaseURL != "" {
+		if parsed, errParse := url.Parse(row.BaseURL); errParse != nil || parsed.Scheme == "" || parsed.Host == "" {
+			return fmt.Sprintf("invalid base-url %q", row.BaseURL)
+		}
+	}
+	if row.Proxy != "" {
+		if _, ok := proxyutil.PoolName(row.Proxy); !ok {
+			if _, errProxy := proxyutil.Parse(row.Proxy); errProxy != nil {
+				return errProxy.Error()
+			}
+		}
+	}
+	if row.BaseURL == "" && (provider == "codex" || provider == "xai") {
+		row.BaseURL = defaultBaseURLs[provider]
+	}
+	return ""
+}
+
+// Validate normalizes rows and checks each one, calling validate, when non-nil, for the
+// rows that pass the static checks. The returned results have an empty Status for rows
+// that are ready for Apply. It does not touch any configuration, so callers can run it
+// without holding the lock that guards their config.
+func Validate(ctx context.Context, rows []Row, validate Validator) []Result {
+	results := make([]Result, len(rows))
+	for i := range rows {
+		problem := normalizeRow(&rows[i])
+		results[i] = Result{
+			Row:      i + 1,
+			Provider: rows[i].Provider,
+			Key:      util.HideAPIKey(strings.TrimSpace(rows[i].Key)),
+			Label:    rows[i].Label,
+		}
+		if problem != "" {
+			results[i].Status = StatusInvalid
+			results[i].Error = problem
+		}
+	}
+	if validate == nil {
+		return results
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, probeConcurrency)
+	for i := range rows {
+		if results[i].Status != "" {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if errValidate := validate(ctx, rows[i]); errValidate != nil {
+				results[i].Status = StatusFailed
+				results[i].Error = errValidate.Error()
+			}
+		}(i)
+	}
+	wg.Wait()
+	return results
+}
+
+// Apply appends the rows that Validate left pending to the API key lists of cfg, marking
+// keys cfg already has as duplicates. It returns the number of keys added.
+func Apply(cfg *config.Config, rows []Row, results []Result) int {
+	if cfg == nil {
+		return 0
+	}
+	added := 0
+	for i := range rows {
+		if i >= len(results) || results[i].Status != "" {
+			continue
+		}
+		row := rows[i]
+		if hasKey(cfg, row) {
+			results[i].Status = StatusDuplicate
+			continue
+		}
+		switch row.Provider {
+		case "gemini":
+			cfg.GeminiKey = append(cfg.GeminiKey, config.GeminiKey{APIKey: row.Key, Comment: row.Label, Priority: row.Weight, BaseURL: row.BaseURL, ProxyURL: row.Proxy})
+		case "claude":
+			cfg.ClaudeKey = append(cfg.ClaudeKey, config.ClaudeKey{APIKey: row.Key, Comment: row.Label, Priority: row.Weight, BaseURL: row.BaseURL, ProxyURL: row.Proxy})
+		case "codex":
+			cfg.CodexKey = append(cfg.CodexKey, config.CodexKey{APIKey: row.Key, Comment: row.Label, Priority: row.Weight, BaseURL: row.BaseURL, ProxyURL: row.Proxy})
+		case "xai":
+			cfg.XAIKey = append(cfg.XAIKey, config.XAIKey{APIKey: row.Key, Comment: row.Label, Priority: row.Weight, BaseURL: row.BaseURL, ProxyURL: row.Proxy})
+		case "mistral":
+			cfg.MistralKey = append(cfg.MistralKey, config.MistralKey{APIKey: row.Key, Comment: row.Label, Priority: row.Weight, BaseURL: row.BaseURL, ProxyURL: row.Proxy})
+		}
+		results[i].Status = StatusAdded
+		added++
+	}
+	return added
+}
+
+// hasKey reports whether cfg already lists the key and base URL of row for its provider.
+func hasKey(cfg *config.Config, row Row) bool {
+	same := func(apiKey, baseURL string) bool {
+		return strings.TrimSpace(apiKey) == row.Key && strings.TrimRight(strings.TrimSpace(baseURL), "/") == row.BaseURL
+	}
+	switch row.Provider {
+	case "gemini":
+		for i := range cfg.GeminiKey {
+			if same(cfg.GeminiKey[i].APIKey, cfg.GeminiKey[i].BaseURL) {
+				return true
+			}
+		}
+	case "claude":
+		for i := range cfg.ClaudeKey {
+			if same(cfg.ClaudeKey[i].APIKey, cfg.ClaudeKey[i].BaseURL) {
+				return true
+			}
+		}
+	case "codex":
+		for i := range cfg.CodexKey {
+			if same(cfg.CodexKey[i].APIKey, cfg.CodexKey[i].BaseURL) {
+				return true
+			}
+		}
+	case "xai":
+		for i := range cfg.XAIKey {
+			if same(cfg.XAIKey[i].APIKey, cfg.XAIKey[i].BaseURL) {
+				return true
+			}
+		}
+	case "mistral":
+		for i := range cfg.MistralKey {
+			if same(cfg.MistralKey[i].APIKey, cfg.MistralKey[i].BaseURL) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Probe returns a Validator that lists the models of the provider with the key, through
+// the proxy of the row or else the global proxy of cfg.
+func Probe(cfg *config.Config) Validator {
+	globalProxy := ""
+	if cfg != nil {
+		globalProxy = cfg.ProxyURL
+	}
+	return func(ctx context.Context, row Row) error {
+		proxy := row.Proxy
+		if proxy == "" {
+			proxy = globalProxy
+		}
+		client := &http.Client{Timeout: probeTimeout}
+		if proxy != "" {
+			transport, _, errBuild := proxyutil.BuildRoundTripper(proxy)
+			if errBuild != nil {
+				return fmt.Errorf("proxy: %w", errBuild)
+			}
+			if transport != nil {
+				client.Transport = transport
+			}
+		}
+		requestURL, headers := probeRequest(row)
+		if _, errFetch := httpfetch.GetBytes(ctx, client, requestURL, headers, probeMaxBody); errFetch != nil {
+			return errFetch
+		}
+		return nil
+	}
+}
+
+// probeRequest returns the models-list URL and auth headers for the key of row.
+func probeRequest(row Row) (string, map[string]string) {
+	baseURL := row.BaseURL
+	if baseURL == "" {
+		baseURL = defaultBaseURLs[row.Provider]
+	}
+	switch row.Provider {
+	case "gemini":
+		return baseURL + "/v1beta/models", map[string]string{"x-goog-api-key": row.Key}
+	case "claude":
+		return baseURL + "/v1/models", map[string]string{"x-api-key": row.Key, "anthropic-version": "2023-06-01"}
+	case "mistral":
+		return baseURL + "/v1/models", map[string]string{"Authorization": "Bearer " + row.Key}
+	default:
+		return baseURL + "/models", map[string]string{"Authorization": "Bearer " + row.Key}
+	}
+}
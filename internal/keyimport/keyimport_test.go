@@ -0,0 +1,71 @@
+package keyimport
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestParseAcceptsJSONAndCSV(t *testing.T) {
+	jsonRows, errJSON := Parse([]byte(`{"items":[{"provider":"gemini","key":"g-1","weight":2}]}`))
+	if errJSON != nil || len(jsonRows) != 1 || jsonRows[0].Weight != 2 {
+		t.Fatalf("Parse(json) = %+v, %v", jsonRows, errJSON)
+	}
+
+	csvRows, errCSV := Parse([]byte("Provider,API_Key,Proxy_URL,weight\nxai,x-1,,abc\n"))
+	if errCSV != nil || len(csvRows) != 1 || csvRows[0].Key != "x-1" {
+		t.Fatalf("Parse(csv) = %+v, %v", csvRows, errCSV)
+	}
+	results := Validate(context.Background(), csvRows, nil)
+	if results[0].Status != StatusInvalid {
+		t.Fatalf("row with a bad weight = %+v, want invalid", results[0])
+	}
+
+	if _, errHeader := Parse([]byte("label,weight\nfoo,1\n")); errHeader == nil {
+		t.Fatal("Parse() accepted a csv manifest without provider and key columns")
+	}
+}
+
+func TestApplyFillsBaseURLAndSkipsDuplicates(t *testing.T) {
+	cfg := &config.Config{}
+	rows := []Row{
+		{Provider: "openai", Key: "sk-1", Label: "primary"},
+		{Provider: "codex", Key: "sk-1"},
+		{Provider: "mistral", Key: "m-1", Weight: 5},
+	}
+	results := Validate(context.Background(), rows, nil)
+	if added := Apply(cfg, rows, results); added != 2 {
+		t.Fatalf("Apply() added %d, want 2; results %+v", added, results)
+	}
+	if results[1].Status != StatusDuplicate {
+		t.Fatalf("repeated key status = %q, want duplicate", results[1].Status)
+	}
+	if len(cfg.CodexKey) != 1 || cfg.CodexKey[0].BaseURL != "https://api.openai.com/v1" || cfg.CodexKey[0].Comment != "primary" {
+		t.Fatalf("codex keys = %+v", cfg.CodexKey)
+	}
+	if len(cfg.MistralKey) != 1 || cfg.MistralKey[0].Priority != 5 {
+		t.Fatalf("mistral keys = %+v", cfg.MistralKey)
+	}
+}
+
+func TestProbeListsModelsWithKey(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" || r.Header.Get("x-api-key") != "good" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	probe := Probe(&config.Config{})
+	if errProbe := probe(context.Background(), Row{Provider: "claude", Key: "good", BaseURL: server.URL}); errProbe != nil {
+		t.Fatalf("probe(good) = %v", errProbe)
+	}
+	if errProbe := probe(context.Background(), Row{Provider: "claude", Key: "bad", BaseURL: server.URL}); errProbe == nil {
+		t.Fatal("probe(bad) succeeded, want an error")
+	}
+}
@@ -229,7 +229,8 @@ func getUpstreamRequestInfoFromContext(c *gin.Context) (url, model string) {
 
 // GinLogrusLogger returns a Gin middleware handler that logs HTTP requests and responses
 // using logrus. It captures request details including method, path, status code, latency,
-// client IP, model name, and auth key name. Request ID is only added for AI API requests.
+// client IP, model name, and auth key name. Request ID is only added for AI API requests;
+// a valid incoming X-Request-ID is reused, and the ID is echoed in the X-Request-ID response header.
 //
 // Output format (AI API): [2025-12-23 20:14:10] [info ] | a1b2c3d4 | 200 |       23.559s | ... | model (auth)
 // Output format (others): [2025-12-23 20:14:10] [info ] | -------- | 200 |       23.559s | ...
@@ -249,10 +250,15 @@ func GinLogrusLogger(cfg *config.Config) gin.HandlerFunc {
 			c.Set(requestBodyKey, requestBody)
 		}
 
-		// Only generate request ID for AI API paths
+		// Only track request IDs for AI API paths. A valid client-supplied X-Request-ID is
+		// reused so callers can correlate their own logs with ours.
 		var requestID string
 		if isAIAPIPath(path) {
-			requestID = GenerateRequestID()
+			requestID = NormalizeRequestID(c.GetHeader(RequestIDHeader))
+			if requestID == "" {
+				requestID = GenerateRequestID()
+			}
+			c.Header(RequestIDHeader, requestID)
 			SetGinRequestID(c, requestID)
 			ctx := WithRequestID(c.Request.Context(), requestID)
 			ctx = context.WithValue(ctx, "gin", c)
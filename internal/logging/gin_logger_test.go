@@ -392,3 +392,55 @@ func TestGinLogrusLoggerAddsRequestIDForCodexBackend(t *testing.T) {
 		t.Fatalf("expected Gin request ID %q to match context request ID %q", requestIDFromGin, requestIDFromContext)
 	}
 }
+
+func TestGinLogrusLoggerReusesIncomingRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	engine := gin.New()
+	engine.Use(GinLogrusLogger(nil))
+	var ctxRequestID string
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		ctxRequestID = GetRequestID(c.Request.Context())
+		c.Status(http.StatusBadRequest)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader([]byte(`{}`)))
+	req.Header.Set(RequestIDHeader, "client-req.42")
+	recorder := httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+
+	if ctxRequestID != "client-req.42" {
+		t.Fatalf("context request ID = %q, want client-req.42", ctxRequestID)
+	}
+	if got := recorder.Header().Get(RequestIDHeader); got != "client-req.42" {
+		t.Fatalf("response %s = %q, want client-req.42", RequestIDHeader, got)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", bytes.NewReader([]byte(`{}`)))
+	req.Header.Set(RequestIDHeader, "bad id\n")
+	recorder = httptest.NewRecorder()
+	engine.ServeHTTP(recorder, req)
+
+	if len(ctxRequestID) != 8 || ctxRequestID == "bad id" {
+		t.Fatalf("expected generated request ID for invalid input, got %q", ctxRequestID)
+	}
+	if got := recorder.Header().Get(RequestIDHeader); got != ctxRequestID {
+		t.Fatalf("response %s = %q, want %q", RequestIDHeader, got, ctxRequestID)
+	}
+}
+
+func TestNormalizeRequestID(t *testing.T) {
+	cases := map[string]string{
+		" abc-123 ":                            "abc-123",
+		"trace:1.2_3":                          "trace:1.2_3",
+		"":                                     "",
+		"has space":                            "",
+		"semi;colon":                           "",
+		string(bytes.Repeat([]byte("a"), 129)): "",
+	}
+	for input, want := range cases {
+		if got := NormalizeRequestID(input); got != want {
+			t.Errorf("NormalizeRequestID(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
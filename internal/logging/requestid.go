@@ -4,10 +4,18 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
+// RequestIDHeader is the header clients may use to supply a request ID and that carries
+// the request ID on downstream responses.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds client-supplied request IDs.
+const maxRequestIDLength = 128
+
 // requestIDKey is the context key for storing/retrieving request IDs.
 type requestIDKey struct{}
 
@@ -23,6 +31,26 @@ func GenerateRequestID() string {
 	return hex.EncodeToString(b)
 }
 
+// NormalizeRequestID returns raw trimmed when it is a usable request ID, or "" when it is
+// empty, longer than 128 characters, or contains characters other than letters, digits,
+// '.', '_', ':' and '-'.
+func NormalizeRequestID(raw string) string {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" || len(trimmed) > maxRequestIDLength {
+		return ""
+	}
+	for i := 0; i < len(trimmed); i++ {
+		ch := trimmed[i]
+		switch {
+		case ch >= 'a' && ch <= 'z', ch >= 'A' && ch <= 'Z', ch >= '0' && ch <= '9':
+		case ch == '.', ch == '_', ch == ':', ch == '-':
+		default:
+			return ""
+		}
+	}
+	return trimmed
+}
+
 // WithRequestID returns a new context with the request ID attached.
 func WithRequestID(ctx context.Context, requestID string) context.Context {
 	return context.WithValue(ctx, requestIDKey{}, requestID)
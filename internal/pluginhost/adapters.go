@@ -714,6 +714,26 @@ func (h *Host) HasRequestInterceptors() bool {
 	return false
 }
 
+// HasTranslatorHooks reports whether any active plugin normalizes or translates requests
+// or responses, which rules out translation passthrough.
+func (h *Host) HasTranslatorHooks() bool {
+	if h == nil {
+		return false
+	}
+	for _, record := range h.activeRecords() {
+		if h.isPluginFused(record.id) {
+			continue
+		}
+		capabilities := record.plugin.Capabilities
+		if capabilities.RequestNormalizer != nil || capabilities.RequestTranslator != nil ||
+			capabilities.ResponseBeforeTranslator != nil || capabilities.ResponseTranslator != nil ||
+			capabilities.ResponseAfterTranslator != nil {
+			return true
+		}
+	}
+	return false
+}
+
 func (h *Host) commitModelClients(snap *Snapshot, modelRegistry modelRegistry, registrations []modelClientRegistration, nextClients map[string]struct{}, nextProviders map[string]string, nextModelRegistrations map[string]pluginModelRegistration) {
 	if h == nil || modelRegistry == nil {
 		return
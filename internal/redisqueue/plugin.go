@@ -111,6 +111,7 @@ func (p *usageQueuePlugin) HandleUsage(ctx context.Context, record coreusage.Rec
 		ReasoningEffort:     reasoningEffort,
 		ServiceTier:         serviceTier,
 		ResponseServiceTier: responseServiceTier,
+		Background:          strings.TrimSpace(record.Background),
 	})
 	if err != nil {
 		return
@@ -133,6 +134,7 @@ type queuedUsageDetail struct {
 	ReasoningEffort     string                   `json:"reasoning_effort"`
 	ServiceTier         string                   `json:"service_tier"`
 	ResponseServiceTier string                   `json:"response_service_tier,omitempty"`
+	Background          string                   `json:"background,omitempty"`
 }
 
 type requestDetail struct {
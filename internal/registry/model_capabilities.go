@@ -0,0 +1,97 @@
+package registry
+
+import "strings"
+
+// Capability is a bit set of request features a model has to support.
+type Capability uint8
+
+const (
+	// CapabilityTools is required by requests that declare tools.
+	CapabilityTools Capability = 1 << iota
+	// CapabilityVision is required by requests that carry image input.
+	CapabilityVision
+	// CapabilityJSONSchema is required by requests asking for schema-constrained output.
+	CapabilityJSONSchema
+	// CapabilityStreaming is required by streaming requests.
+	CapabilityStreaming
+)
+
+// String lists the capability names, e.g. "tools,vision".
+func (c Capability) String() string {
+	names := make([]string, 0, 4)
+	if c&CapabilityTools != 0 {
+		names = append(names, "tools")
+	}
+	if c&CapabilityVision != 0 {
+		names = append(names, "vision")
+	}
+	if c&CapabilityJSONSchema != 0 {
+		names = append(names, "json_schema")
+	}
+	if c&CapabilityStreaming != 0 {
+		names = append(names, "streaming")
+	}
+	return strings.Join(names, ",")
+}
+
+// ModelCapabilities describes which request features a model accepts. Converters fill it
+// in when the upstream model list reports them.
+type ModelCapabilities struct {
+	SupportsTools      bool `json:"supports_tools"`
+	SupportsVision     bool `json:"supports_vision"`
+	SupportsJSONSchema bool `json:"supports_json_schema"`
+	SupportsStreaming  bool `json:"supports_streaming"`
+}
+
+// Missing returns the subset of required the model does not support. A nil receiver
+// means the capabilities are unknown and nothing is reported missing.
+func (c *ModelCapabilities) Missing(required Capability) Capability {
+	if c == nil {
+		return 0
+	}
+	var supported Capability
+	if c.SupportsTools {
+		supported |= CapabilityTools
+	}
+	if c.SupportsVision {
+		supported |= CapabilityVision
+	}
+	if c.SupportsJSONSchema {
+		supported |= CapabilityJSONSchema
+	}
+	if c.SupportsStreaming {
+		supported |= CapabilityStreaming
+	}
+	return required &^ supported
+}
+
+// ClientModelCapabilities returns the capabilities clientID registered for modelID
+// (matched case-insensitively), or nil when the client did not declare any.
+func (r *ModelRegistry) ClientModelCapabilities(clientID, modelID string) *ModelCapabilities {
+	key := modelIndexKey(modelID)
+	if clientID == "" || key == "" {
+		return nil
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	infos := r.clientModelInfos[clientID]
+	if len(infos) == 0 {
+		return nil
+	}
+	info := infos[modelID]
+	if info == nil {
+		for id, candidate := range infos {
+			if modelIndexKey(id) == key {
+				info = candidate
+				break
+			}
+		}
+	}
+	if info == nil || info.Capabilities == nil {
+		return nil
+	}
+	copyCapabilities := *info.Capabilities
+	return &copyCapabilities
+}
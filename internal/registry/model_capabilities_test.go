@@ -0,0 +1,37 @@
+package registry
+
+import "testing"
+
+func TestModelCapabilitiesMissing(t *testing.T) {
+	var unknown *ModelCapabilities
+	if missing := unknown.Missing(CapabilityTools | CapabilityVision); missing != 0 {
+		t.Fatalf("unknown capabilities reported missing %s", missing)
+	}
+	caps := &ModelCapabilities{SupportsTools: true, SupportsStreaming: true}
+	if missing := caps.Missing(CapabilityTools | CapabilityVision | CapabilityStreaming); missing != CapabilityVision {
+		t.Fatalf("missing = %s, want vision", missing)
+	}
+}
+
+func TestClientModelCapabilities(t *testing.T) {
+	r := newTestModelRegistry()
+	r.RegisterClient("client-caps", "kilo", []*ModelInfo{
+		{ID: "Vision-Model", Capabilities: &ModelCapabilities{SupportsVision: true}},
+		{ID: "plain-model"},
+	})
+
+	caps := r.ClientModelCapabilities("client-caps", "vision-model")
+	if caps == nil || !caps.SupportsVision || caps.SupportsTools {
+		t.Fatalf("capabilities = %+v, want vision only", caps)
+	}
+	caps.SupportsTools = true
+	if r.ClientModelCapabilities("client-caps", "Vision-Model").SupportsTools {
+		t.Fatal("returned capabilities alias registry state")
+	}
+	if r.ClientModelCapabilities("client-caps", "plain-model") != nil {
+		t.Fatal("model without metadata reported capabilities")
+	}
+	if r.ClientModelCapabilities("other-client", "Vision-Model") != nil {
+		t.Fatal("unknown client reported capabilities")
+	}
+}
@@ -13,6 +13,7 @@ const (
 	xaiBuiltinImageQualityModelID   = "grok-imagine-image-quality"
 	xaiBuiltinVideoModelID          = "grok-imagine-video"
 	xaiBuiltinVideo15PreviewModelID = "grok-imagine-video-1.5-preview"
+	geminiBuiltinEmbeddingModelID   = "gemini-embedding-001"
 )
 
 // staticModelsJSON mirrors the top-level structure of models.json.
@@ -37,7 +38,7 @@ func GetClaudeModels() []*ModelInfo {
 
 // GetGeminiModels returns the standard Gemini model definitions.
 func GetGeminiModels() []*ModelInfo {
-	return cloneModelInfos(getModels().Gemini)
+	return WithGeminiBuiltins(cloneModelInfos(getModels().Gemini))
 }
 
 // GetGeminiVertexModels returns Gemini model definitions for Vertex AI.
@@ -253,6 +254,11 @@ func WithXAIBuiltins(models []*ModelInfo) []*ModelInfo {
 	return upsertModelInfos(models, xaiBuiltinImageModelInfo(), xaiBuiltinImageQualityModelInfo(), xaiBuiltinVideoModelInfo(), xaiBuiltinVideo15PreviewModelInfo())
 }
 
+// WithGeminiBuiltins injects the Gemini embedding model served through /v1/embeddings.
+func WithGeminiBuiltins(models []*ModelInfo) []*ModelInfo {
+	return upsertModelInfos(models, geminiBuiltinEmbeddingModelInfo())
+}
+
 func normalizeAntigravityCapabilityModelID(modelID string) string {
 	modelID = strings.ToLower(strings.TrimSpace(modelID))
 	if open := strings.LastIndex(modelID, "("); open >= 0 && strings.HasSuffix(modelID, ")") {
@@ -312,6 +318,22 @@ func filterModelInfos(models []*ModelInfo, excludedIDs ...string) []*ModelInfo {
 	return filtered
 }
 
+func geminiBuiltinEmbeddingModelInfo() *ModelInfo {
+	return &ModelInfo{
+		ID:                         geminiBuiltinEmbeddingModelID,
+		Object:                     "model",
+		Created:                    1752537600, // 2025-07-15
+		OwnedBy:                    "google",
+		Type:                       "gemini",
+		DisplayName:                "Gemini Embedding 001",
+		Name:                       "models/" + geminiBuiltinEmbeddingModelID,
+		Version:                    "001",
+		Description:                "Gemini text embedding model.",
+		InputTokenLimit:            2048,
+		SupportedGenerationMethods: []string{"embedContent", "batchEmbedContents"},
+	}
+}
+
 func xaiBuiltinImageModelInfo() *ModelInfo {
 	return &ModelInfo{
 		ID:          xaiBuiltinImageModelID,
@@ -411,6 +433,12 @@ func upsertModelInfos(models []*ModelInfo, extras ...*ModelInfo) []*ModelInfo {
 	return filtered
 }
 
+// CloneModelInfos returns a copy of models with each element deep-cloned, so callers can
+// hand out a shared model list without exposing it to mutation.
+func CloneModelInfos(models []*ModelInfo) []*ModelInfo {
+	return cloneModelInfos(models)
+}
+
 // cloneModelInfos returns a shallow copy of the slice with each element deep-cloned.
 func cloneModelInfos(models []*ModelInfo) []*ModelInfo {
 	if len(models) == 0 {
@@ -9,6 +9,7 @@ import (
 	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	misc "github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
@@ -64,6 +65,9 @@ type ModelInfo struct {
 	// SupportsWebSearch indicates this Antigravity model is listed by
 	// fetchAvailableModels.webSearchModelIds and can execute native googleSearch.
 	SupportsWebSearch bool `json:"supports_web_search,omitempty"`
+	// Capabilities lists the request features the model accepts. Nil means unknown, in
+	// which case no request is steered away from the model.
+	Capabilities *ModelCapabilities `json:"capabilities,omitempty"`
 
 	// Thinking holds provider-specific reasoning/thinking budget capabilities.
 	// This is optional and currently used for Gemini thinking budget normalization.
@@ -146,12 +150,23 @@ type ModelRegistryHook interface {
 	OnModelsUnregistered(ctx context.Context, provider, clientID string)
 }
 
+// ModelChangeHook is optionally implemented by a ModelRegistryHook to learn which models a
+// re-registered client gained or lost, e.g. after a dynamic model list was fetched again.
+// It is called asynchronously like the other hook methods.
+type ModelChangeHook interface {
+	OnModelsChanged(ctx context.Context, provider, clientID string, added, removed []string)
+}
+
 // ModelRegistry manages the global registry of available models
 type ModelRegistry struct {
 	// models maps model ID to registration information
 	models map[string]*ModelRegistration
 	// clientModels maps client ID to the models it provides
 	clientModels map[string][]string
+	// modelClients is the inverse of clientModels: lowercased model ID -> client IDs.
+	modelClients map[string]map[string]struct{}
+	// generation is bumped whenever a client's model set changes.
+	generation atomic.Uint64
 	// clientModelInfos maps client ID to a map of model ID -> ModelInfo
 	// This preserves the original model info provided by each client
 	clientModelInfos map[string]map[string]*ModelInfo
@@ -175,6 +190,7 @@ func GetGlobalRegistry() *ModelRegistry {
 		globalRegistry = &ModelRegistry{
 			models:               make(map[string]*ModelRegistration),
 			clientModels:         make(map[string][]string),
+			modelClients:         make(map[string]map[string]struct{}),
 			clientModelInfos:     make(map[string]map[string]*ModelInfo),
 			clientProviders:      make(map[string]string),
 			availableModelsCache: make(map[string]availableModelsCacheEntry),
@@ -189,6 +205,51 @@ func (r *ModelRegistry) ensureAvailableModelsCacheLocked() {
 	}
 }
 
+// setClientModelsLocked replaces the models a client provides and keeps the model -> client
+// index in sync. A nil or empty slice removes the client.
+func (r *ModelRegistry) setClientModelsLocked(clientID string, modelIDs []string) {
+	if r.modelClients == nil {
+		r.modelClients = make(map[string]map[string]struct{})
+	}
+	for _, id := range r.clientModels[clientID] {
+		key := modelIndexKey(id)
+		if clients := r.modelClients[key]; clients != nil {
+			delete(clients, clientID)
+			if len(clients) == 0 {
+				delete(r.modelClients, key)
+			}
+		}
+	}
+	r.generation.Add(1)
+	if len(modelIDs) == 0 {
+		delete(r.clientModels, clientID)
+		return
+	}
+	r.clientModels[clientID] = append([]string(nil), modelIDs...)
+	for _, id := range modelIDs {
+		key := modelIndexKey(id)
+		if key == "" {
+			continue
+		}
+		clients := r.modelClients[key]
+		if clients == nil {
+			clients = make(map[string]struct{})
+			r.modelClients[key] = clients
+		}
+		clients[clientID] = struct{}{}
+	}
+}
+
+func modelIndexKey(modelID string) string {
+	return strings.ToLower(strings.TrimSpace(modelID))
+}
+
+// Generation returns a counter that changes whenever any client's registered model set
+// changes. Callers can use it to invalidate data derived from client registrations.
+func (r *ModelRegistry) Generation() uint64 {
+	return r.generation.Load()
+}
+
 func (r *ModelRegistry) invalidateAvailableModelsCacheLocked() {
 	if len(r.availableModelsCache) == 0 {
 		return
@@ -286,6 +347,27 @@ func (r *ModelRegistry) triggerModelsUnregistered(provider, clientID string) {
 	}()
 }
 
+func (r *ModelRegistry) triggerModelsChanged(provider, clientID string, added, removed []string) {
+	hook, ok := r.hook.(ModelChangeHook)
+	if !ok {
+		return
+	}
+	added = append([]string(nil), added...)
+	removed = append([]string(nil), removed...)
+	sort.Strings(added)
+	sort.Strings(removed)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				log.Errorf("model registry hook OnModelsChanged panic: %v", recovered)
+			}
+		}()
+		ctx, cancel := context.WithTimeout(context.Background(), defaultModelRegistryHookTimeout)
+		defer cancel()
+		hook.OnModelsChanged(ctx, provider, clientID, added, removed)
+	}()
+}
+
 // RegisterClient registers a client and its supported models
 // Parameters:
 //   - clientID: Unique identifier for the client
@@ -318,7 +400,7 @@ func (r *ModelRegistry) RegisterClient(clientID, clientProvider string, models [
 	if len(uniqueModelIDs) == 0 {
 		// No models supplied; unregister existing client state if present.
 		r.unregisterClientInternal(clientID)
-		delete(r.clientModels, clientID)
+		r.setClientModelsLocked(clientID, nil)
 		delete(r.clientModelInfos, clientID)
 		delete(r.clientProviders, clientID)
 		r.invalidateAvailableModelsCacheLocked()
@@ -337,7 +419,7 @@ func (r *ModelRegistry) RegisterClient(clientID, clientProvider string, models [
 			model := newModels[modelID]
 			r.addModelRegistration(modelID, provider, model, now)
 		}
-		r.clientModels[clientID] = append([]string(nil), rawModelIDs...)
+		r.setClientModelsLocked(clientID, rawModelIDs)
 		// Store client's own model infos
 		clientInfos := make(map[string]*ModelInfo, len(newModels))
 		for id, m := range newModels {
@@ -482,7 +564,7 @@ func (r *ModelRegistry) RegisterClient(clientID, clientProvider string, models [
 
 	// Update client bookkeeping.
 	if len(rawModelIDs) > 0 {
-		r.clientModels[clientID] = append([]string(nil), rawModelIDs...)
+		r.setClientModelsLocked(clientID, rawModelIDs)
 	}
 	// Update client's own model infos
 	clientInfos := make(map[string]*ModelInfo, len(newModels))
@@ -503,6 +585,9 @@ func (r *ModelRegistry) RegisterClient(clientID, clientProvider string, models [
 		return
 	}
 
+	if len(added) > 0 || len(removed) > 0 {
+		r.triggerModelsChanged(provider, clientID, added, removed)
+	}
 	log.Debugf("Reconciled client %s (provider %s) models: +%d, -%d", clientID, provider, len(added), len(removed))
 	misc.LogCredentialSeparator()
 }
@@ -607,6 +692,10 @@ func cloneModelInfo(model *ModelInfo) *ModelInfo {
 		}
 		copyModel.Thinking = &copyThinking
 	}
+	if model.Capabilities != nil {
+		copyCapabilities := *model.Capabilities
+		copyModel.Capabilities = &copyCapabilities
+	}
 	if model.Config != nil {
 		copyConfig := *model.Config
 		if len(model.Config.OverrideHeader) > 0 {
@@ -711,7 +800,7 @@ func (r *ModelRegistry) unregisterClientInternal(clientID string) {
 		}
 	}
 
-	delete(r.clientModels, clientID)
+	r.setClientModelsLocked(clientID, nil)
 	delete(r.clientModelInfos, clientID)
 	if hasProvider {
 		delete(r.clientProviders, clientID)
@@ -824,18 +913,30 @@ func (r *ModelRegistry) ClientSupportsModel(clientID, modelID string) bool {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
-	models, exists := r.clientModels[clientID]
-	if !exists || len(models) == 0 {
-		return false
-	}
+	_, ok := r.modelClients[modelIndexKey(modelID)][clientID]
+	return ok
+}
 
-	for _, id := range models {
-		if strings.EqualFold(strings.TrimSpace(id), modelID) {
-			return true
-		}
+// ClientsForModel returns the IDs of the clients that registered support for modelID,
+// matched case-insensitively. The result is unordered.
+func (r *ModelRegistry) ClientsForModel(modelID string) []string {
+	key := modelIndexKey(modelID)
+	if key == "" {
+		return nil
 	}
 
-	return false
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	clients := r.modelClients[key]
+	if len(clients) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(clients))
+	for clientID := range clients {
+		out = append(out, clientID)
+	}
+	return out
 }
 
 // GetAvailableModels returns all models that have at least one available client
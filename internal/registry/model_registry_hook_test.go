@@ -202,3 +202,55 @@ func TestModelRegistryHook_PanicDoesNotAffectRegistry(t *testing.T) {
 		t.Fatal("timeout waiting for OnModelsUnregistered hook call")
 	}
 }
+
+type changeCall struct {
+	provider string
+	clientID string
+	added    []string
+	removed  []string
+}
+
+type changeCapturingHook struct {
+	capturingHook
+	changedCh chan changeCall
+}
+
+func (h *changeCapturingHook) OnModelsChanged(ctx context.Context, provider, clientID string, added, removed []string) {
+	h.changedCh <- changeCall{provider: provider, clientID: clientID, added: added, removed: removed}
+}
+
+func TestModelRegistryHook_OnModelsChangedReportsDiff(t *testing.T) {
+	r := newTestModelRegistry()
+	hook := &changeCapturingHook{
+		capturingHook: capturingHook{
+			registeredCh:   make(chan registeredCall, 4),
+			unregisteredCh: make(chan unregisteredCall, 4),
+		},
+		changedCh: make(chan changeCall, 4),
+	}
+	r.SetHook(hook)
+
+	r.RegisterClient("client-1", "kilo", []*ModelInfo{{ID: "m1"}, {ID: "m2"}})
+	r.RegisterClient("client-1", "kilo", []*ModelInfo{{ID: "m1"}, {ID: "m2"}})
+	r.RegisterClient("client-1", "kilo", []*ModelInfo{{ID: "m2"}, {ID: "m4"}, {ID: "m3"}})
+
+	select {
+	case call := <-hook.changedCh:
+		if call.provider != "kilo" || call.clientID != "client-1" {
+			t.Fatalf("call = %+v", call)
+		}
+		if len(call.added) != 2 || call.added[0] != "m3" || call.added[1] != "m4" {
+			t.Fatalf("added = %v, want [m3 m4]", call.added)
+		}
+		if len(call.removed) != 1 || call.removed[0] != "m1" {
+			t.Fatalf("removed = %v, want [m1]", call.removed)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for OnModelsChanged hook call")
+	}
+	select {
+	case call := <-hook.changedCh:
+		t.Fatalf("unexpected extra OnModelsChanged call %+v", call)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,42 @@
+package registry
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestClientModelIndexTracksRegistrationChanges(t *testing.T) {
+	r := newTestModelRegistry()
+	r.RegisterClient("client-a", "gemini", []*ModelInfo{{ID: "Model-X"}, {ID: "model-y"}})
+	r.RegisterClient("client-b", "gemini", []*ModelInfo{{ID: "model-x"}})
+
+	if !r.ClientSupportsModel("client-a", " model-x ") {
+		t.Fatal("client-a should support model-x case-insensitively")
+	}
+	got := r.ClientsForModel("MODEL-X")
+	slices.Sort(got)
+	if want := []string{"client-a", "client-b"}; !slices.Equal(got, want) {
+		t.Fatalf("ClientsForModel(model-x) = %v, want %v", got, want)
+	}
+
+	generation := r.Generation()
+	r.RegisterClient("client-a", "gemini", []*ModelInfo{{ID: "model-y"}})
+	if r.Generation() == generation {
+		t.Fatal("re-registration did not bump the generation")
+	}
+	if r.ClientSupportsModel("client-a", "model-x") {
+		t.Fatal("client-a still supports model-x after dropping it")
+	}
+	if got := r.ClientsForModel("model-x"); !slices.Equal(got, []string{"client-b"}) {
+		t.Fatalf("ClientsForModel(model-x) = %v, want [client-b]", got)
+	}
+
+	r.UnregisterClient("client-b")
+	if got := r.ClientsForModel("model-x"); len(got) != 0 {
+		t.Fatalf("ClientsForModel(model-x) after unregister = %v, want none", got)
+	}
+	r.RegisterClient("client-a", "gemini", nil)
+	if r.ClientSupportsModel("client-a", "model-y") || len(r.modelClients) != 0 {
+		t.Fatalf("index not emptied: %v", r.modelClients)
+	}
+}
@@ -0,0 +1,88 @@
+package registry
+
+import (
+	"strings"
+)
+
+// latestModelSuffix marks a request for the newest model of a family, e.g. "claude-sonnet-latest".
+const latestModelSuffix = "latest"
+
+// ResolveNearMatch maps a model name that is not registered onto a registered model.
+//
+//   - A name ending in "-latest" resolves through pins first (keys matched
+//     case-insensitively), otherwise to the newest registered model whose normalized ID
+//     is the normalized family name followed by a version ("claude-sonnet-latest" matches
+//     "claude-sonnet-4-5" but not "claude-sonnet-mini").
+//   - Any other name resolves when exactly one registered model has the same normalized
+//     ID, where normalization lowercases and drops every character except letters and
+//     digits ("gemini-2.5pro" matches "gemini-2.5-pro").
+//
+// It returns "" when the name is already registered or nothing matches unambiguously.
+func (r *ModelRegistry) ResolveNearMatch(name string, pins map[string]string) string {
+	name = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "models/"))
+	if name == "" {
+		return ""
+	}
+	normalized := normalizeModelName(name)
+	family, isLatest := strings.CutSuffix(normalized, latestModelSuffix)
+	if isLatest {
+		for pinName, target := range pins {
+			if strings.EqualFold(strings.TrimSpace(pinName), name) {
+				return strings.TrimSpace(target)
+			}
+		}
+	}
+
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	if reg, ok := r.models[name]; ok && reg != nil && reg.Count > 0 {
+		return ""
+	}
+	var match string
+	var matchCreated int64
+	ambiguous := false
+	for id, reg := range r.models {
+		if reg == nil || reg.Count <= 0 {
+			continue
+		}
+		candidate := normalizeModelName(id)
+		if isLatest {
+			rest, ok := strings.CutPrefix(candidate, family)
+			if family == "" || !ok || (rest != "" && (rest[0] < '0' || rest[0] > '9')) {
+				continue
+			}
+			var created int64
+			if reg.Info != nil {
+				created = reg.Info.Created
+			}
+			if match == "" || created > matchCreated || (created == matchCreated && id > match) {
+				match, matchCreated = id, created
+			}
+			continue
+		}
+		if candidate != normalized {
+			continue
+		}
+		if match != "" {
+			ambiguous = true
+			break
+		}
+		match = id
+	}
+	if ambiguous {
+		return ""
+	}
+	return match
+}
+
+func normalizeModelName(name string) string {
+	var b strings.Builder
+	b.Grow(len(name))
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,27 @@
+package registry
+
+import "testing"
+
+func TestResolveNearMatch(t *testing.T) {
+	r := newTestModelRegistry()
+	r.RegisterClient("client-a", "gemini", []*ModelInfo{{ID: "gemini-2.5-pro"}, {ID: "gpt-4o", Created: 1}, {ID: "gpt-4o-mini", Created: 2}})
+	r.RegisterClient("client-b", "openai", []*ModelInfo{{ID: "gpt4o"}})
+
+	cases := map[string]string{
+		"gemini-2.5-pro":     "",
+		"Gemini-2.5Pro":      "gemini-2.5-pro",
+		"models/gemini25pro": "gemini-2.5-pro",
+		"GPT-4O!":            "",
+		"gpt-4o-latest":      "gpt-4o",
+		"gpt-latest":         "gpt-4o-mini",
+		"unknown-model":      "",
+	}
+	for name, want := range cases {
+		if got := r.ResolveNearMatch(name, nil); got != want {
+			t.Errorf("ResolveNearMatch(%q) = %q, want %q", name, got, want)
+		}
+	}
+	if got := r.ResolveNearMatch("gpt-4o-latest", map[string]string{"gpt-4o-latest": "gpt-4o-mini"}); got != "gpt-4o-mini" {
+		t.Errorf("pinned ResolveNearMatch = %q, want gpt-4o-mini", got)
+	}
+}
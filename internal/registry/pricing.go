@@ -0,0 +1,135 @@
+package registry
+
+import (
+	"strings"
+	"sync"
+)
+
+// ModelPrice describes the token pricing for one provider/model combination in USD.
+type ModelPrice struct {
+	// Provider is the provider key (e.g. "claude"); empty matches any provider.
+	Provider string
+	// Model is the model identifier; a trailing "*" matches any model with that prefix.
+	Model string
+	// PromptPerMillion is the price of one million prompt (input) tokens.
+	PromptPerMillion float64
+	// CompletionPerMillion is the price of one million completion (output) tokens.
+	CompletionPerMillion float64
+}
+
+// Cost returns the estimated cost in USD for the given token counts.
+func (p ModelPrice) Cost(promptTokens, completionTokens int64) float64 {
+	if promptTokens < 0 {
+		promptTokens = 0
+	}
+	if completionTokens < 0 {
+		completionTokens = 0
+	}
+	return (float64(promptTokens)*p.PromptPerMillion + float64(completionTokens)*p.CompletionPerMillion) / 1_000_000
+}
+
+// UnitCost returns a comparable per-token price used to rank candidates when the
+// request size is unknown. Prompt and completion prices are weighted equally.
+func (p ModelPrice) UnitCost() float64 {
+	return p.PromptPerMillion + p.CompletionPerMillion
+}
+
+// PricingRegistry stores configured model prices and resolves them per provider/model.
+type PricingRegistry struct {
+	mu     sync.RWMutex
+	prices []ModelPrice
+}
+
+var (
+	globalPricingRegistry *PricingRegistry
+	pricingRegistryOnce   sync.Once
+)
+
+// GetGlobalPricingRegistry returns the process-wide pricing registry.
+func GetGlobalPricingRegistry() *PricingRegistry {
+	pricingRegistryOnce.Do(func() {
+		globalPricingRegistry = NewPricingRegistry()
+	})
+	return globalPricingRegistry
+}
+
+// NewPricingRegistry creates an empty pricing registry.
+func NewPricingRegistry() *PricingRegistry {
+	return &PricingRegistry{}
+}
+
+// SetPrices replaces the registered price table.
+func (r *PricingRegistry) SetPrices(prices []ModelPrice) {
+	if r == nil {
+		return
+	}
+	normalized := make([]ModelPrice, 0, len(prices))
+	for _, price := range prices {
+		price.Provider = strings.ToLower(strings.TrimSpace(price.Provider))
+		price.Model = strings.ToLower(strings.TrimSpace(price.Model))
+		if price.Model == "" {
+			continue
+		}
+		normalized = append(normalized, price)
+	}
+	r.mu.Lock()
+	r.prices = normalized
+	r.mu.Unlock()
+}
+
+// Prices returns a copy of the registered price table.
+func (r *PricingRegistry) Prices() []ModelPrice {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]ModelPrice, len(r.prices))
+	copy(out, r.prices)
+	return out
+}
+
+// Lookup resolves the price for provider/model.
+// Exact model matches win over prefix wildcards, longer prefixes win over shorter
+// ones, and provider-specific entries win over provider-agnostic ones.
+func (r *PricingRegistry) Lookup(provider, model string) (ModelPrice, bool) {
+	if r == nil {
+		return ModelPrice{}, false
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	model = strings.ToLower(strings.TrimSpace(model))
+	if model == "" {
+		return ModelPrice{}, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var best ModelPrice
+	bestScore := -1
+	for _, price := range r.prices {
+		if price.Provider != "" && price.Provider != provider {
+			continue
+		}
+		score := 0
+		if prefix, ok := strings.CutSuffix(price.Model, "*"); ok {
+			if !strings.HasPrefix(model, prefix) {
+				continue
+			}
+			score = len(prefix) * 4
+		} else {
+			if price.Model != model {
+				continue
+			}
+			score = (len(price.Model) + 1) * 4
+			score += 2
+		}
+		if price.Provider != "" {
+			score++
+		}
+		if score > bestScore {
+			best = price
+			bestScore = score
+		}
+	}
+	return best, bestScore >= 0
+}
@@ -4,7 +4,6 @@
 package executor
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"crypto/sha256"
@@ -1076,13 +1075,13 @@ attemptLoop:
 			out := make(chan cliproxyexecutor.StreamChunk)
 			go func(resp *http.Response) {
 				defer close(out)
+				defer helps.RecoverStreamPanic(ctx, out, e.Identifier())
 				defer func() {
 					if errClose := resp.Body.Close(); errClose != nil {
 						log.Errorf("antigravity executor: close response body error: %v", errClose)
 					}
 				}()
-				scanner := bufio.NewScanner(resp.Body)
-				scanner.Buffer(nil, streamScannerBuffer)
+				scanner := helps.NewStreamScanner(e.cfg, resp.Body)
 				for scanner.Scan() {
 					line := scanner.Bytes()
 					helps.AppendAPIResponseChunk(ctx, e.cfg, line)
@@ -1573,6 +1572,7 @@ attemptLoop:
 			out := make(chan cliproxyexecutor.StreamChunk)
 			go func(resp *http.Response) {
 				defer close(out)
+				defer helps.RecoverStreamPanic(ctx, out, e.Identifier())
 				defer func() {
 					if replayAccumulator != nil {
 						replayAccumulator.Flush(ctx)
@@ -1581,8 +1581,7 @@ attemptLoop:
 						log.Errorf("antigravity executor: close response line error: %v", errClose)
 					}
 				}()
-				scanner := bufio.NewScanner(resp.Body)
-				scanner.Buffer(nil, streamScannerBuffer)
+				scanner := helps.NewStreamScanner(e.cfg, resp.Body)
 				claudeInputTokens := helps.NewClaudeInputTokenState(from, to, responseFormat, originalPayload)
 				var param any
 				for scanner.Scan() {
@@ -335,6 +335,7 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 	if countCacheControls(body) == 0 {
 		body = ensureCacheControl(body)
 	}
+	body = applyClaudePromptCacheHint(e.cfg, auth, baseModel, body)
 
 	// Enforce Anthropic's cache_control block limit (max 4 breakpoints per request).
 	// Cloaking and ensureCacheControl may push the total over 4 when the client
@@ -547,6 +548,7 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 	if countCacheControls(body) == 0 {
 		body = ensureCacheControl(body)
 	}
+	body = applyClaudePromptCacheHint(e.cfg, auth, baseModel, body)
 
 	// Enforce Anthropic's cache_control block limit (max 4 breakpoints per request).
 	body = enforceCacheControlLimit(body, 4)
@@ -651,6 +653,7 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 	out := make(chan cliproxyexecutor.StreamChunk)
 	go func() {
 		defer close(out)
+		defer helps.RecoverStreamPanic(ctx, out, e.Identifier())
 		defer func() {
 			if errClose := decodedBody.Close(); errClose != nil {
 				log.Errorf("response body close error: %v", errClose)
@@ -659,8 +662,7 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 
 		// If the response target is Claude, directly forward complete SSE events without translation.
 		if responseFormat == to {
-			scanner := bufio.NewScanner(decodedBody)
-			scanner.Buffer(nil, 52_428_800) // 50MB
+			scanner := helps.NewStreamScanner(e.cfg, decodedBody)
 			var event bytes.Buffer
 			flushEvent := func() bool {
 				if event.Len() == 0 {
@@ -704,8 +706,7 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 		}
 
 		// For other formats, use translation
-		scanner := bufio.NewScanner(decodedBody)
-		scanner.Buffer(nil, 52_428_800) // 50MB
+		scanner := helps.NewStreamScanner(e.cfg, decodedBody)
 		var param any
 		for scanner.Scan() {
 			line := scanner.Bytes()
@@ -954,6 +955,7 @@ func (e *ClaudeExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (
 	auth.Metadata["type"] = "claude"
 	now := time.Now().Format(time.RFC3339)
 	auth.Metadata["last_refresh"] = now
+	helps.EnsureAuthDeviceID(auth)
 	return auth, nil
 }
 
@@ -2405,6 +2407,10 @@ func applyCloaking(ctx context.Context, cfg *config.Config, auth *cliproxyauth.A
 // Resolve Claude Code identity (bootstrap API for OAuth) and inject metadata.user_id
 	// Replaces the old injectFakeUserID with real Claude Code identity format.
 	identity := helps.ResolveClaudeCodeIdentity(ctx, apiKey, model)
+	if identity != nil && auth != nil {
+		// Keep the device stable per credential instead of per access token.
+		identity.DeviceID = helps.AuthDeviceID(auth)
+	}
 	if identity != nil && identity.AccountUUID != "" {
 		// OAuth token with resolved account: inject proper metadata.user_id
 		payload, _ = helps.ApplyClaudeCodeMetadata(payload, identity)
@@ -2809,15 +2815,20 @@ func injectMessagesCacheControl(payload []byte) []byte {
 	// Get the second-to-last user message index
 	secondToLastUserIdx := userMsgIndices[len(userMsgIndices)-2]
 
-	// Get the content of this message
-	contentPath := fmt.Sprintf("messages.%d.content", secondToLastUserIdx)
+	return setMessageCacheControl(payload, secondToLastUserIdx)
+}
+
+// setMessageCacheControl adds an ephemeral cache_control to the last content block of
+// messages[idx], converting string content to a single text block first.
+func setMessageCacheControl(payload []byte, idx int) []byte {
+	contentPath := fmt.Sprintf("messages.%d.content", idx)
 	content := gjson.GetBytes(payload, contentPath)
 
 	if content.IsArray() {
 		// Add cache_control to the last content block of this message
 		contentCount := int(content.Get("#").Int())
 		if contentCount > 0 {
-			cacheControlPath := fmt.Sprintf("messages.%d.content.%d.cache_control", secondToLastUserIdx, contentCount-1)
+			cacheControlPath := fmt.Sprintf("messages.%d.content.%d.cache_control", idx, contentCount-1)
 			result, err := sjson.SetBytes(payload, cacheControlPath, map[string]string{"type": "ephemeral"})
 			if err != nil {
 				log.Warnf("failed to inject cache_control into messages: %v", err)
@@ -45,7 +45,7 @@ func claudeCredsForAuthLookup(a *cliproxyauth.Auth) (apiKey, baseURL string) {
 		return "", ""
 	}
 	if a.Attributes != nil {
-		apiKey = strings.TrimSpace(a.Attributes["api_key"])
+		apiKey = a.ConfigAPIKey()
 		baseURL = strings.TrimSpace(a.Attributes["base_url"])
 	}
 	if a.Metadata != nil {
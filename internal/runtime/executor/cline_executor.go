@@ -1,7 +1,6 @@
 package executor
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -17,6 +16,7 @@ import (
 	clineauth "github.com/router-for-me/CLIProxyAPI/v7/internal/auth/cline"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
@@ -132,11 +132,45 @@ func (e *ClineExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 		return resp, err
 	}
 
-	url := clineBaseURL + endpoint
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(translated))
+	url := helps.ProviderBaseURL(e.cfg, auth, e.Identifier(), clineBaseURL) + endpoint
+	body, err := e.postChat(ctx, auth, accessToken, url, translated)
+	if err != nil {
+		return resp, err
+	}
+	detail := parseOpenAIUsage(body)
+
+	// Free models often ignore response_format, so json_schema requests are validated
+	// and re-prompted until the reply conforms.
+	body, err = helps.EnforceStructuredOutput(ctx, translated, body, func(ctx context.Context, retryPayload []byte) ([]byte, error) {
+		retryBody, errRetry := e.postChat(ctx, auth, accessToken, url, retryPayload)
+		if errRetry == nil {
+			retryDetail := parseOpenAIUsage(retryBody)
+			detail.InputTokens += retryDetail.InputTokens
+			detail.OutputTokens += retryDetail.OutputTokens
+			detail.ReasoningTokens += retryDetail.ReasoningTokens
+			detail.CachedTokens += retryDetail.CachedTokens
+			detail.TotalTokens += retryDetail.TotalTokens
+		}
+		return retryBody, errRetry
+	})
 	if err != nil {
 		return resp, err
 	}
+	reporter.publish(ctx, detail)
+	reporter.ensurePublished(ctx)
+
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, body, &param)
+	resp = cliproxyexecutor.Response{Payload: []byte(out)}
+	return resp, nil
+}
+
+// postChat sends one non-streaming chat completions request and returns the raw response body.
+func (e *ClineExecutor) postChat(ctx context.Context, auth *cliproxyauth.Auth, accessToken, url string, payload []byte) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
 	applyClineHeaders(httpReq, accessToken, false)
 
 	var attrs map[string]string
@@ -155,7 +189,7 @@ func (e *ClineExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 		URL:       url,
 		Method:    http.MethodPost,
 		Headers:   httpReq.Header.Clone(),
-		Body:      translated,
+		Body:      payload,
 		Provider:  e.Identifier(),
 		AuthID:    authID,
 		AuthLabel: authLabel,
@@ -167,7 +201,7 @@ func (e *ClineExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 	httpResp, err := httpClient.Do(httpReq)
 	if err != nil {
 		recordAPIResponseError(ctx, e.cfg, err)
-		return resp, err
+		return nil, err
 	}
 	defer httpResp.Body.Close()
 
@@ -175,23 +209,16 @@ func (e *ClineExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, re
 	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
 		b, _ := io.ReadAll(httpResp.Body)
 		appendAPIResponseChunk(ctx, e.cfg, b)
-		err = statusErr{code: httpResp.StatusCode, msg: string(b)}
-		return resp, err
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(b)}
 	}
 
 	body, err := io.ReadAll(httpResp.Body)
 	if err != nil {
 		recordAPIResponseError(ctx, e.cfg, err)
-		return resp, err
+		return nil, err
 	}
 	appendAPIResponseChunk(ctx, e.cfg, body)
-	reporter.publish(ctx, parseOpenAIUsage(body))
-	reporter.ensurePublished(ctx)
-
-	var param any
-	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, body, &param)
-	resp = cliproxyexecutor.Response{Payload: []byte(out)}
-	return resp, nil
+	return body, nil
 }
 
 // ExecuteStream performs a streaming request.
@@ -229,7 +256,7 @@ func (e *ClineExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Au
 		return nil, err
 	}
 
-	url := clineBaseURL + endpoint
+	url := helps.ProviderBaseURL(e.cfg, auth, e.Identifier(), clineBaseURL) + endpoint
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(translated))
 	if err != nil {
 		return nil, err
@@ -279,33 +306,31 @@ func (e *ClineExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Au
 	out := make(chan cliproxyexecutor.StreamChunk)
 	go func() {
 		defer close(out)
+		defer helps.RecoverStreamPanic(ctx, out, e.Identifier())
 		defer httpResp.Body.Close()
 
-		scanner := bufio.NewScanner(httpResp.Body)
-		scanner.Buffer(nil, 52_428_800)
+		reader := newSSEReader(e.cfg, httpResp.Body)
 		var param any
-		for scanner.Scan() {
-			line := scanner.Bytes()
-			appendAPIResponseChunk(ctx, e.cfg, line)
+		for {
+			event, errRead := reader.Next()
+			if errRead != nil {
+				if !errors.Is(errRead, io.EOF) {
+					recordAPIResponseError(ctx, e.cfg, errRead)
+					reporter.publishFailure(ctx)
+					sendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{Err: errRead})
+				}
+				break
+			}
+			appendAPIResponseChunk(ctx, e.cfg, event.Raw)
+			line := event.DataLine()
 			if detail, ok := parseOpenAIStreamUsage(line); ok {
 				reporter.publish(ctx, detail)
 			}
-			if len(line) == 0 {
-				continue
-			}
-			if !bytes.HasPrefix(line, []byte("data:")) {
-				continue
-			}
-			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, bytes.Clone(line), &param)
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, line, &param)
 			for i := range chunks {
-				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+				sendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])})
 			}
 		}
-		if errScan := scanner.Err(); errScan != nil {
-			recordAPIResponseError(ctx, e.cfg, errScan)
-			reporter.publishFailure(ctx)
-			out <- cliproxyexecutor.StreamChunk{Err: errScan}
-		}
 		reporter.ensurePublished(ctx)
 	}()
 
@@ -315,17 +340,67 @@ func (e *ClineExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Au
 	}, nil
 }
 
-// Refresh validates the Cline token.
+// Refresh exchanges the stored refresh token for a new WorkOS access token.
 func (e *ClineExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	if refreshed, handled, err := helps.RefreshAuthViaHome(ctx, e.cfg, auth); handled {
+		return refreshed, err
+	}
 	if auth == nil {
 		return nil, fmt.Errorf("missing auth")
 	}
+	refreshToken := clineRefreshToken(auth)
+	if refreshToken == "" {
+		return auth, nil
+	}
+	refreshed, err := clineauth.NewClineAuth(e.cfg).RefreshToken(ctx, refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	if refreshed == nil || strings.TrimSpace(refreshed.AccessToken) == "" {
+		return nil, fmt.Errorf("cline: token refresh returned no access token")
+	}
+	applyClineTokenResponse(auth, refreshed)
+	auth.Metadata["type"] = "cline"
+	auth.Metadata["last_refresh"] = time.Now().Format(time.RFC3339)
 	return auth, nil
 }
 
-// CountTokens returns the token count for the given request.
+// CountTokens estimates the token count locally with the model family's tokenizer,
+// since the Cline API has no counting endpoint.
 func (e *ClineExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
-	return cliproxyexecutor.Response{}, fmt.Errorf("cline: count tokens not supported")
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	from := opts.SourceFormat
+	responseFormat := cliproxyexecutor.ResponseFormatOrSource(opts)
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+
+	translated, err := thinking.ApplyThinking(translated, req.Model, from.String(), to.String(), e.Identifier())
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+
+	enc, err := helps.TokenizerForModel(clineModelFamily(baseModel))
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("cline: tokenizer init failed: %w", err)
+	}
+	count, err := helps.CountOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("cline: token counting failed: %w", err)
+	}
+
+	usageJSON := helps.BuildOpenAIUsageJSON(count)
+	translatedUsage := sdktranslator.TranslateTokenCount(ctx, to, responseFormat, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: translatedUsage}, nil
+}
+
+// clineModelFamily strips the vendor prefix from Cline model IDs such as
+// "anthropic/claude-sonnet-4" so the tokenizer is picked by model family.
+func clineModelFamily(model string) string {
+	if idx := strings.LastIndex(model, "/"); idx >= 0 {
+		return model[idx+1:]
+	}
+	return model
 }
 
 // clineAccessToken extracts access token from auth.
@@ -382,9 +457,14 @@ func clineRefreshToken(auth *cliproxyauth.Auth) string {
 			return token
 		}
 	}
+	if storage, ok := auth.Storage.(*clineauth.ClineTokenStorage); ok && storage != nil {
+		return strings.TrimSpace(storage.RefreshToken)
+	}
 	return ""
 }
 
+// ensureFreshAccessToken returns the access token, refreshing it inline only when it
+// is about to expire and the background refresh has not caught up yet.
 func (e *ClineExecutor) ensureFreshAccessToken(ctx context.Context, auth *cliproxyauth.Auth) (string, error) {
 	accessToken := clineAccessToken(auth)
 	if strings.TrimSpace(accessToken) == "" {
@@ -395,6 +475,10 @@ func (e *ClineExecutor) ensureFreshAccessToken(ctx context.Context, auth *clipro
 	if refreshToken == "" {
 		return accessToken, nil
 	}
+	expiry, hasExpiry := auth.ExpirationTime()
+	if !hasExpiry || !clineauth.ShouldRefresh(expiry.Unix()) {
+		return accessToken, nil
+	}
 
 	authSvc := clineauth.NewClineAuth(e.cfg)
 	refreshed, err := authSvc.RefreshToken(ctx, refreshToken)
@@ -405,7 +489,13 @@ func (e *ClineExecutor) ensureFreshAccessToken(ctx context.Context, auth *clipro
 	if refreshed == nil || strings.TrimSpace(refreshed.AccessToken) == "" {
 		return accessToken, nil
 	}
+	applyClineTokenResponse(auth, refreshed)
+	return strings.TrimSpace(refreshed.AccessToken), nil
+}
 
+// applyClineTokenResponse stores refreshed tokens and expiry in auth metadata and,
+// when present, the token storage written on save.
+func applyClineTokenResponse(auth *cliproxyauth.Auth, refreshed *clineauth.TokenResponse) {
 	newAccessToken := strings.TrimSpace(refreshed.AccessToken)
 	if auth.Metadata == nil {
 		auth.Metadata = make(map[string]any)
@@ -413,23 +503,34 @@ func (e *ClineExecutor) ensureFreshAccessToken(ctx context.Context, auth *clipro
 	auth.Metadata["accessToken"] = newAccessToken
 	auth.Metadata["access_token"] = newAccessToken
 
-	if strings.TrimSpace(refreshed.RefreshToken) != "" {
-		newRefresh := strings.TrimSpace(refreshed.RefreshToken)
+	newRefresh := strings.TrimSpace(refreshed.RefreshToken)
+	if newRefresh != "" {
 		auth.Metadata["refreshToken"] = newRefresh
 		auth.Metadata["refresh_token"] = newRefresh
 	}
 
-	if strings.TrimSpace(refreshed.ExpiresAt) != "" {
-		if t, parseErr := time.Parse(time.RFC3339Nano, refreshed.ExpiresAt); parseErr == nil {
-			auth.Metadata["expiresAt"] = t.Unix()
-			auth.Metadata["expires_at"] = t.Format(time.RFC3339)
-		} else if t, parseErr2 := time.Parse(time.RFC3339, refreshed.ExpiresAt); parseErr2 == nil {
-			auth.Metadata["expiresAt"] = t.Unix()
-			auth.Metadata["expires_at"] = t.Format(time.RFC3339)
+	var expiresAt time.Time
+	if raw := strings.TrimSpace(refreshed.ExpiresAt); raw != "" {
+		if t, parseErr := time.Parse(time.RFC3339Nano, raw); parseErr == nil {
+			expiresAt = t
+		} else if t, parseErr2 := time.Parse(time.RFC3339, raw); parseErr2 == nil {
+			expiresAt = t
 		}
 	}
+	if !expiresAt.IsZero() {
+		auth.Metadata["expiresAt"] = expiresAt.Unix()
+		auth.Metadata["expires_at"] = expiresAt.Format(time.RFC3339)
+	}
 
-	return newAccessToken, nil
+	if storage, ok := auth.Storage.(*clineauth.ClineTokenStorage); ok && storage != nil {
+		storage.AccessToken = newAccessToken
+		if newRefresh != "" {
+			storage.RefreshToken = newRefresh
+		}
+		if !expiresAt.IsZero() {
+			storage.ExpiresAt = expiresAt.Unix()
+		}
+	}
 }
 
 // applyClineHeaders sets the standard Cline headers.
@@ -507,6 +608,22 @@ type ClineModel struct {
 		InputCacheRead string `json:"input_cache_read"`
 		WebSearch      string `json:"web_search"`
 	} `json:"pricing"`
+	SupportedParameters []string `json:"supported_parameters"`
+	Architecture        struct {
+		InputModalities []string `json:"input_modalities"`
+	} `json:"architecture"`
+}
+
+func clineStringList(value gjson.Result) []string {
+	if !value.IsArray() {
+		return nil
+	}
+	items := value.Array()
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		out = append(out, item.String())
+	}
+	return out
 }
 
 func clineIsFreeModel(m ClineModel) bool {
@@ -524,12 +641,20 @@ func clineIsFreeModel(m ClineModel) bool {
 }
 
 // FetchClineModels fetches models from Cline API.
-// The model list endpoint does not require authentication.
+// The model list endpoint does not require authentication, so concurrent fetches for
+// different auths against the same endpoint are coalesced into one upstream call.
 func FetchClineModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config) []*registry.ModelInfo {
+	endpoint := helps.ProviderBaseURL(cfg, auth, "cline", clineBaseURL) + clineModelsEndpoint
+	return helps.CoalesceModelFetch(ctx, "cline|"+endpoint, func(fetchCtx context.Context) []*registry.ModelInfo {
+		return fetchClineModels(fetchCtx, auth, cfg, endpoint)
+	})
+}
+
+func fetchClineModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config, endpoint string) []*registry.ModelInfo {
 	log.Debugf("cline: fetching dynamic models from API")
 
 	httpClient := newProxyAwareHTTPClient(ctx, cfg, auth, 0)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, clineBaseURL+clineModelsEndpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
 		log.Warnf("cline: failed to create model fetch request: %v", err)
 		return nil
@@ -610,6 +735,9 @@ func FetchClineModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.
 					WebSearch:      value.Get("pricing.web_search").String(),
 				},
 			})
+			last := &modelsResponse.Data[len(modelsResponse.Data)-1]
+			last.SupportedParameters = clineStringList(value.Get("supported_parameters"))
+			last.Architecture.InputModalities = clineStringList(value.Get("architecture.input_modalities"))
 			return true
 		})
 	}
@@ -648,6 +776,7 @@ func FetchClineModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.
 			Type:                "cline",
 			Object:              "model",
 			Created:             now,
+			Capabilities:        helps.OpenRouterModelCapabilities(m.SupportedParameters, m.Architecture.InputModalities),
 		})
 		count++
 	}
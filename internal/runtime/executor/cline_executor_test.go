@@ -0,0 +1,70 @@
+package executor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	clineauth "github.com/router-for-me/CLIProxyAPI/v7/internal/auth/cline"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyClineTokenResponseUpdatesMetadataAndStorage(t *testing.T) {
+	storage := &clineauth.ClineTokenStorage{AccessToken: "old", RefreshToken: "old-refresh"}
+	auth := &cliproxyauth.Auth{Provider: "cline", Storage: storage}
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	applyClineTokenResponse(auth, &clineauth.TokenResponse{
+		AccessToken:  "new",
+		RefreshToken: "new-refresh",
+		ExpiresAt:    expiresAt.Format(time.RFC3339Nano),
+	})
+
+	if got := clineAccessToken(auth); got != "new" {
+		t.Fatalf("access token = %q, want new", got)
+	}
+	if got := clineRefreshToken(auth); got != "new-refresh" {
+		t.Fatalf("refresh token = %q, want new-refresh", got)
+	}
+	if expiry, ok := auth.ExpirationTime(); !ok || !expiry.Equal(expiresAt) {
+		t.Fatalf("expiry = %v (%v), want %v", expiry, ok, expiresAt)
+	}
+	if storage.AccessToken != "new" || storage.RefreshToken != "new-refresh" || storage.ExpiresAt != expiresAt.Unix() {
+		t.Fatalf("storage not updated: %+v", storage)
+	}
+}
+
+func TestClineEnsureFreshAccessTokenSkipsUnexpiredToken(t *testing.T) {
+	auth := &cliproxyauth.Auth{
+		Provider: "cline",
+		Metadata: map[string]any{
+			"accessToken":  "current",
+			"refreshToken": "refresh",
+			"expires_at":   time.Now().Add(time.Hour).Format(time.RFC3339),
+		},
+	}
+	token, err := NewClineExecutor(nil).ensureFreshAccessToken(context.Background(), auth)
+	if err != nil {
+		t.Fatalf("ensureFreshAccessToken: %v", err)
+	}
+	if token != "current" {
+		t.Fatalf("token = %q, want current", token)
+	}
+}
+
+func TestClineCountTokensEstimatesLocally(t *testing.T) {
+	payload := []byte(`{"model":"anthropic/claude-sonnet-4","messages":[{"role":"user","content":"Count the tokens in this short sentence."}]}`)
+	resp, err := NewClineExecutor(nil).CountTokens(context.Background(), nil, cliproxyexecutor.Request{
+		Model:   "anthropic/claude-sonnet-4",
+		Payload: payload,
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")})
+	if err != nil {
+		t.Fatalf("CountTokens: %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "usage.prompt_tokens").Int(); got <= 0 {
+		t.Fatalf("prompt_tokens = %d, payload %s", got, resp.Payload)
+	}
+}
@@ -1,7 +1,6 @@
 package executor
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -12,6 +11,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
@@ -84,7 +84,7 @@ func (e *CommandCodeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Au
 		return resp, fmt.Errorf("commandcode: build payload: %w", err)
 	}
 
-	url := commandCodeGenerateURL(auth)
+	url := commandCodeGenerateURL(e.cfg, auth)
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
 	if err != nil {
 		return resp, err
@@ -140,8 +140,7 @@ func (e *CommandCodeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Au
 	var inputTokens, outputTokens int64
 	finishReason := "stop"
 
-	scanner := bufio.NewScanner(httpResp.Body)
-	scanner.Buffer(nil, 52_428_800)
+	scanner := newStreamScanner(e.cfg, httpResp.Body)
 	for scanner.Scan() {
 		line := scanner.Bytes()
 		appendAPIResponseChunk(ctx, e.cfg, line)
@@ -225,7 +224,7 @@ func (e *CommandCodeExecutor) ExecuteStream(ctx context.Context, auth *cliproxya
 		return nil, fmt.Errorf("commandcode: build payload: %w", err)
 	}
 
-	url := commandCodeGenerateURL(auth)
+	url := commandCodeGenerateURL(e.cfg, auth)
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
 	if err != nil {
 		return nil, err
@@ -278,23 +277,25 @@ func (e *CommandCodeExecutor) ExecuteStream(ctx context.Context, auth *cliproxya
 	chatID := fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano())
 	go func() {
 		defer close(out)
+		defer helps.RecoverStreamPanic(ctx, out, e.Identifier())
 		defer func() {
 			if errClose := httpResp.Body.Close(); errClose != nil {
 				log.Errorf("commandcode executor: close response body error: %v", errClose)
 			}
 		}()
 
-		scanner := bufio.NewScanner(httpResp.Body)
-		scanner.Buffer(nil, 52_428_800)
+		scanner := newStreamScanner(e.cfg, httpResp.Body)
 		var param any
 		toolCallIndex := 0
+		encoder := helps.AcquireOpenAISSEChunkEncoder(chatID, baseModel, time.Now().Unix())
+		defer encoder.Release()
 
 		sendSSELine := func(sseLine []byte) {
 			appendAPIResponseChunk(ctx, e.cfg, sseLine)
 			if detail, ok := parseOpenAIStreamUsage(sseLine); ok {
 				reporter.publish(ctx, detail)
 			}
-			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, bytes.Clone(sseLine), &param)
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, sseLine, &param)
 			for i := range chunks {
 				select {
 				case out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}:
@@ -314,16 +315,7 @@ func (e *CommandCodeExecutor) ExecuteStream(ctx context.Context, auth *cliproxya
 			eventType := gjson.GetBytes(trimmed, "type").String()
 			switch eventType {
 			case "text-delta":
-				text := gjson.GetBytes(trimmed, "text").String()
-				chunk := commandCodeStreamChunk(chatID, baseModel, map[string]any{
-					"role":    "assistant",
-					"content": text,
-				}, "")
-				b, errMarshal := json.Marshal(chunk)
-				if errMarshal != nil {
-					continue
-				}
-				sendSSELine(append([]byte("data: "), b...))
+				sendSSELine(encoder.Content("assistant", gjson.GetBytes(trimmed, "text").String()))
 
 			case "reasoning-delta":
 				// Skip reasoning deltas; not surfaced to downstream clients.
@@ -335,25 +327,8 @@ func (e *CommandCodeExecutor) ExecuteStream(ctx context.Context, auth *cliproxya
 				if inputRaw == "" {
 					inputRaw = "{}"
 				}
-				chunk := commandCodeStreamChunk(chatID, baseModel, map[string]any{
-					"tool_calls": []map[string]any{
-						{
-							"index": toolCallIndex,
-							"id":    toolCallID,
-							"type":  "function",
-							"function": map[string]any{
-								"name":      toolName,
-								"arguments": inputRaw,
-							},
-						},
-					},
-				}, "")
+				sendSSELine(encoder.ToolCall(toolCallIndex, toolCallID, toolName, inputRaw))
 				toolCallIndex++
-				b, errMarshal := json.Marshal(chunk)
-				if errMarshal != nil {
-					continue
-				}
-				sendSSELine(append([]byte("data: "), b...))
 
 			case "finish":
 				inputTokens := gjson.GetBytes(trimmed, "totalUsage.inputTokens").Int()
@@ -362,28 +337,7 @@ func (e *CommandCodeExecutor) ExecuteStream(ctx context.Context, auth *cliproxya
 				if fr == "" {
 					fr = "stop"
 				}
-				finishChunk := map[string]any{
-					"id":      chatID,
-					"object":  "chat.completion.chunk",
-					"created": time.Now().Unix(),
-					"model":   baseModel,
-					"choices": []map[string]any{
-						{
-							"index":         0,
-							"delta":         map[string]any{},
-							"finish_reason": fr,
-						},
-					},
-					"usage": map[string]any{
-						"prompt_tokens":     inputTokens,
-						"completion_tokens": outputTokens,
-						"total_tokens":      inputTokens + outputTokens,
-					},
-				}
-				b, errMarshal := json.Marshal(finishChunk)
-				if errMarshal == nil {
-					sendSSELine(append([]byte("data: "), b...))
-				}
+				sendSSELine(encoder.Finish(fr, &helps.OpenAIChunkUsage{PromptTokens: inputTokens, CompletionTokens: outputTokens}))
 				sendSSELine([]byte("data: [DONE]"))
 			}
 		}
@@ -431,14 +385,8 @@ func commandCodeAPIKey(auth *cliproxyauth.Auth) string {
 	return ""
 }
 
-func commandCodeGenerateURL(auth *cliproxyauth.Auth) string {
-	baseURL := commandCodeBaseURL
-	if auth != nil && auth.Attributes != nil {
-		if configured := strings.TrimSpace(auth.Attributes["base_url"]); configured != "" {
-			baseURL = configured
-		}
-	}
-	return strings.TrimRight(baseURL, "/") + "/alpha/generate"
+func commandCodeGenerateURL(cfg *config.Config, auth *cliproxyauth.Auth) string {
+	return helps.ProviderBaseURL(cfg, auth, "commandcode", commandCodeBaseURL) + "/alpha/generate"
 }
 
 // applyCommandCodeHeaders sets the required CommandCode request headers.
@@ -733,21 +681,3 @@ func compactCommandCodeTextParts(parts []string) []string {
 	}
 	return filtered
 }
-
-func commandCodeStreamChunk(id, model string, delta map[string]any, finishReason string) map[string]any {
-	choice := map[string]any{
-		"index":         0,
-		"delta":         delta,
-		"finish_reason": nil,
-	}
-	if finishReason != "" {
-		choice["finish_reason"] = finishReason
-	}
-	return map[string]any{
-		"id":      id,
-		"object":  "chat.completion.chunk",
-		"created": time.Now().Unix(),
-		"model":   model,
-		"choices": []map[string]any{choice},
-	}
-}
@@ -104,10 +104,10 @@ func Test_CommandCodeGenerateURL_uses_default_and_configured_base_url(t *testing
 		"base_url": "https://mock.commandcode.test/",
 	}}
 
-	if got := commandCodeGenerateURL(defaultAuth); got != "https://api.commandcode.ai/alpha/generate" {
+	if got := commandCodeGenerateURL(nil, defaultAuth); got != "https://api.commandcode.ai/alpha/generate" {
 		t.Fatalf("default generate URL = %q", got)
 	}
-	if got := commandCodeGenerateURL(customAuth); got != "https://mock.commandcode.test/alpha/generate" {
+	if got := commandCodeGenerateURL(nil, customAuth); got != "https://mock.commandcode.test/alpha/generate" {
 		t.Fatalf("custom generate URL = %q", got)
 	}
 }
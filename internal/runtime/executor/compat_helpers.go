@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"strings"
@@ -24,6 +25,10 @@ func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *clip
 	return helps.NewProxyAwareHTTPClient(ctx, cfg, auth, timeout)
 }
 
+func wrapUpstreamClient(cfg *config.Config, provider string, client *http.Client) *http.Client {
+	return helps.WrapUpstreamClient(cfg, provider, client)
+}
+
 func parseOpenAIUsage(data []byte) usage.Detail {
 	return helps.ParseOpenAIUsage(data)
 }
@@ -327,3 +332,15 @@ func (r *usageReporter) ensurePublished(ctx context.Context) {
 	}
 	r.reporter.EnsurePublished(ctx)
 }
+
+func sendStreamChunk(ctx context.Context, out chan<- cliproxyexecutor.StreamChunk, chunk cliproxyexecutor.StreamChunk) bool {
+	return helps.SendStreamChunk(ctx, out, chunk)
+}
+
+func newSSEReader(cfg *config.Config, r io.Reader) *helps.SSEReader {
+	return helps.NewSSEReader(cfg, r)
+}
+
+func newStreamScanner(cfg *config.Config, r io.Reader) *helps.StreamScanner {
+	return helps.NewStreamScanner(cfg, r)
+}
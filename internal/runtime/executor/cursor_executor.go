@@ -21,6 +21,7 @@ import (
 	cursorproto "github.com/router-for-me/CLIProxyAPI/v7/internal/auth/cursor/proto"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
@@ -534,23 +535,24 @@ func (e *CursorExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 		}
 	}
 
-	// Wrap sendChunk/sendDone to use emitToOut
-	sendChunkSwitchable := func(delta string, finishReason string) {
-		fr := "null"
-		if finishReason != "" {
-			fr = finishReason
-		}
-		openaiJSON := fmt.Sprintf(`{"id":"%s","object":"chat.completion.chunk","created":%d,"model":"%s","choices":[{"index":0,"delta":%s,"finish_reason":%s}]}`,
-			chatId, created, parsed.Model, delta, fr)
-		sseLine := []byte("data: " + openaiJSON + "\n")
+	// The chunk encoder renders SSE lines for the translator, or bare JSON when the
+	// client speaks OpenAI. It is only used from the session goroutine below.
+	var chunkEncoder *helps.OpenAIChunkEncoder
+	if needsTranslate {
+		chunkEncoder = helps.AcquireOpenAISSEChunkEncoder(chatId, parsed.Model, created)
+	} else {
+		chunkEncoder = helps.AcquireOpenAIChunkEncoder(chatId, parsed.Model, created)
+	}
 
+	// Wrap chunk delivery to use emitToOut
+	sendChunkSwitchable := func(chunk []byte) {
 		if needsTranslate {
-			translated := sdktranslator.TranslateStream(ctx, to, from, req.Model, originalPayload, payload, sseLine, &streamParam)
+			translated := sdktranslator.TranslateStream(ctx, to, from, req.Model, originalPayload, payload, chunk, &streamParam)
 			for _, t := range translated {
 				emitToOut(cliproxyexecutor.StreamChunk{Payload: bytes.Clone(t)})
 			}
 		} else {
-			emitToOut(cliproxyexecutor.StreamChunk{Payload: []byte(openaiJSON)})
+			emitToOut(cliproxyexecutor.StreamChunk{Payload: chunk})
 		}
 	}
 
@@ -581,6 +583,7 @@ func (e *CursorExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 	}
 
 	go func() {
+		defer chunkEncoder.Release()
 		var resumeOutCh chan cliproxyexecutor.StreamChunk
 		_ = resumeOutCh
 		thinkingActive := false
@@ -593,27 +596,25 @@ func (e *CursorExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 				if isThinking {
 					if !thinkingActive {
 						thinkingActive = true
-						sendChunkSwitchable(`{"role":"assistant","content":"<think>"}`, "")
+						sendChunkSwitchable(chunkEncoder.Delta(`{"role":"assistant","content":"<think>"}`, ""))
 					}
-					sendChunkSwitchable(fmt.Sprintf(`{"content":%s}`, jsonString(text)), "")
+					sendChunkSwitchable(chunkEncoder.Content("", text))
 				} else {
 					if thinkingActive {
 						thinkingActive = false
-						sendChunkSwitchable(`{"content":"</think>"}`, "")
+						sendChunkSwitchable(chunkEncoder.Delta(`{"content":"</think>"}`, ""))
 					}
-					sendChunkSwitchable(fmt.Sprintf(`{"content":%s}`, jsonString(text)), "")
+					sendChunkSwitchable(chunkEncoder.Content("", text))
 				}
 			},
 			func(exec pendingMcpExec) {
 				if thinkingActive {
 					thinkingActive = false
-					sendChunkSwitchable(`{"content":"</think>"}`, "")
+					sendChunkSwitchable(chunkEncoder.Delta(`{"content":"</think>"}`, ""))
 				}
-				toolCallJSON := fmt.Sprintf(`{"tool_calls":[{"index":%d,"id":"%s","type":"function","function":{"name":"%s","arguments":%s}}]}`,
-					toolCallIndex, exec.ToolCallId, exec.ToolName, jsonString(exec.Args))
+				sendChunkSwitchable(chunkEncoder.ToolCall(toolCallIndex, exec.ToolCallId, exec.ToolName, exec.Args))
 				toolCallIndex++
-				sendChunkSwitchable(toolCallJSON, "")
-				sendChunkSwitchable(`{}`, `"tool_calls"`)
+				sendChunkSwitchable(chunkEncoder.Finish("tool_calls", nil))
 				sendDoneSwitchable()
 
 				// Close current output to end the current HTTP SSE response
@@ -697,27 +698,12 @@ func (e *CursorExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 		}
 
 		if thinkingActive {
-			sendChunkSwitchable(`{"content":"</think>"}`, "")
+			sendChunkSwitchable(chunkEncoder.Delta(`{"content":"</think>"}`, ""))
 		}
 		// Include token usage in the final stop chunk
 		inputTok, outputTok := usage.get()
-		stopDelta := fmt.Sprintf(`{},"usage":{"prompt_tokens":%d,"completion_tokens":%d,"total_tokens":%d}`,
-			inputTok, outputTok, inputTok+outputTok)
-		// Build the stop chunk with usage embedded in the choices array level
-		fr := `"stop"`
-		openaiJSON := fmt.Sprintf(`{"id":"%s","object":"chat.completion.chunk","created":%d,"model":"%s","choices":[{"index":0,"delta":{},"finish_reason":%s}],"usage":{"prompt_tokens":%d,"completion_tokens":%d,"total_tokens":%d}}`,
-			chatId, created, parsed.Model, fr, inputTok, outputTok, inputTok+outputTok)
-		sseLine := []byte("data: " + openaiJSON + "\n")
-		if needsTranslate {
-			translated := sdktranslator.TranslateStream(ctx, to, from, req.Model, originalPayload, payload, sseLine, &streamParam)
-			for _, t := range translated {
-				emitToOut(cliproxyexecutor.StreamChunk{Payload: bytes.Clone(t)})
-			}
-		} else {
-			emitToOut(cliproxyexecutor.StreamChunk{Payload: []byte(openaiJSON)})
-		}
+		sendChunkSwitchable(chunkEncoder.Finish("stop", &helps.OpenAIChunkUsage{PromptTokens: inputTok, CompletionTokens: outputTok}))
 		sendDoneSwitchable()
-		_ = stopDelta // unused
 
 		// Close whatever output channel is still active
 		outMu.Lock()
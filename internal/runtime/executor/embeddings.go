@@ -0,0 +1,293 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// openAICompatEmbeddingsBatchSize matches the OpenAI limit on inputs per request.
+	openAICompatEmbeddingsBatchSize = 2048
+	// geminiEmbeddingsBatchSize matches the batchEmbedContents limit on requests per call.
+	geminiEmbeddingsBatchSize = 100
+)
+
+// embeddingsRequest holds the client options shared by every provider implementation.
+type embeddingsRequest struct {
+	inputs         []gjson.Result
+	dimensions     int
+	encodingFormat string
+}
+
+func parseEmbeddingsRequest(payload []byte) (embeddingsRequest, error) {
+	req := embeddingsRequest{
+		inputs:         helps.EmbeddingInputs(payload),
+		dimensions:     int(gjson.GetBytes(payload, "dimensions").Int()),
+		encodingFormat: gjson.GetBytes(payload, "encoding_format").String(),
+	}
+	if len(req.inputs) == 0 {
+		return req, statusErr{code: http.StatusBadRequest, msg: "input is required"}
+	}
+	return req, nil
+}
+
+// postEmbeddingsBatch sends one upstream embeddings call and returns the response body.
+func postEmbeddingsBatch(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, provider string, client *http.Client, url string, headers http.Header, body []byte) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range headers {
+		httpReq.Header[key] = values
+	}
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+	var authID, authLabel, authType, authValue string
+	if auth != nil {
+		authID = auth.ID
+		authLabel = auth.Label
+		authType, authValue = auth.AccountInfo()
+	}
+	helps.RecordAPIRequest(ctx, cfg, helps.UpstreamRequestLog{
+		URL:       url,
+		Method:    http.MethodPost,
+		Headers:   httpReq.Header.Clone(),
+		Body:      body,
+		Provider:  provider,
+		AuthID:    authID,
+		AuthLabel: authLabel,
+		AuthType:  authType,
+		AuthValue: authValue,
+	})
+
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, cfg, err)
+		return nil, err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("%s executor: close embeddings response body error: %v", provider, errClose)
+		}
+	}()
+	helps.RecordAPIResponseMetadata(ctx, cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		helps.RecordAPIResponseError(ctx, cfg, err)
+		return nil, err
+	}
+	helps.AppendAPIResponseChunk(ctx, cfg, data)
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		helps.LogWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, helps.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), data))
+		return nil, statusErr{code: httpResp.StatusCode, msg: string(data)}
+	}
+	return data, nil
+}
+
+// executeOpenAIEmbeddingBatches posts each batch built by buildBody to an OpenAI-shaped
+// embeddings endpoint and returns the vectors in input order with the summed prompt tokens.
+func executeOpenAIEmbeddingBatches(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, provider string, client *http.Client, url string, headers http.Header, embedReq embeddingsRequest, buildBody func([]gjson.Result) []byte) ([][]float64, int64, error) {
+	vectors := make([][]float64, 0, len(embedReq.inputs))
+	var promptTokens int64
+	for _, batch := range helps.EmbeddingBatches(embedReq.inputs, openAICompatEmbeddingsBatchSize) {
+		data, errBatch := postEmbeddingsBatch(ctx, cfg, auth, provider, client, url, headers, buildBody(batch))
+		if errBatch != nil {
+			return nil, 0, errBatch
+		}
+		items := gjson.GetBytes(data, "data").Array()
+		if len(items) != len(batch) {
+			return nil, 0, statusErr{code: http.StatusBadGateway, msg: fmt.Sprintf("embeddings: upstream returned %d vectors for %d inputs", len(items), len(batch))}
+		}
+		batchVectors := make([][]float64, len(items))
+		for _, item := range items {
+			index := int(item.Get("index").Int())
+			if index < 0 || index >= len(batchVectors) {
+				return nil, 0, statusErr{code: http.StatusBadGateway, msg: "embeddings: upstream returned an out of range index"}
+			}
+			vector, errDecode := helps.DecodeEmbedding(item.Get("embedding"))
+			if errDecode != nil {
+				return nil, 0, statusErr{code: http.StatusBadGateway, msg: errDecode.Error()}
+			}
+			batchVectors[index] = helps.NormalizeEmbeddingDimensions(vector, embedReq.dimensions)
+		}
+		vectors = append(vectors, batchVectors...)
+		promptTokens += gjson.GetBytes(data, "usage.prompt_tokens").Int()
+	}
+	return vectors, promptTokens, nil
+}
+
+// Embeddings forwards an OpenAI embeddings request to {base}/embeddings, splitting large
+// inputs into batches and truncating vectors to the requested dimensions when the
+// upstream ignores the parameter.
+func (e *OpenAICompatExecutor) Embeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewExecutorUsageReporter(ctx, e, baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	baseURL, apiKey := e.resolveCredentials(auth)
+	if baseURL == "" {
+		err = statusErr{code: http.StatusUnauthorized, msg: "missing provider baseURL"}
+		return resp, err
+	}
+	embedReq, err := parseEmbeddingsRequest(req.Payload)
+	if err != nil {
+		return resp, err
+	}
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		headers.Set("Authorization", "Bearer "+apiKey)
+	}
+	headers.Set("User-Agent", "cli-proxy-openai-compat")
+	url := strings.TrimSuffix(baseURL, "/") + "/embeddings"
+	client := reporter.TrackHTTPClient(helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0))
+
+	vectors, promptTokens, err := executeOpenAIEmbeddingBatches(ctx, e.cfg, auth, e.Identifier(), client, url, headers, embedReq, func(batch []gjson.Result) []byte {
+		body, _ := sjson.SetBytes(req.Payload, "model", baseModel)
+		body, _ = sjson.SetRawBytes(body, "input", helps.EmbeddingInputsJSON(batch))
+		body, _ = sjson.SetBytes(body, "encoding_format", "float")
+		return body
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	reporter.Publish(ctx, usage.Detail{InputTokens: promptTokens, TotalTokens: promptTokens})
+	reporter.EnsurePublished(ctx)
+	out := helps.BuildOpenAIEmbeddingsResponse(req.Model, vectors, promptTokens, embedReq.encodingFormat)
+	return cliproxyexecutor.Response{Payload: out}, nil
+}
+
+// Embeddings converts an OpenAI embeddings request to Gemini batchEmbedContents calls.
+// Gemini returns no token usage, so prompt tokens are estimated locally.
+func (e *GeminiExecutor) Embeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := helps.NewExecutorUsageReporter(ctx, e, baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	embedReq, err := parseEmbeddingsRequest(req.Payload)
+	if err != nil {
+		return resp, err
+	}
+	for _, input := range embedReq.inputs {
+		if input.Type != gjson.String {
+			err = statusErr{code: http.StatusBadRequest, msg: "gemini embeddings only accept string inputs"}
+			return resp, err
+		}
+	}
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+	if apiKey := geminiAPIKey(auth); apiKey != "" {
+		headers.Set("x-goog-api-key", apiKey)
+	}
+	url := fmt.Sprintf("%s/%s/models/%s:batchEmbedContents", resolveGeminiBaseURL(auth), glAPIVersion, baseModel)
+	client := reporter.TrackHTTPClient(helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0))
+
+	vectors := make([][]float64, 0, len(embedReq.inputs))
+	for _, batch := range helps.EmbeddingBatches(embedReq.inputs, geminiEmbeddingsBatchSize) {
+		body := []byte(`{"requests":[]}`)
+		for _, input := range batch {
+			item := []byte(`{}`)
+			item, _ = sjson.SetBytes(item, "model", "models/"+baseModel)
+			item, _ = sjson.SetBytes(item, "content.parts.0.text", input.String())
+			if embedReq.dimensions > 0 {
+				item, _ = sjson.SetBytes(item, "outputDimensionality", embedReq.dimensions)
+			}
+			body, _ = sjson.SetRawBytes(body, "requests.-1", item)
+		}
+		data, errBatch := postEmbeddingsBatch(ctx, e.cfg, auth, e.Identifier(), client, url, headers, body)
+		if errBatch != nil {
+			err = errBatch
+			return resp, err
+		}
+		embeddings := gjson.GetBytes(data, "embeddings").Array()
+		if len(embeddings) != len(batch) {
+			err = statusErr{code: http.StatusBadGateway, msg: fmt.Sprintf("embeddings: upstream returned %d vectors for %d inputs", len(embeddings), len(batch))}
+			return resp, err
+		}
+		for _, embedding := range embeddings {
+			vector, errDecode := helps.DecodeEmbedding(embedding.Get("values"))
+			if errDecode != nil {
+				err = statusErr{code: http.StatusBadGateway, msg: errDecode.Error()}
+				return resp, err
+			}
+			// Gemini only normalizes full-size vectors, so shortened ones are rescaled here.
+			vectors = append(vectors, helps.NormalizeEmbeddingDimensions(vector, embedReq.dimensions))
+		}
+	}
+
+	var promptTokens int64
+	if enc, errTokenizer := helps.TokenizerForModel(baseModel); errTokenizer == nil {
+		for _, input := range embedReq.inputs {
+			if count, errCount := enc.Count(input.String()); errCount == nil {
+				promptTokens += int64(count)
+			}
+		}
+	}
+	reporter.Publish(ctx, usage.Detail{InputTokens: promptTokens, TotalTokens: promptTokens})
+	reporter.EnsurePublished(ctx)
+	out := helps.BuildOpenAIEmbeddingsResponse(req.Model, vectors, promptTokens, embedReq.encodingFormat)
+	return cliproxyexecutor.Response{Payload: out}, nil
+}
+
+// Embeddings calls the Mistral embeddings API. Mistral rejects unknown fields, so only
+// model and input are forwarded and dimensions are applied locally.
+func (e *MistralExecutor) Embeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	baseModel = resolveMistralModelName(e.cfg, auth, baseModel)
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	apiKey := mistralAPIKey(auth)
+	if apiKey == "" {
+		err = statusErr{code: http.StatusUnauthorized, msg: "mistral: missing API key"}
+		return resp, err
+	}
+	embedReq, err := parseEmbeddingsRequest(req.Payload)
+	if err != nil {
+		return resp, err
+	}
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "application/json")
+	headers.Set("Authorization", "Bearer "+apiKey)
+	url := e.resolveBaseURL(auth) + mistralEmbeddingsEndpoint
+	client := reporter.TrackHTTPClient(helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0))
+
+	vectors, promptTokens, err := executeOpenAIEmbeddingBatches(ctx, e.cfg, auth, e.Identifier(), client, url, headers, embedReq, func(batch []gjson.Result) []byte {
+		body, _ := sjson.SetBytes([]byte(`{}`), "model", baseModel)
+		body, _ = sjson.SetRawBytes(body, "input", helps.EmbeddingInputsJSON(batch))
+		return body
+	})
+	if err != nil {
+		return resp, err
+	}
+
+	reporter.Publish(ctx, usage.Detail{InputTokens: promptTokens, TotalTokens: promptTokens})
+	reporter.EnsurePublished(ctx)
+	out := helps.BuildOpenAIEmbeddingsResponse(req.Model, vectors, promptTokens, embedReq.encodingFormat)
+	return cliproxyexecutor.Response{Payload: out}, nil
+}
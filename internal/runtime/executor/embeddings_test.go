@@ -0,0 +1,114 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestOpenAICompatExecutorEmbeddingsBatchesAndNormalizes(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.URL.Path != "/v1/embeddings" {
+			t.Errorf("path = %q, want /v1/embeddings", r.URL.Path)
+		}
+		body, _ := io.ReadAll(r.Body)
+		if gjson.GetBytes(body, "encoding_format").String() != "float" {
+			t.Errorf("upstream encoding_format = %s", gjson.GetBytes(body, "encoding_format").Raw)
+		}
+		inputs := gjson.GetBytes(body, "input").Array()
+		data := make([]string, len(inputs))
+		for i := range inputs {
+			// Reverse the order to check vectors are placed by index.
+			data[i] = fmt.Sprintf(`{"object":"embedding","index":%d,"embedding":[3,4,12]}`, len(inputs)-1-i)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"object":"list","data":[%s],"usage":{"prompt_tokens":%d,"total_tokens":%d}}`, strings.Join(data, ","), len(inputs), len(inputs))
+	}))
+	defer server.Close()
+
+	inputs := make([]string, openAICompatEmbeddingsBatchSize+1)
+	for i := range inputs {
+		inputs[i] = fmt.Sprintf("%q", fmt.Sprintf("text-%d", i))
+	}
+	payload := []byte(`{"model":"embed","dimensions":2,"input":[` + strings.Join(inputs, ",") + `]}`)
+
+	executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": server.URL + "/v1", "api_key": "test"}}
+	resp, err := executor.Embeddings(context.Background(), auth, cliproxyexecutor.Request{Model: "embed", Payload: payload}, cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString(cliproxyauth.EmbeddingsSourceFormat),
+	})
+	if err != nil {
+		t.Fatalf("Embeddings error: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("upstream calls = %d, want 2", calls)
+	}
+	data := gjson.GetBytes(resp.Payload, "data").Array()
+	if len(data) != len(inputs) {
+		t.Fatalf("data length = %d, want %d", len(data), len(inputs))
+	}
+	if got := data[len(data)-1].Get("index").Int(); got != int64(len(inputs)-1) {
+		t.Fatalf("last index = %d", got)
+	}
+	if got := data[0].Get("embedding").Raw; got != "[0.6,0.8]" {
+		t.Fatalf("embedding = %s, want [0.6,0.8]", got)
+	}
+	if got := gjson.GetBytes(resp.Payload, "usage.prompt_tokens").Int(); got != int64(len(inputs)) {
+		t.Fatalf("prompt_tokens = %d", got)
+	}
+}
+
+func TestGeminiExecutorEmbeddingsUsesBatchEmbedContents(t *testing.T) {
+	var gotPath string
+	var gotBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"embeddings":[{"values":[1,0]},{"values":[0,2]}]}`))
+	}))
+	defer server.Close()
+
+	executor := NewGeminiExecutor(&config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": server.URL, "api_key": "test"}}
+	payload := []byte(`{"model":"gemini-embedding-001","input":["a","b"],"dimensions":2,"encoding_format":"float"}`)
+	resp, err := executor.Embeddings(context.Background(), auth, cliproxyexecutor.Request{Model: "gemini-embedding-001", Payload: payload}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("Embeddings error: %v", err)
+	}
+	if gotPath != "/v1beta/models/gemini-embedding-001:batchEmbedContents" {
+		t.Fatalf("path = %q", gotPath)
+	}
+	if got := gjson.GetBytes(gotBody, "requests.1.content.parts.0.text").String(); got != "b" {
+		t.Fatalf("second request text = %q", got)
+	}
+	if got := gjson.GetBytes(gotBody, "requests.0.outputDimensionality").Int(); got != 2 {
+		t.Fatalf("outputDimensionality = %d", got)
+	}
+	if got := gjson.GetBytes(resp.Payload, "data.1.embedding").Raw; got != "[0,1]" {
+		t.Fatalf("second embedding = %s, want normalized [0,1]", got)
+	}
+}
+
+func TestGeminiExecutorEmbeddingsRejectsTokenInputs(t *testing.T) {
+	executor := NewGeminiExecutor(&config.Config{})
+	_, err := executor.Embeddings(context.Background(), nil, cliproxyexecutor.Request{Model: "gemini-embedding-001", Payload: []byte(`{"input":[1,2,3]}`)}, cliproxyexecutor.Options{})
+	if err == nil {
+		t.Fatal("expected error for token array input")
+	}
+	if status, ok := err.(statusErr); !ok || status.code != http.StatusBadRequest {
+		t.Fatalf("error = %v, want 400 statusErr", err)
+	}
+}
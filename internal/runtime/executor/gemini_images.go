@@ -0,0 +1,204 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// geminiImageAspectRatios lists the aspect ratios accepted by Gemini image models.
+var geminiImageAspectRatios = []string{"1:1", "2:3", "3:2", "3:4", "4:3", "4:5", "5:4", "9:16", "16:9", "21:9"}
+
+// imagenAspectRatios lists the narrower set accepted by Imagen models.
+var imagenAspectRatios = []string{"1:1", "3:4", "4:3", "9:16", "16:9"}
+
+// geminiImageAspectRatio maps an OpenAI size such as "1536x1024" to the closest supported
+// aspect ratio. "auto" and unparsable sizes return "" so the provider default applies.
+func geminiImageAspectRatio(size string, supported []string) string {
+	width, height, ok := parseImageSize(size)
+	if !ok {
+		return ""
+	}
+	target := float64(width) / float64(height)
+	best, bestDiff := "", 0.0
+	for _, ratio := range supported {
+		w, h, _ := strings.Cut(ratio, ":")
+		rw, _ := strconv.ParseFloat(w, 64)
+		rh, _ := strconv.ParseFloat(h, 64)
+		diff := target/(rw/rh) - 1
+		if diff < 0 {
+			diff = -diff
+		}
+		if best == "" || diff < bestDiff {
+			best, bestDiff = ratio, diff
+		}
+	}
+	return best
+}
+
+// geminiImageSize maps the OpenAI size and quality to Gemini's imageSize tier, picking the
+// tier that covers the longest edge; "hd" and "high" quality ask for at least 2K. An empty
+// result leaves the provider default.
+func geminiImageSize(size, quality string) string {
+	tier := 0
+	if width, height, ok := parseImageSize(size); ok {
+		switch edge := max(width, height); {
+		case edge > 2048:
+			tier = 4
+		case edge > 1024:
+			tier = 2
+		default:
+			tier = 1
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(quality)) {
+	case "hd", "high":
+		tier = max(tier, 2)
+	}
+	if tier == 0 {
+		return ""
+	}
+	return strconv.Itoa(tier) + "K"
+}
+
+func parseImageSize(size string) (int, int, bool) {
+	w, h, found := strings.Cut(strings.ToLower(strings.TrimSpace(size)), "x")
+	if !found {
+		return 0, 0, false
+	}
+	width, errW := strconv.Atoi(w)
+	height, errH := strconv.Atoi(h)
+	if errW != nil || errH != nil || width <= 0 || height <= 0 {
+		return 0, 0, false
+	}
+	return width, height, true
+}
+
+// geminiImageModelSupportsImageSize reports whether the model accepts imageConfig.imageSize;
+// the Gemini 2.5 image models only support the default resolution.
+func geminiImageModelSupportsImageSize(model string) bool {
+	return !strings.Contains(strings.ToLower(model), "2.5")
+}
+
+// buildGeminiImagesRequest converts an OpenAI image generation request into a Gemini
+// generateContent body. Imagen models additionally get the top-level fields read by
+// convertToImagenRequest, so one request fans out into sampleCount images.
+func buildGeminiImagesRequest(model string, payload []byte) ([]byte, error) {
+	prompt := strings.TrimSpace(gjson.GetBytes(payload, "prompt").String())
+	if prompt == "" {
+		return nil, statusErr{code: http.StatusBadRequest, msg: "prompt is required"}
+	}
+	size := gjson.GetBytes(payload, "size").String()
+	quality := gjson.GetBytes(payload, "quality").String()
+
+	body := []byte(`{"contents":[{"role":"user","parts":[{"text":""}]}],"generationConfig":{"responseModalities":["IMAGE"]}}`)
+	body, _ = sjson.SetBytes(body, "contents.0.parts.0.text", prompt)
+	if isImagenModel(model) {
+		body, _ = sjson.SetBytes(body, "sampleCount", max(gjson.GetBytes(payload, "n").Int(), 1))
+		if ratio := geminiImageAspectRatio(size, imagenAspectRatios); ratio != "" {
+			body, _ = sjson.SetBytes(body, "aspectRatio", ratio)
+		}
+		return body, nil
+	}
+	if ratio := geminiImageAspectRatio(size, geminiImageAspectRatios); ratio != "" {
+		body, _ = sjson.SetBytes(body, "generationConfig.imageConfig.aspectRatio", ratio)
+	}
+	if imageSize := geminiImageSize(size, quality); imageSize != "" && geminiImageModelSupportsImageSize(model) {
+		body, _ = sjson.SetBytes(body, "generationConfig.imageConfig.imageSize", imageSize)
+	}
+	return body, nil
+}
+
+// generateImagesViaGemini serves an OpenAI image generation request through a Gemini-native
+// execute function. Gemini image models return one image per call, so n > 1 repeats the call;
+// Imagen models honour sampleCount in a single call.
+func generateImagesViaGemini(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, execute func(context.Context, *cliproxyauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error)) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	body, err := buildGeminiImagesRequest(baseModel, req.Payload)
+	if err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	calls := 1
+	if !isImagenModel(baseModel) {
+		calls = int(max(gjson.GetBytes(req.Payload, "n").Int(), 1))
+	}
+
+	geminiReq := req
+	geminiReq.Payload = body
+	geminiOpts := opts
+	geminiOpts.SourceFormat = sdktranslator.FromString("gemini")
+	geminiOpts.ResponseFormat = ""
+	geminiOpts.OriginalRequest = body
+	geminiOpts.Stream = false
+
+	data := []byte(`[]`)
+	var inputTokens, outputTokens int64
+	var headers http.Header
+	for range calls {
+		resp, errExec := execute(ctx, auth, geminiReq, geminiOpts)
+		if errExec != nil {
+			return cliproxyexecutor.Response{}, errExec
+		}
+		headers = resp.Headers
+		gjson.GetBytes(resp.Payload, "candidates.#.content.parts").ForEach(func(_, parts gjson.Result) bool {
+			parts.ForEach(func(_, part gjson.Result) bool {
+				inline := part.Get("inlineData")
+				if !inline.Exists() {
+					inline = part.Get("inline_data")
+				}
+				if encoded := inline.Get("data").String(); encoded != "" {
+					item := []byte(`{}`)
+					item, _ = sjson.SetBytes(item, "b64_json", encoded)
+					mimeType := inline.Get("mimeType").String()
+					if mimeType == "" {
+						mimeType = inline.Get("mime_type").String()
+					}
+					if mimeType != "" {
+						item, _ = sjson.SetBytes(item, "mime_type", mimeType)
+					}
+					data, _ = sjson.SetRawBytes(data, "-1", item)
+				}
+				return true
+			})
+			return true
+		})
+		inputTokens += gjson.GetBytes(resp.Payload, "usageMetadata.promptTokenCount").Int()
+		outputTokens += gjson.GetBytes(resp.Payload, "usageMetadata.candidatesTokenCount").Int()
+	}
+	if len(gjson.ParseBytes(data).Array()) == 0 {
+		return cliproxyexecutor.Response{}, statusErr{code: http.StatusBadGateway, msg: fmt.Sprintf("%s returned no image output", baseModel)}
+	}
+
+	out := []byte(`{"created":0,"data":[]}`)
+	out, _ = sjson.SetBytes(out, "created", time.Now().Unix())
+	out, _ = sjson.SetRawBytes(out, "data", data)
+	out, _ = sjson.SetBytes(out, "usage.input_tokens", inputTokens)
+	out, _ = sjson.SetBytes(out, "usage.output_tokens", outputTokens)
+	out, _ = sjson.SetBytes(out, "usage.total_tokens", inputTokens+outputTokens)
+	return cliproxyexecutor.Response{Payload: out, Headers: headers}, nil
+}
+
+// GenerateImages serves /v1/images/generations for Gemini image models.
+func (e *GeminiExecutor) GenerateImages(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return generateImagesViaGemini(ctx, auth, req, opts, e.Execute)
+}
+
+// GenerateImages serves /v1/images/generations for Gemini image and Imagen models on Vertex AI.
+func (e *GeminiVertexExecutor) GenerateImages(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return generateImagesViaGemini(ctx, auth, req, opts, e.Execute)
+}
+
+// GenerateImages serves /v1/images/generations for Gemini image models on AI Studio.
+func (e *AIStudioExecutor) GenerateImages(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return generateImagesViaGemini(ctx, auth, req, opts, e.Execute)
+}
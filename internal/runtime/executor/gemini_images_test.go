@@ -0,0 +1,68 @@
+package executor
+
+import (
+	"context"
+	"testing"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+func TestBuildGeminiImagesRequestTranslatesSizeAndQuality(t *testing.T) {
+	body, err := buildGeminiImagesRequest("gemini-3-pro-image-preview", []byte(`{"prompt":"a cat","size":"1536x1024","quality":"high"}`))
+	if err != nil {
+		t.Fatalf("buildGeminiImagesRequest error: %v", err)
+	}
+	if got := gjson.GetBytes(body, "generationConfig.imageConfig.aspectRatio").String(); got != "3:2" {
+		t.Fatalf("aspectRatio = %q, want 3:2", got)
+	}
+	if got := gjson.GetBytes(body, "generationConfig.imageConfig.imageSize").String(); got != "2K" {
+		t.Fatalf("imageSize = %q, want 2K", got)
+	}
+
+	body, _ = buildGeminiImagesRequest("gemini-2.5-flash-image", []byte(`{"prompt":"a cat","size":"1024x1792","quality":"hd"}`))
+	if got := gjson.GetBytes(body, "generationConfig.imageConfig.aspectRatio").String(); got != "9:16" {
+		t.Fatalf("aspectRatio = %q, want 9:16", got)
+	}
+	if gjson.GetBytes(body, "generationConfig.imageConfig.imageSize").Exists() {
+		t.Fatal("imageSize should be omitted for Gemini 2.5 image models")
+	}
+
+	body, _ = buildGeminiImagesRequest("imagen-4.0-generate-001", []byte(`{"prompt":"a cat","size":"1024x1024","n":3}`))
+	if gjson.GetBytes(body, "sampleCount").Int() != 3 || gjson.GetBytes(body, "aspectRatio").String() != "1:1" {
+		t.Fatalf("unexpected imagen request: %s", body)
+	}
+
+	if _, err = buildGeminiImagesRequest("gemini-3-pro-image-preview", []byte(`{"size":"auto"}`)); err == nil {
+		t.Fatal("expected error for missing prompt")
+	}
+}
+
+func TestGenerateImagesViaGeminiRepeatsCallsForN(t *testing.T) {
+	calls := 0
+	execute := func(_ context.Context, _ *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+		calls++
+		if opts.SourceFormat.String() != "gemini" || !gjson.GetBytes(req.Payload, "contents").Exists() {
+			t.Fatalf("unexpected native request: format=%s payload=%s", opts.SourceFormat, req.Payload)
+		}
+		return cliproxyexecutor.Response{Payload: []byte(`{"candidates":[{"content":{"parts":[{"text":"ok"},{"inlineData":{"mimeType":"image/jpeg","data":"AAAA"}}]}}],"usageMetadata":{"promptTokenCount":5,"candidatesTokenCount":10}}`)}, nil
+	}
+	resp, err := generateImagesViaGemini(context.Background(), nil, cliproxyexecutor.Request{
+		Model:   "gemini-3-pro-image-preview",
+		Payload: []byte(`{"prompt":"a cat","n":2}`),
+	}, cliproxyexecutor.Options{}, execute)
+	if err != nil {
+		t.Fatalf("generateImagesViaGemini error: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+	data := gjson.GetBytes(resp.Payload, "data").Array()
+	if len(data) != 2 || data[0].Get("b64_json").String() != "AAAA" || data[0].Get("mime_type").String() != "image/jpeg" {
+		t.Fatalf("unexpected images response: %s", resp.Payload)
+	}
+	if got := gjson.GetBytes(resp.Payload, "usage.total_tokens").Int(); got != 30 {
+		t.Fatalf("usage.total_tokens = %d, want 30", got)
+	}
+}
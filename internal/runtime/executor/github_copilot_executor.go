@@ -1,7 +1,6 @@
 package executor
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"fmt"
@@ -34,8 +33,6 @@ const (
 	githubCopilotTokenCacheTTL = 25 * time.Minute
 	// tokenExpiryBuffer is the time before expiry when we should refresh the token.
 	tokenExpiryBuffer = 5 * time.Minute
-	// maxScannerBufferSize is the maximum buffer size for SSE scanning (20MB).
-	maxScannerBufferSize = 20_971_520
 
 	// Copilot API header values.
 	copilotUserAgent     = "GitHubCopilotChat/0.35.0"
@@ -364,14 +361,14 @@ func (e *GitHubCopilotExecutor) ExecuteStream(ctx context.Context, auth *cliprox
 
 	go func() {
 		defer close(out)
+		defer helps.RecoverStreamPanic(ctx, out, e.Identifier())
 		defer func() {
 			if errClose := httpResp.Body.Close(); errClose != nil {
 				log.Errorf("github-copilot executor: close response body error: %v", errClose)
 			}
 		}()
 
-		scanner := bufio.NewScanner(httpResp.Body)
-		scanner.Buffer(nil, maxScannerBufferSize)
+		scanner := newStreamScanner(e.cfg, httpResp.Body)
 		var param any
 
 		for scanner.Scan() {
@@ -413,14 +410,14 @@ func (e *GitHubCopilotExecutor) ExecuteStream(ctx context.Context, auth *cliprox
 				chunks = sdktranslator.TranslateStream(ctx, to, from, req.Model, bytes.Clone(opts.OriginalRequest), body, normalizedLine, &param)
 			}
 			for i := range chunks {
-				out <- cliproxyexecutor.StreamChunk{Payload: bytes.Clone(chunks[i])}
+				sendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{Payload: bytes.Clone(chunks[i])})
 			}
 		}
 
 		if errScan := scanner.Err(); errScan != nil {
 			recordAPIResponseError(ctx, e.cfg, errScan)
 			reporter.publishFailure(ctx)
-			out <- cliproxyexecutor.StreamChunk{Err: errScan}
+			sendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{Err: errScan})
 		} else {
 			reporter.ensurePublished(ctx)
 		}
@@ -1757,6 +1754,14 @@ func FetchGitHubCopilotModels(ctx context.Context, auth *cliproxyauth.Auth, cfg
 				m.MaxCompletionTokens = limits.MaxOutputTokens
 			}
 		}
+		if supports := entry.Supports(); supports != nil {
+			m.Capabilities = &registry.ModelCapabilities{
+				SupportsTools:      supports.ToolCalls,
+				SupportsVision:     supports.Vision,
+				SupportsJSONSchema: supports.StructuredOutputs,
+				SupportsStreaming:  supports.Streaming,
+			}
+		}
 
 		models = append(models, m)
 	}
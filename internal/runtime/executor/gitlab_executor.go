@@ -1,10 +1,10 @@
 package executor
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -15,6 +15,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/gitlab"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
@@ -59,6 +60,8 @@ type gitLabOpenAIStreamState struct {
 	LastFullText string
 	Started      bool
 	Finished     bool
+
+	encoder *helps.OpenAIChunkEncoder
 }
 
 var gitLabAgenticCatalog = []gitLabCatalogModel{
@@ -163,6 +166,7 @@ func (e *GitLabExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 	out := make(chan cliproxyexecutor.StreamChunk, 8)
 	go func() {
 		defer close(out)
+		defer helps.RecoverStreamPanic(ctx, out, e.Identifier())
 		var param any
 		lines := buildGitLabOpenAIStream(responseModel, text)
 		for _, line := range lines {
@@ -177,7 +181,7 @@ func (e *GitLabExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 				&param,
 			)
 			for i := range chunks {
-				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+				sendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])})
 			}
 		}
 	}()
@@ -386,33 +390,30 @@ func (e *GitLabExecutor) requestCodeSuggestionsStream(
 	out := make(chan cliproxyexecutor.StreamChunk, 16)
 	go func() {
 		defer close(out)
+		defer helps.RecoverStreamPanic(ctx, out, e.Identifier())
 		defer func() { _ = httpResp.Body.Close() }()
 
-		scanner := bufio.NewScanner(httpResp.Body)
-		scanner.Buffer(nil, 52_428_800)
+		reader := newSSEReader(e.cfg, httpResp.Body)
 
 		var (
-			param     any
-			eventName string
-			state     gitLabOpenAIStreamState
+			param any
+			state gitLabOpenAIStreamState
 		)
-		for scanner.Scan() {
-			line := bytes.Clone(scanner.Bytes())
-			appendAPIResponseChunk(ctx, e.cfg, line)
-			trimmed := bytes.TrimSpace(line)
-			if len(trimmed) == 0 {
-				continue
-			}
-			if bytes.HasPrefix(trimmed, []byte("event:")) {
-				eventName = strings.TrimSpace(string(trimmed[len("event:"):]))
-				continue
-			}
-			if !bytes.HasPrefix(trimmed, []byte("data:")) {
-				continue
+		defer state.release()
+		for {
+			event, errRead := reader.Next()
+			if errRead != nil {
+				if errors.Is(errRead, io.EOF) {
+					break
+				}
+				recordAPIResponseError(ctx, e.cfg, errRead)
+				reporter.publishFailure(ctx)
+				sendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{Err: errRead})
+				return
 			}
-			payload := bytes.TrimSpace(trimmed[len("data:"):])
-			normalized := normalizeGitLabStreamChunk(eventName, payload, responseModel, &state)
-			eventName = ""
+			appendAPIResponseChunk(ctx, e.cfg, event.Raw)
+			payload := bytes.TrimSpace(event.Data)
+			normalized := normalizeGitLabStreamChunk(strings.TrimSpace(event.Name), payload, responseModel, &state)
 			for _, item := range normalized {
 				if detail, ok := parseOpenAIStreamUsage(item); ok {
 					reporter.publish(ctx, detail)
@@ -428,16 +429,10 @@ func (e *GitLabExecutor) requestCodeSuggestionsStream(
 					&param,
 				)
 				for i := range chunks {
-					out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+					sendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])})
 				}
 			}
 		}
-		if errScan := scanner.Err(); errScan != nil {
-			recordAPIResponseError(ctx, e.cfg, errScan)
-			reporter.publishFailure(ctx)
-			out <- cliproxyexecutor.StreamChunk{Err: errScan}
-			return
-		}
 		if !state.Finished {
 			for _, item := range finalizeGitLabStream(responseModel, &state) {
 				chunks := sdktranslator.TranslateStream(
@@ -451,7 +446,7 @@ func (e *GitLabExecutor) requestCodeSuggestionsStream(
 					&param,
 				)
 				for i := range chunks {
-					out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+					sendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])})
 				}
 			}
 		}
@@ -852,10 +847,10 @@ func (s *gitLabOpenAIStreamState) emitText(text string) [][]byte {
 	}
 	out := make([][]byte, 0, 2)
 	if !s.Started {
-		out = append(out, s.buildChunk(map[string]any{"role": "assistant"}, ""))
+		out = append(out, s.chunkEncoder().Delta(`{"role":"assistant"}`, ""))
 		s.Started = true
 	}
-	out = append(out, s.buildChunk(map[string]any{"content": delta}, ""))
+	out = append(out, s.chunkEncoder().Content("", delta))
 	return out
 }
 
@@ -868,7 +863,7 @@ func (s *gitLabOpenAIStreamState) finish(reason string) [][]byte {
 	}
 	s.Finished = true
 	return [][]byte{
-		s.buildChunk(map[string]any{}, reason),
+		s.chunkEncoder().Finish(reason, nil),
 		[]byte("data: [DONE]"),
 	}
 }
@@ -896,26 +891,21 @@ func (s *gitLabOpenAIStreamState) nextDelta(text string) string {
 	return text
 }
 
-func (s *gitLabOpenAIStreamState) buildChunk(delta map[string]any, finishReason string) []byte {
-	payload := map[string]any{
-		"id":      s.ID,
-		"object":  "chat.completion.chunk",
-		"created": s.Created,
-		"model":   s.Model,
-		"choices": []map[string]any{{
-			"index": 0,
-			"delta": delta,
-		}},
+// chunkEncoder returns the stream's chunk encoder, acquiring it on first use. The
+// envelope is fixed at that point, so it must only be called after ensureInitialized.
+func (s *gitLabOpenAIStreamState) chunkEncoder() *helps.OpenAIChunkEncoder {
+	if s.encoder == nil {
+		s.encoder = helps.AcquireOpenAISSEChunkEncoder(s.ID, s.Model, s.Created)
 	}
-	if finishReason != "" {
-		payload["choices"] = []map[string]any{{
-			"index":         0,
-			"delta":         delta,
-			"finish_reason": finishReason,
-		}}
+	return s.encoder
+}
+
+// release returns the chunk encoder to its pool once the stream is done.
+func (s *gitLabOpenAIStreamState) release() {
+	if s != nil && s.encoder != nil {
+		s.encoder.Release()
+		s.encoder = nil
 	}
-	raw, _ := json.Marshal(payload)
-	return append([]byte("data: "), raw...)
 }
 
 func shouldFallbackToCodeSuggestions(err error) bool {
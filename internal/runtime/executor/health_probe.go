@@ -0,0 +1,93 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+// healthProbePayload is the minimal OpenAI-format request sent by count-tokens probes.
+const healthProbePayload = `{"messages":[{"role":"user","content":"ping"}]}`
+
+type countTokensFunc func(context.Context, *cliproxyauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error)
+
+// probeHealthWithCountTokens pings a provider through its upstream count-tokens endpoint.
+// Only executors whose CountTokens calls the provider may use it.
+func probeHealthWithCountTokens(ctx context.Context, count countTokensFunc, auth *cliproxyauth.Auth, model string) error {
+	_, err := count(ctx, auth, cliproxyexecutor.Request{Model: model, Payload: []byte(healthProbePayload)}, cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("openai"),
+	})
+	return err
+}
+
+// ProbeHealth implements cliproxyauth.HealthProber with an upstream count_tokens request.
+func (e *ClaudeExecutor) ProbeHealth(ctx context.Context, auth *cliproxyauth.Auth, model string) error {
+	return probeHealthWithCountTokens(ctx, e.CountTokens, auth, model)
+}
+
+// ProbeHealth implements cliproxyauth.HealthProber with an upstream countTokens request.
+func (e *GeminiExecutor) ProbeHealth(ctx context.Context, auth *cliproxyauth.Auth, model string) error {
+	return probeHealthWithCountTokens(ctx, e.CountTokens, auth, model)
+}
+
+// ProbeHealth implements cliproxyauth.HealthProber with an upstream countTokens request.
+func (e *GeminiVertexExecutor) ProbeHealth(ctx context.Context, auth *cliproxyauth.Auth, model string) error {
+	return probeHealthWithCountTokens(ctx, e.CountTokens, auth, model)
+}
+
+// ProbeHealth implements cliproxyauth.HealthProber with an upstream countTokens request.
+func (e *AIStudioExecutor) ProbeHealth(ctx context.Context, auth *cliproxyauth.Auth, model string) error {
+	return probeHealthWithCountTokens(ctx, e.CountTokens, auth, model)
+}
+
+// ProbeHealth implements cliproxyauth.HealthProber with an upstream countTokens request.
+func (e *AntigravityExecutor) ProbeHealth(ctx context.Context, auth *cliproxyauth.Auth, model string) error {
+	return probeHealthWithCountTokens(ctx, e.CountTokens, auth, model)
+}
+
+// ProbeHealth implements cliproxyauth.HealthProber by listing the provider's models.
+// CountTokens is local for OpenAI-compatible providers, so it cannot tell whether the
+// upstream accepts the credential again.
+func (e *OpenAICompatExecutor) ProbeHealth(ctx context.Context, auth *cliproxyauth.Auth, _ string) error {
+	baseURL, apiKey := e.resolveCredentials(auth)
+	if strings.TrimSpace(baseURL) == "" {
+		return fmt.Errorf("openai compat executor: %w: missing base URL", cliproxyauth.ErrHealthProbeUnsupported)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/models", nil)
+	if err != nil {
+		return err
+	}
+	if apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	httpReq.Header.Set("User-Agent", "cli-proxy-openai-compat")
+	var attrs map[string]string
+	if auth != nil {
+		attrs = auth.Attributes
+	}
+	util.ApplyCustomHeadersFromAttrs(httpReq, attrs)
+
+	httpResp, err := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0).Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if errClose := httpResp.Body.Close(); errClose != nil {
+			log.Errorf("openai compat executor: close health probe body error: %v", errClose)
+		}
+	}()
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		b, _ := io.ReadAll(io.LimitReader(httpResp.Body, 4096))
+		return statusErr{code: httpResp.StatusCode, msg: string(b)}
+	}
+	return nil
+}
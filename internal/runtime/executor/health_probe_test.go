@@ -0,0 +1,44 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestOpenAICompatProbeHealthListsUpstreamModels(t *testing.T) {
+	var status atomic.Int32
+	status.Store(http.StatusTooManyRequests)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/v1/models" || r.Header.Get("Authorization") != "Bearer test" {
+			t.Errorf("unexpected probe %s %s (auth %q)", r.Method, r.URL.Path, r.Header.Get("Authorization"))
+		}
+		w.WriteHeader(int(status.Load()))
+		_, _ = w.Write([]byte(`{"object":"list","data":[]}`))
+	}))
+	defer server.Close()
+
+	executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "test", "base_url": server.URL + "/v1"}}
+
+	errProbe := executor.ProbeHealth(context.Background(), auth, "m")
+	var coded interface{ StatusCode() int }
+	if !errors.As(errProbe, &coded) || coded.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("ProbeHealth() error = %v, want upstream 429", errProbe)
+	}
+	status.Store(http.StatusOK)
+	if errProbe = executor.ProbeHealth(context.Background(), auth, "m"); errProbe != nil {
+		t.Fatalf("ProbeHealth() error = %v, want nil", errProbe)
+	}
+
+	errProbe = executor.ProbeHealth(context.Background(), &cliproxyauth.Auth{}, "m")
+	if !errors.Is(errProbe, cliproxyauth.ErrHealthProbeUnsupported) {
+		t.Fatalf("ProbeHealth() without base URL error = %v, want ErrHealthProbeUnsupported", errProbe)
+	}
+}
@@ -0,0 +1,29 @@
+package helps
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// ProviderBaseURL returns the upstream base URL for provider with this priority:
+// 1. auth.Attributes["base_url"] (per-auth override)
+// 2. cfg.ProviderBaseURLs[provider] (per-provider override)
+// 3. fallback (the production host)
+//
+// The result has no trailing slash.
+func ProviderBaseURL(cfg *config.Config, auth *cliproxyauth.Auth, provider, fallback string) string {
+	baseURL := fallback
+	if auth != nil && auth.Attributes != nil {
+		if configured := strings.TrimSpace(auth.Attributes["base_url"]); configured != "" {
+			return strings.TrimRight(configured, "/")
+		}
+	}
+	if cfg != nil {
+		if configured := strings.TrimSpace(cfg.ProviderBaseURLs[strings.ToLower(strings.TrimSpace(provider))]); configured != "" {
+			baseURL = configured
+		}
+	}
+	return strings.TrimRight(baseURL, "/")
+}
@@ -0,0 +1,26 @@
+package helps
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestProviderBaseURLPriority(t *testing.T) {
+	cfg := &config.Config{ProviderBaseURLs: map[string]string{"cline": "https://gateway.example.com/api/v1/"}}
+	authOverride := &cliproxyauth.Auth{Attributes: map[string]string{"base_url": "https://eu.example.com/"}}
+
+	if got := ProviderBaseURL(nil, nil, "cline", "https://api.cline.bot/api/v1"); got != "https://api.cline.bot/api/v1" {
+		t.Fatalf("fallback = %q", got)
+	}
+	if got := ProviderBaseURL(cfg, &cliproxyauth.Auth{}, "Cline", "https://api.cline.bot/api/v1"); got != "https://gateway.example.com/api/v1" {
+		t.Fatalf("provider override = %q", got)
+	}
+	if got := ProviderBaseURL(cfg, authOverride, "cline", "https://api.cline.bot/api/v1"); got != "https://eu.example.com" {
+		t.Fatalf("auth override = %q", got)
+	}
+	if got := ProviderBaseURL(cfg, nil, "kilo", "https://api.kilo.ai"); got != "https://api.kilo.ai" {
+		t.Fatalf("other provider = %q", got)
+	}
+}
@@ -0,0 +1,425 @@
+package helps
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultCassetteDir = "cassettes"
+	cassetteRedacted   = "REDACTED"
+)
+
+// cassetteExchange is one recorded upstream request and its response.
+type cassetteExchange struct {
+	Request    cassetteRequest  `json:"request"`
+	Response   cassetteResponse `json:"response"`
+	RecordedAt time.Time        `json:"recorded_at"`
+}
+
+type cassetteRequest struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+}
+
+type cassetteResponse struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+	BodyBase64 string      `json:"body_base64,omitempty"`
+	// Truncated reports that the caller closed the body before it was fully read.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// cassetteSequences numbers repeated identical requests so a replay serves recorded
+// responses in the order they were captured.
+var cassetteSequences = struct {
+	sync.Mutex
+	next map[string]int
+}{next: make(map[string]int)}
+
+func nextCassetteSequence(key string) int {
+	cassetteSequences.Lock()
+	defer cassetteSequences.Unlock()
+	seq := cassetteSequences.next[key]
+	cassetteSequences.next[key] = seq + 1
+	return seq
+}
+
+// cassetteRoundTripper records upstream exchanges to cassette files or serves them back.
+// Credentials in headers, query parameters and JSON or form bodies are replaced with
+// "REDACTED" before anything is written or matched, so cassettes can be attached to bug
+// reports and replayed with any credential.
+type cassetteRoundTripper struct {
+	base     http.RoundTripper
+	mode     string
+	dir      string
+	provider string
+}
+
+func (t *cassetteRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		data, errRead := io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if errRead != nil {
+			return nil, fmt.Errorf("cassette: read request body: %w", errRead)
+		}
+		body = data
+	}
+
+	scrubbedURL := scrubCassetteURL(req.URL)
+	scrubbedBody := scrubCassetteBody(req.Header.Get("Content-Type"), body)
+	routeKey := cassetteHash(req.Method + " " + scrubbedURL)
+	exactKey := routeKey + "-" + cassetteHash(string(scrubbedBody))
+	dir := filepath.Join(t.dir, t.provider)
+	seq := nextCassetteSequence(filepath.Join(dir, exactKey))
+
+	if t.mode == config.CassetteModeReplay {
+		exchange, errLoad := loadCassetteExchange(dir, routeKey, exactKey, seq)
+		if errLoad != nil {
+			return nil, fmt.Errorf("cassette: no recording for %s %s: %w", req.Method, scrubbedURL, errLoad)
+		}
+		return exchange.Response.httpResponse(req), nil
+	}
+
+	upstreamReq := req.Clone(req.Context())
+	if body != nil {
+		upstreamReq.Body = io.NopCloser(bytes.NewReader(body))
+		upstreamReq.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+		upstreamReq.ContentLength = int64(len(body))
+	}
+	resp, errRoundTrip := t.base.RoundTrip(upstreamReq)
+	if errRoundTrip != nil {
+		return nil, errRoundTrip
+	}
+
+	exchange := &cassetteExchange{
+		Request: cassetteRequest{
+			Method:  req.Method,
+			URL:     scrubbedURL,
+			Headers: scrubCassetteHeaders(req.Header),
+		},
+		Response: cassetteResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    scrubCassetteHeaders(resp.Header),
+		},
+	}
+	exchange.Request.Body, exchange.Request.BodyBase64 = encodeCassetteBody(scrubbedBody)
+	path := filepath.Join(dir, fmt.Sprintf("%s-%03d.json", exactKey, seq))
+	resp.Body = &cassetteRecorder{
+		ReadCloser: resp.Body,
+		save: func(responseBody []byte, truncated bool) {
+			exchange.Response.Body, exchange.Response.BodyBase64 = encodeCassetteBody(scrubCassetteBody(resp.Header.Get("Content-Type"), responseBody))
+			exchange.Response.Truncated = truncated
+			exchange.RecordedAt = time.Now().UTC()
+			if errSave := saveCassetteExchange(path, exchange); errSave != nil {
+				log.Warnf("cassette: failed to record %s %s: %v", req.Method, scrubbedURL, errSave)
+			}
+		},
+	}
+	return resp, nil
+}
+
+// cassetteRecorder passes the response body through to the caller and saves it once
+// the body is exhausted or closed, so streamed responses are not delayed.
+type cassetteRecorder struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	once sync.Once
+	save func(body []byte, truncated bool)
+}
+
+func (r *cassetteRecorder) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.buf.Write(p[:n])
+	if err == io.EOF {
+		r.once.Do(func() { r.save(r.buf.Bytes(), false) })
+	}
+	return n, err
+}
+
+func (r *cassetteRecorder) Close() error {
+	r.once.Do(func() { r.save(r.buf.Bytes(), true) })
+	return r.ReadCloser.Close()
+}
+
+func (r cassetteResponse) httpResponse(req *http.Request) *http.Response {
+	body := decodeCassetteBody(r.Body, r.BodyBase64)
+	header := r.Headers.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	header.Set("X-Cassette-Replay", "true")
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		StatusCode:    r.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// loadCassetteExchange returns the recording for the seq-th identical request. It falls
+// back to the first identical request, then to the first recording of the same route so
+// requests carrying volatile fields (timestamps, random IDs) still replay.
+func loadCassetteExchange(dir, routeKey, exactKey string, seq int) (*cassetteExchange, error) {
+	candidates := []string{filepath.Join(dir, fmt.Sprintf("%s-%03d.json", exactKey, seq))}
+	if seq > 0 {
+		candidates = append(candidates, filepath.Join(dir, exactKey+"-000.json"))
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, routeKey+"-*.json")); len(matches) > 0 {
+		sort.Strings(matches)
+		candidates = append(candidates, matches[0])
+	}
+	var lastErr error = os.ErrNotExist
+	for _, path := range candidates {
+		data, errRead := os.ReadFile(path)
+		if errRead != nil {
+			lastErr = errRead
+			continue
+		}
+		var exchange cassetteExchange
+		if errUnmarshal := json.Unmarshal(data, &exchange); errUnmarshal != nil {
+			return nil, fmt.Errorf("parse %s: %w", path, errUnmarshal)
+		}
+		return &exchange, nil
+	}
+	return nil, lastErr
+}
+
+func saveCassetteExchange(path string, exchange *cassetteExchange) error {
+	data, errMarshal := json.MarshalIndent(exchange, "", "  ")
+	if errMarshal != nil {
+		return errMarshal
+	}
+	if errMkdir := os.MkdirAll(filepath.Dir(path), 0o700); errMkdir != nil {
+		return errMkdir
+	}
+	tmp := path + ".tmp"
+	if errWrite := os.WriteFile(tmp, data, 0o600); errWrite != nil {
+		return errWrite
+	}
+	return os.Rename(tmp, path)
+}
+
+func cassetteHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:8])
+}
+
+func encodeCassetteBody(body []byte) (string, string) {
+	if len(body) == 0 {
+		return "", ""
+	}
+	if utf8.Valid(body) {
+		return string(body), ""
+	}
+	return "", base64.StdEncoding.EncodeToString(body)
+}
+
+func decodeCassetteBody(text, encoded string) []byte {
+	if encoded != "" {
+		if data, errDecode := base64.StdEncoding.DecodeString(encoded); errDecode == nil {
+			return data
+		}
+	}
+	return []byte(text)
+}
+
+// isCassetteSensitiveName reports whether a header, query parameter or body field name
+// carries a credential. Names are split into words, so "x-api-key", "accessToken" and
+// "client_secret" match while "max_tokens" does not.
+func isCassetteSensitiveName(name string) bool {
+	words := strings.FieldsFunc(splitCamelCase(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		switch strings.ToLower(word) {
+		case "authorization", "cookie", "token", "secret", "password", "key", "apikey", "credential", "credentials":
+			return true
+		}
+	}
+	return false
+}
+
+func splitCamelCase(name string) string {
+	var b strings.Builder
+	var prev rune
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(prev) {
+			b.WriteByte('_')
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return b.String()
+}
+
+func scrubCassetteHeaders(header http.Header) http.Header {
+	out := header.Clone()
+	for name, values := range out {
+		if !isCassetteSensitiveName(name) {
+			continue
+		}
+		for i := range values {
+			values[i] = cassetteRedacted
+		}
+	}
+	return out
+}
+
+func scrubCassetteURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	clone := *u
+	clone.User = nil
+	if clone.RawQuery != "" {
+		query := clone.Query()
+		for name, values := range query {
+			if !isCassetteSensitiveName(name) {
+				continue
+			}
+			for i := range values {
+				values[i] = cassetteRedacted
+			}
+		}
+		clone.RawQuery = query.Encode()
+	}
+	return clone.String()
+}
+
+// scrubCassetteBody redacts credentials in JSON and form bodies. Other bodies, including
+// event streams, are returned unchanged.
+func scrubCassetteBody(contentType string, body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-www-form-urlencoded" {
+		form, errParse := url.ParseQuery(string(body))
+		if errParse != nil {
+			return body
+		}
+		changed := false
+		for name, values := range form {
+			if !isCassetteSensitiveName(name) {
+				continue
+			}
+			for i := range values {
+				values[i] = cassetteRedacted
+			}
+			changed = true
+		}
+		if !changed {
+			return body
+		}
+		return []byte(form.Encode())
+	}
+	if !json.Valid(body) {
+		return body
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var payload any
+	if errDecode := decoder.Decode(&payload); errDecode != nil {
+		return body
+	}
+	if !redactCassetteJSON(payload) {
+		return body
+	}
+	scrubbed, errMarshal := json.Marshal(payload)
+	if errMarshal != nil {
+		return body
+	}
+	return scrubbed
+}
+
+func redactCassetteJSON(value any) bool {
+	changed := false
+	switch v := value.(type) {
+	case map[string]any:
+		for name, child := range v {
+			if _, isString := child.(string); isString && isCassetteSensitiveName(name) {
+				v[name] = cassetteRedacted
+				changed = true
+				continue
+			}
+			if redactCassetteJSON(child) {
+				changed = true
+			}
+		}
+	case []any:
+		for _, child := range v {
+			if redactCassetteJSON(child) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// cassetteModeFor returns the cassette mode that applies to provider, or "".
+func cassetteModeFor(cfg *config.Config, provider string) string {
+	if cfg == nil {
+		return ""
+	}
+	mode := strings.ToLower(strings.TrimSpace(cfg.Cassette.Mode))
+	if mode != config.CassetteModeRecord && mode != config.CassetteModeReplay {
+		return ""
+	}
+	if !providerListed(cfg.Cassette.Providers, provider) {
+		return ""
+	}
+	return mode
+}
+
+// withCassette returns client unchanged when record/replay is disabled for provider,
+// otherwise a copy whose transport records to or replays from the cassette directory.
+func withCassette(cfg *config.Config, provider string, client *http.Client) *http.Client {
+	mode := cassetteModeFor(cfg, provider)
+	if client == nil || mode == "" {
+		return client
+	}
+	dir := strings.TrimSpace(cfg.Cassette.Dir)
+	if dir == "" {
+		dir = defaultCassetteDir
+	}
+	if provider == "" {
+		provider = "unknown"
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := *client
+	wrapped.Transport = &cassetteRoundTripper{base: base, mode: mode, dir: dir, provider: provider}
+	return &wrapped
+}
@@ -0,0 +1,116 @@
+package helps
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestCassetteRecordsScrubbedExchangesAndReplaysThem(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"answer":"call-` + string(rune('0'+calls)) + `","access_token":"upstream-secret"}`))
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	cfg := &config.Config{}
+	cfg.Cassette.Dir = dir
+	auth := &cliproxyauth.Auth{Provider: "claude"}
+	do := func(mode string) string {
+		t.Helper()
+		cfg.Cassette.Mode = mode
+		client := NewProxyAwareHTTPClient(context.Background(), cfg, auth, 0)
+		req, errReq := http.NewRequest(http.MethodPost, server.URL+"/v1/messages?key=sk-live", strings.NewReader(`{"model":"m","refresh_token":"rt-live"}`))
+		if errReq != nil {
+			t.Fatalf("new request: %v", errReq)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer sk-live")
+		resp, errDo := client.Do(req)
+		if errDo != nil {
+			t.Fatalf("do (%s): %v", mode, errDo)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	if first := do(config.CassetteModeRecord); !strings.Contains(first, "upstream-secret") {
+		t.Fatalf("recorded response = %s, want the caller to receive it unscrubbed", first)
+	}
+	do(config.CassetteModeRecord)
+	if calls != 2 {
+		t.Fatalf("upstream calls = %d, want 2", calls)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "claude", "*.json"))
+	if len(files) != 2 {
+		t.Fatalf("cassette files = %v, want 2", files)
+	}
+	for _, file := range files {
+		data, errRead := os.ReadFile(file)
+		if errRead != nil {
+			t.Fatalf("read cassette: %v", errRead)
+		}
+		for _, secret := range []string{"sk-live", "rt-live", "upstream-secret"} {
+			if strings.Contains(string(data), secret) {
+				t.Fatalf("cassette %s leaks %q:\n%s", file, secret, data)
+			}
+		}
+	}
+
+	server.Close()
+	cassetteSequences.Lock()
+	clear(cassetteSequences.next)
+	cassetteSequences.Unlock()
+
+	if got := do(config.CassetteModeReplay); !strings.Contains(got, `"answer":"call-1"`) {
+		t.Fatalf("first replay = %s, want the first recording", got)
+	}
+	if got := do(config.CassetteModeReplay); !strings.Contains(got, `"answer":"call-2"`) {
+		t.Fatalf("second replay = %s, want the second recording", got)
+	}
+}
+
+func TestCassetteReplayWithoutRecordingFails(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Cassette.Mode = config.CassetteModeReplay
+	cfg.Cassette.Dir = t.TempDir()
+	client := NewProxyAwareHTTPClient(context.Background(), cfg, &cliproxyauth.Auth{Provider: "gemini"}, 0)
+	resp, errDo := client.Get("http://127.0.0.1:1/v1beta/models")
+	if errDo == nil {
+		_ = resp.Body.Close()
+		t.Fatal("replay without a recording succeeded")
+	}
+	if !strings.Contains(errDo.Error(), "cassette: no recording") {
+		t.Fatalf("error = %v, want missing recording", errDo)
+	}
+}
+
+func TestIsCassetteSensitiveName(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Authorization":                        true,
+		"x-goog-api-key":                       true,
+		"accessToken":                          true,
+		"client_secret":                        true,
+		"Set-Cookie":                           true,
+		"max_tokens":                           false,
+		"anthropic-ratelimit-tokens-remaining": false,
+		"model":                                false,
+	} {
+		if got := isCassetteSensitiveName(name); got != want {
+			t.Errorf("isCassetteSensitiveName(%q) = %t, want %t", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package helps
+
+import (
+	"strconv"
+	"sync"
+	"unicode/utf8"
+)
+
+// maxPooledChunkBuffer bounds the scratch buffer kept by a released encoder so one huge
+// delta does not pin memory in the pool.
+const maxPooledChunkBuffer = 64 << 10
+
+const chunkHexDigits = "0123456789abcdef"
+
+// OpenAIChunkUsage is the usage block attached to a final OpenAI stream chunk.
+type OpenAIChunkUsage struct {
+	PromptTokens     int64
+	CompletionTokens int64
+}
+
+// OpenAIChunkEncoder renders OpenAI chat.completion.chunk payloads for one stream. The
+// envelope (id, object, created, model) is rendered once into a template and every chunk
+// is appended to a reused scratch buffer, so a delta costs a single exactly-sized
+// allocation instead of building and marshalling a map.
+//
+// Encoders are pooled: obtain one with AcquireOpenAIChunkEncoder or
+// AcquireOpenAISSEChunkEncoder and call Release when the stream ends. Returned payloads
+// are owned by the caller and stay valid after Release. An encoder is not safe for
+// concurrent use.
+type OpenAIChunkEncoder struct {
+	head []byte
+	buf  []byte
+}
+
+var openAIChunkEncoderPool = sync.Pool{New: func() any { return &OpenAIChunkEncoder{} }}
+
+// AcquireOpenAIChunkEncoder returns an encoder producing bare JSON payloads.
+func AcquireOpenAIChunkEncoder(id, model string, created int64) *OpenAIChunkEncoder {
+	return acquireOpenAIChunkEncoder("", id, model, created)
+}
+
+// AcquireOpenAISSEChunkEncoder returns an encoder producing "data: {...}" SSE lines, the
+// form expected by the stream translators.
+func AcquireOpenAISSEChunkEncoder(id, model string, created int64) *OpenAIChunkEncoder {
+	return acquireOpenAIChunkEncoder("data: ", id, model, created)
+}
+
+func acquireOpenAIChunkEncoder(prefix, id, model string, created int64) *OpenAIChunkEncoder {
+	e := openAIChunkEncoderPool.Get().(*OpenAIChunkEncoder)
+	head := append(e.head[:0], prefix...)
+	head = append(head, `{"id":`...)
+	head = AppendJSONString(head, id)
+	head = append(head, `,"object":"chat.completion.chunk","created":`...)
+	head = strconv.AppendInt(head, created, 10)
+	head = append(head, `,"model":`...)
+	head = AppendJSONString(head, model)
+	head = append(head, `,"choices":[{"index":0,"delta":`...)
+	e.head = head
+	return e
+}
+
+// Release returns the encoder to the pool. The encoder must not be used afterwards.
+func (e *OpenAIChunkEncoder) Release() {
+	if e == nil {
+		return
+	}
+	if cap(e.buf) > maxPooledChunkBuffer {
+		e.buf = nil
+	}
+	openAIChunkEncoderPool.Put(e)
+}
+
+// Delta renders a chunk around a delta object that is already JSON encoded. An empty
+// finishReason renders as null.
+func (e *OpenAIChunkEncoder) Delta(delta string, finishReason string) []byte {
+	buf := append(e.buf[:0], e.head...)
+	buf = append(buf, delta...)
+	return e.finish(buf, finishReason, nil)
+}
+
+// Content renders a text delta. role is omitted when empty.
+func (e *OpenAIChunkEncoder) Content(role, text string) []byte {
+	buf := append(e.buf[:0], e.head...)
+	buf = append(buf, '{')
+	if role != "" {
+		buf = append(buf, `"role":`...)
+		buf = AppendJSONString(buf, role)
+		buf = append(buf, ',')
+	}
+	buf = append(buf, `"content":`...)
+	buf = AppendJSONString(buf, text)
+	buf = append(buf, '}')
+	return e.finish(buf, "", nil)
+}
+
+// ToolCall renders a delta carrying one complete function call. arguments is the JSON
+// argument document, sent as a string the way OpenAI does.
+func (e *OpenAIChunkEncoder) ToolCall(index int, id, name, arguments string) []byte {
+	buf := append(e.buf[:0], e.head...)
+	buf = append(buf, `{"tool_calls":[{"index":`...)
+	buf = strconv.AppendInt(buf, int64(index), 10)
+	buf = append(buf, `,"id":`...)
+	buf = AppendJSONString(buf, id)
+	buf = append(buf, `,"type":"function","function":{"name":`...)
+	buf = AppendJSONString(buf, name)
+	buf = append(buf, `,"arguments":`...)
+	buf = AppendJSONString(buf, arguments)
+	buf = append(buf, `}}]}`...)
+	return e.finish(buf, "", nil)
+}
+
+// Finish renders the closing chunk with an empty delta, the finish reason and optional usage.
+func (e *OpenAIChunkEncoder) Finish(finishReason string, usage *OpenAIChunkUsage) []byte {
+	buf := append(e.buf[:0], e.head...)
+	buf = append(buf, `{}`...)
+	return e.finish(buf, finishReason, usage)
+}
+
+func (e *OpenAIChunkEncoder) finish(buf []byte, finishReason string, usage *OpenAIChunkUsage) []byte {
+	buf = append(buf, `,"finish_reason":`...)
+	if finishReason == "" {
+		buf = append(buf, "null"...)
+	} else {
+		buf = AppendJSONString(buf, finishReason)
+	}
+	buf = append(buf, "}]"...)
+	if usage != nil {
+		buf = append(buf, `,"usage":{"prompt_tokens":`...)
+		buf = strconv.AppendInt(buf, usage.PromptTokens, 10)
+		buf = append(buf, `,"completion_tokens":`...)
+		buf = strconv.AppendInt(buf, usage.CompletionTokens, 10)
+		buf = append(buf, `,"total_tokens":`...)
+		buf = strconv.AppendInt(buf, usage.PromptTokens+usage.CompletionTokens, 10)
+		buf = append(buf, '}')
+	}
+	buf = append(buf, '}')
+	e.buf = buf
+	return append(make([]byte, 0, len(buf)), buf...)
+}
+
+// AppendJSONString appends s as a JSON string literal, escaping exactly like
+// encoding/json (including HTML characters and invalid UTF-8).
+func AppendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' {
+				i++
+				continue
+			}
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '"', '\\':
+				dst = append(dst, '\\', b)
+			case '\b':
+				dst = append(dst, '\\', 'b')
+			case '\f':
+				dst = append(dst, '\\', 'f')
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', chunkHexDigits[b>>4], chunkHexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = utf8.AppendRune(dst, utf8.RuneError)
+			i += size
+			start = i
+			continue
+		}
+		if r == '\u2028' || r == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', chunkHexDigits[r&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
@@ -0,0 +1,103 @@
+package helps
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestAppendJSONStringMatchesEncodingJSON(t *testing.T) {
+	inputs := []string{
+		"",
+		"plain text",
+		`quote " and backslash \`,
+		"line\nbreak\r\ttab\b\f",
+		"control \x00\x01\x1f",
+		"<html> & entities",
+		"unicode 你好 🚀",
+		"separators \u2028 \u2029",
+		"invalid \xff\xfe utf-8",
+	}
+	for _, input := range inputs {
+		want, _ := json.Marshal(input)
+		if got := AppendJSONString(nil, input); string(got) != string(want) {
+			t.Errorf("AppendJSONString(%q) = %s, want %s", input, got, want)
+		}
+	}
+}
+
+func TestOpenAIChunkEncoderRendersChunks(t *testing.T) {
+	enc := AcquireOpenAISSEChunkEncoder(`chat"1`, "gpt-test", 1700000000)
+	defer enc.Release()
+
+	content := enc.Content("assistant", "hi <there>")
+	if string(content[:6]) != "data: " {
+		t.Fatalf("content chunk = %q, want data: prefix", content)
+	}
+	root := gjson.ParseBytes(content[6:])
+	if root.Get("id").String() != `chat"1` || root.Get("object").String() != "chat.completion.chunk" || root.Get("created").Int() != 1700000000 {
+		t.Fatalf("envelope = %s", content)
+	}
+	if root.Get("choices.0.delta.role").String() != "assistant" || root.Get("choices.0.delta.content").String() != "hi <there>" {
+		t.Fatalf("delta = %s", content)
+	}
+	if finish := root.Get("choices.0.finish_reason"); finish.Type != gjson.Null {
+		t.Fatalf("finish_reason = %s, want null", finish.Raw)
+	}
+
+	toolCall := gjson.ParseBytes(enc.ToolCall(2, "call_1", "lookup", `{"q":"x"}`)[6:])
+	if toolCall.Get("choices.0.delta.tool_calls.0.index").Int() != 2 || toolCall.Get("choices.0.delta.tool_calls.0.function.arguments").String() != `{"q":"x"}` {
+		t.Fatalf("tool call chunk = %s", toolCall.Raw)
+	}
+
+	finish := gjson.ParseBytes(enc.Finish("stop", &OpenAIChunkUsage{PromptTokens: 3, CompletionTokens: 4})[6:])
+	if finish.Get("choices.0.finish_reason").String() != "stop" || finish.Get("usage.total_tokens").Int() != 7 {
+		t.Fatalf("finish chunk = %s", finish.Raw)
+	}
+
+	raw := AcquireOpenAIChunkEncoder("id", "model", 1)
+	defer raw.Release()
+	if delta := raw.Delta(`{"content":"x"}`, "length"); !json.Valid(delta) {
+		t.Fatalf("raw delta chunk is not valid JSON: %s", delta)
+	}
+}
+
+func TestOpenAIChunkEncoderPayloadsSurviveReuse(t *testing.T) {
+	enc := AcquireOpenAIChunkEncoder("id", "model", 1)
+	first := enc.Content("", "first")
+	second := enc.Content("", "second")
+	enc.Release()
+	if gjson.GetBytes(first, "choices.0.delta.content").String() != "first" || gjson.GetBytes(second, "choices.0.delta.content").String() != "second" {
+		t.Fatalf("payloads were overwritten: %s / %s", first, second)
+	}
+}
+
+func BenchmarkOpenAIStreamChunk(b *testing.B) {
+	b.Run("map-marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			chunk := map[string]any{
+				"id":      "chatcmpl-bench",
+				"object":  "chat.completion.chunk",
+				"created": int64(1700000000),
+				"model":   "bench-model",
+				"choices": []map[string]any{{
+					"index":         0,
+					"delta":         map[string]any{"content": "hello world"},
+					"finish_reason": nil,
+				}},
+			}
+			raw, _ := json.Marshal(chunk)
+			_ = append([]byte("data: "), raw...)
+		}
+	})
+	b.Run("encoder", func(b *testing.B) {
+		enc := AcquireOpenAISSEChunkEncoder("chatcmpl-bench", "bench-model", 1700000000)
+		defer enc.Release()
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = enc.Content("", "hello world")
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package helps
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// DeviceIDMetadataKey stores the device ID an auth presents to its provider.
+const DeviceIDMetadataKey = "device_id"
+
+// AuthDeviceID returns the device ID of auth: the one stored in its metadata, or one derived
+// from the provider and auth ID so every request of a credential looks like the same device,
+// also across restarts and token refreshes. It returns "" for a nil auth.
+func AuthDeviceID(auth *cliproxyauth.Auth) string {
+	if auth == nil {
+		return ""
+	}
+	if auth.Metadata != nil {
+		if stored, ok := auth.Metadata[DeviceIDMetadataKey].(string); ok && strings.TrimSpace(stored) != "" {
+			return strings.TrimSpace(stored)
+		}
+	}
+	sum := sha256.Sum256([]byte("cli-proxy-api:device:" + auth.Provider + ":" + auth.ID))
+	return hex.EncodeToString(sum[:])
+}
+
+// EnsureAuthDeviceID stores the device ID of auth in its metadata so it is persisted with
+// the credential. Call it only on an auth the caller owns, such as the result of a refresh.
+func EnsureAuthDeviceID(auth *cliproxyauth.Auth) string {
+	deviceID := AuthDeviceID(auth)
+	if deviceID == "" {
+		return ""
+	}
+	if auth.Metadata == nil {
+		auth.Metadata = make(map[string]any)
+	}
+	auth.Metadata[DeviceIDMetadataKey] = deviceID
+	return deviceID
+}
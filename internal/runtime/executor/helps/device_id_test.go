@@ -0,0 +1,31 @@
+package helps
+
+import (
+	"testing"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestAuthDeviceIDIsStablePerAuth(t *testing.T) {
+	first := &cliproxyauth.Auth{ID: "claude-a.json", Provider: "claude"}
+	second := &cliproxyauth.Auth{ID: "claude-b.json", Provider: "claude"}
+
+	deviceID := AuthDeviceID(first)
+	if len(deviceID) != 64 {
+		t.Fatalf("AuthDeviceID() = %q, want 64 hex characters", deviceID)
+	}
+	if again := AuthDeviceID(&cliproxyauth.Auth{ID: "claude-a.json", Provider: "claude"}); again != deviceID {
+		t.Fatalf("AuthDeviceID() = %q, then %q; want the same device for the same auth", deviceID, again)
+	}
+	if other := AuthDeviceID(second); other == deviceID {
+		t.Fatal("different auths share a device ID")
+	}
+
+	if stored := EnsureAuthDeviceID(first); stored != deviceID || first.Metadata[DeviceIDMetadataKey] != deviceID {
+		t.Fatalf("EnsureAuthDeviceID() = %q, metadata %v; want %q persisted", stored, first.Metadata, deviceID)
+	}
+	second.Metadata = map[string]any{DeviceIDMetadataKey: "existing-device"}
+	if got := AuthDeviceID(second); got != "existing-device" {
+		t.Fatalf("AuthDeviceID() = %q, want the stored device ID", got)
+	}
+}
@@ -0,0 +1,141 @@
+package helps
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// EmbeddingInputs splits an OpenAI embeddings "input" into individual items. A string
+// is one input; an array of strings or token arrays yields one input per element; a flat
+// array of integers is a single pre-tokenized input.
+func EmbeddingInputs(payload []byte) []gjson.Result {
+	input := gjson.GetBytes(payload, "input")
+	if !input.IsArray() {
+		if input.Exists() {
+			return []gjson.Result{input}
+		}
+		return nil
+	}
+	items := input.Array()
+	if len(items) > 0 && items[0].Type == gjson.Number {
+		return []gjson.Result{input}
+	}
+	return items
+}
+
+// EmbeddingBatches splits inputs into consecutive batches of at most size items.
+func EmbeddingBatches(inputs []gjson.Result, size int) [][]gjson.Result {
+	if size <= 0 || len(inputs) <= size {
+		return [][]gjson.Result{inputs}
+	}
+	batches := make([][]gjson.Result, 0, (len(inputs)+size-1)/size)
+	for start := 0; start < len(inputs); start += size {
+		batches = append(batches, inputs[start:min(start+size, len(inputs))])
+	}
+	return batches
+}
+
+// EmbeddingInputsJSON renders inputs as a JSON array for an upstream request.
+func EmbeddingInputsJSON(inputs []gjson.Result) []byte {
+	out := make([]byte, 0, 64)
+	out = append(out, '[')
+	for i, item := range inputs {
+		if i > 0 {
+			out = append(out, ',')
+		}
+		out = append(out, item.Raw...)
+	}
+	return append(out, ']')
+}
+
+// DecodeEmbedding reads an embedding returned either as a float array or as base64
+// little-endian float32 values.
+func DecodeEmbedding(value gjson.Result) ([]float64, error) {
+	if value.IsArray() {
+		items := value.Array()
+		vector := make([]float64, len(items))
+		for i, item := range items {
+			vector[i] = item.Float()
+		}
+		return vector, nil
+	}
+	if value.Type != gjson.String {
+		return nil, fmt.Errorf("embedding: unexpected value type %s", value.Type)
+	}
+	raw, errDecode := base64.StdEncoding.DecodeString(value.String())
+	if errDecode != nil {
+		return nil, fmt.Errorf("embedding: decode base64: %w", errDecode)
+	}
+	if len(raw)%4 != 0 {
+		return nil, fmt.Errorf("embedding: base64 payload is not a float32 array")
+	}
+	vector := make([]float64, len(raw)/4)
+	for i := range vector {
+		vector[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(raw[i*4:])))
+	}
+	return vector, nil
+}
+
+// NormalizeEmbeddingDimensions truncates vector to dimensions and rescales it to unit
+// length, matching how providers shorten Matryoshka-style embeddings. Rescaling is
+// idempotent, so vectors the upstream already shortened are safe to pass through.
+// dimensions <= 0 returns vector unchanged.
+func NormalizeEmbeddingDimensions(vector []float64, dimensions int) []float64 {
+	if dimensions <= 0 {
+		return vector
+	}
+	if len(vector) > dimensions {
+		vector = vector[:dimensions]
+	}
+	var sum float64
+	for _, v := range vector {
+		sum += v * v
+	}
+	if sum == 0 {
+		return vector
+	}
+	norm := math.Sqrt(sum)
+	out := make([]float64, len(vector))
+	for i, v := range vector {
+		out[i] = v / norm
+	}
+	return out
+}
+
+func encodeEmbeddingBase64(vector []float64) string {
+	raw := make([]byte, len(vector)*4)
+	for i, v := range vector {
+		binary.LittleEndian.PutUint32(raw[i*4:], math.Float32bits(float32(v)))
+	}
+	return base64.StdEncoding.EncodeToString(raw)
+}
+
+// BuildOpenAIEmbeddingsResponse renders vectors as an OpenAI embeddings list response.
+// encodingFormat "base64" encodes each vector as little-endian float32 values.
+func BuildOpenAIEmbeddingsResponse(model string, vectors [][]float64, promptTokens int64, encodingFormat string) []byte {
+	out := []byte(`{"object":"list","data":[],"model":"","usage":{"prompt_tokens":0,"total_tokens":0}}`)
+	out, _ = sjson.SetBytes(out, "model", model)
+	data := []byte{'['}
+	for i, vector := range vectors {
+		item := []byte(`{"object":"embedding","index":0}`)
+		item, _ = sjson.SetBytes(item, "index", i)
+		if encodingFormat == "base64" {
+			item, _ = sjson.SetBytes(item, "embedding", encodeEmbeddingBase64(vector))
+		} else {
+			item, _ = sjson.SetBytes(item, "embedding", vector)
+		}
+		if i > 0 {
+			data = append(data, ',')
+		}
+		data = append(data, item...)
+	}
+	out, _ = sjson.SetRawBytes(out, "data", append(data, ']'))
+	out, _ = sjson.SetBytes(out, "usage.prompt_tokens", promptTokens)
+	out, _ = sjson.SetBytes(out, "usage.total_tokens", promptTokens)
+	return out
+}
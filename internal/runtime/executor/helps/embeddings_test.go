@@ -0,0 +1,62 @@
+package helps
+
+import (
+	"math"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestEmbeddingInputs(t *testing.T) {
+	cases := []struct {
+		payload string
+		want    int
+	}{
+		{`{"input":"hello"}`, 1},
+		{`{"input":["a","b","c"]}`, 3},
+		{`{"input":[1,2,3]}`, 1},
+		{`{"input":[[1,2],[3]]}`, 2},
+		{`{}`, 0},
+	}
+	for _, tc := range cases {
+		if got := len(EmbeddingInputs([]byte(tc.payload))); got != tc.want {
+			t.Fatalf("EmbeddingInputs(%s) = %d items, want %d", tc.payload, got, tc.want)
+		}
+	}
+}
+
+func TestEmbeddingBatches(t *testing.T) {
+	inputs := EmbeddingInputs([]byte(`{"input":["a","b","c","d","e"]}`))
+	batches := EmbeddingBatches(inputs, 2)
+	if len(batches) != 3 || len(batches[2]) != 1 || batches[2][0].String() != "e" {
+		t.Fatalf("unexpected batches: %v", batches)
+	}
+	if got := string(EmbeddingInputsJSON(batches[0])); got != `["a","b"]` {
+		t.Fatalf("EmbeddingInputsJSON = %s", got)
+	}
+}
+
+func TestNormalizeEmbeddingDimensions(t *testing.T) {
+	got := NormalizeEmbeddingDimensions([]float64{3, 4, 12}, 2)
+	if len(got) != 2 || math.Abs(got[0]-0.6) > 1e-9 || math.Abs(got[1]-0.8) > 1e-9 {
+		t.Fatalf("NormalizeEmbeddingDimensions = %v, want [0.6 0.8]", got)
+	}
+	full := []float64{1, 2}
+	if got := NormalizeEmbeddingDimensions(full, 0); &got[0] != &full[0] {
+		t.Fatal("dimensions 0 should return the vector unchanged")
+	}
+}
+
+func TestBuildOpenAIEmbeddingsResponseBase64RoundTrip(t *testing.T) {
+	out := BuildOpenAIEmbeddingsResponse("m", [][]float64{{0.5, -0.25}}, 7, "base64")
+	if gjson.GetBytes(out, "object").String() != "list" || gjson.GetBytes(out, "usage.total_tokens").Int() != 7 {
+		t.Fatalf("unexpected response: %s", out)
+	}
+	vector, err := DecodeEmbedding(gjson.GetBytes(out, "data.0.embedding"))
+	if err != nil {
+		t.Fatalf("DecodeEmbedding error: %v", err)
+	}
+	if len(vector) != 2 || vector[0] != 0.5 || vector[1] != -0.25 {
+		t.Fatalf("decoded vector = %v", vector)
+	}
+}
@@ -0,0 +1,84 @@
+package helps
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// HeaderProfileKey names the header profile a credential uses, as an attribute or metadata field.
+const HeaderProfileKey = "header_profile"
+
+// headerProfileRoundTripper sets the headers of a header profile on upstream requests and
+// then re-applies the credential's own custom headers so they keep precedence.
+type headerProfileRoundTripper struct {
+	base    http.RoundTripper
+	headers map[string]string
+	attrs   map[string]string
+}
+
+func (t *headerProfileRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	clone := req.Clone(req.Context())
+	for name, value := range t.headers {
+		if http.CanonicalHeaderKey(name) == "Host" {
+			clone.Host = value
+		}
+		clone.Header.Set(name, value)
+	}
+	util.ApplyCustomHeadersFromAttrs(clone, t.attrs)
+	return t.base.RoundTrip(clone)
+}
+
+// headerProfileFor returns the headers of the profile selected by auth: its named profile
+// when it has one, else the default profile of its provider.
+func headerProfileFor(cfg *config.Config, auth *cliproxyauth.Auth) map[string]string {
+	if cfg == nil || auth == nil || len(cfg.HeaderProfiles) == 0 {
+		return nil
+	}
+	name := strings.TrimSpace(auth.Attributes[HeaderProfileKey])
+	if name == "" && auth.Metadata != nil {
+		if raw, ok := auth.Metadata[HeaderProfileKey].(string); ok {
+			name = strings.TrimSpace(raw)
+		}
+	}
+	var fallback map[string]string
+	for i := range cfg.HeaderProfiles {
+		profile := &cfg.HeaderProfiles[i]
+		if !strings.EqualFold(strings.TrimSpace(profile.Provider), auth.Provider) {
+			continue
+		}
+		profileName := strings.TrimSpace(profile.Name)
+		if name != "" && profileName == name {
+			return profile.Headers
+		}
+		if profileName == "" && fallback == nil {
+			fallback = profile.Headers
+		}
+	}
+	return fallback
+}
+
+// withHeaderProfile returns client unchanged when no header profile applies to auth,
+// otherwise a copy whose transport sets the profile headers.
+func withHeaderProfile(cfg *config.Config, auth *cliproxyauth.Auth, client *http.Client) *http.Client {
+	headers := headerProfileFor(cfg, auth)
+	if client == nil || len(headers) == 0 {
+		return client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := *client
+	wrapped.Transport = &headerProfileRoundTripper{base: base, headers: headers, attrs: auth.Attributes}
+	return &wrapped
+}
+
+// wrapAuthUpstreamClient applies the transport decorations of an upstream client built
+// for auth: the header profile of the credential and the per-provider decorations.
+func wrapAuthUpstreamClient(cfg *config.Config, auth *cliproxyauth.Auth, provider string, client *http.Client) *http.Client {
+	return withHeaderProfile(cfg, auth, WrapUpstreamClient(cfg, provider, client))
+}
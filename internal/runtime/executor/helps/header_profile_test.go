@@ -0,0 +1,57 @@
+package helps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestNewProxyAwareHTTPClientAppliesHeaderProfile(t *testing.T) {
+	var got []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Clone())
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{HeaderProfiles: []config.HeaderProfile{
+		{Provider: "codebuddy", Headers: map[string]string{"X-IDE-Version": "9.9.9", "User-Agent": "profile-agent"}},
+		{Name: "beta", Provider: "codebuddy", Headers: map[string]string{"X-IDE-Version": "10.0.0-beta"}},
+	}}
+
+	do := func(auth *cliproxyauth.Auth) {
+		t.Helper()
+		client := NewProxyAwareHTTPClient(context.Background(), cfg, auth, 0)
+		req, errReq := http.NewRequest(http.MethodGet, server.URL, nil)
+		if errReq != nil {
+			t.Fatalf("new request: %v", errReq)
+		}
+		req.Header.Set("X-IDE-Version", "2.63.2")
+		resp, errDo := client.Do(req)
+		if errDo != nil {
+			t.Fatalf("do: %v", errDo)
+		}
+		_ = resp.Body.Close()
+	}
+
+	do(&cliproxyauth.Auth{Provider: "codebuddy"})
+	do(&cliproxyauth.Auth{Provider: "codebuddy", Attributes: map[string]string{HeaderProfileKey: "beta"}})
+	do(&cliproxyauth.Auth{Provider: "codebuddy", Attributes: map[string]string{"header:X-IDE-Version": "pinned"}})
+	do(&cliproxyauth.Auth{Provider: "kiro"})
+
+	want := []string{"9.9.9", "10.0.0-beta", "pinned", "2.63.2"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d requests, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if version := got[i].Get("X-IDE-Version"); version != want[i] {
+			t.Fatalf("request %d X-IDE-Version = %q, want %q", i, version, want[i])
+		}
+	}
+	if agent := got[0].Get("User-Agent"); agent != "profile-agent" {
+		t.Fatalf("User-Agent = %q, want the profile value", agent)
+	}
+}
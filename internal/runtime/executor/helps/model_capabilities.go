@@ -0,0 +1,55 @@
+package helps
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/tidwall/gjson"
+)
+
+// OpenRouterModelCapabilities derives capabilities from the supported_parameters and
+// architecture.input_modalities lists of an OpenRouter-style model entry, the shape used by
+// the Kilo and Cline model lists. A nil list means the field was absent, so nothing is ruled
+// out on its account; it returns nil when both are absent.
+func OpenRouterModelCapabilities(supportedParameters, inputModalities []string) *registry.ModelCapabilities {
+	if supportedParameters == nil && inputModalities == nil {
+		return nil
+	}
+	caps := &registry.ModelCapabilities{
+		SupportsTools:      supportedParameters == nil,
+		SupportsJSONSchema: supportedParameters == nil,
+		SupportsVision:     inputModalities == nil,
+		SupportsStreaming:  true,
+	}
+	for _, param := range supportedParameters {
+		switch strings.ToLower(strings.TrimSpace(param)) {
+		case "tools", "tool_choice":
+			caps.SupportsTools = true
+		case "structured_outputs", "response_format":
+			caps.SupportsJSONSchema = true
+		}
+	}
+	for _, modality := range inputModalities {
+		if strings.EqualFold(strings.TrimSpace(modality), "image") {
+			caps.SupportsVision = true
+		}
+	}
+	return caps
+}
+
+// OpenRouterEntryCapabilities is OpenRouterModelCapabilities for an entry parsed with gjson.
+func OpenRouterEntryCapabilities(entry gjson.Result) *registry.ModelCapabilities {
+	return OpenRouterModelCapabilities(gjsonStrings(entry.Get("supported_parameters")), gjsonStrings(entry.Get("architecture.input_modalities")))
+}
+
+func gjsonStrings(value gjson.Result) []string {
+	if !value.IsArray() {
+		return nil
+	}
+	items := value.Array()
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		out = append(out, item.String())
+	}
+	return out
+}
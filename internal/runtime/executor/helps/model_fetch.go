@@ -0,0 +1,92 @@
+package helps
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"golang.org/x/sync/singleflight"
+)
+
+// ModelFetchCoalesceInterval is how long a successful model list fetch is reused by later
+// callers with the same key.
+const ModelFetchCoalesceInterval = time.Minute
+
+// modelFetchTimeout bounds a coalesced fetch, which no longer follows a single caller's
+// cancellation.
+const modelFetchTimeout = 30 * time.Second
+
+var modelFetches = newModelFetchCoalescer()
+
+type modelFetchResult struct {
+	models    []*registry.ModelInfo
+	fetchedAt time.Time
+}
+
+// modelFetchCoalescer shares model list fetches between auths: concurrent callers with the
+// same key wait for one upstream call, and its result is reused for an interval.
+type modelFetchCoalescer struct {
+	group   singleflight.Group
+	mu      sync.Mutex
+	results map[string]modelFetchResult
+	now     func() time.Time
+}
+
+func newModelFetchCoalescer() *modelFetchCoalescer {
+	return &modelFetchCoalescer{results: make(map[string]modelFetchResult), now: time.Now}
+}
+
+// CoalesceModelFetch returns the model list for key, running fetch at most once per
+// ModelFetchCoalesceInterval across all callers. key must identify everything the response
+// depends on: provider and endpoint, plus any account scope such as an organization.
+// Empty results are not reused, so failed fetches are retried by the next caller. Every
+// caller receives its own copy of the models. A caller whose ctx ends while waiting gets
+// nil; the shared fetch keeps running for the others.
+func CoalesceModelFetch(ctx context.Context, key string, fetch func(context.Context) []*registry.ModelInfo) []*registry.ModelInfo {
+	return modelFetches.do(ctx, key, ModelFetchCoalesceInterval, fetch)
+}
+
+func (c *modelFetchCoalescer) do(ctx context.Context, key string, interval time.Duration, fetch func(context.Context) []*registry.ModelInfo) []*registry.ModelInfo {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if models, ok := c.cached(key, interval); ok {
+		return registry.CloneModelInfos(models)
+	}
+	results := c.group.DoChan(key, func() (any, error) {
+		if models, ok := c.cached(key, interval); ok {
+			return models, nil
+		}
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), modelFetchTimeout)
+		defer cancel()
+		models := fetch(fetchCtx)
+		if len(models) > 0 {
+			c.mu.Lock()
+			c.results[key] = modelFetchResult{models: models, fetchedAt: c.now()}
+			c.mu.Unlock()
+		}
+		return models, nil
+	})
+	select {
+	case <-ctx.Done():
+		return nil
+	case result := <-results:
+		models, _ := result.Val.([]*registry.ModelInfo)
+		return registry.CloneModelInfos(models)
+	}
+}
+
+func (c *modelFetchCoalescer) cached(key string, interval time.Duration) ([]*registry.ModelInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, ok := c.results[key]
+	if !ok {
+		return nil, false
+	}
+	if c.now().Sub(result.fetchedAt) >= interval {
+		delete(c.results, key)
+		return nil, false
+	}
+	return result.models, true
+}
@@ -0,0 +1,95 @@
+package helps
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+)
+
+func TestModelFetchCoalescerSharesConcurrentFetches(t *testing.T) {
+	coalescer := newModelFetchCoalescer()
+	var calls atomic.Int32
+	release := make(chan struct{})
+	fetch := func(context.Context) []*registry.ModelInfo {
+		calls.Add(1)
+		<-release
+		return []*registry.ModelInfo{{ID: "shared-model"}}
+	}
+
+	const callers = 8
+	var started, done sync.WaitGroup
+	results := make([][]*registry.ModelInfo, callers)
+	for i := 0; i < callers; i++ {
+		started.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			started.Done()
+			results[i] = coalescer.do(context.Background(), "provider|endpoint", time.Minute, fetch)
+		}()
+	}
+	started.Wait()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	done.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("upstream fetches = %d, want 1", got)
+	}
+	for i, models := range results {
+		if len(models) != 1 || models[0].ID != "shared-model" {
+			t.Fatalf("caller %d models = %+v", i, models)
+		}
+	}
+	results[0][0].ID = "mutated"
+	if again := coalescer.do(context.Background(), "provider|endpoint", time.Minute, fetch); again[0].ID != "shared-model" {
+		t.Fatal("a caller's mutation leaked into the shared result")
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("upstream fetches after reuse = %d, want 1", got)
+	}
+}
+
+func TestModelFetchCoalescerRefetchesAfterIntervalAndFailures(t *testing.T) {
+	coalescer := newModelFetchCoalescer()
+	now := time.Unix(1700000000, 0)
+	coalescer.now = func() time.Time { return now }
+	var calls atomic.Int32
+	var fail atomic.Bool
+	fetch := func(context.Context) []*registry.ModelInfo {
+		calls.Add(1)
+		if fail.Load() {
+			return nil
+		}
+		return []*registry.ModelInfo{{ID: "model"}}
+	}
+
+	fail.Store(true)
+	if models := coalescer.do(context.Background(), "key", time.Minute, fetch); models != nil {
+		t.Fatalf("failed fetch models = %+v, want nil", models)
+	}
+	fail.Store(false)
+	coalescer.do(context.Background(), "key", time.Minute, fetch)
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("fetches after a failure = %d, want 2", got)
+	}
+
+	now = now.Add(30 * time.Second)
+	coalescer.do(context.Background(), "key", time.Minute, fetch)
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("fetches within the interval = %d, want 2", got)
+	}
+	now = now.Add(time.Minute)
+	coalescer.do(context.Background(), "key", time.Minute, fetch)
+	if got := calls.Load(); got != 3 {
+		t.Fatalf("fetches after the interval = %d, want 3", got)
+	}
+	coalescer.do(context.Background(), "other-key", time.Minute, fetch)
+	if got := calls.Load(); got != 4 {
+		t.Fatalf("fetches for another key = %d, want 4", got)
+	}
+}
@@ -39,8 +39,7 @@ func ApplyPayloadConfigWithRequest(cfg *config.Config, model, protocol, fromProt
 	}
 
 	rules := cfg.Payload
-	hasPayloadRules := len(rules.Default) != 0 || len(rules.DefaultRaw) != 0 || len(rules.Override) != 0 || len(rules.OverrideRaw) != 0 || len(rules.Filter) != 0
-	if hasPayloadRules {
+	if hasPayloadRules(rules) {
 		model = strings.TrimSpace(model)
 		requestedModel = strings.TrimSpace(requestedModel)
 		if model != "" || requestedModel != "" {
@@ -171,6 +170,20 @@ func ApplyPayloadConfigWithRequest(cfg *config.Config, model, protocol, fromProt
 	return out
 }
 
+// PayloadRewritesConfigured reports whether ApplyPayloadConfigWithRequest could change a
+// payload sent to requestPath. Executors use it to decide whether a request may be
+// forwarded without translation.
+func PayloadRewritesConfigured(cfg *config.Config, requestPath string) bool {
+	if cfg == nil {
+		return false
+	}
+	return shouldStripImageGeneration(cfg.DisableImageGeneration, requestPath) || hasPayloadRules(cfg.Payload)
+}
+
+func hasPayloadRules(rules config.PayloadConfig) bool {
+	return len(rules.Default) != 0 || len(rules.DefaultRaw) != 0 || len(rules.Override) != 0 || len(rules.OverrideRaw) != 0 || len(rules.Filter) != 0
+}
+
 func isImagesEndpointRequestPath(path string) bool {
 	path = strings.TrimSpace(path)
 	if path == "" {
@@ -0,0 +1,77 @@
+package helps
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+)
+
+// requestIDRoundTripper stamps the request ID from the request context onto upstream
+// requests. A header already set by the executor is left untouched.
+type requestIDRoundTripper struct {
+	base   http.RoundTripper
+	header string
+}
+
+func (t *requestIDRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	requestID := logging.GetRequestID(req.Context())
+	if requestID == "" || req.Header.Get(t.header) != "" {
+		return t.base.RoundTrip(req)
+	}
+	clone := req.Clone(req.Context())
+	clone.Header.Set(t.header, requestID)
+	return t.base.RoundTrip(clone)
+}
+
+// requestIDHeaderFor returns the upstream header configured for provider, or "" when
+// request ID propagation does not apply.
+func requestIDHeaderFor(cfg *config.Config, provider string) string {
+	if cfg == nil {
+		return ""
+	}
+	header := strings.TrimSpace(cfg.RequestID.UpstreamHeader)
+	if header == "" {
+		return ""
+	}
+	if !providerListed(cfg.RequestID.Providers, provider) {
+		return ""
+	}
+	return header
+}
+
+// providerListed reports whether provider is in providers; an empty list includes every provider.
+func providerListed(providers []string, provider string) bool {
+	if len(providers) == 0 {
+		return true
+	}
+	for _, allowed := range providers {
+		if strings.EqualFold(strings.TrimSpace(allowed), provider) {
+			return true
+		}
+	}
+	return false
+}
+
+// WrapUpstreamClient applies the per-provider transport decorations (request ID
+// propagation, cassette record/replay) to an upstream HTTP client.
+func WrapUpstreamClient(cfg *config.Config, provider string, client *http.Client) *http.Client {
+	return withCassette(cfg, provider, withRequestIDHeader(cfg, provider, client))
+}
+
+// withRequestIDHeader returns client unchanged when propagation is disabled for provider,
+// otherwise a copy whose transport adds the request ID header.
+func withRequestIDHeader(cfg *config.Config, provider string, client *http.Client) *http.Client {
+	header := requestIDHeaderFor(cfg, provider)
+	if client == nil || header == "" {
+		return client
+	}
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := *client
+	wrapped.Transport = &requestIDRoundTripper{base: base, header: header}
+	return &wrapped
+}
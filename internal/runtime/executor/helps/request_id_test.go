@@ -0,0 +1,56 @@
+package helps
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestNewProxyAwareHTTPClientPropagatesRequestID(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get("X-Upstream-Request-ID"))
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{}
+	cfg.RequestID.UpstreamHeader = "X-Upstream-Request-ID"
+	cfg.RequestID.Providers = []string{"claude"}
+
+	do := func(provider string, preset string) {
+		t.Helper()
+		client := NewProxyAwareHTTPClient(context.Background(), cfg, &cliproxyauth.Auth{Provider: provider}, 0)
+		ctx := logging.WithRequestID(context.Background(), "req-1")
+		req, errReq := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if errReq != nil {
+			t.Fatalf("new request: %v", errReq)
+		}
+		if preset != "" {
+			req.Header.Set("X-Upstream-Request-ID", preset)
+		}
+		resp, errDo := client.Do(req)
+		if errDo != nil {
+			t.Fatalf("do: %v", errDo)
+		}
+		_ = resp.Body.Close()
+	}
+
+	do("claude", "")
+	do("gemini", "")
+	do("claude", "executor-set")
+
+	want := []string{"req-1", "", "executor-set"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d requests, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("request %d header = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
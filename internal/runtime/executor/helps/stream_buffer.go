@@ -0,0 +1,84 @@
+package helps
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/sse"
+)
+
+// StreamEventTooLargeError reports an upstream stream line or event above the configured
+// streaming.max-event-bytes. It maps to 502 so the failure is surfaced to the client as
+// an upstream error rather than a silently truncated stream.
+type StreamEventTooLargeError struct {
+	Limit int
+}
+
+func (e *StreamEventTooLargeError) Error() string {
+	return fmt.Sprintf("upstream stream event exceeds the maximum size of %d bytes (streaming.max-event-bytes)", e.Limit)
+}
+
+// StatusCode implements the status-carrying error interface used by the handlers.
+func (e *StreamEventTooLargeError) StatusCode() int { return http.StatusBadGateway }
+
+// StreamMaxEventBytes returns the configured per-event limit for upstream streams.
+func StreamMaxEventBytes(cfg *config.Config) int {
+	if cfg == nil || cfg.Streaming.MaxEventBytes <= 0 {
+		return sse.DefaultMaxEventSize
+	}
+	return cfg.Streaming.MaxEventBytes
+}
+
+// StreamScanner is a line scanner over an upstream stream whose Err reports oversized
+// lines as *StreamEventTooLargeError.
+type StreamScanner struct {
+	*bufio.Scanner
+	limit int
+}
+
+// NewStreamScanner returns a line scanner bounded by streaming.max-event-bytes.
+func NewStreamScanner(cfg *config.Config, r io.Reader) *StreamScanner {
+	limit := StreamMaxEventBytes(cfg)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, min(limit, 64<<10)), limit)
+	return &StreamScanner{Scanner: scanner, limit: limit}
+}
+
+// Err returns the first non-EOF error, translating buffer overflows.
+func (s *StreamScanner) Err() error {
+	return StreamReadError(s.Scanner.Err(), s.limit)
+}
+
+// SSEReader is an event reader over an upstream stream whose Next reports oversized
+// events as *StreamEventTooLargeError.
+type SSEReader struct {
+	*sse.Reader
+	limit int
+}
+
+// NewSSEReader returns an event reader bounded by streaming.max-event-bytes.
+func NewSSEReader(cfg *config.Config, r io.Reader) *SSEReader {
+	limit := StreamMaxEventBytes(cfg)
+	reader := sse.NewReader(r)
+	reader.SetMaxEventSize(limit)
+	return &SSEReader{Reader: reader, limit: limit}
+}
+
+// Next returns the next event, translating overflows.
+func (r *SSEReader) Next() (sse.Event, error) {
+	event, err := r.Reader.Next()
+	return event, StreamReadError(err, r.limit)
+}
+
+// StreamReadError translates scanner and reader overflow errors into
+// *StreamEventTooLargeError; other errors are returned unchanged.
+func StreamReadError(err error, limit int) error {
+	if errors.Is(err, bufio.ErrTooLong) || errors.Is(err, sse.ErrEventTooLarge) {
+		return &StreamEventTooLargeError{Limit: limit}
+	}
+	return err
+}
@@ -0,0 +1,38 @@
+package helps
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestStreamReadersReportOversizedEvents(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Streaming.MaxEventBytes = 1024
+	body := "data: " + strings.Repeat("x", 4096) + "\n\n"
+
+	scanner := NewStreamScanner(cfg, strings.NewReader("data: ok\n"+body))
+	if !scanner.Scan() || scanner.Text() != "data: ok" {
+		t.Fatalf("first line = %q", scanner.Text())
+	}
+	for scanner.Scan() {
+	}
+	var tooLarge *StreamEventTooLargeError
+	if err := scanner.Err(); !errors.As(err, &tooLarge) || tooLarge.Limit != 1024 || tooLarge.StatusCode() != http.StatusBadGateway {
+		t.Fatalf("scanner error = %v, want a 502 StreamEventTooLargeError", err)
+	}
+
+	reader := NewSSEReader(cfg, strings.NewReader(body))
+	if _, err := reader.Next(); !errors.As(err, &tooLarge) {
+		t.Fatalf("reader error = %v, want StreamEventTooLargeError", err)
+	}
+}
+
+func TestStreamMaxEventBytesDefault(t *testing.T) {
+	if got := StreamMaxEventBytes(nil); got != 50<<20 {
+		t.Fatalf("default = %d, want 50 MiB", got)
+	}
+}
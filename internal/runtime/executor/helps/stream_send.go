@@ -0,0 +1,52 @@
+package helps
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// SendStreamChunk delivers chunk to out unless ctx is cancelled first. It returns false
+// when the consumer is gone, so stream goroutines can stop reading and close the upstream
+// body instead of blocking on a channel nobody drains.
+func SendStreamChunk(ctx context.Context, out chan<- cliproxyexecutor.StreamChunk, chunk cliproxyexecutor.StreamChunk) bool {
+	if ctx == nil {
+		out <- chunk
+		return true
+	}
+	select {
+	case out <- chunk:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// StreamPanicError reports a panic recovered in an executor's stream goroutine.
+type StreamPanicError struct {
+	Provider string
+	Value    any
+}
+
+func (e *StreamPanicError) Error() string {
+	return fmt.Sprintf("%s executor: stream panicked: %v", e.Provider, e.Value)
+}
+
+// StatusCode implements the status interface used by retry and cooldown handling.
+func (e *StreamPanicError) StatusCode() int { return http.StatusInternalServerError }
+
+// RecoverStreamPanic turns a panic in a stream goroutine into a final error chunk, so a
+// faulty parser or translator fails one stream instead of crashing the process. Defer it
+// directly in the goroutine right after close(out) so the chunk is sent before out closes.
+func RecoverStreamPanic(ctx context.Context, out chan<- cliproxyexecutor.StreamChunk, provider string) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	log.Errorf("%s executor: stream goroutine panicked: %v\n%s", provider, recovered, debug.Stack())
+	SendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{Err: &StreamPanicError{Provider: provider, Value: recovered}})
+}
@@ -0,0 +1,32 @@
+package helps
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestRecoverStreamPanicSendsErrorChunk(t *testing.T) {
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer RecoverStreamPanic(context.Background(), out, "test")
+		out <- cliproxyexecutor.StreamChunk{Payload: []byte("partial")}
+		panic("parser bug")
+	}()
+
+	var chunks []cliproxyexecutor.StreamChunk
+	for chunk := range out {
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) != 2 || string(chunks[0].Payload) != "partial" {
+		t.Fatalf("chunks = %+v, want the payload then an error", chunks)
+	}
+	var panicErr *StreamPanicError
+	if !errors.As(chunks[1].Err, &panicErr) || panicErr.StatusCode() != http.StatusInternalServerError || panicErr.Value != "parser bug" {
+		t.Fatalf("last chunk error = %v, want a StreamPanicError", chunks[1].Err)
+	}
+}
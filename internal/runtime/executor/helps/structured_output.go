@@ -0,0 +1,276 @@
+package helps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// StructuredOutputMaxAttempts bounds how many times EnforceStructuredOutput asks the
+// upstream for a schema-conforming reply, including the first request.
+const StructuredOutputMaxAttempts = 3
+
+// StructuredOutputSchema returns the JSON schema of an OpenAI Chat Completions
+// response_format of type json_schema, or false when the request does not ask for one.
+func StructuredOutputSchema(payload []byte) (gjson.Result, bool) {
+	responseFormat := gjson.GetBytes(payload, "response_format")
+	if !strings.EqualFold(responseFormat.Get("type").String(), "json_schema") {
+		return gjson.Result{}, false
+	}
+	schema := responseFormat.Get("json_schema.schema")
+	if !schema.IsObject() {
+		return gjson.Result{}, false
+	}
+	return schema, true
+}
+
+// EnforceStructuredOutput validates the assistant message of an OpenAI Chat Completions
+// response against the request's json_schema response_format. When the reply does not
+// parse or match, the conversation is extended with the invalid reply and a correction
+// prompt and sent again through send, up to StructuredOutputMaxAttempts requests in total.
+// Replies wrapped in a Markdown code fence are unwrapped before validation. The last
+// response is returned when no attempt conforms.
+func EnforceStructuredOutput(ctx context.Context, payload, response []byte, send func(context.Context, []byte) ([]byte, error)) ([]byte, error) {
+	schema, ok := StructuredOutputSchema(payload)
+	if !ok {
+		return response, nil
+	}
+	for attempt := 1; ; attempt++ {
+		content := gjson.GetBytes(response, "choices.0.message.content").String()
+		candidate := stripJSONCodeFence(content)
+		errValidate := ValidateJSONSchema([]byte(candidate), schema)
+		if errValidate == nil {
+			if candidate != content {
+				response, _ = sjson.SetBytes(response, "choices.0.message.content", candidate)
+			}
+			return response, nil
+		}
+		if attempt >= StructuredOutputMaxAttempts || ctx.Err() != nil {
+			return response, nil
+		}
+
+		assistant := []byte(`{"role":"assistant","content":""}`)
+		assistant, _ = sjson.SetBytes(assistant, "content", content)
+		correction := []byte(`{"role":"user","content":""}`)
+		correction, _ = sjson.SetBytes(correction, "content", fmt.Sprintf(
+			"Your previous reply did not match the required JSON schema (%v). Reply again with only a JSON value that matches this schema, without any other text:\n%s",
+			errValidate, schema.Raw))
+		payload, _ = sjson.SetRawBytes(payload, "messages.-1", assistant)
+		payload, _ = sjson.SetRawBytes(payload, "messages.-1", correction)
+
+		next, errSend := send(ctx, payload)
+		if errSend != nil {
+			return nil, errSend
+		}
+		response = next
+	}
+}
+
+func stripJSONCodeFence(content string) string {
+	trimmed := strings.TrimSpace(content)
+	if !strings.HasPrefix(trimmed, "```") || !strings.HasSuffix(trimmed, "```") || len(trimmed) < 6 {
+		return content
+	}
+	inner := strings.TrimSuffix(strings.TrimPrefix(trimmed, "```"), "```")
+	if newline := strings.IndexByte(inner, '\n'); newline >= 0 && !strings.ContainsAny(inner[:newline], "{[\"") {
+		inner = inner[newline+1:]
+	}
+	return strings.TrimSpace(inner)
+}
+
+// ValidateJSONSchema checks data against a JSON schema. It covers the keywords used by
+// OpenAI structured outputs: type, properties, required, additionalProperties, items,
+// enum, const, anyOf/oneOf/allOf, local $ref, and string/number/array bounds.
+func ValidateJSONSchema(data []byte, schema gjson.Result) error {
+	if !gjson.ValidBytes(data) {
+		return fmt.Errorf("reply is not valid JSON")
+	}
+	return validateJSONSchemaValue(gjson.ParseBytes(data), schema, schema, "$", 0)
+}
+
+func validateJSONSchemaValue(value, schema, root gjson.Result, path string, depth int) error {
+	if depth > 64 {
+		return fmt.Errorf("%s: schema nesting too deep", path)
+	}
+	if ref := schema.Get(`\$ref`).String(); ref != "" {
+		resolved, ok := resolveJSONSchemaRef(root, ref)
+		if !ok {
+			return fmt.Errorf("%s: unresolvable $ref %q", path, ref)
+		}
+		return validateJSONSchemaValue(value, resolved, root, path, depth+1)
+	}
+
+	if types := schema.Get("type"); types.Exists() && !jsonSchemaTypeMatches(value, types) {
+		return fmt.Errorf("%s: expected type %s", path, types.Raw)
+	}
+	if enum := schema.Get("enum"); enum.IsArray() {
+		matched := false
+		for _, option := range enum.Array() {
+			if jsonValuesEqual(value, option) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("%s: value is not one of %s", path, enum.Raw)
+		}
+	}
+	if constant := schema.Get("const"); constant.Exists() && !jsonValuesEqual(value, constant) {
+		return fmt.Errorf("%s: value must be %s", path, constant.Raw)
+	}
+
+	for _, sub := range schema.Get("allOf").Array() {
+		if err := validateJSONSchemaValue(value, sub, root, path, depth+1); err != nil {
+			return err
+		}
+	}
+	if anyOf := schema.Get("anyOf"); anyOf.IsArray() && countJSONSchemaMatches(value, anyOf, root, path, depth) == 0 {
+		return fmt.Errorf("%s: value matches none of anyOf", path)
+	}
+	if oneOf := schema.Get("oneOf"); oneOf.IsArray() && countJSONSchemaMatches(value, oneOf, root, path, depth) != 1 {
+		return fmt.Errorf("%s: value must match exactly one of oneOf", path)
+	}
+
+	switch {
+	case value.IsObject():
+		return validateJSONSchemaObject(value, schema, root, path, depth)
+	case value.IsArray():
+		items := value.Array()
+		if minItems := schema.Get("minItems"); minItems.Exists() && int64(len(items)) < minItems.Int() {
+			return fmt.Errorf("%s: expected at least %d items", path, minItems.Int())
+		}
+		if maxItems := schema.Get("maxItems"); maxItems.Exists() && int64(len(items)) > maxItems.Int() {
+			return fmt.Errorf("%s: expected at most %d items", path, maxItems.Int())
+		}
+		if itemSchema := schema.Get("items"); itemSchema.IsObject() {
+			for i, item := range items {
+				if err := validateJSONSchemaValue(item, itemSchema, root, fmt.Sprintf("%s[%d]", path, i), depth+1); err != nil {
+					return err
+				}
+			}
+		}
+	case value.Type == gjson.String:
+		length := int64(utf8.RuneCountInString(value.Str))
+		if minLength := schema.Get("minLength"); minLength.Exists() && length < minLength.Int() {
+			return fmt.Errorf("%s: expected at least %d characters", path, minLength.Int())
+		}
+		if maxLength := schema.Get("maxLength"); maxLength.Exists() && length > maxLength.Int() {
+			return fmt.Errorf("%s: expected at most %d characters", path, maxLength.Int())
+		}
+	case value.Type == gjson.Number:
+		if minimum := schema.Get("minimum"); minimum.Exists() && value.Num < minimum.Num {
+			return fmt.Errorf("%s: must be >= %s", path, minimum.Raw)
+		}
+		if maximum := schema.Get("maximum"); maximum.Exists() && value.Num > maximum.Num {
+			return fmt.Errorf("%s: must be <= %s", path, maximum.Raw)
+		}
+	}
+	return nil
+}
+
+func validateJSONSchemaObject(value, schema, root gjson.Result, path string, depth int) error {
+	for _, required := range schema.Get("required").Array() {
+		if !value.Get(gjson.Escape(required.String())).Exists() {
+			return fmt.Errorf("%s: missing required property %q", path, required.String())
+		}
+	}
+	properties := schema.Get("properties")
+	additional := schema.Get("additionalProperties")
+	var errProperty error
+	value.ForEach(func(key, child gjson.Result) bool {
+		childPath := path + "." + key.String()
+		if propertySchema := properties.Get(gjson.Escape(key.String())); propertySchema.Exists() {
+			errProperty = validateJSONSchemaValue(child, propertySchema, root, childPath, depth+1)
+		} else if additional.Type == gjson.False {
+			errProperty = fmt.Errorf("%s: unexpected property", childPath)
+		} else if additional.IsObject() {
+			errProperty = validateJSONSchemaValue(child, additional, root, childPath, depth+1)
+		}
+		return errProperty == nil
+	})
+	return errProperty
+}
+
+func countJSONSchemaMatches(value, schemas, root gjson.Result, path string, depth int) int {
+	matches := 0
+	for _, sub := range schemas.Array() {
+		if validateJSONSchemaValue(value, sub, root, path, depth+1) == nil {
+			matches++
+		}
+	}
+	return matches
+}
+
+func resolveJSONSchemaRef(root gjson.Result, ref string) (gjson.Result, bool) {
+	if ref == "#" {
+		return root, true
+	}
+	if !strings.HasPrefix(ref, "#/") {
+		return gjson.Result{}, false
+	}
+	current := root
+	for _, segment := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		segment = strings.ReplaceAll(strings.ReplaceAll(segment, "~1", "/"), "~0", "~")
+		current = current.Get(gjson.Escape(segment))
+		if !current.Exists() {
+			return gjson.Result{}, false
+		}
+	}
+	return current, true
+}
+
+func jsonSchemaTypeMatches(value, types gjson.Result) bool {
+	if types.IsArray() {
+		for _, t := range types.Array() {
+			if jsonSchemaTypeMatches(value, t) {
+				return true
+			}
+		}
+		return false
+	}
+	switch types.String() {
+	case "object":
+		return value.IsObject()
+	case "array":
+		return value.IsArray()
+	case "string":
+		return value.Type == gjson.String
+	case "number":
+		return value.Type == gjson.Number
+	case "integer":
+		return value.Type == gjson.Number && value.Num == math.Trunc(value.Num)
+	case "boolean":
+		return value.Type == gjson.True || value.Type == gjson.False
+	case "null":
+		return value.Type == gjson.Null
+	default:
+		return true
+	}
+}
+
+func jsonValuesEqual(a, b gjson.Result) bool {
+	if a.Type != b.Type {
+		return false
+	}
+	switch a.Type {
+	case gjson.Number:
+		return a.Num == b.Num
+	case gjson.String:
+		return a.Str == b.Str
+	case gjson.JSON:
+		var compactA, compactB bytes.Buffer
+		if json.Compact(&compactA, []byte(a.Raw)) != nil || json.Compact(&compactB, []byte(b.Raw)) != nil {
+			return a.Raw == b.Raw
+		}
+		return bytes.Equal(compactA.Bytes(), compactB.Bytes())
+	default:
+		return true
+	}
+}
@@ -0,0 +1,83 @@
+package helps
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+const structuredOutputTestSchema = `{
+	"type":"object",
+	"properties":{
+		"name":{"type":"string","minLength":1},
+		"age":{"type":"integer","minimum":0},
+		"tags":{"type":"array","items":{"$ref":"#/$defs/tag"}}
+	},
+	"required":["name","age"],
+	"additionalProperties":false,
+	"$defs":{"tag":{"type":"string","enum":["a","b"]}}
+}`
+
+func TestValidateJSONSchema(t *testing.T) {
+	schema := gjson.Parse(structuredOutputTestSchema)
+	cases := []struct {
+		data    string
+		wantErr string
+	}{
+		{`{"name":"x","age":3,"tags":["a"]}`, ""},
+		{`{"name":"x"}`, `missing required property "age"`},
+		{`{"name":"x","age":1.5}`, "$.age: expected type"},
+		{`{"name":"x","age":1,"extra":true}`, "$.extra: unexpected property"},
+		{`{"name":"x","age":1,"tags":["c"]}`, "$.tags[0]: value is not one of"},
+		{`not json`, "not valid JSON"},
+	}
+	for _, tc := range cases {
+		err := ValidateJSONSchema([]byte(tc.data), schema)
+		if tc.wantErr == "" {
+			if err != nil {
+				t.Fatalf("ValidateJSONSchema(%s) error = %v", tc.data, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Fatalf("ValidateJSONSchema(%s) error = %v, want %q", tc.data, err, tc.wantErr)
+		}
+	}
+}
+
+func TestEnforceStructuredOutputRetriesUntilValid(t *testing.T) {
+	payload := []byte(`{"messages":[{"role":"user","content":"hi"}],"response_format":{"type":"json_schema","json_schema":{"name":"p","schema":` + structuredOutputTestSchema + `}}}`)
+	first := []byte(`{"choices":[{"message":{"role":"assistant","content":"Sure! Here it is."}}]}`)
+
+	var sent [][]byte
+	out, err := EnforceStructuredOutput(context.Background(), payload, first, func(_ context.Context, retry []byte) ([]byte, error) {
+		sent = append(sent, retry)
+		return []byte("{\"choices\":[{\"message\":{\"role\":\"assistant\",\"content\":\"```json\\n{\\\"name\\\":\\\"x\\\",\\\"age\\\":2}\\n```\"}}]}"), nil
+	})
+	if err != nil {
+		t.Fatalf("EnforceStructuredOutput error: %v", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("retries = %d, want 1", len(sent))
+	}
+	messages := gjson.GetBytes(sent[0], "messages").Array()
+	if len(messages) != 3 || messages[1].Get("role").String() != "assistant" || !strings.Contains(messages[2].Get("content").String(), "did not match") {
+		t.Fatalf("unexpected retry messages: %s", gjson.GetBytes(sent[0], "messages").Raw)
+	}
+	if got := gjson.GetBytes(out, "choices.0.message.content").String(); got != `{"name":"x","age":2}` {
+		t.Fatalf("content = %q, want unwrapped JSON", got)
+	}
+}
+
+func TestEnforceStructuredOutputSkipsWithoutSchema(t *testing.T) {
+	response := []byte(`{"choices":[{"message":{"content":"plain"}}]}`)
+	out, err := EnforceStructuredOutput(context.Background(), []byte(`{"response_format":{"type":"json_object"}}`), response, func(context.Context, []byte) ([]byte, error) {
+		t.Fatal("send should not be called")
+		return nil, nil
+	})
+	if err != nil || string(out) != string(response) {
+		t.Fatalf("out = %s, err = %v", out, err)
+	}
+}
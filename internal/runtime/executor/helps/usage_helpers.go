@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
 	internallogging "github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
@@ -35,6 +36,7 @@ type UsageReporter struct {
 	reasoning    string
 	serviceTier  string
 	generate     bool
+	background   string
 	requestedAt  time.Time
 	ttftMu       sync.RWMutex
 	ttft         time.Duration
@@ -74,6 +76,7 @@ func NewUsageReporter(ctx context.Context, provider, model string, auth *cliprox
 		reasoning:   usage.ReasoningEffortFromContext(ctx),
 		serviceTier: usage.ServiceTierFromContext(ctx),
 		generate:    usage.GenerateFromContext(ctx),
+		background:  usage.BackgroundLaneFromContext(ctx),
 	}
 	if auth != nil {
 		reporter.authID = auth.ID
@@ -204,6 +207,7 @@ func (r *UsageReporter) publishWithOutcome(ctx context.Context, detail usage.Det
 	}
 	detail = normalizeUsageDetailTotal(detail, r.provider, r.executorType)
 	r.once.Do(func() {
+		cache.RecordPromptCacheUsage(r.provider, detail.CacheReadTokens, detail.CacheCreationTokens)
 		r.publishRecord(ctx, r.buildRecord(detail, failed, fail))
 	})
 }
@@ -238,6 +242,9 @@ func (r *UsageReporter) EnsurePublished(ctx context.Context) {
 
 func (r *UsageReporter) publishRecord(ctx context.Context, record usage.Record) {
 	record.ResponseHeaders = internallogging.GetResponseHeaders(ctx)
+	if record.RequestID == "" {
+		record.RequestID = internallogging.GetRequestID(ctx)
+	}
 	usage.PublishRecord(ctx, record)
 
 	if ginCtx := ginContextFrom(ctx); ginCtx != nil && hasNonZeroTokenUsage(record.Detail) {
@@ -289,6 +296,7 @@ func (r *UsageReporter) buildRecordForModel(model string, detail usage.Detail, f
 		ServiceTier:         r.serviceTier,
 		ResponseServiceTier: strings.TrimSpace(detail.ResponseServiceTier),
 		Generate:            usage.GenerateFlag(r.generate),
+		Background:          r.background,
 		RequestedAt:         r.requestedAt,
 		Latency:             r.latency(),
 		TTFT:                r.ttftDuration(),
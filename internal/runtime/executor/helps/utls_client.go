@@ -2,6 +2,7 @@ package helps
 
 import (
 	"context"
+	stdtls "crypto/tls"
 	"net"
 	"net/http"
 	"strings"
@@ -24,15 +25,16 @@ type utlsRoundTripper struct {
 	connections map[string]*http2.ClientConn
 	pending     map[string]*sync.Cond
 	dialer      proxy.Dialer
+	tlsConfig   *stdtls.Config
 }
 
-func newUtlsRoundTripper(proxyURL string) *utlsRoundTripper {
+func newUtlsRoundTripper(proxyURL, sourceAddr, provider string) *utlsRoundTripper {
 	var dialer proxy.Dialer = proxy.Direct
-	if proxyURL != "" {
-		proxyDialer, mode, errBuild := proxyutil.BuildDialer(proxyURL)
+	if proxyURL != "" || sourceAddr != "" {
+		proxyDialer, _, errBuild := proxyutil.BuildSourceDialer(proxyURL, sourceAddr)
 		if errBuild != nil {
 			log.Errorf("utls: failed to configure proxy dialer for %q: %v", proxyutil.Redact(proxyURL), errBuild)
-		} else if mode != proxyutil.ModeInherit && proxyDialer != nil {
+		} else if proxyDialer != nil {
 			dialer = proxyDialer
 		}
 	}
@@ -40,6 +42,7 @@ func newUtlsRoundTripper(proxyURL string) *utlsRoundTripper {
 		connections: make(map[string]*http2.ClientConn),
 		pending:     make(map[string]*sync.Cond),
 		dialer:      dialer,
+		tlsConfig:   proxyutil.TLSConfig(provider),
 	}
 }
 
@@ -86,6 +89,12 @@ func (t *utlsRoundTripper) createConnection(host, addr string) (*http2.ClientCon
 	}
 
 	tlsConfig := &tls.Config{ServerName: host}
+	if t.tlsConfig != nil {
+		tlsConfig.RootCAs = t.tlsConfig.RootCAs
+		for _, cert := range t.tlsConfig.Certificates {
+			tlsConfig.Certificates = append(tlsConfig.Certificates, tls.Certificate{Certificate: cert.Certificate, PrivateKey: cert.PrivateKey, Leaf: cert.Leaf})
+		}
+	}
 	tlsConn := tls.UClient(conn, tlsConfig, tls.HelloChrome_Auto)
 
 	if err := tlsConn.Handshake(); err != nil {
@@ -169,10 +178,15 @@ func NewUtlsHTTPClient(ctx context.Context, cfg *config.Config, auth *cliproxyau
 		ctxRoundTripper, _ = ctx.Value("cliproxy.roundtripper").(http.RoundTripper)
 	}
 
-	var utlsRT http.RoundTripper = newUtlsRoundTripper(proxyURL)
+	sourceAddr := auth.SourceAddress()
+	var provider string
+	if auth != nil {
+		provider = auth.Provider
+	}
+	var utlsRT http.RoundTripper = newUtlsRoundTripper(proxyURL, sourceAddr, provider)
 	var standardTransport http.RoundTripper = http.DefaultTransport
-	if proxyURL != "" {
-		if transport := buildProxyTransport(proxyURL); transport != nil {
+	if proxyURL != "" || sourceAddr != "" || proxyutil.TLSConfigKey(provider) != "" {
+		if transport := buildProxyTransport(proxyURL, sourceAddr, provider); transport != nil {
 			standardTransport = transport
 		}
 	} else if ctxRoundTripper != nil {
@@ -189,5 +203,5 @@ func NewUtlsHTTPClient(ctx context.Context, cfg *config.Config, auth *cliproxyau
 	if timeout > 0 {
 		client.Timeout = timeout
 	}
-	return client
+	return wrapAuthUpstreamClient(cfg, auth, provider, client)
 }
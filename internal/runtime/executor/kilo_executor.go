@@ -1,7 +1,6 @@
 package executor
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"errors"
@@ -13,6 +12,7 @@ import (
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
@@ -23,6 +23,7 @@ import (
 )
 
 const (
+	kiloBaseURL      = "https://api.kilo.ai"
 	kiloVersion      = "3.26.0"
 	kiloTesterHeader = "X-Kilocode-Tester"
 )
@@ -106,7 +107,7 @@ func (e *KiloExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req
 		return resp, err
 	}
 
-	url := "https://api.kilo.ai" + endpoint
+	url := helps.ProviderBaseURL(e.cfg, auth, e.Identifier(), kiloBaseURL) + endpoint
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(translated))
 	if err != nil {
 		return resp, err
@@ -198,7 +199,7 @@ func (e *KiloExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Aut
 		return nil, err
 	}
 
-	url := "https://api.kilo.ai" + endpoint
+	url := helps.ProviderBaseURL(e.cfg, auth, e.Identifier(), kiloBaseURL) + endpoint
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(translated))
 	if err != nil {
 		return nil, err
@@ -248,33 +249,31 @@ func (e *KiloExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Aut
 	out := make(chan cliproxyexecutor.StreamChunk)
 	go func() {
 		defer close(out)
+		defer helps.RecoverStreamPanic(ctx, out, e.Identifier())
 		defer httpResp.Body.Close()
 
-		scanner := bufio.NewScanner(httpResp.Body)
-		scanner.Buffer(nil, 52_428_800)
+		reader := newSSEReader(e.cfg, httpResp.Body)
 		var param any
-		for scanner.Scan() {
-			line := scanner.Bytes()
-			appendAPIResponseChunk(ctx, e.cfg, line)
+		for {
+			event, errRead := reader.Next()
+			if errRead != nil {
+				if !errors.Is(errRead, io.EOF) {
+					recordAPIResponseError(ctx, e.cfg, errRead)
+					reporter.publishFailure(ctx)
+					sendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{Err: errRead})
+				}
+				break
+			}
+			appendAPIResponseChunk(ctx, e.cfg, event.Raw)
+			line := event.DataLine()
 			if detail, ok := parseOpenAIStreamUsage(line); ok {
 				reporter.publish(ctx, detail)
 			}
-			if len(line) == 0 {
-				continue
-			}
-			if !bytes.HasPrefix(line, []byte("data:")) {
-				continue
-			}
-			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, bytes.Clone(line), &param)
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, line, &param)
 			for i := range chunks {
-				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])}
+				sendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{Payload: []byte(chunks[i])})
 			}
 		}
-		if errScan := scanner.Err(); errScan != nil {
-			recordAPIResponseError(ctx, e.cfg, errScan)
-			reporter.publishFailure(ctx)
-			out <- cliproxyexecutor.StreamChunk{Err: errScan}
-		}
 		reporter.ensurePublished(ctx)
 	}()
 
@@ -350,13 +349,27 @@ func FetchKiloModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.C
 		return registry.GetKiloModels()
 	}
 
+	// The curated list depends on the organization, not on the individual account, so auths
+	// of one organization share a single upstream fetch.
+	endpoint := helps.ProviderBaseURL(cfg, auth, "kilo", kiloBaseURL) + "/api/openrouter/models"
+	models := helps.CoalesceModelFetch(ctx, "kilo|"+endpoint+"|"+orgID, func(fetchCtx context.Context) []*registry.ModelInfo {
+		return fetchKiloModels(fetchCtx, auth, cfg, endpoint, accessToken, orgID)
+	})
+	if len(models) == 0 {
+		return registry.GetKiloModels()
+	}
+	return models
+}
+
+// fetchKiloModels returns kilo/auto plus the curated free models, or nil when the fetch fails.
+func fetchKiloModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config, endpoint, accessToken, orgID string) []*registry.ModelInfo {
 	log.Debugf("kilo: fetching dynamic models (orgID: %s)", orgID)
 
 	httpClient := newProxyAwareHTTPClient(ctx, cfg, auth, 0)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "https://api.kilo.ai/api/openrouter/models", nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
 	if err != nil {
 		log.Warnf("kilo: failed to create model fetch request: %v", err)
-		return registry.GetKiloModels()
+		return nil
 	}
 
 	req.Header.Set("Authorization", "Bearer "+accessToken)
@@ -372,19 +385,19 @@ func FetchKiloModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.C
 		} else {
 			log.Warnf("kilo: using static models (API fetch failed: %v)", err)
 		}
-		return registry.GetKiloModels()
+		return nil
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Warnf("kilo: failed to read models response: %v", err)
-		return registry.GetKiloModels()
+		return nil
 	}
 
 	if resp.StatusCode != http.StatusOK {
 		log.Warnf("kilo: fetch models failed: status %d, body: %s", resp.StatusCode, string(body))
-		return registry.GetKiloModels()
+		return nil
 	}
 
 	result := gjson.GetBytes(body, "data")
@@ -394,7 +407,7 @@ func FetchKiloModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.C
 		if !result.IsArray() {
 			log.Debugf("kilo: response body: %s", string(body))
 			log.Warn("kilo: invalid API response format (expected array or data field with array)")
-			return registry.GetKiloModels()
+			return nil
 		}
 	}
 
@@ -439,6 +452,7 @@ func FetchKiloModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.C
 			Type:          "kilo",
 			Object:        "model",
 			Created:       now,
+			Capabilities:  helps.OpenRouterEntryCapabilities(value),
 		})
 		count++
 		return true
@@ -23,6 +23,7 @@ import (
 	"github.com/google/uuid"
 	kiroauth "github.com/router-for-me/CLIProxyAPI/v7/internal/auth/kiro"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
 	kiroclaude "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/kiro/claude"
 	kirocommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/kiro/common"
 	kiroopenai "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/kiro/openai"
@@ -89,11 +90,11 @@ var endpointAliases = map[string]string{
 	"cli":           "amazonq",
 }
 
-func enqueueTranslatedSSE(out chan<- cliproxyexecutor.StreamChunk, chunk []byte) {
+func enqueueTranslatedSSE(ctx context.Context, out chan<- cliproxyexecutor.StreamChunk, chunk []byte) {
 	if len(chunk) == 0 {
 		return
 	}
-	out <- cliproxyexecutor.StreamChunk{Payload: append(bytes.Clone(chunk), '\n', '\n')}
+	sendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{Payload: append(bytes.Clone(chunk), '\n', '\n')})
 }
 
 // retryConfig holds configuration for socket retry logic.
@@ -306,13 +307,13 @@ func newKiroHTTPClientWithPooling(ctx context.Context, cfg *config.Config, auth
 
 	// If timeout is specified, we need to wrap the pooled transport with timeout
 	if timeout > 0 {
-		return &http.Client{
+		return wrapUpstreamClient(cfg, "kiro", &http.Client{
 			Transport: pooledClient.Transport,
 			Timeout:   timeout,
-		}
+		})
 	}
 
-	return pooledClient
+	return wrapUpstreamClient(cfg, "kiro", pooledClient)
 }
 
 // kiroEndpointConfig bundles endpoint URL with its compatible Origin and AmzTarget values.
@@ -471,7 +472,7 @@ type KiroExecutor struct {
 // Returns the serialized JSON payload and a boolean indicating whether thinking mode was injected.
 func buildKiroPayloadForFormat(body []byte, modelID, profileArn, origin string, isAgentic, isChatOnly bool, sourceFormat sdktranslator.Format, headers http.Header) ([]byte, bool) {
 	switch sourceFormat.String() {
-	case "openai":
+	case "openai", "openai-response":
 		log.Debugf("kiro: using OpenAI payload builder for source format: %s", sourceFormat.String())
 		return kiroopenai.BuildKiroPayloadFromOpenAI(body, modelID, profileArn, origin, isAgentic, isChatOnly, headers, nil)
 	case "kiro":
@@ -1413,7 +1414,7 @@ func (e *KiroExecutor) executeStreamWithRetry(ctx context.Context, auth *cliprox
 				defer func() {
 					if r := recover(); r != nil {
 						log.Errorf("kiro: panic in stream handler: %v", r)
-						out <- cliproxyexecutor.StreamChunk{Err: fmt.Errorf("internal error: %v", r)}
+						sendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{Err: fmt.Errorf("internal error: %v", r)})
 					}
 				}()
 				defer func() {
@@ -2565,7 +2566,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 			log.Errorf("kiro: streamToChannel error: %v", eventErr)
 
 			// Send error to channel for client notification
-			out <- cliproxyexecutor.StreamChunk{Err: eventErr}
+			sendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{Err: eventErr})
 			return
 		}
 		if msg == nil {
@@ -2588,7 +2589,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 				blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(contentBlockIndex, "tool_use", currentToolUse.ToolUseID, currentToolUse.Name)
 				sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
 				for _, chunk := range sseData {
-					enqueueTranslatedSSE(out, chunk)
+					enqueueTranslatedSSE(ctx, out, chunk)
 				}
 
 				// Send tool input as delta
@@ -2596,14 +2597,14 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 				inputDelta := kiroclaude.BuildClaudeInputJsonDeltaEvent(string(inputBytes), contentBlockIndex)
 				sseData = sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, inputDelta, &translatorParam)
 				for _, chunk := range sseData {
-					enqueueTranslatedSSE(out, chunk)
+					enqueueTranslatedSSE(ctx, out, chunk)
 				}
 
 				// Close block
 				blockStop := kiroclaude.BuildClaudeContentBlockStopEvent(contentBlockIndex)
 				sseData = sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
 				for _, chunk := range sseData {
-					enqueueTranslatedSSE(out, chunk)
+					enqueueTranslatedSSE(ctx, out, chunk)
 				}
 
 				hasToolUses = true
@@ -2639,7 +2640,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 				errMsg = msg
 			}
 			log.Errorf("kiro: received AWS error in stream: type=%s, message=%s", errType, errMsg)
-			out <- cliproxyexecutor.StreamChunk{Err: fmt.Errorf("kiro API error: %s - %s", errType, errMsg)}
+			sendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{Err: fmt.Errorf("kiro API error: %s - %s", errType, errMsg)})
 			return
 		}
 		if errType, hasErrType := event["type"].(string); hasErrType && (errType == "error" || errType == "exception") {
@@ -2653,7 +2654,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 				}
 			}
 			log.Errorf("kiro: received error event in stream: type=%s, message=%s", errType, errMsg)
-			out <- cliproxyexecutor.StreamChunk{Err: fmt.Errorf("kiro API error: %s", errMsg)}
+			sendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{Err: fmt.Errorf("kiro API error: %s", errMsg)})
 			return
 		}
 
@@ -2673,7 +2674,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 			msgStart := kiroclaude.BuildClaudeMessageStartEvent(model, totalUsage.InputTokens)
 			sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, msgStart, &translatorParam)
 			for _, chunk := range sseData {
-				enqueueTranslatedSSE(out, chunk)
+				enqueueTranslatedSSE(ctx, out, chunk)
 			}
 			messageStartSent = true
 		}
@@ -2762,9 +2763,9 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 
 			// Send error to the stream and exit
 			if errMsg != "" {
-				out <- cliproxyexecutor.StreamChunk{
+				sendStreamChunk(ctx, out, cliproxyexecutor.StreamChunk{
 					Err: fmt.Errorf("kiro API error (%s): %s", errType, errMsg),
-				}
+				})
 				return
 			}
 
@@ -2923,7 +2924,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 						pingEvent := kiroclaude.BuildClaudePingEventWithUsage(totalUsage.InputTokens, currentOutputTokens)
 						sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, pingEvent, &translatorParam)
 						for _, chunk := range sseData {
-							enqueueTranslatedSSE(out, chunk)
+							enqueueTranslatedSSE(ctx, out, chunk)
 						}
 
 						lastReportedOutputTokens = currentOutputTokens
@@ -2944,13 +2945,13 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 							blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(contentBlockIndex, "text", "", "")
 							sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
 							for _, chunk := range sseData {
-								enqueueTranslatedSSE(out, chunk)
+								enqueueTranslatedSSE(ctx, out, chunk)
 							}
 						}
 						claudeEvent := kiroclaude.BuildClaudeStreamEvent(processText, contentBlockIndex)
 						sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, claudeEvent, &translatorParam)
 						for _, chunk := range sseData {
-							enqueueTranslatedSSE(out, chunk)
+							enqueueTranslatedSSE(ctx, out, chunk)
 						}
 					}
 					continue
@@ -2979,14 +2980,14 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 									blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(thinkingBlockIndex, "thinking", "", "")
 									sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
 									for _, chunk := range sseData {
-										enqueueTranslatedSSE(out, chunk)
+										enqueueTranslatedSSE(ctx, out, chunk)
 									}
 								}
 								// Send thinking delta
 								thinkingEvent := kiroclaude.BuildClaudeThinkingDeltaEvent(thinkingText, thinkingBlockIndex)
 								sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, thinkingEvent, &translatorParam)
 								for _, chunk := range sseData {
-									enqueueTranslatedSSE(out, chunk)
+									enqueueTranslatedSSE(ctx, out, chunk)
 								}
 								accumulatedThinkingContent.WriteString(thinkingText)
 							}
@@ -2995,7 +2996,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 								blockStop := kiroclaude.BuildClaudeThinkingBlockStopEvent(thinkingBlockIndex)
 								sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
 								for _, chunk := range sseData {
-									enqueueTranslatedSSE(out, chunk)
+									enqueueTranslatedSSE(ctx, out, chunk)
 								}
 								isThinkingBlockOpen = false
 							}
@@ -3024,13 +3025,13 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 										blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(thinkingBlockIndex, "thinking", "", "")
 										sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
 										for _, chunk := range sseData {
-											enqueueTranslatedSSE(out, chunk)
+											enqueueTranslatedSSE(ctx, out, chunk)
 										}
 									}
 									thinkingEvent := kiroclaude.BuildClaudeThinkingDeltaEvent(processContent, thinkingBlockIndex)
 									sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, thinkingEvent, &translatorParam)
 									for _, chunk := range sseData {
-										enqueueTranslatedSSE(out, chunk)
+										enqueueTranslatedSSE(ctx, out, chunk)
 									}
 									accumulatedThinkingContent.WriteString(processContent)
 								}
@@ -3049,7 +3050,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 									blockStop := kiroclaude.BuildClaudeThinkingBlockStopEvent(thinkingBlockIndex)
 									sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
 									for _, chunk := range sseData {
-										enqueueTranslatedSSE(out, chunk)
+										enqueueTranslatedSSE(ctx, out, chunk)
 									}
 									isThinkingBlockOpen = false
 								}
@@ -3060,14 +3061,14 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 									blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(contentBlockIndex, "text", "", "")
 									sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
 									for _, chunk := range sseData {
-										enqueueTranslatedSSE(out, chunk)
+										enqueueTranslatedSSE(ctx, out, chunk)
 									}
 								}
 								// Send text delta
 								claudeEvent := kiroclaude.BuildClaudeStreamEvent(textBefore, contentBlockIndex)
 								sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, claudeEvent, &translatorParam)
 								for _, chunk := range sseData {
-									enqueueTranslatedSSE(out, chunk)
+									enqueueTranslatedSSE(ctx, out, chunk)
 								}
 							}
 							// Close text block before entering thinking
@@ -3075,7 +3076,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 								blockStop := kiroclaude.BuildClaudeContentBlockStopEvent(contentBlockIndex)
 								sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
 								for _, chunk := range sseData {
-									enqueueTranslatedSSE(out, chunk)
+									enqueueTranslatedSSE(ctx, out, chunk)
 								}
 								isTextBlockOpen = false
 							}
@@ -3103,13 +3104,13 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 										blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(contentBlockIndex, "text", "", "")
 										sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
 										for _, chunk := range sseData {
-											enqueueTranslatedSSE(out, chunk)
+											enqueueTranslatedSSE(ctx, out, chunk)
 										}
 									}
 									claudeEvent := kiroclaude.BuildClaudeStreamEvent(processContent, contentBlockIndex)
 									sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, claudeEvent, &translatorParam)
 									for _, chunk := range sseData {
-										enqueueTranslatedSSE(out, chunk)
+										enqueueTranslatedSSE(ctx, out, chunk)
 									}
 								}
 							}
@@ -3137,7 +3138,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 					blockStop := kiroclaude.BuildClaudeContentBlockStopEvent(contentBlockIndex)
 					sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
 					for _, chunk := range sseData {
-						enqueueTranslatedSSE(out, chunk)
+						enqueueTranslatedSSE(ctx, out, chunk)
 					}
 					isTextBlockOpen = false
 				}
@@ -3148,7 +3149,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 				blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(contentBlockIndex, "tool_use", toolUseID, toolName)
 				sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
 				for _, chunk := range sseData {
-					enqueueTranslatedSSE(out, chunk)
+					enqueueTranslatedSSE(ctx, out, chunk)
 				}
 
 				// Send input_json_delta with the tool input
@@ -3161,7 +3162,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 						inputDelta := kiroclaude.BuildClaudeInputJsonDeltaEvent(string(inputJSON), contentBlockIndex)
 						sseData = sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, inputDelta, &translatorParam)
 						for _, chunk := range sseData {
-							enqueueTranslatedSSE(out, chunk)
+							enqueueTranslatedSSE(ctx, out, chunk)
 						}
 					}
 				}
@@ -3170,7 +3171,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 				blockStop := kiroclaude.BuildClaudeContentBlockStopEvent(contentBlockIndex)
 				sseData = sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
 				for _, chunk := range sseData {
-					enqueueTranslatedSSE(out, chunk)
+					enqueueTranslatedSSE(ctx, out, chunk)
 				}
 			}
 
@@ -3210,7 +3211,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 					blockStop := kiroclaude.BuildClaudeContentBlockStopEvent(contentBlockIndex)
 					sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
 					for _, chunk := range sseData {
-						enqueueTranslatedSSE(out, chunk)
+						enqueueTranslatedSSE(ctx, out, chunk)
 					}
 					isTextBlockOpen = false
 				}
@@ -3223,7 +3224,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 					blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(thinkingBlockIndex, "thinking", "", "")
 					sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
 					for _, chunk := range sseData {
-						enqueueTranslatedSSE(out, chunk)
+						enqueueTranslatedSSE(ctx, out, chunk)
 					}
 				}
 
@@ -3231,7 +3232,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 				thinkingEvent := kiroclaude.BuildClaudeThinkingDeltaEvent(thinkingText, thinkingBlockIndex)
 				sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, thinkingEvent, &translatorParam)
 				for _, chunk := range sseData {
-					enqueueTranslatedSSE(out, chunk)
+					enqueueTranslatedSSE(ctx, out, chunk)
 				}
 
 				// Accumulate for token counting
@@ -3263,7 +3264,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 					blockStop := kiroclaude.BuildClaudeContentBlockStopEvent(contentBlockIndex)
 					sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
 					for _, chunk := range sseData {
-						enqueueTranslatedSSE(out, chunk)
+						enqueueTranslatedSSE(ctx, out, chunk)
 					}
 					isTextBlockOpen = false
 				}
@@ -3273,7 +3274,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 				blockStart := kiroclaude.BuildClaudeContentBlockStartEvent(contentBlockIndex, "tool_use", tu.ToolUseID, tu.Name)
 				sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStart, &translatorParam)
 				for _, chunk := range sseData {
-					enqueueTranslatedSSE(out, chunk)
+					enqueueTranslatedSSE(ctx, out, chunk)
 				}
 
 				if tu.Input != nil {
@@ -3284,7 +3285,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 						inputDelta := kiroclaude.BuildClaudeInputJsonDeltaEvent(string(inputJSON), contentBlockIndex)
 						sseData = sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, inputDelta, &translatorParam)
 						for _, chunk := range sseData {
-							enqueueTranslatedSSE(out, chunk)
+							enqueueTranslatedSSE(ctx, out, chunk)
 						}
 					}
 				}
@@ -3292,7 +3293,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 				blockStop := kiroclaude.BuildClaudeContentBlockStopEvent(contentBlockIndex)
 				sseData = sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
 				for _, chunk := range sseData {
-					enqueueTranslatedSSE(out, chunk)
+					enqueueTranslatedSSE(ctx, out, chunk)
 				}
 			}
 
@@ -3479,7 +3480,7 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 		blockStop := kiroclaude.BuildClaudeContentBlockStopEvent(contentBlockIndex)
 		sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, blockStop, &translatorParam)
 		for _, chunk := range sseData {
-			enqueueTranslatedSSE(out, chunk)
+			enqueueTranslatedSSE(ctx, out, chunk)
 		}
 	}
 
@@ -3564,14 +3565,14 @@ func (e *KiroExecutor) streamToChannel(ctx context.Context, body io.Reader, out
 	msgDelta := kiroclaude.BuildClaudeMessageDeltaEvent(stopReason, totalUsage)
 	sseData := sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, msgDelta, &translatorParam)
 	for _, chunk := range sseData {
-		enqueueTranslatedSSE(out, chunk)
+		enqueueTranslatedSSE(ctx, out, chunk)
 	}
 
 	// Send message_stop event separately
 	msgStop := kiroclaude.BuildClaudeMessageStopOnlyEvent()
 	sseData = sdktranslator.TranslateStream(ctx, sdktranslator.FromString("kiro"), targetFormat, model, originalReq, claudeBody, msgStop, &translatorParam)
 	for _, chunk := range sseData {
-		enqueueTranslatedSSE(out, chunk)
+		enqueueTranslatedSSE(ctx, out, chunk)
 	}
 	// reporter.publish is called via defer
 }
@@ -4330,6 +4331,7 @@ func (e *KiroExecutor) handleWebSearchStream(
 		var wsErr error
 		defer reporter.trackFailure(ctx, &wsErr)
 		defer close(out)
+		defer helps.RecoverStreamPanic(ctx, out, e.Identifier())
 
 		// Estimate input tokens using tokenizer (matching streamToChannel pattern)
 		var totalUsage usage.Detail
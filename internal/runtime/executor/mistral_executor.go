@@ -1,9 +1,9 @@
 package executor
 
 import (
-	"bufio"
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -23,8 +23,9 @@ import (
 )
 
 const (
-	mistralDefaultBaseURL = "https://api.mistral.ai"
-	mistralChatEndpoint   = "/v1/chat/completions"
+	mistralDefaultBaseURL     = "https://api.mistral.ai"
+	mistralChatEndpoint       = "/v1/chat/completions"
+	mistralEmbeddingsEndpoint = "/v1/embeddings"
 )
 
 type MistralExecutor struct {
@@ -216,26 +217,35 @@ func (e *MistralExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.
 	out := make(chan cliproxyexecutor.StreamChunk)
 	go func() {
 		defer close(out)
+		defer helps.RecoverStreamPanic(ctx, out, e.Identifier())
 		defer func() {
 			if errClose := httpResp.Body.Close(); errClose != nil {
 				log.Errorf("mistral executor: close response body error: %v", errClose)
 			}
 		}()
 
-		scanner := bufio.NewScanner(httpResp.Body)
-		scanner.Buffer(nil, 52_428_800)
+		reader := helps.NewSSEReader(e.cfg, httpResp.Body)
 		var param any
-		for scanner.Scan() {
-			line := scanner.Bytes()
-			trimmed := bytes.TrimSpace(line)
-			if len(trimmed) == 0 {
-				continue
+		for {
+			event, errRead := reader.Next()
+			if errors.Is(errRead, io.EOF) {
+				break
 			}
-			helps.AppendAPIResponseChunk(ctx, e.cfg, trimmed)
-			if detail, ok := helps.ParseOpenAIStreamUsage(trimmed); ok {
+			if errRead != nil {
+				helps.RecordAPIResponseError(ctx, e.cfg, errRead)
+				reporter.PublishFailure(ctx, errRead)
+				select {
+				case out <- cliproxyexecutor.StreamChunk{Err: errRead}:
+				case <-ctx.Done():
+				}
+				return
+			}
+			helps.AppendAPIResponseChunk(ctx, e.cfg, event.Raw)
+			line := event.DataLine()
+			if detail, ok := helps.ParseOpenAIStreamUsage(line); ok {
 				reporter.Publish(ctx, detail)
 			}
-			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, bytes.Clone(trimmed), &param)
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, line, &param)
 			for i := range chunks {
 				select {
 				case out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}:
@@ -244,15 +254,6 @@ func (e *MistralExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.
 				}
 			}
 		}
-		if errScan := scanner.Err(); errScan != nil {
-			helps.RecordAPIResponseError(ctx, e.cfg, errScan)
-			reporter.PublishFailure(ctx, errScan)
-			select {
-			case out <- cliproxyexecutor.StreamChunk{Err: errScan}:
-			case <-ctx.Done():
-			}
-			return
-		}
 		reporter.EnsurePublished(ctx)
 	}()
 
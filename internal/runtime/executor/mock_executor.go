@@ -0,0 +1,251 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/sjson"
+)
+
+// MockProviderKey is the provider identifier of the scripted mock executor.
+const MockProviderKey = "mock"
+
+// MockExecutor answers requests from the scripts in mock-provider instead of a real
+// upstream. Responses are produced in OpenAI chat completion format and translated to
+// the caller's format, so every ingress endpoint can be exercised.
+type MockExecutor struct {
+	cfg *config.Config
+
+	mu    sync.Mutex
+	calls map[string]int
+}
+
+// NewMockExecutor creates the mock executor.
+func NewMockExecutor(cfg *config.Config) *MockExecutor {
+	return &MockExecutor{cfg: cfg, calls: make(map[string]int)}
+}
+
+func (e *MockExecutor) Identifier() string { return MockProviderKey }
+
+// nextStep returns the next scripted step for model and advances its script.
+func (e *MockExecutor) nextStep(model string) config.MockStep {
+	if e.cfg == nil {
+		return config.MockStep{}
+	}
+	for _, entry := range e.cfg.MockProvider.Models {
+		if !strings.EqualFold(strings.TrimSpace(entry.Name), model) || len(entry.Script) == 0 {
+			continue
+		}
+		e.mu.Lock()
+		idx := e.calls[model] % len(entry.Script)
+		e.calls[model]++
+		e.mu.Unlock()
+		return entry.Script[idx]
+	}
+	return config.MockStep{}
+}
+
+func mockStepError(step config.MockStep) error {
+	if step.Status == 0 || (step.Status >= 200 && step.Status < 300) {
+		return nil
+	}
+	message := strings.TrimSpace(step.Message)
+	if message == "" {
+		message = http.StatusText(step.Status)
+	}
+	err := statusErr{code: step.Status, msg: fmt.Sprintf(`{"error":{"message":%q,"type":"mock_error"}}`, message)}
+	if step.RetryAfterSeconds > 0 {
+		retryAfter := time.Duration(step.RetryAfterSeconds) * time.Second
+		err.retryAfter = &retryAfter
+	}
+	return err
+}
+
+func mockStepText(step config.MockStep, model string) string {
+	if text := step.Message; text != "" {
+		return text
+	}
+	return "mock response from " + model
+}
+
+// mockSleep waits for d or until ctx is done.
+func mockSleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (e *MockExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	step := e.nextStep(baseModel)
+	if err = mockSleep(ctx, time.Duration(step.LatencyMs)*time.Millisecond); err != nil {
+		return resp, err
+	}
+	if err = mockStepError(step); err != nil {
+		return resp, err
+	}
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, bytes.Clone(req.Payload), false)
+
+	text := mockStepText(step, baseModel)
+	body := []byte(`{"id":"chatcmpl-mock","object":"chat.completion","created":0,"model":"","choices":[{"index":0,"message":{"role":"assistant","content":""},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`)
+	body, _ = sjson.SetBytes(body, "created", time.Now().Unix())
+	body, _ = sjson.SetBytes(body, "model", baseModel)
+	body, _ = sjson.SetBytes(body, "choices.0.message.content", text)
+	reporter.Publish(ctx, usage.Detail{InputTokens: 1, OutputTokens: 1, TotalTokens: 2})
+
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, body, &param)
+	return cliproxyexecutor.Response{Payload: out, Headers: http.Header{"X-Mock-Provider": {"true"}}}, nil
+}
+
+func (e *MockExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	step := e.nextStep(baseModel)
+	if err = mockStepError(step); err != nil {
+		if errSleep := mockSleep(ctx, time.Duration(step.LatencyMs)*time.Millisecond); errSleep != nil {
+			return nil, errSleep
+		}
+		return nil, err
+	}
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, bytes.Clone(req.Payload), true)
+
+	chunkCount := step.Chunks
+	if chunkCount <= 0 {
+		chunkCount = 1
+	}
+	parts := splitMockText(mockStepText(step, baseModel), chunkCount)
+	created := time.Now().Unix()
+
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer helps.RecoverStreamPanic(ctx, out, e.Identifier())
+		var param any
+		emit := func(line []byte) bool {
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, line, &param)
+			for i := range chunks {
+				select {
+				case out <- cliproxyexecutor.StreamChunk{Payload: chunks[i]}:
+				case <-ctx.Done():
+					return false
+				}
+			}
+			return true
+		}
+		if errSleep := mockSleep(ctx, time.Duration(step.LatencyMs)*time.Millisecond); errSleep != nil {
+			return
+		}
+		for i, part := range parts {
+			if step.FailAfterChunks > 0 && i >= step.FailAfterChunks {
+				errStream := statusErr{code: http.StatusBadGateway, msg: "mock stream interrupted"}
+				reporter.PublishFailure(ctx, errStream)
+				select {
+				case out <- cliproxyexecutor.StreamChunk{Err: errStream}:
+				case <-ctx.Done():
+				}
+				return
+			}
+			if i > 0 {
+				if errSleep := mockSleep(ctx, time.Duration(step.ChunkDelayMs)*time.Millisecond); errSleep != nil {
+					return
+				}
+			}
+			if !emit(mockStreamChunk(baseModel, created, part, "")) {
+				return
+			}
+		}
+		if !emit(mockStreamChunk(baseModel, created, "", "stop")) {
+			return
+		}
+		reporter.Publish(ctx, usage.Detail{InputTokens: 1, OutputTokens: int64(len(parts)), TotalTokens: 1 + int64(len(parts))})
+		emit([]byte("data: [DONE]"))
+	}()
+
+	return &cliproxyexecutor.StreamResult{Headers: http.Header{"X-Mock-Provider": {"true"}}, Chunks: out}, nil
+}
+
+func mockStreamChunk(model string, created int64, content, finishReason string) []byte {
+	chunk := []byte(`{"id":"chatcmpl-mock","object":"chat.completion.chunk","created":0,"model":"","choices":[{"index":0,"delta":{},"finish_reason":null}]}`)
+	chunk, _ = sjson.SetBytes(chunk, "created", created)
+	chunk, _ = sjson.SetBytes(chunk, "model", model)
+	if content != "" {
+		chunk, _ = sjson.SetBytes(chunk, "choices.0.delta.content", content)
+	}
+	if finishReason != "" {
+		chunk, _ = sjson.SetBytes(chunk, "choices.0.finish_reason", finishReason)
+	}
+	return append([]byte("data: "), chunk...)
+}
+
+// splitMockText splits text into n roughly equal parts on rune boundaries.
+func splitMockText(text string, n int) []string {
+	runes := []rune(text)
+	if n > len(runes) {
+		n = len(runes)
+	}
+	if n <= 1 {
+		return []string{text}
+	}
+	parts := make([]string, 0, n)
+	size := (len(runes) + n - 1) / n
+	for start := 0; start < len(runes); start += size {
+		end := min(start+size, len(runes))
+		parts = append(parts, string(runes[start:end]))
+	}
+	return parts
+}
+
+func (e *MockExecutor) CountTokens(ctx context.Context, _ *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	step := e.nextStep(thinking.ParseSuffix(req.Model).ModelName)
+	if err := mockSleep(ctx, time.Duration(step.LatencyMs)*time.Millisecond); err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	if err := mockStepError(step); err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	// A rough four-bytes-per-token estimate keeps the mock free of tokenizer setup.
+	count := int64(len(req.Payload) / 4)
+	usageJSON := helps.BuildOpenAIUsageJSON(count)
+	out := sdktranslator.TranslateTokenCount(ctx, sdktranslator.FromString("openai"), cliproxyexecutor.ResponseFormatOrSource(opts), count, usageJSON)
+	return cliproxyexecutor.Response{Payload: out}, nil
+}
+
+func (e *MockExecutor) Refresh(_ context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *MockExecutor) HttpRequest(_ context.Context, _ *cliproxyauth.Auth, _ *http.Request) (*http.Response, error) {
+	return nil, statusErr{code: http.StatusNotImplemented, msg: "mock executor: raw HTTP requests are not supported"}
+}
@@ -0,0 +1,108 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func newTestMockExecutor(models ...config.MockModel) *MockExecutor {
+	return NewMockExecutor(&config.Config{MockProvider: config.MockProviderConfig{Enabled: true, Models: models}})
+}
+
+func mockTestRequest(model string) (cliproxyexecutor.Request, cliproxyexecutor.Options) {
+	payload := []byte(`{"model":"` + model + `","messages":[{"role":"user","content":"hi"}]}`)
+	return cliproxyexecutor.Request{Model: model, Payload: payload},
+		cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), OriginalRequest: payload}
+}
+
+func TestMockExecutorExecuteFollowsScript(t *testing.T) {
+	exec := newTestMockExecutor(config.MockModel{
+		Name: "mock-flaky",
+		Script: []config.MockStep{
+			{Status: http.StatusTooManyRequests, RetryAfterSeconds: 30, Message: "slow down"},
+			{Message: "hello"},
+		},
+	})
+	auth := &cliproxyauth.Auth{ID: "mock-1", Provider: MockProviderKey}
+	req, opts := mockTestRequest("mock-flaky")
+
+	_, err := exec.Execute(context.Background(), auth, req, opts)
+	var se statusErr
+	if !errors.As(err, &se) {
+		t.Fatalf("first call error = %v, want statusErr", err)
+	}
+	if se.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", se.StatusCode(), http.StatusTooManyRequests)
+	}
+	if ra := se.RetryAfter(); ra == nil || *ra != 30*time.Second {
+		t.Fatalf("retry after = %v, want 30s", ra)
+	}
+	if got := gjson.Get(se.Error(), "error.message").String(); got != "slow down" {
+		t.Fatalf("error message = %q, want %q", got, "slow down")
+	}
+
+	resp, err := exec.Execute(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("second call error = %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "hello" {
+		t.Fatalf("content = %q, want %q", got, "hello")
+	}
+
+	// The script starts over once exhausted.
+	if _, err = exec.Execute(context.Background(), auth, req, opts); err == nil {
+		t.Fatal("third call succeeded, want scripted 429")
+	}
+}
+
+func TestMockExecutorExecuteStreamFailsAfterChunks(t *testing.T) {
+	exec := newTestMockExecutor(config.MockModel{
+		Name:   "mock-stream",
+		Script: []config.MockStep{{Message: "abcdef", Chunks: 3, FailAfterChunks: 2}},
+	})
+	req, opts := mockTestRequest("mock-stream")
+
+	result, err := exec.ExecuteStream(context.Background(), &cliproxyauth.Auth{ID: "mock-1"}, req, opts)
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	var content strings.Builder
+	var streamErr error
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			streamErr = chunk.Err
+			continue
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(string(chunk.Payload), "data:"))
+		content.WriteString(gjson.Get(data, "choices.0.delta.content").String())
+	}
+	if content.String() != "abcd" {
+		t.Fatalf("streamed content = %q, want %q", content.String(), "abcd")
+	}
+	if streamErr == nil {
+		t.Fatal("stream ended without the scripted error")
+	}
+}
+
+func TestMockExecutorUnscriptedModelSucceeds(t *testing.T) {
+	exec := newTestMockExecutor()
+	req, opts := mockTestRequest("anything")
+
+	resp, err := exec.Execute(context.Background(), &cliproxyauth.Auth{ID: "mock-1"}, req, opts)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "mock response from anything" {
+		t.Fatalf("content = %q", got)
+	}
+}
@@ -0,0 +1,74 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestOpenAICompatCanPassthrough(t *testing.T) {
+	openai := sdktranslator.FromString("openai")
+	claude := sdktranslator.FromString("claude")
+
+	executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{})
+	if !executor.canPassthrough(openai, openai, openai, "/v1/chat/completions") {
+		t.Fatal("OpenAI to OpenAI without payload rules should pass through")
+	}
+	if executor.canPassthrough(claude, claude, openai, "/v1/messages") {
+		t.Fatal("Claude ingress must be translated")
+	}
+	if executor.canPassthrough(openai, claude, openai, "/v1/chat/completions") {
+		t.Fatal("a different response format must be translated")
+	}
+
+	withRules := &config.Config{}
+	withRules.Payload.Override = []config.PayloadRule{{Params: map[string]any{"temperature": 0}}}
+	if NewOpenAICompatExecutor("openai-compatibility", withRules).canPassthrough(openai, openai, openai, "/v1/chat/completions") {
+		t.Fatal("payload rules must disable passthrough")
+	}
+}
+
+func TestOpenAICompatExecuteStreamPassthroughForwardsChunks(t *testing.T) {
+	var upstreamModel string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		upstreamModel = gjson.GetBytes(body, "model").String()
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hi\"}}]}\n\n"))
+		_, _ = w.Write([]byte("data: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "test", "base_url": server.URL + "/v1"}}
+	result, err := executor.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+		Model:   "gpt-4o",
+		Payload: []byte(`{"model":"alias/gpt-4o","messages":[{"role":"user","content":"hi"}],"stream":true}`),
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream() error = %v", err)
+	}
+	var payloads []string
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error = %v", chunk.Err)
+		}
+		payloads = append(payloads, string(chunk.Payload))
+	}
+	if upstreamModel != "gpt-4o" {
+		t.Fatalf("upstream model = %q, want gpt-4o", upstreamModel)
+	}
+	want := `{"id":"c1","choices":[{"index":0,"delta":{"content":"hi"}}]}`
+	if len(payloads) != 1 || payloads[0] != want {
+		t.Fatalf("payloads = %q, want [%s]", payloads, want)
+	}
+}
@@ -0,0 +1,219 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultPromptCacheMinTokens = 1024
+	defaultGeminiCacheTTL       = 5 * time.Minute
+
+	// promptCacheCharsPerToken approximates token counts from JSON size without a tokenizer.
+	promptCacheCharsPerToken = 4
+)
+
+func promptCacheHintsEnabled(cfg *config.Config) bool {
+	return cfg != nil && cfg.PromptCacheHints.Enabled
+}
+
+func promptCacheMinChars(cfg *config.Config) int {
+	tokens := cfg.PromptCacheHints.MinPrefixTokens
+	if tokens <= 0 {
+		tokens = defaultPromptCacheMinTokens
+	}
+	return tokens * promptCacheCharsPerToken
+}
+
+func geminiPromptCacheTTL(cfg *config.Config) time.Duration {
+	raw := strings.TrimSpace(cfg.PromptCacheHints.GeminiCacheTTL)
+	if raw == "" {
+		return defaultGeminiCacheTTL
+	}
+	ttl, errParse := time.ParseDuration(raw)
+	if errParse != nil || ttl <= 0 {
+		return defaultGeminiCacheTTL
+	}
+	return ttl
+}
+
+// promptCacheScope limits prefix matching to one provider, credential and model, since
+// provider caches are not shared across accounts or models.
+func promptCacheScope(provider string, auth *cliproxyauth.Auth, model string) string {
+	authID := ""
+	if auth != nil {
+		authID = auth.ID
+	}
+	return provider + "\x00" + authID + "\x00" + model
+}
+
+func promptPrefixChars(blocks [][]byte, n int) int {
+	total := 0
+	for _, block := range blocks[:n] {
+		total += len(block)
+	}
+	return total
+}
+
+// applyClaudePromptCacheHint marks the end of the longest message prefix shared with an
+// earlier request as a cache breakpoint, so repeated evaluation and agent loops read the
+// prefix from Anthropic's cache even when the client sends its own breakpoints elsewhere.
+func applyClaudePromptCacheHint(cfg *config.Config, auth *cliproxyauth.Auth, model string, payload []byte) []byte {
+	if !promptCacheHintsEnabled(cfg) {
+		return payload
+	}
+	messages := gjson.GetBytes(payload, "messages")
+	if !messages.IsArray() {
+		return payload
+	}
+	blocks := [][]byte{[]byte(gjson.GetBytes(payload, "tools").Raw), []byte(gjson.GetBytes(payload, "system").Raw)}
+	messages.ForEach(func(_, msg gjson.Result) bool {
+		blocks = append(blocks, []byte(msg.Raw))
+		return true
+	})
+
+	shared := cache.SharedPromptPrefix(promptCacheScope("claude", auth, model), blocks)
+	lastShared := shared - 3
+	if lastShared < 0 || promptPrefixChars(blocks, shared) < promptCacheMinChars(cfg) {
+		return payload
+	}
+	hasBreakpoint := false
+	gjson.GetBytes(payload, fmt.Sprintf("messages.%d.content", lastShared)).ForEach(func(_, item gjson.Result) bool {
+		hasBreakpoint = item.Get("cache_control").Exists()
+		return !hasBreakpoint
+	})
+	if hasBreakpoint {
+		return payload
+	}
+	cache.RecordPromptCacheHint("claude", int64(promptPrefixChars(blocks, shared)/promptCacheCharsPerToken))
+	return setMessageCacheControl(payload, lastShared)
+}
+
+// applyGeminiPromptCacheHint moves the longest contents prefix shared with an earlier request
+// into a cachedContents resource and references it from the request. The system instruction
+// and tools move with it, as Gemini rejects them alongside cachedContent. Any failure leaves
+// the request unchanged.
+func (e *GeminiExecutor) applyGeminiPromptCacheHint(ctx context.Context, auth *cliproxyauth.Auth, apiKey, model string, payload []byte) []byte {
+	if !promptCacheHintsEnabled(e.cfg) || apiKey == "" || gjson.GetBytes(payload, "cachedContent").Exists() {
+		return payload
+	}
+	contents := gjson.GetBytes(payload, "contents")
+	if !contents.IsArray() {
+		return payload
+	}
+	systemInstruction := gjson.GetBytes(payload, "systemInstruction")
+	if !systemInstruction.Exists() {
+		systemInstruction = gjson.GetBytes(payload, "system_instruction")
+	}
+	tools := gjson.GetBytes(payload, "tools")
+	toolConfig := gjson.GetBytes(payload, "toolConfig")
+	blocks := [][]byte{[]byte(systemInstruction.Raw + tools.Raw + toolConfig.Raw)}
+	contents.ForEach(func(_, item gjson.Result) bool {
+		blocks = append(blocks, []byte(item.Raw))
+		return true
+	})
+
+	scope := promptCacheScope("gemini", auth, model)
+	shared := cache.SharedPromptPrefix(scope, blocks)
+	cachedCount := shared - 1
+	if cachedCount < 1 || cachedCount >= len(blocks)-1 || promptPrefixChars(blocks, shared) < promptCacheMinChars(e.cfg) {
+		return payload
+	}
+
+	key := cache.PromptPrefixKey(scope, blocks, shared)
+	name, ok := cache.GetGeminiCachedContent(key)
+	if !ok {
+		ttl := geminiPromptCacheTTL(e.cfg)
+		created, errCreate := e.createGeminiCachedContent(ctx, auth, apiKey, model, payload, cachedCount, ttl)
+		if errCreate != nil {
+			helps.LogWithRequestID(ctx).Debugf("gemini prompt cache: %v", errCreate)
+		}
+		// Failed attempts are remembered too, so a prefix below the provider minimum is not retried every request.
+		cache.SetGeminiCachedContent(key, created, time.Now().Add(ttl))
+		name = created
+	}
+	if name == "" {
+		return payload
+	}
+
+	remaining := make([]byte, 0, len(contents.Raw))
+	remaining = append(remaining, '[')
+	for i, item := range contents.Array()[cachedCount:] {
+		if i > 0 {
+			remaining = append(remaining, ',')
+		}
+		remaining = append(remaining, item.Raw...)
+	}
+	remaining = append(remaining, ']')
+	out, errSet := sjson.SetRawBytes(payload, "contents", remaining)
+	if errSet != nil {
+		return payload
+	}
+	out, _ = sjson.SetBytes(out, "cachedContent", name)
+	for _, field := range []string{"systemInstruction", "system_instruction", "tools", "toolConfig"} {
+		out, _ = sjson.DeleteBytes(out, field)
+	}
+	cache.RecordPromptCacheHint("gemini", int64(promptPrefixChars(blocks, shared)/promptCacheCharsPerToken))
+	return out
+}
+
+// createGeminiCachedContent creates a cachedContents resource holding the first count
+// contents plus the system instruction and tools, returning its resource name.
+func (e *GeminiExecutor) createGeminiCachedContent(ctx context.Context, auth *cliproxyauth.Auth, apiKey, model string, payload []byte, count int, ttl time.Duration) (string, error) {
+	body := []byte(`{}`)
+	body, _ = sjson.SetBytes(body, "model", "models/"+model)
+	prefix := []byte(`[]`)
+	for _, item := range gjson.GetBytes(payload, "contents").Array()[:count] {
+		prefix, _ = sjson.SetRawBytes(prefix, "-1", []byte(item.Raw))
+	}
+	body, _ = sjson.SetRawBytes(body, "contents", prefix)
+	for _, field := range []string{"systemInstruction", "system_instruction", "tools", "toolConfig"} {
+		if value := gjson.GetBytes(payload, field); value.Exists() {
+			target := field
+			if field == "system_instruction" {
+				target = "systemInstruction"
+			}
+			body, _ = sjson.SetRawBytes(body, target, []byte(value.Raw))
+		}
+	}
+	body, _ = sjson.SetBytes(body, "ttl", fmt.Sprintf("%ds", int(ttl.Seconds())))
+
+	url := fmt.Sprintf("%s/%s/cachedContents", resolveGeminiBaseURL(auth), glAPIVersion)
+	httpReq, errReq := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if errReq != nil {
+		return "", errReq
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-goog-api-key", apiKey)
+	applyGeminiHeaders(httpReq, auth)
+
+	httpResp, errDo := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0).Do(httpReq)
+	if errDo != nil {
+		return "", fmt.Errorf("create cached content: %w", errDo)
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+	data, errRead := io.ReadAll(httpResp.Body)
+	if errRead != nil {
+		return "", fmt.Errorf("create cached content: %w", errRead)
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return "", fmt.Errorf("create cached content: status %d: %s", httpResp.StatusCode, helps.SummarizeErrorBody(httpResp.Header.Get("Content-Type"), data))
+	}
+	name := gjson.GetBytes(data, "name").String()
+	if name == "" {
+		return "", fmt.Errorf("create cached content: response has no name")
+	}
+	return name, nil
+}
@@ -0,0 +1,76 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+)
+
+func promptCacheHintsTestConfig() *config.Config {
+	return &config.Config{PromptCacheHints: config.PromptCacheHintsConfig{Enabled: true, MinPrefixTokens: 10}}
+}
+
+func TestApplyClaudePromptCacheHintMarksSharedPrefix(t *testing.T) {
+	cfg := promptCacheHintsTestConfig()
+	auth := &cliproxyauth.Auth{ID: "claude-prefix-test"}
+	long := strings.Repeat("shared context ", 10)
+	first := []byte(`{"system":"sys","messages":[{"role":"user","content":"` + long + `"},{"role":"assistant","content":"ok"},{"role":"user","content":"q1"}]}`)
+	second := []byte(`{"system":"sys","messages":[{"role":"user","content":"` + long + `"},{"role":"assistant","content":"ok"},{"role":"user","content":"q2"}]}`)
+
+	if out := applyClaudePromptCacheHint(cfg, auth, "claude-test", first); countCacheControls(out) != 0 {
+		t.Fatalf("first request should not be hinted: %s", out)
+	}
+	out := applyClaudePromptCacheHint(cfg, auth, "claude-test", second)
+	if !gjson.GetBytes(out, "messages.1.content.0.cache_control").Exists() {
+		t.Fatalf("expected breakpoint on last shared message, got %s", out)
+	}
+	if gjson.GetBytes(out, "messages.2.content.0.cache_control").Exists() {
+		t.Fatalf("new message must not be marked: %s", out)
+	}
+}
+
+func TestApplyGeminiPromptCacheHintUsesCachedContent(t *testing.T) {
+	var creates atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.HasSuffix(r.URL.Path, "/cachedContents") {
+			creates.Add(1)
+			if gjson.GetBytes(body, "contents.#").Int() != 2 || !gjson.GetBytes(body, "systemInstruction").Exists() {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"name":"cachedContents/abc"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"candidates":[{"content":{"role":"model","parts":[{"text":"ok"}]}}]}`))
+	}))
+	defer server.Close()
+
+	e := NewGeminiExecutor(promptCacheHintsTestConfig())
+	auth := &cliproxyauth.Auth{ID: "gemini-prefix-test", Attributes: map[string]string{"api_key": "k", "base_url": server.URL}}
+	long := strings.Repeat("shared context ", 10)
+	payload := func(question string) []byte {
+		return []byte(`{"systemInstruction":{"parts":[{"text":"sys"}]},"contents":[{"role":"user","parts":[{"text":"` + long + `"}]},{"role":"model","parts":[{"text":"ok"}]},{"role":"user","parts":[{"text":"` + question + `"}]}]}`)
+	}
+
+	e.applyGeminiPromptCacheHint(context.Background(), auth, "k", "gemini-test", payload("q1"))
+	out := e.applyGeminiPromptCacheHint(context.Background(), auth, "k", "gemini-test", payload("q2"))
+	if got := gjson.GetBytes(out, "cachedContent").String(); got != "cachedContents/abc" {
+		t.Fatalf("cachedContent = %q, body %s", got, out)
+	}
+	if gjson.GetBytes(out, "contents.#").Int() != 1 || gjson.GetBytes(out, "systemInstruction").Exists() {
+		t.Fatalf("cached prefix not removed from request: %s", out)
+	}
+	e.applyGeminiPromptCacheHint(context.Background(), auth, "k", "gemini-test", payload("q3"))
+	if creates.Load() != 1 {
+		t.Fatalf("cachedContents created %d times, want 1", creates.Load())
+	}
+}
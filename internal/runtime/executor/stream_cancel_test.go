@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+)
+
+type streamExecutor interface {
+	ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error)
+}
+
+// TestExecuteStreamCancelsUpstreamOnClientDisconnect checks that cancelling the request
+// context tears down the upstream HTTP request and closes the chunk channel promptly.
+func TestExecuteStreamCancelsUpstreamOnClientDisconnect(t *testing.T) {
+	cases := []struct {
+		name     string
+		executor func() streamExecutor
+		baseURL  func(string) string
+		model    string
+		source   string
+		payload  string
+		event    string
+	}{
+		{
+			name:     "claude",
+			executor: func() streamExecutor { return NewClaudeExecutor(&config.Config{}) },
+			model:    "claude-3-5-sonnet-20241022",
+			source:   "claude",
+			payload:  `{"messages":[{"role":"user","content":"hi"}]}`,
+			event:    "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"hi\"}}\n\n",
+		},
+		{
+			name:     "gemini",
+			executor: func() streamExecutor { return NewGeminiExecutor(&config.Config{}) },
+			model:    "gemini-2.5-flash",
+			source:   "gemini",
+			payload:  `{"contents":[{"role":"user","parts":[{"text":"hi"}]}]}`,
+			event:    "data: {\"candidates\":[{\"content\":{\"role\":\"model\",\"parts\":[{\"text\":\"hi\"}]}}]}\n\n",
+		},
+		{
+			name:     "codex",
+			executor: func() streamExecutor { return NewCodexExecutor(&config.Config{}) },
+			model:    "gpt-5",
+			source:   "openai-response",
+			payload:  `{"model":"gpt-5","input":"hi"}`,
+			event:    "data: {\"type\":\"response.output_text.delta\",\"delta\":\"hi\"}\n\n",
+		},
+		{
+			name:     "openai-compatibility",
+			executor: func() streamExecutor { return NewOpenAICompatExecutor("openai-compatibility", &config.Config{}) },
+			baseURL:  func(url string) string { return url + "/v1" },
+			model:    "gpt-4o",
+			source:   "openai",
+			payload:  `{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`,
+			event:    "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hi\"}}]}\n\n",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			started := make(chan struct{})
+			upstreamClosed := make(chan struct{})
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = w.Write([]byte(tc.event))
+				w.(http.Flusher).Flush()
+				close(started)
+				<-r.Context().Done()
+				close(upstreamClosed)
+			}))
+			defer server.Close()
+
+			baseURL := server.URL
+			if tc.baseURL != nil {
+				baseURL = tc.baseURL(server.URL)
+			}
+			auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "test", "base_url": baseURL}}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			result, err := tc.executor().ExecuteStream(ctx, auth, cliproxyexecutor.Request{
+				Model:   tc.model,
+				Payload: []byte(tc.payload),
+			}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString(tc.source), Stream: true})
+			if err != nil {
+				t.Fatalf("ExecuteStream() error = %v", err)
+			}
+			<-started
+			cancel()
+
+			select {
+			case <-upstreamClosed:
+			case <-time.After(2 * time.Second):
+				t.Fatal("upstream request was not cancelled")
+			}
+			// Nobody reads chunks after the client left; the channel must still close.
+			deadline := time.After(2 * time.Second)
+			for {
+				select {
+				case _, ok := <-result.Chunks:
+					if !ok {
+						return
+					}
+				case <-deadline:
+					t.Fatal("stream channel was not closed after cancellation")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+)
+
+// TestExecuteStreamOversizedEventEndsWithErrorChunk checks that an upstream event above
+// streaming.max-event-bytes ends the stream with a descriptive error chunk.
+func TestExecuteStreamOversizedEventEndsWithErrorChunk(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Streaming.MaxEventBytes = 4096
+	cases := []struct {
+		name     string
+		executor streamExecutor
+		baseURL  func(string) string
+	}{
+		{name: "openai-compatibility (scanner)", executor: NewOpenAICompatExecutor("openai-compatibility", cfg), baseURL: func(url string) string { return url + "/v1" }},
+		{name: "kilo (event reader)", executor: NewKiloExecutor(cfg)},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = w.Write([]byte("data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"hi\"}}]}\n\n"))
+				_, _ = w.Write([]byte("data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"" + strings.Repeat("x", 16<<10) + "\"}}]}\n\n"))
+			}))
+			defer server.Close()
+
+			baseURL := server.URL
+			if tc.baseURL != nil {
+				baseURL = tc.baseURL(server.URL)
+			}
+			auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "test", "base_url": baseURL}, Metadata: map[string]any{"token": "test"}}
+			result, err := tc.executor.ExecuteStream(context.Background(), auth, cliproxyexecutor.Request{
+				Model:   "gpt-4o",
+				Payload: []byte(`{"model":"gpt-4o","messages":[{"role":"user","content":"hi"}]}`),
+			}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai"), Stream: true})
+			if err != nil {
+				t.Fatalf("ExecuteStream() error = %v", err)
+			}
+			var payloads int
+			var streamErr error
+			for chunk := range result.Chunks {
+				if chunk.Err != nil {
+					streamErr = chunk.Err
+					continue
+				}
+				payloads++
+			}
+			var tooLarge *helps.StreamEventTooLargeError
+			if payloads == 0 || !errors.As(streamErr, &tooLarge) {
+				t.Fatalf("payloads=%d err=%v, want data followed by StreamEventTooLargeError", payloads, streamErr)
+			}
+		})
+	}
+}
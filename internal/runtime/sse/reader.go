@@ -0,0 +1,226 @@
+// Package sse reads Server-Sent Events streams returned by upstream providers.
+//
+// The reader follows the WHATWG event-stream format (LF, CRLF and CR line endings,
+// comments, multi-line data, the optional space after the colon) and tolerates the
+// deviations seen in practice: streams ending without a trailing blank line and providers
+// that emit one JSON document per data line without separating the events.
+package sse
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+)
+
+// DefaultMaxEventSize bounds a single event when no other limit is set.
+const DefaultMaxEventSize = 50 << 20
+
+// ErrEventTooLarge is returned when an event exceeds the reader's maximum size.
+var ErrEventTooLarge = errors.New("sse: event exceeds the maximum size")
+
+var doneMarker = []byte("[DONE]")
+
+// Event is one dispatched event.
+type Event struct {
+	// Name is the event field; empty for the default "message" type.
+	Name string
+	// Data is the event data; multiple data lines are joined with "\n".
+	Data []byte
+	// ID is the last id field seen in the event.
+	ID string
+	// Raw is the event as received with LF line endings and without the trailing blank line.
+	Raw []byte
+}
+
+// Done reports whether the event is the OpenAI-style "[DONE]" terminator.
+func (e Event) Done() bool {
+	return bytes.Equal(bytes.TrimSpace(e.Data), doneMarker)
+}
+
+// DataLine returns the data as a single "data: ..." line, the form translators consume.
+func (e Event) DataLine() []byte {
+	line := make([]byte, 0, len(e.Data)+6)
+	line = append(line, "data: "...)
+	return append(line, e.Data...)
+}
+
+// Reader parses events from an io.Reader. It is not safe for concurrent use.
+type Reader struct {
+	br      *bufio.Reader
+	maxSize int
+
+	pending [][]byte
+	line    []byte
+	// skipLF drops the LF of a CRLF pair split across reads.
+	skipLF bool
+	eof    bool
+
+	name    string
+	id      string
+	data    []byte
+	hasData bool
+	raw     []byte
+}
+
+// NewReader returns a reader limited to DefaultMaxEventSize per event.
+func NewReader(r io.Reader) *Reader {
+	return &Reader{br: bufio.NewReaderSize(r, 64<<10), maxSize: DefaultMaxEventSize}
+}
+
+// SetMaxEventSize changes the per-event size limit; n <= 0 restores the default.
+func (r *Reader) SetMaxEventSize(n int) {
+	if n <= 0 {
+		n = DefaultMaxEventSize
+	}
+	r.maxSize = n
+}
+
+// Next returns the next event. It returns io.EOF once the stream is exhausted; any
+// partial event left at the end of the stream is returned before that.
+func (r *Reader) Next() (Event, error) {
+	for {
+		line, errLine := r.readLine()
+		if errLine != nil {
+			if errors.Is(errLine, io.EOF) && r.hasData {
+				return r.dispatch(), nil
+			}
+			r.reset()
+			return Event{}, errLine
+		}
+		if len(line) == 0 {
+			if r.hasData {
+				return r.dispatch(), nil
+			}
+			r.reset()
+			continue
+		}
+		if line[0] == ':' {
+			continue
+		}
+		field, value := line, []byte(nil)
+		if idx := bytes.IndexByte(line, ':'); idx >= 0 {
+			field, value = line[:idx], line[idx+1:]
+			if len(value) > 0 && value[0] == ' ' {
+				value = value[1:]
+			}
+		}
+		var dispatched *Event
+		if string(field) == "data" && r.hasData && json.Valid(r.data) && json.Valid(value) {
+			// A complete JSON document followed by another data line without a blank line
+			// in between: treat it as two events, as line-based parsers would.
+			event := r.dispatch()
+			dispatched = &event
+		}
+		r.raw = append(r.raw, line...)
+		r.raw = append(r.raw, '\n')
+		switch string(field) {
+		case "data":
+			if r.hasData {
+				r.data = append(r.data, '\n')
+			}
+			r.data = append(r.data, value...)
+			r.hasData = true
+		case "event":
+			r.name = string(value)
+		case "id":
+			if bytes.IndexByte(value, 0) < 0 {
+				r.id = string(value)
+			}
+		}
+		if len(r.raw) > r.maxSize {
+			r.reset()
+			return Event{}, ErrEventTooLarge
+		}
+		if dispatched != nil {
+			return *dispatched, nil
+		}
+	}
+}
+
+func (r *Reader) dispatch() Event {
+	event := Event{Name: r.name, Data: r.data, ID: r.id, Raw: bytes.TrimSuffix(r.raw, []byte("\n"))}
+	r.reset()
+	return event
+}
+
+func (r *Reader) reset() {
+	r.name, r.data, r.hasData, r.raw = "", nil, false, nil
+}
+
+// readLine returns the next line without its terminator. Bare CR terminators split a
+// read into several lines, which are queued in pending.
+func (r *Reader) readLine() ([]byte, error) {
+	for {
+		if len(r.pending) > 0 {
+			line := r.pending[0]
+			r.pending = r.pending[1:]
+			return line, nil
+		}
+		if r.eof {
+			return nil, io.EOF
+		}
+		chunk, errRead := r.br.ReadSlice('\n')
+		if r.skipLF && len(chunk) > 0 {
+			r.skipLF = false
+			if chunk[0] == '\n' {
+				chunk = chunk[1:]
+				if len(chunk) == 0 && errRead == nil {
+					continue
+				}
+			}
+		}
+		r.line = append(r.line, chunk...)
+		if len(r.line) > r.maxSize {
+			r.line = nil
+			return nil, ErrEventTooLarge
+		}
+		switch {
+		case errRead == nil:
+		case errors.Is(errRead, bufio.ErrBufferFull):
+			if bytes.IndexByte(chunk, '\r') < 0 {
+				continue
+			}
+		case errors.Is(errRead, io.EOF):
+			r.eof = true
+			if len(r.line) == 0 {
+				return nil, io.EOF
+			}
+		default:
+			return nil, errRead
+		}
+		r.splitLines(errRead == nil)
+	}
+}
+
+// splitLines moves the complete lines of the buffered input into pending. terminated
+// reports whether the buffer ends with LF; otherwise a trailing partial line is kept
+// unless the input ended.
+func (r *Reader) splitLines(terminated bool) {
+	buf := r.line
+	r.line = nil
+	if terminated {
+		buf = bytes.TrimSuffix(buf, []byte("\n"))
+		if rest, ok := bytes.CutSuffix(buf, []byte("\r")); ok {
+			buf = rest
+		}
+		r.pending = append(r.pending, bytes.Split(buf, []byte("\r"))...)
+		return
+	}
+	parts := bytes.Split(buf, []byte("\r"))
+	last := parts[len(parts)-1]
+	parts = parts[:len(parts)-1]
+	r.pending = append(r.pending, parts...)
+	switch {
+	case r.eof:
+		if len(last) > 0 {
+			r.pending = append(r.pending, last)
+		}
+	case len(last) == 0:
+		// The buffer ended in CR; an LF at the start of the next read belongs to it.
+		r.skipLF = true
+	default:
+		r.line = append([]byte(nil), last...)
+	}
+}
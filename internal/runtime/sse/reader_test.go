@@ -0,0 +1,102 @@
+package sse
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func readAll(t *testing.T, r *Reader) []Event {
+	t.Helper()
+	var events []Event
+	for {
+		event, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return events
+		}
+		if err != nil {
+			t.Fatalf("Next returned error: %v", err)
+		}
+		events = append(events, event)
+	}
+}
+
+func TestReaderParsesEvents(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  []Event
+	}{
+		{
+			name:  "openai chunks and done",
+			input: "data: {\"a\":1}\n\ndata: [DONE]\n\n",
+			want:  []Event{{Data: []byte(`{"a":1}`)}, {Data: []byte("[DONE]")}},
+		},
+		{
+			name:  "named events with CRLF and comments",
+			input: ": keep-alive\r\nevent: message_start\r\ndata:{\"type\":\"message_start\"}\r\n\r\n: ping\r\n\r\n",
+			want:  []Event{{Name: "message_start", Data: []byte(`{"type":"message_start"}`)}},
+		},
+		{
+			name:  "multi-line data",
+			input: "id: 7\ndata: {\"text\":\ndata: \"hi\"}\n\n",
+			want:  []Event{{ID: "7", Data: []byte("{\"text\":\n\"hi\"}")}},
+		},
+		{
+			name:  "bare CR line endings",
+			input: "event: a\rdata: 1\r\rdata: 2\r\r",
+			want:  []Event{{Name: "a", Data: []byte("1")}, {Data: []byte("2")}},
+		},
+		{
+			name:  "data lines without separators",
+			input: "data: {\"n\":1}\ndata: {\"n\":2}\n",
+			want:  []Event{{Data: []byte(`{"n":1}`)}, {Data: []byte(`{"n":2}`)}},
+		},
+		{
+			name:  "event without data is skipped",
+			input: "event: ping\n\nevent: done\ndata: x",
+			want:  []Event{{Name: "done", Data: []byte("x")}},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			// One byte at a time exercises CRLF pairs and lines split across reads.
+			got := readAll(t, NewReader(iotest.OneByteReader(strings.NewReader(tc.input))))
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %d events %+v, want %d", len(got), got, len(tc.want))
+			}
+			for i := range tc.want {
+				if got[i].Name != tc.want[i].Name || string(got[i].Data) != string(tc.want[i].Data) || got[i].ID != tc.want[i].ID {
+					t.Fatalf("event %d = {%q %q %q}, want {%q %q %q}", i, got[i].Name, got[i].Data, got[i].ID, tc.want[i].Name, tc.want[i].Data, tc.want[i].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestReaderLargeEvents(t *testing.T) {
+	large := strings.Repeat("x", 1<<20)
+	r := NewReader(strings.NewReader("data: " + large + "\n\n"))
+	event, err := r.Next()
+	if err != nil || len(event.Data) != len(large) {
+		t.Fatalf("large event: len=%d err=%v", len(event.Data), err)
+	}
+
+	r = NewReader(strings.NewReader("data: " + large + "\n\n"))
+	r.SetMaxEventSize(64 << 10)
+	if _, err = r.Next(); !errors.Is(err, ErrEventTooLarge) {
+		t.Fatalf("err = %v, want ErrEventTooLarge", err)
+	}
+}
+
+func TestEventHelpers(t *testing.T) {
+	event := Event{Data: []byte(" [DONE]")}
+	if !event.Done() {
+		t.Fatal("expected the [DONE] marker to be recognised")
+	}
+	if got := string((Event{Data: []byte(`{}`)}).DataLine()); got != "data: {}" {
+		t.Fatalf("DataLine = %q", got)
+	}
+}
@@ -0,0 +1,145 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+const (
+	awsService     = "secretsmanager"
+	awsTarget      = "secretsmanager.GetSecretValue"
+	awsContentType = "application/x-amz-json-1.1"
+)
+
+// fetchAWS reads arn:aws:secretsmanager:<region>:<account>:secret:<name>[#field] with a
+// SigV4-signed GetSecretValue call. With a field, SecretString is decoded as JSON.
+func fetchAWS(ctx context.Context, client *http.Client, cfg config.AWSSecretsConfig, ref string, now time.Time) (string, error) {
+	arn, field := splitField(ref)
+	parts := strings.SplitN(arn, ":", 7)
+	if len(parts) < 7 || parts[3] == "" {
+		return "", fmt.Errorf("secrets: invalid secrets manager ARN %q", arn)
+	}
+	region := parts[3]
+	accessKey := strings.TrimSpace(os.Getenv("AWS_ACCESS_KEY_ID"))
+	secretKey := strings.TrimSpace(os.Getenv("AWS_SECRET_ACCESS_KEY"))
+	if accessKey == "" || secretKey == "" {
+		return "", fmt.Errorf("secrets: AWS credentials are not set for %s", arn)
+	}
+	endpoint := firstNonEmpty(cfg.Endpoint, "https://secretsmanager."+region+".amazonaws.com")
+
+	body, errMarshal := json.Marshal(map[string]string{"SecretId": arn})
+	if errMarshal != nil {
+		return "", errMarshal
+	}
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if errReq != nil {
+		return "", fmt.Errorf("secrets: build request for %s: %w", arn, errReq)
+	}
+	req.Header.Set("Content-Type", awsContentType)
+	req.Header.Set("X-Amz-Target", awsTarget)
+	signAWSRequest(req, body, region, accessKey, secretKey, strings.TrimSpace(os.Getenv("AWS_SESSION_TOKEN")), now)
+
+	resp, errDo := client.Do(req)
+	if errDo != nil {
+		return "", fmt.Errorf("secrets: read %s: %w", arn, errDo)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, errRead := io.ReadAll(io.LimitReader(resp.Body, maxSecretLen))
+	if errRead != nil {
+		return "", fmt.Errorf("secrets: read %s: %w", arn, errRead)
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return "", fmt.Errorf("secrets: read %s: status %d: %s", arn, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	var payload struct {
+		SecretString string `json:"SecretString"`
+	}
+	if errUnmarshal := json.Unmarshal(respBody, &payload); errUnmarshal != nil {
+		return "", fmt.Errorf("secrets: decode %s: %w", arn, errUnmarshal)
+	}
+	if field == "" {
+		return payload.SecretString, nil
+	}
+	var data map[string]any
+	if errUnmarshal := json.Unmarshal([]byte(payload.SecretString), &data); errUnmarshal != nil {
+		return "", fmt.Errorf("secrets: %s is not a JSON secret: %w", arn, errUnmarshal)
+	}
+	return pickField(data, field, ref)
+}
+
+// signAWSRequest adds AWS Signature Version 4 headers for a JSON POST to "/".
+func signAWSRequest(req *http.Request, body []byte, region, accessKey, secretKey, sessionToken string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	headers := []string{"content-type", "host", "x-amz-date"}
+	if sessionToken != "" {
+		headers = append(headers, "x-amz-security-token")
+	}
+	headers = append(headers, "x-amz-target")
+	var canonicalHeaders strings.Builder
+	for _, name := range headers {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(headers, ";")
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		canonicalQuery(req.URL),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hexSHA256(body),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + awsService + "/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hexSHA256([]byte(canonicalRequest))}, "\n")
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, awsService)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+}
+
+func canonicalQuery(u *url.URL) string {
+	return strings.ReplaceAll(u.Query().Encode(), "+", "%20")
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
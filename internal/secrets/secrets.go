@@ -0,0 +1,208 @@
+// Package secrets resolves provider API keys declared by reference in the config
+// (vault://path#field or arn:aws:secretsmanager:...) so the keys themselves never live
+// in config.yaml. Resolved values are cached and re-resolved periodically.
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultRefreshInterval is used when SecretsConfig.RefreshInterval is empty.
+	DefaultRefreshInterval = 5 * time.Minute
+
+	vaultScheme  = "vault://"
+	awsARNPrefix = "arn:aws:secretsmanager:"
+
+	// DefaultResolveTimeout bounds one resolution so a slow backend cannot stall config
+	// synthesis for long.
+	DefaultResolveTimeout = 5 * time.Second
+
+	fetchTimeout = 10 * time.Second
+	maxSecretLen = 1 << 20
+)
+
+// IsReference reports whether value is a secret reference rather than a literal key.
+func IsReference(value string) bool {
+	value = strings.TrimSpace(value)
+	return strings.HasPrefix(value, vaultScheme) || strings.HasPrefix(value, awsARNPrefix)
+}
+
+// Resolver fetches and caches referenced secrets.
+type Resolver struct {
+	client *http.Client
+	// timeout bounds each fetch; zero uses DefaultResolveTimeout.
+	timeout time.Duration
+
+	mu    sync.Mutex
+	cfg   config.SecretsConfig
+	cache map[string]string
+}
+
+// NewResolver creates an empty resolver.
+func NewResolver() *Resolver {
+	return &Resolver{
+		client: &http.Client{Timeout: fetchTimeout},
+		cache:  make(map[string]string),
+	}
+}
+
+var defaultResolver = NewResolver()
+
+// Default returns the process-wide resolver used by config synthesis.
+func Default() *Resolver { return defaultResolver }
+
+// Configure replaces the backend settings. Cached values are kept.
+func (r *Resolver) Configure(cfg config.SecretsConfig) {
+	r.mu.Lock()
+	r.cfg = cfg
+	r.mu.Unlock()
+}
+
+// RefreshInterval returns the configured re-resolution interval; zero disables it.
+func (r *Resolver) RefreshInterval() time.Duration {
+	r.mu.Lock()
+	raw := strings.TrimSpace(r.cfg.RefreshInterval)
+	r.mu.Unlock()
+	if raw == "" {
+		return DefaultRefreshInterval
+	}
+	interval, errParse := time.ParseDuration(raw)
+	if errParse != nil || interval <= 0 {
+		return 0
+	}
+	return interval
+}
+
+// Resolve returns the secret for ref, fetching it on first use.
+func (r *Resolver) Resolve(ctx context.Context, ref string) (string, error) {
+	ref = strings.TrimSpace(ref)
+	r.mu.Lock()
+	value, ok := r.cache[ref]
+	r.mu.Unlock()
+	if ok {
+		return value, nil
+	}
+	value, errFetch := r.fetch(ctx, ref)
+	if errFetch != nil {
+		return "", errFetch
+	}
+	r.mu.Lock()
+	r.cache[ref] = value
+	r.mu.Unlock()
+	return value, nil
+}
+
+// Retain evicts cached secrets whose reference is not in refs, e.g. references removed
+// from the config on reload.
+func (r *Resolver) Retain(refs map[string]struct{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for ref := range r.cache {
+		if _, ok := refs[ref]; !ok {
+			delete(r.cache, ref)
+		}
+	}
+}
+
+// Refresh re-fetches every cached secret and reports whether any value changed. A
+// failed fetch keeps the previous value.
+func (r *Resolver) Refresh(ctx context.Context) bool {
+	r.mu.Lock()
+	refs := make([]string, 0, len(r.cache))
+	for ref := range r.cache {
+		refs = append(refs, ref)
+	}
+	r.mu.Unlock()
+
+	changed := false
+	for _, ref := range refs {
+		value, errFetch := r.fetch(ctx, ref)
+		if errFetch != nil {
+			log.Warnf("secrets: re-resolve %s failed, keeping cached value: %v", ref, errFetch)
+			continue
+		}
+		r.mu.Lock()
+		if r.cache[ref] != value {
+			r.cache[ref] = value
+			changed = true
+		}
+		r.mu.Unlock()
+	}
+	return changed
+}
+
+func (r *Resolver) fetch(ctx context.Context, ref string) (string, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout := r.timeout
+	if timeout <= 0 {
+		timeout = DefaultResolveTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	r.mu.Lock()
+	cfg := r.cfg
+	r.mu.Unlock()
+
+	var (
+		value string
+		err   error
+	)
+	switch {
+	case strings.HasPrefix(ref, vaultScheme):
+		value, err = fetchVault(ctx, r.client, cfg.Vault, ref)
+	case strings.HasPrefix(ref, awsARNPrefix):
+		value, err = fetchAWS(ctx, r.client, cfg.AWS, ref, time.Now())
+	default:
+		return "", fmt.Errorf("secrets: unsupported reference %q", ref)
+	}
+	if err != nil {
+		return "", err
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("secrets: %s resolved to an empty value", ref)
+	}
+	return value, nil
+}
+
+// splitField separates the optional "#field" suffix from a reference.
+func splitField(ref string) (string, string) {
+	if idx := strings.LastIndex(ref, "#"); idx >= 0 {
+		return ref[:idx], strings.TrimSpace(ref[idx+1:])
+	}
+	return ref, ""
+}
+
+// pickField returns field from data, or the only string value when field is empty.
+func pickField(data map[string]any, field, ref string) (string, error) {
+	if field != "" {
+		value, ok := data[field].(string)
+		if !ok {
+			return "", fmt.Errorf("secrets: %s has no string field %q", ref, field)
+		}
+		return value, nil
+	}
+	var only string
+	count := 0
+	for _, raw := range data {
+		if value, ok := raw.(string); ok {
+			only = value
+			count++
+		}
+	}
+	if count != 1 {
+		return "", fmt.Errorf("secrets: %s holds %d values; select one with #field", ref, count)
+	}
+	return only, nil
+}
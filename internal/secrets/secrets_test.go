@@ -0,0 +1,176 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestIsReference(t *testing.T) {
+	cases := map[string]bool{
+		"vault://secret/data/claude#api_key":                          true,
+		"arn:aws:secretsmanager:us-east-1:123456789012:secret:claude": true,
+		"sk-ant-literal": false,
+		"":               false,
+	}
+	for value, want := range cases {
+		if got := IsReference(value); got != want {
+			t.Errorf("IsReference(%q) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestResolveVaultKV2AndRefresh(t *testing.T) {
+	var version atomic.Int32
+	version.Store(1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "vault-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if r.URL.Path != "/v1/secret/data/cliproxy" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		key := "key-v1"
+		if version.Load() == 2 {
+			key = "key-v2"
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"data": map[string]any{
+				"data":     map[string]any{"claude": key, "other": "x"},
+				"metadata": map[string]any{"version": version.Load()},
+			},
+		})
+	}))
+	defer server.Close()
+	t.Setenv("VAULT_TOKEN", "vault-token")
+
+	r := NewResolver()
+	r.Configure(config.SecretsConfig{Vault: config.VaultSecretsConfig{Address: server.URL}})
+	ref := "vault://secret/data/cliproxy#claude"
+
+	got, err := r.Resolve(context.Background(), ref)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if got != "key-v1" {
+		t.Fatalf("Resolve = %q, want key-v1", got)
+	}
+	if r.Refresh(context.Background()) {
+		t.Fatal("Refresh reported a change for an unchanged secret")
+	}
+	version.Store(2)
+	if !r.Refresh(context.Background()) {
+		t.Fatal("Refresh did not report the rotated secret")
+	}
+	if got, _ = r.Resolve(context.Background(), ref); got != "key-v2" {
+		t.Fatalf("Resolve after refresh = %q, want key-v2", got)
+	}
+
+	if _, err = r.Resolve(context.Background(), "vault://secret/data/cliproxy"); err == nil {
+		t.Fatal("expected an error when several fields exist and none is selected")
+	}
+}
+
+func TestResolveAWSSecretsManager(t *testing.T) {
+	const arn = "arn:aws:secretsmanager:eu-west-1:123456789012:secret:proxy-keys"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != awsTarget {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") || !strings.Contains(auth, "/eu-west-1/secretsmanager/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["SecretId"] != arn {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"SecretString": `{"codex":"sk-codex"}`})
+	}))
+	defer server.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	r := NewResolver()
+	r.Configure(config.SecretsConfig{AWS: config.AWSSecretsConfig{Endpoint: server.URL}})
+	got, err := r.Resolve(context.Background(), arn+"#codex")
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	if got != "sk-codex" {
+		t.Fatalf("Resolve = %q, want sk-codex", got)
+	}
+}
+
+func TestRefreshInterval(t *testing.T) {
+	r := NewResolver()
+	if got := r.RefreshInterval(); got != DefaultRefreshInterval {
+		t.Fatalf("default interval = %v", got)
+	}
+	r.Configure(config.SecretsConfig{RefreshInterval: "0"})
+	if got := r.RefreshInterval(); got != 0 {
+		t.Fatalf("disabled interval = %v", got)
+	}
+	r.Configure(config.SecretsConfig{RefreshInterval: "30s"})
+	if got := r.RefreshInterval(); got != 30*time.Second {
+		t.Fatalf("interval = %v", got)
+	}
+}
+
+func TestResolveTimesOutAndRetainEvictsRemovedRefs(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/slow") {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"key": "value"}})
+	}))
+	defer server.Close()
+	defer close(release)
+	t.Setenv("VAULT_TOKEN", "vault-token")
+
+	r := NewResolver()
+	r.timeout = 50 * time.Millisecond
+	r.Configure(config.SecretsConfig{Vault: config.VaultSecretsConfig{Address: server.URL}})
+
+	start := time.Now()
+	if _, err := r.Resolve(context.Background(), "vault://secret/slow#key"); err == nil {
+		t.Fatal("expected a slow backend to time out")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("resolution took %s, want it bounded by the timeout", elapsed)
+	}
+
+	kept, removed := "vault://secret/kept#key", "vault://secret/removed#key"
+	for _, ref := range []string{kept, removed} {
+		if _, err := r.Resolve(context.Background(), ref); err != nil {
+			t.Fatalf("Resolve(%s) error = %v", ref, err)
+		}
+	}
+	r.Retain(map[string]struct{}{kept: {}})
+	r.mu.Lock()
+	_, hasKept := r.cache[kept]
+	_, hasRemoved := r.cache[removed]
+	r.mu.Unlock()
+	if !hasKept || hasRemoved {
+		t.Fatalf("cache after Retain = %v, want only %s", r.cache, kept)
+	}
+}
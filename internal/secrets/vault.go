@@ -0,0 +1,65 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/httpfetch"
+)
+
+// fetchVault reads vault://<path>#<field> from the Vault HTTP API. Both KV v2
+// (data.data) and KV v1 (data) responses are accepted.
+func fetchVault(ctx context.Context, client *http.Client, cfg config.VaultSecretsConfig, ref string) (string, error) {
+	path, field := splitField(strings.TrimPrefix(ref, vaultScheme))
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return "", fmt.Errorf("secrets: %s has no path", ref)
+	}
+	address := firstNonEmpty(cfg.Address, os.Getenv("VAULT_ADDR"))
+	if address == "" {
+		return "", fmt.Errorf("secrets: vault address is not configured for %s", ref)
+	}
+	tokenEnv := firstNonEmpty(cfg.TokenEnv, "VAULT_TOKEN")
+	token := strings.TrimSpace(os.Getenv(tokenEnv))
+	if token == "" {
+		return "", fmt.Errorf("secrets: vault token environment variable %s is not set", tokenEnv)
+	}
+	headers := map[string]string{"X-Vault-Token": token}
+	if namespace := firstNonEmpty(cfg.Namespace, os.Getenv("VAULT_NAMESPACE")); namespace != "" {
+		headers["X-Vault-Namespace"] = namespace
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	body, errFetch := httpfetch.GetBytes(ctx, client, strings.TrimRight(address, "/")+"/v1/"+path, headers, maxSecretLen)
+	if errFetch != nil {
+		return "", fmt.Errorf("secrets: read %s: %w", ref, errFetch)
+	}
+	var payload struct {
+		Data map[string]any `json:"data"`
+	}
+	if errUnmarshal := json.Unmarshal(body, &payload); errUnmarshal != nil {
+		return "", fmt.Errorf("secrets: decode %s: %w", ref, errUnmarshal)
+	}
+	data := payload.Data
+	if nested, ok := data["data"].(map[string]any); ok {
+		if _, hasMeta := data["metadata"]; hasMeta {
+			data = nested
+		}
+	}
+	return pickField(data, field, ref)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,459 @@
+// Package spendalert tracks estimated daily spend per credential and per tenant against
+// configured budgets. It alerts once spend crosses the warning ratio and acts as a
+// kill-switch at 100%: the credential is disabled or the tenant blocked until re-armed.
+package spendalert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tenant"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// Budget scopes.
+const (
+	ScopeAuth   = "auth"
+	ScopeTenant = "tenant"
+)
+
+// Alert levels.
+const (
+	LevelWarning   = "warning"
+	LevelExhausted = "exhausted"
+)
+
+const (
+	defaultWarnRatio = 0.8
+	webhookTimeout   = 10 * time.Second
+	dayLayout        = "2006-01-02"
+	// tripReasonPrefix starts the reason recorded on tripped credentials and tenants, which
+	// lets Configure tell kill-switch stops apart from manual ones after a restart.
+	tripReasonPrefix = "daily spend budget"
+)
+
+// ErrUnknownScope is returned by Rearm for scopes other than ScopeAuth and ScopeTenant.
+var ErrUnknownScope = errors.New("unknown spend scope")
+
+// Alert is logged, and POSTed to the webhook when configured, whenever a budget crosses
+// the warning ratio or is exhausted.
+type Alert struct {
+	Level     string    `json:"level"`
+	Scope     string    `json:"scope"`
+	ID        string    `json:"id"`
+	Day       string    `json:"day"`
+	SpentUSD  float64   `json:"spent_usd"`
+	BudgetUSD float64   `json:"budget_usd"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Status is the current-day spend of one credential or tenant.
+type Status struct {
+	Scope     string  `json:"scope"`
+	ID        string  `json:"id"`
+	Day       string  `json:"day"`
+	SpentUSD  float64 `json:"spent_usd"`
+	BudgetUSD float64 `json:"budget_usd,omitempty"`
+	Warned    bool    `json:"warned,omitempty"`
+	Tripped   bool    `json:"tripped,omitempty"`
+}
+
+type scopeKey struct {
+	scope string
+	id    string
+}
+
+type spendEntry struct {
+	day    string
+	usd    float64
+	warned bool
+	// tripped survives the day rollover: the kill-switch holds until Rearm.
+	tripped bool
+}
+
+// Guard is a usage plugin accumulating spend and enforcing the configured budgets.
+type Guard struct {
+	mu      sync.Mutex
+	cfg     config.SpendAlertsConfig
+	auths   *coreauth.Manager
+	tenants *tenant.Store
+	spend   map[scopeKey]*spendEntry
+
+	pricing *registry.PricingRegistry
+	// counters seeds credential spend after a restart; nil uses the default usage manager's counters.
+	counters *coreusage.Counters
+	client   *http.Client
+	now      func() time.Time
+}
+
+var defaultGuard = NewGuard(tenant.Default())
+
+func init() {
+	coreusage.RegisterPlugin(defaultGuard)
+}
+
+// Default returns the process-wide guard fed by the default usage manager.
+func Default() *Guard { return defaultGuard }
+
+// NewGuard creates a disabled guard charging tenants from the given store.
+func NewGuard(tenants *tenant.Store) *Guard {
+	return &Guard{
+		tenants: tenants,
+		spend:   make(map[scopeKey]*spendEntry),
+		client:  &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+// Configure applies the spend-alerts settings and the manager used to disable credentials.
+// Accumulated spend is kept across reloads. Credentials not tracked yet today are seeded
+// from the persisted usage counters, and credentials or tenants stopped by the kill-switch
+// before a restart are tracked as tripped again.
+func (g *Guard) Configure(cfg config.SpendAlertsConfig, auths *coreauth.Manager) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	g.cfg = cfg
+	g.auths = auths
+	g.mu.Unlock()
+
+	if cfg.Enabled {
+		g.seed(auths)
+	}
+}
+
+func (g *Guard) countersStore() *coreusage.Counters {
+	if g.counters != nil {
+		return g.counters
+	}
+	return coreusage.DefaultManager().Counters()
+}
+
+// seed loads today's spend of credentials not tracked yet from the usage counters and
+// rebuilds the tripped state from the persisted disabled and blocked flags. Counters are
+// kept per credential, so tenant spend restarts from zero.
+func (g *Guard) seed(auths *coreauth.Manager) {
+	today := g.currentTime().UTC().Format(dayLayout)
+	counters := g.countersStore()
+	var list []*coreauth.Auth
+	if auths != nil {
+		list = auths.List()
+	}
+	var tenants []tenant.Tenant
+	if g.tenants != nil {
+		tenants = g.tenants.List()
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	for _, auth := range list {
+		if auth == nil || auth.ID == "" {
+			continue
+		}
+		key := scopeKey{scope: ScopeAuth, id: auth.ID}
+		entry := g.spend[key]
+		if entry != nil && entry.day >= today {
+			continue
+		}
+		usd := 0.0
+		for _, row := range counters.Query(coreusage.CounterFilter{AuthID: auth.ID, From: today, To: today}) {
+			usd += g.cost(row.Provider, row.Model, "", row.InputTokens, row.OutputTokens)
+		}
+		tripped := auth.Disabled && strings.HasPrefix(auth.StatusMessage, tripReasonPrefix)
+		if entry != nil {
+			tripped = tripped || entry.tripped
+		}
+		if usd <= 0 && !tripped {
+			continue
+		}
+		budget := g.budgetLocked(ScopeAuth, auth.ID)
+		g.spend[key] = &spendEntry{
+			day:     today,
+			usd:     usd,
+			warned:  budget > 0 && usd >= budget*g.warnRatioLocked(),
+			tripped: tripped,
+		}
+	}
+	for _, t := range tenants {
+		if !t.Blocked || !strings.HasPrefix(t.BlockedReason, tripReasonPrefix) {
+			continue
+		}
+		key := scopeKey{scope: ScopeTenant, id: t.ID}
+		if entry := g.spend[key]; entry != nil {
+			entry.tripped = true
+			continue
+		}
+		g.spend[key] = &spendEntry{day: today, tripped: true}
+	}
+}
+
+func (g *Guard) currentTime() time.Time {
+	if g.now != nil {
+		return g.now()
+	}
+	return time.Now()
+}
+
+func (g *Guard) priceRegistry() *registry.PricingRegistry {
+	if g.pricing != nil {
+		return g.pricing
+	}
+	return registry.GetGlobalPricingRegistry()
+}
+
+func (g *Guard) budgetLocked(scope, id string) float64 {
+	switch scope {
+	case ScopeAuth:
+		if budget, ok := g.cfg.AuthBudgets[id]; ok {
+			return budget
+		}
+		return g.cfg.AuthDailyBudget
+	case ScopeTenant:
+		if budget, ok := g.cfg.TenantBudgets[id]; ok {
+			return budget
+		}
+		return g.cfg.TenantDailyBudget
+	}
+	return 0
+}
+
+func (g *Guard) warnRatioLocked() float64 {
+	if ratio := g.cfg.WarnRatio; ratio > 0 && ratio < 1 {
+		return ratio
+	}
+	return defaultWarnRatio
+}
+
+// HandleUsage implements usage.Plugin and charges the record to its credential and tenant.
+func (g *Guard) HandleUsage(ctx context.Context, record coreusage.Record) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	enabled := g.cfg.Enabled
+	g.mu.Unlock()
+	if !enabled {
+		return
+	}
+	cost := g.recordCost(record)
+	if cost <= 0 {
+		return
+	}
+	at := record.RequestedAt
+	if at.IsZero() {
+		at = g.currentTime()
+	}
+	day := at.UTC().Format(dayLayout)
+
+	if record.AuthID != "" {
+		g.charge(ctx, ScopeAuth, record.AuthID, day, cost)
+	}
+	if record.APIKey != "" && record.Background == "" && g.tenants != nil {
+		if t, ok := g.tenants.Lookup(record.APIKey); ok {
+			g.charge(ctx, ScopeTenant, t.ID, day, cost)
+		}
+	}
+}
+
+func (g *Guard) recordCost(record coreusage.Record) float64 {
+	return g.cost(record.Provider, record.Model, record.Alias, record.Detail.InputTokens, record.Detail.OutputTokens)
+}
+
+func (g *Guard) cost(provider, model, alias string, inputTokens, outputTokens int64) float64 {
+	pricing := g.priceRegistry()
+	price, ok := pricing.Lookup(provider, canonicalModel(model))
+	if !ok && alias != "" {
+		price, ok = pricing.Lookup(provider, canonicalModel(alias))
+	}
+	if !ok {
+		return 0
+	}
+	return price.Cost(inputTokens, outputTokens)
+}
+
+func canonicalModel(model string) string {
+	model = strings.TrimSpace(model)
+	if name := strings.TrimSpace(thinking.ParseSuffix(model).ModelName); name != "" {
+		return name
+	}
+	return model
+}
+
+func (g *Guard) charge(ctx context.Context, scope, id, day string, cost float64) {
+	g.mu.Lock()
+	budget := g.budgetLocked(scope, id)
+	key := scopeKey{scope: scope, id: id}
+	entry := g.spend[key]
+	if entry == nil {
+		entry = &spendEntry{day: day}
+		g.spend[key] = entry
+	}
+	if entry.day != day {
+		if entry.day > day {
+			// A late record for a past day does not count against today.
+			g.mu.Unlock()
+			return
+		}
+		entry.day, entry.usd, entry.warned = day, 0, false
+	}
+	entry.usd += cost
+	var alerts []Alert
+	trip := false
+	if budget > 0 {
+		if !entry.warned && entry.usd >= budget*g.warnRatioLocked() {
+			entry.warned = true
+			if entry.usd < budget {
+				alerts = append(alerts, g.newAlert(LevelWarning, scope, id, entry, budget))
+			}
+		}
+		if !entry.tripped && entry.usd >= budget {
+			entry.tripped = true
+			trip = true
+			alerts = append(alerts, g.newAlert(LevelExhausted, scope, id, entry, budget))
+		}
+	}
+	auths := g.auths
+	webhookURL := strings.TrimSpace(g.cfg.WebhookURL)
+	headers := g.cfg.WebhookHeaders
+	g.mu.Unlock()
+
+	for _, alert := range alerts {
+		g.emit(alert, webhookURL, headers)
+	}
+	if trip {
+		g.trip(ctx, scope, id, budget, auths)
+	}
+}
+
+func (g *Guard) newAlert(level, scope, id string, entry *spendEntry, budget float64) Alert {
+	return Alert{Level: level, Scope: scope, ID: id, Day: entry.day, SpentUSD: entry.usd, BudgetUSD: budget, Timestamp: g.currentTime().UTC()}
+}
+
+func (g *Guard) trip(ctx context.Context, scope, id string, budget float64, auths *coreauth.Manager) {
+	reason := fmt.Sprintf("%s of $%.2f exhausted", tripReasonPrefix, budget)
+	var errTrip error
+	switch scope {
+	case ScopeAuth:
+		if auths == nil {
+			return
+		}
+		_, errTrip = auths.Disable(context.WithoutCancel(ctx), id, reason)
+	case ScopeTenant:
+		errTrip = g.tenants.Block(id, reason)
+	}
+	if errTrip != nil {
+		log.Warnf("spend alert: failed to stop %s %s: %v", scope, id, errTrip)
+	}
+}
+
+func (g *Guard) emit(alert Alert, webhookURL string, headers map[string]string) {
+	entry := log.WithFields(log.Fields{
+		"scope":      alert.Scope,
+		"id":         alert.ID,
+		"spent_usd":  fmt.Sprintf("%.4f", alert.SpentUSD),
+		"budget_usd": alert.BudgetUSD,
+	})
+	if alert.Level == LevelExhausted {
+		entry.Warn("spend alert: daily budget exhausted, routing stopped")
+	} else {
+		entry.Info("spend alert: daily budget nearly exhausted")
+	}
+	if webhookURL != "" {
+		go g.deliver(alert, webhookURL, headers)
+	}
+}
+
+func (g *Guard) deliver(alert Alert, webhookURL string, headers map[string]string) {
+	body, errMarshal := json.Marshal(alert)
+	if errMarshal != nil {
+		return
+	}
+	req, errReq := http.NewRequest(http.MethodPost, webhookURL, bytes.NewReader(body))
+	if errReq != nil {
+		log.Warnf("spend alert: invalid webhook url: %v", errReq)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, errDo := g.client.Do(req)
+	if errDo != nil {
+		log.Warnf("spend alert: webhook delivery failed: %v", errDo)
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		log.Warnf("spend alert: webhook returned status %d", resp.StatusCode)
+	}
+}
+
+// Rearm lifts the kill-switch for a credential or tenant and lets its alerts fire again.
+// resetSpend also clears today's spend; otherwise a still-exhausted budget trips again on
+// the next charged request.
+func (g *Guard) Rearm(ctx context.Context, scope, id string, resetSpend bool) error {
+	if g == nil {
+		return nil
+	}
+	scope = strings.ToLower(strings.TrimSpace(scope))
+	if scope != ScopeAuth && scope != ScopeTenant {
+		return ErrUnknownScope
+	}
+	g.mu.Lock()
+	if entry := g.spend[scopeKey{scope: scope, id: id}]; entry != nil {
+		entry.warned, entry.tripped = false, false
+		if resetSpend {
+			entry.usd = 0
+		}
+	}
+	auths := g.auths
+	g.mu.Unlock()
+
+	if scope == ScopeTenant {
+		return g.tenants.Unblock(id)
+	}
+	if auths == nil {
+		return coreauth.ErrAuthNotFound
+	}
+	_, errEnable := auths.Enable(ctx, id)
+	return errEnable
+}
+
+// Statuses returns today's spend for every tracked credential and tenant.
+func (g *Guard) Statuses() []Status {
+	if g == nil {
+		return nil
+	}
+	today := g.currentTime().UTC().Format(dayLayout)
+	g.mu.Lock()
+	out := make([]Status, 0, len(g.spend))
+	for key, entry := range g.spend {
+		status := Status{Scope: key.scope, ID: key.id, Day: today, BudgetUSD: g.budgetLocked(key.scope, key.id), Tripped: entry.tripped}
+		if entry.day == today {
+			status.SpentUSD = entry.usd
+			status.Warned = entry.warned
+		}
+		out = append(out, status)
+	}
+	g.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Scope != out[j].Scope {
+			return out[i].Scope < out[j].Scope
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
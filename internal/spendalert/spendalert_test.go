@@ -0,0 +1,152 @@
+package spendalert
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tenant"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func newTestGuard(t *testing.T) (*Guard, *coreauth.Manager, *tenant.Store) {
+	t.Helper()
+	tenants := tenant.NewStore("")
+	manager := coreauth.NewManager(nil, nil, nil)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: "auth-1", Provider: "claude"}); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	guard := NewGuard(tenants)
+	guard.pricing = registry.NewPricingRegistry()
+	// $1 per million input tokens: each test record costs $0.125.
+	guard.pricing.SetPrices([]registry.ModelPrice{{Provider: "claude", Model: "claude-*", PromptPerMillion: 1}})
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	guard.now = func() time.Time { return now }
+	guard.counters = coreusage.NewCounters(coreusage.CountersOptions{})
+	guard.Configure(config.SpendAlertsConfig{Enabled: true, AuthDailyBudget: 1, TenantBudgets: map[string]float64{"team": 0.25}}, manager)
+	return guard, manager, tenants
+}
+
+func spendRecord(apiKey string) coreusage.Record {
+	return coreusage.Record{
+		Provider: "claude",
+		Model:    "claude-sonnet-4",
+		AuthID:   "auth-1",
+		APIKey:   apiKey,
+		Detail:   coreusage.Detail{InputTokens: 125_000},
+	}
+}
+
+func TestGuardDisablesAuthAtBudget(t *testing.T) {
+	guard, manager, _ := newTestGuard(t)
+	ctx := context.Background()
+
+	for i := 0; i < 7; i++ {
+		guard.HandleUsage(ctx, spendRecord(""))
+	}
+	statuses := guard.Statuses()
+	if len(statuses) != 1 || !statuses[0].Warned || statuses[0].Tripped {
+		t.Fatalf("expected a warned, untripped auth at 80%%, got %+v", statuses)
+	}
+	if auth, _ := manager.GetByID("auth-1"); auth.Disabled {
+		t.Fatal("auth disabled before reaching its budget")
+	}
+
+	guard.HandleUsage(ctx, spendRecord(""))
+	if auth, _ := manager.GetByID("auth-1"); !auth.Disabled {
+		t.Fatal("auth should be disabled once its budget is exhausted")
+	}
+
+	if err := guard.Rearm(ctx, ScopeAuth, "auth-1", true); err != nil {
+		t.Fatalf("Rearm: %v", err)
+	}
+	if auth, _ := manager.GetByID("auth-1"); auth.Disabled {
+		t.Fatal("auth should be enabled after re-arming")
+	}
+	if statuses = guard.Statuses(); statuses[0].SpentUSD != 0 || statuses[0].Tripped {
+		t.Fatalf("re-arm with reset should clear spend, got %+v", statuses[0])
+	}
+}
+
+func TestGuardBlocksTenantAtBudget(t *testing.T) {
+	guard, _, tenants := newTestGuard(t)
+	ctx := context.Background()
+	if _, err := tenants.Create(tenant.Tenant{ID: "team", APIKey: "sk-team"}); err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		guard.HandleUsage(ctx, spendRecord("sk-team"))
+	}
+	if _, _, err := tenants.Authorize("sk-team"); !errors.Is(err, tenant.ErrQuotaExceeded) {
+		t.Fatalf("expected the tenant to be blocked, got %v", err)
+	}
+
+	if err := guard.Rearm(ctx, ScopeTenant, "team", false); err != nil {
+		t.Fatalf("Rearm: %v", err)
+	}
+	if _, _, err := tenants.Authorize("sk-team"); err != nil {
+		t.Fatalf("expected the tenant to be unblocked, got %v", err)
+	}
+	if err := guard.Rearm(ctx, "project", "team", false); !errors.Is(err, ErrUnknownScope) {
+		t.Fatalf("expected ErrUnknownScope, got %v", err)
+	}
+}
+
+func TestGuardConfigureSeedsSpendAndTrippedState(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	counters := coreusage.NewCounters(coreusage.CountersOptions{})
+	for i := 0; i < 6; i++ {
+		record := spendRecord("")
+		record.RequestedAt = now.Add(-time.Hour)
+		counters.HandleUsage(context.Background(), record)
+	}
+	tenants := tenant.NewStore("")
+	if _, err := tenants.Create(tenant.Tenant{ID: "team", APIKey: "team-key"}); err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+	if err := tenants.Block("team", tripReasonPrefix+" of $0.25 exhausted"); err != nil {
+		t.Fatalf("block tenant: %v", err)
+	}
+	manager := coreauth.NewManager(nil, nil, nil)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: "auth-1", Provider: "claude"}); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: "auth-2", Provider: "claude"}); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	if _, err := manager.Disable(context.Background(), "auth-2", tripReasonPrefix+" of $1.00 exhausted"); err != nil {
+		t.Fatalf("disable auth: %v", err)
+	}
+
+	guard := NewGuard(tenants)
+	guard.pricing = registry.NewPricingRegistry()
+	guard.pricing.SetPrices([]registry.ModelPrice{{Provider: "claude", Model: "claude-*", PromptPerMillion: 1}})
+	guard.now = func() time.Time { return now }
+	guard.counters = counters
+	guard.Configure(config.SpendAlertsConfig{Enabled: true, AuthDailyBudget: 1}, manager)
+
+	statuses := guard.Statuses()
+	if len(statuses) != 3 {
+		t.Fatalf("expected auth-1, auth-2 and team to be tracked, got %+v", statuses)
+	}
+	if got := statuses[0]; got.ID != "auth-1" || got.SpentUSD != 0.75 || got.Tripped {
+		t.Fatalf("auth-1 should be seeded with $0.75 from the counters, got %+v", got)
+	}
+	if got := statuses[1]; got.ID != "auth-2" || !got.Tripped {
+		t.Fatalf("auth-2 disabled by the kill-switch should be tripped, got %+v", got)
+	}
+	if got := statuses[2]; got.Scope != ScopeTenant || got.ID != "team" || !got.Tripped {
+		t.Fatalf("tenant blocked by the kill-switch should be tripped, got %+v", got)
+	}
+
+	guard.HandleUsage(context.Background(), spendRecord(""))
+	guard.HandleUsage(context.Background(), spendRecord(""))
+	if auth, _ := manager.GetByID("auth-1"); !auth.Disabled {
+		t.Fatal("seeded spend should count towards the budget")
+	}
+}
@@ -0,0 +1,465 @@
+// Package tenant manages proxy-level API keys issued to tenants, each bound to daily
+// request and token quotas. Consumption is fed from the usage pipeline and persisted
+// together with the tenant list so quotas survive restarts.
+package tenant
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// usageFlushInterval bounds how long consumption updates stay unpersisted.
+	usageFlushInterval = 30 * time.Second
+
+	dayLayout = "2006-01-02"
+	keyPrefix = "sk-tenant-"
+)
+
+var (
+	// ErrNotFound is returned when no tenant matches the requested ID.
+	ErrNotFound = errors.New("tenant not found")
+	// ErrDuplicateKey is returned when an API key is already bound to another tenant.
+	ErrDuplicateKey = errors.New("api key already in use")
+	// ErrDisabled is returned by Authorize for disabled tenants.
+	ErrDisabled = errors.New("tenant is disabled")
+	// ErrQuotaExceeded is returned by Authorize once a daily quota is used up.
+	ErrQuotaExceeded = errors.New("tenant daily quota exceeded")
+)
+
+// Tenant is a proxy client with its own API key and daily quotas.
+type Tenant struct {
+	ID     string `json:"id"`
+	Name   string `json:"name,omitempty"`
+	APIKey string `json:"api_key"`
+	// RequestsPerDay caps requests per UTC day. 0 means unlimited.
+	RequestsPerDay int64 `json:"requests_per_day,omitempty"`
+	// TokensPerDay caps total tokens per UTC day. 0 means unlimited.
+	TokensPerDay int64 `json:"tokens_per_day,omitempty"`
+	// AllowedProviders restricts the providers that may serve the tenant. Empty allows all.
+	AllowedProviders []string `json:"allowed_providers,omitempty"`
+	// AuthGroups restricts the credentials serving the tenant to these auth groups. Empty allows all.
+	AuthGroups []string `json:"auth_groups,omitempty"`
+	// ModelAliases maps client model names to routed models for this tenant only.
+	ModelAliases map[string]string `json:"model_aliases,omitempty"`
+	Disabled     bool              `json:"disabled,omitempty"`
+	// Blocked is set by the spend kill-switch and cleared by Unblock; Update keeps it.
+	Blocked       bool      `json:"blocked,omitempty"`
+	BlockedReason string    `json:"blocked_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Usage is a tenant's consumption for one UTC day.
+type Usage struct {
+	Day      string `json:"day"`
+	Requests int64  `json:"requests"`
+	Tokens   int64  `json:"tokens"`
+}
+
+type snapshot struct {
+	Tenants []*Tenant        `json:"tenants"`
+	Usage   map[string]Usage `json:"usage,omitempty"`
+}
+
+// Store holds tenants and their consumption for the current day.
+type Store struct {
+	mu        sync.RWMutex
+	path      string
+	loaded    bool
+	tenants   map[string]*Tenant
+	byKey     map[string]string
+	usage     map[string]Usage
+	dirty     bool
+	lastFlush time.Time
+
+	flushMu sync.Mutex
+	now     func() time.Time
+}
+
+var defaultStore = NewStore("")
+
+func init() {
+	coreusage.RegisterPlugin(defaultStore)
+}
+
+// Default returns the process-wide store fed by the default usage manager.
+func Default() *Store { return defaultStore }
+
+// NewStore creates a store persisted at path ("" keeps it in memory) and loads the
+// existing file when present.
+func NewStore(path string) *Store {
+	s := &Store{tenants: make(map[string]*Tenant), byKey: make(map[string]string), usage: make(map[string]Usage)}
+	s.SetPath(path)
+	return s
+}
+
+func (s *Store) currentTime() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+func (s *Store) today() string {
+	return s.currentTime().UTC().Format(dayLayout)
+}
+
+// SetPath switches the persistence file and reloads tenants from it. Calling it with the
+// current path is a no-op.
+func (s *Store) SetPath(path string) {
+	path = strings.TrimSpace(path)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.loaded && path == s.path {
+		return
+	}
+	s.loaded = true
+	s.path = path
+	s.tenants = make(map[string]*Tenant)
+	s.byKey = make(map[string]string)
+	s.usage = make(map[string]Usage)
+	s.dirty = false
+	s.lastFlush = s.currentTime()
+	if path == "" {
+		return
+	}
+	data, errRead := os.ReadFile(path)
+	if errRead != nil {
+		if !errors.Is(errRead, os.ErrNotExist) {
+			log.Warnf("tenant: failed to read %s: %v", path, errRead)
+		}
+		return
+	}
+	var snap snapshot
+	if errUnmarshal := json.Unmarshal(data, &snap); errUnmarshal != nil {
+		log.Warnf("tenant: failed to parse %s: %v", path, errUnmarshal)
+		return
+	}
+	for _, t := range snap.Tenants {
+		if t == nil || t.ID == "" || t.APIKey == "" {
+			continue
+		}
+		s.tenants[t.ID] = t
+		s.byKey[t.APIKey] = t.ID
+	}
+	for id, u := range snap.Usage {
+		if _, ok := s.tenants[id]; ok {
+			s.usage[id] = u
+		}
+	}
+}
+
+// List returns all tenants ordered by creation time.
+func (s *Store) List() []Tenant {
+	s.mu.RLock()
+	out := make([]Tenant, 0, len(s.tenants))
+	for _, t := range s.tenants {
+		out = append(out, *t)
+	}
+	s.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].CreatedAt.Before(out[j].CreatedAt)
+		}
+		return out[i].ID < out[j].ID
+	})
+	return out
+}
+
+// Get returns the tenant with the given ID.
+func (s *Store) Get(id string) (Tenant, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.tenants[id]
+	if !ok {
+		return Tenant{}, false
+	}
+	return *t, true
+}
+
+// Lookup returns the tenant bound to apiKey.
+func (s *Store) Lookup(apiKey string) (Tenant, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	id, ok := s.byKey[apiKey]
+	if !ok {
+		return Tenant{}, false
+	}
+	return *s.tenants[id], true
+}
+
+// Len reports how many tenants exist.
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.tenants)
+}
+
+// Create adds a tenant. A missing ID or API key is generated.
+func (s *Store) Create(t Tenant) (Tenant, error) {
+	t.ID = strings.TrimSpace(t.ID)
+	t.APIKey = strings.TrimSpace(t.APIKey)
+	if t.ID == "" {
+		t.ID = "tenant_" + randomHex(8)
+	}
+	if t.APIKey == "" {
+		t.APIKey = keyPrefix + randomHex(24)
+	}
+	now := s.currentTime().UTC()
+	t.CreatedAt, t.UpdatedAt = now, now
+
+	s.mu.Lock()
+	if _, exists := s.tenants[t.ID]; exists {
+		s.mu.Unlock()
+		return Tenant{}, fmt.Errorf("tenant %s already exists", t.ID)
+	}
+	if _, exists := s.byKey[t.APIKey]; exists {
+		s.mu.Unlock()
+		return Tenant{}, ErrDuplicateKey
+	}
+	stored := t
+	s.tenants[t.ID] = &stored
+	s.byKey[t.APIKey] = t.ID
+	s.dirty = true
+	s.mu.Unlock()
+	s.persist()
+	return t, nil
+}
+
+// Update replaces the mutable fields of a tenant. An empty APIKey keeps the current key.
+func (s *Store) Update(id string, update Tenant) (Tenant, error) {
+	s.mu.Lock()
+	current, ok := s.tenants[id]
+	if !ok {
+		s.mu.Unlock()
+		return Tenant{}, ErrNotFound
+	}
+	newKey := strings.TrimSpace(update.APIKey)
+	if newKey != "" && newKey != current.APIKey {
+		if _, exists := s.byKey[newKey]; exists {
+			s.mu.Unlock()
+			return Tenant{}, ErrDuplicateKey
+		}
+		delete(s.byKey, current.APIKey)
+		s.byKey[newKey] = id
+		current.APIKey = newKey
+	}
+	current.Name = update.Name
+	current.RequestsPerDay = update.RequestsPerDay
+	current.TokensPerDay = update.TokensPerDay
+	current.AllowedProviders = update.AllowedProviders
+	current.AuthGroups = update.AuthGroups
+	current.ModelAliases = update.ModelAliases
+	current.Disabled = update.Disabled
+	current.UpdatedAt = s.currentTime().UTC()
+	out := *current
+	s.dirty = true
+	s.mu.Unlock()
+	s.persist()
+	return out, nil
+}
+
+// Delete removes a tenant and its consumption.
+func (s *Store) Delete(id string) error {
+	s.mu.Lock()
+	current, ok := s.tenants[id]
+	if !ok {
+		s.mu.Unlock()
+		return ErrNotFound
+	}
+	delete(s.byKey, current.APIKey)
+	delete(s.tenants, id)
+	delete(s.usage, id)
+	s.dirty = true
+	s.mu.Unlock()
+	s.persist()
+	return nil
+}
+
+// Block rejects the tenant's requests with ErrQuotaExceeded until Unblock is called.
+func (s *Store) Block(id, reason string) error {
+	return s.setBlocked(id, true, strings.TrimSpace(reason))
+}
+
+// Unblock clears a block set by Block.
+func (s *Store) Unblock(id string) error {
+	return s.setBlocked(id, false, "")
+}
+
+func (s *Store) setBlocked(id string, blocked bool, reason string) error {
+	s.mu.Lock()
+	current, ok := s.tenants[id]
+	if !ok {
+		s.mu.Unlock()
+		return ErrNotFound
+	}
+	if current.Blocked == blocked && current.BlockedReason == reason {
+		s.mu.Unlock()
+		return nil
+	}
+	current.Blocked = blocked
+	current.BlockedReason = reason
+	current.UpdatedAt = s.currentTime().UTC()
+	s.dirty = true
+	s.mu.Unlock()
+	s.persist()
+	return nil
+}
+
+// UsageOf returns the tenant's consumption for the current UTC day.
+func (s *Store) UsageOf(id string) Usage {
+	today := s.today()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if u, ok := s.usage[id]; ok && u.Day == today {
+		return u
+	}
+	return Usage{Day: today}
+}
+
+// Authorize checks whether the tenant bound to apiKey may send another request. It
+// returns false when the key belongs to no tenant.
+func (s *Store) Authorize(apiKey string) (Tenant, bool, error) {
+	t, ok := s.Lookup(apiKey)
+	if !ok {
+		return Tenant{}, false, nil
+	}
+	if t.Disabled {
+		return t, true, ErrDisabled
+	}
+	if t.Blocked {
+		reason := t.BlockedReason
+		if reason == "" {
+			reason = "blocked"
+		}
+		return t, true, fmt.Errorf("%w: %s", ErrQuotaExceeded, reason)
+	}
+	u := s.UsageOf(t.ID)
+	if t.RequestsPerDay > 0 && u.Requests >= t.RequestsPerDay {
+		return t, true, fmt.Errorf("%w: %d/%d requests used today", ErrQuotaExceeded, u.Requests, t.RequestsPerDay)
+	}
+	if t.TokensPerDay > 0 && u.Tokens >= t.TokensPerDay {
+		return t, true, fmt.Errorf("%w: %d/%d tokens used today", ErrQuotaExceeded, u.Tokens, t.TokensPerDay)
+	}
+	return t, true, nil
+}
+
+// HandleUsage implements usage.Plugin and charges records made with a tenant key.
+func (s *Store) HandleUsage(_ context.Context, record coreusage.Record) {
+	if s == nil || record.Background != "" || record.APIKey == "" {
+		return
+	}
+	day := record.RequestedAt
+	if day.IsZero() {
+		day = s.currentTime()
+	}
+	dayKey := day.UTC().Format(dayLayout)
+
+	s.mu.Lock()
+	id, ok := s.byKey[record.APIKey]
+	if !ok {
+		s.mu.Unlock()
+		return
+	}
+	u := s.usage[id]
+	if u.Day != dayKey {
+		if u.Day > dayKey {
+			// A late record for a past day does not count against today.
+			s.mu.Unlock()
+			return
+		}
+		u = Usage{Day: dayKey}
+	}
+	u.Requests++
+	u.Tokens += record.Detail.TotalTokens
+	s.usage[id] = u
+	s.dirty = true
+	flushDue := s.path != "" && s.currentTime().Sub(s.lastFlush) >= usageFlushInterval
+	s.mu.Unlock()
+
+	if flushDue {
+		s.persist()
+	}
+}
+
+func (s *Store) persist() {
+	if errFlush := s.Flush(); errFlush != nil {
+		log.Warnf("tenant: %v", errFlush)
+	}
+}
+
+// Flush writes the tenants and current consumption to disk when anything changed.
+func (s *Store) Flush() error {
+	if s == nil {
+		return nil
+	}
+	s.flushMu.Lock()
+	defer s.flushMu.Unlock()
+
+	s.mu.Lock()
+	if s.path == "" || !s.dirty {
+		s.mu.Unlock()
+		return nil
+	}
+	snap := snapshot{Tenants: make([]*Tenant, 0, len(s.tenants)), Usage: make(map[string]Usage, len(s.usage))}
+	for _, t := range s.tenants {
+		copied := *t
+		snap.Tenants = append(snap.Tenants, &copied)
+	}
+	for id, u := range s.usage {
+		snap.Usage[id] = u
+	}
+	path := s.path
+	s.dirty = false
+	s.lastFlush = s.currentTime()
+	s.mu.Unlock()
+
+	sort.Slice(snap.Tenants, func(i, j int) bool { return snap.Tenants[i].ID < snap.Tenants[j].ID })
+	if errWrite := writeSnapshot(path, snap); errWrite != nil {
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+		return errWrite
+	}
+	return nil
+}
+
+func writeSnapshot(path string, snap snapshot) error {
+	data, errMarshal := json.MarshalIndent(snap, "", "  ")
+	if errMarshal != nil {
+		return fmt.Errorf("marshal tenants: %w", errMarshal)
+	}
+	if errMkdir := os.MkdirAll(filepath.Dir(path), 0o700); errMkdir != nil {
+		return fmt.Errorf("create tenants dir: %w", errMkdir)
+	}
+	tmp := path + ".tmp"
+	if errWrite := os.WriteFile(tmp, data, 0o600); errWrite != nil {
+		return fmt.Errorf("write tenants: %w", errWrite)
+	}
+	if errRename := os.Rename(tmp, path); errRename != nil {
+		return fmt.Errorf("replace tenants file: %w", errRename)
+	}
+	return nil
+}
+
+func randomHex(n int) string {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(buf)
+}
@@ -0,0 +1,85 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func TestStoreQuotas(t *testing.T) {
+	now := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	store := NewStore("")
+	store.now = func() time.Time { return now }
+
+	created, err := store.Create(Tenant{Name: "acme", RequestsPerDay: 2, TokensPerDay: 100})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if created.ID == "" || created.APIKey == "" {
+		t.Fatalf("expected generated id and key, got %+v", created)
+	}
+	if _, errDup := store.Create(Tenant{APIKey: created.APIKey}); !errors.Is(errDup, ErrDuplicateKey) {
+		t.Fatalf("expected ErrDuplicateKey, got %v", errDup)
+	}
+
+	record := coreusage.Record{APIKey: created.APIKey, RequestedAt: now, Detail: coreusage.Detail{TotalTokens: 40}}
+	store.HandleUsage(context.Background(), record)
+	if _, found, errAuth := store.Authorize(created.APIKey); !found || errAuth != nil {
+		t.Fatalf("Authorize after one request: found=%v err=%v", found, errAuth)
+	}
+	store.HandleUsage(context.Background(), record)
+	if _, _, errAuth := store.Authorize(created.APIKey); !errors.Is(errAuth, ErrQuotaExceeded) {
+		t.Fatalf("expected request quota to be exceeded, got %v", errAuth)
+	}
+	if usage := store.UsageOf(created.ID); usage.Requests != 2 || usage.Tokens != 80 {
+		t.Fatalf("usage = %+v", usage)
+	}
+
+	now = now.Add(2 * time.Hour)
+	if _, _, errAuth := store.Authorize(created.APIKey); errAuth != nil {
+		t.Fatalf("quota should reset on the next UTC day, got %v", errAuth)
+	}
+
+	if _, errUpdate := store.Update(created.ID, Tenant{Name: "acme", Disabled: true}); errUpdate != nil {
+		t.Fatalf("Update: %v", errUpdate)
+	}
+	if _, _, errAuth := store.Authorize(created.APIKey); !errors.Is(errAuth, ErrDisabled) {
+		t.Fatalf("expected ErrDisabled, got %v", errAuth)
+	}
+	if _, found, _ := store.Authorize("unknown"); found {
+		t.Fatal("unknown keys must not match a tenant")
+	}
+}
+
+func TestStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	store := NewStore(path)
+	created, err := store.Create(Tenant{Name: "acme", APIKey: "sk-acme", TokensPerDay: 10})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	store.HandleUsage(context.Background(), coreusage.Record{APIKey: "sk-acme", Detail: coreusage.Detail{TotalTokens: 7}})
+	if errFlush := store.Flush(); errFlush != nil {
+		t.Fatalf("Flush: %v", errFlush)
+	}
+
+	reloaded := NewStore(path)
+	got, ok := reloaded.Lookup("sk-acme")
+	if !ok || got.ID != created.ID || got.TokensPerDay != 10 {
+		t.Fatalf("reloaded tenant = %+v, ok=%v", got, ok)
+	}
+	if usage := reloaded.UsageOf(created.ID); usage.Tokens != 7 || usage.Requests != 1 {
+		t.Fatalf("reloaded usage = %+v", usage)
+	}
+
+	if errDelete := reloaded.Delete(created.ID); errDelete != nil {
+		t.Fatalf("Delete: %v", errDelete)
+	}
+	if NewStore(path).Len() != 0 {
+		t.Fatal("delete should be persisted")
+	}
+}
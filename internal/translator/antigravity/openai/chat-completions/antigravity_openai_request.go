@@ -122,6 +122,9 @@ func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _
 		}
 	}
 
+	// OpenAI response_format -> request.generationConfig.responseMimeType / responseJsonSchema
+	out = applyOpenAIResponseFormatToAntigravity(out, rawJSON)
+
 	// messages -> systemInstruction + contents
 	messages := gjson.GetBytes(rawJSON, "messages")
 	if messages.IsArray() {
@@ -610,3 +613,19 @@ func antigravityOpenAIDefaultIncludeThoughts(modelName string) bool {
 	modelName = strings.ToLower(modelName)
 	return strings.Contains(modelName, "gemini-3")
 }
+
+// applyOpenAIResponseFormatToAntigravity maps OpenAI response_format onto Gemini structured output:
+// json_object requests JSON, json_schema additionally constrains it with responseJsonSchema.
+func applyOpenAIResponseFormatToAntigravity(out, rawJSON []byte) []byte {
+	responseFormat := gjson.GetBytes(rawJSON, "response_format")
+	switch strings.ToLower(strings.TrimSpace(responseFormat.Get("type").String())) {
+	case "json_object":
+		out, _ = sjson.SetBytes(out, "request.generationConfig.responseMimeType", "application/json")
+	case "json_schema":
+		out, _ = sjson.SetBytes(out, "request.generationConfig.responseMimeType", "application/json")
+		if schema := responseFormat.Get("json_schema.schema"); schema.IsObject() {
+			out, _ = sjson.SetRawBytes(out, "request.generationConfig.responseJsonSchema", []byte(schema.Raw))
+		}
+	}
+	return out
+}
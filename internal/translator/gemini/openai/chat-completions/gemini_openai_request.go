@@ -123,6 +123,9 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 		}
 	}
 
+	// OpenAI response_format -> generationConfig.responseMimeType / responseJsonSchema
+	out = applyOpenAIResponseFormatToGemini(out, rawJSON)
+
 	// messages -> systemInstruction + contents
 	messages := gjson.GetBytes(rawJSON, "messages")
 	if messages.IsArray() {
@@ -492,3 +495,19 @@ func openAIInputAudioMimeType(audioFormat string) string {
 		return "audio/" + audioFormat
 	}
 }
+
+// applyOpenAIResponseFormatToGemini maps OpenAI response_format onto Gemini structured output:
+// json_object requests JSON, json_schema additionally constrains it with responseJsonSchema.
+func applyOpenAIResponseFormatToGemini(out, rawJSON []byte) []byte {
+	responseFormat := gjson.GetBytes(rawJSON, "response_format")
+	switch strings.ToLower(strings.TrimSpace(responseFormat.Get("type").String())) {
+	case "json_object":
+		out, _ = sjson.SetBytes(out, "generationConfig.responseMimeType", "application/json")
+	case "json_schema":
+		out, _ = sjson.SetBytes(out, "generationConfig.responseMimeType", "application/json")
+		if schema := responseFormat.Get("json_schema.schema"); schema.IsObject() {
+			out, _ = sjson.SetRawBytes(out, "generationConfig.responseJsonSchema", []byte(schema.Raw))
+		}
+	}
+	return out
+}
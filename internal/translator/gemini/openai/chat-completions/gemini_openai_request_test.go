@@ -343,3 +343,24 @@ func TestConvertOpenAIRequestToGeminiCleansToolSchemaRequiredFields(t *testing.T
 		t.Fatalf("required[1] = %q, want industry. Schema: %s", got, schema.Raw)
 	}
 }
+
+func TestConvertOpenAIRequestToGeminiMapsResponseFormat(t *testing.T) {
+	input := []byte(`{
+		"messages":[{"role":"user","content":"hi"}],
+		"response_format":{"type":"json_schema","json_schema":{"name":"person","schema":{"type":"object","properties":{"name":{"type":"string"}},"required":["name"]}}}
+	}`)
+
+	out := ConvertOpenAIRequestToGemini("gemini-2.5-pro", input, false)
+
+	if got := gjson.GetBytes(out, "generationConfig.responseMimeType").String(); got != "application/json" {
+		t.Fatalf("responseMimeType = %q, body=%s", got, out)
+	}
+	if got := gjson.GetBytes(out, "generationConfig.responseJsonSchema.required.0").String(); got != "name" {
+		t.Fatalf("responseJsonSchema not mapped, body=%s", out)
+	}
+
+	jsonObject := ConvertOpenAIRequestToGemini("gemini-2.5-pro", []byte(`{"messages":[{"role":"user","content":"hi"}],"response_format":{"type":"json_object"}}`), false)
+	if gjson.GetBytes(jsonObject, "generationConfig.responseJsonSchema").Exists() || gjson.GetBytes(jsonObject, "generationConfig.responseMimeType").String() != "application/json" {
+		t.Fatalf("unexpected json_object mapping, body=%s", jsonObject)
+	}
+}
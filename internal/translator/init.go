@@ -33,5 +33,7 @@ import (
 	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/antigravity/openai/chat-completions"
 	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/antigravity/openai/responses"
 	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/kiro/claude"
+	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/kiro/gemini"
 	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/kiro/openai"
+	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/kiro/openai/responses"
 )
@@ -0,0 +1,20 @@
+// Package gemini provides translation between native Gemini generateContent and Kiro formats.
+package gemini
+
+import (
+	. "github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/translator/translator"
+)
+
+func init() {
+	translator.Register(
+		Gemini, // source format
+		Kiro,   // target format
+		ConvertGeminiRequestToKiro,
+		interfaces.TranslateResponse{
+			Stream:    ConvertKiroStreamToGemini,
+			NonStream: ConvertKiroNonStreamToGemini,
+		},
+	)
+}
@@ -0,0 +1,40 @@
+package gemini
+
+import (
+	"bytes"
+	"context"
+
+	claudegemini "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/claude/gemini"
+	kiroopenai "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/kiro/openai"
+	openaigemini "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/openai/gemini"
+)
+
+// ConvertGeminiRequestToKiro converts a native Gemini generateContent request into a
+// Claude Messages body. The Kiro executor's default payload builder consumes Claude
+// format, so no Gemini-specific builder is needed.
+func ConvertGeminiRequestToKiro(modelName string, inputRawJSON []byte, stream bool) []byte {
+	return claudegemini.ConvertGeminiRequestToClaude(modelName, inputRawJSON, stream)
+}
+
+// ConvertKiroStreamToGemini converts Kiro streaming events to Gemini streamGenerateContent
+// chunks. The Kiro executor emits Claude-compatible events (event: xxx\ndata: {...}), so
+// each data line is handed to the Claude → Gemini stream converter.
+func ConvertKiroStreamToGemini(ctx context.Context, model string, originalRequest, request, rawResponse []byte, param *any) [][]byte {
+	var results [][]byte
+	for _, line := range bytes.Split(rawResponse, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		results = append(results, claudegemini.ConvertClaudeResponseToGemini(ctx, model, originalRequest, request, line, param)...)
+	}
+	return results
+}
+
+// ConvertKiroNonStreamToGemini converts a Kiro non-streaming response to a Gemini
+// generateContent response. The Claude-compatible response is first rendered as a Chat
+// Completions response, which the OpenAI → Gemini converter then reshapes.
+func ConvertKiroNonStreamToGemini(ctx context.Context, model string, originalRequest, request, rawResponse []byte, param *any) []byte {
+	chat := kiroopenai.ConvertKiroNonStreamToOpenAI(ctx, model, originalRequest, request, rawResponse, param)
+	return openaigemini.ConvertOpenAIResponseToGeminiNonStream(ctx, model, originalRequest, request, chat, param)
+}
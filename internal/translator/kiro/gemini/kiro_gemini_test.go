@@ -0,0 +1,57 @@
+package gemini
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertGeminiRequestToKiroProducesClaudeMessages(t *testing.T) {
+	input := []byte(`{"system_instruction":{"parts":[{"text":"Be brief."}]},"contents":[{"role":"user","parts":[{"text":"Hello"}]}]}`)
+	out := ConvertGeminiRequestToKiro("kiro-claude-sonnet-4-5", input, false)
+
+	if got := gjson.GetBytes(out, "messages.#(role==\"user\")#").Array(); len(got) == 0 {
+		t.Fatalf("no user messages: %s", out)
+	}
+	if !strings.Contains(string(out), "Be brief.") {
+		t.Fatalf("system prompt missing: %s", out)
+	}
+}
+
+func TestConvertKiroStreamToGeminiEmitsCandidates(t *testing.T) {
+	events := []string{
+		"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"usage\":{\"input_tokens\":3,\"output_tokens\":0}}}",
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}",
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hi\"}}",
+		"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":1}}",
+	}
+
+	var param any
+	var text strings.Builder
+	var finishReason string
+	for _, event := range events {
+		for _, chunk := range ConvertKiroStreamToGemini(context.Background(), "kiro-claude-sonnet-4-5", nil, nil, []byte(event), &param) {
+			text.WriteString(gjson.GetBytes(chunk, "candidates.0.content.parts.0.text").String())
+			if reason := gjson.GetBytes(chunk, "candidates.0.finishReason").String(); reason != "" {
+				finishReason = reason
+			}
+		}
+	}
+	if text.String() != "Hi" {
+		t.Fatalf("streamed text = %q, want Hi", text.String())
+	}
+	if finishReason != "STOP" {
+		t.Fatalf("finishReason = %q, want STOP", finishReason)
+	}
+}
+
+func TestConvertKiroNonStreamToGemini(t *testing.T) {
+	raw := []byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"Hi there"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":2}}`)
+	out := ConvertKiroNonStreamToGemini(context.Background(), "kiro-claude-sonnet-4-5", nil, nil, raw, nil)
+
+	if got := gjson.GetBytes(out, "candidates.0.content.parts.0.text").String(); got != "Hi there" {
+		t.Fatalf("candidate text = %q: %s", got, out)
+	}
+}
@@ -0,0 +1,20 @@
+// Package responses provides translation between the OpenAI Responses API and Kiro formats.
+package responses
+
+import (
+	. "github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/translator/translator"
+)
+
+func init() {
+	translator.Register(
+		OpenaiResponse, // source format
+		Kiro,           // target format
+		ConvertOpenAIResponsesRequestToKiro,
+		interfaces.TranslateResponse{
+			Stream:    ConvertKiroStreamToOpenAIResponses,
+			NonStream: ConvertKiroNonStreamToOpenAIResponses,
+		},
+	)
+}
@@ -0,0 +1,40 @@
+package responses
+
+import (
+	"bytes"
+	"context"
+
+	clauderesponses "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/claude/openai/responses"
+	kiroopenai "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/kiro/openai"
+	openairesponses "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/openai/openai/responses"
+)
+
+// ConvertOpenAIResponsesRequestToKiro converts an OpenAI Responses request into an OpenAI
+// Chat Completions body. The Kiro executor builds the final payload from it with
+// BuildKiroPayloadFromOpenAI, the same way it handles /v1/chat/completions requests.
+func ConvertOpenAIResponsesRequestToKiro(modelName string, inputRawJSON []byte, stream bool) []byte {
+	return openairesponses.ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName, inputRawJSON, stream)
+}
+
+// ConvertKiroStreamToOpenAIResponses converts Kiro streaming events to OpenAI Responses
+// SSE events. The Kiro executor emits Claude-compatible events (event: xxx\ndata: {...}),
+// so each data line is handed to the Claude → Responses stream converter.
+func ConvertKiroStreamToOpenAIResponses(ctx context.Context, model string, originalRequest, request, rawResponse []byte, param *any) [][]byte {
+	var results [][]byte
+	for _, line := range bytes.Split(rawResponse, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		results = append(results, clauderesponses.ConvertClaudeResponseToOpenAIResponses(ctx, model, originalRequest, request, line, param)...)
+	}
+	return results
+}
+
+// ConvertKiroNonStreamToOpenAIResponses converts a Kiro non-streaming response to an
+// OpenAI Responses object. The Claude-compatible response is first rendered as a Chat
+// Completions response, which the OpenAI → Responses converter then reshapes.
+func ConvertKiroNonStreamToOpenAIResponses(ctx context.Context, model string, originalRequest, request, rawResponse []byte, param *any) []byte {
+	chat := kiroopenai.ConvertKiroNonStreamToOpenAI(ctx, model, originalRequest, request, rawResponse, param)
+	return openairesponses.ConvertOpenAIChatCompletionsResponseToOpenAIResponsesNonStream(ctx, model, originalRequest, request, chat, param)
+}
@@ -0,0 +1,61 @@
+package responses
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIResponsesRequestToKiroProducesChatMessages(t *testing.T) {
+	input := []byte(`{"model":"kiro-claude-sonnet-4-5","instructions":"Be brief.","input":"Hello","stream":true}`)
+	out := ConvertOpenAIResponsesRequestToKiro("kiro-claude-sonnet-4-5", input, true)
+
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 2 {
+		t.Fatalf("messages = %s, want system + user", gjson.GetBytes(out, "messages").Raw)
+	}
+	if messages[0].Get("role").String() != "system" || messages[1].Get("role").String() != "user" {
+		t.Fatalf("unexpected roles: %s", gjson.GetBytes(out, "messages").Raw)
+	}
+}
+
+func TestConvertKiroStreamToOpenAIResponsesEmitsTextDeltas(t *testing.T) {
+	events := []string{
+		"event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"usage\":{\"input_tokens\":3,\"output_tokens\":0}}}",
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}",
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hi\"}}",
+		"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}",
+		"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":1}}",
+		"event: message_stop\ndata: {\"type\":\"message_stop\"}",
+	}
+
+	var param any
+	var joined strings.Builder
+	for _, event := range events {
+		for _, chunk := range ConvertKiroStreamToOpenAIResponses(context.Background(), "kiro-claude-sonnet-4-5", nil, nil, []byte(event), &param) {
+			joined.Write(chunk)
+			joined.WriteString("\n")
+		}
+	}
+
+	got := joined.String()
+	for _, want := range []string{"response.created", "response.output_text.delta", "response.completed"} {
+		if !strings.Contains(got, want) {
+			t.Fatalf("stream output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestConvertKiroNonStreamToOpenAIResponses(t *testing.T) {
+	raw := []byte(`{"id":"msg_1","type":"message","role":"assistant","content":[{"type":"text","text":"Hi there"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":2}}`)
+	out := ConvertKiroNonStreamToOpenAIResponses(context.Background(), "kiro-claude-sonnet-4-5", nil, nil, raw, nil)
+
+	if got := gjson.GetBytes(out, "object").String(); got != "response" {
+		t.Fatalf("object = %q, want response: %s", got, out)
+	}
+	if got := gjson.GetBytes(out, `output.#(type=="message").content.0.text`).String(); got != "Hi there" {
+		t.Fatalf("output text = %q: %s", got, out)
+	}
+}
@@ -16,4 +16,5 @@ func init() {
 			NonStream: ConvertOpenAIResponseToOpenAINonStream,
 		},
 	)
+	translator.RegisterPassthrough(OpenAI)
 }
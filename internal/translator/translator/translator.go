@@ -25,6 +25,14 @@ func Register(from, to string, request interfaces.TranslateRequestFunc, response
 	registry.Register(sdktranslator.FromString(from), sdktranslator.FromString(to), request, response)
 }
 
+// RegisterPassthrough marks a format whose same-format translators may be skipped.
+//
+// Parameters:
+//   - format: The API format identifier
+func RegisterPassthrough(format string) {
+	registry.RegisterPassthrough(sdktranslator.FromString(format))
+}
+
 // Request translates a request from one API format to another.
 //
 // Parameters:
@@ -0,0 +1,146 @@
+package util
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// ModelMapper applies an ordered list of model mapping rules.
+type ModelMapper struct {
+	rules []modelMappingRule
+}
+
+type modelMappingRule struct {
+	index   int
+	to      string
+	literal bool
+	re      *regexp.Regexp
+}
+
+// NewModelMapper compiles mappings. Rules with an empty side or an invalid pattern are
+// skipped and reported in the returned errors; the mapper still applies the others.
+func NewModelMapper(mappings []config.ModelMapping) (*ModelMapper, []error) {
+	mapper := &ModelMapper{rules: make([]modelMappingRule, 0, len(mappings))}
+	var errs []error
+	for i, mapping := range mappings {
+		from := strings.TrimSpace(mapping.From)
+		to := strings.TrimSpace(mapping.To)
+		if from == "" || to == "" {
+			errs = append(errs, fmt.Errorf("model-mappings[%d]: from and to are required", i))
+			continue
+		}
+		rule := modelMappingRule{index: i, to: to}
+		pattern := ""
+		switch {
+		case mapping.Regex:
+			pattern = "(?i)^(?:" + from + ")$"
+		case strings.Contains(from, "*"):
+			parts := strings.Split(from, "*")
+			for j := range parts {
+				parts[j] = regexp.QuoteMeta(parts[j])
+			}
+			pattern = "(?i)^" + strings.Join(parts, "(.*)") + "$"
+		default:
+			rule.literal = true
+			pattern = "(?i)^" + regexp.QuoteMeta(from) + "$"
+		}
+		re, errCompile := regexp.Compile(pattern)
+		if errCompile != nil {
+			errs = append(errs, fmt.Errorf("model-mappings[%d]: invalid pattern %q: %w", i, from, errCompile))
+			continue
+		}
+		rule.re = re
+		mapper.rules = append(mapper.rules, rule)
+	}
+	return mapper, errs
+}
+
+// Map returns the target of the first rule matching model and that rule's index in the
+// configured list. ok is false when no rule matches.
+func (m *ModelMapper) Map(model string) (target string, rule int, ok bool) {
+	if m == nil {
+		return "", -1, false
+	}
+	model = strings.TrimSpace(model)
+	if model == "" {
+		return "", -1, false
+	}
+	for _, r := range m.rules {
+		match := r.re.FindStringSubmatchIndex(model)
+		if match == nil {
+			continue
+		}
+		if r.literal {
+			return r.to, r.index, true
+		}
+		return string(r.re.ExpandString(nil, r.to, model, match)), r.index, true
+	}
+	return "", -1, false
+}
+
+// ModelMappingShadow reports a rule that can never apply because an earlier rule already
+// matches every model name it matches.
+type ModelMappingShadow struct {
+	Rule       int
+	ShadowedBy int
+}
+
+// FindShadowedModelMappings lists the rules that are unreachable behind an earlier rule.
+// Literal and glob rules are compared exactly; a regex rule can shadow a literal rule, but
+// a regex rule itself is never reported since its language cannot be compared in general.
+func FindShadowedModelMappings(mappings []config.ModelMapping) []ModelMappingShadow {
+	var shadows []ModelMappingShadow
+	for j, later := range mappings {
+		laterFrom := strings.TrimSpace(later.From)
+		if later.Regex || laterFrom == "" {
+			continue
+		}
+		for i := 0; i < j; i++ {
+			earlier := mappings[i]
+			earlierFrom := strings.TrimSpace(earlier.From)
+			if earlierFrom == "" || strings.TrimSpace(earlier.To) == "" {
+				continue
+			}
+			shadowed := false
+			if earlier.Regex {
+				if !strings.Contains(laterFrom, "*") {
+					re, errCompile := regexp.Compile("(?i)^(?:" + earlierFrom + ")$")
+					shadowed = errCompile == nil && re.MatchString(laterFrom)
+				}
+			} else {
+				shadowed = globCovers(strings.ToLower(earlierFrom), strings.ToLower(laterFrom))
+			}
+			if shadowed {
+				shadows = append(shadows, ModelMappingShadow{Rule: j, ShadowedBy: i})
+				break
+			}
+		}
+	}
+	return shadows
+}
+
+// globCovers reports whether every name matched by glob q is also matched by glob p: p must
+// match q's text, where a "*" in q can only be consumed by a "*" in p.
+func globCovers(p, q string) bool {
+	if p == "" {
+		return q == ""
+	}
+	if p[0] == '*' {
+		for k := 0; k <= len(q); k++ {
+			if globCovers(p[1:], q[k:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if q == "" || q[0] == '*' {
+		return false
+	}
+	pr, pSize := utf8.DecodeRuneInString(p)
+	qr, qSize := utf8.DecodeRuneInString(q)
+	return pr == qr && globCovers(p[pSize:], q[qSize:])
+}
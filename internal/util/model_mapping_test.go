@@ -0,0 +1,57 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestModelMapperMap(t *testing.T) {
+	mapper, errs := NewModelMapper([]config.ModelMapping{
+		{From: "gpt-4o", To: "claude-sonnet-4-5"},
+		{From: "gpt-4*", To: "gemini-2.5-pro"},
+		{From: "claude-*-latest", To: "claude-$1-4-5"},
+		{From: `(gemini-2\.5-\w+)\((\w+)\)`, To: "${1}-preview(${2})", Regex: true},
+		{From: "bad-(", To: "x", Regex: true},
+		{From: "", To: "x"},
+	})
+	if len(errs) != 2 {
+		t.Fatalf("errs = %v, want 2 errors", errs)
+	}
+	cases := []struct {
+		model    string
+		want     string
+		wantRule int
+	}{
+		{model: "GPT-4o", want: "claude-sonnet-4-5", wantRule: 0},
+		{model: "gpt-4.1-mini", want: "gemini-2.5-pro", wantRule: 1},
+		{model: "claude-opus-latest", want: "claude-opus-4-5", wantRule: 2},
+		{model: "gemini-2.5-flash(high)", want: "gemini-2.5-flash-preview(high)", wantRule: 3},
+	}
+	for _, tc := range cases {
+		got, rule, ok := mapper.Map(tc.model)
+		if !ok || got != tc.want || rule != tc.wantRule {
+			t.Errorf("Map(%q) = %q, %d, %t, want %q, %d", tc.model, got, rule, ok, tc.want, tc.wantRule)
+		}
+	}
+	if _, _, ok := mapper.Map("gpt-3.5"); ok {
+		t.Error("Map(gpt-3.5) matched, want no match")
+	}
+}
+
+func TestFindShadowedModelMappings(t *testing.T) {
+	mappings := []config.ModelMapping{
+		{From: "gpt-4*", To: "a"},
+		{From: "gpt-4o", To: "b"},
+		{From: "gpt-4o-*", To: "c"},
+		{From: "gpt-*", To: "d"},
+		{From: "claude-(.*)", To: "e", Regex: true},
+		{From: "claude-opus", To: "f"},
+		{From: "claude-*", To: "g"},
+	}
+	want := []ModelMappingShadow{{Rule: 1, ShadowedBy: 0}, {Rule: 2, ShadowedBy: 0}, {Rule: 5, ShadowedBy: 4}}
+	if got := FindShadowedModelMappings(mappings); !reflect.DeepEqual(got, want) {
+		t.Fatalf("FindShadowedModelMappings = %+v, want %+v", got, want)
+	}
+}
@@ -21,6 +21,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.Port != newCfg.Port {
 		changes = append(changes, fmt.Sprintf("port: %d -> %d", oldCfg.Port, newCfg.Port))
 	}
+	if oldCfg.ReusePort != newCfg.ReusePort {
+		changes = append(changes, fmt.Sprintf("reuse-port: %t -> %t", oldCfg.ReusePort, newCfg.ReusePort))
+	}
 	if oldCfg.AuthDir != newCfg.AuthDir {
 		changes = append(changes, fmt.Sprintf("auth-dir: %s -> %s", oldCfg.AuthDir, newCfg.AuthDir))
 	}
@@ -51,6 +54,12 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.TransientErrorCooldownSeconds != newCfg.TransientErrorCooldownSeconds {
 		changes = append(changes, fmt.Sprintf("transient-error-cooldown-seconds: %d -> %d", oldCfg.TransientErrorCooldownSeconds, newCfg.TransientErrorCooldownSeconds))
 	}
+	if !reflect.DeepEqual(oldCfg.RetryBackoff, newCfg.RetryBackoff) {
+		changes = append(changes, fmt.Sprintf("retry-backoff: %d -> %d providers", len(oldCfg.RetryBackoff), len(newCfg.RetryBackoff)))
+	}
+	if !reflect.DeepEqual(oldCfg.QuotaResets, newCfg.QuotaResets) {
+		changes = append(changes, fmt.Sprintf("quota-resets: %d -> %d providers", len(oldCfg.QuotaResets), len(newCfg.QuotaResets)))
+	}
 	if oldCfg.DisableClaudeCloakMode != newCfg.DisableClaudeCloakMode {
 		changes = append(changes, fmt.Sprintf("disable-claude-cloak-mode: %t -> %t", oldCfg.DisableClaudeCloakMode, newCfg.DisableClaudeCloakMode))
 	}
@@ -69,6 +78,12 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.ErrorLogsMaxFiles != newCfg.ErrorLogsMaxFiles {
 		changes = append(changes, fmt.Sprintf("error-logs-max-files: %d -> %d", oldCfg.ErrorLogsMaxFiles, newCfg.ErrorLogsMaxFiles))
 	}
+	if oldCfg.AuthAutoRefreshJitter != newCfg.AuthAutoRefreshJitter {
+		changes = append(changes, fmt.Sprintf("auth-auto-refresh-jitter: %s -> %s", oldCfg.AuthAutoRefreshJitter, newCfg.AuthAutoRefreshJitter))
+	}
+	if oldCfg.AuthAutoRefreshProviderLimit != newCfg.AuthAutoRefreshProviderLimit {
+		changes = append(changes, fmt.Sprintf("auth-auto-refresh-provider-limit: %d -> %d", oldCfg.AuthAutoRefreshProviderLimit, newCfg.AuthAutoRefreshProviderLimit))
+	}
 	if oldCfg.RequestRetry != newCfg.RequestRetry {
 		changes = append(changes, fmt.Sprintf("request-retry: %d -> %d", oldCfg.RequestRetry, newCfg.RequestRetry))
 	}
@@ -81,15 +96,159 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.ProxyURL != newCfg.ProxyURL {
 		changes = append(changes, fmt.Sprintf("proxy-url: %s -> %s", formatProxyURL(oldCfg.ProxyURL), formatProxyURL(newCfg.ProxyURL)))
 	}
+	if !reflect.DeepEqual(oldCfg.ProxyPools, newCfg.ProxyPools) {
+		changes = append(changes, fmt.Sprintf("proxy-pools: %d -> %d pools (updated)", len(oldCfg.ProxyPools), len(newCfg.ProxyPools)))
+	}
+	if !reflect.DeepEqual(oldCfg.OutboundTLS, newCfg.OutboundTLS) {
+		changes = append(changes, "outbound-tls: updated")
+	}
+	if oldCfg.TLS.Enable != newCfg.TLS.Enable {
+		changes = append(changes, fmt.Sprintf("tls.enable: %t -> %t", oldCfg.TLS.Enable, newCfg.TLS.Enable))
+	}
+	if strings.TrimSpace(oldCfg.TLS.Cert) != strings.TrimSpace(newCfg.TLS.Cert) || strings.TrimSpace(oldCfg.TLS.Key) != strings.TrimSpace(newCfg.TLS.Key) {
+		changes = append(changes, "tls.cert/tls.key: updated")
+	}
+	if !reflect.DeepEqual(oldCfg.TLS.ACME, newCfg.TLS.ACME) {
+		changes = append(changes, "tls.acme: updated")
+	}
 	if oldCfg.WebsocketAuth != newCfg.WebsocketAuth {
 		changes = append(changes, fmt.Sprintf("ws-auth: %t -> %t", oldCfg.WebsocketAuth, newCfg.WebsocketAuth))
 	}
+	if oldCfg.ShutdownDrainTimeout != newCfg.ShutdownDrainTimeout {
+		changes = append(changes, fmt.Sprintf("shutdown-drain-timeout: %s -> %s", oldCfg.ShutdownDrainTimeout, newCfg.ShutdownDrainTimeout))
+	}
+	if oldCfg.RequestLimits != newCfg.RequestLimits {
+		changes = append(changes, fmt.Sprintf("request-limits: %+v -> %+v", oldCfg.RequestLimits, newCfg.RequestLimits))
+	}
 	if oldCfg.ForceModelPrefix != newCfg.ForceModelPrefix {
 		changes = append(changes, fmt.Sprintf("force-model-prefix: %t -> %t", oldCfg.ForceModelPrefix, newCfg.ForceModelPrefix))
 	}
 	if oldCfg.NonStreamKeepAliveInterval != newCfg.NonStreamKeepAliveInterval {
 		changes = append(changes, fmt.Sprintf("nonstream-keepalive-interval: %d -> %d", oldCfg.NonStreamKeepAliveInterval, newCfg.NonStreamKeepAliveInterval))
 	}
+	if oldCfg.Idempotency.Enabled != newCfg.Idempotency.Enabled {
+		changes = append(changes, fmt.Sprintf("idempotency.enabled: %t -> %t", oldCfg.Idempotency.Enabled, newCfg.Idempotency.Enabled))
+	}
+	if strings.TrimSpace(oldCfg.Idempotency.TTL) != strings.TrimSpace(newCfg.Idempotency.TTL) {
+		changes = append(changes, fmt.Sprintf("idempotency.ttl: %s -> %s", strings.TrimSpace(oldCfg.Idempotency.TTL), strings.TrimSpace(newCfg.Idempotency.TTL)))
+	}
+	if oldCfg.Idempotency.MaxEntries != newCfg.Idempotency.MaxEntries {
+		changes = append(changes, fmt.Sprintf("idempotency.max-entries: %d -> %d", oldCfg.Idempotency.MaxEntries, newCfg.Idempotency.MaxEntries))
+	}
+	if strings.TrimSpace(oldCfg.Idempotency.PersistPath) != strings.TrimSpace(newCfg.Idempotency.PersistPath) {
+		changes = append(changes, fmt.Sprintf("idempotency.persist-path: %s -> %s", strings.TrimSpace(oldCfg.Idempotency.PersistPath), strings.TrimSpace(newCfg.Idempotency.PersistPath)))
+	}
+	if oldCfg.CostCeiling.MaxCost != newCfg.CostCeiling.MaxCost {
+		changes = append(changes, fmt.Sprintf("cost-ceiling.max-cost: %g -> %g", oldCfg.CostCeiling.MaxCost, newCfg.CostCeiling.MaxCost))
+	}
+	if !reflect.DeepEqual(oldCfg.CostCeiling.APIKeys, newCfg.CostCeiling.APIKeys) {
+		changes = append(changes, fmt.Sprintf("cost-ceiling.api-keys: updated (%d -> %d entries)", len(oldCfg.CostCeiling.APIKeys), len(newCfg.CostCeiling.APIKeys)))
+	}
+	if oldCfg.RequestCoalescing.Enabled != newCfg.RequestCoalescing.Enabled {
+		changes = append(changes, fmt.Sprintf("request-coalescing.enabled: %t -> %t", oldCfg.RequestCoalescing.Enabled, newCfg.RequestCoalescing.Enabled))
+	}
+	if strings.TrimSpace(oldCfg.RequestCoalescing.Window) != strings.TrimSpace(newCfg.RequestCoalescing.Window) {
+		changes = append(changes, fmt.Sprintf("request-coalescing.window: %s -> %s", strings.TrimSpace(oldCfg.RequestCoalescing.Window), strings.TrimSpace(newCfg.RequestCoalescing.Window)))
+	}
+	if strings.TrimSpace(oldCfg.RequestDeadline.Timeout) != strings.TrimSpace(newCfg.RequestDeadline.Timeout) {
+		changes = append(changes, fmt.Sprintf("request-deadline.timeout: %s -> %s", strings.TrimSpace(oldCfg.RequestDeadline.Timeout), strings.TrimSpace(newCfg.RequestDeadline.Timeout)))
+	}
+	if oldCfg.ResponseCache.Enabled != newCfg.ResponseCache.Enabled {
+		changes = append(changes, fmt.Sprintf("response-cache.enabled: %t -> %t", oldCfg.ResponseCache.Enabled, newCfg.ResponseCache.Enabled))
+	}
+	if strings.TrimSpace(oldCfg.ResponseCache.TTL) != strings.TrimSpace(newCfg.ResponseCache.TTL) {
+		changes = append(changes, fmt.Sprintf("response-cache.ttl: %s -> %s", strings.TrimSpace(oldCfg.ResponseCache.TTL), strings.TrimSpace(newCfg.ResponseCache.TTL)))
+	}
+	if oldCfg.ResponseCache.MaxEntries != newCfg.ResponseCache.MaxEntries {
+		changes = append(changes, fmt.Sprintf("response-cache.max-entries: %d -> %d", oldCfg.ResponseCache.MaxEntries, newCfg.ResponseCache.MaxEntries))
+	}
+	if strings.TrimSpace(oldCfg.ResponseCache.Dir) != strings.TrimSpace(newCfg.ResponseCache.Dir) {
+		changes = append(changes, fmt.Sprintf("response-cache.dir: %s -> %s", strings.TrimSpace(oldCfg.ResponseCache.Dir), strings.TrimSpace(newCfg.ResponseCache.Dir)))
+	}
+	if oldCfg.Batch.Enabled != newCfg.Batch.Enabled {
+		changes = append(changes, fmt.Sprintf("batch.enabled: %t -> %t", oldCfg.Batch.Enabled, newCfg.Batch.Enabled))
+	}
+	if strings.TrimSpace(oldCfg.Batch.Dir) != strings.TrimSpace(newCfg.Batch.Dir) {
+		changes = append(changes, fmt.Sprintf("batch.dir: %s -> %s", strings.TrimSpace(oldCfg.Batch.Dir), strings.TrimSpace(newCfg.Batch.Dir)))
+	}
+	if oldCfg.Batch.Concurrency != newCfg.Batch.Concurrency {
+		changes = append(changes, fmt.Sprintf("batch.concurrency: %d -> %d", oldCfg.Batch.Concurrency, newCfg.Batch.Concurrency))
+	}
+	if oldCfg.Batch.MaxRequests != newCfg.Batch.MaxRequests {
+		changes = append(changes, fmt.Sprintf("batch.max-requests: %d -> %d", oldCfg.Batch.MaxRequests, newCfg.Batch.MaxRequests))
+	}
+	if oldCfg.Batch.MaxFileBytes != newCfg.Batch.MaxFileBytes {
+		changes = append(changes, fmt.Sprintf("batch.max-file-bytes: %d -> %d", oldCfg.Batch.MaxFileBytes, newCfg.Batch.MaxFileBytes))
+	}
+	if oldCfg.Sessions.Enabled != newCfg.Sessions.Enabled {
+		changes = append(changes, fmt.Sprintf("sessions.enabled: %t -> %t", oldCfg.Sessions.Enabled, newCfg.Sessions.Enabled))
+	}
+	if strings.TrimSpace(oldCfg.Sessions.RedisURL) != strings.TrimSpace(newCfg.Sessions.RedisURL) {
+		changes = append(changes, "sessions.redis-url: updated")
+	}
+	if strings.TrimSpace(oldCfg.Sessions.TTL) != strings.TrimSpace(newCfg.Sessions.TTL) {
+		changes = append(changes, fmt.Sprintf("sessions.ttl: %s -> %s", strings.TrimSpace(oldCfg.Sessions.TTL), strings.TrimSpace(newCfg.Sessions.TTL)))
+	}
+	if strings.TrimSpace(oldCfg.ReasoningPolicy.Default) != strings.TrimSpace(newCfg.ReasoningPolicy.Default) {
+		changes = append(changes, fmt.Sprintf("reasoning-policy.default: %s -> %s", strings.TrimSpace(oldCfg.ReasoningPolicy.Default), strings.TrimSpace(newCfg.ReasoningPolicy.Default)))
+	}
+	if !reflect.DeepEqual(oldCfg.ReasoningPolicy.Models, newCfg.ReasoningPolicy.Models) {
+		changes = append(changes, fmt.Sprintf("reasoning-policy.models: updated (%d -> %d entries)", len(oldCfg.ReasoningPolicy.Models), len(newCfg.ReasoningPolicy.Models)))
+	}
+	if !reflect.DeepEqual(oldCfg.ReasoningPolicy.APIKeys, newCfg.ReasoningPolicy.APIKeys) {
+		changes = append(changes, fmt.Sprintf("reasoning-policy.api-keys: updated (%d -> %d entries)", len(oldCfg.ReasoningPolicy.APIKeys), len(newCfg.ReasoningPolicy.APIKeys)))
+	}
+	if !reflect.DeepEqual(oldCfg.APIKeyPolicies, newCfg.APIKeyPolicies) {
+		changes = append(changes, fmt.Sprintf("api-key-policies: updated (%d -> %d entries)", len(oldCfg.APIKeyPolicies), len(newCfg.APIKeyPolicies)))
+	}
+	if oldCfg.Tenants.Enabled != newCfg.Tenants.Enabled {
+		changes = append(changes, fmt.Sprintf("tenants.enabled: %t -> %t", oldCfg.Tenants.Enabled, newCfg.Tenants.Enabled))
+	}
+	if strings.TrimSpace(oldCfg.Tenants.StorePath) != strings.TrimSpace(newCfg.Tenants.StorePath) {
+		changes = append(changes, fmt.Sprintf("tenants.store-path: %s -> %s", strings.TrimSpace(oldCfg.Tenants.StorePath), strings.TrimSpace(newCfg.Tenants.StorePath)))
+	}
+	if strings.TrimSpace(oldCfg.RequestID.UpstreamHeader) != strings.TrimSpace(newCfg.RequestID.UpstreamHeader) {
+		changes = append(changes, fmt.Sprintf("request-id.upstream-header: %s -> %s", strings.TrimSpace(oldCfg.RequestID.UpstreamHeader), strings.TrimSpace(newCfg.RequestID.UpstreamHeader)))
+	}
+	if !reflect.DeepEqual(oldCfg.RequestID.Providers, newCfg.RequestID.Providers) {
+		changes = append(changes, fmt.Sprintf("request-id.providers: %v -> %v", oldCfg.RequestID.Providers, newCfg.RequestID.Providers))
+	}
+	if oldCfg.Cassette.Mode != newCfg.Cassette.Mode || oldCfg.Cassette.Dir != newCfg.Cassette.Dir {
+		changes = append(changes, fmt.Sprintf("cassette: %s %s -> %s %s", oldCfg.Cassette.Mode, oldCfg.Cassette.Dir, newCfg.Cassette.Mode, newCfg.Cassette.Dir))
+	}
+	if !reflect.DeepEqual(oldCfg.Cassette.Providers, newCfg.Cassette.Providers) {
+		changes = append(changes, fmt.Sprintf("cassette.providers: %v -> %v", oldCfg.Cassette.Providers, newCfg.Cassette.Providers))
+	}
+	if !reflect.DeepEqual(oldCfg.ModelMappings, newCfg.ModelMappings) {
+		changes = append(changes, fmt.Sprintf("model-mappings: %d -> %d rules", len(oldCfg.ModelMappings), len(newCfg.ModelMappings)))
+	}
+	if oldCfg.ModelResolution.Enabled != newCfg.ModelResolution.Enabled {
+		changes = append(changes, fmt.Sprintf("model-resolution.enabled: %t -> %t", oldCfg.ModelResolution.Enabled, newCfg.ModelResolution.Enabled))
+	}
+	if oldCfg.RoutingHeaders.Enabled != newCfg.RoutingHeaders.Enabled {
+		changes = append(changes, fmt.Sprintf("routing-headers.enabled: %t -> %t", oldCfg.RoutingHeaders.Enabled, newCfg.RoutingHeaders.Enabled))
+	}
+	if len(oldCfg.RoutingHeaders.APIKeys) != len(newCfg.RoutingHeaders.APIKeys) {
+		changes = append(changes, fmt.Sprintf("routing-headers.api-keys: %d -> %d entries", len(oldCfg.RoutingHeaders.APIKeys), len(newCfg.RoutingHeaders.APIKeys)))
+	}
+	if !reflect.DeepEqual(oldCfg.RoutingHeaders.Providers, newCfg.RoutingHeaders.Providers) {
+		changes = append(changes, fmt.Sprintf("routing-headers.providers: %v -> %v", oldCfg.RoutingHeaders.Providers, newCfg.RoutingHeaders.Providers))
+	}
+	if oldCfg.RoutingHeaders.AllowAuthID != newCfg.RoutingHeaders.AllowAuthID || oldCfg.RoutingHeaders.AllowNoFallback != newCfg.RoutingHeaders.AllowNoFallback {
+		changes = append(changes, fmt.Sprintf("routing-headers: allow-auth-id %t -> %t, allow-no-fallback %t -> %t", oldCfg.RoutingHeaders.AllowAuthID, newCfg.RoutingHeaders.AllowAuthID, oldCfg.RoutingHeaders.AllowNoFallback, newCfg.RoutingHeaders.AllowNoFallback))
+	}
+	if oldCfg.ContextPreflight != newCfg.ContextPreflight {
+		changes = append(changes, fmt.Sprintf("context-preflight: enabled %t -> %t, clamp-max-tokens %t -> %t", oldCfg.ContextPreflight.Enabled, newCfg.ContextPreflight.Enabled, oldCfg.ContextPreflight.ClampMaxTokens, newCfg.ContextPreflight.ClampMaxTokens))
+	}
+	if oldCfg.ContextTruncation.Enabled != newCfg.ContextTruncation.Enabled {
+		changes = append(changes, fmt.Sprintf("context-truncation.enabled: %t -> %t", oldCfg.ContextTruncation.Enabled, newCfg.ContextTruncation.Enabled))
+	}
+	if !reflect.DeepEqual(oldCfg.HistoryCompression, newCfg.HistoryCompression) {
+		changes = append(changes, fmt.Sprintf("history-compression: enabled %t -> %t, model %q -> %q", oldCfg.HistoryCompression.Enabled, newCfg.HistoryCompression.Enabled, oldCfg.HistoryCompression.Model, newCfg.HistoryCompression.Model))
+	}
+	if !reflect.DeepEqual(oldCfg.ModelResolution.LatestPins, newCfg.ModelResolution.LatestPins) {
+		changes = append(changes, fmt.Sprintf("model-resolution.latest-pins: %d -> %d entries", len(oldCfg.ModelResolution.LatestPins), len(newCfg.ModelResolution.LatestPins)))
+	}
 
 	// Quota-exceeded behavior
 	if oldCfg.QuotaExceeded.SwitchProject != newCfg.QuotaExceeded.SwitchProject {
@@ -109,9 +268,153 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.Routing.Strategy != newCfg.Routing.Strategy {
 		changes = append(changes, fmt.Sprintf("routing.strategy: %s -> %s", oldCfg.Routing.Strategy, newCfg.Routing.Strategy))
 	}
+	if !reflect.DeepEqual(oldCfg.Routing.FallbackModels, newCfg.Routing.FallbackModels) {
+		changes = append(changes, fmt.Sprintf("routing.fallback-models: %d -> %d entries", len(oldCfg.Routing.FallbackModels), len(newCfg.Routing.FallbackModels)))
+	}
+	if !reflect.DeepEqual(oldCfg.Routing.FallbackChain, newCfg.Routing.FallbackChain) {
+		changes = append(changes, fmt.Sprintf("routing.fallback-chain: %v -> %v", oldCfg.Routing.FallbackChain, newCfg.Routing.FallbackChain))
+	}
+	if oldCfg.Routing.FallbackMaxDepth != newCfg.Routing.FallbackMaxDepth {
+		changes = append(changes, fmt.Sprintf("routing.fallback-max-depth: %d -> %d", oldCfg.Routing.FallbackMaxDepth, newCfg.Routing.FallbackMaxDepth))
+	}
 	if !reflect.DeepEqual(oldCfg.Routing.TokenThresholdRules, newCfg.Routing.TokenThresholdRules) {
 		changes = append(changes, fmt.Sprintf("routing.token-threshold-rules: %d -> %d entries", len(oldCfg.Routing.TokenThresholdRules), len(newCfg.Routing.TokenThresholdRules)))
 	}
+	if !reflect.DeepEqual(oldCfg.Routing.ModelGroups, newCfg.Routing.ModelGroups) {
+		changes = append(changes, fmt.Sprintf("routing.model-groups: %d -> %d entries", len(oldCfg.Routing.ModelGroups), len(newCfg.Routing.ModelGroups)))
+	}
+	if oldCfg.UsageCounters.Enabled != newCfg.UsageCounters.Enabled {
+		changes = append(changes, fmt.Sprintf("usage-counters.enabled: %t -> %t", oldCfg.UsageCounters.Enabled, newCfg.UsageCounters.Enabled))
+	}
+	if oldCfg.UsageCounters.PersistPath != newCfg.UsageCounters.PersistPath {
+		changes = append(changes, fmt.Sprintf("usage-counters.persist-path: %s -> %s", oldCfg.UsageCounters.PersistPath, newCfg.UsageCounters.PersistPath))
+	}
+	if oldCfg.UsageCounters.FlushInterval != newCfg.UsageCounters.FlushInterval {
+		changes = append(changes, fmt.Sprintf("usage-counters.flush-interval: %s -> %s", oldCfg.UsageCounters.FlushInterval, newCfg.UsageCounters.FlushInterval))
+	}
+	if oldCfg.UsageCounters.RetentionDays != newCfg.UsageCounters.RetentionDays {
+		changes = append(changes, fmt.Sprintf("usage-counters.retention-days: %d -> %d", oldCfg.UsageCounters.RetentionDays, newCfg.UsageCounters.RetentionDays))
+	}
+	if oldCfg.BackgroundLane.MaxConcurrency != newCfg.BackgroundLane.MaxConcurrency {
+		changes = append(changes, fmt.Sprintf("background-lane.max-concurrency: %d -> %d", oldCfg.BackgroundLane.MaxConcurrency, newCfg.BackgroundLane.MaxConcurrency))
+	}
+	if oldCfg.BackgroundLane.MinInterval != newCfg.BackgroundLane.MinInterval {
+		changes = append(changes, fmt.Sprintf("background-lane.min-interval: %s -> %s", oldCfg.BackgroundLane.MinInterval, newCfg.BackgroundLane.MinInterval))
+	}
+	if oldCfg.RequestPriority.MaxConcurrency != newCfg.RequestPriority.MaxConcurrency {
+		changes = append(changes, fmt.Sprintf("request-priority.max-concurrency: %d -> %d", oldCfg.RequestPriority.MaxConcurrency, newCfg.RequestPriority.MaxConcurrency))
+	}
+	if oldCfg.RequestPriority.AgingInterval != newCfg.RequestPriority.AgingInterval {
+		changes = append(changes, fmt.Sprintf("request-priority.aging-interval: %s -> %s", oldCfg.RequestPriority.AgingInterval, newCfg.RequestPriority.AgingInterval))
+	}
+	if !reflect.DeepEqual(oldCfg.OAuthCallback, newCfg.OAuthCallback) {
+		changes = append(changes, "oauth-callback: updated")
+	}
+	if !reflect.DeepEqual(oldCfg.HeaderProfiles, newCfg.HeaderProfiles) {
+		changes = append(changes, fmt.Sprintf("header-profiles: %d -> %d profiles", len(oldCfg.HeaderProfiles), len(newCfg.HeaderProfiles)))
+	}
+	if oldCfg.SelectionAudit.Enabled != newCfg.SelectionAudit.Enabled {
+		changes = append(changes, fmt.Sprintf("selection-audit.enabled: %t -> %t", oldCfg.SelectionAudit.Enabled, newCfg.SelectionAudit.Enabled))
+	}
+	if oldCfg.SelectionAudit.Path != newCfg.SelectionAudit.Path {
+		changes = append(changes, fmt.Sprintf("selection-audit.path: %s -> %s", oldCfg.SelectionAudit.Path, newCfg.SelectionAudit.Path))
+	}
+	if oldCfg.SelectionAudit.MaxSizeMB != newCfg.SelectionAudit.MaxSizeMB || oldCfg.SelectionAudit.MaxBackups != newCfg.SelectionAudit.MaxBackups || oldCfg.SelectionAudit.MaxAgeDays != newCfg.SelectionAudit.MaxAgeDays {
+		changes = append(changes, fmt.Sprintf("selection-audit.rotation: %d/%d/%d -> %d/%d/%d", oldCfg.SelectionAudit.MaxSizeMB, oldCfg.SelectionAudit.MaxBackups, oldCfg.SelectionAudit.MaxAgeDays, newCfg.SelectionAudit.MaxSizeMB, newCfg.SelectionAudit.MaxBackups, newCfg.SelectionAudit.MaxAgeDays))
+	}
+	if oldCfg.HealthProbe.Enabled != newCfg.HealthProbe.Enabled {
+		changes = append(changes, fmt.Sprintf("health-probe.enabled: %t -> %t", oldCfg.HealthProbe.Enabled, newCfg.HealthProbe.Enabled))
+	}
+	if oldCfg.HealthProbe.Interval != newCfg.HealthProbe.Interval || oldCfg.HealthProbe.Lead != newCfg.HealthProbe.Lead || oldCfg.HealthProbe.Timeout != newCfg.HealthProbe.Timeout || oldCfg.HealthProbe.Extend != newCfg.HealthProbe.Extend {
+		changes = append(changes, fmt.Sprintf("health-probe.timing: %s/%s/%s/%s -> %s/%s/%s/%s", oldCfg.HealthProbe.Interval, oldCfg.HealthProbe.Lead, oldCfg.HealthProbe.Timeout, oldCfg.HealthProbe.Extend, newCfg.HealthProbe.Interval, newCfg.HealthProbe.Lead, newCfg.HealthProbe.Timeout, newCfg.HealthProbe.Extend))
+	}
+	if !reflect.DeepEqual(oldCfg.HealthProbe.Providers, newCfg.HealthProbe.Providers) {
+		changes = append(changes, fmt.Sprintf("health-probe.providers: %v -> %v", oldCfg.HealthProbe.Providers, newCfg.HealthProbe.Providers))
+	}
+	if oldCfg.Warmup.Enabled != newCfg.Warmup.Enabled {
+		changes = append(changes, fmt.Sprintf("warmup.enabled: %t -> %t", oldCfg.Warmup.Enabled, newCfg.Warmup.Enabled))
+	}
+	if oldCfg.Warmup.Timeout != newCfg.Warmup.Timeout {
+		changes = append(changes, fmt.Sprintf("warmup.timeout: %s -> %s", oldCfg.Warmup.Timeout, newCfg.Warmup.Timeout))
+	}
+	if !reflect.DeepEqual(oldCfg.Warmup.Schedules, newCfg.Warmup.Schedules) {
+		changes = append(changes, fmt.Sprintf("warmup.schedules: %d -> %d entries", len(oldCfg.Warmup.Schedules), len(newCfg.Warmup.Schedules)))
+	}
+	if oldCfg.AuthPersistence != newCfg.AuthPersistence {
+		changes = append(changes, fmt.Sprintf("auth-persistence: %s/%s -> %s/%s", oldCfg.AuthPersistence.Mode, oldCfg.AuthPersistence.FlushInterval, newCfg.AuthPersistence.Mode, newCfg.AuthPersistence.FlushInterval))
+	}
+	if oldCfg.HookDispatch != newCfg.HookDispatch {
+		changes = append(changes, fmt.Sprintf("hook-dispatch: mode=%s workers=%d queue=%d policy=%s -> mode=%s workers=%d queue=%d policy=%s", oldCfg.HookDispatch.Mode, oldCfg.HookDispatch.Workers, oldCfg.HookDispatch.QueueSize, oldCfg.HookDispatch.ErrorPolicy, newCfg.HookDispatch.Mode, newCfg.HookDispatch.Workers, newCfg.HookDispatch.QueueSize, newCfg.HookDispatch.ErrorPolicy))
+	}
+	if oldCfg.ModelRefresh.Enabled != newCfg.ModelRefresh.Enabled || oldCfg.ModelRefresh.TTL != newCfg.ModelRefresh.TTL || !reflect.DeepEqual(oldCfg.ModelRefresh.Providers, newCfg.ModelRefresh.Providers) {
+		changes = append(changes, fmt.Sprintf("model-refresh: enabled=%t ttl=%s providers=%v -> enabled=%t ttl=%s providers=%v", oldCfg.ModelRefresh.Enabled, oldCfg.ModelRefresh.TTL, oldCfg.ModelRefresh.Providers, newCfg.ModelRefresh.Enabled, newCfg.ModelRefresh.TTL, newCfg.ModelRefresh.Providers))
+	}
+	if oldCfg.AuthWebhook.Enabled != newCfg.AuthWebhook.Enabled {
+		changes = append(changes, fmt.Sprintf("auth-webhook.enabled: %t -> %t", oldCfg.AuthWebhook.Enabled, newCfg.AuthWebhook.Enabled))
+	}
+	if oldCfg.AuthWebhook.URL != newCfg.AuthWebhook.URL {
+		changes = append(changes, "auth-webhook.url: updated")
+	}
+	if oldCfg.AuthWebhook.Timeout != newCfg.AuthWebhook.Timeout || !reflect.DeepEqual(oldCfg.AuthWebhook.Events, newCfg.AuthWebhook.Events) {
+		changes = append(changes, fmt.Sprintf("auth-webhook.delivery: %s %v -> %s %v", oldCfg.AuthWebhook.Timeout, oldCfg.AuthWebhook.Events, newCfg.AuthWebhook.Timeout, newCfg.AuthWebhook.Events))
+	}
+	if !reflect.DeepEqual(oldCfg.AuthWebhook.Headers, newCfg.AuthWebhook.Headers) {
+		changes = append(changes, "auth-webhook.headers: updated")
+	}
+	if oldCfg.SpendAlerts.Enabled != newCfg.SpendAlerts.Enabled {
+		changes = append(changes, fmt.Sprintf("spend-alerts.enabled: %t -> %t", oldCfg.SpendAlerts.Enabled, newCfg.SpendAlerts.Enabled))
+	}
+	if oldCfg.SpendAlerts.WarnRatio != newCfg.SpendAlerts.WarnRatio || oldCfg.SpendAlerts.AuthDailyBudget != newCfg.SpendAlerts.AuthDailyBudget || oldCfg.SpendAlerts.TenantDailyBudget != newCfg.SpendAlerts.TenantDailyBudget ||
+		!reflect.DeepEqual(oldCfg.SpendAlerts.AuthBudgets, newCfg.SpendAlerts.AuthBudgets) || !reflect.DeepEqual(oldCfg.SpendAlerts.TenantBudgets, newCfg.SpendAlerts.TenantBudgets) {
+		changes = append(changes, "spend-alerts.budgets: updated")
+	}
+	if oldCfg.SpendAlerts.WebhookURL != newCfg.SpendAlerts.WebhookURL || !reflect.DeepEqual(oldCfg.SpendAlerts.WebhookHeaders, newCfg.SpendAlerts.WebhookHeaders) {
+		changes = append(changes, "spend-alerts.webhook: updated")
+	}
+	if oldCfg.ExpiryReport.Enabled != newCfg.ExpiryReport.Enabled || oldCfg.ExpiryReport.Interval != newCfg.ExpiryReport.Interval ||
+		oldCfg.ExpiryReport.WithinDays != newCfg.ExpiryReport.WithinDays || oldCfg.ExpiryReport.FailureThreshold != newCfg.ExpiryReport.FailureThreshold {
+		changes = append(changes, fmt.Sprintf("expiry-report: enabled %t -> %t, interval %s -> %s, within-days %d -> %d, failure-threshold %d -> %d",
+			oldCfg.ExpiryReport.Enabled, newCfg.ExpiryReport.Enabled, oldCfg.ExpiryReport.Interval, newCfg.ExpiryReport.Interval,
+			oldCfg.ExpiryReport.WithinDays, newCfg.ExpiryReport.WithinDays, oldCfg.ExpiryReport.FailureThreshold, newCfg.ExpiryReport.FailureThreshold))
+	}
+	if oldCfg.ExpiryReport.WebhookURL != newCfg.ExpiryReport.WebhookURL || !reflect.DeepEqual(oldCfg.ExpiryReport.WebhookHeaders, newCfg.ExpiryReport.WebhookHeaders) {
+		changes = append(changes, "expiry-report.webhook: updated")
+	}
+	if oldCfg.DailyCaps.Enabled != newCfg.DailyCaps.Enabled {
+		changes = append(changes, fmt.Sprintf("daily-caps.enabled: %t -> %t", oldCfg.DailyCaps.Enabled, newCfg.DailyCaps.Enabled))
+	}
+	if oldCfg.DailyCaps.Requests != newCfg.DailyCaps.Requests || oldCfg.DailyCaps.Tokens != newCfg.DailyCaps.Tokens ||
+		oldCfg.DailyCaps.ResetTime != newCfg.DailyCaps.ResetTime || oldCfg.DailyCaps.Timezone != newCfg.DailyCaps.Timezone ||
+		!reflect.DeepEqual(oldCfg.DailyCaps.Providers, newCfg.DailyCaps.Providers) {
+		changes = append(changes, "daily-caps.limits: updated")
+	}
+	if oldCfg.AuthEncryption.Enabled != newCfg.AuthEncryption.Enabled || oldCfg.AuthEncryption.KeyEnv != newCfg.AuthEncryption.KeyEnv {
+		changes = append(changes, fmt.Sprintf("auth-encryption: %t %s -> %t %s (restart required)", oldCfg.AuthEncryption.Enabled, oldCfg.AuthEncryption.KeyEnv, newCfg.AuthEncryption.Enabled, newCfg.AuthEncryption.KeyEnv))
+	}
+	if !reflect.DeepEqual(oldCfg.ProviderBaseURLs, newCfg.ProviderBaseURLs) {
+		changes = append(changes, fmt.Sprintf("provider-base-urls: %d -> %d entries", len(oldCfg.ProviderBaseURLs), len(newCfg.ProviderBaseURLs)))
+	}
+	if oldCfg.MockProvider.Enabled != newCfg.MockProvider.Enabled || oldCfg.MockProvider.Credentials != newCfg.MockProvider.Credentials {
+		changes = append(changes, fmt.Sprintf("mock-provider: enabled %t -> %t, credentials %d -> %d", oldCfg.MockProvider.Enabled, newCfg.MockProvider.Enabled, oldCfg.MockProvider.Credentials, newCfg.MockProvider.Credentials))
+	}
+	if oldCfg.MockProvider.Prefix != newCfg.MockProvider.Prefix || !reflect.DeepEqual(oldCfg.MockProvider.Models, newCfg.MockProvider.Models) {
+		changes = append(changes, "mock-provider.models: updated")
+	}
+	if oldCfg.PromptCacheHints != newCfg.PromptCacheHints {
+		changes = append(changes, fmt.Sprintf("prompt-cache-hints: %+v -> %+v", oldCfg.PromptCacheHints, newCfg.PromptCacheHints))
+	}
+	if !reflect.DeepEqual(oldCfg.Secrets, newCfg.Secrets) {
+		changes = append(changes, "secrets: updated")
+	}
+	if !reflect.DeepEqual(oldCfg.ForbiddenRules, newCfg.ForbiddenRules) {
+		changes = append(changes, fmt.Sprintf("forbidden-rules: %d -> %d entries", len(oldCfg.ForbiddenRules), len(newCfg.ForbiddenRules)))
+	}
+	if !reflect.DeepEqual(oldCfg.Routing.Pricing, newCfg.Routing.Pricing) {
+		changes = append(changes, fmt.Sprintf("routing.pricing: %d -> %d entries", len(oldCfg.Routing.Pricing), len(newCfg.Routing.Pricing)))
+	}
+	if oldCfg.Routing.AuthDailyBudget != newCfg.Routing.AuthDailyBudget {
+		changes = append(changes, fmt.Sprintf("routing.auth-daily-budget: %g -> %g", oldCfg.Routing.AuthDailyBudget, newCfg.Routing.AuthDailyBudget))
+	}
 	if !reflect.DeepEqual(oldCfg.Payload, newCfg.Payload) {
 		changes = appendPayloadConfigChanges(changes, oldCfg.Payload, newCfg.Payload)
 	}
@@ -134,6 +437,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
+			if strings.TrimSpace(o.SourceAddress) != strings.TrimSpace(n.SourceAddress) {
+				changes = append(changes, fmt.Sprintf("gemini[%d].source-address: %s -> %s", i, strings.TrimSpace(o.SourceAddress), strings.TrimSpace(n.SourceAddress)))
+			}
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
@@ -170,6 +476,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("interactions[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
+			if strings.TrimSpace(o.SourceAddress) != strings.TrimSpace(n.SourceAddress) {
+				changes = append(changes, fmt.Sprintf("interactions[%d].source-address: %s -> %s", i, strings.TrimSpace(o.SourceAddress), strings.TrimSpace(n.SourceAddress)))
+			}
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("interactions[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
@@ -205,6 +514,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("claude[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
+			if strings.TrimSpace(o.SourceAddress) != strings.TrimSpace(n.SourceAddress) {
+				changes = append(changes, fmt.Sprintf("claude[%d].source-address: %s -> %s", i, strings.TrimSpace(o.SourceAddress), strings.TrimSpace(n.SourceAddress)))
+			}
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("claude[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
@@ -257,6 +569,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("codex[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
+			if strings.TrimSpace(o.SourceAddress) != strings.TrimSpace(n.SourceAddress) {
+				changes = append(changes, fmt.Sprintf("codex[%d].source-address: %s -> %s", i, strings.TrimSpace(o.SourceAddress), strings.TrimSpace(n.SourceAddress)))
+			}
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("codex[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
@@ -295,6 +610,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("commandcode[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
+			if strings.TrimSpace(o.SourceAddress) != strings.TrimSpace(n.SourceAddress) {
+				changes = append(changes, fmt.Sprintf("commandcode[%d].source-address: %s -> %s", i, strings.TrimSpace(o.SourceAddress), strings.TrimSpace(n.SourceAddress)))
+			}
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("commandcode[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
@@ -330,6 +648,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("xai[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
+			if strings.TrimSpace(o.SourceAddress) != strings.TrimSpace(n.SourceAddress) {
+				changes = append(changes, fmt.Sprintf("xai[%d].source-address: %s -> %s", i, strings.TrimSpace(o.SourceAddress), strings.TrimSpace(n.SourceAddress)))
+			}
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("xai[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
@@ -397,6 +718,15 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.RemoteManagement.AllowRemote != newCfg.RemoteManagement.AllowRemote {
 		changes = append(changes, fmt.Sprintf("remote-management.allow-remote: %t -> %t", oldCfg.RemoteManagement.AllowRemote, newCfg.RemoteManagement.AllowRemote))
 	}
+	if strings.TrimSpace(oldCfg.RemoteManagement.Listen) != strings.TrimSpace(newCfg.RemoteManagement.Listen) {
+		changes = append(changes, fmt.Sprintf("remote-management.listen: %s -> %s", strings.TrimSpace(oldCfg.RemoteManagement.Listen), strings.TrimSpace(newCfg.RemoteManagement.Listen)))
+	}
+	if !reflect.DeepEqual(oldCfg.RemoteManagement.AllowedCIDRs, newCfg.RemoteManagement.AllowedCIDRs) {
+		changes = append(changes, fmt.Sprintf("remote-management.allowed-cidrs: %d -> %d entries", len(oldCfg.RemoteManagement.AllowedCIDRs), len(newCfg.RemoteManagement.AllowedCIDRs)))
+	}
+	if oldCfg.RemoteManagement.BasicAuth != newCfg.RemoteManagement.BasicAuth {
+		changes = append(changes, "remote-management.basic-auth: updated")
+	}
 	if oldCfg.RemoteManagement.DisableControlPanel != newCfg.RemoteManagement.DisableControlPanel {
 		changes = append(changes, fmt.Sprintf("remote-management.disable-control-panel: %t -> %t", oldCfg.RemoteManagement.DisableControlPanel, newCfg.RemoteManagement.DisableControlPanel))
 	}
@@ -440,6 +770,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("vertex[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
+			if strings.TrimSpace(o.SourceAddress) != strings.TrimSpace(n.SourceAddress) {
+				changes = append(changes, fmt.Sprintf("vertex[%d].source-address: %s -> %s", i, strings.TrimSpace(o.SourceAddress), strings.TrimSpace(n.SourceAddress)))
+			}
 			if strings.TrimSpace(o.Prefix) != strings.TrimSpace(n.Prefix) {
 				changes = append(changes, fmt.Sprintf("vertex[%d].prefix: %s -> %s", i, strings.TrimSpace(o.Prefix), strings.TrimSpace(n.Prefix)))
 			}
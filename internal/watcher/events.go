@@ -43,6 +43,7 @@ func (w *Watcher) start(ctx context.Context) error {
 	w.watchKiroIDETokenFile()
 
 	go w.processEvents(ctx)
+	go w.refreshSecretsLoop(ctx)
 
 	w.reloadClients(true, nil, false)
 	return nil
@@ -0,0 +1,36 @@
+package watcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/secrets"
+	log "github.com/sirupsen/logrus"
+)
+
+// refreshSecretsLoop periodically re-resolves referenced API keys and re-synthesizes
+// auths when a secret was rotated.
+func (w *Watcher) refreshSecretsLoop(ctx context.Context) {
+	resolver := secrets.Default()
+	for {
+		interval := resolver.RefreshInterval()
+		wait := interval
+		if wait <= 0 {
+			wait = secrets.DefaultRefreshInterval
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if interval <= 0 || w.stopped.Load() {
+			continue
+		}
+		if resolver.Refresh(ctx) {
+			log.Info("secrets: referenced api keys changed, refreshing auths")
+			w.refreshAuthState(false)
+		}
+	}
+}
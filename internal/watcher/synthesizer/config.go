@@ -8,6 +8,7 @@ import (
 	kiroauth "github.com/router-for-me/CLIProxyAPI/v7/internal/auth/kiro"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/secrets"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/watcher/diff"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
@@ -29,6 +30,7 @@ func (s *ConfigSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth,
 	if ctx == nil || ctx.Config == nil {
 		return out, nil
 	}
+	secrets.Default().Configure(ctx.Config.Secrets)
 
 	// Gemini API Keys
 	out = append(out, s.synthesizeGeminiKeys(ctx)...)
@@ -46,15 +48,56 @@ func (s *ConfigSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth,
 	out = append(out, s.synthesizeCommandCodeKeys(ctx)...)
 	// Mistral API Keys
 	out = append(out, s.synthesizeMistralKeys(ctx)...)
+	// Scripted mock provider
+	out = append(out, s.synthesizeMockProvider(ctx)...)
 
 	// OpenAI-compat
 	out = append(out, s.synthesizeOpenAICompat(ctx)...)
 	// Vertex-compat
 	out = append(out, s.synthesizeVertexCompat(ctx)...)
 
+	// Drop cached secrets of references no longer in the config.
+	refs := make(map[string]struct{})
+	for _, auth := range out {
+		if ref := auth.Attributes[coreauth.AttributeAPIKeyRef]; ref != "" {
+			refs[ref] = struct{}{}
+		}
+	}
+	secrets.Default().Retain(refs)
+
 	return out, nil
 }
 
+// synthesizeMockProvider creates the credentials of the scripted mock provider.
+func (s *ConfigSynthesizer) synthesizeMockProvider(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	if !cfg.MockProvider.Enabled {
+		return nil
+	}
+	now := ctx.Now
+	idGen := ctx.IDGenerator
+
+	count := max(cfg.MockProvider.Credentials, 1)
+	prefix := strings.TrimSpace(cfg.MockProvider.Prefix)
+	out := make([]*coreauth.Auth, 0, count)
+	for i := 0; i < count; i++ {
+		id, token := idGen.Next("mock:credential", strconv.Itoa(i))
+		out = append(out, &coreauth.Auth{
+			ID:       id,
+			Provider: "mock",
+			Label:    fmt.Sprintf("mock-%d", i+1),
+			Prefix:   prefix,
+			Status:   coreauth.StatusActive,
+			Attributes: map[string]string{
+				"source": fmt.Sprintf("config:mock[%s]", token),
+			},
+			CreatedAt: now,
+			UpdatedAt: now,
+		})
+	}
+	return out
+}
+
 // synthesizeGeminiKeys creates Auth entries for Gemini API keys.
 func (s *ConfigSynthesizer) synthesizeGeminiKeys(ctx *SynthesisContext) []*coreauth.Auth {
 	return s.synthesizeGeminiKeyEntries(ctx, ctx.Config.GeminiKey, "gemini:apikey", "gemini", "gemini-apikey", constant.Gemini)
@@ -85,6 +128,9 @@ func (s *ConfigSynthesizer) synthesizeGeminiKeyEntries(ctx *SynthesisContext, en
 			"source":  fmt.Sprintf("config:%s[%s]", sourceName, token),
 			"api_key": key,
 		}
+		if !resolveAPIKeyAttr(attrs) {
+			continue
+		}
 		metadata := map[string]any{}
 		if entry.DisableCooling {
 			metadata["disable_cooling"] = true
@@ -102,6 +148,7 @@ func (s *ConfigSynthesizer) synthesizeGeminiKeyEntries(ctx *SynthesisContext, en
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(entry.Headers, attrs)
+		addSourceAddressToAttrs(entry.SourceAddress, attrs)
 		a := &coreauth.Auth{
 			ID:         id,
 			Provider:   provider,
@@ -143,6 +190,9 @@ func (s *ConfigSynthesizer) synthesizeClaudeKeys(ctx *SynthesisContext) []*corea
 			"source":  fmt.Sprintf("config:claude[%s]", token),
 			"api_key": key,
 		}
+		if !resolveAPIKeyAttr(attrs) {
+			continue
+		}
 		metadata := map[string]any{}
 		if ck.DisableCooling {
 			metadata["disable_cooling"] = true
@@ -163,6 +213,7 @@ func (s *ConfigSynthesizer) synthesizeClaudeKeys(ctx *SynthesisContext) []*corea
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(ck.Headers, attrs)
+		addSourceAddressToAttrs(ck.SourceAddress, attrs)
 		proxyURL := strings.TrimSpace(ck.ProxyURL)
 		a := &coreauth.Auth{
 			ID:         id,
@@ -214,6 +265,9 @@ func (s *ConfigSynthesizer) synthesizeCodexStyleKeys(ctx *SynthesisContext, entr
 			"source":  fmt.Sprintf("config:%s[%s]", provider, token),
 			"api_key": key,
 		}
+		if !resolveAPIKeyAttr(attrs) {
+			continue
+		}
 		metadata := map[string]any{}
 		if entry.DisableCooling {
 			metadata["disable_cooling"] = true
@@ -235,6 +289,7 @@ func (s *ConfigSynthesizer) synthesizeCodexStyleKeys(ctx *SynthesisContext, entr
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(entry.Headers, attrs)
+		addSourceAddressToAttrs(entry.SourceAddress, attrs)
 		a := &coreauth.Auth{
 			ID:         id,
 			Provider:   provider,
@@ -275,6 +330,9 @@ func (s *ConfigSynthesizer) synthesizeCommandCodeKeys(ctx *SynthesisContext) []*
 			"source":  fmt.Sprintf("config:commandcode[%s]", token),
 			"api_key": key,
 		}
+		if !resolveAPIKeyAttr(attrs) {
+			continue
+		}
 		metadata := map[string]any{}
 		if ck.DisableCooling {
 			metadata["disable_cooling"] = true
@@ -292,6 +350,7 @@ func (s *ConfigSynthesizer) synthesizeCommandCodeKeys(ctx *SynthesisContext) []*
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(ck.Headers, attrs)
+		addSourceAddressToAttrs(ck.SourceAddress, attrs)
 		proxyURL := strings.TrimSpace(ck.ProxyURL)
 		a := &coreauth.Auth{
 			ID:         id,
@@ -333,6 +392,9 @@ func (s *ConfigSynthesizer) synthesizeMistralKeys(ctx *SynthesisContext) []*core
 			"source":  fmt.Sprintf("config:mistral[%s]", token),
 			"api_key": key,
 		}
+		if !resolveAPIKeyAttr(attrs) {
+			continue
+		}
 		metadata := map[string]any{}
 		if mk.DisableCooling {
 			metadata["disable_cooling"] = true
@@ -350,6 +412,7 @@ func (s *ConfigSynthesizer) synthesizeMistralKeys(ctx *SynthesisContext) []*core
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(mk.Headers, attrs)
+		addSourceAddressToAttrs(mk.SourceAddress, attrs)
 		proxyURL := strings.TrimSpace(mk.ProxyURL)
 		a := &coreauth.Auth{
 			ID:         id,
@@ -420,10 +483,14 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 			if key != "" {
 				attrs["api_key"] = key
 			}
+			if !resolveAPIKeyAttr(attrs) {
+				continue
+			}
 			if hash := diff.ComputeOpenAICompatModelsHash(compat.Models); hash != "" {
 				attrs["models_hash"] = hash
 			}
 			addConfigHeadersToAttrs(compat.Headers, attrs)
+			addSourceAddressToAttrs(entry.SourceAddress, attrs)
 			a := &coreauth.Auth{
 				ID:         id,
 				Provider:   internalProviderKey,
@@ -517,10 +584,14 @@ func (s *ConfigSynthesizer) synthesizeVertexCompat(ctx *SynthesisContext) []*cor
 		if key != "" {
 			attrs["api_key"] = key
 		}
+		if !resolveAPIKeyAttr(attrs) {
+			continue
+		}
 		if hash := diff.ComputeVertexCompatModelsHash(compat.Models); hash != "" {
 			attrs["models_hash"] = hash
 		}
 		addConfigHeadersToAttrs(compat.Headers, attrs)
+		addSourceAddressToAttrs(compat.SourceAddress, attrs)
 		a := &coreauth.Auth{
 			ID:         id,
 			Provider:   providerName,
@@ -607,6 +678,7 @@ func (s *ConfigSynthesizer) synthesizeKiroKeys(ctx *SynthesisContext) []*coreaut
 		if refreshToken != "" {
 			attrs["refresh_token"] = refreshToken
 		}
+		addSourceAddressToAttrs(kk.SourceAddress, attrs)
 		proxyURL := strings.TrimSpace(kk.ProxyURL)
 		a := &coreauth.Auth{
 			ID:         id,
@@ -16,6 +16,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/geminicli"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/pluginapi"
+	log "github.com/sirupsen/logrus"
 )
 
 // FileSynthesizer generates Auth entries from OAuth JSON files.
@@ -74,6 +75,11 @@ func synthesizeFileAuths(ctx *SynthesisContext, fullPath string, data []byte) []
 	}
 	now := ctx.Now
 	cfg := ctx.Config
+	data, errDecrypt := coreauth.DecryptAuthJSON(data)
+	if errDecrypt != nil {
+		log.Warnf("skip auth file %s: %v", fullPath, errDecrypt)
+		return nil
+	}
 	var metadata map[string]any
 	if errUnmarshal := json.Unmarshal(data, &metadata); errUnmarshal != nil {
 		return nil
@@ -179,6 +185,7 @@ func synthesizeFileAuths(ctx *SynthesisContext, fullPath string, data []byte) []
 		ID:       id,
 		Provider: provider,
 		Label:    label,
+		Groups:   coreauth.ParseGroups(metadata["groups"]),
 		Prefix:   prefix,
 		Status:   status,
 		Disabled: disabled,
@@ -237,6 +244,11 @@ func synthesizeFileAuths(ctx *SynthesisContext, fullPath string, data []byte) []
 			a.Attributes["base_url"] = trimmed
 		}
 	}
+	if availability, errAvailability := coreauth.AvailabilityFromMetadata(metadata); errAvailability != nil {
+		log.Warnf("auth %s: ignoring availability windows: %v", id, errAvailability)
+	} else {
+		a.Availability = availability
+	}
 	coreauth.ApplyCustomHeadersFromMetadata(a)
 	coreauth.SetOAuthModelAliasesAttribute(a, perAccountModelAliases)
 	ApplyAuthExcludedModelsMeta(a, cfg, perAccountExcluded, "oauth")
@@ -372,17 +384,19 @@ func SynthesizeGeminiVirtualAuths(primary *coreauth.Auth, metadata map[string]an
 			metadataCopy["proxy_url"] = proxy
 		}
 		virtual := &coreauth.Auth{
-			ID:         buildGeminiVirtualID(primary.ID, projectID),
-			Provider:   originalProvider,
-			Label:      fmt.Sprintf("%s [%s]", label, projectID),
-			Status:     coreauth.StatusActive,
-			Attributes: attrs,
-			Metadata:   metadataCopy,
-			ProxyURL:   primary.ProxyURL,
-			Prefix:     primary.Prefix,
-			CreatedAt:  primary.CreatedAt,
-			UpdatedAt:  primary.UpdatedAt,
-			Runtime:    geminicli.NewVirtualCredential(projectID, shared),
+			ID:           buildGeminiVirtualID(primary.ID, projectID),
+			Provider:     originalProvider,
+			Label:        fmt.Sprintf("%s [%s]", label, projectID),
+			Status:       coreauth.StatusActive,
+			Attributes:   attrs,
+			Metadata:     metadataCopy,
+			ProxyURL:     primary.ProxyURL,
+			Prefix:       primary.Prefix,
+			Groups:       primary.Groups,
+			Availability: primary.Availability,
+			CreatedAt:    primary.CreatedAt,
+			UpdatedAt:    primary.UpdatedAt,
+			Runtime:      geminicli.NewVirtualCredential(projectID, shared),
 		}
 		virtuals = append(virtuals, virtual)
 	}
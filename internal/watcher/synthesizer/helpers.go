@@ -1,6 +1,7 @@
 package synthesizer
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
@@ -8,8 +9,10 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/secrets"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/watcher/diff"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
 )
 
 // StableIDGenerator generates stable, deterministic IDs for auth entries.
@@ -118,3 +121,28 @@ func addConfigHeadersToAttrs(headers map[string]string, attrs map[string]string)
 		attrs["header:"+key] = val
 	}
 }
+
+// addSourceAddressToAttrs records the configured outbound source address, if any.
+func addSourceAddressToAttrs(source string, attrs map[string]string) {
+	if source = strings.TrimSpace(source); source != "" && attrs != nil {
+		attrs[coreauth.AttributeSourceAddress] = source
+	}
+}
+
+// resolveAPIKeyAttr replaces a secret reference in attrs["api_key"] with the resolved
+// key and keeps the reference in attrs["api_key_ref"]. It returns false when the
+// reference cannot be resolved, in which case the entry is skipped.
+func resolveAPIKeyAttr(attrs map[string]string) bool {
+	ref := attrs[coreauth.AttributeAPIKey]
+	if !secrets.IsReference(ref) {
+		return true
+	}
+	key, errResolve := secrets.Default().Resolve(context.Background(), ref)
+	if errResolve != nil {
+		log.Errorf("skip config api key %s: %v", ref, errResolve)
+		return false
+	}
+	attrs[coreauth.AttributeAPIKey] = key
+	attrs[coreauth.AttributeAPIKeyRef] = ref
+	return true
+}
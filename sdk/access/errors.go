@@ -14,6 +14,8 @@ const (
 	AuthErrorCodeInvalidCredential AuthErrorCode = "invalid_credential"
 	AuthErrorCodeNotHandled        AuthErrorCode = "not_handled"
 	AuthErrorCodeInternal          AuthErrorCode = "internal_error"
+	AuthErrorCodeQuotaExceeded     AuthErrorCode = "quota_exceeded"
+	AuthErrorCodeForbidden         AuthErrorCode = "forbidden"
 )
 
 // AuthError carries authentication failure details and HTTP status.
@@ -81,6 +83,16 @@ func NewInvalidCredentialErrorForProvider(providerType string) *AuthError {
 	return newProviderAuthError(AuthErrorCodeInvalidCredential, "Invalid API key", http.StatusUnauthorized, nil, providerType)
 }
 
+// NewQuotaExceededErrorForProvider reports a recognised credential whose quota is used up.
+func NewQuotaExceededErrorForProvider(message, providerType string) *AuthError {
+	return newProviderAuthError(AuthErrorCodeQuotaExceeded, message, http.StatusTooManyRequests, nil, providerType)
+}
+
+// NewForbiddenErrorForProvider reports a recognised credential that may not be used.
+func NewForbiddenErrorForProvider(message, providerType string) *AuthError {
+	return newProviderAuthError(AuthErrorCodeForbidden, message, http.StatusForbidden, nil, providerType)
+}
+
 func NewNotHandledError() *AuthError {
 	return newAuthError(AuthErrorCodeNotHandled, "authentication provider did not handle request", 0, nil)
 }
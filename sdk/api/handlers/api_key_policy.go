@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tenant"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxTokensFields lists, per entry protocol, the request fields holding the output token
+// limit. The first field is set when the request does not carry any of them.
+var maxTokensFields = map[string][]string{
+	"openai":          {"max_tokens", "max_completion_tokens"},
+	"openai-response": {"max_output_tokens"},
+	"claude":          {"max_tokens"},
+	"gemini":          {"generationConfig.maxOutputTokens"},
+}
+
+// requestAPIKeyPolicy returns the policy configured for the client API key of the request.
+// Tenant keys without a configured policy use the tenant's providers, groups and aliases.
+func (h *BaseAPIHandler) requestAPIKeyPolicy(ctx context.Context) (config.APIKeyPolicy, bool) {
+	if h == nil || h.Cfg == nil || ctx == nil {
+		return config.APIKeyPolicy{}, false
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return config.APIKeyPolicy{}, false
+	}
+	value, exists := ginCtx.Get("userApiKey")
+	if !exists {
+		return config.APIKeyPolicy{}, false
+	}
+	apiKey, ok := value.(string)
+	if !ok {
+		return config.APIKeyPolicy{}, false
+	}
+	if policy, found := h.Cfg.APIKeyPolicies[apiKey]; found {
+		return policy, true
+	}
+	if !h.Cfg.Tenants.Enabled {
+		return config.APIKeyPolicy{}, false
+	}
+	t, found := tenant.Default().Lookup(apiKey)
+	if !found || (len(t.AllowedProviders) == 0 && len(t.ModelAliases) == 0 && len(t.AuthGroups) == 0) {
+		return config.APIKeyPolicy{}, false
+	}
+	return config.APIKeyPolicy{AllowedProviders: t.AllowedProviders, AuthGroups: t.AuthGroups, ModelAliases: t.ModelAliases}, true
+}
+
+// setAuthGroupsMetadata restricts credential selection to the auth groups of the client
+// key policy, if it names any.
+func (h *BaseAPIHandler) setAuthGroupsMetadata(ctx context.Context, meta map[string]any) {
+	if meta == nil {
+		return
+	}
+	policy, ok := h.requestAPIKeyPolicy(ctx)
+	if !ok {
+		return
+	}
+	if groups := coreauth.ParseGroups(policy.AuthGroups); len(groups) > 0 {
+		meta[coreexecutor.AuthGroupsMetadataKey] = groups
+	}
+}
+
+// resolveAPIKeyModelAlias maps modelName through the model aliases of the client API key.
+// It runs before model routing so each key can point shared names at different models.
+func (h *BaseAPIHandler) resolveAPIKeyModelAlias(ctx context.Context, modelName string) string {
+	policy, ok := h.requestAPIKeyPolicy(ctx)
+	if !ok || len(policy.ModelAliases) == 0 {
+		return modelName
+	}
+	if target := strings.TrimSpace(lookupModelEntry(policy.ModelAliases, []string{modelName})); target != "" {
+		return target
+	}
+	return modelName
+}
+
+// applyAPIKeyPolicy enforces the client key policy before credential selection. It rejects
+// models outside AllowedModels, narrows providers to AllowedProviders, and clamps (or
+// rejects) output token limits above MaxTokens. requestedModel and model are both checked
+// so a policy can name either the client-facing alias or the resolved model.
+func (h *BaseAPIHandler) applyAPIKeyPolicy(ctx context.Context, entryProtocol, requestedModel, model string, providers []string, rawJSON []byte) ([]string, []byte, *interfaces.ErrorMessage) {
+	policy, ok := h.requestAPIKeyPolicy(ctx)
+	if !ok {
+		return providers, rawJSON, nil
+	}
+
+	if len(policy.AllowedModels) > 0 && !apiKeyPolicyAllowsModel(policy.AllowedModels, requestedModel, model) {
+		return nil, nil, apiKeyPolicyError(http.StatusForbidden, "permission_error", "model_not_allowed",
+			fmt.Sprintf("model %s is not allowed for this API key", requestedModel))
+	}
+
+	if len(policy.AllowedProviders) > 0 {
+		allowed := make([]string, 0, len(providers))
+		for _, provider := range providers {
+			for _, candidate := range policy.AllowedProviders {
+				if strings.EqualFold(strings.TrimSpace(candidate), provider) {
+					allowed = append(allowed, provider)
+					break
+				}
+			}
+		}
+		if len(allowed) == 0 {
+			return nil, nil, apiKeyPolicyError(http.StatusForbidden, "permission_error", "provider_not_allowed",
+				fmt.Sprintf("no provider allowed for this API key serves model %s", requestedModel))
+		}
+		providers = allowed
+	}
+
+	if policy.MaxTokens > 0 {
+		fields := maxTokensFields[entryProtocol]
+		present := false
+		for _, field := range fields {
+			value := gjson.GetBytes(rawJSON, field)
+			if !value.Exists() || value.Type == gjson.Null {
+				continue
+			}
+			present = true
+			if value.Int() <= policy.MaxTokens {
+				continue
+			}
+			if policy.RejectOverMaxTokens {
+				return nil, nil, apiKeyPolicyError(http.StatusBadRequest, "invalid_request_error", "max_tokens_exceeded",
+					fmt.Sprintf("%s %d exceeds the limit of %d for this API key", field, value.Int(), policy.MaxTokens))
+			}
+			rawJSON, _ = sjson.SetBytes(rawJSON, field, policy.MaxTokens)
+		}
+		if !present && len(fields) > 0 {
+			rawJSON, _ = sjson.SetBytes(rawJSON, fields[0], policy.MaxTokens)
+		}
+	}
+	return providers, rawJSON, nil
+}
+
+func apiKeyPolicyAllowsModel(patterns []string, models ...string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		for _, model := range models {
+			if model != "" && pattern != "" && matchModelWildcard(pattern, model) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// apiKeyPolicyError builds an OpenAI-style error whose body carries a machine-readable code.
+func apiKeyPolicyError(status int, errType, code, message string) *interfaces.ErrorMessage {
+	body, _ := json.Marshal(ErrorResponse{Error: ErrorDetail{Message: message, Type: errType, Code: code}})
+	return &interfaces.ErrorMessage{StatusCode: status, Error: errors.New(string(body))}
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tenant"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyAPIKeyPolicy(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{APIKeyPolicies: map[string]sdkconfig.APIKeyPolicy{
+		"clamp":  {MaxTokens: 100, AllowedModels: []string{"gpt-*"}, AllowedProviders: []string{"codex"}},
+		"reject": {MaxTokens: 100, RejectOverMaxTokens: true},
+	}}}
+	providers := []string{"openai-compat", "codex"}
+
+	got, body, errMsg := h.applyAPIKeyPolicy(clientRequestTestContext("clamp", nil), "openai", "gpt-5", "gpt-5", providers, []byte(`{"max_tokens":500,"max_completion_tokens":50}`))
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if !reflect.DeepEqual(got, []string{"codex"}) {
+		t.Fatalf("providers = %v", got)
+	}
+	if gjson.GetBytes(body, "max_tokens").Int() != 100 || gjson.GetBytes(body, "max_completion_tokens").Int() != 50 {
+		t.Fatalf("max tokens not clamped: %s", body)
+	}
+
+	_, body, _ = h.applyAPIKeyPolicy(clientRequestTestContext("clamp", nil), "gemini", "gpt-5", "gpt-5", providers, []byte(`{}`))
+	if gjson.GetBytes(body, "generationConfig.maxOutputTokens").Int() != 100 {
+		t.Fatalf("missing limit should be set: %s", body)
+	}
+
+	_, _, errMsg = h.applyAPIKeyPolicy(clientRequestTestContext("clamp", nil), "openai", "claude-sonnet", "claude-sonnet", providers, []byte(`{}`))
+	if errMsg == nil || errMsg.StatusCode != http.StatusForbidden || gjson.Get(errMsg.Error.Error(), "error.code").String() != "model_not_allowed" {
+		t.Fatalf("expected model_not_allowed, got %+v", errMsg)
+	}
+
+	_, _, errMsg = h.applyAPIKeyPolicy(clientRequestTestContext("clamp", nil), "openai", "gpt-5", "gpt-5", []string{"openai-compat"}, []byte(`{}`))
+	if errMsg == nil || !strings.Contains(errMsg.Error.Error(), "provider_not_allowed") {
+		t.Fatalf("expected provider_not_allowed, got %+v", errMsg)
+	}
+
+	_, _, errMsg = h.applyAPIKeyPolicy(clientRequestTestContext("reject", nil), "claude", "m", "m", providers, []byte(`{"max_tokens":101}`))
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest || gjson.Get(errMsg.Error.Error(), "error.code").String() != "max_tokens_exceeded" {
+		t.Fatalf("expected max_tokens_exceeded, got %+v", errMsg)
+	}
+
+	got, body, errMsg = h.applyAPIKeyPolicy(clientRequestTestContext("other", nil), "openai", "m", "m", providers, []byte(`{"max_tokens":500}`))
+	if errMsg != nil || !reflect.DeepEqual(got, providers) || gjson.GetBytes(body, "max_tokens").Int() != 500 {
+		t.Fatalf("keys without a policy must pass through unchanged")
+	}
+}
+
+func TestResolveAPIKeyModelAlias(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{
+		Tenants: sdkconfig.TenantsConfig{Enabled: true},
+		APIKeyPolicies: map[string]sdkconfig.APIKeyPolicy{
+			"team-a": {ModelAliases: map[string]string{"default": "gemini-2.5-pro", "fast-*": "gemini-2.5-flash"}},
+		},
+	}}
+	team, err := tenant.Default().Create(tenant.Tenant{APIKey: "team-b", ModelAliases: map[string]string{"default": "claude-sonnet-4"}})
+	if err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+	t.Cleanup(func() { _ = tenant.Default().Delete(team.ID) })
+
+	cases := []struct{ apiKey, model, want string }{
+		{"team-a", "default", "gemini-2.5-pro"},
+		{"team-a", "fast-chat", "gemini-2.5-flash"},
+		{"team-a", "gpt-5", "gpt-5"},
+		{"team-b", "default", "claude-sonnet-4"},
+		{"other", "default", "default"},
+	}
+	for _, tc := range cases {
+		if got := h.resolveAPIKeyModelAlias(clientRequestTestContext(tc.apiKey, nil), tc.model); got != tc.want {
+			t.Fatalf("%s/%s resolved to %q, want %q", tc.apiKey, tc.model, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,1024 @@
+package handlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tenant"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultBatchConcurrency  = 4
+	defaultBatchMaxRequests  = 50000
+	defaultBatchMaxFileBytes = 200 << 20
+
+	// batchMaxMemoryResultBytes bounds the result lines an in-memory store keeps per batch.
+	// Lines past it are recorded as failed; a store with a directory keeps results on disk.
+	batchMaxMemoryResultBytes = 256 << 20
+
+	// batchCompletionWindow is the only completion window accepted by the Batch API.
+	batchCompletionWindow = "24h"
+
+	// batchLineMaxAttempts bounds retries of a line rejected because every credential is
+	// cooling down or rate limited.
+	batchLineMaxAttempts = 5
+	batchLineRetryDelay  = 10 * time.Second
+
+	batchInputPurpose  = "batch"
+	batchOutputPurpose = "batch_output"
+)
+
+// batchEndpoints maps the endpoints a batch may target to the handler type used to execute each line.
+var batchEndpoints = map[string]string{
+	"/v1/chat/completions": "openai",
+	"/v1/responses":        "openai-response",
+	"/v1/embeddings":       coreauth.EmbeddingsSourceFormat,
+}
+
+// batchSleep waits between line retries; tests replace it to avoid real delays.
+var batchSleep = func(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// BatchFile is an uploaded or generated file in the OpenAI Files API shape.
+type BatchFile struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	Bytes     int    `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+	Filename  string `json:"filename"`
+	Purpose   string `json:"purpose"`
+}
+
+// BatchRequestCounts tracks line progress of a batch.
+type BatchRequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// BatchErrorData describes one validation error of a batch input file.
+type BatchErrorData struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+}
+
+// BatchErrors lists validation errors of a failed batch.
+type BatchErrors struct {
+	Object string           `json:"object"`
+	Data   []BatchErrorData `json:"data"`
+}
+
+// Batch is a batch job in the OpenAI Batch API shape.
+type Batch struct {
+	ID               string             `json:"id"`
+	Object           string             `json:"object"`
+	Endpoint         string             `json:"endpoint"`
+	Errors           *BatchErrors       `json:"errors"`
+	InputFileID      string             `json:"input_file_id"`
+	CompletionWindow string             `json:"completion_window"`
+	Status           string             `json:"status"`
+	OutputFileID     *string            `json:"output_file_id"`
+	ErrorFileID      *string            `json:"error_file_id"`
+	CreatedAt        int64              `json:"created_at"`
+	InProgressAt     *int64             `json:"in_progress_at"`
+	ExpiresAt        int64              `json:"expires_at"`
+	FinalizingAt     *int64             `json:"finalizing_at"`
+	CompletedAt      *int64             `json:"completed_at"`
+	FailedAt         *int64             `json:"failed_at"`
+	ExpiredAt        *int64             `json:"expired_at"`
+	CancellingAt     *int64             `json:"cancelling_at"`
+	CancelledAt      *int64             `json:"cancelled_at"`
+	RequestCounts    BatchRequestCounts `json:"request_counts"`
+	Metadata         map[string]string  `json:"metadata"`
+}
+
+// batchLine is one parsed request of a batch input file.
+type batchLine struct {
+	CustomID string
+	Body     []byte
+}
+
+// batchRun is the in-memory state of a batch: its public record, the submitting client,
+// completed lines keyed by custom_id, and the cancel function of the running worker.
+// Result lines are held in results only when the store has no directory; otherwise the
+// map marks completed lines and the lines are read back from the results file.
+type batchRun struct {
+	batch       Batch
+	owner       string
+	apiKey      string
+	results     map[string][]byte
+	resultBytes int
+	order       []string
+	cancel      context.CancelFunc
+}
+
+// batchRecord is the persisted form of a batch. Only the owner hash is stored; a resumed
+// batch looks the submitter's API key up again by that hash.
+type batchRecord struct {
+	Batch
+	Owner string `json:"owner,omitempty"`
+}
+
+// batchFileRecord is the persisted form of a file.
+type batchFileRecord struct {
+	BatchFile
+	Owner string `json:"owner,omitempty"`
+}
+
+// batchStore keeps uploaded files and batches. When dir is set, files, batch records and
+// per-line results are written under it and unfinished batches resume on restart. Files
+// and batches are visible only to the client that created them, identified by owner.
+type batchStore struct {
+	mu         sync.Mutex
+	dir        string
+	files      map[string]*BatchFile
+	fileOwners map[string]string
+	content    map[string][]byte
+	batches    map[string]*batchRun
+	handler    *BaseAPIHandler
+}
+
+func newBatchStore(dir string, handler *BaseAPIHandler) *batchStore {
+	return &batchStore{
+		dir:        strings.TrimSpace(dir),
+		files:      make(map[string]*BatchFile),
+		fileOwners: make(map[string]string),
+		content:    make(map[string][]byte),
+		batches:    make(map[string]*batchRun),
+		handler:    handler,
+	}
+}
+
+func batchConcurrency(cfg *config.SDKConfig) int {
+	if cfg == nil || cfg.Batch.Concurrency <= 0 {
+		return defaultBatchConcurrency
+	}
+	return cfg.Batch.Concurrency
+}
+
+func batchMaxRequests(cfg *config.SDKConfig) int {
+	if cfg == nil || cfg.Batch.MaxRequests <= 0 {
+		return defaultBatchMaxRequests
+	}
+	return cfg.Batch.MaxRequests
+}
+
+// BatchMaxFileBytes returns the size limit of one uploaded batch input file.
+func (h *BaseAPIHandler) BatchMaxFileBytes() int64 {
+	if h.Cfg == nil || h.Cfg.Batch.MaxFileBytes <= 0 {
+		return defaultBatchMaxFileBytes
+	}
+	return h.Cfg.Batch.MaxFileBytes
+}
+
+// batchesForConfig returns the active batch store, recreating it (and resuming persisted
+// batches) when the directory changes. It returns nil when batches are disabled.
+func (h *BaseAPIHandler) batchesForConfig(cfg *config.SDKConfig) *batchStore {
+	if cfg == nil || !cfg.Batch.Enabled {
+		return nil
+	}
+	dir := strings.TrimSpace(cfg.Batch.Dir)
+	h.batchesMu.Lock()
+	defer h.batchesMu.Unlock()
+	if h.batches == nil || h.batches.dir != dir {
+		if h.batches != nil {
+			h.batches.stopAll()
+		}
+		h.batches = newBatchStore(dir, h)
+		h.batches.load()
+	}
+	return h.batches
+}
+
+func batchesDisabledError() *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{StatusCode: http.StatusNotFound, Error: errors.New("batch API is not enabled")}
+}
+
+func batchInvalidRequest(format string, args ...any) *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf(format, args...)}
+}
+
+func batchFileNotFound(id string) *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{StatusCode: http.StatusNotFound, Error: fmt.Errorf("file %s not found", id)}
+}
+
+func batchNotFound(id string) *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{StatusCode: http.StatusNotFound, Error: fmt.Errorf("batch %s not found", id)}
+}
+
+// UploadBatchFile stores a file for later use by a batch of the same client.
+func (h *BaseAPIHandler) UploadBatchFile(ctx context.Context, filename, purpose string, content []byte) (*BatchFile, *interfaces.ErrorMessage) {
+	store := h.batchesForConfig(h.Cfg)
+	if store == nil {
+		return nil, batchesDisabledError()
+	}
+	if purpose != batchInputPurpose {
+		return nil, batchInvalidRequest("purpose must be %q", batchInputPurpose)
+	}
+	if len(bytes.TrimSpace(content)) == 0 {
+		return nil, batchInvalidRequest("file is empty")
+	}
+	if limit := h.BatchMaxFileBytes(); int64(len(content)) > limit {
+		return nil, batchInvalidRequest("file exceeds the %d byte limit", limit)
+	}
+	file := store.addFile(sessionOwner(ctx), filename, purpose, content)
+	return &file, nil
+}
+
+// GetBatchFile returns the metadata of a stored file.
+func (h *BaseAPIHandler) GetBatchFile(ctx context.Context, id string) (*BatchFile, *interfaces.ErrorMessage) {
+	store := h.batchesForConfig(h.Cfg)
+	if store == nil {
+		return nil, batchesDisabledError()
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	file, ok := store.files[id]
+	if !ok || store.fileOwners[id] != sessionOwner(ctx) {
+		return nil, batchFileNotFound(id)
+	}
+	out := *file
+	return &out, nil
+}
+
+// GetBatchFileContent returns the raw content of a stored file.
+func (h *BaseAPIHandler) GetBatchFileContent(ctx context.Context, id string) ([]byte, *interfaces.ErrorMessage) {
+	store := h.batchesForConfig(h.Cfg)
+	if store == nil {
+		return nil, batchesDisabledError()
+	}
+	content, ok := store.ownedFileContent(sessionOwner(ctx), id)
+	if !ok {
+		return nil, batchFileNotFound(id)
+	}
+	return content, nil
+}
+
+// CreateBatch validates the input file and starts processing it in the background. A file
+// that fails validation yields a batch with status "failed" and the validation errors.
+// Lines run under the API key of the request, so its key policy and tenant quota apply.
+func (h *BaseAPIHandler) CreateBatch(ctx context.Context, inputFileID, endpoint, completionWindow string, metadata map[string]string) (*Batch, *interfaces.ErrorMessage) {
+	store := h.batchesForConfig(h.Cfg)
+	if store == nil {
+		return nil, batchesDisabledError()
+	}
+	if _, ok := batchEndpoints[endpoint]; !ok {
+		return nil, batchInvalidRequest("unsupported endpoint %q", endpoint)
+	}
+	if completionWindow != batchCompletionWindow {
+		return nil, batchInvalidRequest("completion_window must be %q", batchCompletionWindow)
+	}
+	owner := sessionOwner(ctx)
+	content, ok := store.ownedFileContent(owner, inputFileID)
+	if !ok {
+		return nil, batchFileNotFound(inputFileID)
+	}
+
+	now := time.Now()
+	run := &batchRun{
+		batch: Batch{
+			ID:               "batch_" + strings.ReplaceAll(uuid.NewString(), "-", ""),
+			Object:           "batch",
+			Endpoint:         endpoint,
+			InputFileID:      inputFileID,
+			CompletionWindow: completionWindow,
+			Status:           "validating",
+			CreatedAt:        now.Unix(),
+			ExpiresAt:        now.Add(24 * time.Hour).Unix(),
+			Metadata:         metadata,
+		},
+		owner:   owner,
+		apiKey:  requestClientAPIKey(ctx),
+		results: make(map[string][]byte),
+	}
+	lines, validationErrors := parseBatchLines(content, endpoint, batchMaxRequests(h.Cfg))
+	if len(validationErrors) > 0 {
+		run.batch.Status = "failed"
+		run.batch.FailedAt = batchTimestamp(now)
+		run.batch.Errors = &BatchErrors{Object: "list", Data: validationErrors}
+	} else {
+		run.batch.RequestCounts.Total = len(lines)
+	}
+
+	store.mu.Lock()
+	store.batches[run.batch.ID] = run
+	store.persistBatchLocked(run)
+	out := run.batch
+	store.mu.Unlock()
+
+	if len(validationErrors) == 0 {
+		store.start(run, lines)
+	}
+	return &out, nil
+}
+
+// GetBatch returns the current state of a batch.
+func (h *BaseAPIHandler) GetBatch(ctx context.Context, id string) (*Batch, *interfaces.ErrorMessage) {
+	store := h.batchesForConfig(h.Cfg)
+	if store == nil {
+		return nil, batchesDisabledError()
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	run, ok := store.batches[id]
+	if !ok || run.owner != sessionOwner(ctx) {
+		return nil, batchNotFound(id)
+	}
+	out := run.batch
+	return &out, nil
+}
+
+// ListBatches returns the client's batches newest first, starting after the batch with
+// ID after. The boolean reports whether more batches follow.
+func (h *BaseAPIHandler) ListBatches(ctx context.Context, after string, limit int) ([]Batch, bool, *interfaces.ErrorMessage) {
+	store := h.batchesForConfig(h.Cfg)
+	if store == nil {
+		return nil, false, batchesDisabledError()
+	}
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	owner := sessionOwner(ctx)
+	store.mu.Lock()
+	all := make([]Batch, 0, len(store.batches))
+	for _, run := range store.batches {
+		if run.owner == owner {
+			all = append(all, run.batch)
+		}
+	}
+	store.mu.Unlock()
+	sort.Slice(all, func(i, j int) bool {
+		if all[i].CreatedAt != all[j].CreatedAt {
+			return all[i].CreatedAt > all[j].CreatedAt
+		}
+		return all[i].ID > all[j].ID
+	})
+	if after != "" {
+		for i := range all {
+			if all[i].ID == after {
+				all = all[i+1:]
+				break
+			}
+		}
+	}
+	if len(all) > limit {
+		return all[:limit], true, nil
+	}
+	return all, false, nil
+}
+
+// CancelBatch stops a running batch. Lines already finished remain in the output file.
+func (h *BaseAPIHandler) CancelBatch(ctx context.Context, id string) (*Batch, *interfaces.ErrorMessage) {
+	store := h.batchesForConfig(h.Cfg)
+	if store == nil {
+		return nil, batchesDisabledError()
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	run, ok := store.batches[id]
+	if !ok || run.owner != sessionOwner(ctx) {
+		return nil, batchNotFound(id)
+	}
+	switch run.batch.Status {
+	case "validating", "in_progress", "finalizing":
+		run.batch.Status = "cancelling"
+		run.batch.CancellingAt = batchTimestamp(time.Now())
+		store.persistBatchLocked(run)
+		if run.cancel != nil {
+			run.cancel()
+		}
+	case "cancelling", "cancelled":
+	default:
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusConflict, Error: fmt.Errorf("cannot cancel batch with status %s", run.batch.Status)}
+	}
+	out := run.batch
+	return &out, nil
+}
+
+// parseBatchLines validates an input file against endpoint and returns its requests.
+func parseBatchLines(content []byte, endpoint string, maxRequests int) ([]batchLine, []BatchErrorData) {
+	var lines []batchLine
+	var validationErrors []BatchErrorData
+	seen := make(map[string]struct{})
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		raw := bytes.TrimSpace(scanner.Bytes())
+		if len(raw) == 0 {
+			continue
+		}
+		fail := func(code, message string) {
+			validationErrors = append(validationErrors, BatchErrorData{Code: code, Message: message, Line: lineNo})
+		}
+		if !gjson.ValidBytes(raw) {
+			fail("invalid_json_line", "line is not valid JSON")
+			continue
+		}
+		customID := gjson.GetBytes(raw, "custom_id").String()
+		if customID == "" {
+			fail("missing_custom_id", "custom_id is required")
+			continue
+		}
+		if _, dup := seen[customID]; dup {
+			fail("duplicate_custom_id", fmt.Sprintf("custom_id %q is used more than once", customID))
+			continue
+		}
+		seen[customID] = struct{}{}
+		if method := gjson.GetBytes(raw, "method").String(); !strings.EqualFold(method, http.MethodPost) {
+			fail("invalid_method", "method must be POST")
+			continue
+		}
+		if url := gjson.GetBytes(raw, "url").String(); url != endpoint {
+			fail("mismatched_endpoint", fmt.Sprintf("url %q does not match batch endpoint %q", url, endpoint))
+			continue
+		}
+		body := gjson.GetBytes(raw, "body")
+		if !body.IsObject() || strings.TrimSpace(body.Get("model").String()) == "" {
+			fail("invalid_request", "body must be an object with a model")
+			continue
+		}
+		lines = append(lines, batchLine{CustomID: customID, Body: []byte(body.Raw)})
+	}
+	if errScan := scanner.Err(); errScan != nil {
+		validationErrors = append(validationErrors, BatchErrorData{Code: "invalid_file", Message: errScan.Error()})
+	}
+	if len(lines) == 0 && len(validationErrors) == 0 {
+		validationErrors = append(validationErrors, BatchErrorData{Code: "empty_file", Message: "input file has no requests"})
+	}
+	if maxRequests > 0 && len(lines) > maxRequests {
+		validationErrors = append(validationErrors, BatchErrorData{Code: "too_many_requests", Message: fmt.Sprintf("input file has %d requests, the limit is %d", len(lines), maxRequests)})
+	}
+	return lines, validationErrors
+}
+
+func batchTimestamp(t time.Time) *int64 {
+	ts := t.Unix()
+	return &ts
+}
+
+func (s *batchStore) addFile(owner, filename, purpose string, content []byte) BatchFile {
+	file := BatchFile{
+		ID:        "file-" + strings.ReplaceAll(uuid.NewString(), "-", ""),
+		Object:    "file",
+		Bytes:     len(content),
+		CreatedAt: time.Now().Unix(),
+		Filename:  filename,
+		Purpose:   purpose,
+	}
+	if s.dir != "" {
+		// Content lives on disk only; it is read back on demand.
+		if data, errMarshal := json.Marshal(batchFileRecord{BatchFile: file, Owner: owner}); errMarshal == nil {
+			s.writeFile(filepath.Join("files", file.ID+".json"), data)
+		}
+		s.writeFile(filepath.Join("files", file.ID+".jsonl"), content)
+	}
+	s.mu.Lock()
+	s.files[file.ID] = &file
+	s.fileOwners[file.ID] = owner
+	if s.dir == "" {
+		s.content[file.ID] = content
+	}
+	s.mu.Unlock()
+	return file
+}
+
+// ownedFileContent returns the content of a file created by owner.
+func (s *batchStore) ownedFileContent(owner, id string) ([]byte, bool) {
+	s.mu.Lock()
+	fileOwner, ok := s.fileOwners[id]
+	s.mu.Unlock()
+	if !ok || fileOwner != owner {
+		return nil, false
+	}
+	return s.fileContent(id)
+}
+
+func (s *batchStore) fileContent(id string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.files[id]; !ok {
+		return nil, false
+	}
+	if content, ok := s.content[id]; ok {
+		return content, true
+	}
+	if s.dir == "" {
+		return nil, false
+	}
+	content, errRead := os.ReadFile(filepath.Join(s.dir, "files", id+".jsonl"))
+	if errRead != nil {
+		log.Warnf("batch: failed to read file %s: %v", id, errRead)
+		return nil, false
+	}
+	return content, true
+}
+
+// start runs the remaining lines of run in a background worker.
+func (s *batchStore) start(run *batchRun, lines []batchLine) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	run.cancel = cancel
+	if run.batch.Status == "validating" {
+		run.batch.Status = "in_progress"
+		run.batch.InProgressAt = batchTimestamp(time.Now())
+	}
+	s.persistBatchLocked(run)
+	s.mu.Unlock()
+	go s.process(ctx, run, lines)
+}
+
+func (s *batchStore) process(ctx context.Context, run *batchRun, lines []batchLine) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("batch %s: worker panic: %v", run.batch.ID, r)
+		}
+	}()
+	handlerType := batchEndpoints[run.batch.Endpoint]
+	deadline := time.Unix(run.batch.ExpiresAt, 0)
+	ctx, cancelDeadline := context.WithDeadline(ctx, deadline)
+	defer cancelDeadline()
+
+	sem := make(chan struct{}, batchConcurrency(s.handler.Cfg))
+	var wg sync.WaitGroup
+	for _, line := range lines {
+		s.mu.Lock()
+		_, done := run.results[line.CustomID]
+		s.mu.Unlock()
+		if done {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case sem <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		wg.Add(1)
+		go func(line batchLine) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			result, ok := s.executeLine(ctx, run, handlerType, line)
+			if ctx.Err() != nil && !ok {
+				return
+			}
+			s.recordResult(run, line.CustomID, result, ok)
+		}(line)
+	}
+	wg.Wait()
+	s.finish(run, ctx.Err())
+}
+
+// batchLineHandlerKey carries the function one batch line runs through batchLineEngine.
+type batchLineHandlerKey struct{}
+
+// batchLineEngine routes every request to the function stored under batchLineHandlerKey,
+// so batch lines get a gin context the same way client requests do.
+var batchLineEngine = sync.OnceValue(func() *gin.Engine {
+	engine := gin.New()
+	engine.NoRoute(func(c *gin.Context) {
+		if run, ok := c.Request.Context().Value(batchLineHandlerKey{}).(func(*gin.Context)); ok {
+			run(c)
+		}
+	})
+	return engine
+})
+
+// batchDiscardWriter drops the response of a batch line request; results are returned by
+// the executor rather than written to the gin context.
+type batchDiscardWriter struct {
+	header http.Header
+}
+
+func (w *batchDiscardWriter) Header() http.Header         { return w.header }
+func (w *batchDiscardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *batchDiscardWriter) WriteHeader(int)             {}
+
+// withBatchLineContext runs fn with the context one line runs under. It carries a request
+// made with the submitter's API key at batch priority, so the key policy, the tenant usage
+// charge and the priority gate treat the line like a request from that client. Each line
+// gets its own gin context because lines run concurrently.
+func withBatchLineContext(ctx context.Context, run *batchRun, fn func(context.Context)) {
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, run.batch.Endpoint, nil)
+	if errReq != nil {
+		fn(ctx)
+		return
+	}
+	req.Header.Set(PriorityHeader, coreauth.RequestPriorityBatch.String())
+	req = req.WithContext(context.WithValue(ctx, batchLineHandlerKey{}, func(c *gin.Context) {
+		if run.apiKey != "" {
+			c.Set("userApiKey", run.apiKey)
+		}
+		fn(context.WithValue(ctx, "gin", c))
+	}))
+	batchLineEngine().ServeHTTP(&batchDiscardWriter{header: make(http.Header)}, req)
+}
+
+// batchTenantQuotaError rejects a line when the submitter is a tenant whose daily quota
+// is used up or who has been disabled since the batch was created.
+func (s *batchStore) batchTenantQuotaError(run *batchRun) *interfaces.ErrorMessage {
+	cfg := s.handler.Cfg
+	if cfg == nil || !cfg.Tenants.Enabled || run.apiKey == "" {
+		return nil
+	}
+	_, found, errAuthorize := tenant.Default().Authorize(run.apiKey)
+	switch {
+	case !found || errAuthorize == nil:
+		return nil
+	case errors.Is(errAuthorize, tenant.ErrQuotaExceeded):
+		return &interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests, Error: errAuthorize}
+	default:
+		return &interfaces.ErrorMessage{StatusCode: http.StatusForbidden, Error: errAuthorize}
+	}
+}
+
+// executeLine runs one request, retrying while every credential is cooling down or rate
+// limited, and renders it as a Batch API output line.
+func (s *batchStore) executeLine(ctx context.Context, run *batchRun, handlerType string, line batchLine) ([]byte, bool) {
+	model := gjson.GetBytes(line.Body, "model").String()
+	body, _ := sjson.DeleteBytes(line.Body, "stream")
+
+	var resp []byte
+	var errMsg *interfaces.ErrorMessage
+	withBatchLineContext(ctx, run, func(ctx context.Context) {
+		for attempt := 1; ; attempt++ {
+			if errMsg = s.batchTenantQuotaError(run); errMsg != nil {
+				return
+			}
+			if handlerType == coreauth.EmbeddingsSourceFormat {
+				resp, _, errMsg = s.handler.ExecuteEmbeddingsWithAuthManager(ctx, model, body)
+			} else {
+				resp, _, errMsg = s.handler.ExecuteWithAuthManager(ctx, handlerType, model, body, "")
+			}
+			if errMsg == nil || attempt >= batchLineMaxAttempts {
+				return
+			}
+			if errMsg.StatusCode != http.StatusTooManyRequests && errMsg.StatusCode != http.StatusServiceUnavailable {
+				return
+			}
+			if batchSleep(ctx, batchRetryDelay(errMsg, attempt)) != nil {
+				return
+			}
+		}
+	})
+
+	out := []byte(`{"id":"","custom_id":"","response":{"status_code":200,"request_id":"","body":null},"error":null}`)
+	out, _ = sjson.SetBytes(out, "id", "batch_req_"+strings.ReplaceAll(uuid.NewString(), "-", ""))
+	out, _ = sjson.SetBytes(out, "custom_id", line.CustomID)
+	if errMsg != nil {
+		status := errMsg.StatusCode
+		if status <= 0 {
+			status = http.StatusInternalServerError
+		}
+		errText := http.StatusText(status)
+		if errMsg.Error != nil && strings.TrimSpace(errMsg.Error.Error()) != "" {
+			errText = strings.TrimSpace(errMsg.Error.Error())
+		}
+		out, _ = sjson.SetBytes(out, "response.status_code", status)
+		out, _ = sjson.SetRawBytes(out, "response.body", BuildErrorResponseBody(status, errText))
+		return out, false
+	}
+	if gjson.ValidBytes(resp) {
+		out, _ = sjson.SetRawBytes(out, "response.body", resp)
+	} else {
+		out, _ = sjson.SetBytes(out, "response.body", string(resp))
+	}
+	return out, true
+}
+
+// batchRetryDelay honours an upstream Retry-After and otherwise backs off linearly.
+func batchRetryDelay(errMsg *interfaces.ErrorMessage, attempt int) time.Duration {
+	if errMsg != nil && errMsg.Error != nil {
+		if raw := coreauth.SafeResponseHeaders(errMsg.Error).Get("Retry-After"); raw != "" {
+			if seconds, errParse := strconv.Atoi(strings.TrimSpace(raw)); errParse == nil && seconds > 0 {
+				return time.Duration(seconds) * time.Second
+			}
+		}
+	}
+	return time.Duration(attempt) * batchLineRetryDelay
+}
+
+func (s *batchStore) recordResult(run *batchRun, customID string, result []byte, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.dir != "" {
+		s.appendFile(filepath.Join("batches", run.batch.ID+".results.jsonl"), append(append([]byte{}, result...), '\n'))
+		run.results[customID] = nil
+	} else {
+		if run.resultBytes+len(result) > batchMaxMemoryResultBytes {
+			result, ok = batchOversizedResult(result), false
+		}
+		run.results[customID] = result
+		run.resultBytes += len(result)
+	}
+	run.order = append(run.order, customID)
+	if ok {
+		run.batch.RequestCounts.Completed++
+	} else {
+		run.batch.RequestCounts.Failed++
+	}
+	s.persistBatchLocked(run)
+}
+
+// batchOversizedResult replaces the response of a line that no longer fits in memory.
+func batchOversizedResult(result []byte) []byte {
+	const message = "batch output exceeds the in-memory limit; set batch.dir to keep results on disk"
+	out, _ := sjson.SetBytes(result, "response.status_code", http.StatusInsufficientStorage)
+	out, _ = sjson.SetRawBytes(out, "response.body", BuildErrorResponseBody(http.StatusInsufficientStorage, message))
+	return out
+}
+
+// finish writes the output and error files and moves the batch to its final status.
+// A worker stopped by stopAll leaves the batch in progress so it resumes on the next load.
+func (s *batchStore) finish(run *batchRun, errCtx error) {
+	s.mu.Lock()
+	now := time.Now()
+	run.cancel = nil
+	cancelling := run.batch.Status == "cancelling"
+	expired := errors.Is(errCtx, context.DeadlineExceeded)
+	if errCtx != nil && !cancelling && !expired {
+		s.mu.Unlock()
+		return
+	}
+	if !cancelling {
+		run.batch.Status = "finalizing"
+		run.batch.FinalizingAt = batchTimestamp(now)
+	}
+	order := append([]string(nil), run.order...)
+	results := run.results
+	if s.dir == "" {
+		results = maps.Clone(results)
+	}
+	s.mu.Unlock()
+
+	if s.dir != "" && len(order) > 0 {
+		results = s.readResults(run.batch.ID)
+	}
+	var output, failures bytes.Buffer
+	for _, customID := range order {
+		result := results[customID]
+		target := &output
+		if gjson.GetBytes(result, "response.status_code").Int() >= http.StatusBadRequest {
+			target = &failures
+		}
+		target.Write(result)
+		target.WriteByte('\n')
+	}
+
+	var outputID, errorID *string
+	if output.Len() > 0 {
+		file := s.addFile(run.owner, run.batch.ID+"_output.jsonl", batchOutputPurpose, output.Bytes())
+		outputID = &file.ID
+	}
+	if failures.Len() > 0 {
+		file := s.addFile(run.owner, run.batch.ID+"_error.jsonl", batchOutputPurpose, failures.Bytes())
+		errorID = &file.ID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run.batch.OutputFileID = outputID
+	run.batch.ErrorFileID = errorID
+	switch {
+	case cancelling:
+		run.batch.Status = "cancelled"
+		run.batch.CancelledAt = batchTimestamp(now)
+	case expired:
+		run.batch.Status = "expired"
+		run.batch.ExpiredAt = batchTimestamp(now)
+	default:
+		run.batch.Status = "completed"
+		run.batch.CompletedAt = batchTimestamp(now)
+	}
+	s.persistBatchLocked(run)
+}
+
+// stopAll cancels every running worker without marking batches cancelled so they resume
+// when a store for the same directory is loaded again.
+func (s *batchStore) stopAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, run := range s.batches {
+		if run.cancel != nil {
+			run.cancel()
+		}
+	}
+}
+
+func (s *batchStore) persistBatchLocked(run *batchRun) {
+	if s.dir == "" {
+		return
+	}
+	data, errMarshal := json.Marshal(batchRecord{Batch: run.batch, Owner: run.owner})
+	if errMarshal != nil {
+		log.Warnf("batch: failed to encode %s: %v", run.batch.ID, errMarshal)
+		return
+	}
+	s.writeFile(filepath.Join("batches", run.batch.ID+".json"), data)
+}
+
+func (s *batchStore) writeFile(name string, data []byte) {
+	path := filepath.Join(s.dir, name)
+	if errMkdir := os.MkdirAll(filepath.Dir(path), 0o700); errMkdir != nil {
+		log.Warnf("batch: failed to create directory: %v", errMkdir)
+		return
+	}
+	tmpPath := path + ".tmp"
+	if errWrite := os.WriteFile(tmpPath, data, 0o600); errWrite != nil {
+		log.Warnf("batch: failed to write %s: %v", name, errWrite)
+		return
+	}
+	if errRename := os.Rename(tmpPath, path); errRename != nil {
+		log.Warnf("batch: failed to replace %s: %v", name, errRename)
+	}
+}
+
+func (s *batchStore) appendFile(name string, data []byte) {
+	path := filepath.Join(s.dir, name)
+	if errMkdir := os.MkdirAll(filepath.Dir(path), 0o700); errMkdir != nil {
+		log.Warnf("batch: failed to create directory: %v", errMkdir)
+		return
+	}
+	f, errOpen := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if errOpen != nil {
+		log.Warnf("batch: failed to open %s: %v", name, errOpen)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	if _, errWrite := f.Write(data); errWrite != nil {
+		log.Warnf("batch: failed to append %s: %v", name, errWrite)
+	}
+}
+
+// load restores files and batches from dir and resumes batches that were still running.
+func (s *batchStore) load() {
+	if s.dir == "" {
+		return
+	}
+	fileMetas, _ := filepath.Glob(filepath.Join(s.dir, "files", "*.json"))
+	for _, path := range fileMetas {
+		data, errRead := os.ReadFile(path)
+		if errRead != nil {
+			continue
+		}
+		var record batchFileRecord
+		if errUnmarshal := json.Unmarshal(data, &record); errUnmarshal != nil || record.ID == "" {
+			log.Warnf("batch: skipping unreadable file record %s", path)
+			continue
+		}
+		file := record.BatchFile
+		s.files[file.ID] = &file
+		s.fileOwners[file.ID] = record.Owner
+	}
+
+	batchMetas, _ := filepath.Glob(filepath.Join(s.dir, "batches", "*.json"))
+	var resume []*batchRun
+	for _, path := range batchMetas {
+		data, errRead := os.ReadFile(path)
+		if errRead != nil {
+			continue
+		}
+		var record batchRecord
+		if errUnmarshal := json.Unmarshal(data, &record); errUnmarshal != nil || record.ID == "" {
+			log.Warnf("batch: skipping unreadable batch record %s", path)
+			continue
+		}
+		run := &batchRun{batch: record.Batch, owner: record.Owner, results: make(map[string][]byte)}
+		s.batches[run.batch.ID] = run
+		switch run.batch.Status {
+		case "validating", "in_progress", "finalizing", "cancelling":
+			s.loadResults(run)
+			resume = append(resume, run)
+		}
+	}
+
+	for _, run := range resume {
+		content, ok := s.fileContent(run.batch.InputFileID)
+		if !ok {
+			log.Warnf("batch %s: input file %s is missing; marking failed", run.batch.ID, run.batch.InputFileID)
+			run.batch.Status = "failed"
+			run.batch.FailedAt = batchTimestamp(time.Now())
+			s.persistBatchLocked(run)
+			continue
+		}
+		lines, _ := parseBatchLines(content, run.batch.Endpoint, 0)
+		if run.batch.Status == "cancelling" {
+			s.finish(run, context.Canceled)
+			continue
+		}
+		apiKey, found := batchAPIKeyForOwner(s.handler.Cfg, run.owner)
+		if !found {
+			log.Warnf("batch %s: submitting API key no longer exists; marking failed", run.batch.ID)
+			run.batch.Status = "failed"
+			run.batch.FailedAt = batchTimestamp(time.Now())
+			run.batch.Errors = &BatchErrors{Object: "list", Data: []BatchErrorData{{
+				Code:    "api_key_not_found",
+				Message: "the API key that created this batch no longer exists",
+			}}}
+			s.persistBatchLocked(run)
+			continue
+		}
+		run.apiKey = apiKey
+		log.Infof("batch %s: resuming with %d of %d requests done", run.batch.ID, len(run.results), len(lines))
+		s.start(run, lines)
+	}
+}
+
+// batchAPIKeyForOwner returns the client API key whose owner hash is owner among the keys
+// currently accepted by the configuration and the tenant store. The empty key matches only
+// while no api-keys are configured.
+func batchAPIKeyForOwner(cfg *config.SDKConfig, owner string) (string, bool) {
+	var candidates []string
+	if cfg == nil || len(cfg.APIKeys) == 0 {
+		candidates = append(candidates, "")
+	}
+	if cfg != nil {
+		candidates = append(candidates, cfg.APIKeys...)
+		if cfg.Tenants.Enabled {
+			for _, t := range tenant.Default().List() {
+				candidates = append(candidates, t.APIKey)
+			}
+		}
+	}
+	for _, key := range candidates {
+		if apiKeyOwner(key) == owner {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// loadResults restores which lines of run completed from its results file so they are not re-run.
+func (s *batchStore) loadResults(run *batchRun) {
+	data, errRead := os.ReadFile(filepath.Join(s.dir, "batches", run.batch.ID+".results.jsonl"))
+	if errRead != nil {
+		return
+	}
+	run.batch.RequestCounts.Completed, run.batch.RequestCounts.Failed = 0, 0
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		customID := gjson.GetBytes(line, "custom_id").String()
+		if customID == "" {
+			continue
+		}
+		if _, dup := run.results[customID]; dup {
+			continue
+		}
+		run.results[customID] = nil
+		run.order = append(run.order, customID)
+		if gjson.GetBytes(line, "response.status_code").Int() >= http.StatusBadRequest {
+			run.batch.RequestCounts.Failed++
+		} else {
+			run.batch.RequestCounts.Completed++
+		}
+	}
+}
+
+// readResults returns the result lines of a batch from its results file keyed by custom_id.
+func (s *batchStore) readResults(id string) map[string][]byte {
+	results := make(map[string][]byte)
+	data, errRead := os.ReadFile(filepath.Join(s.dir, "batches", id+".results.jsonl"))
+	if errRead != nil {
+		log.Warnf("batch %s: failed to read results: %v", id, errRead)
+		return results
+	}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		customID := gjson.GetBytes(line, "custom_id").String()
+		if _, dup := results[customID]; customID == "" || dup {
+			continue
+		}
+		results[customID] = line
+	}
+	return results
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func waitForBatchStatus(t *testing.T, ctx context.Context, h *BaseAPIHandler, id, status string) *Batch {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		batch, errMsg := h.GetBatch(ctx, id)
+		if errMsg != nil {
+			t.Fatalf("GetBatch: %v", errMsg.Error)
+		}
+		if batch.Status == status {
+			return batch
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("batch status = %q, want %q", batch.Status, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBatchRunsLinesAndSplitsOutput(t *testing.T) {
+	executor := &modelExecutionCaptureExecutor{
+		provider: "batch-test",
+		execute: func(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+			if strings.Contains(string(req.Payload), "bad") {
+				return coreexecutor.Response{}, &coreauth.Error{Code: "request_scoped", Message: "bad prompt", HTTPStatus: http.StatusBadRequest}
+			}
+			return coreexecutor.Response{Payload: []byte(`{"id":"chatcmpl-1","object":"chat.completion"}`)}, nil
+		},
+	}
+	cfg := &sdkconfig.SDKConfig{Batch: sdkconfig.BatchConfig{Enabled: true}}
+	h := newModelExecutionHandler(t, "batch-model", executor, cfg)
+
+	input := strings.Join([]string{
+		`{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{"model":"batch-model","messages":[{"role":"user","content":"hi"}]}}`,
+		`{"custom_id":"b","method":"POST","url":"/v1/chat/completions","body":{"model":"batch-model","messages":[{"role":"user","content":"bad"}]}}`,
+	}, "\n")
+	file, errMsg := h.UploadBatchFile(context.Background(), "input.jsonl", "batch", []byte(input))
+	if errMsg != nil {
+		t.Fatalf("UploadBatchFile: %v", errMsg.Error)
+	}
+	batch, errMsg := h.CreateBatch(context.Background(), file.ID, "/v1/chat/completions", "24h", nil)
+	if errMsg != nil {
+		t.Fatalf("CreateBatch: %v", errMsg.Error)
+	}
+
+	done := waitForBatchStatus(t, context.Background(), h, batch.ID, "completed")
+	if done.RequestCounts != (BatchRequestCounts{Total: 2, Completed: 1, Failed: 1}) {
+		t.Fatalf("request counts = %+v", done.RequestCounts)
+	}
+	if done.OutputFileID == nil || done.ErrorFileID == nil {
+		t.Fatalf("expected output and error files, got %+v", done)
+	}
+	output, _ := h.GetBatchFileContent(context.Background(), *done.OutputFileID)
+	if gjson.GetBytes(output, "custom_id").String() != "a" || gjson.GetBytes(output, "response.body.id").String() != "chatcmpl-1" {
+		t.Fatalf("unexpected output file: %s", output)
+	}
+	failures, _ := h.GetBatchFileContent(context.Background(), *done.ErrorFileID)
+	if gjson.GetBytes(failures, "custom_id").String() != "b" || gjson.GetBytes(failures, "response.status_code").Int() != http.StatusBadRequest {
+		t.Fatalf("unexpected error file: %s", failures)
+	}
+}
+
+func TestBatchValidationFailure(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{Batch: sdkconfig.BatchConfig{Enabled: true}}}
+	input := `{"custom_id":"a","method":"POST","url":"/v1/embeddings","body":{"model":"m"}}` + "\n" + `not json`
+	file, _ := h.UploadBatchFile(context.Background(), "input.jsonl", "batch", []byte(input))
+
+	batch, errMsg := h.CreateBatch(context.Background(), file.ID, "/v1/chat/completions", "24h", nil)
+	if errMsg != nil {
+		t.Fatalf("CreateBatch: %v", errMsg.Error)
+	}
+	if batch.Status != "failed" || batch.Errors == nil || len(batch.Errors.Data) != 2 {
+		t.Fatalf("expected failed batch with two errors, got %+v", batch)
+	}
+	if batch.Errors.Data[0].Code != "mismatched_endpoint" || batch.Errors.Data[1].Line != 2 {
+		t.Fatalf("unexpected validation errors: %+v", batch.Errors.Data)
+	}
+
+	if _, errMsg = h.UploadBatchFile(context.Background(), "x.jsonl", "fine-tune", []byte(input)); errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected purpose rejection, got %+v", errMsg)
+	}
+}
+
+func TestBatchResumesFromDisk(t *testing.T) {
+	var calls atomic.Int32
+	executor := &modelExecutionCaptureExecutor{
+		provider: "batch-test",
+		execute: func(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+			calls.Add(1)
+			return coreexecutor.Response{Payload: []byte(`{"ok":true}`)}, nil
+		},
+	}
+	dir := t.TempDir()
+	cfg := &sdkconfig.SDKConfig{Batch: sdkconfig.BatchConfig{Enabled: true, Dir: dir}}
+	h := newModelExecutionHandler(t, "batch-model", executor, cfg)
+
+	store := h.batchesForConfig(cfg)
+	input := `{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{"model":"batch-model","messages":[]}}` + "\n" +
+		`{"custom_id":"b","method":"POST","url":"/v1/chat/completions","body":{"model":"batch-model","messages":[]}}`
+	file := store.addFile(sessionOwner(context.Background()), "input.jsonl", "batch", []byte(input))
+
+	// Simulate a process that stopped after finishing line "a".
+	run := &batchRun{batch: Batch{
+		ID:               "batch_resume",
+		Object:           "batch",
+		Endpoint:         "/v1/chat/completions",
+		InputFileID:      file.ID,
+		CompletionWindow: "24h",
+		Status:           "in_progress",
+		CreatedAt:        time.Now().Unix(),
+		ExpiresAt:        time.Now().Add(time.Hour).Unix(),
+		RequestCounts:    BatchRequestCounts{Total: 2},
+	}, owner: sessionOwner(context.Background())}
+	store.persistBatchLocked(run)
+	results := `{"id":"batch_req_1","custom_id":"a","response":{"status_code":200,"body":{"ok":true}},"error":null}` + "\n"
+	if errWrite := os.WriteFile(filepath.Join(dir, "batches", "batch_resume.results.jsonl"), []byte(results), 0o600); errWrite != nil {
+		t.Fatalf("write results: %v", errWrite)
+	}
+
+	// A fresh store for the same directory picks the batch up again.
+	h.batches = nil
+	h.batchesForConfig(cfg)
+	done := waitForBatchStatus(t, context.Background(), h, "batch_resume", "completed")
+	if calls.Load() != 1 {
+		t.Fatalf("executor calls = %d, want only the unfinished line", calls.Load())
+	}
+	if done.RequestCounts.Completed != 2 {
+		t.Fatalf("request counts = %+v", done.RequestCounts)
+	}
+	output, _ := h.GetBatchFileContent(context.Background(), *done.OutputFileID)
+	if got := strings.Count(strings.TrimSpace(string(output)), "\n") + 1; got != 2 {
+		t.Fatalf("output lines = %d, want 2: %s", got, output)
+	}
+}
+
+func TestBatchUploadRespectsMaxFileBytes(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{Batch: sdkconfig.BatchConfig{Enabled: true, MaxFileBytes: 64}}
+	h := newModelExecutionHandler(t, "batch-model", &modelExecutionCaptureExecutor{provider: "batch-test"}, cfg)
+
+	line := `{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{"model":"batch-model","messages":[]}}`
+	if _, errMsg := h.UploadBatchFile(context.Background(), "input.jsonl", "batch", []byte(line)); errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("UploadBatchFile over the limit = %+v, want 400", errMsg)
+	}
+	cfg.Batch.MaxFileBytes = 0
+	if _, errMsg := h.UploadBatchFile(context.Background(), "input.jsonl", "batch", []byte(line)); errMsg != nil {
+		t.Fatalf("UploadBatchFile with the default limit: %v", errMsg.Error)
+	}
+}
+
+func TestBatchInMemoryResultsAreCapped(t *testing.T) {
+	store := newBatchStore("", &BaseAPIHandler{})
+	run := &batchRun{results: make(map[string][]byte), resultBytes: batchMaxMemoryResultBytes - 10}
+	result := []byte(`{"id":"batch_req_1","custom_id":"a","response":{"status_code":200,"request_id":"","body":{"ok":true}},"error":null}`)
+	store.recordResult(run, "a", result, true)
+
+	if run.batch.RequestCounts.Failed != 1 || run.batch.RequestCounts.Completed != 0 {
+		t.Fatalf("request counts = %+v, want the line failed", run.batch.RequestCounts)
+	}
+	if status := gjson.GetBytes(run.results["a"], "response.status_code").Int(); status != http.StatusInsufficientStorage {
+		t.Fatalf("status_code = %d, want %d", status, http.StatusInsufficientStorage)
+	}
+}
+
+func TestBatchResumeResolvesKeyFromConfig(t *testing.T) {
+	executor := &modelExecutionCaptureExecutor{
+		provider: "batch-test",
+		execute: func(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+			return coreexecutor.Response{Payload: []byte(`{"ok":true}`)}, nil
+		},
+	}
+	dir := t.TempDir()
+	cfg := &sdkconfig.SDKConfig{APIKeys: []string{"key-a", "key-b"}, Batch: sdkconfig.BatchConfig{Enabled: true, Dir: dir}}
+	h := newModelExecutionHandler(t, "batch-model", executor, cfg)
+
+	store := h.batchesForConfig(cfg)
+	input := `{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{"model":"batch-model","messages":[]}}`
+	for _, key := range []string{"key-a", "key-b"} {
+		file := store.addFile(apiKeyOwner(key), "input.jsonl", "batch", []byte(input))
+		run := &batchRun{batch: Batch{
+			ID:               "batch_" + strings.ReplaceAll(key, "-", "_"),
+			Object:           "batch",
+			Endpoint:         "/v1/chat/completions",
+			InputFileID:      file.ID,
+			CompletionWindow: "24h",
+			Status:           "in_progress",
+			CreatedAt:        time.Now().Unix(),
+			ExpiresAt:        time.Now().Add(time.Hour).Unix(),
+			RequestCounts:    BatchRequestCounts{Total: 1},
+		}, owner: apiKeyOwner(key), apiKey: key}
+		store.persistBatchLocked(run)
+	}
+	records, _ := filepath.Glob(filepath.Join(dir, "batches", "*.json"))
+	for _, path := range records {
+		data, _ := os.ReadFile(path)
+		if strings.Contains(string(data), "key-a") || strings.Contains(string(data), "key-b") {
+			t.Fatalf("batch record %s contains the raw API key: %s", path, data)
+		}
+	}
+
+	// key-b was removed while the process was down.
+	cfg.APIKeys = []string{"key-a"}
+	h.batches = nil
+	h.batchesForConfig(cfg)
+	waitForBatchStatus(t, clientRequestTestContext("key-a", nil), h, "batch_key_a", "completed")
+	failed := waitForBatchStatus(t, clientRequestTestContext("key-b", nil), h, "batch_key_b", "failed")
+	if failed.Errors == nil || len(failed.Errors.Data) != 1 || failed.Errors.Data[0].Code != "api_key_not_found" {
+		t.Fatalf("errors = %+v, want api_key_not_found", failed.Errors)
+	}
+}
+
+func TestBatchIsScopedToTheSubmittingKey(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		apiKeys  []string
+		priority []any
+	)
+	executor := &modelExecutionCaptureExecutor{
+		provider: "batch-test",
+		execute: func(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			apiKeys = append(apiKeys, requestClientAPIKey(ctx))
+			priority = append(priority, opts.Metadata[coreexecutor.RequestPriorityMetadataKey])
+			return coreexecutor.Response{Payload: []byte(`{"ok":true}`)}, nil
+		},
+	}
+	cfg := &sdkconfig.SDKConfig{Batch: sdkconfig.BatchConfig{Enabled: true}}
+	h := newModelExecutionHandler(t, "batch-model", executor, cfg)
+	owner := clientRequestTestContext("key-a", nil)
+	other := clientRequestTestContext("key-b", nil)
+
+	input := `{"custom_id":"a","method":"POST","url":"/v1/chat/completions","body":{"model":"batch-model","messages":[]}}`
+	file, errMsg := h.UploadBatchFile(owner, "input.jsonl", "batch", []byte(input))
+	if errMsg != nil {
+		t.Fatalf("UploadBatchFile: %v", errMsg.Error)
+	}
+	if _, errMsg = h.GetBatchFile(other, file.ID); errMsg == nil || errMsg.StatusCode != http.StatusNotFound {
+		t.Fatalf("other key GetBatchFile = %+v, want 404", errMsg)
+	}
+	if _, errMsg = h.CreateBatch(other, file.ID, "/v1/chat/completions", "24h", nil); errMsg == nil || errMsg.StatusCode != http.StatusNotFound {
+		t.Fatalf("other key CreateBatch = %+v, want 404", errMsg)
+	}
+	batch, errMsg := h.CreateBatch(owner, file.ID, "/v1/chat/completions", "24h", nil)
+	if errMsg != nil {
+		t.Fatalf("CreateBatch: %v", errMsg.Error)
+	}
+	if _, errMsg = h.GetBatch(other, batch.ID); errMsg == nil || errMsg.StatusCode != http.StatusNotFound {
+		t.Fatalf("other key GetBatch = %+v, want 404", errMsg)
+	}
+	if _, errMsg = h.CancelBatch(other, batch.ID); errMsg == nil || errMsg.StatusCode != http.StatusNotFound {
+		t.Fatalf("other key CancelBatch = %+v, want 404", errMsg)
+	}
+	if listed, _, _ := h.ListBatches(other, "", 0); len(listed) != 0 {
+		t.Fatalf("other key ListBatches = %+v, want none", listed)
+	}
+
+	done := waitForBatchStatus(t, owner, h, batch.ID, "completed")
+	if _, errMsg = h.GetBatchFileContent(other, *done.OutputFileID); errMsg == nil {
+		t.Fatal("other key read the output file")
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(apiKeys) != 1 || apiKeys[0] != "key-a" || priority[0] != "batch" {
+		t.Fatalf("line ran with api keys %v and priority %v, want key-a at batch priority", apiKeys, priority)
+	}
+}
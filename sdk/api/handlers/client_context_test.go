@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gin-gonic/gin"
+)
+
+// clientRequestTestContext returns a request context carrying a gin POST request from the
+// client apiKey with the given headers. Empty keys and header values are left unset.
+func clientRequestTestContext(apiKey string, headers map[string]string) context.Context {
+	gin.SetMode(gin.TestMode)
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	for name, value := range headers {
+		if value != "" {
+			ginCtx.Request.Header.Set(name, value)
+		}
+	}
+	if apiKey != "" {
+		ginCtx.Set("userApiKey", apiKey)
+	}
+	return context.WithValue(context.Background(), "gin", ginCtx)
+}
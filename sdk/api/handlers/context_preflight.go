@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// modelContextLimits holds the most permissive limits among the providers that may serve a
+// model, so a request is only refused when none of them could take it. contextLength is 0
+// unless every provider declares a combined context window.
+type modelContextLimits struct {
+	contextLength int
+	inputLimit    int
+}
+
+// preflightContextWindow compares the estimated prompt tokens with the context window of
+// the model and refuses requests that cannot fit, instead of spending an upstream call and
+// a credential cooldown on a guaranteed 400. When the prompt fits but the requested output
+// limit does not, the output limit is lowered if clamping is enabled. It returns the
+// possibly rewritten request body.
+func (h *BaseAPIHandler) preflightContextWindow(entryProtocol string, providers []string, model string, meta map[string]any, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	if h == nil || h.Cfg == nil || !h.Cfg.ContextPreflight.Enabled {
+		return rawJSON, nil
+	}
+	promptTokens, ok := meta[coreexecutor.EstimatedInputTokensMetadataKey].(int)
+	if !ok || promptTokens <= 0 {
+		return rawJSON, nil
+	}
+	baseModel := strings.TrimSpace(model)
+	if parsed := thinking.ParseSuffix(baseModel); parsed.ModelName != "" {
+		baseModel = parsed.ModelName
+	}
+	limits := lookupModelContextLimits(providers, baseModel)
+	if limits.inputLimit > 0 && promptTokens > limits.inputLimit {
+		return nil, contextLengthExceededError(fmt.Sprintf("estimated prompt of %d tokens exceeds the %d token input limit of model %s", promptTokens, limits.inputLimit, baseModel))
+	}
+	if limits.contextLength <= 0 {
+		return rawJSON, nil
+	}
+	if promptTokens >= limits.contextLength {
+		return nil, contextLengthExceededError(fmt.Sprintf("estimated prompt of %d tokens exceeds the %d token context window of model %s", promptTokens, limits.contextLength, baseModel))
+	}
+	available := int64(limits.contextLength - promptTokens)
+	for _, field := range maxTokensFields[entryProtocol] {
+		value := gjson.GetBytes(rawJSON, field)
+		if !value.Exists() || value.Type == gjson.Null || value.Int() <= available {
+			continue
+		}
+		if !h.Cfg.ContextPreflight.ClampMaxTokens {
+			return nil, contextLengthExceededError(fmt.Sprintf("estimated prompt of %d tokens plus %s %d exceeds the %d token context window of model %s", promptTokens, field, value.Int(), limits.contextLength, baseModel))
+		}
+		rawJSON, _ = sjson.SetBytes(rawJSON, field, available)
+	}
+	return rawJSON, nil
+}
+
+func lookupModelContextLimits(providers []string, model string) modelContextLimits {
+	var limits modelContextLimits
+	if len(providers) == 0 {
+		providers = []string{""}
+	}
+	allHaveContextLength := true
+	for _, provider := range providers {
+		info := registry.LookupModelInfo(model, provider)
+		if info == nil {
+			continue
+		}
+		inputLimit := info.InputTokenLimit
+		if inputLimit <= 0 {
+			inputLimit = info.ContextLength
+		}
+		// An unknown limit on any candidate means the request may fit there.
+		if inputLimit <= 0 {
+			return modelContextLimits{}
+		}
+		limits.inputLimit = max(limits.inputLimit, inputLimit)
+		if info.ContextLength <= 0 {
+			allHaveContextLength = false
+		}
+		limits.contextLength = max(limits.contextLength, info.ContextLength)
+	}
+	if !allHaveContextLength {
+		limits.contextLength = 0
+	}
+	return limits
+}
+
+func contextLengthExceededError(message string) *interfaces.ErrorMessage {
+	return apiKeyPolicyError(http.StatusBadRequest, "invalid_request_error", "context_length_exceeded", message)
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestPreflightContextWindow(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("test-context-preflight", "openai", []*registry.ModelInfo{{ID: "preflight-model", ContextLength: 1000}})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-context-preflight") })
+
+	cfg := &sdkconfig.SDKConfig{ContextPreflight: sdkconfig.ContextPreflightConfig{Enabled: true}}
+	handler := NewBaseAPIHandlers(cfg, nil)
+	body := []byte(`{"model":"preflight-model","max_tokens":500}`)
+	meta := func(tokens int) map[string]any {
+		return map[string]any{coreexecutor.EstimatedInputTokensMetadataKey: tokens}
+	}
+
+	if _, errMsg := handler.preflightContextWindow("openai", []string{"openai"}, "preflight-model(high)", meta(1200), body); errMsg == nil || errMsg.StatusCode != 400 || !strings.Contains(errMsg.Error.Error(), "context_length_exceeded") {
+		t.Fatalf("oversized prompt error = %+v, want 400 context_length_exceeded", errMsg)
+	}
+	if got, errMsg := handler.preflightContextWindow("openai", []string{"openai"}, "preflight-model", meta(200), body); errMsg != nil || string(got) != string(body) {
+		t.Fatalf("fitting request = %s, %+v; want it unchanged", got, errMsg)
+	}
+	if _, errMsg := handler.preflightContextWindow("openai", []string{"openai"}, "preflight-model", meta(800), body); errMsg == nil {
+		t.Fatal("expected prompt plus max_tokens over the window to be refused")
+	}
+
+	cfg.ContextPreflight.ClampMaxTokens = true
+	got, errMsg := handler.preflightContextWindow("openai", []string{"openai"}, "preflight-model", meta(800), body)
+	if errMsg != nil || gjson.GetBytes(got, "max_tokens").Int() != 200 {
+		t.Fatalf("clamped request = %s, %+v; want max_tokens 200", got, errMsg)
+	}
+
+	if _, errMsg := handler.preflightContextWindow("openai", []string{"openai"}, "unknown-preflight-model", meta(1_000_000), body); errMsg != nil {
+		t.Fatalf("unknown model error = %+v, want no check", errMsg)
+	}
+}
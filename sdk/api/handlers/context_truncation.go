@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ContextTruncatedHeader reports how many conversation items were dropped to fit the
+// model's context window.
+const ContextTruncatedHeader = "X-CLIProxy-Context-Truncated"
+
+// conversationLayout describes where a request format keeps its conversation and which
+// items start a new turn. Turns are dropped whole so tool calls stay next to their results.
+type conversationLayout struct {
+	path      string
+	pinned    func(item gjson.Result) bool
+	turnStart func(item gjson.Result) bool
+}
+
+var conversationLayouts = map[string]conversationLayout{
+	"openai": {
+		path:      "messages",
+		pinned:    isSystemConversationItem,
+		turnStart: func(item gjson.Result) bool { return item.Get("role").String() == "user" },
+	},
+	"openai-response": {
+		path:      "input",
+		pinned:    isSystemConversationItem,
+		turnStart: func(item gjson.Result) bool { return item.Get("role").String() == "user" },
+	},
+	"claude": {
+		path:      "messages",
+		turnStart: isClaudeUserTurn,
+	},
+}
+
+func isSystemConversationItem(item gjson.Result) bool {
+	role := item.Get("role").String()
+	return role == "system" || role == "developer"
+}
+
+// isClaudeUserTurn reports whether item is a user message that is not just carrying tool results.
+func isClaudeUserTurn(item gjson.Result) bool {
+	if item.Get("role").String() != "user" {
+		return false
+	}
+	content := item.Get("content")
+	if !content.IsArray() {
+		return true
+	}
+	for _, block := range content.Array() {
+		if block.Get("type").String() == "tool_result" {
+			return false
+		}
+	}
+	return true
+}
+
+// truncateContextWindow drops the oldest turns of a conversation that does not fit the
+// model's context window, keeping system messages and the latest turn. The estimate in
+// meta is updated and the number of dropped items is reported in ContextTruncatedHeader.
+func (h *BaseAPIHandler) truncateContextWindow(ctx context.Context, entryProtocol string, providers []string, model string, meta map[string]any, rawJSON []byte) []byte {
+	if h == nil || h.Cfg == nil || !h.Cfg.ContextTruncation.Enabled {
+		return rawJSON
+	}
+	layout, ok := conversationLayouts[entryProtocol]
+	if !ok {
+		return rawJSON
+	}
+	promptTokens, ok := meta[coreexecutor.EstimatedInputTokensMetadataKey].(int)
+	if !ok || promptTokens <= 0 {
+		return rawJSON
+	}
+	baseModel := strings.TrimSpace(model)
+	if parsed := thinking.ParseSuffix(baseModel); parsed.ModelName != "" {
+		baseModel = parsed.ModelName
+	}
+	budget := contextTokenBudget(entryProtocol, lookupModelContextLimits(providers, baseModel), rawJSON)
+	if budget <= 0 || promptTokens <= budget {
+		return rawJSON
+	}
+
+	items := gjson.GetBytes(rawJSON, layout.path).Array()
+	pinned := 0
+	for pinned < len(items) && layout.pinned != nil && layout.pinned(items[pinned]) {
+		pinned++
+	}
+	var cuts []int
+	for i := pinned + 1; i < len(items); i++ {
+		if layout.turnStart(items[i]) {
+			cuts = append(cuts, i)
+		}
+	}
+	if len(cuts) == 0 {
+		return rawJSON
+	}
+	format := sdktranslator.FromString(entryProtocol)
+	bodies := make(map[int][]byte, len(cuts))
+	counts := make(map[int]int, len(cuts))
+	truncate := func(k int) []byte {
+		if body, ok := bodies[k]; ok {
+			return body
+		}
+		kept := make([]string, 0, pinned+len(items)-cuts[k])
+		for _, item := range items[:pinned] {
+			kept = append(kept, item.Raw)
+		}
+		for _, item := range items[cuts[k]:] {
+			kept = append(kept, item.Raw)
+		}
+		body, errSet := sjson.SetRawBytes(rawJSON, layout.path, []byte("["+strings.Join(kept, ",")+"]"))
+		if errSet != nil {
+			body = nil
+		}
+		bodies[k] = body
+		counts[k] = estimateInputTokens(format, model, body)
+		return body
+	}
+	// Fewer kept turns never cost more tokens, so the first cut that fits is found by bisection.
+	k := sort.Search(len(cuts), func(k int) bool {
+		return truncate(k) != nil && counts[k] <= budget
+	})
+	if k == len(cuts) {
+		k = len(cuts) - 1
+	}
+	body := truncate(k)
+	if body == nil {
+		return rawJSON
+	}
+	dropped := cuts[k] - pinned
+	log.Infof("context truncation: dropped %d of %d conversation items for model %s (estimated %d -> %d tokens, budget %d)", dropped, len(items), baseModel, promptTokens, counts[k], budget)
+	if counts[k] > 0 {
+		meta[coreexecutor.EstimatedInputTokensMetadataKey] = counts[k]
+	}
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+			ginCtx.Header(ContextTruncatedHeader, strconv.Itoa(dropped))
+		}
+	}
+	return body
+}
+
+// contextTokenBudget returns the prompt tokens available to a request: the input limit,
+// lowered by the requested output limit when the model has a combined context window.
+func contextTokenBudget(entryProtocol string, limits modelContextLimits, rawJSON []byte) int {
+	budget := limits.inputLimit
+	if limits.contextLength <= 0 {
+		return budget
+	}
+	for _, field := range maxTokensFields[entryProtocol] {
+		if value := gjson.GetBytes(rawJSON, field); value.Exists() && value.Int() > 0 {
+			return min(budget, limits.contextLength-int(value.Int()))
+		}
+	}
+	return budget
+}
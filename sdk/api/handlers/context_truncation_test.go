@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestTruncateContextWindowDropsOldestTurns(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("test-context-truncation", "openai", []*registry.ModelInfo{{ID: "truncation-model", ContextLength: 300}})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-context-truncation") })
+
+	filler := strings.Repeat("lorem ipsum dolor sit amet ", 10)
+	messages := []string{`{"role":"system","content":"be brief"}`}
+	for i := 0; i < 6; i++ {
+		messages = append(messages,
+			fmt.Sprintf(`{"role":"user","content":"question %d %s"}`, i, filler),
+			fmt.Sprintf(`{"role":"assistant","tool_calls":[{"id":"call_%d","type":"function","function":{"name":"f","arguments":"{}"}}]}`, i),
+			fmt.Sprintf(`{"role":"tool","tool_call_id":"call_%d","content":"%s"}`, i, filler))
+	}
+	body := []byte(`{"model":"truncation-model","max_tokens":50,"messages":[` + strings.Join(messages, ",") + `]}`)
+	meta := map[string]any{}
+	maybeAttachEstimatedInputTokens(meta, sdktranslator.FromString("openai"), "truncation-model", body)
+	before, _ := meta[coreexecutor.EstimatedInputTokensMetadataKey].(int)
+	if before <= 250 {
+		t.Fatalf("estimated tokens = %d, test conversation should exceed the window", before)
+	}
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+	if got := handler.truncateContextWindow(nil, "openai", []string{"openai"}, "truncation-model", meta, body); string(got) != string(body) {
+		t.Fatal("truncation ran while disabled")
+	}
+
+	handler.Cfg.ContextTruncation.Enabled = true
+	got := handler.truncateContextWindow(nil, "openai", []string{"openai"}, "truncation-model", meta, body)
+	kept := gjson.GetBytes(got, "messages").Array()
+	if len(kept) >= len(messages) || len(kept) < 4 {
+		t.Fatalf("kept %d of %d messages", len(kept), len(messages))
+	}
+	if kept[0].Get("role").String() != "system" || kept[1].Get("role").String() != "user" {
+		t.Fatalf("truncated conversation must keep the system prompt and start at a user turn: %s", got)
+	}
+	if last := kept[len(kept)-1].Get("tool_call_id").String(); last != "call_5" {
+		t.Fatalf("latest turn was not kept: %s", got)
+	}
+	after, _ := meta[coreexecutor.EstimatedInputTokensMetadataKey].(int)
+	if after >= before || after > 250 {
+		t.Fatalf("estimate after truncation = %d (before %d), want it within the 250 token budget", after, before)
+	}
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+// MaxCostHeader lets a client lower the cost ceiling (in USD) for a single request.
+const MaxCostHeader = "X-Max-Cost"
+
+// costOutputTextFields lists the JSON fields whose string values carry generated text in
+// the streaming formats the proxy emits. Only these are counted toward output tokens so
+// envelope fields such as ids and model names do not inflate the estimate.
+var costOutputTextFields = map[string]struct{}{
+	"text":              {},
+	"content":           {},
+	"delta":             {},
+	"partial_json":      {},
+	"arguments":         {},
+	"thinking":          {},
+	"reasoning_content": {},
+	"refusal":           {},
+}
+
+// requestCostBudget tracks the estimated cost of one request against its ceiling.
+type requestCostBudget struct {
+	ceiling      float64
+	price        registry.ModelPrice
+	promptTokens int64
+	outputChars  int64
+}
+
+// estimatedCost returns the prompt cost plus the cost of the output seen so far.
+func (b *requestCostBudget) estimatedCost() float64 {
+	return b.price.Cost(b.promptTokens, (b.outputChars+3)/4)
+}
+
+// consume accounts for one streamed chunk and reports an error once the ceiling is crossed.
+func (b *requestCostBudget) consume(payload []byte) *interfaces.ErrorMessage {
+	if b == nil {
+		return nil
+	}
+	b.outputChars += int64(streamChunkTextLength(payload))
+	if cost := b.estimatedCost(); cost > b.ceiling {
+		return costCeilingError(cost, b.ceiling, "stream aborted: estimated cost")
+	}
+	return nil
+}
+
+// prepareCostBudget resolves the cost ceiling for the request and rejects it when the
+// estimated prompt cost already exceeds it. It returns nil when no ceiling applies or
+// the model has no configured price.
+func (h *BaseAPIHandler) prepareCostBudget(ctx context.Context, providers []string, model string, meta map[string]any, rawJSON []byte) (*requestCostBudget, *interfaces.ErrorMessage) {
+	ceiling, errMsg := h.requestCostCeiling(ctx)
+	if errMsg != nil || ceiling <= 0 {
+		return nil, errMsg
+	}
+	price, ok := cheapestModelPrice(providers, model)
+	if !ok {
+		return nil, nil
+	}
+	promptTokens := int64(len(rawJSON) / 4)
+	if estimated, okEstimated := meta[coreexecutor.EstimatedInputTokensMetadataKey].(int); okEstimated && estimated > 0 {
+		promptTokens = int64(estimated)
+	}
+	budget := &requestCostBudget{ceiling: ceiling, price: price, promptTokens: promptTokens}
+	if cost := budget.estimatedCost(); cost > ceiling {
+		return nil, costCeilingError(cost, ceiling, "estimated request cost")
+	}
+	return budget, nil
+}
+
+// requestCostCeiling returns the effective ceiling for the request: the per-key or default
+// configured value, lowered by the client's X-Max-Cost header when present.
+func (h *BaseAPIHandler) requestCostCeiling(ctx context.Context) (float64, *interfaces.ErrorMessage) {
+	var ginCtx *gin.Context
+	if ctx != nil {
+		ginCtx, _ = ctx.Value("gin").(*gin.Context)
+	}
+	ceiling := 0.0
+	if h != nil && h.Cfg != nil {
+		ceiling = h.Cfg.CostCeiling.MaxCost
+		if ginCtx != nil && len(h.Cfg.CostCeiling.APIKeys) > 0 {
+			if value, exists := ginCtx.Get("userApiKey"); exists {
+				if apiKey, ok := value.(string); ok {
+					if keyCeiling, found := h.Cfg.CostCeiling.APIKeys[apiKey]; found {
+						ceiling = keyCeiling
+					}
+				}
+			}
+		}
+	}
+	if ginCtx == nil || ginCtx.Request == nil {
+		return ceiling, nil
+	}
+	raw := strings.TrimSpace(ginCtx.GetHeader(MaxCostHeader))
+	if raw == "" {
+		return ceiling, nil
+	}
+	requested, errParse := strconv.ParseFloat(raw, 64)
+	if errParse != nil || requested <= 0 {
+		return 0, &interfaces.ErrorMessage{
+			StatusCode: http.StatusBadRequest,
+			Error:      fmt.Errorf("invalid %s header %q: must be a positive USD amount", MaxCostHeader, raw),
+		}
+	}
+	if ceiling <= 0 || requested < ceiling {
+		ceiling = requested
+	}
+	return ceiling, nil
+}
+
+// cheapestModelPrice returns the lowest configured price for model across providers,
+// so a request is only rejected when no candidate provider could serve it within budget.
+func cheapestModelPrice(providers []string, model string) (registry.ModelPrice, bool) {
+	pricing := registry.GetGlobalPricingRegistry()
+	var best registry.ModelPrice
+	found := false
+	if len(providers) == 0 {
+		providers = []string{""}
+	}
+	for _, provider := range providers {
+		price, ok := pricing.Lookup(provider, model)
+		if !ok {
+			continue
+		}
+		if !found || price.UnitCost() < best.UnitCost() {
+			best = price
+			found = true
+		}
+	}
+	return best, found
+}
+
+func costCeilingError(cost, ceiling float64, what string) *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadRequest,
+		Error:      fmt.Errorf("%s $%.6f exceeds the cost ceiling of $%.6f", what, cost, ceiling),
+	}
+}
+
+// streamChunkTextLength sums the generated text carried by a streamed chunk.
+// It accepts raw JSON as well as SSE frames with one or more "data:" lines.
+func streamChunkTextLength(payload []byte) int {
+	total := 0
+	for _, line := range strings.Split(string(payload), "\n") {
+		line = strings.TrimSpace(line)
+		if data, ok := strings.CutPrefix(line, "data:"); ok {
+			line = strings.TrimSpace(data)
+		}
+		if line == "" || !gjson.Valid(line) {
+			continue
+		}
+		total += jsonTextLength(gjson.Parse(line), false)
+	}
+	return total
+}
+
+func jsonTextLength(value gjson.Result, counted bool) int {
+	switch {
+	case value.IsObject():
+		total := 0
+		value.ForEach(func(key, child gjson.Result) bool {
+			_, isText := costOutputTextFields[key.String()]
+			total += jsonTextLength(child, isText)
+			return true
+		})
+		return total
+	case value.IsArray():
+		total := 0
+		value.ForEach(func(_, child gjson.Result) bool {
+			total += jsonTextLength(child, counted)
+			return true
+		})
+		return total
+	case value.Type == gjson.String && counted:
+		return len(value.Str)
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func withTestPrices(t *testing.T, prices ...registry.ModelPrice) {
+	t.Helper()
+	pricing := registry.GetGlobalPricingRegistry()
+	previous := pricing.Prices()
+	pricing.SetPrices(prices)
+	t.Cleanup(func() { pricing.SetPrices(previous) })
+}
+
+func TestRequestCostCeilingResolution(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{CostCeiling: sdkconfig.CostCeilingConfig{
+		MaxCost: 1,
+		APIKeys: map[string]float64{"vip": 5},
+	}}}
+
+	cases := []struct {
+		name    string
+		apiKey  string
+		header  string
+		want    float64
+		wantErr bool
+	}{
+		{name: "default", want: 1},
+		{name: "per key", apiKey: "vip", want: 5},
+		{name: "header lowers", apiKey: "vip", header: "0.25", want: 0.25},
+		{name: "header cannot raise", header: "3", want: 1},
+		{name: "invalid header", header: "free", wantErr: true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, errMsg := h.requestCostCeiling(clientRequestTestContext(tc.apiKey, map[string]string{MaxCostHeader: tc.header}))
+			if (errMsg != nil) != tc.wantErr {
+				t.Fatalf("error = %v, wantErr %v", errMsg, tc.wantErr)
+			}
+			if !tc.wantErr && got != tc.want {
+				t.Fatalf("ceiling = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestPrepareCostBudgetRejectsAndAbortsStreams(t *testing.T) {
+	withTestPrices(t,
+		registry.ModelPrice{Provider: "claude", Model: "m1", PromptPerMillion: 10, CompletionPerMillion: 100},
+		registry.ModelPrice{Provider: "codex", Model: "m1", PromptPerMillion: 1, CompletionPerMillion: 10},
+	)
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{}}
+	meta := map[string]any{coreexecutor.EstimatedInputTokensMetadataKey: 100_000}
+
+	// Cheapest provider: 100k prompt tokens at $1/M = $0.10.
+	if _, errMsg := h.prepareCostBudget(clientRequestTestContext("", map[string]string{MaxCostHeader: "0.05"}), []string{"claude", "codex"}, "m1", meta, nil); errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("prepareCostBudget() error = %v, want 400 rejection", errMsg)
+	}
+	budget, errMsg := h.prepareCostBudget(clientRequestTestContext("", map[string]string{MaxCostHeader: "0.11"}), []string{"claude", "codex"}, "m1", meta, nil)
+	if errMsg != nil || budget == nil {
+		t.Fatalf("prepareCostBudget() = %v, %v; want budget", budget, errMsg)
+	}
+	if errConsume := budget.consume([]byte(`data: {"id":"chatcmpl-123456789","choices":[{"delta":{"content":"hello"}}]}`)); errConsume != nil {
+		t.Fatalf("consume() small chunk error = %v", errConsume)
+	}
+	// 5000 chars ≈ 1250 tokens at $10/M = $0.0125, crossing the $0.11 ceiling.
+	large := make([]byte, 5000)
+	for i := range large {
+		large[i] = 'a'
+	}
+	if errConsume := budget.consume([]byte(`{"delta":{"text":"` + string(large) + `"}}`)); errConsume == nil {
+		t.Fatalf("consume() large chunk error = nil, want abort")
+	}
+
+	if budget, errMsg = h.prepareCostBudget(clientRequestTestContext("", map[string]string{MaxCostHeader: "0.01"}), []string{"claude"}, "unpriced", meta, nil); budget != nil || errMsg != nil {
+		t.Fatalf("unpriced model budget = %v, %v; want no limit", budget, errMsg)
+	}
+}
+
+func TestStreamChunkTextLengthIgnoresEnvelope(t *testing.T) {
+	chunk := []byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"abcd\"}}\n\n")
+	if got := streamChunkTextLength(chunk); got != 4 {
+		t.Fatalf("streamChunkTextLength() = %d, want 4", got)
+	}
+}
@@ -0,0 +1,271 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ErrorDialect names the wire format used to render error bodies for a client.
+type ErrorDialect string
+
+const (
+	// ErrorDialectOpenAI renders {"error":{"message","type","code"}}.
+	ErrorDialectOpenAI ErrorDialect = "openai"
+	// ErrorDialectClaude renders {"type":"error","error":{"type","message"}}.
+	ErrorDialectClaude ErrorDialect = "claude"
+	// ErrorDialectGemini renders {"error":{"code","message","status","details"}}.
+	ErrorDialectGemini ErrorDialect = "gemini"
+)
+
+// ErrorDialectForPath returns the error dialect native to the ingress endpoint at path.
+func ErrorDialectForPath(path string) ErrorDialect {
+	switch {
+	case path == "/v1/messages" || strings.HasPrefix(path, "/v1/messages/"):
+		return ErrorDialectClaude
+	case path == "/v1beta" || strings.HasPrefix(path, "/v1beta/"):
+		return ErrorDialectGemini
+	default:
+		return ErrorDialectOpenAI
+	}
+}
+
+// ErrorDialectForRequest returns the error dialect for the request carried by c.
+func ErrorDialectForRequest(c *gin.Context) ErrorDialect {
+	if c == nil || c.Request == nil || c.Request.URL == nil {
+		return ErrorDialectOpenAI
+	}
+	return ErrorDialectForPath(c.Request.URL.Path)
+}
+
+// ErrorStatus returns the HTTP status for msg, defaulting to 500.
+func ErrorStatus(msg *interfaces.ErrorMessage) int {
+	if msg != nil && msg.StatusCode > 0 {
+		return msg.StatusCode
+	}
+	return http.StatusInternalServerError
+}
+
+// ErrorRetryAfter returns the retry hint carried by msg, or 0 when there is none.
+func ErrorRetryAfter(msg *interfaces.ErrorMessage) time.Duration {
+	if msg == nil || msg.Error == nil {
+		return 0
+	}
+	for _, value := range coreauth.SafeResponseHeaders(msg.Error).Values("Retry-After") {
+		if seconds, errParse := strconv.ParseInt(strings.TrimSpace(value), 10, 64); errParse == nil && seconds > 0 {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	var hinted interface{ RetryAfter() *time.Duration }
+	if errors.As(msg.Error, &hinted) && hinted != nil {
+		if retryAfter := hinted.RetryAfter(); retryAfter != nil && *retryAfter > 0 {
+			return *retryAfter
+		}
+	}
+	return 0
+}
+
+// retryAfterSeconds rounds retryAfter up to whole seconds.
+func retryAfterSeconds(retryAfter time.Duration) int64 {
+	seconds := int64(retryAfter / time.Second)
+	if retryAfter%time.Second != 0 {
+		seconds++
+	}
+	return seconds
+}
+
+// BuildDialectErrorBody renders msg as an error body in dialect. Manager errors
+// (*coreauth.Error) contribute their machine readable code; upstream JSON payloads
+// are kept for OpenAI and re-shaped for the other dialects. When every upstream model
+// of an alias failed, the per-model detail is listed in error.attempts.
+func BuildDialectErrorBody(dialect ErrorDialect, msg *interfaces.ErrorMessage) []byte {
+	status := ErrorStatus(msg)
+	errText := http.StatusText(status)
+	var code string
+	if msg != nil && msg.Error != nil {
+		var authErr *coreauth.Error
+		if errors.As(msg.Error, &authErr) && authErr != nil && strings.TrimSpace(authErr.Message) != "" {
+			errText = strings.TrimSpace(authErr.Message)
+			code = strings.TrimSpace(authErr.Code)
+		} else if v := strings.TrimSpace(msg.Error.Error()); v != "" {
+			errText = v
+		}
+	}
+
+	var body []byte
+	switch dialect {
+	case ErrorDialectClaude:
+		errType, message := ClaudeErrorDetail(status, errText)
+		encoded, errMarshal := json.Marshal(map[string]any{
+			"type":  "error",
+			"error": map[string]string{"type": errType, "message": message},
+		})
+		if errMarshal != nil {
+			encoded = []byte(`{"type":"error","error":{"type":"api_error","message":"Internal Server Error"}}`)
+		}
+		body = encoded
+	case ErrorDialectGemini:
+		body = buildGeminiErrorBody(status, errText, ErrorRetryAfter(msg))
+	default:
+		body = buildOpenAIErrorBody(status, errText, code)
+	}
+	if msg != nil {
+		body = withModelAttempts(body, coreauth.ModelAttemptsFromError(msg.Error))
+	}
+	return body
+}
+
+// withModelAttempts adds the per-model failures of an alias as error.attempts so clients
+// can tell which upstream models may succeed on retry.
+func withModelAttempts(body []byte, attempts []coreauth.ModelAttempt) []byte {
+	if len(attempts) == 0 || !gjson.GetBytes(body, "error").IsObject() {
+		return body
+	}
+	updated, errSet := sjson.SetBytes(body, "error.attempts", attempts)
+	if errSet != nil {
+		return body
+	}
+	return updated
+}
+
+// ClaudeErrorDetail returns the Anthropic error type and message for errText. Upstream
+// JSON in OpenAI or Anthropic shape contributes its type and message.
+func ClaudeErrorDetail(status int, errText string) (string, string) {
+	message := strings.TrimSpace(errText)
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	errType := claudeErrorTypeFromStatus(status)
+
+	var payload map[string]any
+	if json.Valid([]byte(message)) {
+		if err := json.Unmarshal([]byte(message), &payload); err == nil {
+			if e, ok := payload["error"].(map[string]any); ok {
+				if t, ok := e["type"].(string); ok && strings.TrimSpace(t) != "" {
+					errType = strings.TrimSpace(t)
+				}
+				if m, ok := e["message"].(string); ok && strings.TrimSpace(m) != "" {
+					message = strings.TrimSpace(m)
+				} else if c, ok := e["code"].(string); ok && strings.TrimSpace(c) != "" {
+					message = strings.TrimSpace(c)
+				}
+			} else {
+				if t, ok := payload["type"].(string); ok && strings.TrimSpace(t) != "" && strings.TrimSpace(t) != "error" {
+					errType = strings.TrimSpace(t)
+				}
+				if m, ok := payload["message"].(string); ok && strings.TrimSpace(m) != "" {
+					message = strings.TrimSpace(m)
+				}
+			}
+		}
+	}
+
+	return errType, message
+}
+
+func claudeErrorTypeFromStatus(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusPaymentRequired:
+		return "billing_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case http.StatusGatewayTimeout:
+		return "timeout_error"
+	case 529:
+		return "overloaded_error"
+	default:
+		if status >= http.StatusInternalServerError {
+			return "api_error"
+		}
+		return "invalid_request_error"
+	}
+}
+
+// buildGeminiErrorBody renders a google.rpc.Status style error. Upstream payloads that
+// already carry error.status are returned unchanged.
+func buildGeminiErrorBody(status int, errText string, retryAfter time.Duration) []byte {
+	message := strings.TrimSpace(errText)
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	if json.Valid([]byte(message)) {
+		var payload map[string]any
+		if err := json.Unmarshal([]byte(message), &payload); err == nil {
+			if e, ok := payload["error"].(map[string]any); ok {
+				if s, ok := e["status"].(string); ok && strings.TrimSpace(s) != "" {
+					return []byte(message)
+				}
+				if m, ok := e["message"].(string); ok && strings.TrimSpace(m) != "" {
+					message = strings.TrimSpace(m)
+				}
+			} else if m, ok := payload["message"].(string); ok && strings.TrimSpace(m) != "" {
+				message = strings.TrimSpace(m)
+			}
+		}
+	}
+
+	errorBody := map[string]any{
+		"code":    status,
+		"message": message,
+		"status":  geminiStatusFromHTTP(status),
+	}
+	if retryAfter > 0 {
+		errorBody["details"] = []map[string]any{{
+			"@type":      "type.googleapis.com/google.rpc.RetryInfo",
+			"retryDelay": fmt.Sprintf("%ds", retryAfterSeconds(retryAfter)),
+		}}
+	}
+	body, errMarshal := json.Marshal(map[string]any{"error": errorBody})
+	if errMarshal != nil {
+		return []byte(`{"error":{"code":500,"message":"Internal Server Error","status":"INTERNAL"}}`)
+	}
+	return body
+}
+
+func geminiStatusFromHTTP(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "INVALID_ARGUMENT"
+	case http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusConflict:
+		return "ABORTED"
+	case http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case statusClientClosedRequest:
+		return "CANCELLED"
+	case http.StatusNotImplemented:
+		return "UNIMPLEMENTED"
+	case http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	case http.StatusGatewayTimeout, http.StatusRequestTimeout:
+		return "DEADLINE_EXCEEDED"
+	default:
+		if status >= http.StatusInternalServerError {
+			return "INTERNAL"
+		}
+		return "FAILED_PRECONDITION"
+	}
+}
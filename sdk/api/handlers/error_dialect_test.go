@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+)
+
+type retryHintError struct{ wait time.Duration }
+
+func (e retryHintError) Error() string              { return "cooling down" }
+func (e retryHintError) RetryAfter() *time.Duration { return &e.wait }
+
+func TestErrorDialectForPath(t *testing.T) {
+	cases := map[string]ErrorDialect{
+		"/v1/chat/completions":                      ErrorDialectOpenAI,
+		"/v1/responses":                             ErrorDialectOpenAI,
+		"/v1/messages":                              ErrorDialectClaude,
+		"/v1/messages/count_tokens":                 ErrorDialectClaude,
+		"/v1beta/models/gemini-pro:generateContent": ErrorDialectGemini,
+		"/openai/v1/chat/completions":               ErrorDialectOpenAI,
+		"/backend-api/codex/responses":              ErrorDialectOpenAI,
+	}
+	for path, want := range cases {
+		if got := ErrorDialectForPath(path); got != want {
+			t.Errorf("ErrorDialectForPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestBuildDialectErrorBodyUsesManagerErrorCode(t *testing.T) {
+	msg := &interfaces.ErrorMessage{
+		StatusCode: http.StatusServiceUnavailable,
+		Error:      &coreauth.Error{Code: "auth_unavailable", Message: "no auth available", HTTPStatus: http.StatusServiceUnavailable},
+	}
+
+	openai := BuildDialectErrorBody(ErrorDialectOpenAI, msg)
+	if got := gjson.GetBytes(openai, "error.message").String(); got != "no auth available" {
+		t.Fatalf("openai message = %q; body=%s", got, openai)
+	}
+	if got := gjson.GetBytes(openai, "error.code").String(); got != "auth_unavailable" {
+		t.Fatalf("openai code = %q; body=%s", got, openai)
+	}
+
+	claude := BuildDialectErrorBody(ErrorDialectClaude, msg)
+	if gjson.GetBytes(claude, "type").String() != "error" || gjson.GetBytes(claude, "error.type").String() != "api_error" {
+		t.Fatalf("claude body = %s", claude)
+	}
+
+	gemini := BuildDialectErrorBody(ErrorDialectGemini, msg)
+	if gjson.GetBytes(gemini, "error.code").Int() != http.StatusServiceUnavailable || gjson.GetBytes(gemini, "error.status").String() != "UNAVAILABLE" {
+		t.Fatalf("gemini body = %s", gemini)
+	}
+}
+
+func TestBuildDialectErrorBodyGeminiKeepsNativeUpstreamPayload(t *testing.T) {
+	upstream := `{"error":{"code":429,"message":"quota","status":"RESOURCE_EXHAUSTED"}}`
+	msg := &interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests, Error: errors.New(upstream)}
+	if got := string(BuildDialectErrorBody(ErrorDialectGemini, msg)); got != upstream {
+		t.Fatalf("gemini body = %s, want upstream payload", got)
+	}
+
+	msg.Error = errors.New(`{"error":{"message":"slow down","type":"rate_limit_error"}}`)
+	body := BuildDialectErrorBody(ErrorDialectGemini, msg)
+	if gjson.GetBytes(body, "error.message").String() != "slow down" || gjson.GetBytes(body, "error.status").String() != "RESOURCE_EXHAUSTED" {
+		t.Fatalf("gemini body = %s", body)
+	}
+}
+
+func TestWriteErrorResponseRendersIngressDialectWithRetryHint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1beta/models/gemini-pro:generateContent", nil)
+
+	handler := &BaseAPIHandler{}
+	handler.WriteErrorResponse(c, &interfaces.ErrorMessage{
+		StatusCode: http.StatusTooManyRequests,
+		Error:      retryHintError{wait: 1500 * time.Millisecond},
+	})
+
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", recorder.Code, http.StatusTooManyRequests)
+	}
+	if got := recorder.Header().Get("Retry-After"); got != "2" {
+		t.Fatalf("Retry-After = %q, want 2", got)
+	}
+	body := recorder.Body.Bytes()
+	if got := gjson.GetBytes(body, "error.details.0.retryDelay").String(); got != "2s" {
+		t.Fatalf("retryDelay = %q; body=%s", got, body)
+	}
+	if got := gjson.GetBytes(body, "error.message").String(); got != "cooling down" {
+		t.Fatalf("message = %q; body=%s", got, body)
+	}
+}
+
+func TestBuildDialectErrorBodyListsModelAttempts(t *testing.T) {
+	err := fmt.Errorf("wrapped: %w", &coreauth.ModelAttemptsError{
+		Attempts: []coreauth.ModelAttempt{
+			{Model: "a", StatusCode: http.StatusTooManyRequests, RetryAfterSeconds: 30, Message: "slow down"},
+			{Model: "b", StatusCode: http.StatusBadGateway, Message: "bad gateway"},
+		},
+	})
+	msg := &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: err}
+
+	for _, dialect := range []ErrorDialect{ErrorDialectOpenAI, ErrorDialectClaude, ErrorDialectGemini} {
+		body := BuildDialectErrorBody(dialect, msg)
+		if got := gjson.GetBytes(body, "error.attempts.#").Int(); got != 2 {
+			t.Fatalf("%s: attempts = %d; body=%s", dialect, got, body)
+		}
+		if got := gjson.GetBytes(body, "error.attempts.0.retry_after_seconds").Int(); got != 30 {
+			t.Fatalf("%s: retry_after_seconds = %d; body=%s", dialect, got, body)
+		}
+		if got := gjson.GetBytes(body, "error.attempts.1.status_code").Int(); got != http.StatusBadGateway {
+			t.Fatalf("%s: status_code = %d; body=%s", dialect, got, body)
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -20,6 +21,7 @@ import (
 	. "github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
@@ -36,6 +38,10 @@ import (
 
 const statusClientClosedRequest = 499
 
+// ResolvedModelHeader reports the model that served a request when it differs from the
+// requested one (auto resolution, near-miss matching or route fallback).
+const ResolvedModelHeader = "X-CLIProxy-Resolved-Model"
+
 // ErrorResponse represents a standard error response format for the API.
 // It contains a single ErrorDetail field.
 type ErrorResponse struct {
@@ -190,6 +196,12 @@ func WithDisallowFreeAuth(ctx context.Context) context.Context {
 // BuildErrorResponseBody builds an OpenAI-compatible JSON error response body.
 // If errText is already valid JSON, it is returned as-is to preserve upstream error payloads.
 func BuildErrorResponseBody(status int, errText string) []byte {
+	return buildOpenAIErrorBody(status, errText, "")
+}
+
+// buildOpenAIErrorBody is BuildErrorResponseBody with an optional code that replaces the
+// one derived from status.
+func buildOpenAIErrorBody(status int, errText, codeOverride string) []byte {
 	if status <= 0 {
 		status = http.StatusInternalServerError
 	}
@@ -233,6 +245,10 @@ func BuildErrorResponseBody(status int, errText string) []byte {
 		}
 	}
 
+	if codeOverride != "" {
+		code = codeOverride
+	}
+
 	payload, err := json.Marshal(ErrorResponse{
 		Error: ErrorDetail{
 			Message: errText,
@@ -259,6 +275,17 @@ func StreamingKeepAliveInterval(cfg *config.SDKConfig) time.Duration {
 	return time.Duration(seconds) * time.Second
 }
 
+// StreamingKeepAliveComment returns the SSE heartbeat written between streamed chunks.
+func StreamingKeepAliveComment(cfg *config.SDKConfig) []byte {
+	comment := "keep-alive"
+	if cfg != nil {
+		if custom := strings.TrimSpace(cfg.Streaming.KeepAliveComment); custom != "" {
+			comment = strings.NewReplacer("\r", " ", "\n", " ").Replace(custom)
+		}
+	}
+	return []byte(": " + comment + "\n\n")
+}
+
 // NonStreamingKeepAliveInterval returns the keep-alive interval for non-streaming responses.
 // Returning 0 disables keep-alives (default when unset).
 func NonStreamingKeepAliveInterval(cfg *config.SDKConfig) time.Duration {
@@ -284,6 +311,14 @@ func StreamingBootstrapRetries(cfg *config.SDKConfig) int {
 	return retries
 }
 
+// StreamingResumeAttempts returns how many times a stream failing mid-response may be resumed.
+func StreamingResumeAttempts(cfg *config.SDKConfig) int {
+	if cfg == nil || cfg.Streaming.ResumeAttempts < 0 {
+		return 0
+	}
+	return cfg.Streaming.ResumeAttempts
+}
+
 // PassthroughHeadersEnabled returns whether upstream response headers should be forwarded to clients.
 // Default is false.
 func PassthroughHeadersEnabled(cfg *config.SDKConfig) bool {
@@ -331,6 +366,9 @@ func requestExecutionMetadata(ctx context.Context) map[string]any {
 	if disallowFreeAuthFromContext(ctx) {
 		meta[coreexecutor.DisallowFreeAuthMetadataKey] = true
 	}
+	if noFallbackFromContext(ctx) {
+		meta[coreexecutor.NoFallbackMetadataKey] = true
+	}
 	return meta
 }
 
@@ -476,6 +514,37 @@ type BaseAPIHandler struct {
 	// ModelRouterHost optionally routes matching requests to a plugin executor, the router's own
 	// executor, or a built-in provider before model-to-provider resolution and auth selection.
 	ModelRouterHost PluginModelRouterHost
+
+	// idempotency stores non-streaming outcomes replayed for Idempotency-Key retries.
+	idempotency   *idempotencyStore
+	idempotencyMu sync.Mutex
+
+	// transforms are embedder-registered middlewares chained after plugin interceptors.
+	transforms []TransformMiddleware
+
+	// responseCache serves repeated deterministic non-streaming requests.
+	responseCache   *responseCache
+	responseCacheMu sync.Mutex
+
+	// coalescer shares one upstream call among identical in-flight non-streaming requests.
+	coalescer   *requestCoalescer
+	coalescerMu sync.Mutex
+
+	// batches runs /v1/batches jobs in the background.
+	batches   *batchStore
+	batchesMu sync.Mutex
+
+	// sessions keeps conversation state for the /v1/sessions endpoints.
+	sessions   *sessionStore
+	sessionsMu sync.Mutex
+
+	// modelMappings is the compiled form of the current model-mappings list.
+	modelMappings   *compiledModelMappings
+	modelMappingsMu sync.Mutex
+
+	// historySummaries caches conversation summaries written by the history compression model.
+	historySummaries   *responseCache
+	historySummariesMu sync.Mutex
 }
 
 // NewBaseAPIHandlers creates a new API handlers instance.
@@ -492,6 +561,7 @@ func NewBaseAPIHandlers(cfg *config.SDKConfig, authManager *coreauth.Manager) *B
 		Cfg:         cfg,
 		AuthManager: authManager,
 	}
+	h.batchesForConfig(cfg)
 	return h
 }
 
@@ -501,7 +571,10 @@ func NewBaseAPIHandlers(cfg *config.SDKConfig, authManager *coreauth.Manager) *B
 // Parameters:
 //   - clients: The new slice of AI service clients
 //   - cfg: The new application configuration
-func (h *BaseAPIHandler) UpdateClients(cfg *config.SDKConfig) { h.Cfg = cfg }
+func (h *BaseAPIHandler) UpdateClients(cfg *config.SDKConfig) {
+	h.Cfg = cfg
+	h.batchesForConfig(cfg)
+}
 
 // SetPluginHost configures the optional plugin interceptor host.
 func (h *BaseAPIHandler) SetPluginHost(host PluginInterceptorHost) {
@@ -829,12 +902,31 @@ func (h *BaseAPIHandler) ExecuteImageWithAuthManager(ctx context.Context, handle
 	return h.executeWithAuthManager(ctx, handlerType, modelName, rawJSON, alt, true)
 }
 
+// ExecuteEmbeddingsWithAuthManager executes an OpenAI-compatible embeddings request. Executors
+// without embeddings support fail with 501 and are not penalized.
+func (h *BaseAPIHandler) ExecuteEmbeddingsWithAuthManager(ctx context.Context, modelName string, rawJSON []byte) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	return h.executeWithAuthManager(ctx, coreauth.EmbeddingsSourceFormat, modelName, rawJSON, "", false)
+}
+
 func (h *BaseAPIHandler) executeWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string, allowImageModel bool) ([]byte, http.Header, *interfaces.ErrorMessage) {
-	return h.executeWithAuthManagerFormats(ctx, handlerType, handlerType, modelName, rawJSON, alt, allowImageModel, modelExecutionOptions{})
+	return h.executeIdempotent(ctx, handlerType, handlerType, modelName, rawJSON, func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		execute := func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+			return h.executeWithAuthManagerFormats(ctx, handlerType, handlerType, modelName, rawJSON, alt, allowImageModel, modelExecutionOptions{})
+		}
+		if allowImageModel {
+			return execute()
+		}
+		return h.executeCached(ctx, handlerType, modelName, rawJSON, func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+			return h.executeCoalesced(ctx, handlerType, modelName, rawJSON, execute)
+		})
+	})
 }
 
 func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entryProtocol, exitProtocol, modelName string, rawJSON []byte, alt string, allowImageModel bool, execOptions modelExecutionOptions) ([]byte, http.Header, *interfaces.ErrorMessage) {
 	originalRequestedModel := modelName
+	modelName = h.resolveAPIKeyModelAlias(ctx, modelName)
+	modelName = h.applyModelMappings(modelName)
+	ctx, execOptions = h.applyRoutingHeaders(ctx, execOptions)
 	routeDecision := h.applyModelRouter(ctx, entryProtocol, modelName, rawJSON, false, execOptions)
 	responseProtocol := modelExecutionResponseProtocol(entryProtocol, exitProtocol)
 	if errMsg := validateNativeInteractionsExecution(entryProtocol, execOptions, routeDecision); errMsg != nil {
@@ -849,15 +941,30 @@ func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entr
 		return nil, nil, errMsg
 	}
 	providers = adjustExecutionProvidersForEntryProtocol(entryProtocol, providers)
+	providers, rawJSON, errMsg = h.applyAPIKeyPolicy(ctx, entryProtocol, originalRequestedModel, normalizedModel, providers, rawJSON)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
 	attachRouteFallbackToGinContext(ctx, modelName, normalizedModel)
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = originalRequestedModel
+	h.setRequestPriorityMetadata(ctx, reqMeta)
+	h.setAuthGroupsMetadata(ctx, reqMeta)
+	h.setRequestDeadlineMetadata(ctx, reqMeta)
 	addAuthSelectionModelMetadata(reqMeta, execOptions.AuthSelectionModel)
 	addModelExecutionSourceMetadata(reqMeta, execOptions.InternalSource)
 	maybeAttachEstimatedInputTokens(reqMeta, sdktranslator.FromString(entryProtocol), normalizedModel, rawJSON)
 	setReasoningEffortMetadata(reqMeta, entryProtocol, normalizedModel, rawJSON)
 	setServiceTierMetadata(reqMeta, rawJSON)
 	setGenerateMetadata(reqMeta, rawJSON)
+	rawJSON = h.compressHistory(ctx, entryProtocol, normalizedModel, reqMeta, rawJSON, execOptions)
+	rawJSON = h.truncateContextWindow(ctx, entryProtocol, providers, normalizedModel, reqMeta, rawJSON)
+	if rawJSON, errMsg = h.preflightContextWindow(entryProtocol, providers, normalizedModel, reqMeta, rawJSON); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if _, errMsg := h.prepareCostBudget(ctx, providers, normalizedModel, reqMeta, rawJSON); errMsg != nil {
+		return nil, nil, errMsg
+	}
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -880,7 +987,7 @@ func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entr
 	opts.Metadata = reqMeta
 	req, opts = h.applyRequestInterceptorsBeforeAuth(ctx, entryProtocol, originalRequestedModel, req, opts, execOptions.SkipInterceptorPluginID)
 	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
-	if err != nil && isNotFoundError(err) {
+	if err != nil && isNotFoundError(err) && !execOptions.NoFallback {
 		fbProviders, fbModel := h.resolveFallbackProvidersForRuntime404(normalizedModel)
 		if len(fbProviders) > 0 {
 			log.WithFields(log.Fields{
@@ -901,6 +1008,7 @@ func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entr
 	rawResponseHeaders := cloneHeader(resp.Headers)
 	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, PassthroughHeadersEnabled(h.Cfg))
 	body, responseHeaders := h.applyResponseInterceptors(ctx, responseProtocol, normalizedModel, originalRequestedModel, executedOpts, rawResponseHeaders, responseHeaders, executedOpts.OriginalRequest, executedReq.Payload, resp.Payload, http.StatusOK, execOptions.SkipInterceptorPluginID)
+	body = h.newReasoningFilter(ctx, responseProtocol, originalRequestedModel, normalizedModel).applyBody(body)
 	h.recordSuccessfulAPIResponse(ctx, body)
 	return body, responseHeaders, nil
 }
@@ -913,6 +1021,9 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 
 func (h *BaseAPIHandler) executeCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string, execOptions modelExecutionOptions) ([]byte, http.Header, *interfaces.ErrorMessage) {
 	originalRequestedModel := modelName
+	modelName = h.resolveAPIKeyModelAlias(ctx, modelName)
+	modelName = h.applyModelMappings(modelName)
+	ctx, execOptions = h.applyRoutingHeaders(ctx, execOptions)
 	routeDecision := h.applyModelRouter(ctx, handlerType, modelName, rawJSON, false, execOptions)
 	if routeDecision.ExecutorPluginID != "" {
 		return h.countWithPluginExecutor(ctx, handlerType, modelName, originalRequestedModel, rawJSON, alt, routeDecision.ExecutorPluginID, execOptions)
@@ -923,9 +1034,16 @@ func (h *BaseAPIHandler) executeCountWithAuthManager(ctx context.Context, handle
 		return nil, nil, errMsg
 	}
 	providers = adjustExecutionProvidersForEntryProtocol(handlerType, providers)
+	providers, rawJSON, errMsg = h.applyAPIKeyPolicy(ctx, handlerType, originalRequestedModel, normalizedModel, providers, rawJSON)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
 	attachRouteFallbackToGinContext(ctx, modelName, normalizedModel)
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = originalRequestedModel
+	h.setRequestPriorityMetadata(ctx, reqMeta)
+	h.setAuthGroupsMetadata(ctx, reqMeta)
+	h.setRequestDeadlineMetadata(ctx, reqMeta)
 	addAuthSelectionModelMetadata(reqMeta, execOptions.AuthSelectionModel)
 	maybeAttachEstimatedInputTokens(reqMeta, sdktranslator.FromString(handlerType), normalizedModel, rawJSON)
 	setReasoningEffortMetadata(reqMeta, handlerType, normalizedModel, rawJSON)
@@ -952,7 +1070,7 @@ func (h *BaseAPIHandler) executeCountWithAuthManager(ctx context.Context, handle
 	opts.Metadata = reqMeta
 	req, opts = h.applyRequestInterceptorsBeforeAuth(ctx, handlerType, originalRequestedModel, req, opts, execOptions.SkipInterceptorPluginID)
 	resp, err := h.AuthManager.ExecuteCount(ctx, providers, req, opts)
-	if err != nil && isNotFoundError(err) {
+	if err != nil && isNotFoundError(err) && !execOptions.NoFallback {
 		fbProviders, fbModel := h.resolveFallbackProvidersForRuntime404(normalizedModel)
 		if len(fbProviders) > 0 {
 			log.WithFields(log.Fields{
@@ -1238,6 +1356,9 @@ func (h *BaseAPIHandler) executeStreamWithAuthManager(ctx context.Context, handl
 
 func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context, entryProtocol, exitProtocol, modelName string, rawJSON []byte, alt string, allowImageModel bool, execOptions modelExecutionOptions) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
 	originalRequestedModel := modelName
+	modelName = h.resolveAPIKeyModelAlias(ctx, modelName)
+	modelName = h.applyModelMappings(modelName)
+	ctx, execOptions = h.applyRoutingHeaders(ctx, execOptions)
 	routeDecision, preparedRoute := preparedModelRouteFromContext(ctx)
 	if !preparedRoute {
 		routeDecision = h.applyModelRouter(ctx, entryProtocol, modelName, rawJSON, true, execOptions)
@@ -1261,15 +1382,40 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 		return nil, nil, errChan
 	}
 	providers = adjustExecutionProvidersForEntryProtocol(entryProtocol, providers)
+	providers, rawJSON, errMsg = h.applyAPIKeyPolicy(ctx, entryProtocol, originalRequestedModel, normalizedModel, providers, rawJSON)
+	if errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
 	attachRouteFallbackToGinContext(ctx, modelName, normalizedModel)
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = originalRequestedModel
+	h.setRequestPriorityMetadata(ctx, reqMeta)
+	h.setAuthGroupsMetadata(ctx, reqMeta)
+	h.setRequestDeadlineMetadata(ctx, reqMeta)
 	addAuthSelectionModelMetadata(reqMeta, execOptions.AuthSelectionModel)
 	maybeAttachEstimatedInputTokens(reqMeta, sdktranslator.FromString(entryProtocol), normalizedModel, rawJSON)
 	addModelExecutionSourceMetadata(reqMeta, execOptions.InternalSource)
 	setReasoningEffortMetadata(reqMeta, entryProtocol, normalizedModel, rawJSON)
 	setServiceTierMetadata(reqMeta, rawJSON)
 	setGenerateMetadata(reqMeta, rawJSON)
+	rawJSON = h.compressHistory(ctx, entryProtocol, normalizedModel, reqMeta, rawJSON, execOptions)
+	rawJSON = h.truncateContextWindow(ctx, entryProtocol, providers, normalizedModel, reqMeta, rawJSON)
+	if rawJSON, errMsg = h.preflightContextWindow(entryProtocol, providers, normalizedModel, reqMeta, rawJSON); errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
+	costBudget, errMsg := h.prepareCostBudget(ctx, providers, normalizedModel, reqMeta, rawJSON)
+	if errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -1292,7 +1438,7 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 	opts.Metadata = reqMeta
 	req, opts = h.applyRequestInterceptorsBeforeAuth(ctx, entryProtocol, originalRequestedModel, req, opts, execOptions.SkipInterceptorPluginID)
 	streamResult, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
-	if err != nil && isNotFoundError(err) {
+	if err != nil && isNotFoundError(err) && !execOptions.NoFallback {
 		fbProviders, fbModel := h.resolveFallbackProvidersForRuntime404(normalizedModel)
 		if len(fbProviders) > 0 {
 			log.WithFields(log.Fields{
@@ -1324,6 +1470,7 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 	passthroughHeadersEnabled := PassthroughHeadersEnabled(h.Cfg)
 	interceptorHost := h.interceptorHost()
 	streamInterceptorsActive := streamInterceptorsEnabled(interceptorHost)
+	reasoning := h.newReasoningFilter(ctx, responseProtocol, originalRequestedModel, normalizedModel)
 	// Resolve bootstrap retries and header initialization before returning so the
 	// returned header snapshot is never modified by the stream goroutine.
 	rawStreamHeaders := cloneHeader(streamResult.Headers)
@@ -1396,7 +1543,7 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 				return nil, false, &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: errValidate}
 			}
 		}
-		return payload, true, nil
+		return reasoning.applyChunk(payload), true, nil
 	}
 
 	var bootstrapPayload []byte
@@ -1488,6 +1635,7 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 		bootstrapPayload = nil
 		bootstrapChunkIndex = 0
 		bootstrapHistoryChunks = nil
+		reasoning = h.newReasoningFilter(ctx, responseProtocol, originalRequestedModel, normalizedModel)
 		chunks = retryResult.Chunks
 		if chunks == nil {
 			closed := make(chan coreexecutor.StreamChunk)
@@ -1543,10 +1691,19 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 
 		chunkIndex := bootstrapChunkIndex
 		historyChunks := bootstrapHistoryChunks
+		var resumer *streamResumer
+		if !h.AuthManager.HomeEnabled() {
+			resumer = newStreamResumer(StreamingResumeAttempts(h.Cfg), entryProtocol, responseProtocol, rawJSON)
+		}
 		if bootstrapPayload != nil {
+			if errMsg := costBudget.consume(bootstrapPayload); errMsg != nil {
+				_ = sendErr(errMsg)
+				return
+			}
 			if okSendData := sendData(bootstrapPayload); !okSendData {
 				return
 			}
+			resumer.observe(bootstrapPayload)
 			if streamInterceptorsActive {
 				historyChunks = appendStreamInterceptorHistory(historyChunks, bootstrapPayload)
 			}
@@ -1557,13 +1714,22 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 				return
 			}
 			if chunk.Err != nil {
+				if resumed, okResume := h.resumeStream(ctx, resumer, chunk.Err, providers, req, opts); okResume {
+					chunks = resumed
+					streamClosedBeforeRead = false
+					continue
+				}
 				_ = sendErr(executionErrorMessage(chunk.Err))
 				return
 			}
 			if len(chunk.Payload) == 0 {
 				continue
 			}
-			payload, deliverable, errMsg := transformStreamPayload(chunk.Payload, &chunkIndex, historyChunks)
+			raw := resumer.stitch(chunk.Payload)
+			if len(raw) == 0 {
+				continue
+			}
+			payload, deliverable, errMsg := transformStreamPayload(raw, &chunkIndex, historyChunks)
 			if errMsg != nil {
 				_ = sendErr(errMsg)
 				return
@@ -1571,9 +1737,14 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 			if !deliverable {
 				continue
 			}
+			if errMsg := costBudget.consume(payload); errMsg != nil {
+				_ = sendErr(errMsg)
+				return
+			}
 			if okSendData := sendData(payload); !okSendData {
 				return
 			}
+			resumer.observe(payload)
 			if streamInterceptorsActive {
 				historyChunks = appendStreamInterceptorHistory(historyChunks, payload)
 			}
@@ -1741,10 +1912,16 @@ func (h *BaseAPIHandler) providersForExecution(modelName, originalRequestedModel
 		}
 		return []string{routeDecision.Provider}, normalizedModel, nil
 	}
-	return h.getRequestDetailsWithOptions(modelName, allowImageModel)
+	return h.getRequestDetailsWithFallback(modelName, allowImageModel, !execOptions.NoFallback)
 }
 
 func (h *BaseAPIHandler) getRequestDetailsWithOptions(modelName string, allowImageModel bool) (providers []string, normalizedModel string, err *interfaces.ErrorMessage) {
+	return h.getRequestDetailsWithFallback(modelName, allowImageModel, true)
+}
+
+// getRequestDetailsWithFallback resolves providers for modelName. allowFallback controls
+// whether an unresolvable model may be replaced by a configured fallback model.
+func (h *BaseAPIHandler) getRequestDetailsWithFallback(modelName string, allowImageModel, allowFallback bool) (providers []string, normalizedModel string, err *interfaces.ErrorMessage) {
 	resolvedModelName := modelName
 	initialSuffix := thinking.ParseSuffix(modelName)
 	if initialSuffix.ModelName == "auto" {
@@ -1854,6 +2031,24 @@ func (h *BaseAPIHandler) getRequestDetailsWithOptions(modelName string, allowIma
 		}
 	}
 
+	if len(providers) == 0 {
+		if nearModel := h.resolveNearMatchModel(baseModel); nearModel != "" {
+			if nearProviders := util.GetProviderName(nearModel); len(nearProviders) > 0 {
+				log.WithFields(log.Fields{
+					"requested_model": modelName,
+					"base_model":      baseModel,
+					"resolved_model":  nearModel,
+					"providers":       strings.Join(nearProviders, ","),
+				}).Infof("resolved near-miss model name: requested=%s selected=%s", modelName, nearModel)
+				providers = nearProviders
+				resolvedModelName = nearModel
+				if parsed.HasSuffix {
+					resolvedModelName = fmt.Sprintf("%s(%s)", nearModel, parsed.RawSuffix)
+				}
+			}
+		}
+	}
+
 	// Log when all resolution paths fail before attempting fallback
 	if len(providers) == 0 {
 		log.WithFields(log.Fields{
@@ -1871,7 +2066,7 @@ func (h *BaseAPIHandler) getRequestDetailsWithOptions(modelName string, allowIma
 		}).Warn("all route model resolution paths exhausted, attempting fallback")
 	}
 
-	if len(providers) == 0 && h != nil && h.AuthManager != nil {
+	if len(providers) == 0 && allowFallback && h != nil && h.AuthManager != nil {
 		if fbProviders, fbModel := h.AuthManager.ResolveProvidersForFallback(baseModel); len(fbProviders) > 0 {
 			log.WithFields(log.Fields{
 				"requested_model":         modelName,
@@ -1901,6 +2096,15 @@ func (h *BaseAPIHandler) getRequestDetailsWithOptions(modelName string, allowIma
 	return providers, resolvedModelName, nil
 }
 
+// resolveNearMatchModel maps an unknown model name onto a registered model when
+// model-resolution is enabled.
+func (h *BaseAPIHandler) resolveNearMatchModel(baseModel string) string {
+	if h == nil || h.Cfg == nil || !h.Cfg.ModelResolution.Enabled {
+		return ""
+	}
+	return registry.GetGlobalRegistry().ResolveNearMatch(baseModel, h.Cfg.ModelResolution.LatestPins)
+}
+
 func attachRouteFallbackToGinContext(ctx context.Context, requestedModel, normalizedModel string) {
 	if ctx == nil {
 		return
@@ -1918,6 +2122,7 @@ func attachRouteFallbackToGinContext(ctx context.Context, requestedModel, normal
 		"requested_model": rm,
 		"actual_model":    nm,
 	})
+	c.Header(ResolvedModelHeader, nm)
 }
 
 func attachUnknownProviderUpstreamHint(ctx context.Context, originalModel string, resolvedModel string) {
@@ -2063,12 +2268,23 @@ func cloneBytes(src []byte) []byte {
 }
 
 func maybeAttachEstimatedInputTokens(meta map[string]any, format sdktranslator.Format, model string, rawJSON []byte) {
-	if meta == nil || len(rawJSON) == 0 {
+	if meta == nil {
 		return
 	}
+	if count := estimateInputTokens(format, model, rawJSON); count > 0 {
+		meta[coreexecutor.EstimatedInputTokensMetadataKey] = count
+	}
+}
+
+// estimateInputTokens estimates the prompt tokens of rawJSON locally; it returns 0 when no
+// estimate is available.
+func estimateInputTokens(format sdktranslator.Format, model string, rawJSON []byte) int {
+	if len(rawJSON) == 0 {
+		return 0
+	}
 	codec, err := tokenizerForModel(model)
 	if err != nil || codec == nil {
-		return
+		return 0
 	}
 	var count int
 	switch format {
@@ -2078,9 +2294,9 @@ func maybeAttachEstimatedInputTokens(meta map[string]any, format sdktranslator.F
 		count, err = estimateOpenAIInputTokens(codec, rawJSON)
 	}
 	if err != nil || count <= 0 {
-		return
+		return 0
 	}
-	meta[coreexecutor.EstimatedInputTokensMetadataKey] = count
+	return count
 }
 
 func tokenizerForModel(model string) (tokenizer.Codec, error) {
@@ -2416,6 +2632,9 @@ func (h *BaseAPIHandler) interceptorHost() PluginInterceptorHost {
 	if h == nil {
 		return nil
 	}
+	if len(h.transforms) > 0 {
+		return &transformInterceptorHost{base: h.PluginHost, middlewares: h.transforms}
+	}
 	return h.PluginHost
 }
 
@@ -2426,7 +2645,7 @@ func (h *BaseAPIHandler) modelRouterHost() PluginModelRouterHost {
 	if !isNilPluginModelRouterHost(h.ModelRouterHost) {
 		return h.ModelRouterHost
 	}
-	host := h.interceptorHost()
+	host := h.PluginHost
 	if host == nil {
 		return nil
 	}
@@ -2778,17 +2997,26 @@ func enrichAuthSelectionError(err error, providers []string, model string) error
 }
 
 // WriteErrorResponse writes an error message to the response writer using the HTTP status embedded in the message.
+// The body uses the error dialect native to the ingress endpoint (see ErrorDialectForPath).
 func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.ErrorMessage) {
-	status := http.StatusInternalServerError
-	if msg != nil && msg.StatusCode > 0 {
-		status = msg.StatusCode
+	h.WriteErrorResponseInDialect(c, msg, ErrorDialectForRequest(c))
+}
+
+// WriteErrorResponseInDialect writes msg as an error body in dialect, preserving the
+// HTTP status and emitting Retry-After when the error carries a retry hint.
+func (h *BaseAPIHandler) WriteErrorResponseInDialect(c *gin.Context, msg *interfaces.ErrorMessage, dialect ErrorDialect) {
+	status := ErrorStatus(msg)
+	if requestID := logging.GetGinRequestID(c); requestID != "" {
+		c.Writer.Header().Set(logging.RequestIDHeader, requestID)
 	}
-	if msg != nil && msg.Error != nil {
-		for _, value := range coreauth.SafeResponseHeaders(msg.Error).Values("Retry-After") {
-			c.Writer.Header().Add("Retry-After", value)
-		}
+	if retryAfter := ErrorRetryAfter(msg); retryAfter > 0 {
+		c.Writer.Header().Set("Retry-After", strconv.FormatInt(retryAfterSeconds(retryAfter), 10))
+	}
+	var cfg *config.SDKConfig
+	if h != nil {
+		cfg = h.Cfg
 	}
-	if msg != nil && msg.Addon != nil && PassthroughHeadersEnabled(h.Cfg) {
+	if msg != nil && msg.Addon != nil && PassthroughHeadersEnabled(cfg) {
 		for key, values := range msg.Addon {
 			if len(values) == 0 || IsCPAReservedResponseHeader(key) {
 				continue
@@ -2807,7 +3035,7 @@ func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.Erro
 		}
 	}
 
-	body := BuildErrorResponseBody(status, errText)
+	body := BuildDialectErrorBody(dialect, msg)
 	// Append first to preserve upstream response logs, then drop duplicate payloads if already recorded.
 	var previous []byte
 	if existing, exists := c.Get("API_RESPONSE"); exists {
@@ -472,3 +472,52 @@ func TestExecuteImageWithAuthManager_AllowsImageOnlyModels(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRequestDetails_ResolvesNearMissModelNames(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("test-near-match-claude", "claude", []*registry.ModelInfo{
+		{ID: "nearmatch-sonnet-4-0", Created: 100},
+		{ID: "nearmatch-sonnet-4-5", Created: 200},
+		{ID: "nearmatch-sonnet-mini", Created: 300},
+	})
+	modelRegistry.RegisterClient("test-near-match-gemini", "gemini", []*registry.ModelInfo{{ID: "nearmatch-2.5-pro"}})
+	t.Cleanup(func() {
+		modelRegistry.UnregisterClient("test-near-match-claude")
+		modelRegistry.UnregisterClient("test-near-match-gemini")
+	})
+
+	cfg := &sdkconfig.SDKConfig{ModelResolution: sdkconfig.ModelResolutionConfig{Enabled: true}}
+	handler := NewBaseAPIHandlers(cfg, coreauth.NewManager(nil, nil, nil))
+
+	tests := []struct {
+		name          string
+		inputModel    string
+		wantProviders []string
+		wantModel     string
+	}{
+		{name: "punctuation", inputModel: "NearMatch-2.5pro", wantProviders: []string{"gemini"}, wantModel: "nearmatch-2.5-pro"},
+		{name: "suffix kept", inputModel: "nearmatch-2.5pro(high)", wantProviders: []string{"gemini"}, wantModel: "nearmatch-2.5-pro(high)"},
+		{name: "latest picks newest version", inputModel: "nearmatch-sonnet-latest", wantProviders: []string{"claude"}, wantModel: "nearmatch-sonnet-4-5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers, model, errMsg := handler.getRequestDetails(tt.inputModel)
+			if errMsg != nil {
+				t.Fatalf("getRequestDetails() error = %v", errMsg.Error)
+			}
+			if !reflect.DeepEqual(providers, tt.wantProviders) || model != tt.wantModel {
+				t.Fatalf("getRequestDetails() = %v, %q, want %v, %q", providers, model, tt.wantProviders, tt.wantModel)
+			}
+		})
+	}
+
+	cfg.ModelResolution.LatestPins = map[string]string{"NearMatch-Sonnet-Latest": "nearmatch-sonnet-4-0"}
+	if _, model, errMsg := handler.getRequestDetails("nearmatch-sonnet-latest"); errMsg != nil || model != "nearmatch-sonnet-4-0" {
+		t.Fatalf("pinned latest = %q, %v, want nearmatch-sonnet-4-0", model, errMsg)
+	}
+
+	cfg.ModelResolution.Enabled = false
+	if _, _, errMsg := handler.getRequestDetails("nearmatch-2.5pro"); errMsg == nil {
+		t.Fatal("near-miss name resolved with model-resolution disabled")
+	}
+}
@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// HistorySummarizedHeader reports how many conversation items were replaced by a summary.
+	HistorySummarizedHeader = "X-CLIProxy-History-Summarized"
+
+	defaultHistoryCompressionThreshold = 32000
+	defaultHistoryCompressionKeepTurns = 2
+	defaultHistoryCompressionCacheTTL  = time.Hour
+	historySummaryCacheMaxEntries      = 1024
+
+	historySummarizerPrompt = "Summarize the conversation below for an assistant that will continue it without seeing it. " +
+		"Keep the user's goals, decisions, facts, file names, identifiers, tool results that are still relevant and open questions. " +
+		"Be concise and write only the summary."
+	historySummaryPrefix = "Summary of the earlier conversation:\n"
+)
+
+// compressHistory replaces the older turns of a long conversation with a summary written
+// by the configured summarizer model, keeping system messages and the latest turns.
+// Summaries are cached per client and conversation prefix, so a growing conversation only
+// summarizes the turns added since the previous summary. On any summarizer failure the
+// request is forwarded unchanged.
+func (h *BaseAPIHandler) compressHistory(ctx context.Context, entryProtocol, model string, meta map[string]any, rawJSON []byte, execOptions modelExecutionOptions) []byte {
+	if h == nil || h.Cfg == nil || execOptions.InternalSource {
+		return rawJSON
+	}
+	settings := h.Cfg.HistoryCompression
+	summarizer := strings.TrimSpace(settings.Model)
+	if !settings.Enabled || summarizer == "" {
+		return rawJSON
+	}
+	layout, ok := conversationLayouts[entryProtocol]
+	if !ok {
+		return rawJSON
+	}
+	promptTokens, _ := meta[coreexecutor.EstimatedInputTokensMetadataKey].(int)
+	threshold := settings.ThresholdTokens
+	if threshold <= 0 {
+		threshold = defaultHistoryCompressionThreshold
+	}
+	if promptTokens <= threshold {
+		return rawJSON
+	}
+	principal, allowed := historyCompressionPrincipal(ctx, settings)
+	if !allowed {
+		return rawJSON
+	}
+
+	items := gjson.GetBytes(rawJSON, layout.path).Array()
+	pinned := 0
+	for pinned < len(items) && layout.pinned != nil && layout.pinned(items[pinned]) {
+		pinned++
+	}
+	var starts []int
+	for i := pinned; i < len(items); i++ {
+		if layout.turnStart(items[i]) {
+			starts = append(starts, i)
+		}
+	}
+	keepTurns := settings.KeepTurns
+	if keepTurns <= 0 {
+		keepTurns = defaultHistoryCompressionKeepTurns
+	}
+	if len(starts) <= keepTurns {
+		return rawJSON
+	}
+	cut := starts[len(starts)-keepTurns]
+
+	// keys[i] identifies the conversation prefix items[pinned:i] of this client.
+	keys := make(map[int]string, cut-pinned+1)
+	chain := sha256.Sum256([]byte(principal + "\x00" + summarizer + "\x00" + entryProtocol))
+	keys[pinned] = hex.EncodeToString(chain[:])
+	for i := pinned; i < cut; i++ {
+		chain = sha256.Sum256(append(chain[:], items[i].Raw...))
+		keys[i+1] = hex.EncodeToString(chain[:])
+	}
+
+	cache := h.historySummaryCache()
+	now := time.Now()
+	summary := ""
+	if entry, hit := cache.get(keys[cut], now); hit {
+		summary = string(entry.Body)
+	} else {
+		from, previous := pinned, ""
+		for i := len(starts) - keepTurns - 1; i >= 0; i-- {
+			if entry, hit := cache.get(keys[starts[i]], now); hit && starts[i] > pinned {
+				from, previous = starts[i], string(entry.Body)
+				break
+			}
+		}
+		generated, errSummarize := h.summarizeHistory(ctx, summarizer, entryProtocol, previous, items[from:cut])
+		if errSummarize != nil {
+			log.Warnf("history compression: summarizer %s failed, forwarding full history: %v", summarizer, errSummarize)
+			return rawJSON
+		}
+		summary = generated
+		ttl := defaultHistoryCompressionCacheTTL
+		if parsed, errParse := time.ParseDuration(strings.TrimSpace(settings.CacheTTL)); errParse == nil && parsed > 0 {
+			ttl = parsed
+		}
+		cache.put(&responseCacheEntry{Key: keys[cut], Body: []byte(summary), ExpiresAt: now.Add(ttl)}, historySummaryCacheMaxEntries)
+	}
+
+	body, errReplace := replaceHistoryWithSummary(entryProtocol, layout, rawJSON, items, pinned, cut, historySummaryPrefix+summary)
+	if errReplace != nil {
+		log.Warnf("history compression: failed to rewrite request: %v", errReplace)
+		return rawJSON
+	}
+	if count := estimateInputTokens(sdktranslator.FromString(entryProtocol), model, body); count > 0 {
+		meta[coreexecutor.EstimatedInputTokensMetadataKey] = count
+	}
+	summarized := cut - pinned
+	log.Debugf("history compression: replaced %d conversation items with a summary (estimated %d tokens before)", summarized, promptTokens)
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+			ginCtx.Header(HistorySummarizedHeader, strconv.Itoa(summarized))
+		}
+	}
+	return body
+}
+
+// historyCompressionPrincipal returns the client API key of the request and whether
+// compression applies to it.
+func historyCompressionPrincipal(ctx context.Context, settings config.HistoryCompressionConfig) (string, bool) {
+	principal := ""
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+			if value, exists := ginCtx.Get("userApiKey"); exists {
+				principal = fmt.Sprint(value)
+			}
+		}
+	}
+	if len(settings.APIKeys) == 0 {
+		return principal, true
+	}
+	return principal, principal != "" && slices.Contains(settings.APIKeys, principal)
+}
+
+func (h *BaseAPIHandler) historySummaryCache() *responseCache {
+	h.historySummariesMu.Lock()
+	defer h.historySummariesMu.Unlock()
+	if h.historySummaries == nil {
+		h.historySummaries = newResponseCache("")
+	}
+	return h.historySummaries
+}
+
+// summarizeHistory asks the summarizer model, through the OpenAI chat format, to fold items
+// into previous (the summary of the turns before them, if any).
+func (h *BaseAPIHandler) summarizeHistory(ctx context.Context, summarizer, entryProtocol, previous string, items []gjson.Result) (string, error) {
+	var transcript strings.Builder
+	if previous != "" {
+		transcript.WriteString("Summary so far:\n")
+		transcript.WriteString(previous)
+		transcript.WriteString("\n\nNew turns:\n")
+	}
+	for _, item := range items {
+		role := item.Get("role").String()
+		if role == "" {
+			role = item.Get("type").String()
+		}
+		transcript.WriteString(role)
+		transcript.WriteString(": ")
+		transcript.WriteString(conversationItemText(entryProtocol, item))
+		transcript.WriteString("\n")
+	}
+	request := []byte(`{"messages":[{"role":"system"},{"role":"user"}],"stream":false}`)
+	request, _ = sjson.SetBytes(request, "model", summarizer)
+	request, _ = sjson.SetBytes(request, "messages.0.content", historySummarizerPrompt)
+	request, _ = sjson.SetBytes(request, "messages.1.content", transcript.String())
+	resp, errMsg := h.ExecuteModel(ctx, ModelExecutionRequest{EntryProtocol: "openai", ExitProtocol: "openai", Model: summarizer, Body: request})
+	if errMsg != nil {
+		if errMsg.Error != nil {
+			return "", errMsg.Error
+		}
+		return "", fmt.Errorf("summarizer returned status %d", errMsg.StatusCode)
+	}
+	summary := strings.TrimSpace(gjson.GetBytes(resp.Body, "choices.0.message.content").String())
+	if summary == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	return summary, nil
+}
+
+// conversationItemText flattens one conversation item into plain text for the summarizer.
+func conversationItemText(entryProtocol string, item gjson.Result) string {
+	segments := make([]string, 0, 4)
+	if entryProtocol == "claude" {
+		collectClaudeContentSegments(item.Get("content"), &segments)
+		return strings.Join(segments, "\n")
+	}
+	collectOpenAIContentSegments(item.Get("content"), &segments)
+	for _, call := range item.Get("tool_calls").Array() {
+		addSegment(&segments, call.Get("function.name").String())
+		addSegment(&segments, call.Get("function.arguments").String())
+	}
+	addSegment(&segments, item.Get("name").String())
+	addSegment(&segments, item.Get("arguments").String())
+	addSegment(&segments, item.Get("output").String())
+	return strings.Join(segments, "\n")
+}
+
+// replaceHistoryWithSummary drops items[pinned:cut] and carries the summary in a system
+// message, or in the system prompt for Claude where messages must start with a user turn.
+func replaceHistoryWithSummary(entryProtocol string, layout conversationLayout, rawJSON []byte, items []gjson.Result, pinned, cut int, summary string) ([]byte, error) {
+	kept := make([]string, 0, pinned+1+len(items)-cut)
+	for _, item := range items[:pinned] {
+		kept = append(kept, item.Raw)
+	}
+	if entryProtocol != "claude" {
+		message, _ := sjson.Set(`{"role":"system"}`, "content", summary)
+		kept = append(kept, message)
+	}
+	for _, item := range items[cut:] {
+		kept = append(kept, item.Raw)
+	}
+	body, errSet := sjson.SetRawBytes(rawJSON, layout.path, []byte("["+strings.Join(kept, ",")+"]"))
+	if errSet != nil || entryProtocol != "claude" {
+		return body, errSet
+	}
+	system := gjson.GetBytes(body, "system")
+	switch {
+	case system.IsArray():
+		block, _ := sjson.Set(`{"type":"text"}`, "text", summary)
+		return sjson.SetRawBytes(body, "system.-1", []byte(block))
+	case system.Type == gjson.String && strings.TrimSpace(system.String()) != "":
+		return sjson.SetBytes(body, "system", system.String()+"\n\n"+summary)
+	default:
+		return sjson.SetBytes(body, "system", summary)
+	}
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func historyCompressionTestBody(turns int) []byte {
+	messages := []string{`{"role":"system","content":"be brief"}`}
+	for i := 0; i < turns; i++ {
+		messages = append(messages,
+			fmt.Sprintf(`{"role":"user","content":"question %d"}`, i),
+			fmt.Sprintf(`{"role":"assistant","content":"answer %d"}`, i))
+	}
+	return []byte(`{"model":"chat-model","messages":[` + strings.Join(messages, ",") + `]}`)
+}
+
+func TestCompressHistorySummarizesOlderTurnsAndRollsForward(t *testing.T) {
+	var calls atomic.Int32
+	var lastPrompt atomic.Value
+	executor := &modelExecutionCaptureExecutor{
+		provider: "openai",
+		execute: func(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+			n := calls.Add(1)
+			lastPrompt.Store(gjson.GetBytes(req.Payload, "messages.1.content").String())
+			return coreexecutor.Response{Payload: []byte(fmt.Sprintf(`{"choices":[{"message":{"role":"assistant","content":"summary %d"}}]}`, n))}, nil
+		},
+	}
+	cfg := &sdkconfig.SDKConfig{HistoryCompression: sdkconfig.HistoryCompressionConfig{
+		Enabled:         true,
+		Model:           "summarizer-model",
+		ThresholdTokens: 10,
+		KeepTurns:       2,
+	}}
+	handler := newModelExecutionHandler(t, "summarizer-model", executor, cfg)
+	ctx := clientRequestTestContext("tenant-key", nil)
+	compress := func(body []byte) []byte {
+		meta := map[string]any{}
+		maybeAttachEstimatedInputTokens(meta, sdktranslator.FromString("openai"), "chat-model", body)
+		return handler.compressHistory(ctx, "openai", "chat-model", meta, body, modelExecutionOptions{})
+	}
+
+	got := compress(historyCompressionTestBody(4))
+	messages := gjson.GetBytes(got, "messages").Array()
+	if len(messages) != 6 {
+		t.Fatalf("kept %d messages, want system, summary and the last two turns: %s", len(messages), got)
+	}
+	if messages[1].Get("role").String() != "system" || !strings.HasSuffix(messages[1].Get("content").String(), "summary 1") {
+		t.Fatalf("summary message = %s", messages[1].Raw)
+	}
+	if messages[2].Get("content").String() != "question 2" {
+		t.Fatalf("first kept turn = %s, want question 2", messages[2].Raw)
+	}
+
+	if compress(historyCompressionTestBody(4)); calls.Load() != 1 {
+		t.Fatalf("summarizer calls = %d, want cached summary reused", calls.Load())
+	}
+
+	got = compress(historyCompressionTestBody(5))
+	if calls.Load() != 2 {
+		t.Fatalf("summarizer calls = %d, want 2", calls.Load())
+	}
+	prompt, _ := lastPrompt.Load().(string)
+	if !strings.Contains(prompt, "summary 1") || strings.Contains(prompt, "question 0") || !strings.Contains(prompt, "question 2") {
+		t.Fatalf("rolled summary prompt should extend the cached summary with the new turn only: %q", prompt)
+	}
+	if first := gjson.GetBytes(got, "messages.2.content").String(); first != "question 3" {
+		t.Fatalf("first kept turn = %q, want question 3", first)
+	}
+
+	cfg.HistoryCompression.APIKeys = []string{"other-key"}
+	if body := historyCompressionTestBody(6); string(compress(body)) != string(body) {
+		t.Fatal("history compressed for a client outside api-keys")
+	}
+}
+
+func TestReplaceHistoryWithSummaryClaudeSystem(t *testing.T) {
+	body := []byte(`{"system":[{"type":"text","text":"be brief"}],"messages":[{"role":"user","content":"a"},{"role":"assistant","content":"b"},{"role":"user","content":"c"}]}`)
+	items := gjson.GetBytes(body, "messages").Array()
+	got, err := replaceHistoryWithSummary("claude", conversationLayouts["claude"], body, items, 0, 2, "summary")
+	if err != nil {
+		t.Fatalf("replaceHistoryWithSummary() error = %v", err)
+	}
+	if messages := gjson.GetBytes(got, "messages").Array(); len(messages) != 1 || messages[0].Get("content").String() != "c" {
+		t.Fatalf("messages = %s, want only the latest turn", gjson.GetBytes(got, "messages").Raw)
+	}
+	if text := gjson.GetBytes(got, "system.1.text").String(); text != "summary" {
+		t.Fatalf("system = %s, want summary block appended", gjson.GetBytes(got, "system").Raw)
+	}
+}
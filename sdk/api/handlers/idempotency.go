@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/context"
+)
+
+const (
+	// IdempotencyKeyHeader is the client-supplied header that enables response replay.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks responses served from the idempotency store.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	defaultIdempotencyTTL        = 24 * time.Hour
+	defaultIdempotencyMaxEntries = 1024
+
+	// idempotencyPersistDelay batches snapshot writes: completions within this delay of the
+	// first unsaved one are written together.
+	idempotencyPersistDelay = time.Second
+)
+
+// idempotencyEntry is a stored non-streaming outcome for one Idempotency-Key.
+type idempotencyEntry struct {
+	Fingerprint  string      `json:"fingerprint"`
+	StatusCode   int         `json:"status_code,omitempty"`
+	Body         []byte      `json:"body,omitempty"`
+	Headers      http.Header `json:"headers,omitempty"`
+	ErrorMessage string      `json:"error_message,omitempty"`
+	ErrorHeaders http.Header `json:"error_headers,omitempty"`
+	CreatedAt    time.Time   `json:"created_at"`
+	ExpiresAt    time.Time   `json:"expires_at"`
+}
+
+// idempotencyStore keeps completed outcomes keyed by client scope and Idempotency-Key.
+// Entries are optionally mirrored to a JSON snapshot so they survive restarts; snapshot
+// writes are debounced so a busy store does not rewrite the file on every request.
+type idempotencyStore struct {
+	mu           sync.Mutex
+	entries      map[string]idempotencyEntry
+	inFlight     map[string]string
+	path         string
+	persistTimer *time.Timer
+
+	persistMu sync.Mutex
+}
+
+func newIdempotencyStore(path string) *idempotencyStore {
+	store := &idempotencyStore{
+		entries:  make(map[string]idempotencyEntry),
+		inFlight: make(map[string]string),
+		path:     strings.TrimSpace(path),
+	}
+	store.load()
+	return store
+}
+
+func (s *idempotencyStore) load() {
+	if s.path == "" {
+		return
+	}
+	data, errRead := os.ReadFile(s.path)
+	if errRead != nil {
+		if !errors.Is(errRead, os.ErrNotExist) {
+			log.Warnf("idempotency: failed to read snapshot %s: %v", s.path, errRead)
+		}
+		return
+	}
+	var entries map[string]idempotencyEntry
+	if errUnmarshal := json.Unmarshal(data, &entries); errUnmarshal != nil {
+		log.Warnf("idempotency: failed to parse snapshot %s: %v", s.path, errUnmarshal)
+		return
+	}
+	now := time.Now()
+	for key, entry := range entries {
+		if now.Before(entry.ExpiresAt) {
+			s.entries[key] = entry
+		}
+	}
+}
+
+func (s *idempotencyStore) persist() {
+	if s.path == "" {
+		return
+	}
+	s.mu.Lock()
+	snapshot := make(map[string]idempotencyEntry, len(s.entries))
+	for key, entry := range s.entries {
+		snapshot[key] = entry
+	}
+	s.mu.Unlock()
+
+	data, errMarshal := json.Marshal(snapshot)
+	if errMarshal != nil {
+		log.Warnf("idempotency: failed to encode snapshot: %v", errMarshal)
+		return
+	}
+	s.persistMu.Lock()
+	defer s.persistMu.Unlock()
+	if errMkdir := os.MkdirAll(filepath.Dir(s.path), 0o700); errMkdir != nil {
+		log.Warnf("idempotency: failed to create snapshot directory: %v", errMkdir)
+		return
+	}
+	tmpPath := s.path + ".tmp"
+	if errWrite := os.WriteFile(tmpPath, data, 0o600); errWrite != nil {
+		log.Warnf("idempotency: failed to write snapshot: %v", errWrite)
+		return
+	}
+	if errRename := os.Rename(tmpPath, s.path); errRename != nil {
+		log.Warnf("idempotency: failed to replace snapshot: %v", errRename)
+	}
+}
+
+// schedulePersistLocked arranges for a snapshot write after idempotencyPersistDelay unless
+// one is already pending. The caller must hold s.mu.
+func (s *idempotencyStore) schedulePersistLocked() {
+	if s.path == "" || s.persistTimer != nil {
+		return
+	}
+	s.persistTimer = time.AfterFunc(idempotencyPersistDelay, func() {
+		s.mu.Lock()
+		s.persistTimer = nil
+		s.mu.Unlock()
+		s.persist()
+	})
+}
+
+// flush writes a pending snapshot immediately.
+func (s *idempotencyStore) flush() {
+	s.mu.Lock()
+	pending := s.persistTimer != nil && s.persistTimer.Stop()
+	s.persistTimer = nil
+	s.mu.Unlock()
+	if pending {
+		s.persist()
+	}
+}
+
+// claim returns the stored entry for key, or reserves key for the caller.
+// It returns an error message when the key is in flight or was used with a different payload.
+func (s *idempotencyStore) claim(key, fingerprint string, now time.Time) (*idempotencyEntry, *interfaces.ErrorMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry, ok := s.entries[key]; ok {
+		if now.Before(entry.ExpiresAt) {
+			if entry.Fingerprint != fingerprint {
+				return nil, idempotencyMismatchError()
+			}
+			return &entry, nil
+		}
+		delete(s.entries, key)
+	}
+	if current, ok := s.inFlight[key]; ok {
+		if current != fingerprint {
+			return nil, idempotencyMismatchError()
+		}
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusConflict,
+			Error:      fmt.Errorf("a request with this %s is already in progress", IdempotencyKeyHeader),
+		}
+	}
+	s.inFlight[key] = fingerprint
+	return nil, nil
+}
+
+// release drops the in-flight reservation without storing an outcome.
+func (s *idempotencyStore) release(key string) {
+	s.mu.Lock()
+	delete(s.inFlight, key)
+	s.mu.Unlock()
+}
+
+// complete stores the outcome for key and releases the in-flight reservation.
+func (s *idempotencyStore) complete(key string, entry idempotencyEntry, maxEntries int) {
+	s.mu.Lock()
+	delete(s.inFlight, key)
+	s.entries[key] = entry
+	s.evictLocked(entry.CreatedAt, maxEntries)
+	s.schedulePersistLocked()
+	s.mu.Unlock()
+}
+
+func (s *idempotencyStore) evictLocked(now time.Time, maxEntries int) {
+	for key, entry := range s.entries {
+		if !now.Before(entry.ExpiresAt) {
+			delete(s.entries, key)
+		}
+	}
+	for len(s.entries) > maxEntries {
+		oldestKey := ""
+		var oldest time.Time
+		for key, entry := range s.entries {
+			if oldestKey == "" || entry.CreatedAt.Before(oldest) {
+				oldestKey = key
+				oldest = entry.CreatedAt
+			}
+		}
+		delete(s.entries, oldestKey)
+	}
+}
+
+func idempotencyMismatchError() *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusUnprocessableEntity,
+		Error:      fmt.Errorf("%s was already used with a different request payload", IdempotencyKeyHeader),
+	}
+}
+
+func idempotencyTTL(cfg *config.SDKConfig) time.Duration {
+	if cfg == nil {
+		return defaultIdempotencyTTL
+	}
+	raw := strings.TrimSpace(cfg.Idempotency.TTL)
+	if raw == "" {
+		return defaultIdempotencyTTL
+	}
+	ttl, errParse := time.ParseDuration(raw)
+	if errParse != nil || ttl <= 0 {
+		return defaultIdempotencyTTL
+	}
+	return ttl
+}
+
+func idempotencyMaxEntries(cfg *config.SDKConfig) int {
+	if cfg == nil || cfg.Idempotency.MaxEntries <= 0 {
+		return defaultIdempotencyMaxEntries
+	}
+	return cfg.Idempotency.MaxEntries
+}
+
+// idempotencyStoreForConfig returns the active store, recreating it when the snapshot path changes.
+func (h *BaseAPIHandler) idempotencyStoreForConfig(cfg *config.SDKConfig) *idempotencyStore {
+	if cfg == nil || !cfg.Idempotency.Enabled {
+		return nil
+	}
+	path := strings.TrimSpace(cfg.Idempotency.PersistPath)
+	h.idempotencyMu.Lock()
+	defer h.idempotencyMu.Unlock()
+	if h.idempotency == nil || h.idempotency.path != path {
+		if h.idempotency != nil {
+			h.idempotency.flush()
+		}
+		h.idempotency = newIdempotencyStore(path)
+	}
+	return h.idempotency
+}
+
+// idempotencyScope identifies the store key for the request in ctx.
+// The client principal is folded into the key so different API keys never share replays.
+func idempotencyScope(ctx context.Context) string {
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil || ginCtx.Request.Method != http.MethodPost {
+		return ""
+	}
+	key := strings.TrimSpace(ginCtx.GetHeader(IdempotencyKeyHeader))
+	if key == "" {
+		return ""
+	}
+	principal := ""
+	if value, exists := ginCtx.Get("userApiKey"); exists {
+		principal = fmt.Sprint(value)
+	}
+	sum := sha256.Sum256([]byte(principal + "\x00" + key))
+	return hex.EncodeToString(sum[:])
+}
+
+func idempotencyFingerprint(entryProtocol, exitProtocol, modelName string, rawJSON []byte) string {
+	hasher := sha256.New()
+	hasher.Write([]byte(entryProtocol))
+	hasher.Write([]byte{0})
+	hasher.Write([]byte(exitProtocol))
+	hasher.Write([]byte{0})
+	hasher.Write([]byte(modelName))
+	hasher.Write([]byte{0})
+	hasher.Write(rawJSON)
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+func (e *idempotencyEntry) replay() ([]byte, http.Header, *interfaces.ErrorMessage) {
+	if e.ErrorMessage != "" {
+		headers := cloneHeader(e.ErrorHeaders)
+		if headers == nil {
+			headers = make(http.Header)
+		}
+		headers.Set(IdempotentReplayedHeader, "true")
+		return nil, nil, &interfaces.ErrorMessage{StatusCode: e.StatusCode, Error: errors.New(e.ErrorMessage), Addon: headers}
+	}
+	headers := cloneHeader(e.Headers)
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	headers.Set(IdempotentReplayedHeader, "true")
+	return cloneBytes(e.Body), headers, nil
+}
+
+// executeIdempotent wraps a non-streaming execution with Idempotency-Key replay.
+// Requests without the header, or with replay disabled, call execute directly.
+func (h *BaseAPIHandler) executeIdempotent(ctx context.Context, entryProtocol, exitProtocol, modelName string, rawJSON []byte, execute func() ([]byte, http.Header, *interfaces.ErrorMessage)) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	store := h.idempotencyStoreForConfig(h.Cfg)
+	if store == nil || ctx == nil {
+		return execute()
+	}
+	scope := idempotencyScope(ctx)
+	if scope == "" {
+		return execute()
+	}
+	fingerprint := idempotencyFingerprint(entryProtocol, exitProtocol, modelName, rawJSON)
+	stored, errMsg := store.claim(scope, fingerprint, time.Now())
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if stored != nil {
+		return stored.replay()
+	}
+	// Release the reservation unless an outcome is stored, so a panicking handler does not
+	// leave the key answering 409 until restart.
+	completed := false
+	defer func() {
+		if !completed {
+			store.release(scope)
+		}
+	}()
+
+	body, headers, errExec := execute()
+	if errExec != nil && (errExec.StatusCode == statusClientClosedRequest || errors.Is(errExec.Error, context.Canceled)) {
+		// The client went away; let a retry execute again instead of replaying a cancellation.
+		return body, headers, errExec
+	}
+
+	now := time.Now()
+	entry := idempotencyEntry{
+		Fingerprint: fingerprint,
+		StatusCode:  http.StatusOK,
+		Body:        cloneBytes(body),
+		Headers:     cloneHeader(headers),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(idempotencyTTL(h.Cfg)),
+	}
+	if errExec != nil {
+		entry.StatusCode = errExec.StatusCode
+		if entry.StatusCode <= 0 {
+			entry.StatusCode = http.StatusInternalServerError
+		}
+		entry.Body = nil
+		entry.Headers = nil
+		entry.ErrorMessage = http.StatusText(entry.StatusCode)
+		if errExec.Error != nil && errExec.Error.Error() != "" {
+			entry.ErrorMessage = errExec.Error.Error()
+		}
+		entry.ErrorHeaders = cloneHeader(errExec.Addon)
+	}
+	store.complete(scope, entry, idempotencyMaxEntries(h.Cfg))
+	completed = true
+	return body, headers, errExec
+}
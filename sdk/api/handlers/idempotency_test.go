@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"golang.org/x/net/context"
+)
+
+func TestExecuteIdempotentReplaysStoredResponse(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &config.SDKConfig{Idempotency: config.IdempotencyConfig{Enabled: true}}}
+	calls := 0
+	execute := func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		calls++
+		return []byte(`{"id":"one"}`), http.Header{"X-Test": {"1"}}, nil
+	}
+	body := []byte(`{"model":"m"}`)
+
+	first, _, errFirst := h.executeIdempotent(clientRequestTestContext("client", map[string]string{IdempotencyKeyHeader: "key-1"}), "openai", "openai", "m", body, execute)
+	if errFirst != nil {
+		t.Fatalf("first call error: %v", errFirst.Error)
+	}
+	second, headers, errSecond := h.executeIdempotent(clientRequestTestContext("client", map[string]string{IdempotencyKeyHeader: "key-1"}), "openai", "openai", "m", body, execute)
+	if errSecond != nil {
+		t.Fatalf("second call error: %v", errSecond.Error)
+	}
+	if calls != 1 {
+		t.Fatalf("execute calls = %d, want 1", calls)
+	}
+	if string(first) != string(second) {
+		t.Fatalf("replayed body = %s, want %s", second, first)
+	}
+	if headers.Get(IdempotentReplayedHeader) != "true" || headers.Get("X-Test") != "1" {
+		t.Fatalf("unexpected replay headers: %v", headers)
+	}
+
+	// A different client principal must not observe the stored response.
+	if _, _, errOther := h.executeIdempotent(clientRequestTestContext("other", map[string]string{IdempotencyKeyHeader: "key-1"}), "openai", "openai", "m", body, execute); errOther != nil {
+		t.Fatalf("other principal error: %v", errOther.Error)
+	}
+	if calls != 2 {
+		t.Fatalf("execute calls = %d, want 2", calls)
+	}
+}
+
+func TestExecuteIdempotentRejectsPayloadMismatch(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &config.SDKConfig{Idempotency: config.IdempotencyConfig{Enabled: true}}}
+	execute := func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		return []byte(`{}`), nil, nil
+	}
+	ctx := clientRequestTestContext("client", map[string]string{IdempotencyKeyHeader: "key-1"})
+	if _, _, errMsg := h.executeIdempotent(ctx, "openai", "openai", "m", []byte(`{"a":1}`), execute); errMsg != nil {
+		t.Fatalf("first call error: %v", errMsg.Error)
+	}
+	_, _, errMsg := h.executeIdempotent(ctx, "openai", "openai", "m", []byte(`{"a":2}`), execute)
+	if errMsg == nil || errMsg.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422 mismatch, got %+v", errMsg)
+	}
+}
+
+func TestExecuteIdempotentReplaysErrorsButNotCancellations(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &config.SDKConfig{Idempotency: config.IdempotencyConfig{Enabled: true}}}
+	ctx := clientRequestTestContext("client", map[string]string{IdempotencyKeyHeader: "key-1"})
+	body := []byte(`{}`)
+
+	calls := 0
+	cancelled := func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		calls++
+		return nil, nil, &interfaces.ErrorMessage{StatusCode: statusClientClosedRequest, Error: context.Canceled}
+	}
+	h.executeIdempotent(ctx, "openai", "openai", "m", body, cancelled)
+	failed := func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		calls++
+		return nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests, Error: errors.New("quota")}
+	}
+	h.executeIdempotent(ctx, "openai", "openai", "m", body, failed)
+	_, _, errMsg := h.executeIdempotent(ctx, "openai", "openai", "m", body, failed)
+	if calls != 2 {
+		t.Fatalf("execute calls = %d, want 2", calls)
+	}
+	if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests || errMsg.Error.Error() != "quota" {
+		t.Fatalf("unexpected replayed error: %+v", errMsg)
+	}
+}
+
+func TestIdempotencyStorePersistsAcrossRestarts(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "idempotency.json")
+	cfg := &config.SDKConfig{Idempotency: config.IdempotencyConfig{Enabled: true, PersistPath: path}}
+	execute := func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		return []byte(`{"id":"persisted"}`), nil, nil
+	}
+	first := &BaseAPIHandler{Cfg: cfg}
+	first.executeIdempotent(clientRequestTestContext("client", map[string]string{IdempotencyKeyHeader: "key-1"}), "openai", "openai", "m", []byte(`{}`), execute)
+	first.idempotency.flush()
+
+	restarted := &BaseAPIHandler{Cfg: cfg}
+	replayed, _, errMsg := restarted.executeIdempotent(clientRequestTestContext("client", map[string]string{IdempotencyKeyHeader: "key-1"}), "openai", "openai", "m", []byte(`{}`), func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		t.Fatal("execute should not run for a persisted key")
+		return nil, nil, nil
+	})
+	if errMsg != nil || string(replayed) != `{"id":"persisted"}` {
+		t.Fatalf("replayed = %s, err = %+v", replayed, errMsg)
+	}
+}
+
+func TestExecuteIdempotentReleasesKeyAfterPanic(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &config.SDKConfig{Idempotency: config.IdempotencyConfig{Enabled: true}}}
+	ctx := clientRequestTestContext("client", map[string]string{IdempotencyKeyHeader: "key-1"})
+	func() {
+		defer func() { _ = recover() }()
+		h.executeIdempotent(ctx, "openai", "openai", "m", []byte(`{}`), func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+			panic("handler boom")
+		})
+	}()
+
+	body, _, errMsg := h.executeIdempotent(ctx, "openai", "openai", "m", []byte(`{}`), func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		return []byte(`{"id":"retried"}`), nil, nil
+	})
+	if errMsg != nil || string(body) != `{"id":"retried"}` {
+		t.Fatalf("retry after panic = %s, %+v; want a fresh execution", body, errMsg)
+	}
+}
+
+func TestIdempotencyStoreDebouncesSnapshotWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "idempotency.json")
+	store := newIdempotencyStore(path)
+	now := time.Now()
+	for _, key := range []string{"a", "b", "c"} {
+		store.complete(key, idempotencyEntry{Fingerprint: key, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}, 10)
+	}
+	if _, errStat := os.Stat(path); !os.IsNotExist(errStat) {
+		t.Fatalf("snapshot written synchronously: %v", errStat)
+	}
+	store.flush()
+	if restored := newIdempotencyStore(path); len(restored.entries) != 3 {
+		t.Fatalf("restored entries = %d, want 3", len(restored.entries))
+	}
+}
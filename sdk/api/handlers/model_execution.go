@@ -22,6 +22,7 @@ type modelExecutionOptions struct {
 	SkipRouterPluginID      string
 	ForcedProvider          string
 	AuthSelectionModel      string
+	NoFallback              bool
 }
 
 // ProtocolExecutionRequest describes a route-level model execution request with explicit protocols.
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// compiledModelMappings caches the mapper built from one model-mappings list.
+type compiledModelMappings struct {
+	source []config.ModelMapping
+	mapper *util.ModelMapper
+}
+
+func (h *BaseAPIHandler) modelMapperForConfig(cfg *config.SDKConfig) *util.ModelMapper {
+	if cfg == nil || len(cfg.ModelMappings) == 0 {
+		return nil
+	}
+	h.modelMappingsMu.Lock()
+	defer h.modelMappingsMu.Unlock()
+	if h.modelMappings == nil || !reflect.DeepEqual(h.modelMappings.source, cfg.ModelMappings) {
+		mapper, errs := util.NewModelMapper(cfg.ModelMappings)
+		for _, err := range errs {
+			log.Warnf("%v, skipping", err)
+		}
+		h.modelMappings = &compiledModelMappings{
+			source: append([]config.ModelMapping(nil), cfg.ModelMappings...),
+			mapper: mapper,
+		}
+	}
+	return h.modelMappings.mapper
+}
+
+// applyModelMappings rewrites modelName through the configured model-mappings. A name with
+// a thinking suffix is mapped by its base name first and keeps the suffix; when no rule
+// matches the base name, the full name is tried so rules can rewrite the suffix themselves.
+func (h *BaseAPIHandler) applyModelMappings(modelName string) string {
+	if h == nil {
+		return modelName
+	}
+	mapper := h.modelMapperForConfig(h.Cfg)
+	if mapper == nil {
+		return modelName
+	}
+	var target string
+	var rule int
+	var ok bool
+	if parsed := thinking.ParseSuffix(modelName); parsed.HasSuffix {
+		if target, rule, ok = mapper.Map(parsed.ModelName); ok && !thinking.ParseSuffix(target).HasSuffix {
+			target = fmt.Sprintf("%s(%s)", target, parsed.RawSuffix)
+		}
+	}
+	if !ok {
+		if target, rule, ok = mapper.Map(modelName); !ok {
+			return modelName
+		}
+	}
+	target = strings.TrimSpace(target)
+	if target == "" || target == modelName {
+		return modelName
+	}
+	log.Debugf("model-mappings[%d]: %s -> %s", rule, modelName, target)
+	return target
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"testing"
+
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestApplyModelMappings(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{ModelMappings: []sdkconfig.ModelMapping{
+		{From: "gpt-4*", To: "gemini-2.5-pro"},
+		{From: `o3\((\w+)\)`, To: "gpt-5($1)", Regex: true},
+	}}
+	handler := NewBaseAPIHandlers(cfg, nil)
+
+	cases := map[string]string{
+		"gpt-4o":        "gemini-2.5-pro",
+		"gpt-4o(high)":  "gemini-2.5-pro(high)",
+		"o3(low)":       "gpt-5(low)",
+		"claude-opus-4": "claude-opus-4",
+	}
+	for model, want := range cases {
+		if got := handler.applyModelMappings(model); got != want {
+			t.Errorf("applyModelMappings(%q) = %q, want %q", model, got, want)
+		}
+	}
+
+	cfg.ModelMappings = []sdkconfig.ModelMapping{{From: "gpt-4o", To: "claude-sonnet-4-5"}}
+	if got := handler.applyModelMappings("gpt-4o"); got != "claude-sonnet-4-5" {
+		t.Fatalf("after config change applyModelMappings(gpt-4o) = %q, want claude-sonnet-4-5", got)
+	}
+}
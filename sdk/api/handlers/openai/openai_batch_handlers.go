@@ -0,0 +1,150 @@
+package openai
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// UploadFile handles POST /v1/files. Only purpose "batch" is accepted.
+func (h *OpenAIAPIHandler) UploadFile(c *gin.Context) {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		writeBatchInvalidRequest(c, "file is required")
+		return
+	}
+	limit := h.BatchMaxFileBytes()
+	tooLarge := fmt.Sprintf("file exceeds the %d byte limit", limit)
+	if fileHeader.Size > limit {
+		writeBatchInvalidRequest(c, tooLarge)
+		return
+	}
+	f, err := fileHeader.Open()
+	if err != nil {
+		writeBatchInvalidRequest(c, "failed to read file")
+		return
+	}
+	defer func() { _ = f.Close() }()
+	content, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		writeBatchInvalidRequest(c, "failed to read file")
+		return
+	}
+	if int64(len(content)) > limit {
+		writeBatchInvalidRequest(c, tooLarge)
+		return
+	}
+
+	file, errMsg := h.UploadBatchFile(sessionContext(c), fileHeader.Filename, strings.TrimSpace(c.PostForm("purpose")), content)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	c.JSON(http.StatusOK, file)
+}
+
+// RetrieveFile handles GET /v1/files/:file_id.
+func (h *OpenAIAPIHandler) RetrieveFile(c *gin.Context) {
+	file, errMsg := h.GetBatchFile(sessionContext(c), c.Param("file_id"))
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	c.JSON(http.StatusOK, file)
+}
+
+// RetrieveFileContent handles GET /v1/files/:file_id/content.
+func (h *OpenAIAPIHandler) RetrieveFileContent(c *gin.Context) {
+	content, errMsg := h.GetBatchFileContent(sessionContext(c), c.Param("file_id"))
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	c.Data(http.StatusOK, "application/jsonl", content)
+}
+
+// CreateBatch handles POST /v1/batches.
+func (h *OpenAIAPIHandler) CreateBatch(c *gin.Context) {
+	rawJSON, err := handlers.ReadRequestBody(c)
+	if err != nil || !gjson.ValidBytes(rawJSON) {
+		writeBatchInvalidRequest(c, "request body must be JSON")
+		return
+	}
+	inputFileID := strings.TrimSpace(gjson.GetBytes(rawJSON, "input_file_id").String())
+	if inputFileID == "" {
+		writeBatchInvalidRequest(c, "input_file_id is required")
+		return
+	}
+	var metadata map[string]string
+	gjson.GetBytes(rawJSON, "metadata").ForEach(func(key, value gjson.Result) bool {
+		if metadata == nil {
+			metadata = make(map[string]string)
+		}
+		metadata[key.String()] = value.String()
+		return true
+	})
+
+	batch, errMsg := h.BaseAPIHandler.CreateBatch(
+		sessionContext(c),
+		inputFileID,
+		strings.TrimSpace(gjson.GetBytes(rawJSON, "endpoint").String()),
+		strings.TrimSpace(gjson.GetBytes(rawJSON, "completion_window").String()),
+		metadata,
+	)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	c.JSON(http.StatusOK, batch)
+}
+
+// RetrieveBatch handles GET /v1/batches/:batch_id.
+func (h *OpenAIAPIHandler) RetrieveBatch(c *gin.Context) {
+	batch, errMsg := h.GetBatch(sessionContext(c), c.Param("batch_id"))
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	c.JSON(http.StatusOK, batch)
+}
+
+// ListBatches handles GET /v1/batches with OpenAI-style after/limit pagination.
+func (h *OpenAIAPIHandler) ListBatches(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	batches, hasMore, errMsg := h.BaseAPIHandler.ListBatches(sessionContext(c), c.Query("after"), limit)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	resp := gin.H{"object": "list", "data": batches, "has_more": hasMore, "first_id": nil, "last_id": nil}
+	if len(batches) > 0 {
+		resp["first_id"] = batches[0].ID
+		resp["last_id"] = batches[len(batches)-1].ID
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// CancelBatch handles POST /v1/batches/:batch_id/cancel.
+func (h *OpenAIAPIHandler) CancelBatch(c *gin.Context) {
+	batch, errMsg := h.BaseAPIHandler.CancelBatch(sessionContext(c), c.Param("batch_id"))
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	c.JSON(http.StatusOK, batch)
+}
+
+func writeBatchInvalidRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+		},
+	})
+}
@@ -0,0 +1,61 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// Embeddings handles the /v1/embeddings endpoint. The request body is forwarded in
+// OpenAI format; providers convert it to their native embeddings API.
+func (h *OpenAIAPIHandler) Embeddings(c *gin.Context) {
+	rawJSON, err := handlers.ReadRequestBody(c)
+	if err != nil {
+		writeEmbeddingsInvalidRequest(c, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	modelName := strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String())
+	if modelName == "" {
+		writeEmbeddingsInvalidRequest(c, "model is required")
+		return
+	}
+	input := gjson.GetBytes(rawJSON, "input")
+	if !input.Exists() || (input.IsArray() && len(input.Array()) == 0) {
+		writeEmbeddingsInvalidRequest(c, "input is required")
+		return
+	}
+	if input.Type != gjson.String && !input.IsArray() {
+		writeEmbeddingsInvalidRequest(c, "input must be a string or an array")
+		return
+	}
+	if format := gjson.GetBytes(rawJSON, "encoding_format").String(); format != "" && format != "float" && format != "base64" {
+		writeEmbeddingsInvalidRequest(c, "encoding_format must be float or base64")
+		return
+	}
+
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, upstreamHeaders, errMsg := h.ExecuteEmbeddingsWithAuthManager(cliCtx, modelName, rawJSON)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
+func writeEmbeddingsInvalidRequest(c *gin.Context, message string) {
+	c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+		Error: handlers.ErrorDetail{
+			Message: message,
+			Type:    "invalid_request_error",
+		},
+	})
+}
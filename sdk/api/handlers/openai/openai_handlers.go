@@ -21,6 +21,7 @@ import (
 	codexconverter "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/codex/openai/chat-completions"
 	responsesconverter "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/openai/openai/responses"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
 
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -533,7 +534,7 @@ func (h *OpenAIAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSON []
 		return
 	}
 	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
-	_, _ = c.Writer.Write(resp)
+	_, _ = c.Writer.Write(sdktranslator.NormalizeToolCallsNonStream(resp))
 	cliCancel()
 }
 
@@ -628,11 +629,14 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 			setSSEHeaders()
 			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
 
-			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(chunk))
+			toolCalls := sdktranslator.NewToolCallNormalizer()
+			for _, normalized := range toolCalls.NormalizeChunk(chunk) {
+				_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(normalized))
+			}
 			flusher.Flush()
 
 			// Continue streaming the rest
-			h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan)
+			h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, toolCalls)
 			return
 		}
 	}
@@ -835,15 +839,20 @@ func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, ra
 			h.handleStreamResult(c, flusher, func(err error) {
 				stop()
 				cliCancel(err)
-			}, convertedChan, errChan)
+			}, convertedChan, errChan, nil)
 			return
 		}
 	}
 }
-func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+
+// handleStreamResult forwards the remaining stream chunks. When toolCalls is non-nil the
+// chunks are Chat Completions chunks and pass through the tool-call normalizer.
+func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage, toolCalls *sdktranslator.ToolCallNormalizer) {
 	h.ForwardStream(c, flusher, cancel, data, errs, handlers.StreamForwardOptions{
 		WriteChunk: func(chunk []byte) {
-			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(chunk))
+			for _, normalized := range toolCalls.NormalizeChunk(chunk) {
+				_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(normalized))
+			}
 		},
 		WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
 			if errMsg == nil {
@@ -861,6 +870,9 @@ func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flush
 			_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(body))
 		},
 		WriteDone: func() {
+			for _, repair := range toolCalls.Flush() {
+				_, _ = fmt.Fprintf(c.Writer, "data: %s\n\n", string(repair))
+			}
 			_, _ = fmt.Fprint(c.Writer, "data: [DONE]\n\n")
 		},
 	})
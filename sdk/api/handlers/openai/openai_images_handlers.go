@@ -236,6 +236,17 @@ func isOpenAICompatImagesModel(model string) bool {
 	return info != nil && info.Type == registry.OpenAIImageModelType
 }
 
+// isGeminiImagesModel reports whether model is a Gemini image or Imagen model, which
+// /v1/images/generations serves through the Gemini executors' image capability.
+func isGeminiImagesModel(model string) bool {
+	info := registry.LookupModelInfo(strings.TrimSpace(model))
+	if info == nil || info.Type != "gemini" {
+		return false
+	}
+	id := strings.ToLower(info.ID)
+	return strings.Contains(id, "-image") || strings.HasPrefix(id, "imagen")
+}
+
 func rejectUnsupportedImagesModel(c *gin.Context, model string) bool {
 	if isSupportedImagesModel(model) {
 		return false
@@ -607,7 +618,8 @@ func (h *OpenAIAPIHandler) ImagesGenerations(c *gin.Context) {
 	if imageModel == "" {
 		imageModel = defaultImagesToolModel
 	}
-	if rejectUnsupportedImagesModel(c, imageModel) {
+	geminiModel := isGeminiImagesModel(imageModel)
+	if !geminiModel && rejectUnsupportedImagesModel(c, imageModel) {
 		return
 	}
 
@@ -628,6 +640,19 @@ func (h *OpenAIAPIHandler) ImagesGenerations(c *gin.Context) {
 	}
 	stream := gjson.GetBytes(rawJSON, "stream").Bool()
 
+	if geminiModel {
+		if stream {
+			c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+				Error: handlers.ErrorDetail{
+					Message: fmt.Sprintf("Invalid request: streaming is not supported for %s", imageModel),
+					Type:    "invalid_request_error",
+				},
+			})
+			return
+		}
+		h.collectImagesWithModel(c, rawJSON, imageModel, responseFormat)
+		return
+	}
 	if isCodexImagesToolModel(imageModel) {
 		imageReq := buildOpenAICompatImagesJSONRequest(rawJSON, imageModel, stream)
 		h.handleRoutedImages(c, imageReq, imageModel, stream)
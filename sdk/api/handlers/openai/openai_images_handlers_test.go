@@ -344,3 +344,16 @@ func TestImagesEdits_DisableImageGenerationChat_DoesNotReturn404(t *testing.T) {
 		t.Fatalf("status = %d, want %d: %s", resp.Code, http.StatusBadRequest, resp.Body.String())
 	}
 }
+
+func TestIsGeminiImagesModel(t *testing.T) {
+	for _, model := range []string{"gemini-3-pro-image-preview", "imagen-4.0-generate-001"} {
+		if !isGeminiImagesModel(model) {
+			t.Fatalf("expected %s to be a Gemini image model", model)
+		}
+	}
+	for _, model := range []string{"gemini-2.5-pro", "gpt-image-2", "unknown-model"} {
+		if isGeminiImagesModel(model) {
+			t.Fatalf("expected %s not to be a Gemini image model", model)
+		}
+	}
+}
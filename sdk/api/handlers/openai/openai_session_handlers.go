@@ -0,0 +1,90 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+)
+
+// CreateSession handles POST /v1/sessions.
+func (h *OpenAIAPIHandler) CreateSession(c *gin.Context) {
+	rawJSON, ok := readSessionBody(c)
+	if !ok {
+		return
+	}
+	session, errMsg := h.BaseAPIHandler.CreateSession(sessionContext(c), rawJSON)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// RetrieveSession handles GET /v1/sessions/:session_id.
+func (h *OpenAIAPIHandler) RetrieveSession(c *gin.Context) {
+	session, errMsg := h.GetSession(sessionContext(c), c.Param("session_id"))
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// DeleteSession handles DELETE /v1/sessions/:session_id.
+func (h *OpenAIAPIHandler) DeleteSession(c *gin.Context) {
+	id := c.Param("session_id")
+	if errMsg := h.BaseAPIHandler.DeleteSession(sessionContext(c), id); errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"id": id, "object": "session.deleted", "deleted": true})
+}
+
+// AppendSessionMessages handles POST /v1/sessions/:session_id/messages.
+func (h *OpenAIAPIHandler) AppendSessionMessages(c *gin.Context) {
+	rawJSON, ok := readSessionBody(c)
+	if !ok {
+		return
+	}
+	session, errMsg := h.BaseAPIHandler.AppendSessionMessages(sessionContext(c), c.Param("session_id"), rawJSON)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		return
+	}
+	c.JSON(http.StatusOK, session)
+}
+
+// RunSession handles POST /v1/sessions/:session_id/run and returns a chat completion.
+func (h *OpenAIAPIHandler) RunSession(c *gin.Context) {
+	rawJSON, ok := readSessionBody(c)
+	if !ok {
+		return
+	}
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	resp, upstreamHeaders, errMsg := h.BaseAPIHandler.RunSession(cliCtx, c.Param("session_id"), rawJSON)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	c.Data(http.StatusOK, "application/json", resp)
+	cliCancel()
+}
+
+// readSessionBody reads an optional JSON request body, writing a 400 when it is not JSON.
+func readSessionBody(c *gin.Context) ([]byte, bool) {
+	rawJSON, err := handlers.ReadRequestBody(c)
+	if err != nil || (len(rawJSON) > 0 && !gjson.ValidBytes(rawJSON)) {
+		writeBatchInvalidRequest(c, "request body must be JSON")
+		return nil, false
+	}
+	return rawJSON, true
+}
+
+func sessionContext(c *gin.Context) context.Context {
+	return context.WithValue(c.Request.Context(), "gin", c)
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	thinkOpenTag  = "<think>"
+	thinkCloseTag = "</think>"
+)
+
+// reasoningFieldPaths lists the structured reasoning fields providers attach to OpenAI
+// Chat Completions messages and deltas.
+var reasoningFieldPaths = []string{"reasoning_content", "reasoning", "reasoning_details"}
+
+// reasoningPolicy resolves the reasoning policy for the request: the client API key entry
+// first, then the model entries (exact names before wildcard patterns, longest pattern
+// first), then the default. Unknown values fall back to expose.
+func (h *BaseAPIHandler) reasoningPolicy(ctx context.Context, models ...string) string {
+	if h == nil || h.Cfg == nil {
+		return config.ReasoningPolicyExpose
+	}
+	cfg := h.Cfg.ReasoningPolicy
+	policy := ""
+	if len(cfg.APIKeys) > 0 && ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+			if value, exists := ginCtx.Get("userApiKey"); exists {
+				if apiKey, okKey := value.(string); okKey {
+					policy = cfg.APIKeys[apiKey]
+				}
+			}
+		}
+	}
+	if policy == "" && len(cfg.Models) > 0 {
+		policy = lookupModelEntry(cfg.Models, models)
+	}
+	if policy == "" {
+		policy = cfg.Default
+	}
+	switch policy = strings.ToLower(strings.TrimSpace(policy)); policy {
+	case config.ReasoningPolicyStrip, config.ReasoningPolicyConvert:
+		return policy
+	default:
+		return config.ReasoningPolicyExpose
+	}
+}
+
+// lookupModelEntry returns the value of the first entry matching one of models: exact
+// names before wildcard patterns, longest pattern first.
+func lookupModelEntry(entries map[string]string, models []string) string {
+	for _, model := range models {
+		if policy, ok := entries[model]; ok && model != "" {
+			return policy
+		}
+	}
+	patterns := make([]string, 0, len(entries))
+	for pattern := range entries {
+		if strings.Contains(pattern, "*") {
+			patterns = append(patterns, pattern)
+		}
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, pattern := range patterns {
+		for _, model := range models {
+			if model != "" && matchModelWildcard(pattern, model) {
+				return entries[pattern]
+			}
+		}
+	}
+	return ""
+}
+
+// matchModelWildcard reports whether model matches pattern, where '*' matches any run of
+// characters including none.
+func matchModelWildcard(pattern, model string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == model
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(model, parts[0]) {
+		return false
+	}
+	model = model[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(model, part)
+		if idx < 0 {
+			return false
+		}
+		model = model[idx+len(part):]
+	}
+	return strings.HasSuffix(model, last)
+}
+
+// reasoningFilter applies a strip or convert policy to OpenAI Chat Completions output.
+// Streams keep one filter so <think> tags split across chunks are still recognised.
+type reasoningFilter struct {
+	policy  string
+	choices map[int64]*thinkSplitter
+}
+
+// newReasoningFilter returns a filter for the request, or nil when the response is not an
+// OpenAI Chat Completions payload or the policy is expose.
+func (h *BaseAPIHandler) newReasoningFilter(ctx context.Context, responseProtocol string, models ...string) *reasoningFilter {
+	if responseProtocol != "openai" {
+		return nil
+	}
+	policy := h.reasoningPolicy(ctx, models...)
+	if policy == config.ReasoningPolicyExpose {
+		return nil
+	}
+	return &reasoningFilter{policy: policy, choices: make(map[int64]*thinkSplitter)}
+}
+
+// applyChunk rewrites one chat.completion.chunk payload.
+func (f *reasoningFilter) applyChunk(payload []byte) []byte {
+	if f == nil || !gjson.ValidBytes(payload) {
+		return payload
+	}
+	for position, choice := range gjson.GetBytes(payload, "choices").Array() {
+		choiceIndex := int64(position)
+		if idx := choice.Get("index"); idx.Exists() {
+			choiceIndex = idx.Int()
+		}
+		splitter, ok := f.choices[choiceIndex]
+		if !ok {
+			splitter = &thinkSplitter{}
+			f.choices[choiceIndex] = splitter
+		}
+		finished := choice.Get("finish_reason").String() != ""
+		payload = f.applyMessage(payload, "choices."+strconv.Itoa(position)+".delta", choice.Get("delta"), splitter, finished)
+		if finished {
+			delete(f.choices, choiceIndex)
+		}
+	}
+	return payload
+}
+
+// applyBody rewrites a non-streaming chat.completion payload.
+func (f *reasoningFilter) applyBody(body []byte) []byte {
+	if f == nil || !gjson.ValidBytes(body) {
+		return body
+	}
+	for position, choice := range gjson.GetBytes(body, "choices").Array() {
+		body = f.applyMessage(body, "choices."+strconv.Itoa(position)+".message", choice.Get("message"), &thinkSplitter{}, true)
+	}
+	return body
+}
+
+func (f *reasoningFilter) applyMessage(payload []byte, path string, message gjson.Result, splitter *thinkSplitter, final bool) []byte {
+	content := message.Get("content")
+	text, reasoning := "", ""
+	if content.Type == gjson.String || final {
+		text, reasoning = splitter.split(content.String(), final)
+	}
+	if content.Type == gjson.String || text != "" {
+		payload, _ = sjson.SetBytes(payload, path+".content", text)
+	}
+
+	if f.policy == config.ReasoningPolicyStrip {
+		for _, field := range reasoningFieldPaths {
+			if message.Get(field).Exists() {
+				payload, _ = sjson.DeleteBytes(payload, path+"."+field)
+			}
+		}
+		return payload
+	}
+	if reasoning != "" {
+		payload, _ = sjson.SetBytes(payload, path+".reasoning_content", message.Get("reasoning_content").String()+reasoning)
+	}
+	return payload
+}
+
+// thinkSplitter separates <think>...</think> blocks from content text. Text that could be
+// the start of a tag is held back until the next call so tags split across chunks match.
+type thinkSplitter struct {
+	inThink    bool
+	afterClose bool
+	pending    string
+}
+
+// split returns the content and reasoning parts of text. final flushes held-back text.
+func (s *thinkSplitter) split(text string, final bool) (string, string) {
+	buf := s.pending + text
+	s.pending = ""
+	var content, reasoning strings.Builder
+	for buf != "" {
+		tag := thinkOpenTag
+		if s.inThink {
+			tag = thinkCloseTag
+		}
+		if idx := strings.Index(buf, tag); idx >= 0 {
+			s.write(&content, &reasoning, buf[:idx])
+			buf = buf[idx+len(tag):]
+			s.afterClose = s.inThink
+			s.inThink = !s.inThink
+			continue
+		}
+		keep := 0
+		if !final {
+			keep = partialTagSuffix(buf, tag)
+		}
+		s.write(&content, &reasoning, buf[:len(buf)-keep])
+		s.pending = buf[len(buf)-keep:]
+		break
+	}
+	return content.String(), reasoning.String()
+}
+
+func (s *thinkSplitter) write(content, reasoning *strings.Builder, text string) {
+	if s.inThink {
+		reasoning.WriteString(text)
+		return
+	}
+	if s.afterClose {
+		text = strings.TrimLeft(text, "\r\n")
+		if text == "" {
+			return
+		}
+		s.afterClose = false
+	}
+	content.WriteString(text)
+}
+
+// partialTagSuffix returns the length of the longest suffix of text that is a proper
+// prefix of tag.
+func partialTagSuffix(text, tag string) int {
+	for n := min(len(tag)-1, len(text)); n > 0; n-- {
+		if strings.HasSuffix(text, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestReasoningPolicyResolution(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{ReasoningPolicy: sdkconfig.ReasoningPolicyConfig{
+		Default: "strip",
+		Models:  map[string]string{"deepseek-*": "convert", "deepseek-r1-*": "expose", "gpt-5": "expose"},
+		APIKeys: map[string]string{"key-a": "convert"},
+	}}}
+
+	cases := []struct {
+		name   string
+		apiKey string
+		models []string
+		want   string
+	}{
+		{name: "api key wins", apiKey: "key-a", models: []string{"gpt-5"}, want: sdkconfig.ReasoningPolicyConvert},
+		{name: "exact model", models: []string{"gpt-5"}, want: sdkconfig.ReasoningPolicyExpose},
+		{name: "longest pattern", models: []string{"deepseek-r1-0528"}, want: sdkconfig.ReasoningPolicyExpose},
+		{name: "pattern", models: []string{"alias", "deepseek-v3"}, want: sdkconfig.ReasoningPolicyConvert},
+		{name: "default", models: []string{"claude-sonnet"}, want: sdkconfig.ReasoningPolicyStrip},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := h.reasoningPolicy(clientRequestTestContext(tc.apiKey, nil), tc.models...); got != tc.want {
+				t.Fatalf("reasoningPolicy = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	if filter := (&BaseAPIHandler{}).newReasoningFilter(context.Background(), "openai", "m"); filter != nil {
+		t.Fatal("expose policy should not build a filter")
+	}
+	if filter := h.newReasoningFilter(context.Background(), "claude", "m"); filter != nil {
+		t.Fatal("non-OpenAI responses should not be filtered")
+	}
+}
+
+func TestReasoningFilterConvertsSplitThinkTags(t *testing.T) {
+	filter := &reasoningFilter{policy: sdkconfig.ReasoningPolicyConvert, choices: make(map[int64]*thinkSplitter)}
+	chunks := []string{
+		`{"choices":[{"index":0,"delta":{"content":"<thi"},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"nk>plan</th"},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"ink>\n\nAnswer <"},"finish_reason":null}]}`,
+		`{"choices":[{"index":0,"delta":{"content":"3"},"finish_reason":"stop"}]}`,
+	}
+	var content, reasoning string
+	for _, chunk := range chunks {
+		out := filter.applyChunk([]byte(chunk))
+		content += gjson.GetBytes(out, "choices.0.delta.content").String()
+		reasoning += gjson.GetBytes(out, "choices.0.delta.reasoning_content").String()
+	}
+	if content != "Answer <3" || reasoning != "plan" {
+		t.Fatalf("content = %q, reasoning = %q", content, reasoning)
+	}
+}
+
+func TestReasoningFilterStrip(t *testing.T) {
+	filter := &reasoningFilter{policy: sdkconfig.ReasoningPolicyStrip}
+	body := []byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"<think>hidden</think>visible","reasoning_content":"more"},"finish_reason":"stop"}]}`)
+	out := filter.applyBody(body)
+	message := gjson.GetBytes(out, "choices.0.message")
+	if message.Get("content").String() != "visible" || message.Get("reasoning_content").Exists() {
+		t.Fatalf("unexpected message: %s", message.Raw)
+	}
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+const (
+	// CoalescedHeader marks responses shared from an identical request already in flight.
+	CoalescedHeader = "X-CLIProxy-Coalesced"
+
+	defaultCoalescingWindow = 10 * time.Second
+)
+
+// errCoalescedLeaderFailed is returned to waiters whose shared call ended without an outcome.
+var errCoalescedLeaderFailed = errors.New("coalesced request failed before producing a response")
+
+// coalescedCall is one upstream execution shared by identical in-flight requests.
+type coalescedCall struct {
+	startedAt time.Time
+	done      chan struct{}
+	body      []byte
+	headers   http.Header
+	errMsg    *interfaces.ErrorMessage
+}
+
+// requestCoalescer tracks in-flight non-streaming executions by request key.
+type requestCoalescer struct {
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+func newRequestCoalescer() *requestCoalescer {
+	return &requestCoalescer{calls: make(map[string]*coalescedCall)}
+}
+
+// join returns the in-flight call for key when it started within window, or registers a
+// new call for the caller to lead. leader reports which of the two happened.
+func (c *requestCoalescer) join(key string, window time.Duration, now time.Time) (call *coalescedCall, leader bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if current, ok := c.calls[key]; ok && now.Sub(current.startedAt) < window {
+		return current, false
+	}
+	call = &coalescedCall{startedAt: now, done: make(chan struct{})}
+	c.calls[key] = call
+	return call, true
+}
+
+// finish publishes the outcome of call and stops new requests from joining it.
+func (c *requestCoalescer) finish(key string, call *coalescedCall) {
+	c.mu.Lock()
+	if c.calls[key] == call {
+		delete(c.calls, key)
+	}
+	c.mu.Unlock()
+	close(call.done)
+}
+
+func (h *BaseAPIHandler) requestCoalescerForConfig(cfg *config.SDKConfig) *requestCoalescer {
+	if cfg == nil || !cfg.RequestCoalescing.Enabled {
+		return nil
+	}
+	h.coalescerMu.Lock()
+	defer h.coalescerMu.Unlock()
+	if h.coalescer == nil {
+		h.coalescer = newRequestCoalescer()
+	}
+	return h.coalescer
+}
+
+func coalescingWindow(cfg *config.SDKConfig) time.Duration {
+	if cfg == nil {
+		return defaultCoalescingWindow
+	}
+	raw := strings.TrimSpace(cfg.RequestCoalescing.Window)
+	if raw == "" {
+		return defaultCoalescingWindow
+	}
+	window, errParse := time.ParseDuration(raw)
+	if errParse != nil || window <= 0 {
+		return defaultCoalescingWindow
+	}
+	return window
+}
+
+// coalescingKey hashes the client principal, protocol, model and exact request body, so
+// only byte-identical requests of the same client share an upstream call.
+func coalescingKey(ctx context.Context, entryProtocol, modelName string, rawJSON []byte) string {
+	principal := ""
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		if value, exists := ginCtx.Get("userApiKey"); exists {
+			principal = fmt.Sprint(value)
+		}
+	}
+	hasher := sha256.New()
+	hasher.Write([]byte(principal))
+	hasher.Write([]byte{0})
+	hasher.Write([]byte(entryProtocol))
+	hasher.Write([]byte{0})
+	hasher.Write([]byte(modelName))
+	hasher.Write([]byte{0})
+	hasher.Write(rawJSON)
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// executeCoalesced runs execute once for identical non-streaming requests arriving while
+// an earlier one is in flight, fanning its response out to every waiter. A leader that
+// was cancelled by its client does not cancel the others: they execute on their own.
+func (h *BaseAPIHandler) executeCoalesced(ctx context.Context, entryProtocol, modelName string, rawJSON []byte, execute func() ([]byte, http.Header, *interfaces.ErrorMessage)) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	coalescer := h.requestCoalescerForConfig(h.Cfg)
+	if coalescer == nil || ctx == nil {
+		return execute()
+	}
+	key := coalescingKey(ctx, entryProtocol, modelName, rawJSON)
+	call, leader := coalescer.join(key, coalescingWindow(h.Cfg), time.Now())
+	if leader {
+		// Waiters get an error if the leader panics before publishing its outcome; the
+		// deferred finish still releases them and frees the key.
+		call.errMsg = &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: errCoalescedLeaderFailed}
+		defer coalescer.finish(key, call)
+		body, headers, errMsg := execute()
+		// Waiters read copies so the leader's caller may keep mutating its own response.
+		call.body, call.headers, call.errMsg = cloneBytes(body), cloneHeader(headers), nil
+		if errMsg != nil {
+			call.errMsg = &interfaces.ErrorMessage{StatusCode: errMsg.StatusCode, Error: errMsg.Error, Addon: cloneHeader(errMsg.Addon)}
+		}
+		return body, headers, errMsg
+	}
+
+	select {
+	case <-call.done:
+	case <-ctx.Done():
+		return nil, nil, &interfaces.ErrorMessage{StatusCode: statusClientClosedRequest, Error: ctx.Err()}
+	}
+	if errMsg := call.errMsg; errMsg != nil {
+		if errMsg.StatusCode == statusClientClosedRequest || errors.Is(errMsg.Error, context.Canceled) {
+			return execute()
+		}
+		headers := cloneHeader(errMsg.Addon)
+		if headers == nil {
+			headers = make(http.Header)
+		}
+		headers.Set(CoalescedHeader, "true")
+		return nil, nil, &interfaces.ErrorMessage{StatusCode: errMsg.StatusCode, Error: errMsg.Error, Addon: headers}
+	}
+	headers := cloneHeader(call.headers)
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	headers.Set(CoalescedHeader, "true")
+	return cloneBytes(call.body), headers, nil
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestExecuteCoalescedSharesInFlightCall(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{RequestCoalescing: sdkconfig.RequestCoalescingConfig{Enabled: true}}}
+	body := []byte(`{"model":"m","messages":[]}`)
+	release := make(chan struct{})
+	var calls atomic.Int32
+	execute := func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		calls.Add(1)
+		<-release
+		return []byte("answer"), http.Header{"X-Upstream": {"1"}}, nil
+	}
+
+	ctx := clientRequestTestContext("key-a", nil)
+	var wg sync.WaitGroup
+	results := make([]http.Header, 3)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			payload, headers, errMsg := h.executeCoalesced(ctx, "openai", "m", body, execute)
+			if errMsg != nil || string(payload) != "answer" {
+				t.Errorf("request %d = %q, %v", i, payload, errMsg)
+			}
+			results[i] = headers
+		}(i)
+		if i == 0 {
+			for deadline := time.Now().Add(time.Second); calls.Load() == 0 && time.Now().Before(deadline); {
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("upstream calls = %d, want 1", got)
+	}
+	coalesced := 0
+	for _, headers := range results {
+		if headers.Get(CoalescedHeader) == "true" {
+			coalesced++
+		}
+	}
+	if coalesced != 2 {
+		t.Fatalf("coalesced responses = %d, want 2", coalesced)
+	}
+
+	other := clientRequestTestContext("key-b", nil)
+	if _, headers, _ := h.executeCoalesced(other, "openai", "m", body, func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		return []byte("own"), nil, nil
+	}); headers.Get(CoalescedHeader) != "" {
+		t.Fatal("requests of another client key must not be coalesced")
+	}
+}
+
+func TestExecuteCoalescedRetriesAfterCancelledLeader(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{RequestCoalescing: sdkconfig.RequestCoalescingConfig{Enabled: true}}}
+	ctx := clientRequestTestContext("key-a", nil)
+	coalescer := h.requestCoalescerForConfig(h.Cfg)
+	key := coalescingKey(ctx, "openai", "m", []byte("{}"))
+	call, leader := coalescer.join(key, time.Minute, time.Now())
+	if !leader {
+		t.Fatal("first request should lead")
+	}
+
+	done := make(chan []byte)
+	go func() {
+		payload, _, _ := h.executeCoalesced(ctx, "openai", "m", []byte("{}"), func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+			return []byte("follower"), nil, nil
+		})
+		done <- payload
+	}()
+	time.Sleep(20 * time.Millisecond)
+	call.errMsg = &interfaces.ErrorMessage{StatusCode: statusClientClosedRequest, Error: context.Canceled}
+	coalescer.finish(key, call)
+
+	select {
+	case payload := <-done:
+		if string(payload) != "follower" {
+			t.Fatalf("follower payload = %q, want its own execution", payload)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("follower did not finish")
+	}
+	if !errors.Is(call.errMsg.Error, context.Canceled) {
+		t.Fatal("leader outcome changed")
+	}
+}
+
+func TestExecuteCoalescedReleasesWaitersWhenLeaderPanics(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{RequestCoalescing: sdkconfig.RequestCoalescingConfig{Enabled: true}}}
+	ctx := clientRequestTestContext("key-a", nil)
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	go func() {
+		defer func() { _ = recover() }()
+		_, _, _ = h.executeCoalesced(ctx, "openai", "m", []byte("{}"), func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+			close(started)
+			<-release
+			panic("leader boom")
+		})
+	}()
+	<-started
+
+	done := make(chan *interfaces.ErrorMessage)
+	go func() {
+		_, _, errMsg := h.executeCoalesced(ctx, "openai", "m", []byte("{}"), func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+			return []byte("unexpected"), nil, nil
+		})
+		done <- errMsg
+	}()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	select {
+	case errMsg := <-done:
+		if errMsg == nil || errMsg.StatusCode != http.StatusInternalServerError {
+			t.Fatalf("waiter error = %v, want 500", errMsg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("waiter hung after the leader panicked")
+	}
+	h.coalescer.mu.Lock()
+	remaining := len(h.coalescer.calls)
+	h.coalescer.mu.Unlock()
+	if remaining != 0 {
+		t.Fatalf("in-flight calls = %d, want the panicked call removed", remaining)
+	}
+}
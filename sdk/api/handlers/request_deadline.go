@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// DeadlineHeader lets a client bound, in seconds, the total time the proxy spends on a
+// request across retries, cooldown waits and fallbacks.
+const DeadlineHeader = "X-CLIProxy-Deadline"
+
+// stainlessTimeoutHeader carries the client-side timeout (seconds) sent by the OpenAI and
+// Anthropic SDKs; it is honoured when DeadlineHeader is absent.
+const stainlessTimeoutHeader = "X-Stainless-Timeout"
+
+// setRequestDeadlineMetadata records the overall deadline of the request so the conductor
+// stops starting new attempts once the client is no longer waiting.
+func (h *BaseAPIHandler) setRequestDeadlineMetadata(ctx context.Context, meta map[string]any) {
+	if meta == nil {
+		return
+	}
+	if budget := h.requestDeadlineBudget(ctx); budget > 0 {
+		meta[coreexecutor.RequestDeadlineMetadataKey] = time.Now().Add(budget)
+	}
+}
+
+// requestDeadlineBudget returns the effective budget for the request: the configured
+// request-deadline timeout, lowered by a client header when present. Zero means unbounded.
+func (h *BaseAPIHandler) requestDeadlineBudget(ctx context.Context) time.Duration {
+	var budget time.Duration
+	if h != nil && h.Cfg != nil {
+		if raw := strings.TrimSpace(h.Cfg.RequestDeadline.Timeout); raw != "" {
+			parsed, errParse := time.ParseDuration(raw)
+			if errParse != nil || parsed <= 0 {
+				log.Debugf("ignoring invalid request-deadline.timeout %q", raw)
+			} else {
+				budget = parsed
+			}
+		}
+	}
+	if ctx == nil {
+		return budget
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return budget
+	}
+	for _, header := range []string{DeadlineHeader, stainlessTimeoutHeader} {
+		raw := strings.TrimSpace(ginCtx.GetHeader(header))
+		if raw == "" {
+			continue
+		}
+		seconds, errParse := strconv.ParseFloat(raw, 64)
+		if errParse != nil || seconds <= 0 {
+			log.Debugf("ignoring invalid %s header %q", header, raw)
+			continue
+		}
+		if requested := time.Duration(seconds * float64(time.Second)); budget <= 0 || requested < budget {
+			budget = requested
+		}
+		break
+	}
+	return budget
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestRequestDeadlineBudgetResolution(t *testing.T) {
+	configured := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{RequestDeadline: sdkconfig.RequestDeadlineConfig{Timeout: "2m"}}}
+	unconfigured := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{}}
+
+	cases := []struct {
+		name    string
+		handler *BaseAPIHandler
+		headers map[string]string
+		want    time.Duration
+	}{
+		{name: "configured default", handler: configured, want: 2 * time.Minute},
+		{name: "header lowers", handler: configured, headers: map[string]string{DeadlineHeader: "30"}, want: 30 * time.Second},
+		{name: "header cannot raise", handler: configured, headers: map[string]string{DeadlineHeader: "600"}, want: 2 * time.Minute},
+		{name: "sdk timeout", handler: unconfigured, headers: map[string]string{stainlessTimeoutHeader: "1.5"}, want: 1500 * time.Millisecond},
+		{name: "deadline header wins", handler: unconfigured, headers: map[string]string{DeadlineHeader: "10", stainlessTimeoutHeader: "600"}, want: 10 * time.Second},
+		{name: "invalid header ignored", handler: configured, headers: map[string]string{DeadlineHeader: "soon"}, want: 2 * time.Minute},
+		{name: "unbounded", handler: unconfigured},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.handler.requestDeadlineBudget(clientRequestTestContext("", tc.headers)); got != tc.want {
+				t.Fatalf("budget = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestSetRequestDeadlineMetadata(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &sdkconfig.SDKConfig{}}
+	meta := map[string]any{}
+	h.setRequestDeadlineMetadata(clientRequestTestContext("", nil), meta)
+	if _, ok := meta[coreexecutor.RequestDeadlineMetadataKey]; ok {
+		t.Fatal("deadline set without a configured or requested budget")
+	}
+
+	before := time.Now()
+	h.setRequestDeadlineMetadata(clientRequestTestContext("", map[string]string{DeadlineHeader: "5"}), meta)
+	deadline, ok := meta[coreexecutor.RequestDeadlineMetadataKey].(time.Time)
+	if !ok || deadline.Before(before.Add(5*time.Second)) || deadline.After(time.Now().Add(5*time.Second)) {
+		t.Fatalf("deadline = %v, want about 5s from now", meta[coreexecutor.RequestDeadlineMetadataKey])
+	}
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// PriorityHeader selects the request priority class: "interactive", "normal" or "batch".
+const PriorityHeader = "X-CLIProxy-Priority"
+
+// setRequestPriorityMetadata records the priority class of the request. The API key policy
+// takes precedence over the header so a batch key cannot promote its own traffic.
+func (h *BaseAPIHandler) setRequestPriorityMetadata(ctx context.Context, meta map[string]any) {
+	if meta == nil {
+		return
+	}
+	raw := ""
+	if policy, ok := h.requestAPIKeyPolicy(ctx); ok {
+		raw = policy.Priority
+	}
+	if raw == "" && ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+			raw = ginCtx.GetHeader(PriorityHeader)
+		}
+	}
+	if raw == "" {
+		return
+	}
+	priority, ok := coreauth.ParseRequestPriority(raw)
+	if !ok {
+		log.Debugf("ignoring unknown request priority %q", raw)
+		return
+	}
+	meta[coreexecutor.RequestPriorityMetadataKey] = priority.String()
+}
@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// ResponseCacheHeader reports whether a response was served from the response cache (HIT or MISS).
+	ResponseCacheHeader = "X-CLIProxy-Cache"
+
+	defaultResponseCacheTTL        = time.Hour
+	defaultResponseCacheMaxEntries = 512
+)
+
+// responseCacheVolatileFields are dropped before hashing because they do not change the output.
+var responseCacheVolatileFields = []string{"stream", "user", "metadata"}
+
+// responseCacheEntry is one cached non-streaming response.
+type responseCacheEntry struct {
+	Key       string      `json:"key"`
+	Body      []byte      `json:"body"`
+	Headers   http.Header `json:"headers,omitempty"`
+	ExpiresAt time.Time   `json:"expires_at"`
+}
+
+// responseCache is an in-memory LRU of deterministic responses, optionally backed by
+// one JSON file per entry in dir.
+type responseCache struct {
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]*list.Element
+	dir     string
+}
+
+func newResponseCache(dir string) *responseCache {
+	return &responseCache{
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		dir:     strings.TrimSpace(dir),
+	}
+}
+
+func (c *responseCache) get(key string, now time.Time) (*responseCacheEntry, bool) {
+	c.mu.Lock()
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*responseCacheEntry)
+		if now.Before(entry.ExpiresAt) {
+			c.order.MoveToFront(elem)
+			c.mu.Unlock()
+			return entry, true
+		}
+		c.order.Remove(elem)
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+
+	entry, ok := c.readDisk(key, now)
+	if !ok {
+		return nil, false
+	}
+	c.mu.Lock()
+	c.insertLocked(entry, 0)
+	c.mu.Unlock()
+	return entry, true
+}
+
+func (c *responseCache) put(entry *responseCacheEntry, maxEntries int) {
+	c.mu.Lock()
+	c.insertLocked(entry, maxEntries)
+	c.mu.Unlock()
+	c.writeDisk(entry)
+}
+
+// insertLocked adds entry as most recently used and trims the list to maxEntries (when > 0).
+func (c *responseCache) insertLocked(entry *responseCacheEntry, maxEntries int) {
+	if elem, ok := c.entries[entry.Key]; ok {
+		elem.Value = entry
+		c.order.MoveToFront(elem)
+	} else {
+		c.entries[entry.Key] = c.order.PushFront(entry)
+	}
+	for maxEntries > 0 && c.order.Len() > maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*responseCacheEntry).Key)
+	}
+}
+
+func (c *responseCache) diskPath(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+func (c *responseCache) readDisk(key string, now time.Time) (*responseCacheEntry, bool) {
+	if c.dir == "" {
+		return nil, false
+	}
+	path := c.diskPath(key)
+	data, errRead := os.ReadFile(path)
+	if errRead != nil {
+		if !errors.Is(errRead, os.ErrNotExist) {
+			log.Warnf("response cache: failed to read %s: %v", path, errRead)
+		}
+		return nil, false
+	}
+	var entry responseCacheEntry
+	if errUnmarshal := json.Unmarshal(data, &entry); errUnmarshal != nil || entry.Key != key {
+		_ = os.Remove(path)
+		return nil, false
+	}
+	if !now.Before(entry.ExpiresAt) {
+		_ = os.Remove(path)
+		return nil, false
+	}
+	return &entry, true
+}
+
+func (c *responseCache) writeDisk(entry *responseCacheEntry) {
+	if c.dir == "" {
+		return
+	}
+	data, errMarshal := json.Marshal(entry)
+	if errMarshal != nil {
+		log.Warnf("response cache: failed to encode entry: %v", errMarshal)
+		return
+	}
+	if errMkdir := os.MkdirAll(c.dir, 0o700); errMkdir != nil {
+		log.Warnf("response cache: failed to create directory: %v", errMkdir)
+		return
+	}
+	path := c.diskPath(entry.Key)
+	tmpPath := path + ".tmp"
+	if errWrite := os.WriteFile(tmpPath, data, 0o600); errWrite != nil {
+		log.Warnf("response cache: failed to write entry: %v", errWrite)
+		return
+	}
+	if errRename := os.Rename(tmpPath, path); errRename != nil {
+		log.Warnf("response cache: failed to replace entry: %v", errRename)
+	}
+}
+
+func responseCacheTTL(cfg *config.SDKConfig) time.Duration {
+	if cfg == nil {
+		return defaultResponseCacheTTL
+	}
+	raw := strings.TrimSpace(cfg.ResponseCache.TTL)
+	if raw == "" {
+		return defaultResponseCacheTTL
+	}
+	ttl, errParse := time.ParseDuration(raw)
+	if errParse != nil || ttl <= 0 {
+		return defaultResponseCacheTTL
+	}
+	return ttl
+}
+
+func responseCacheMaxEntries(cfg *config.SDKConfig) int {
+	if cfg == nil || cfg.ResponseCache.MaxEntries <= 0 {
+		return defaultResponseCacheMaxEntries
+	}
+	return cfg.ResponseCache.MaxEntries
+}
+
+// responseCacheForConfig returns the active cache, recreating it when the directory changes.
+func (h *BaseAPIHandler) responseCacheForConfig(cfg *config.SDKConfig) *responseCache {
+	if cfg == nil || !cfg.ResponseCache.Enabled {
+		return nil
+	}
+	dir := strings.TrimSpace(cfg.ResponseCache.Dir)
+	h.responseCacheMu.Lock()
+	defer h.responseCacheMu.Unlock()
+	if h.responseCache == nil || h.responseCache.dir != dir {
+		h.responseCache = newResponseCache(dir)
+	}
+	return h.responseCache
+}
+
+// responseCacheDirectives reads Cache-Control from the inbound request. no-cache skips
+// the lookup; no-store also skips storing the response.
+func responseCacheDirectives(ctx context.Context) (principal string, lookup, store bool) {
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return "", true, true
+	}
+	if value, exists := ginCtx.Get("userApiKey"); exists {
+		principal = fmt.Sprint(value)
+	}
+	lookup, store = true, true
+	for _, directive := range strings.Split(strings.ToLower(ginCtx.GetHeader("Cache-Control")), ",") {
+		switch strings.TrimSpace(directive) {
+		case "no-cache":
+			lookup = false
+		case "no-store":
+			lookup, store = false, false
+		}
+	}
+	return principal, lookup, store
+}
+
+// isDeterministicRequest reports whether rawJSON explicitly sets temperature 0.
+func isDeterministicRequest(rawJSON []byte) bool {
+	for _, path := range []string{"temperature", "generationConfig.temperature", "generation_config.temperature"} {
+		if value := gjson.GetBytes(rawJSON, path); value.Exists() {
+			return value.Type == gjson.Number && value.Float() == 0
+		}
+	}
+	return false
+}
+
+// responseCacheKey hashes the request after normalizing JSON key order and dropping
+// fields that do not affect the output. Responses are never shared across client keys.
+func responseCacheKey(principal, entryProtocol, modelName string, rawJSON []byte) (string, bool) {
+	var body map[string]any
+	if errUnmarshal := json.Unmarshal(rawJSON, &body); errUnmarshal != nil {
+		return "", false
+	}
+	for _, field := range responseCacheVolatileFields {
+		delete(body, field)
+	}
+	normalized, errMarshal := json.Marshal(body)
+	if errMarshal != nil {
+		return "", false
+	}
+	hasher := sha256.New()
+	for _, part := range []string{principal, entryProtocol, strings.ToLower(strings.TrimSpace(modelName))} {
+		hasher.Write([]byte(part))
+		hasher.Write([]byte{0})
+	}
+	hasher.Write(normalized)
+	return hex.EncodeToString(hasher.Sum(nil)), true
+}
+
+// executeCached serves deterministic (temperature 0) non-streaming requests from the
+// response cache and stores successful responses. Other requests call execute directly.
+func (h *BaseAPIHandler) executeCached(ctx context.Context, entryProtocol, modelName string, rawJSON []byte, execute func() ([]byte, http.Header, *interfaces.ErrorMessage)) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	cache := h.responseCacheForConfig(h.Cfg)
+	if cache == nil || ctx == nil || !isDeterministicRequest(rawJSON) {
+		return execute()
+	}
+	principal, lookup, store := responseCacheDirectives(ctx)
+	key, ok := responseCacheKey(principal, entryProtocol, modelName, rawJSON)
+	if !ok {
+		return execute()
+	}
+	now := time.Now()
+	if lookup {
+		if entry, hit := cache.get(key, now); hit {
+			headers := cloneHeader(entry.Headers)
+			if headers == nil {
+				headers = make(http.Header)
+			}
+			headers.Set(ResponseCacheHeader, "HIT")
+			return cloneBytes(entry.Body), headers, nil
+		}
+	}
+
+	body, headers, errExec := execute()
+	if errExec != nil {
+		return body, headers, errExec
+	}
+	if store {
+		cache.put(&responseCacheEntry{
+			Key:       key,
+			Body:      cloneBytes(body),
+			Headers:   cloneHeader(headers),
+			ExpiresAt: now.Add(responseCacheTTL(h.Cfg)),
+		}, responseCacheMaxEntries(h.Cfg))
+	}
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	headers.Set(ResponseCacheHeader, "MISS")
+	return body, headers, nil
+}
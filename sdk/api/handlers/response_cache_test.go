@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestExecuteCachedServesDeterministicRequests(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &config.SDKConfig{ResponseCache: config.ResponseCacheConfig{Enabled: true}}}
+	calls := 0
+	execute := func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		calls++
+		return []byte(`{"id":"one"}`), http.Header{"X-Test": {"1"}}, nil
+	}
+
+	first := []byte(`{"model":"m","temperature":0,"messages":[{"role":"user","content":"hi"}],"user":"a"}`)
+	reordered := []byte(`{"messages":[{"role":"user","content":"hi"}],"temperature":0,"model":"m","user":"b"}`)
+	_, headers, _ := h.executeCached(clientRequestTestContext("client", nil), "openai", "m", first, execute)
+	if got := headers.Get(ResponseCacheHeader); got != "MISS" {
+		t.Fatalf("first %s = %q, want MISS", ResponseCacheHeader, got)
+	}
+	body, headers, errMsg := h.executeCached(clientRequestTestContext("client", nil), "openai", "m", reordered, execute)
+	if errMsg != nil {
+		t.Fatalf("cached call error: %v", errMsg.Error)
+	}
+	if calls != 1 || string(body) != `{"id":"one"}` || headers.Get("X-Test") != "1" || headers.Get(ResponseCacheHeader) != "HIT" {
+		t.Fatalf("expected cache hit, calls=%d body=%s headers=%v", calls, body, headers)
+	}
+
+	h.executeCached(clientRequestTestContext("other", nil), "openai", "m", first, execute)
+	if calls != 2 {
+		t.Fatalf("cache shared across client keys, calls=%d", calls)
+	}
+}
+
+func TestExecuteCachedSkipsNonDeterministicAndBypass(t *testing.T) {
+	h := &BaseAPIHandler{Cfg: &config.SDKConfig{ResponseCache: config.ResponseCacheConfig{Enabled: true}}}
+	calls := 0
+	execute := func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		calls++
+		return []byte(`{}`), nil, nil
+	}
+
+	warm := []byte(`{"model":"m","temperature":0.7}`)
+	h.executeCached(clientRequestTestContext("client", nil), "openai", "m", warm, execute)
+	h.executeCached(clientRequestTestContext("client", nil), "openai", "m", warm, execute)
+	if calls != 2 {
+		t.Fatalf("non-deterministic request was cached, calls=%d", calls)
+	}
+
+	cold := []byte(`{"model":"m","temperature":0}`)
+	h.executeCached(clientRequestTestContext("client", map[string]string{"Cache-Control": "no-store"}), "openai", "m", cold, execute)
+	h.executeCached(clientRequestTestContext("client", nil), "openai", "m", cold, execute)
+	h.executeCached(clientRequestTestContext("client", map[string]string{"Cache-Control": "no-cache"}), "openai", "m", cold, execute)
+	if calls != 5 {
+		t.Fatalf("cache-control bypass not honored, calls=%d", calls)
+	}
+}
+
+func TestExecuteCachedReadsDiskEntries(t *testing.T) {
+	cfg := &config.SDKConfig{ResponseCache: config.ResponseCacheConfig{Enabled: true, Dir: t.TempDir()}}
+	body := []byte(`{"model":"m","temperature":0}`)
+	first := &BaseAPIHandler{Cfg: cfg}
+	first.executeCached(clientRequestTestContext("client", nil), "openai", "m", body, func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		return []byte(`{"id":"disk"}`), nil, nil
+	})
+
+	restarted := &BaseAPIHandler{Cfg: cfg}
+	cached, _, errMsg := restarted.executeCached(clientRequestTestContext("client", nil), "openai", "m", body, func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		t.Fatal("execute called despite a disk entry")
+		return nil, nil, nil
+	})
+	if errMsg != nil || string(cached) != `{"id":"disk"}` {
+		t.Fatalf("disk entry not served: body=%s err=%v", cached, errMsg)
+	}
+}
+
+func TestResponseCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newResponseCache("")
+	now := time.Now()
+	for _, key := range []string{"a", "b", "c"} {
+		cache.put(&responseCacheEntry{Key: key, ExpiresAt: now.Add(defaultResponseCacheTTL)}, 2)
+		if key == "b" {
+			cache.get("a", now)
+		}
+	}
+	if _, ok := cache.get("b", now); ok {
+		t.Fatal("least recently used entry was not evicted")
+	}
+	if _, ok := cache.get("a", now); !ok {
+		t.Fatal("recently used entry was evicted")
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// ProviderOverrideHeader pins a request to one provider.
+	ProviderOverrideHeader = "X-CLIProxy-Provider"
+	// AuthIDOverrideHeader pins a request to one credential.
+	AuthIDOverrideHeader = "X-CLIProxy-Auth-ID"
+	// NoFallbackHeader disables model fallback for a request when set to a true value.
+	NoFallbackHeader = "X-CLIProxy-No-Fallback"
+)
+
+type noFallbackContextKey struct{}
+
+// applyRoutingHeaders applies the routing override headers of the inbound request allowed
+// by routing-headers. Overrides set by the caller, such as a forced provider, take precedence.
+func (h *BaseAPIHandler) applyRoutingHeaders(ctx context.Context, execOptions modelExecutionOptions) (context.Context, modelExecutionOptions) {
+	if h == nil || h.Cfg == nil || !h.Cfg.RoutingHeaders.Enabled || ctx == nil {
+		return ctx, execOptions
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return ctx, execOptions
+	}
+	provider := strings.ToLower(strings.TrimSpace(ginCtx.GetHeader(ProviderOverrideHeader)))
+	authID := strings.TrimSpace(ginCtx.GetHeader(AuthIDOverrideHeader))
+	noFallback := false
+	if raw := strings.TrimSpace(ginCtx.GetHeader(NoFallbackHeader)); raw != "" {
+		parsed, errParse := strconv.ParseBool(raw)
+		noFallback = errParse == nil && parsed
+	}
+	if provider == "" && authID == "" && !noFallback {
+		return ctx, execOptions
+	}
+
+	settings := h.Cfg.RoutingHeaders
+	if !routingHeadersClientAllowed(settings, ginCtx) {
+		log.Debug("routing headers ignored: client API key is not allowed")
+		return ctx, execOptions
+	}
+	if provider != "" && execOptions.ForcedProvider == "" {
+		if routingHeadersProviderAllowed(settings, provider) {
+			execOptions.ForcedProvider = provider
+		} else {
+			log.Debugf("routing headers: %s %q is not allowed", ProviderOverrideHeader, provider)
+		}
+	}
+	if authID != "" && pinnedAuthIDFromContext(ctx) == "" {
+		if settings.AllowAuthID {
+			ctx = WithPinnedAuthID(ctx, authID)
+		} else {
+			log.Debugf("routing headers: %s is not allowed", AuthIDOverrideHeader)
+		}
+	}
+	if noFallback {
+		if settings.AllowNoFallback {
+			execOptions.NoFallback = true
+			ctx = context.WithValue(ctx, noFallbackContextKey{}, true)
+		} else {
+			log.Debugf("routing headers: %s is not allowed", NoFallbackHeader)
+		}
+	}
+	return ctx, execOptions
+}
+
+func routingHeadersClientAllowed(settings config.RoutingHeadersConfig, ginCtx *gin.Context) bool {
+	if len(settings.APIKeys) == 0 {
+		return true
+	}
+	value, exists := ginCtx.Get("userApiKey")
+	if !exists {
+		return false
+	}
+	apiKey, ok := value.(string)
+	return ok && apiKey != "" && slices.Contains(settings.APIKeys, apiKey)
+}
+
+func routingHeadersProviderAllowed(settings config.RoutingHeadersConfig, provider string) bool {
+	if len(settings.Providers) == 0 {
+		return true
+	}
+	for _, allowed := range settings.Providers {
+		if strings.EqualFold(strings.TrimSpace(allowed), provider) {
+			return true
+		}
+	}
+	return false
+}
+
+func noFallbackFromContext(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	raw, ok := ctx.Value(noFallbackContextKey{}).(bool)
+	return ok && raw
+}
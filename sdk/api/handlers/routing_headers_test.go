@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestApplyRoutingHeaders(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{RoutingHeaders: sdkconfig.RoutingHeadersConfig{
+		Enabled:         true,
+		APIKeys:         []string{"debug-key"},
+		Providers:       []string{"gemini"},
+		AllowAuthID:     true,
+		AllowNoFallback: true,
+	}}
+	handler := NewBaseAPIHandlers(cfg, nil)
+	headers := map[string]string{
+		ProviderOverrideHeader: "Gemini",
+		AuthIDOverrideHeader:   "auth-1",
+		NoFallbackHeader:       "true",
+	}
+
+	ctx, opts := handler.applyRoutingHeaders(clientRequestTestContext("debug-key", headers), modelExecutionOptions{})
+	if opts.ForcedProvider != "gemini" || !opts.NoFallback {
+		t.Fatalf("options = %+v, want forced provider gemini and no fallback", opts)
+	}
+	meta := requestExecutionMetadata(ctx)
+	if meta[coreexecutor.PinnedAuthMetadataKey] != "auth-1" || meta[coreexecutor.NoFallbackMetadataKey] != true {
+		t.Fatalf("metadata = %v, want pinned auth-1 and no fallback", meta)
+	}
+
+	_, opts = handler.applyRoutingHeaders(clientRequestTestContext("other-key", headers), modelExecutionOptions{})
+	if opts.ForcedProvider != "" || opts.NoFallback {
+		t.Fatalf("options for disallowed client = %+v, want none", opts)
+	}
+
+	headers[ProviderOverrideHeader] = "claude"
+	_, opts = handler.applyRoutingHeaders(clientRequestTestContext("debug-key", headers), modelExecutionOptions{ForcedProvider: "gemini-interactions"})
+	if opts.ForcedProvider != "gemini-interactions" {
+		t.Fatalf("forced provider = %q, want caller override kept", opts.ForcedProvider)
+	}
+	_, opts = handler.applyRoutingHeaders(clientRequestTestContext("debug-key", headers), modelExecutionOptions{})
+	if opts.ForcedProvider != "" {
+		t.Fatalf("forced provider = %q, want provider outside the allowlist ignored", opts.ForcedProvider)
+	}
+
+	cfg.RoutingHeaders.AllowAuthID = false
+	ctx, _ = handler.applyRoutingHeaders(clientRequestTestContext("debug-key", headers), modelExecutionOptions{})
+	if pinned := pinnedAuthIDFromContext(ctx); pinned != "" {
+		t.Fatalf("pinned auth = %q, want auth ID override ignored", pinned)
+	}
+}
+
+func TestProvidersForExecutionNoFallbackSkipsRouteFallback(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("test-routing-headers-gemini", "gemini", []*registry.ModelInfo{{ID: "haiku"}})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-routing-headers-gemini") })
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.SetFallbackChain([]string{"haiku"}, 5)
+	if _, err := manager.Register(context.Background(), &coreauth.Auth{ID: "test-routing-headers-gemini", Provider: "gemini", Status: coreauth.StatusActive}); err != nil {
+		t.Fatalf("Register auth: %v", err)
+	}
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager)
+
+	if _, model, errMsg := handler.providersForExecution("unknown-model", "unknown-model", false, modelRouteDecision{}, modelExecutionOptions{}); errMsg != nil || model != "haiku" {
+		t.Fatalf("providersForExecution() = %q, %v; want fallback to haiku", model, errMsg)
+	}
+	if _, model, errMsg := handler.providersForExecution("unknown-model", "unknown-model", false, modelRouteDecision{}, modelExecutionOptions{NoFallback: true}); errMsg == nil {
+		t.Fatalf("providersForExecution() with no fallback = %q, want unknown provider error", model)
+	}
+}
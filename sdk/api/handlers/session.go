@@ -0,0 +1,445 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultSessionTTL     = 24 * time.Hour
+	sessionRedisKeyPrefix = "cliproxy:session:"
+	sessionStoreTimeout   = 3 * time.Second
+)
+
+// Session is a conversation kept by the proxy for clients that do not resend history.
+type Session struct {
+	ID        string            `json:"id"`
+	Object    string            `json:"object"`
+	Model     string            `json:"model,omitempty"`
+	CreatedAt int64             `json:"created_at"`
+	UpdatedAt int64             `json:"updated_at"`
+	Messages  []json.RawMessage `json:"messages"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// sessionRecord is a stored session with the hash of the client API key that owns it.
+type sessionRecord struct {
+	Session
+	Owner string `json:"owner"`
+}
+
+// sessionBackend persists session records. get returns nil when the session does not exist.
+type sessionBackend interface {
+	get(ctx context.Context, id string) (*sessionRecord, error)
+	put(ctx context.Context, record *sessionRecord, ttl time.Duration) error
+	delete(ctx context.Context, id string) error
+	close()
+}
+
+// sessionStore serializes read-modify-write updates of sessions on this node.
+type sessionStore struct {
+	mu       sync.Mutex
+	redisURL string
+	backend  sessionBackend
+}
+
+type memorySessionEntry struct {
+	data      []byte
+	expiresAt time.Time
+}
+
+type memorySessionBackend struct {
+	mu      sync.Mutex
+	entries map[string]memorySessionEntry
+}
+
+func newMemorySessionBackend() *memorySessionBackend {
+	return &memorySessionBackend{entries: make(map[string]memorySessionEntry)}
+}
+
+func (b *memorySessionBackend) get(_ context.Context, id string) (*sessionRecord, error) {
+	b.mu.Lock()
+	entry, ok := b.entries[id]
+	if ok && time.Now().After(entry.expiresAt) {
+		delete(b.entries, id)
+		ok = false
+	}
+	b.mu.Unlock()
+	if !ok {
+		return nil, nil
+	}
+	var record sessionRecord
+	if errUnmarshal := json.Unmarshal(entry.data, &record); errUnmarshal != nil {
+		return nil, errUnmarshal
+	}
+	return &record, nil
+}
+
+func (b *memorySessionBackend) put(_ context.Context, record *sessionRecord, ttl time.Duration) error {
+	data, errMarshal := json.Marshal(record)
+	if errMarshal != nil {
+		return errMarshal
+	}
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for id, entry := range b.entries {
+		if now.After(entry.expiresAt) {
+			delete(b.entries, id)
+		}
+	}
+	b.entries[record.ID] = memorySessionEntry{data: data, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (b *memorySessionBackend) delete(_ context.Context, id string) error {
+	b.mu.Lock()
+	delete(b.entries, id)
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *memorySessionBackend) close() {}
+
+type redisSessionBackend struct {
+	client *redis.Client
+}
+
+func newRedisSessionBackend(rawURL string) (*redisSessionBackend, error) {
+	opts, errParse := redis.ParseURL(rawURL)
+	if errParse != nil {
+		return nil, errParse
+	}
+	return &redisSessionBackend{client: redis.NewClient(opts)}, nil
+}
+
+func (b *redisSessionBackend) get(ctx context.Context, id string) (*sessionRecord, error) {
+	ctx, cancel := context.WithTimeout(ctx, sessionStoreTimeout)
+	defer cancel()
+	data, errGet := b.client.Get(ctx, sessionRedisKeyPrefix+id).Bytes()
+	if errors.Is(errGet, redis.Nil) {
+		return nil, nil
+	}
+	if errGet != nil {
+		return nil, errGet
+	}
+	var record sessionRecord
+	if errUnmarshal := json.Unmarshal(data, &record); errUnmarshal != nil {
+		return nil, errUnmarshal
+	}
+	return &record, nil
+}
+
+func (b *redisSessionBackend) put(ctx context.Context, record *sessionRecord, ttl time.Duration) error {
+	data, errMarshal := json.Marshal(record)
+	if errMarshal != nil {
+		return errMarshal
+	}
+	ctx, cancel := context.WithTimeout(ctx, sessionStoreTimeout)
+	defer cancel()
+	return b.client.Set(ctx, sessionRedisKeyPrefix+record.ID, data, ttl).Err()
+}
+
+func (b *redisSessionBackend) delete(ctx context.Context, id string) error {
+	ctx, cancel := context.WithTimeout(ctx, sessionStoreTimeout)
+	defer cancel()
+	return b.client.Del(ctx, sessionRedisKeyPrefix+id).Err()
+}
+
+func (b *redisSessionBackend) close() {
+	if errClose := b.client.Close(); errClose != nil {
+		log.Debugf("sessions: failed to close redis client: %v", errClose)
+	}
+}
+
+// sessionsForConfig returns the active session store, recreating it when the Redis URL
+// changes. It returns nil when sessions are disabled or the store cannot be created.
+func (h *BaseAPIHandler) sessionsForConfig(cfg *config.SDKConfig) *sessionStore {
+	if cfg == nil || !cfg.Sessions.Enabled {
+		return nil
+	}
+	redisURL := strings.TrimSpace(cfg.Sessions.RedisURL)
+	h.sessionsMu.Lock()
+	defer h.sessionsMu.Unlock()
+	if h.sessions != nil && h.sessions.redisURL == redisURL {
+		return h.sessions
+	}
+	var backend sessionBackend = newMemorySessionBackend()
+	if redisURL != "" {
+		redisBackend, errRedis := newRedisSessionBackend(redisURL)
+		if errRedis != nil {
+			log.Errorf("sessions: invalid redis-url: %v", errRedis)
+			return nil
+		}
+		backend = redisBackend
+	}
+	if h.sessions != nil {
+		h.sessions.backend.close()
+	}
+	h.sessions = &sessionStore{redisURL: redisURL, backend: backend}
+	return h.sessions
+}
+
+func sessionTTL(cfg *config.SDKConfig) time.Duration {
+	if cfg != nil {
+		if parsed, errParse := time.ParseDuration(strings.TrimSpace(cfg.Sessions.TTL)); errParse == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultSessionTTL
+}
+
+// sessionOwner identifies the client of the request by a hash of its API key.
+func sessionOwner(ctx context.Context) string {
+	return apiKeyOwner(requestClientAPIKey(ctx))
+}
+
+// apiKeyOwner hashes a client API key into the owner identifier stored with sessions and batches.
+func apiKeyOwner(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// requestClientAPIKey returns the client API key the request authenticated with.
+func requestClientAPIKey(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		if value, exists := ginCtx.Get("userApiKey"); exists {
+			return fmt.Sprint(value)
+		}
+	}
+	return ""
+}
+
+func sessionsDisabledError() *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{StatusCode: http.StatusNotFound, Error: errors.New("session API is not enabled")}
+}
+
+func sessionNotFoundError(id string) *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{StatusCode: http.StatusNotFound, Error: fmt.Errorf("session %s not found", id)}
+}
+
+func sessionStoreError(err error) *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{StatusCode: http.StatusServiceUnavailable, Error: fmt.Errorf("session store unavailable: %w", err)}
+}
+
+func sessionInvalidRequest(format string, args ...any) *interfaces.ErrorMessage {
+	return &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf(format, args...)}
+}
+
+// parseSessionMessages validates a JSON array of OpenAI chat messages.
+func parseSessionMessages(raw gjson.Result) ([]json.RawMessage, *interfaces.ErrorMessage) {
+	if !raw.Exists() {
+		return nil, nil
+	}
+	if !raw.IsArray() {
+		return nil, sessionInvalidRequest("messages must be an array")
+	}
+	items := raw.Array()
+	messages := make([]json.RawMessage, 0, len(items))
+	for i, item := range items {
+		if !item.IsObject() || strings.TrimSpace(item.Get("role").String()) == "" {
+			return nil, sessionInvalidRequest("messages[%d] must be an object with a role", i)
+		}
+		messages = append(messages, json.RawMessage(item.Raw))
+	}
+	return messages, nil
+}
+
+// loadSession returns the session id when it exists and belongs to the client of ctx.
+func (s *sessionStore) loadSession(ctx context.Context, id string) (*sessionRecord, *interfaces.ErrorMessage) {
+	record, errGet := s.backend.get(ctx, id)
+	if errGet != nil {
+		return nil, sessionStoreError(errGet)
+	}
+	if record == nil || record.Owner != sessionOwner(ctx) {
+		return nil, sessionNotFoundError(id)
+	}
+	return record, nil
+}
+
+// appendSessionMessages adds messages to the stored session and returns the updated record.
+func (s *sessionStore) appendSessionMessages(ctx context.Context, id string, messages []json.RawMessage, ttl time.Duration) (*sessionRecord, *interfaces.ErrorMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, errMsg := s.loadSession(ctx, id)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	if len(messages) == 0 {
+		return record, nil
+	}
+	record.Messages = append(record.Messages, messages...)
+	record.UpdatedAt = time.Now().Unix()
+	if errPut := s.backend.put(ctx, record, ttl); errPut != nil {
+		return nil, sessionStoreError(errPut)
+	}
+	return record, nil
+}
+
+// CreateSession stores a new session owned by the client of ctx. body may set model,
+// messages (initial history, e.g. a system prompt) and metadata.
+func (h *BaseAPIHandler) CreateSession(ctx context.Context, body []byte) (*Session, *interfaces.ErrorMessage) {
+	store := h.sessionsForConfig(h.Cfg)
+	if store == nil {
+		return nil, sessionsDisabledError()
+	}
+	messages, errMsg := parseSessionMessages(gjson.GetBytes(body, "messages"))
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	now := time.Now().Unix()
+	record := &sessionRecord{
+		Session: Session{
+			ID:        "sess_" + strings.ReplaceAll(uuid.NewString(), "-", ""),
+			Object:    "session",
+			Model:     strings.TrimSpace(gjson.GetBytes(body, "model").String()),
+			CreatedAt: now,
+			UpdatedAt: now,
+			Messages:  messages,
+		},
+		Owner: sessionOwner(ctx),
+	}
+	if record.Messages == nil {
+		record.Messages = []json.RawMessage{}
+	}
+	gjson.GetBytes(body, "metadata").ForEach(func(key, value gjson.Result) bool {
+		if record.Metadata == nil {
+			record.Metadata = make(map[string]string)
+		}
+		record.Metadata[key.String()] = value.String()
+		return true
+	})
+	if errPut := store.backend.put(ctx, record, sessionTTL(h.Cfg)); errPut != nil {
+		return nil, sessionStoreError(errPut)
+	}
+	out := record.Session
+	return &out, nil
+}
+
+// GetSession returns a session owned by the client of ctx.
+func (h *BaseAPIHandler) GetSession(ctx context.Context, id string) (*Session, *interfaces.ErrorMessage) {
+	store := h.sessionsForConfig(h.Cfg)
+	if store == nil {
+		return nil, sessionsDisabledError()
+	}
+	record, errMsg := store.loadSession(ctx, id)
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	return &record.Session, nil
+}
+
+// DeleteSession removes a session owned by the client of ctx.
+func (h *BaseAPIHandler) DeleteSession(ctx context.Context, id string) *interfaces.ErrorMessage {
+	store := h.sessionsForConfig(h.Cfg)
+	if store == nil {
+		return sessionsDisabledError()
+	}
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if _, errMsg := store.loadSession(ctx, id); errMsg != nil {
+		return errMsg
+	}
+	if errDelete := store.backend.delete(ctx, id); errDelete != nil {
+		return sessionStoreError(errDelete)
+	}
+	return nil
+}
+
+// AppendSessionMessages adds the messages array of body to a session.
+func (h *BaseAPIHandler) AppendSessionMessages(ctx context.Context, id string, body []byte) (*Session, *interfaces.ErrorMessage) {
+	store := h.sessionsForConfig(h.Cfg)
+	if store == nil {
+		return nil, sessionsDisabledError()
+	}
+	messages, errMsg := parseSessionMessages(gjson.GetBytes(body, "messages"))
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	if len(messages) == 0 {
+		return nil, sessionInvalidRequest("messages is required")
+	}
+	record, errMsg := store.appendSessionMessages(ctx, id, messages, sessionTTL(h.Cfg))
+	if errMsg != nil {
+		return nil, errMsg
+	}
+	return &record.Session, nil
+}
+
+// RunSession appends the optional messages of body to a session, runs a chat completion
+// over the stored history and records the assistant reply. Other fields of body (model,
+// temperature, tools, ...) are passed to the completion; the model defaults to the one
+// the session was created with. The conversation goes through the regular request
+// pipeline, so context truncation and history compression apply to it.
+func (h *BaseAPIHandler) RunSession(ctx context.Context, id string, body []byte) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	store := h.sessionsForConfig(h.Cfg)
+	if store == nil {
+		return nil, nil, sessionsDisabledError()
+	}
+	if gjson.GetBytes(body, "stream").Bool() {
+		return nil, nil, sessionInvalidRequest("streaming is not supported for sessions")
+	}
+	messages, errMsg := parseSessionMessages(gjson.GetBytes(body, "messages"))
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	ttl := sessionTTL(h.Cfg)
+	record, errMsg := store.appendSessionMessages(ctx, id, messages, ttl)
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if len(record.Messages) == 0 {
+		return nil, nil, sessionInvalidRequest("session has no messages")
+	}
+	model := strings.TrimSpace(gjson.GetBytes(body, "model").String())
+	if model == "" {
+		model = record.Model
+	}
+	if model == "" {
+		return nil, nil, sessionInvalidRequest("model is required")
+	}
+
+	history, errMarshal := json.Marshal(record.Messages)
+	if errMarshal != nil {
+		return nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: errMarshal}
+	}
+	request := body
+	if len(strings.TrimSpace(string(request))) == 0 {
+		request = []byte(`{}`)
+	}
+	request, _ = sjson.SetBytes(request, "model", model)
+	request, _ = sjson.SetRawBytes(request, "messages", history)
+	request, _ = sjson.DeleteBytes(request, "stream")
+
+	resp, headers, errMsg := h.ExecuteWithAuthManager(ctx, "openai", model, request, "")
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	reply := gjson.GetBytes(resp, "choices.0.message")
+	if reply.IsObject() {
+		if _, errAppend := store.appendSessionMessages(ctx, id, []json.RawMessage{json.RawMessage(reply.Raw)}, ttl); errAppend != nil {
+			log.Warnf("sessions: failed to record reply of session %s: %v", id, errAppend.Error)
+		}
+	}
+	return resp, headers, nil
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestSessionRunUsesStoredHistoryAndRecordsReply(t *testing.T) {
+	executor := &modelExecutionCaptureExecutor{
+		provider: "openai",
+		execute: func(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+			return coreexecutor.Response{Payload: []byte(`{"choices":[{"message":{"role":"assistant","content":"hi there"}}]}`)}, nil
+		},
+	}
+	handler := newModelExecutionHandler(t, "session-model", executor, &sdkconfig.SDKConfig{Sessions: sdkconfig.SessionsConfig{Enabled: true}})
+	owner := clientRequestTestContext("client-a", nil)
+
+	session, errMsg := handler.CreateSession(owner, []byte(`{"model":"session-model","messages":[{"role":"system","content":"be brief"}]}`))
+	if errMsg != nil {
+		t.Fatalf("CreateSession() error = %v", errMsg.Error)
+	}
+	if _, errMsg = handler.AppendSessionMessages(owner, session.ID, []byte(`{"messages":[{"content":"no role"}]}`)); errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("AppendSessionMessages() with invalid message = %+v, want 400", errMsg)
+	}
+
+	resp, _, errMsg := handler.RunSession(owner, session.ID, []byte(`{"messages":[{"role":"user","content":"hello"}],"temperature":0.2}`))
+	if errMsg != nil {
+		t.Fatalf("RunSession() error = %v", errMsg.Error)
+	}
+	if gjson.GetBytes(resp, "choices.0.message.content").String() != "hi there" {
+		t.Fatalf("response = %s", resp)
+	}
+	req, _ := executor.captured()
+	if sent := gjson.GetBytes(req.Payload, "messages.#").Int(); sent != 2 {
+		t.Fatalf("upstream messages = %d, want system and user: %s", sent, req.Payload)
+	}
+	if gjson.GetBytes(req.Payload, "temperature").Float() != 0.2 {
+		t.Fatalf("completion parameters were not forwarded: %s", req.Payload)
+	}
+
+	stored, errMsg := handler.GetSession(owner, session.ID)
+	if errMsg != nil {
+		t.Fatalf("GetSession() error = %v", errMsg.Error)
+	}
+	if len(stored.Messages) != 3 || gjson.GetBytes(stored.Messages[2], "content").String() != "hi there" {
+		t.Fatalf("stored messages = %d, want the assistant reply recorded", len(stored.Messages))
+	}
+
+	other := clientRequestTestContext("client-b", nil)
+	if _, errMsg = handler.GetSession(other, session.ID); errMsg == nil || errMsg.StatusCode != http.StatusNotFound {
+		t.Fatalf("GetSession() by another client = %+v, want 404", errMsg)
+	}
+	if errMsg = handler.DeleteSession(owner, session.ID); errMsg != nil {
+		t.Fatalf("DeleteSession() error = %v", errMsg.Error)
+	}
+	if _, errMsg = handler.GetSession(owner, session.ID); errMsg == nil {
+		t.Fatal("session still exists after delete")
+	}
+}
@@ -44,8 +44,9 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 
 	writeKeepAlive := opts.WriteKeepAlive
 	if writeKeepAlive == nil {
+		heartbeat := StreamingKeepAliveComment(h.Cfg)
 		writeKeepAlive = func() {
-			_, _ = c.Writer.Write([]byte(": keep-alive\n\n"))
+			_, _ = c.Writer.Write(heartbeat)
 		}
 	}
 
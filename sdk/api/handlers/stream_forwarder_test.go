@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestForwardStreamWritesConfiguredKeepAlive(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+
+	cfg := &sdkconfig.SDKConfig{}
+	cfg.Streaming.KeepAliveComment = "ping"
+	handler := NewBaseAPIHandlers(cfg, nil)
+	data := make(chan []byte)
+	errs := make(chan *interfaces.ErrorMessage)
+	interval := 10 * time.Millisecond
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		data <- []byte("data: {}\n\n")
+		close(data)
+	}()
+
+	handler.ForwardStream(c, recorder, func(error) {}, data, errs, StreamForwardOptions{
+		KeepAliveInterval: &interval,
+		WriteChunk:        func(chunk []byte) { _, _ = c.Writer.Write(chunk) },
+	})
+
+	body := recorder.Body.String()
+	if !strings.HasPrefix(body, ": ping\n\n") || !strings.HasSuffix(body, "data: {}\n\n") {
+		t.Fatalf("body = %q, want ping heartbeats before the chunk", body)
+	}
+}
@@ -0,0 +1,351 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// streamResumeInstruction asks the model to continue a response that was cut off.
+const streamResumeInstruction = "Your previous response was interrupted. Continue it exactly where it stopped, without repeating any text already written and without commentary."
+
+// streamResumer tracks the text a stream has delivered so that, when the upstream fails
+// mid-response, the request can be re-issued with that text as context and the second
+// stream stitched onto the first. Only plain text is resumable: once a tool call or the
+// end of the message was sent, the stream is left to fail as before.
+type streamResumer struct {
+	protocol  string
+	request   []byte
+	remaining int
+
+	text      strings.Builder
+	resumable bool
+	resuming  bool
+
+	// excluded lists the credentials whose streams failed; metadata is that of the latest attempt.
+	excluded []string
+	metadata map[string]any
+
+	// OpenAI chat: identity of the first stream, reused for stitched chunks.
+	chunkID string
+
+	// Claude: the open text block of the first stream and index remapping for the next one.
+	openTextIndex int
+	textMerged    bool
+	nextIndex     int
+	indexMap      map[int]int
+	dropped       map[int]bool
+}
+
+// newStreamResumer returns nil when resume is disabled or unsupported for the protocol.
+func newStreamResumer(attempts int, entryProtocol, responseProtocol string, rawJSON []byte) *streamResumer {
+	if attempts <= 0 || entryProtocol != responseProtocol || len(rawJSON) == 0 {
+		return nil
+	}
+	switch responseProtocol {
+	case "openai", "claude", "gemini":
+	default:
+		return nil
+	}
+	return &streamResumer{
+		protocol:      responseProtocol,
+		request:       cloneBytes(rawJSON),
+		remaining:     attempts,
+		resumable:     true,
+		openTextIndex: -1,
+	}
+}
+
+// canResume reports whether err may be recovered by continuing on another credential.
+func (r *streamResumer) canResume(err error) bool {
+	if r == nil || r.remaining <= 0 || !r.resumable || err == nil {
+		return false
+	}
+	switch status := statusFromError(err); {
+	case status == 0, status == http.StatusRequestTimeout, status == http.StatusTooManyRequests:
+		return true
+	default:
+		return status >= http.StatusInternalServerError
+	}
+}
+
+// continuation builds the follow-up request: the original request plus the text already
+// delivered, as an assistant prefill for Claude and with a continue instruction otherwise.
+func (r *streamResumer) continuation() []byte {
+	text := r.text.String()
+	if strings.TrimSpace(text) == "" {
+		return cloneBytes(r.request)
+	}
+	out := cloneBytes(r.request)
+	var errSet error
+	switch r.protocol {
+	case "openai":
+		out, errSet = sjson.SetBytes(out, "messages.-1", map[string]any{"role": "assistant", "content": text})
+		if errSet == nil {
+			out, errSet = sjson.SetBytes(out, "messages.-1", map[string]any{"role": "user", "content": streamResumeInstruction})
+		}
+	case "claude":
+		// Claude continues a trailing assistant turn natively but rejects trailing whitespace.
+		prefill := strings.TrimRight(text, " \t\r\n")
+		out, errSet = sjson.SetBytes(out, "messages.-1", map[string]any{
+			"role":    "assistant",
+			"content": []any{map[string]any{"type": "text", "text": prefill}},
+		})
+	case "gemini":
+		out, errSet = sjson.SetBytes(out, "contents.-1", map[string]any{"role": "model", "parts": []any{map[string]any{"text": text}}})
+		if errSet == nil {
+			out, errSet = sjson.SetBytes(out, "contents.-1", map[string]any{"role": "user", "parts": []any{map[string]any{"text": streamResumeInstruction}}})
+		}
+	}
+	if errSet != nil {
+		return cloneBytes(r.request)
+	}
+	return out
+}
+
+// begin switches to stitching mode for the stream that continues the response.
+func (r *streamResumer) begin() {
+	r.remaining--
+	r.resuming = true
+	r.textMerged = false
+	r.indexMap = make(map[int]int)
+	r.dropped = make(map[int]bool)
+}
+
+// observe records a payload delivered to the client.
+func (r *streamResumer) observe(payload []byte) {
+	if r == nil || !r.resumable {
+		return
+	}
+	switch r.protocol {
+	case "openai":
+		r.observeOpenAI(sseJSON(payload))
+	case "gemini":
+		r.observeGemini(sseJSON(payload))
+	case "claude":
+		for _, event := range splitSSEEvents(payload) {
+			r.observeClaude(event.data)
+		}
+	}
+}
+
+// stitch adapts a payload of the continuing stream so the client sees one response.
+// It returns nil for payloads that must be dropped.
+func (r *streamResumer) stitch(payload []byte) []byte {
+	if r == nil || !r.resuming {
+		return payload
+	}
+	switch r.protocol {
+	case "openai":
+		data := sseJSON(payload)
+		if data == nil {
+			return payload
+		}
+		if r.chunkID != "" {
+			data, _ = sjson.SetBytes(data, "id", r.chunkID)
+		}
+		data, _ = sjson.DeleteBytes(data, "choices.0.delta.role")
+		return data
+	case "claude":
+		return r.stitchClaude(payload)
+	}
+	return payload
+}
+
+func (r *streamResumer) observeOpenAI(data []byte) {
+	if data == nil {
+		return
+	}
+	if r.chunkID == "" {
+		r.chunkID = gjson.GetBytes(data, "id").String()
+	}
+	choice := gjson.GetBytes(data, "choices.0")
+	if choice.Get("delta.tool_calls").Exists() || choice.Get("delta.function_call").Exists() ||
+		(choice.Get("finish_reason").Exists() && choice.Get("finish_reason").Type != gjson.Null) {
+		r.resumable = false
+		return
+	}
+	r.text.WriteString(choice.Get("delta.content").String())
+}
+
+func (r *streamResumer) observeGemini(data []byte) {
+	if data == nil {
+		return
+	}
+	candidate := gjson.GetBytes(data, "candidates.0")
+	for _, part := range candidate.Get("content.parts").Array() {
+		if part.Get("functionCall").Exists() {
+			r.resumable = false
+			return
+		}
+		if !part.Get("thought").Bool() {
+			r.text.WriteString(part.Get("text").String())
+		}
+	}
+	if candidate.Get("finishReason").String() != "" {
+		r.resumable = false
+	}
+}
+
+func (r *streamResumer) observeClaude(data []byte) {
+	if data == nil {
+		return
+	}
+	index := int(gjson.GetBytes(data, "index").Int())
+	switch gjson.GetBytes(data, "type").String() {
+	case "content_block_start":
+		if index >= r.nextIndex {
+			r.nextIndex = index + 1
+		}
+		switch gjson.GetBytes(data, "content_block.type").String() {
+		case "text":
+			r.openTextIndex = index
+		case "thinking", "redacted_thinking":
+		default:
+			r.resumable = false
+		}
+	case "content_block_delta":
+		if gjson.GetBytes(data, "delta.type").String() == "text_delta" {
+			r.text.WriteString(gjson.GetBytes(data, "delta.text").String())
+		}
+	case "content_block_stop":
+		if index == r.openTextIndex {
+			r.openTextIndex = -1
+		}
+	case "message_delta", "message_stop":
+		r.resumable = false
+	}
+}
+
+// stitchClaude drops the second message_start and thinking blocks, continues the first
+// stream's open text block and renumbers the remaining content blocks.
+func (r *streamResumer) stitchClaude(payload []byte) []byte {
+	var out bytes.Buffer
+	for _, event := range splitSSEEvents(payload) {
+		data := event.data
+		if data == nil {
+			out.Write(event.raw)
+			continue
+		}
+		index := int(gjson.GetBytes(data, "index").Int())
+		switch gjson.GetBytes(data, "type").String() {
+		case "message_start":
+			continue
+		case "content_block_start":
+			switch gjson.GetBytes(data, "content_block.type").String() {
+			case "thinking", "redacted_thinking":
+				r.dropped[index] = true
+				continue
+			case "text":
+				if r.openTextIndex >= 0 && !r.textMerged {
+					r.indexMap[index] = r.openTextIndex
+					r.textMerged = true
+					continue
+				}
+			}
+			r.indexMap[index] = r.nextIndex
+			r.nextIndex++
+		case "content_block_delta", "content_block_stop":
+			if r.dropped[index] {
+				continue
+			}
+		}
+		if mapped, ok := r.indexMap[index]; ok && gjson.GetBytes(data, "index").Exists() {
+			data, _ = sjson.SetBytes(data, "index", mapped)
+		}
+		if event.name != "" {
+			out.WriteString("event: " + event.name + "\n")
+		}
+		out.WriteString("data: ")
+		out.Write(data)
+		out.WriteString("\n\n")
+	}
+	if out.Len() == 0 {
+		return nil
+	}
+	return out.Bytes()
+}
+
+type sseEvent struct {
+	name string
+	data []byte
+	raw  []byte
+}
+
+// splitSSEEvents splits a payload into events; data is nil for events without JSON data.
+func splitSSEEvents(payload []byte) []sseEvent {
+	var events []sseEvent
+	for _, block := range bytes.Split(payload, []byte("\n\n")) {
+		if len(bytes.TrimSpace(block)) == 0 {
+			continue
+		}
+		event := sseEvent{raw: append(cloneBytes(block), '\n', '\n')}
+		for _, line := range bytes.Split(block, []byte("\n")) {
+			line = bytes.TrimSpace(line)
+			switch {
+			case bytes.HasPrefix(line, []byte("event:")):
+				event.name = string(bytes.TrimSpace(line[len("event:"):]))
+			case bytes.HasPrefix(line, []byte("data:")):
+				if data := bytes.TrimSpace(line[len("data:"):]); gjson.ValidBytes(data) {
+					event.data = cloneBytes(data)
+				}
+			}
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// sseJSON returns the JSON body of a chunk that may or may not carry a "data:" prefix.
+func sseJSON(payload []byte) []byte {
+	data := bytes.TrimSpace(payload)
+	if bytes.HasPrefix(data, []byte("data:")) {
+		data = bytes.TrimSpace(data[len("data:"):])
+	}
+	if !gjson.ValidBytes(data) {
+		return nil
+	}
+	return data
+}
+
+// resumeStream re-issues a failed stream on another credential. It returns the chunks of
+// the continuing stream, or false when the failure must be reported to the client.
+func (h *BaseAPIHandler) resumeStream(ctx context.Context, resumer *streamResumer, streamErr error, providers []string, req coreexecutor.Request, opts coreexecutor.Options) (<-chan coreexecutor.StreamChunk, bool) {
+	if !resumer.canResume(streamErr) || (ctx != nil && ctx.Err() != nil) {
+		return nil, false
+	}
+	payload := resumer.continuation()
+	resumeReq := req
+	resumeReq.Payload = payload
+	resumeOpts := opts
+	resumeOpts.OriginalRequest = payload
+	metadata := resumer.metadata
+	if metadata == nil {
+		metadata = opts.Metadata
+	}
+	if failedAuth, _ := metadata[coreexecutor.SelectedAuthMetadataKey].(string); failedAuth != "" {
+		resumer.excluded = append(resumer.excluded, failedAuth)
+	}
+	resumeOpts.Metadata = make(map[string]any, len(opts.Metadata)+1)
+	for key, value := range opts.Metadata {
+		resumeOpts.Metadata[key] = value
+	}
+	delete(resumeOpts.Metadata, coreexecutor.SelectedAuthMetadataKey)
+	resumeOpts.Metadata[coreexecutor.ExcludedAuthsMetadataKey] = append([]string(nil), resumer.excluded...)
+
+	result, errResume := h.AuthManager.ExecuteStream(ctx, providers, resumeReq, resumeOpts)
+	if errResume != nil || result == nil || result.Chunks == nil {
+		log.WithError(errResume).Warn("stream resume failed, reporting the original error")
+		return nil, false
+	}
+	log.WithError(streamErr).Infof("stream failed mid-response, resumed on another credential after %d characters", resumer.text.Len())
+	resumer.metadata = resumeOpts.Metadata
+	resumer.begin()
+	return result.Chunks, true
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+)
+
+func TestStreamResumerClaudeContinuesOpenTextBlock(t *testing.T) {
+	resumer := newStreamResumer(1, "claude", "claude", []byte(`{"model":"claude-sonnet-4","messages":[{"role":"user","content":"hi"}]}`))
+	resumer.observe([]byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\"}}\n\n"))
+	resumer.observe([]byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"thinking\"}}\n\n"))
+	resumer.observe([]byte("event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n"))
+	resumer.observe([]byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n" +
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hello, \"}}\n\n"))
+
+	if !resumer.canResume(errors.New("connection reset")) {
+		t.Fatal("expected a transport error to be resumable")
+	}
+	if resumer.canResume(&coreauth.Error{HTTPStatus: http.StatusBadRequest, Message: "bad request"}) {
+		t.Fatal("client errors must not be resumed")
+	}
+	continuation := resumer.continuation()
+	if got := gjson.GetBytes(continuation, "messages.1.content.0.text").String(); got != "Hello," {
+		t.Fatalf("assistant prefill = %q, want %q", got, "Hello,")
+	}
+
+	resumer.begin()
+	if out := resumer.stitch([]byte("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_2\"}}\n\n")); out != nil {
+		t.Fatalf("second message_start should be dropped, got %q", out)
+	}
+	out := string(resumer.stitch([]byte("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n" +
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\" world\"}}\n\n" +
+		"event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n" +
+		"event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n")))
+	if strings.Count(out, "content_block_start") != 2 {
+		t.Fatalf("the resumed text block should merge into the open one, got %q", out)
+	}
+	if !strings.Contains(out, `"index":1,"delta"`) || !strings.Contains(out, `{"type":"content_block_stop","index":1}`) {
+		t.Fatalf("resumed text should continue block 1, got %q", out)
+	}
+	if !strings.Contains(out, `{"type":"content_block_start","index":2`) {
+		t.Fatalf("later blocks should be renumbered after the first stream, got %q", out)
+	}
+	if resumer.canResume(errors.New("again")) {
+		t.Fatal("resume attempts should be exhausted")
+	}
+}
+
+func TestStreamResumerOpenAI(t *testing.T) {
+	resumer := newStreamResumer(2, "openai", "openai", []byte(`{"model":"gpt-5","messages":[{"role":"user","content":"hi"}]}`))
+	resumer.observe([]byte(`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`))
+	resumer.observe([]byte(`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"content":"lo"}}]}`))
+
+	continuation := resumer.continuation()
+	if got := gjson.GetBytes(continuation, "messages.1.content").String(); got != "Hello" {
+		t.Fatalf("assistant message = %q", got)
+	}
+	if got := gjson.GetBytes(continuation, "messages.2.role").String(); got != "user" {
+		t.Fatalf("expected a continue instruction, got role %q", got)
+	}
+
+	resumer.begin()
+	out := resumer.stitch([]byte(`{"id":"chatcmpl-2","choices":[{"index":0,"delta":{"role":"assistant","content":" there"}}]}`))
+	if gjson.GetBytes(out, "id").String() != "chatcmpl-1" || gjson.GetBytes(out, "choices.0.delta.role").Exists() {
+		t.Fatalf("stitched chunk = %s", out)
+	}
+
+	resumer.observe([]byte(`{"id":"chatcmpl-1","choices":[{"index":0,"delta":{"tool_calls":[{"index":0}]}}]}`))
+	if resumer.canResume(errors.New("reset")) {
+		t.Fatal("a stream that emitted tool calls must not be resumed")
+	}
+}
+
+func TestNewStreamResumerRequiresMatchingProtocols(t *testing.T) {
+	if newStreamResumer(1, "openai", "claude", []byte(`{}`)) != nil {
+		t.Fatal("translated streams should not be resumed")
+	}
+	if newStreamResumer(0, "claude", "claude", []byte(`{}`)) != nil {
+		t.Fatal("resume should be disabled by default")
+	}
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/pluginapi"
+)
+
+// TransformMiddleware rewrites traffic in-process for embedders that do not want to ship a
+// plugin, e.g. PII redaction, system-prompt injection or stop-sequence enforcement.
+// Middlewares run in registration order after any plugin interceptors and use the same
+// request/response contract: returned Headers replace matching headers, ClearHeaders removes
+// headers, and Body replaces the payload only when non-empty.
+type TransformMiddleware interface {
+	// MutateRequest runs on the client request before it is translated for the upstream provider.
+	MutateRequest(context.Context, pluginapi.RequestInterceptRequest) pluginapi.RequestInterceptResponse
+	// FilterResponse runs on successful non-streaming responses after executor output is translated.
+	FilterResponse(context.Context, pluginapi.ResponseInterceptRequest) pluginapi.ResponseInterceptResponse
+	// FilterStreamChunk runs on each translated stream chunk, and once with
+	// ChunkIndex == pluginapi.StreamChunkHeaderInitIndex before the first chunk to adjust headers.
+	FilterStreamChunk(context.Context, pluginapi.StreamChunkInterceptRequest) pluginapi.StreamChunkInterceptResponse
+}
+
+// TransformFuncs adapts optional functions to TransformMiddleware. Nil functions pass traffic through.
+type TransformFuncs struct {
+	Request     func(context.Context, pluginapi.RequestInterceptRequest) pluginapi.RequestInterceptResponse
+	Response    func(context.Context, pluginapi.ResponseInterceptRequest) pluginapi.ResponseInterceptResponse
+	StreamChunk func(context.Context, pluginapi.StreamChunkInterceptRequest) pluginapi.StreamChunkInterceptResponse
+}
+
+// MutateRequest implements TransformMiddleware.
+func (f TransformFuncs) MutateRequest(ctx context.Context, req pluginapi.RequestInterceptRequest) pluginapi.RequestInterceptResponse {
+	if f.Request == nil {
+		return pluginapi.RequestInterceptResponse{}
+	}
+	return f.Request(ctx, req)
+}
+
+// FilterResponse implements TransformMiddleware.
+func (f TransformFuncs) FilterResponse(ctx context.Context, req pluginapi.ResponseInterceptRequest) pluginapi.ResponseInterceptResponse {
+	if f.Response == nil {
+		return pluginapi.ResponseInterceptResponse{}
+	}
+	return f.Response(ctx, req)
+}
+
+// FilterStreamChunk implements TransformMiddleware.
+func (f TransformFuncs) FilterStreamChunk(ctx context.Context, req pluginapi.StreamChunkInterceptRequest) pluginapi.StreamChunkInterceptResponse {
+	if f.StreamChunk == nil {
+		return pluginapi.StreamChunkInterceptResponse{}
+	}
+	return f.StreamChunk(ctx, req)
+}
+
+// UseTransformMiddleware appends middlewares to the handler chain. Register them before the
+// server starts serving; the chain is not synchronized for concurrent registration.
+func (h *BaseAPIHandler) UseTransformMiddleware(middlewares ...TransformMiddleware) {
+	if h == nil {
+		return
+	}
+	for _, middleware := range middlewares {
+		if !isNilInterface(middleware) {
+			h.transforms = append(h.transforms, middleware)
+		}
+	}
+}
+
+// transformInterceptorHost chains embedder middlewares after the plugin interceptor host.
+// The plugin host returns complete header sets, which replace the current headers just as
+// the handlers treat them; middleware results are merged like individual plugin results.
+type transformInterceptorHost struct {
+	base        PluginInterceptorHost
+	middlewares []TransformMiddleware
+}
+
+func (t *transformInterceptorHost) InterceptRequestBeforeAuth(ctx context.Context, req pluginapi.RequestInterceptRequest) pluginapi.RequestInterceptResponse {
+	return t.InterceptRequestBeforeAuthExcept(ctx, req, "")
+}
+
+func (t *transformInterceptorHost) InterceptRequestBeforeAuthExcept(ctx context.Context, req pluginapi.RequestInterceptRequest, skipPluginID string) pluginapi.RequestInterceptResponse {
+	current := pluginapi.RequestInterceptResponse{Headers: cloneHeader(req.Headers), Body: cloneBytes(req.Body)}
+	if t.base != nil {
+		resp := interceptRequestBeforeAuth(ctx, t.base, req, skipPluginID)
+		current.Headers = finalInterceptorHeaders(current.Headers, resp.Headers)
+		if len(resp.Body) > 0 {
+			current.Body = cloneBytes(resp.Body)
+		}
+	}
+	for _, middleware := range t.middlewares {
+		next := req
+		next.Headers = cloneHeader(current.Headers)
+		next.Body = cloneBytes(current.Body)
+		current = applyRequestTransform(current, middleware.MutateRequest(ctx, next))
+	}
+	return current
+}
+
+func (t *transformInterceptorHost) InterceptRequestAfterAuth(ctx context.Context, req pluginapi.RequestInterceptRequest) pluginapi.RequestInterceptResponse {
+	return t.InterceptRequestAfterAuthExcept(ctx, req, "")
+}
+
+func (t *transformInterceptorHost) InterceptRequestAfterAuthExcept(ctx context.Context, req pluginapi.RequestInterceptRequest, skipPluginID string) pluginapi.RequestInterceptResponse {
+	if t.base == nil {
+		return pluginapi.RequestInterceptResponse{}
+	}
+	return interceptRequestAfterAuth(ctx, t.base, req, skipPluginID)
+}
+
+func (t *transformInterceptorHost) InterceptResponse(ctx context.Context, req pluginapi.ResponseInterceptRequest) pluginapi.ResponseInterceptResponse {
+	return t.InterceptResponseExcept(ctx, req, "")
+}
+
+func (t *transformInterceptorHost) InterceptResponseExcept(ctx context.Context, req pluginapi.ResponseInterceptRequest, skipPluginID string) pluginapi.ResponseInterceptResponse {
+	current := pluginapi.ResponseInterceptResponse{Headers: cloneHeader(req.ResponseHeaders), Body: cloneBytes(req.Body)}
+	if t.base != nil {
+		resp := interceptResponse(ctx, t.base, req, skipPluginID)
+		current.Headers = finalInterceptorHeaders(current.Headers, resp.Headers)
+		if len(resp.Body) > 0 {
+			current.Body = cloneBytes(resp.Body)
+		}
+	}
+	for _, middleware := range t.middlewares {
+		next := req
+		next.ResponseHeaders = cloneHeader(current.Headers)
+		next.Body = cloneBytes(current.Body)
+		resp := middleware.FilterResponse(ctx, next)
+		current.Headers = mergeTransformHeaders(current.Headers, resp.Headers, resp.ClearHeaders)
+		if len(resp.Body) > 0 {
+			current.Body = cloneBytes(resp.Body)
+		}
+	}
+	return current
+}
+
+func (t *transformInterceptorHost) InterceptStreamChunk(ctx context.Context, req pluginapi.StreamChunkInterceptRequest) pluginapi.StreamChunkInterceptResponse {
+	return t.InterceptStreamChunkExcept(ctx, req, "")
+}
+
+func (t *transformInterceptorHost) InterceptStreamChunkExcept(ctx context.Context, req pluginapi.StreamChunkInterceptRequest, skipPluginID string) pluginapi.StreamChunkInterceptResponse {
+	current := pluginapi.StreamChunkInterceptResponse{Headers: cloneHeader(req.ResponseHeaders), Body: cloneBytes(req.Body)}
+	if streamInterceptorsEnabled(t.base) {
+		resp := interceptStreamChunk(ctx, t.base, req, skipPluginID)
+		current.Headers = finalInterceptorHeaders(current.Headers, resp.Headers)
+		if len(resp.Body) > 0 {
+			current.Body = cloneBytes(resp.Body)
+		}
+		current.DropChunk = resp.DropChunk
+	}
+	for _, middleware := range t.middlewares {
+		if current.DropChunk {
+			break
+		}
+		next := req
+		next.ResponseHeaders = cloneHeader(current.Headers)
+		next.Body = cloneBytes(current.Body)
+		current = applyStreamTransform(current, middleware.FilterStreamChunk(ctx, next))
+	}
+	return current
+}
+
+// HasStreamInterceptors reports true because every middleware may filter stream chunks.
+func (t *transformInterceptorHost) HasStreamInterceptors() bool {
+	return true
+}
+
+// HasRequestInterceptors reports whether after-auth interception is needed; middlewares only
+// mutate requests before translation, so this follows the plugin host.
+func (t *transformInterceptorHost) HasRequestInterceptors() bool {
+	return requestInterceptorsEnabled(t.base)
+}
+
+func applyRequestTransform(current, resp pluginapi.RequestInterceptResponse) pluginapi.RequestInterceptResponse {
+	current.Headers = mergeTransformHeaders(current.Headers, resp.Headers, resp.ClearHeaders)
+	if len(resp.Body) > 0 {
+		current.Body = cloneBytes(resp.Body)
+	}
+	return current
+}
+
+func applyStreamTransform(current, resp pluginapi.StreamChunkInterceptResponse) pluginapi.StreamChunkInterceptResponse {
+	current.Headers = mergeTransformHeaders(current.Headers, resp.Headers, resp.ClearHeaders)
+	if len(resp.Body) > 0 {
+		current.Body = cloneBytes(resp.Body)
+	}
+	current.DropChunk = current.DropChunk || resp.DropChunk
+	return current
+}
+
+func mergeTransformHeaders(current, updates http.Header, clear []string) http.Header {
+	out := cloneHeader(current)
+	if out == nil {
+		out = make(http.Header)
+	}
+	for _, key := range clear {
+		out.Del(key)
+	}
+	for key, values := range updates {
+		out.Del(key)
+		for _, value := range values {
+			out.Add(key, value)
+		}
+	}
+	return out
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/pluginapi"
+)
+
+func TestTransformMiddlewareRewritesRequestAndResponse(t *testing.T) {
+	model := "handler-transform-middleware-model"
+	executor := &interceptorCaptureExecutor{
+		execute: func(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (coreexecutor.Response, error) {
+			return coreexecutor.Response{Payload: []byte(`{"text":"call 555-0100"}`)}, nil
+		},
+	}
+	handler := newInterceptorHandler(t, model, executor, &sdkconfig.SDKConfig{})
+	handler.SetPluginHost(&handlerInterceptorTestHost{
+		interceptRequestBeforeAuth: func(ctx context.Context, req pluginapi.RequestInterceptRequest) pluginapi.RequestInterceptResponse {
+			return pluginapi.RequestInterceptResponse{Body: bytes.Replace(req.Body, []byte(`}`), []byte(`,"plugin":true}`), 1)}
+		},
+	})
+	handler.UseTransformMiddleware(TransformFuncs{
+		Request: func(ctx context.Context, req pluginapi.RequestInterceptRequest) pluginapi.RequestInterceptResponse {
+			if !bytes.Contains(req.Body, []byte(`"plugin":true`)) {
+				t.Fatalf("middleware ran before the plugin host: %s", req.Body)
+			}
+			return pluginapi.RequestInterceptResponse{Body: bytes.Replace(req.Body, []byte(`}`), []byte(`,"system":"be brief"}`), 1)}
+		},
+		Response: func(ctx context.Context, req pluginapi.ResponseInterceptRequest) pluginapi.ResponseInterceptResponse {
+			return pluginapi.ResponseInterceptResponse{Body: bytes.ReplaceAll(req.Body, []byte("555-0100"), []byte("[redacted]"))}
+		},
+	})
+
+	body, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", model, []byte(fmt.Sprintf(`{"model":%q}`, model)), "")
+	if errMsg != nil {
+		t.Fatalf("ExecuteWithAuthManager() error = %+v", errMsg)
+	}
+	if string(body) != `{"text":"call [redacted]"}` {
+		t.Fatalf("body = %s, want redacted response", body)
+	}
+	gotReq, _ := executor.captured()
+	want := fmt.Sprintf(`{"model":%q,"plugin":true,"system":"be brief"}`, model)
+	if string(gotReq.Payload) != want {
+		t.Fatalf("executor payload = %s, want %s", gotReq.Payload, want)
+	}
+}
+
+func TestTransformMiddlewareFiltersStreamChunks(t *testing.T) {
+	model := "handler-transform-middleware-stream-model"
+	executor := &interceptorCaptureExecutor{
+		stream: func(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+			chunks := make(chan coreexecutor.StreamChunk, 3)
+			chunks <- coreexecutor.StreamChunk{Payload: []byte("one ")}
+			chunks <- coreexecutor.StreamChunk{Payload: []byte("STOP ")}
+			chunks <- coreexecutor.StreamChunk{Payload: []byte("two")}
+			close(chunks)
+			return &coreexecutor.StreamResult{Chunks: chunks}, nil
+		},
+	}
+	handler := newInterceptorHandler(t, model, executor, &sdkconfig.SDKConfig{})
+	stopped := false
+	handler.UseTransformMiddleware(TransformFuncs{
+		StreamChunk: func(ctx context.Context, req pluginapi.StreamChunkInterceptRequest) pluginapi.StreamChunkInterceptResponse {
+			if req.ChunkIndex == pluginapi.StreamChunkHeaderInitIndex {
+				return pluginapi.StreamChunkInterceptResponse{}
+			}
+			if stopped || bytes.Contains(req.Body, []byte("STOP")) {
+				stopped = true
+				return pluginapi.StreamChunkInterceptResponse{DropChunk: true}
+			}
+			return pluginapi.StreamChunkInterceptResponse{}
+		},
+	})
+
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", model, []byte(fmt.Sprintf(`{"model":%q}`, model)), "")
+	var got []byte
+	for chunk := range dataChan {
+		got = append(got, chunk...)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected stream error: %+v", msg)
+		}
+	}
+	if string(got) != "one " {
+		t.Fatalf("stream payload = %q, want chunks before the stop sequence", got)
+	}
+}
@@ -5,12 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/antigravity"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/oauthcallback"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/browser"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
@@ -77,15 +77,17 @@ func (AntigravityAuthenticator) Login(ctx context.Context, cfg *config.Config, o
 		return nil, fmt.Errorf("antigravity: failed to generate state: %w", errState)
 	}
 
-	srv, port, cbChan, errServer := startAntigravityCallbackServer(callbackPort)
+	registration, cbChan, errServer := startAntigravityCallbackServer(oauthcallback.FromConfig(cfg), callbackPort, state)
 	if errServer != nil {
 		return nil, fmt.Errorf("antigravity: failed to start callback server: %w", errServer)
 	}
 	defer func() {
 		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
 		defer cancel()
-		_ = srv.Shutdown(shutdownCtx)
+		_ = registration.Close(shutdownCtx)
 	}()
+	port := registration.Port()
+	listenerErr := registration.Err()
 
 	redirectURI := fmt.Sprintf("http://localhost:%d/oauth-callback", port)
 	authURL := authSvc.BuildAuthURL(state, redirectURI, pkceCodes)
@@ -115,7 +117,7 @@ func (AntigravityAuthenticator) Login(ctx context.Context, cfg *config.Config, o
 	var manualPromptTimer *time.Timer
 	var manualPromptC <-chan time.Time
 	if opts.Prompt != nil {
-		manualPromptTimer = time.NewTimer(15 * time.Second)
+		manualPromptTimer = time.NewTimer(manualCallbackDelay(opts))
 		manualPromptC = manualPromptTimer.C
 		defer manualPromptTimer.Stop()
 	}
@@ -129,6 +131,8 @@ waitForCallback:
 		case res := <-cbChan:
 			cbRes = res
 			break waitForCallback
+		case errListener := <-listenerErr:
+			return nil, fmt.Errorf("antigravity: callback server: %w", errListener)
 		case <-manualPromptC:
 			manualPromptC = nil
 			if manualPromptTimer != nil {
@@ -252,45 +256,37 @@ type callbackResult struct {
 	State string
 }
 
-func startAntigravityCallbackServer(port int) (*http.Server, int, <-chan callbackResult, error) {
+func startAntigravityCallbackServer(settings oauthcallback.Settings, port int, state string) (*oauthcallback.Registration, <-chan callbackResult, error) {
 	if port <= 0 {
 		port = antigravityCallbackPort
 	}
-	addr := fmt.Sprintf(":%d", port)
-	listener, err := net.Listen("tcp", addr)
-	if err != nil {
-		return nil, 0, nil, err
-	}
-	port = listener.Addr().(*net.TCPAddr).Port
 	resultCh := make(chan callbackResult, 1)
-
-	mux := http.NewServeMux()
-	mux.HandleFunc("/oauth-callback", func(w http.ResponseWriter, r *http.Request) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		q := r.URL.Query()
 		res := callbackResult{
 			Code:  strings.TrimSpace(q.Get("code")),
 			Error: strings.TrimSpace(q.Get("error")),
 			State: strings.TrimSpace(q.Get("state")),
 		}
-		resultCh <- res
+		select {
+		case resultCh <- res:
+		default:
+		}
 		if res.Code != "" && res.Error == "" {
-			_, _ = w.Write([]byte("<h1>Login successful</h1><p>You can close this window.</p>"))
+			if !settings.WriteSuccessPage(w) {
+				_, _ = w.Write([]byte("<h1>Login successful</h1><p>You can close this window.</p>"))
+			}
 		} else {
 			_, _ = w.Write([]byte("<h1>Login failed</h1><p>Please check the CLI output.</p>"))
 		}
 	})
-
-	srv := &http.Server{Handler: mux}
-	go func() {
-		if errServe := srv.Serve(listener); errServe != nil && !strings.Contains(errServe.Error(), "Server closed") {
-			log.Warnf("antigravity callback server error: %v", errServe)
-		}
-	}()
-
-	return srv, port, resultCh, nil
+	registration, errRegister := oauthcallback.Register(settings, port, "/oauth-callback", state, handler)
+	if errRegister != nil {
+		return nil, nil, errRegister
+	}
+	return registration, resultCh, nil
 }
 
-
 func sanitizeAntigravityFileName(email string) string {
 	if strings.TrimSpace(email) == "" {
 		return "antigravity.json"
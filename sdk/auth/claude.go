@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/claude"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/oauthcallback"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/browser"
 	// legacy client removed
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
@@ -61,20 +62,25 @@ func (a *ClaudeAuthenticator) Login(ctx context.Context, cfg *config.Config, opt
 		return nil, fmt.Errorf("claude state generation failed: %w", err)
 	}
 
-	oauthServer := claude.NewOAuthServer(callbackPort)
+	oauthServer := claude.NewOAuthServer(callbackPort).Configure(oauthcallback.FromConfig(cfg), state)
 	if err = oauthServer.Start(); err != nil {
-		if strings.Contains(err.Error(), "already in use") {
-			return nil, claude.NewAuthenticationError(claude.ErrPortInUse, err)
+		if !skipCallbackServer(opts, err) {
+			if strings.Contains(err.Error(), "already in use") {
+				return nil, claude.NewAuthenticationError(claude.ErrPortInUse, err)
+			}
+			return nil, claude.NewAuthenticationError(claude.ErrServerStartFailed, err)
 		}
-		return nil, claude.NewAuthenticationError(claude.ErrServerStartFailed, err)
+		oauthServer = nil
+	}
+	if oauthServer != nil {
+		defer func() {
+			stopCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			if stopErr := oauthServer.Stop(stopCtx); stopErr != nil {
+				log.Warnf("claude oauth server stop error: %v", stopErr)
+			}
+		}()
 	}
-	defer func() {
-		stopCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-		if stopErr := oauthServer.Stop(stopCtx); stopErr != nil {
-			log.Warnf("claude oauth server stop error: %v", stopErr)
-		}
-	}()
 
 	authSvc := claude.NewClaudeAuth(cfg)
 
@@ -106,20 +112,22 @@ func (a *ClaudeAuthenticator) Login(ctx context.Context, cfg *config.Config, opt
 	callbackErrCh := make(chan error, 1)
 	manualDescription := ""
 
-	go func() {
-		result, errWait := oauthServer.WaitForCallback(5 * time.Minute)
-		if errWait != nil {
-			callbackErrCh <- errWait
-			return
-		}
-		callbackCh <- result
-	}()
+	if oauthServer != nil {
+		go func() {
+			result, errWait := oauthServer.WaitForCallback(5 * time.Minute)
+			if errWait != nil {
+				callbackErrCh <- errWait
+				return
+			}
+			callbackCh <- result
+		}()
+	}
 
 	var result *claude.OAuthResult
 	var manualPromptTimer *time.Timer
 	var manualPromptC <-chan time.Time
 	if opts.Prompt != nil {
-		manualPromptTimer = time.NewTimer(15 * time.Second)
+		manualPromptTimer = time.NewTimer(manualCallbackDelay(opts))
 		manualPromptC = manualPromptTimer.C
 		defer manualPromptTimer.Stop()
 	}
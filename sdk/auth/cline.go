@@ -6,11 +6,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/cline"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/oauthcallback"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/browser"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
@@ -114,6 +114,18 @@ func (a *ClineAuthenticator) Login(ctx context.Context, cfg *config.Config, opts
 		return nil, fmt.Errorf("cline state generation failed: %w", err)
 	}
 
+	registration, resultCh, err := startClineCallbackServer(oauthcallback.FromConfig(cfg), callbackPort, state)
+	if err != nil {
+		return nil, fmt.Errorf("cline callback server failed: %w", err)
+	}
+	defer func() {
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if errClose := registration.Close(shutdownCtx); errClose != nil {
+			log.Warnf("cline callback server shutdown error: %v", errClose)
+		}
+	}()
+
 	callbackURL := fmt.Sprintf("http://localhost:%d/callback", callbackPort)
 	authSvc := cline.NewClineAuth(cfg)
 	authURL := authSvc.GenerateAuthURL(state, callbackURL)
@@ -135,7 +147,7 @@ func (a *ClineAuthenticator) Login(ctx context.Context, cfg *config.Config, opts
 	}
 
 	fmt.Println("Waiting for Cline authentication callback...")
-	result, err := waitForClineCallback(ctx, callbackPort, opts.Prompt)
+	result, err := waitForClineCallback(ctx, registration, resultCh, opts.Prompt, manualCallbackDelay(opts))
 	if err != nil {
 		return nil, err
 	}
@@ -244,22 +256,9 @@ type clineOAuthResult struct {
 	ErrorDescription string
 }
 
-func waitForClineCallback(ctx context.Context, callbackPort int, prompt func(prompt string) (string, error)) (*clineOAuthResult, error) {
-	if ctx == nil {
-		ctx = context.Background()
-	}
-
+func startClineCallbackServer(settings oauthcallback.Settings, callbackPort int, state string) (*oauthcallback.Registration, <-chan *clineOAuthResult, error) {
 	resultCh := make(chan *clineOAuthResult, 1)
-	errCh := make(chan error, 1)
-
-	mux := http.NewServeMux()
-	server := &http.Server{
-		Addr:              ":" + strconv.Itoa(callbackPort),
-		Handler:           mux,
-		ReadHeaderTimeout: 5 * time.Second,
-	}
-
-	mux.HandleFunc("/callback", func(w http.ResponseWriter, r *http.Request) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		q := r.URL.Query()
 		res := &clineOAuthResult{
 			Code:             strings.TrimSpace(q.Get("code")),
@@ -273,28 +272,29 @@ func waitForClineCallback(ctx context.Context, callbackPort int, prompt func(pro
 		default:
 		}
 
+		if settings.WriteSuccessPage(w) {
+			return
+		}
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		_, _ = w.Write([]byte("<html><body><h2>Cline login complete</h2><p>You can close this window and return to CLI.</p></body></html>"))
 	})
+	registration, errRegister := oauthcallback.Register(settings, callbackPort, "/callback", state, handler)
+	if errRegister != nil {
+		return nil, nil, errRegister
+	}
+	return registration, resultCh, nil
+}
 
-	go func() {
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			errCh <- fmt.Errorf("cline callback server failed: %w", err)
-		}
-	}()
-
-	defer func() {
-		shutdownCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-		if err := server.Shutdown(shutdownCtx); err != nil {
-			log.Warnf("cline callback server shutdown error: %v", err)
-		}
-	}()
+func waitForClineCallback(ctx context.Context, registration *oauthcallback.Registration, resultCh <-chan *clineOAuthResult, prompt func(prompt string) (string, error), manualDelay time.Duration) (*clineOAuthResult, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	errCh := registration.Err()
 
 	var manualTimer *time.Timer
 	var manualTimerC <-chan time.Time
 	if prompt != nil {
-		manualTimer = time.NewTimer(15 * time.Second)
+		manualTimer = time.NewTimer(manualDelay)
 		manualTimerC = manualTimer.C
 		defer manualTimer.Stop()
 	}
@@ -316,7 +316,7 @@ func waitForClineCallback(ctx context.Context, callbackPort int, prompt func(pro
 		case <-timeoutTimer.C:
 			return nil, fmt.Errorf("cline callback wait timeout after %s", timeout.String())
 		case err := <-errCh:
-			return nil, err
+			return nil, fmt.Errorf("cline callback server failed: %w", err)
 		case res := <-resultCh:
 			return res, nil
 		case <-manualTimerC:
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/codex"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/oauthcallback"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/browser"
 	// legacy client removed
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
@@ -65,20 +66,25 @@ func (a *CodexAuthenticator) Login(ctx context.Context, cfg *config.Config, opts
 		return nil, fmt.Errorf("codex state generation failed: %w", err)
 	}
 
-	oauthServer := codex.NewOAuthServer(callbackPort)
+	oauthServer := codex.NewOAuthServer(callbackPort).Configure(oauthcallback.FromConfig(cfg), state)
 	if err = oauthServer.Start(); err != nil {
-		if strings.Contains(err.Error(), "already in use") {
-			return nil, codex.NewAuthenticationError(codex.ErrPortInUse, err)
+		if !skipCallbackServer(opts, err) {
+			if strings.Contains(err.Error(), "already in use") {
+				return nil, codex.NewAuthenticationError(codex.ErrPortInUse, err)
+			}
+			return nil, codex.NewAuthenticationError(codex.ErrServerStartFailed, err)
 		}
-		return nil, codex.NewAuthenticationError(codex.ErrServerStartFailed, err)
+		oauthServer = nil
+	}
+	if oauthServer != nil {
+		defer func() {
+			stopCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			if stopErr := oauthServer.Stop(stopCtx); stopErr != nil {
+				log.Warnf("codex oauth server stop error: %v", stopErr)
+			}
+		}()
 	}
-	defer func() {
-		stopCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-		if stopErr := oauthServer.Stop(stopCtx); stopErr != nil {
-			log.Warnf("codex oauth server stop error: %v", stopErr)
-		}
-	}()
 
 	authSvc := codex.NewCodexAuth(cfg)
 
@@ -109,20 +115,22 @@ func (a *CodexAuthenticator) Login(ctx context.Context, cfg *config.Config, opts
 	callbackErrCh := make(chan error, 1)
 	manualDescription := ""
 
-	go func() {
-		result, errWait := oauthServer.WaitForCallback(5 * time.Minute)
-		if errWait != nil {
-			callbackErrCh <- errWait
-			return
-		}
-		callbackCh <- result
-	}()
+	if oauthServer != nil {
+		go func() {
+			result, errWait := oauthServer.WaitForCallback(5 * time.Minute)
+			if errWait != nil {
+				callbackErrCh <- errWait
+				return
+			}
+			callbackCh <- result
+		}()
+	}
 
 	var result *codex.OAuthResult
 	var manualPromptTimer *time.Timer
 	var manualPromptC <-chan time.Time
 	if opts.Prompt != nil {
-		manualPromptTimer = time.NewTimer(15 * time.Second)
+		manualPromptTimer = time.NewTimer(manualCallbackDelay(opts))
 		manualPromptC = manualPromptTimer.C
 		defer manualPromptTimer.Stop()
 	}
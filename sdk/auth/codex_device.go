@@ -55,7 +55,13 @@ type codexDeviceTokenResponse struct {
 }
 
 func shouldUseCodexDeviceFlow(opts *LoginOptions) bool {
-	if opts == nil || opts.Metadata == nil {
+	if opts == nil {
+		return false
+	}
+	if opts.Headless {
+		return true
+	}
+	if opts.Metadata == nil {
 		return false
 	}
 	return strings.EqualFold(strings.TrimSpace(opts.Metadata[codexLoginModeMetadataKey]), codexLoginModeDevice)
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -243,6 +244,12 @@ func (s *FileTokenStore) readAuthFiles(path, baseDir string) ([]*cliproxyauth.Au
 	if len(data) == 0 {
 		return nil, nil
 	}
+	plainData, errDecrypt := cliproxyauth.DecryptAuthJSON(data)
+	if errDecrypt != nil {
+		return nil, fmt.Errorf("decrypt auth json: %w", errDecrypt)
+	}
+	encrypted := !bytes.Equal(plainData, data)
+	data = plainData
 	metadata := make(map[string]any)
 	if err = json.Unmarshal(data, &metadata); err != nil {
 		return nil, fmt.Errorf("unmarshal auth json: %w", err)
@@ -320,7 +327,13 @@ func (s *FileTokenStore) readAuthFiles(path, baseDir string) ([]*cliproxyauth.Au
 				fetchedProjectID, errFetch := FetchAntigravityProjectID(context.Background(), accessToken, http.DefaultClient)
 				if errFetch == nil && strings.TrimSpace(fetchedProjectID) != "" {
 					metadata["project_id"] = strings.TrimSpace(fetchedProjectID)
-					if raw, errMarshal := json.Marshal(metadata); errMarshal == nil {
+					stored := metadata
+					var errEncrypt error
+					if encrypted {
+						// Never write decrypted credentials back to an encrypted file.
+						stored, errEncrypt = cliproxyauth.EncryptMetadata(cliproxyauth.RegisteredMetadataCipher(), metadata)
+					}
+					if raw, errMarshal := json.Marshal(stored); errEncrypt == nil && errMarshal == nil {
 						if file, errOpen := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0o600); errOpen == nil {
 							_, _ = file.Write(raw)
 							_ = file.Close()
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/gemini"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/oauthcallback"
 	// legacy client removed
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
@@ -45,9 +46,11 @@ func (a *GeminiAuthenticator) Login(ctx context.Context, cfg *config.Config, opt
 
 	geminiAuth := gemini.NewGeminiAuth()
 	_, err := geminiAuth.GetAuthenticatedClient(ctx, &ts, cfg, &gemini.WebLoginOptions{
-		NoBrowser:    opts.NoBrowser,
-		CallbackPort: opts.CallbackPort,
-		Prompt:       opts.Prompt,
+		NoBrowser:        opts.NoBrowser,
+		CallbackPort:     opts.CallbackPort,
+		Prompt:           opts.Prompt,
+		Headless:         opts.Headless,
+		CallbackSettings: oauthcallback.FromConfig(cfg),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("gemini authentication failed: %w", err)
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	gitlabauth "github.com/router-for-me/CLIProxyAPI/v7/internal/auth/gitlab"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/oauthcallback"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/browser"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
@@ -77,11 +78,13 @@ func (a *GitLabAuthenticator) loginOAuth(ctx context.Context, cfg *config.Config
 		return nil, err
 	}
 
+	callbackSettings := oauthcallback.FromConfig(cfg)
 	callbackPort := a.CallbackPort
 	if opts.CallbackPort > 0 {
 		callbackPort = opts.CallbackPort
+	} else if callbackSettings.RandomPort {
+		callbackPort = 0
 	}
-	redirectURI := gitlabauth.RedirectURL(callbackPort)
 
 	pkceCodes, err := gitlabauth.GeneratePKCECodes()
 	if err != nil {
@@ -92,17 +95,29 @@ func (a *GitLabAuthenticator) loginOAuth(ctx context.Context, cfg *config.Config
 		return nil, fmt.Errorf("gitlab state generation failed: %w", err)
 	}
 
-	oauthServer := gitlabauth.NewOAuthServer(callbackPort)
+	oauthServer := gitlabauth.NewOAuthServer(callbackPort).Configure(callbackSettings, state)
+	callbackPath := oauthServer.Path()
 	if err := oauthServer.Start(); err != nil {
-		return nil, err
-	}
-	defer func() {
-		stopCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-		if stopErr := oauthServer.Stop(stopCtx); stopErr != nil {
-			log.Warnf("gitlab oauth server stop error: %v", stopErr)
+		if !skipCallbackServer(opts, err) {
+			return nil, err
 		}
-	}()
+		oauthServer = nil
+	}
+	if oauthServer != nil {
+		callbackPort = oauthServer.Port()
+	} else if callbackPort == 0 {
+		callbackPort = a.CallbackPort
+	}
+	redirectURI := gitlabauth.RedirectURLWithPath(callbackPort, callbackPath)
+	if oauthServer != nil {
+		defer func() {
+			stopCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			if stopErr := oauthServer.Stop(stopCtx); stopErr != nil {
+				log.Warnf("gitlab oauth server stop error: %v", stopErr)
+			}
+		}()
+	}
 
 	authURL, err := client.GenerateAuthURL(baseURL, clientID, redirectURI, state, pkceCodes)
 	if err != nil {
@@ -129,20 +144,22 @@ func (a *GitLabAuthenticator) loginOAuth(ctx context.Context, cfg *config.Config
 
 	callbackCh := make(chan *gitlabauth.OAuthResult, 1)
 	callbackErrCh := make(chan error, 1)
-	go func() {
-		result, waitErr := oauthServer.WaitForCallback(5 * time.Minute)
-		if waitErr != nil {
-			callbackErrCh <- waitErr
-			return
-		}
-		callbackCh <- result
-	}()
+	if oauthServer != nil {
+		go func() {
+			result, waitErr := oauthServer.WaitForCallback(5 * time.Minute)
+			if waitErr != nil {
+				callbackErrCh <- waitErr
+				return
+			}
+			callbackCh <- result
+		}()
+	}
 
 	var result *gitlabauth.OAuthResult
 	var manualPromptTimer *time.Timer
 	var manualPromptC <-chan time.Time
 	if opts.Prompt != nil {
-		manualPromptTimer = time.NewTimer(15 * time.Second)
+		manualPromptTimer = time.NewTimer(manualCallbackDelay(opts))
 		manualPromptC = manualPromptTimer.C
 		defer manualPromptTimer.Stop()
 	}
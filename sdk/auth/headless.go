@@ -0,0 +1,26 @@
+package auth
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// manualCallbackDelay returns how long a callback flow waits for the local callback server
+// before it also offers to paste the redirected URL. Headless logins prompt right away.
+func manualCallbackDelay(opts *LoginOptions) time.Duration {
+	if opts != nil && opts.Headless {
+		return 0
+	}
+	return 15 * time.Second
+}
+
+// skipCallbackServer reports whether a headless login carries on without its local callback
+// server after errStart, relying on the pasted redirect URL instead.
+func skipCallbackServer(opts *LoginOptions, errStart error) bool {
+	if opts == nil || !opts.Headless || opts.Prompt == nil {
+		return false
+	}
+	log.Warnf("local OAuth callback server unavailable, paste the redirected URL instead: %v", errStart)
+	return true
+}
@@ -0,0 +1,30 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestHeadlessLoginSkipsLocalCallbackDependencies(t *testing.T) {
+	prompt := func(string) (string, error) { return "", nil }
+	headless := &LoginOptions{Headless: true, Prompt: prompt}
+	errPort := errors.New("port 54545 already in use")
+
+	if !shouldUseCodexDeviceFlow(headless) {
+		t.Fatal("headless codex login does not use the device-code flow")
+	}
+	if delay := manualCallbackDelay(headless); delay != 0 {
+		t.Fatalf("manualCallbackDelay() = %v, want an immediate prompt", delay)
+	}
+	if !skipCallbackServer(headless, errPort) {
+		t.Fatal("headless login aborts when the callback port is taken")
+	}
+
+	interactive := &LoginOptions{Prompt: prompt}
+	if shouldUseCodexDeviceFlow(interactive) || manualCallbackDelay(interactive) == 0 || skipCallbackServer(interactive, errPort) {
+		t.Fatal("interactive login changed behaviour")
+	}
+	if skipCallbackServer(&LoginOptions{Headless: true}, errPort) {
+		t.Fatal("headless login without a prompt skipped the only way to receive the code")
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/iflow"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/oauthcallback"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/browser"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
@@ -42,34 +43,49 @@ func (a *IFlowAuthenticator) Login(ctx context.Context, cfg *config.Config, opts
 		opts = &LoginOptions{}
 	}
 
+	callbackSettings := oauthcallback.FromConfig(cfg)
 	callbackPort := iflow.CallbackPort
 	if opts.CallbackPort > 0 {
 		callbackPort = opts.CallbackPort
+	} else if callbackSettings.RandomPort {
+		callbackPort = 0
 	}
 
 	authSvc := iflow.NewIFlowAuth(cfg)
 
-	oauthServer := iflow.NewOAuthServer(callbackPort)
+	state, err := misc.GenerateRandomState()
+	if err != nil {
+		return nil, fmt.Errorf("iflow auth: failed to generate state: %w", err)
+	}
+
+	oauthServer := iflow.NewOAuthServer(callbackPort).Configure(callbackSettings, state)
+	callbackPath := oauthServer.Path()
 	if err := oauthServer.Start(); err != nil {
-		if strings.Contains(err.Error(), "already in use") {
-			return nil, fmt.Errorf("iflow authentication server port in use: %w", err)
+		if !skipCallbackServer(opts, err) {
+			if strings.Contains(err.Error(), "already in use") {
+				return nil, fmt.Errorf("iflow authentication server port in use: %w", err)
+			}
+			return nil, fmt.Errorf("iflow authentication server failed: %w", err)
 		}
-		return nil, fmt.Errorf("iflow authentication server failed: %w", err)
+		oauthServer = nil
+	}
+	if oauthServer != nil {
+		defer func() {
+			stopCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			if stopErr := oauthServer.Stop(stopCtx); stopErr != nil {
+				log.Warnf("iflow oauth server stop error: %v", stopErr)
+			}
+		}()
 	}
-	defer func() {
-		stopCtx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-		defer cancel()
-		if stopErr := oauthServer.Stop(stopCtx); stopErr != nil {
-			log.Warnf("iflow oauth server stop error: %v", stopErr)
-		}
-	}()
 
-	state, err := misc.GenerateRandomState()
-	if err != nil {
-		return nil, fmt.Errorf("iflow auth: failed to generate state: %w", err)
+	if oauthServer != nil {
+		callbackPort = oauthServer.Port()
+	} else if callbackPort == 0 {
+		callbackPort = iflow.CallbackPort
 	}
 
-	authURL, redirectURI := authSvc.AuthorizationURL(state, callbackPort)
+	authURL, redirectURI := authSvc.AuthorizationURLWithPath(state, callbackPort, callbackPath)
 
 	if !opts.NoBrowser {
 		fmt.Println("Opening browser for iFlow authentication")
@@ -92,20 +108,22 @@ func (a *IFlowAuthenticator) Login(ctx context.Context, cfg *config.Config, opts
 	callbackCh := make(chan *iflow.OAuthResult, 1)
 	callbackErrCh := make(chan error, 1)
 
-	go func() {
-		result, errWait := oauthServer.WaitForCallback(5 * time.Minute)
-		if errWait != nil {
-			callbackErrCh <- errWait
-			return
-		}
-		callbackCh <- result
-	}()
+	if oauthServer != nil {
+		go func() {
+			result, errWait := oauthServer.WaitForCallback(5 * time.Minute)
+			if errWait != nil {
+				callbackErrCh <- errWait
+				return
+			}
+			callbackCh <- result
+		}()
+	}
 
 	var result *iflow.OAuthResult
 	var manualPromptTimer *time.Timer
 	var manualPromptC <-chan time.Time
 	if opts.Prompt != nil {
-		manualPromptTimer = time.NewTimer(15 * time.Second)
+		manualPromptTimer = time.NewTimer(manualCallbackDelay(opts))
 		manualPromptC = manualPromptTimer.C
 		defer manualPromptTimer.Stop()
 	}
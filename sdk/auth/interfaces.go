@@ -19,6 +19,10 @@ type LoginOptions struct {
 	CallbackPort int
 	Metadata     map[string]string
 	Prompt       func(prompt string) (string, error)
+	// Headless completes the login on a host without a browser: device-code flows are used
+	// where the provider has one, and callback flows prompt for the redirected URL at once
+	// and keep going when the local callback port cannot be bound.
+	Headless bool
 }
 
 // Authenticator manages login and optional refresh flows for a provider.
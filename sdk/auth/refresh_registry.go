@@ -17,6 +17,7 @@ func init() {
 	registerRefreshLead("gitlab", func() Authenticator { return NewGitLabAuthenticator() })
 	registerRefreshLead("codebuddy", func() Authenticator { return NewCodeBuddyAuthenticator() })
 	registerRefreshLead("cursor", func() Authenticator { return NewCursorAuthenticator() })
+	registerRefreshLead("cline", func() Authenticator { return NewClineAuthenticator() })
 }
 
 func registerRefreshLead(provider string, factory func() Authenticator) {
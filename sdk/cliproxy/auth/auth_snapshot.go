@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+)
+
+// maxRouteAwareMemoEntries bounds the per-snapshot route-aware memo so arbitrary request
+// models cannot grow it without limit. Lookups past the bound are computed uncached.
+const maxRouteAwareMemoEntries = 1024
+
+// authSnapshot is an immutable copy-on-write view of the registered auths that lets the
+// selection hot path scan candidates without taking m.mu. Entries are clones taken when
+// the snapshot was built and must never be mutated.
+//
+// A snapshot carries the fields that decide eligibility before scheduling (ID, provider,
+// attributes, prefix, disabled flag). Runtime state such as cooldowns, model states and
+// counters is updated in place on m.auths and may be newer than the snapshot; read it
+// from m.auths under m.mu or through the scheduler.
+//
+// Route-aware indexes memoized on a snapshot also depend on the OAuth model alias table,
+// so replacing the table invalidates the snapshot too.
+type authSnapshot struct {
+	// generation is the value of Manager.authGeneration the snapshot was built from.
+	generation uint64
+	// byProvider groups auths by executor key.
+	byProvider map[string][]*Auth
+	// openAICompatKeys lists the executor keys of openai-compatibility auths.
+	openAICompatKeys map[string]struct{}
+
+	// routeAware memoizes routeAwareIndex results per provider and route model.
+	routeAware      sync.Map
+	routeAwareCount atomic.Int64
+}
+
+// routeAwareIndex lists the auths of one provider whose selection model differs from a
+// route model, so the scheduler fast path only inspects those instead of every auth.
+type routeAwareIndex struct {
+	// registryGeneration is the model registry generation the index was built against.
+	registryGeneration uint64
+	// always holds auths that resolve to another model regardless of runtime state.
+	always []string
+	// whenBlocked holds auths with a fork alias for the model; they only resolve to the
+	// fork target while blocked, which must be checked against the live auth.
+	whenBlocked []string
+}
+
+// bumpAuthGenerationLocked invalidates the published auth snapshot. It must be called with
+// m.mu held for writing whenever an auth is added, replaced or removed, or when a field
+// read from snapshots (see authSnapshot) is changed in place.
+func (m *Manager) bumpAuthGenerationLocked() {
+	m.authGeneration.Add(1)
+}
+
+// currentAuthSnapshot returns the published snapshot, rebuilding it when the auth set
+// changed since it was built. Concurrent callers may rebuild in parallel after a change;
+// each result is consistent and the generation check discards stale ones.
+func (m *Manager) currentAuthSnapshot() *authSnapshot {
+	if snapshot := m.authView.Load(); snapshot != nil && snapshot.generation == m.authGeneration.Load() {
+		return snapshot
+	}
+
+	m.mu.RLock()
+	snapshot := &authSnapshot{
+		generation:       m.authGeneration.Load(),
+		byProvider:       make(map[string][]*Auth),
+		openAICompatKeys: make(map[string]struct{}),
+	}
+	for _, auth := range m.auths {
+		if auth == nil {
+			continue
+		}
+		clone := auth.Clone()
+		key := executorKeyFromAuth(clone)
+		snapshot.byProvider[key] = append(snapshot.byProvider[key], clone)
+		if strings.EqualFold(strings.TrimSpace(clone.Provider), "openai-compatibility") {
+			snapshot.openAICompatKeys[key] = struct{}{}
+		}
+	}
+	m.mu.RUnlock()
+
+	for {
+		published := m.authView.Load()
+		if published != nil && published.generation >= snapshot.generation {
+			if published.generation == m.authGeneration.Load() {
+				return published
+			}
+			return snapshot
+		}
+		if m.authView.CompareAndSwap(published, snapshot) {
+			return snapshot
+		}
+	}
+}
+
+// routeAwareIndexFor returns the route-aware auths of provider for model, building and
+// memoizing the index when missing or built against an older model registry.
+func (m *Manager) routeAwareIndexFor(snapshot *authSnapshot, provider, model string) *routeAwareIndex {
+	registryGeneration := registry.GetGlobalRegistry().Generation()
+	key := provider + "\x00" + model
+	if cached, ok := snapshot.routeAware.Load(key); ok {
+		if index := cached.(*routeAwareIndex); index.registryGeneration == registryGeneration {
+			return index
+		}
+	}
+
+	index := &routeAwareIndex{registryGeneration: registryGeneration}
+	routeKey := canonicalModelKey(model)
+	for _, candidate := range snapshot.byProvider[provider] {
+		if candidate.Disabled {
+			continue
+		}
+		requestedModel, resolvedModel := m.aliasSelectionModelForAuth(candidate, model)
+		resolvedKey := canonicalModelKey(resolvedModel)
+		switch {
+		case resolvedKey != routeKey:
+			index.always = append(index.always, candidate.ID)
+		case resolvedKey == canonicalModelKey(requestedModel) && strings.TrimSpace(m.resolveBlockedForkAliasTarget(candidate, requestedModel)) != "":
+			index.whenBlocked = append(index.whenBlocked, candidate.ID)
+		}
+	}
+
+	if _, exists := snapshot.routeAware.Load(key); exists || snapshot.routeAwareCount.Load() < maxRouteAwareMemoEntries {
+		if _, loaded := snapshot.routeAware.Swap(key, index); !loaded {
+			snapshot.routeAwareCount.Add(1)
+		}
+	}
+	return index
+}
+
+// routeAwareCandidatePending reports whether an untried auth of provider needs route-aware
+// selection for model, i.e. whether the scheduler fast path has to defer to the legacy
+// picker. Fork alias candidates are checked against the live auth under m.mu because
+// their outcome depends on the current block state.
+func (m *Manager) routeAwareCandidatePending(snapshot *authSnapshot, provider, model string, tried map[string]struct{}) bool {
+	index := m.routeAwareIndexFor(snapshot, provider, model)
+	for _, id := range index.always {
+		if _, used := tried[id]; !used {
+			return true
+		}
+	}
+	if len(index.whenBlocked) == 0 {
+		return false
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, id := range index.whenBlocked {
+		if _, used := tried[id]; used {
+			continue
+		}
+		if auth := m.auths[id]; auth != nil && !auth.Disabled && m.routeAwareSelectionRequired(auth, model) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestCurrentAuthSnapshotTracksRegisterUpdateRemove(t *testing.T) {
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	ctx := context.Background()
+
+	if _, errRegister := manager.Register(ctx, &Auth{ID: "snap-a", Provider: "gemini"}); errRegister != nil {
+		t.Fatalf("Register error = %v", errRegister)
+	}
+	first := manager.currentAuthSnapshot()
+	if got := len(first.byProvider["gemini"]); got != 1 {
+		t.Fatalf("gemini auths = %d, want 1", got)
+	}
+	if manager.currentAuthSnapshot() != first {
+		t.Fatal("unchanged auth set rebuilt the snapshot")
+	}
+
+	if _, errUpdate := manager.Update(ctx, &Auth{ID: "snap-a", Provider: "gemini", Disabled: true}); errUpdate != nil {
+		t.Fatalf("Update error = %v", errUpdate)
+	}
+	updated := manager.currentAuthSnapshot()
+	if updated == first || !updated.byProvider["gemini"][0].Disabled {
+		t.Fatal("snapshot did not pick up the update")
+	}
+	if first.byProvider["gemini"][0].Disabled {
+		t.Fatal("update mutated a published snapshot")
+	}
+
+	manager.Remove(ctx, "snap-a")
+	if got := len(manager.currentAuthSnapshot().byProvider["gemini"]); got != 0 {
+		t.Fatalf("gemini auths after Remove = %d, want 0", got)
+	}
+}
+
+func TestCurrentAuthSnapshotListsOpenAICompatProviders(t *testing.T) {
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	auth := &Auth{
+		ID:         "compat-a",
+		Provider:   "openai-compatibility",
+		Attributes: map[string]string{"compat_name": "acme"},
+	}
+	if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("Register error = %v", errRegister)
+	}
+	key := executorKeyFromAuth(auth)
+	if !manager.hasOpenAICompatAuthProvider([]string{key}) {
+		t.Fatalf("hasOpenAICompatAuthProvider(%q) = false, want true", key)
+	}
+	if manager.hasOpenAICompatAuthProvider([]string{"gemini"}) {
+		t.Fatal("hasOpenAICompatAuthProvider(gemini) = true, want false")
+	}
+}
+
+func TestRouteAwareCandidatePendingChecksLiveBlockState(t *testing.T) {
+	const (
+		provider    = "antigravity"
+		routeModel  = "route-aware-model"
+		targetModel = "route-aware-model-fork"
+	)
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.SetOAuthModelAlias(map[string][]internalconfig.OAuthModelAlias{
+		provider: {{Name: targetModel, Alias: routeModel, Fork: true}},
+	})
+	auth := &Auth{ID: "route-aware-fork", Provider: provider, Attributes: map[string]string{"auth_kind": "oauth"}}
+	if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("Register error = %v", errRegister)
+	}
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient(auth.ID, provider, []*registry.ModelInfo{{ID: routeModel}, {ID: targetModel}})
+	t.Cleanup(func() { reg.UnregisterClient(auth.ID) })
+
+	snapshot := manager.currentAuthSnapshot()
+	index := manager.routeAwareIndexFor(snapshot, provider, routeModel)
+	if len(index.always) != 0 || len(index.whenBlocked) != 1 {
+		t.Fatalf("index = %+v, want one fork candidate", index)
+	}
+	if manager.routeAwareIndexFor(snapshot, provider, routeModel) != index {
+		t.Fatal("index was rebuilt without a registry change")
+	}
+	if manager.routeAwareCandidatePending(snapshot, provider, routeModel, nil) {
+		t.Fatal("unblocked fork candidate reported as route-aware")
+	}
+
+	manager.mu.Lock()
+	manager.auths[auth.ID].ModelStates = map[string]*ModelState{
+		routeModel: {Unavailable: true, Status: StatusError, NextRetryAfter: time.Now().Add(time.Hour)},
+	}
+	manager.mu.Unlock()
+	if !manager.routeAwareCandidatePending(snapshot, provider, routeModel, nil) {
+		t.Fatal("blocked fork candidate not reported as route-aware")
+	}
+	if manager.routeAwareCandidatePending(snapshot, provider, routeModel, map[string]struct{}{auth.ID: {}}) {
+		t.Fatal("tried auth reported as route-aware")
+	}
+}
+
+// TestManagerSelectionUnderAuthChurn runs selection concurrently with registration,
+// updates, removals and result marking. Run with -race to check the snapshot handoff.
+func TestManagerSelectionUnderAuthChurn(t *testing.T) {
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.RegisterExecutor(conductorBenchmarkExecutor{schedulerBenchmarkExecutor{id: "gemini"}})
+	ctx := context.Background()
+	reg := registry.GetGlobalRegistry()
+	const model = "churn-model"
+	const stable = 16
+	const churn = 16
+
+	register := func(id string) {
+		if _, errRegister := manager.Register(ctx, &Auth{ID: id, Provider: "gemini"}); errRegister != nil {
+			t.Errorf("Register(%s) error = %v", id, errRegister)
+		}
+		reg.RegisterClient(id, "gemini", []*registry.ModelInfo{{ID: model}})
+	}
+	for i := 0; i < stable; i++ {
+		register(fmt.Sprintf("churn-stable-%02d", i))
+	}
+	t.Cleanup(func() {
+		for i := 0; i < stable; i++ {
+			reg.UnregisterClient(fmt.Sprintf("churn-stable-%02d", i))
+		}
+		for i := 0; i < churn; i++ {
+			reg.UnregisterClient(fmt.Sprintf("churn-dynamic-%02d", i))
+		}
+	})
+
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for round := 0; ; round++ {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			id := fmt.Sprintf("churn-dynamic-%02d", round%churn)
+			register(id)
+			_, _ = manager.Update(ctx, &Auth{ID: id, Provider: "gemini", Disabled: round%2 == 0})
+			manager.Remove(ctx, id)
+		}
+	}()
+
+	var pickers sync.WaitGroup
+	for worker := 0; worker < 4; worker++ {
+		pickers.Add(1)
+		go func() {
+			defer pickers.Done()
+			for i := 0; i < 200; i++ {
+				auth, _, errPick := manager.pickNext(ctx, "gemini", model, cliproxyexecutor.Options{}, map[string]struct{}{})
+				if errPick != nil {
+					t.Errorf("pickNext error = %v", errPick)
+					return
+				}
+				manager.MarkResult(ctx, Result{AuthID: auth.ID, Provider: "gemini", Model: model, Success: true})
+			}
+		}()
+	}
+	pickers.Wait()
+	close(stop)
+	wg.Wait()
+}
@@ -19,6 +19,10 @@ type authAutoRefreshLoop struct {
 	queue refreshMinHeap
 	index map[string]*refreshHeapItem
 	dirty map[string]struct{}
+	// inFlight maps auth IDs dispatched to workers to their provider; providerInFlight
+	// counts them per provider for the per-provider refresh cap.
+	inFlight         map[string]string
+	providerInFlight map[string]int
 
 	wakeCh chan struct{}
 	jobs   chan string
@@ -36,13 +40,15 @@ func newAuthAutoRefreshLoop(manager *Manager, interval time.Duration, concurrenc
 		jobBuffer = 64
 	}
 	return &authAutoRefreshLoop{
-		manager:     manager,
-		interval:    interval,
-		concurrency: concurrency,
-		index:       make(map[string]*refreshHeapItem),
-		dirty:       make(map[string]struct{}),
-		wakeCh:      make(chan struct{}, 1),
-		jobs:        make(chan string, jobBuffer),
+		manager:          manager,
+		interval:         interval,
+		concurrency:      concurrency,
+		index:            make(map[string]*refreshHeapItem),
+		dirty:            make(map[string]struct{}),
+		inFlight:         make(map[string]string),
+		providerInFlight: make(map[string]int),
+		wakeCh:           make(chan struct{}, 1),
+		jobs:             make(chan string, jobBuffer),
 	}
 }
 
@@ -85,6 +91,7 @@ func (l *authAutoRefreshLoop) worker(ctx context.Context) {
 				continue
 			}
 			l.manager.refreshAuth(ctx, authID)
+			l.releaseProvider(authID)
 			l.queueReschedule(authID)
 		}
 	}
@@ -97,6 +104,7 @@ func (l *authAutoRefreshLoop) rebuild(now time.Time) {
 	}
 
 	entries := make([]entry, 0)
+	jitter, _ := l.manager.refreshSchedulingSettings()
 
 	l.manager.mu.RLock()
 	for id, auth := range l.manager.auths {
@@ -104,7 +112,7 @@ func (l *authAutoRefreshLoop) rebuild(now time.Time) {
 		if !ok {
 			continue
 		}
-		entries = append(entries, entry{id: id, next: next})
+		entries = append(entries, entry{id: id, next: jitterRefreshTime(next, jitter)})
 	}
 	l.manager.mu.RUnlock()
 
@@ -234,6 +242,7 @@ func (l *authAutoRefreshLoop) handleDueAuth(ctx context.Context, now time.Time,
 	next, shouldSchedule := nextRefreshCheckAt(now, auth, l.interval)
 	shouldRefresh := manager.shouldRefresh(auth, now)
 	exec := manager.executors[auth.Provider]
+	provider := auth.Provider
 	manager.mu.RUnlock()
 
 	if !shouldSchedule {
@@ -251,7 +260,14 @@ func (l *authAutoRefreshLoop) handleDueAuth(ctx context.Context, now time.Time,
 		return
 	}
 
+	_, providerLimit := manager.refreshSchedulingSettings()
+	if !l.tryAcquireProvider(authID, provider, providerLimit) {
+		l.upsert(authID, now.Add(refreshProviderBusyDelay))
+		return
+	}
+
 	if !manager.markRefreshPending(authID, now) {
+		l.releaseProvider(authID)
 		manager.mu.RLock()
 		auth = manager.auths[authID]
 		next, shouldSchedule = nextRefreshCheckAt(now, auth, l.interval)
@@ -266,17 +282,47 @@ func (l *authAutoRefreshLoop) handleDueAuth(ctx context.Context, now time.Time,
 
 	select {
 	case <-ctx.Done():
+		l.releaseProvider(authID)
 		return
 	case l.jobs <- authID:
 	}
 }
 
+// tryAcquireProvider reserves a refresh slot for provider; limit <= 0 means unlimited.
+func (l *authAutoRefreshLoop) tryAcquireProvider(authID, provider string, limit int) bool {
+	key := strings.ToLower(strings.TrimSpace(provider))
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if limit > 0 && l.providerInFlight[key] >= limit {
+		return false
+	}
+	l.inFlight[authID] = key
+	l.providerInFlight[key]++
+	return true
+}
+
+func (l *authAutoRefreshLoop) releaseProvider(authID string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	key, ok := l.inFlight[authID]
+	if !ok {
+		return
+	}
+	delete(l.inFlight, authID)
+	if l.providerInFlight[key] <= 1 {
+		delete(l.providerInFlight, key)
+		return
+	}
+	l.providerInFlight[key]--
+}
+
 func (l *authAutoRefreshLoop) applyDirty(now time.Time) {
 	dirty := l.drainDirty()
 	if len(dirty) == 0 {
 		return
 	}
 
+	jitter, _ := l.manager.refreshSchedulingSettings()
 	for _, authID := range dirty {
 		l.manager.mu.RLock()
 		auth := l.manager.auths[authID]
@@ -287,7 +333,7 @@ func (l *authAutoRefreshLoop) applyDirty(now time.Time) {
 			l.remove(authID)
 			continue
 		}
-		l.upsert(authID, next)
+		l.upsert(authID, jitterRefreshTime(next, jitter))
 	}
 }
 
@@ -0,0 +1,273 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const minutesPerDay = 24 * 60
+
+var availabilityWeekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// availabilityWindow is one recurring window: the weekdays it starts on and its start and
+// end as minutes after midnight. An end at or before the start runs past midnight.
+type availabilityWindow struct {
+	days  [7]bool
+	start int
+	end   int
+}
+
+// Availability restricts an auth to recurring time windows, e.g. a personal account used
+// only off-hours. Outside every window the auth is skipped by selection.
+type Availability struct {
+	specs    []string
+	timezone string
+	location *time.Location
+	windows  []availabilityWindow
+}
+
+// ParseAvailability parses window specs of the form "[days] [HH:MM-HH:MM]", where days is
+// "*" or a comma-separated list of weekdays and ranges ("mon-fri,sun"). A spec without
+// days applies every day; one without hours covers the whole day. An end time before the
+// start runs into the next day ("mon-fri 18:00-08:00"). timezone is an IANA name; empty
+// uses the local time zone. Nil is returned when specs is empty.
+func ParseAvailability(specs []string, timezone string) (*Availability, error) {
+	availability := &Availability{timezone: strings.TrimSpace(timezone), location: time.Local}
+	if availability.timezone != "" {
+		location, errLoad := time.LoadLocation(availability.timezone)
+		if errLoad != nil {
+			return nil, fmt.Errorf("invalid availability timezone %q: %w", availability.timezone, errLoad)
+		}
+		availability.location = location
+	}
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		window, errParse := parseAvailabilityWindow(spec)
+		if errParse != nil {
+			return nil, errParse
+		}
+		availability.specs = append(availability.specs, spec)
+		availability.windows = append(availability.windows, window)
+	}
+	if len(availability.windows) == 0 {
+		return nil, nil
+	}
+	return availability, nil
+}
+
+func parseAvailabilityWindow(spec string) (availabilityWindow, error) {
+	window := availabilityWindow{start: 0, end: minutesPerDay}
+	fields := strings.Fields(strings.ToLower(spec))
+	if len(fields) > 2 {
+		return window, fmt.Errorf("invalid availability window %q", spec)
+	}
+	daysSet := false
+	for _, field := range fields {
+		if strings.Contains(field, ":") {
+			start, end, ok := parseAvailabilityHours(field)
+			if !ok {
+				return window, fmt.Errorf("invalid availability hours in %q", spec)
+			}
+			window.start, window.end = start, end
+			continue
+		}
+		if daysSet {
+			return window, fmt.Errorf("invalid availability window %q", spec)
+		}
+		days, ok := parseAvailabilityDays(field)
+		if !ok {
+			return window, fmt.Errorf("invalid availability days in %q", spec)
+		}
+		window.days, daysSet = days, true
+	}
+	if !daysSet {
+		for i := range window.days {
+			window.days[i] = true
+		}
+	}
+	return window, nil
+}
+
+func parseAvailabilityDays(field string) ([7]bool, bool) {
+	var days [7]bool
+	if field == "*" {
+		for i := range days {
+			days[i] = true
+		}
+		return days, true
+	}
+	for _, part := range strings.Split(field, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, okFirst := availabilityWeekdays[from]
+		if !okFirst {
+			return days, false
+		}
+		last := first
+		if isRange {
+			var okLast bool
+			if last, okLast = availabilityWeekdays[to]; !okLast {
+				return days, false
+			}
+		}
+		for day := first; ; day = (day + 1) % 7 {
+			days[day] = true
+			if day == last {
+				break
+			}
+		}
+	}
+	return days, true
+}
+
+func parseAvailabilityHours(field string) (int, int, bool) {
+	from, to, ok := strings.Cut(field, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	start, okStart := parseAvailabilityClock(from)
+	end, okEnd := parseAvailabilityClock(to)
+	return start, end, okStart && okEnd && start < minutesPerDay
+}
+
+func parseAvailabilityClock(raw string) (int, bool) {
+	hours, minutes, ok := strings.Cut(raw, ":")
+	if !ok {
+		return 0, false
+	}
+	h, errHours := strconv.Atoi(hours)
+	m, errMinutes := strconv.Atoi(minutes)
+	if errHours != nil || errMinutes != nil || h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m != 0) {
+		return 0, false
+	}
+	return h*60 + m, true
+}
+
+// Specs returns the window specs the availability was parsed from.
+func (a *Availability) Specs() []string {
+	if a == nil {
+		return nil
+	}
+	return append([]string(nil), a.specs...)
+}
+
+// Timezone returns the configured time zone name; empty means local time.
+func (a *Availability) Timezone() string {
+	if a == nil {
+		return ""
+	}
+	return a.timezone
+}
+
+// Allows reports whether t falls inside one of the windows. A nil availability allows
+// any time.
+func (a *Availability) Allows(t time.Time) bool {
+	if a == nil || len(a.windows) == 0 {
+		return true
+	}
+	local := t.In(a.location)
+	minute := local.Hour()*60 + local.Minute()
+	today := local.Weekday()
+	yesterday := (today + 6) % 7
+	for _, window := range a.windows {
+		if window.end > window.start {
+			if window.days[today] && minute >= window.start && minute < window.end {
+				return true
+			}
+			continue
+		}
+		if (window.days[today] && minute >= window.start) || (window.days[yesterday] && minute < window.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns the next time after t at which a window opens, or the zero time when
+// the availability is nil or no window ever opens.
+func (a *Availability) NextOpen(t time.Time) time.Time {
+	if a == nil || len(a.windows) == 0 {
+		return time.Time{}
+	}
+	local := t.In(a.location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, a.location)
+	var next time.Time
+	for offset := 0; offset <= 7; offset++ {
+		day := midnight.AddDate(0, 0, offset)
+		for _, window := range a.windows {
+			if !window.days[day.Weekday()] {
+				continue
+			}
+			start := day.Add(time.Duration(window.start) * time.Minute)
+			if start.After(t) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+	return next
+}
+
+type availabilityJSON struct {
+	Windows  []string `json:"windows"`
+	Timezone string   `json:"timezone,omitempty"`
+}
+
+// MarshalJSON encodes the availability as its window specs and time zone.
+func (a *Availability) MarshalJSON() ([]byte, error) {
+	if a == nil {
+		return []byte("null"), nil
+	}
+	return json.Marshal(availabilityJSON{Windows: a.specs, Timezone: a.timezone})
+}
+
+// UnmarshalJSON decodes an availability encoded by MarshalJSON.
+func (a *Availability) UnmarshalJSON(data []byte) error {
+	var raw availabilityJSON
+	if errUnmarshal := json.Unmarshal(data, &raw); errUnmarshal != nil {
+		return errUnmarshal
+	}
+	parsed, errParse := ParseAvailability(raw.Windows, raw.Timezone)
+	if errParse != nil {
+		return errParse
+	}
+	if parsed == nil {
+		*a = Availability{}
+		return nil
+	}
+	*a = *parsed
+	return nil
+}
+
+// AvailabilityFromMetadata parses the "availability" windows (a string or a list) and the
+// optional "availability_timezone" of auth file metadata.
+func AvailabilityFromMetadata(metadata map[string]any) (*Availability, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+	var specs []string
+	switch v := metadata["availability"].(type) {
+	case string:
+		specs = strings.Split(v, ";")
+	case []string:
+		specs = v
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				specs = append(specs, s)
+			}
+		}
+	}
+	timezone, _ := metadata["availability_timezone"].(string)
+	return ParseAvailability(specs, timezone)
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestParseAvailabilityRejectsInvalidSpecs(t *testing.T) {
+	for _, spec := range []string{"mon-xyz", "mon 9-17", "mon 09:00-25:00", "mon tue 09:00-10:00"} {
+		if _, errParse := ParseAvailability([]string{spec}, ""); errParse == nil {
+			t.Fatalf("ParseAvailability(%q) expected error", spec)
+		}
+	}
+	if _, errParse := ParseAvailability([]string{"*"}, "Not/AZone"); errParse == nil {
+		t.Fatal("ParseAvailability with invalid timezone expected error")
+	}
+	availability, errParse := ParseAvailability([]string{" ", ""}, "")
+	if errParse != nil || availability != nil {
+		t.Fatalf("ParseAvailability(blank) = %v, %v; want nil, nil", availability, errParse)
+	}
+}
+
+func TestAvailabilityAllowsOvernightWindow(t *testing.T) {
+	availability, errParse := ParseAvailability([]string{"mon-fri 18:00-08:00", "sat,sun"}, "UTC")
+	if errParse != nil {
+		t.Fatalf("ParseAvailability error = %v", errParse)
+	}
+	cases := []struct {
+		at   string
+		want bool
+	}{
+		{"2026-10-12T12:00:00Z", false}, // Monday midday
+		{"2026-10-12T18:00:00Z", true},  // Monday evening
+		{"2026-10-13T07:59:00Z", true},  // Tuesday morning, Monday's window
+		{"2026-10-13T08:00:00Z", false}, // window closed
+		{"2026-10-17T12:00:00Z", true},  // Saturday
+		{"2026-10-12T03:00:00Z", false}, // Monday early, Sunday's window ended at midnight
+	}
+	for _, tc := range cases {
+		at, _ := time.Parse(time.RFC3339, tc.at)
+		if got := availability.Allows(at); got != tc.want {
+			t.Fatalf("Allows(%s) = %v, want %v", tc.at, got, tc.want)
+		}
+	}
+
+	var nilAvailability *Availability
+	if !nilAvailability.Allows(time.Now()) {
+		t.Fatal("nil availability must allow any time")
+	}
+}
+
+func TestAvailabilityNextOpen(t *testing.T) {
+	availability, errParse := ParseAvailability([]string{"mon-fri 18:00-08:00"}, "UTC")
+	if errParse != nil {
+		t.Fatalf("ParseAvailability error = %v", errParse)
+	}
+	friday, _ := time.Parse(time.RFC3339, "2026-10-16T12:00:00Z")
+	want, _ := time.Parse(time.RFC3339, "2026-10-16T18:00:00Z")
+	if got := availability.NextOpen(friday); !got.Equal(want) {
+		t.Fatalf("NextOpen(Friday noon) = %v, want %v", got, want)
+	}
+	saturday, _ := time.Parse(time.RFC3339, "2026-10-17T12:00:00Z")
+	want, _ = time.Parse(time.RFC3339, "2026-10-19T18:00:00Z")
+	if got := availability.NextOpen(saturday); !got.Equal(want) {
+		t.Fatalf("NextOpen(Saturday noon) = %v, want %v", got, want)
+	}
+}
+
+func TestAvailabilityJSONRoundTrip(t *testing.T) {
+	auth := &Auth{ID: "a"}
+	var errParse error
+	auth.Availability, errParse = AvailabilityFromMetadata(map[string]any{
+		"availability":          []any{"mon-fri 09:00-17:00"},
+		"availability_timezone": "America/New_York",
+	})
+	if errParse != nil {
+		t.Fatalf("AvailabilityFromMetadata error = %v", errParse)
+	}
+	data, errMarshal := json.Marshal(auth)
+	if errMarshal != nil {
+		t.Fatalf("Marshal error = %v", errMarshal)
+	}
+	var decoded Auth
+	if errUnmarshal := json.Unmarshal(data, &decoded); errUnmarshal != nil {
+		t.Fatalf("Unmarshal error = %v", errUnmarshal)
+	}
+	if specs := decoded.Availability.Specs(); len(specs) != 1 || specs[0] != "mon-fri 09:00-17:00" {
+		t.Fatalf("decoded specs = %v", specs)
+	}
+	if decoded.Availability.Timezone() != "America/New_York" {
+		t.Fatalf("decoded timezone = %q", decoded.Availability.Timezone())
+	}
+}
+
+func TestIsAuthBlockedForModelOutsideAvailabilityWindow(t *testing.T) {
+	availability, errParse := ParseAvailability([]string{"09:00-17:00"}, "UTC")
+	if errParse != nil {
+		t.Fatalf("ParseAvailability error = %v", errParse)
+	}
+	auth := &Auth{ID: "a", Provider: "claude", Status: StatusActive, Availability: availability}
+	night, _ := time.Parse(time.RFC3339, "2026-10-12T20:00:00Z")
+	blocked, reason, next := isAuthBlockedForModel(auth, "claude-sonnet", night)
+	if !blocked || reason != blockReasonOutsideWindow {
+		t.Fatalf("isAuthBlockedForModel = %v, %v; want blocked outside window", blocked, reason)
+	}
+	want, _ := time.Parse(time.RFC3339, "2026-10-13T09:00:00Z")
+	if !next.Equal(want) {
+		t.Fatalf("next = %v, want %v", next, want)
+	}
+	day, _ := time.Parse(time.RFC3339, "2026-10-12T10:00:00Z")
+	if blocked, _, _ = isAuthBlockedForModel(auth, "claude-sonnet", day); blocked {
+		t.Fatal("auth blocked inside its availability window")
+	}
+}
@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+const (
+	// DefaultBackgroundLaneConcurrency bounds concurrent internal requests when unset.
+	DefaultBackgroundLaneConcurrency = 2
+	// DefaultBackgroundLaneMinInterval spaces internal request starts when unset.
+	DefaultBackgroundLaneMinInterval = 200 * time.Millisecond
+)
+
+// Background lane kinds used by built-in internal traffic.
+const (
+	BackgroundKindWarmup    = "warmup"
+	BackgroundKindProbe     = "probe"
+	BackgroundKindModelList = "model-list"
+)
+
+// BackgroundLaneOptions configures the pacing of internal traffic.
+type BackgroundLaneOptions struct {
+	// MaxConcurrency bounds how many internal requests run at once (<= 0 uses the default).
+	MaxConcurrency int
+	// MinInterval is the minimum spacing between internal request starts (< 0 disables pacing).
+	MinInterval time.Duration
+}
+
+// BackgroundLaneStats is a point-in-time view of the lane.
+type BackgroundLaneStats struct {
+	Active    int   `json:"active"`
+	Waiting   int   `json:"waiting"`
+	Completed int64 `json:"completed"`
+	Failed    int64 `json:"failed"`
+}
+
+// BackgroundLane runs internal traffic (validation, warmup, probes, model list
+// refreshes) with its own concurrency limit and pacing so it never competes with
+// client requests in bursts. Work run through the lane carries a usage tag so its
+// results are kept out of user-facing success metrics.
+type BackgroundLane struct {
+	mu        sync.Mutex
+	opts      BackgroundLaneOptions
+	active    int
+	waiting   int
+	completed int64
+	failed    int64
+	next      time.Time
+	wake      chan struct{}
+}
+
+// NewBackgroundLane creates a lane with the given options.
+func NewBackgroundLane(opts BackgroundLaneOptions) *BackgroundLane {
+	lane := &BackgroundLane{wake: make(chan struct{})}
+	lane.SetOptions(opts)
+	return lane
+}
+
+// SetOptions updates the lane limits. Running work is not interrupted.
+func (l *BackgroundLane) SetOptions(opts BackgroundLaneOptions) {
+	if l == nil {
+		return
+	}
+	if opts.MaxConcurrency <= 0 {
+		opts.MaxConcurrency = DefaultBackgroundLaneConcurrency
+	}
+	if opts.MinInterval == 0 {
+		opts.MinInterval = DefaultBackgroundLaneMinInterval
+	} else if opts.MinInterval < 0 {
+		opts.MinInterval = 0
+	}
+	l.mu.Lock()
+	l.opts = opts
+	l.broadcastLocked()
+	l.mu.Unlock()
+}
+
+// Options returns the effective lane options.
+func (l *BackgroundLane) Options() BackgroundLaneOptions {
+	if l == nil {
+		return BackgroundLaneOptions{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.opts
+}
+
+// Stats returns the current lane counters.
+func (l *BackgroundLane) Stats() BackgroundLaneStats {
+	if l == nil {
+		return BackgroundLaneStats{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return BackgroundLaneStats{Active: l.active, Waiting: l.waiting, Completed: l.completed, Failed: l.failed}
+}
+
+// Run waits for a lane slot, then calls fn with a context tagged as background
+// traffic of the given kind. It returns ctx.Err() if the context ends while waiting.
+func (l *BackgroundLane) Run(ctx context.Context, kind string, fn func(context.Context) error) error {
+	if fn == nil {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = usage.WithBackgroundLane(ctx, kind)
+	if l == nil {
+		return fn(ctx)
+	}
+	if errAcquire := l.acquire(ctx); errAcquire != nil {
+		return errAcquire
+	}
+	err := fn(ctx)
+	l.release(err)
+	return err
+}
+
+func (l *BackgroundLane) acquire(ctx context.Context) error {
+	l.mu.Lock()
+	l.waiting++
+	for l.active >= l.opts.MaxConcurrency {
+		wake := l.wake
+		l.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			l.mu.Lock()
+			l.waiting--
+			l.mu.Unlock()
+			return ctx.Err()
+		case <-wake:
+		}
+		l.mu.Lock()
+	}
+	l.waiting--
+	l.active++
+	now := time.Now()
+	start := now
+	if l.next.After(start) {
+		start = l.next
+	}
+	l.next = start.Add(l.opts.MinInterval)
+	l.mu.Unlock()
+
+	if delay := start.Sub(now); delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			l.release(ctx.Err())
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	return nil
+}
+
+func (l *BackgroundLane) release(err error) {
+	l.mu.Lock()
+	l.active--
+	if err != nil {
+		l.failed++
+	} else {
+		l.completed++
+	}
+	l.broadcastLocked()
+	l.mu.Unlock()
+}
+
+func (l *BackgroundLane) broadcastLocked() {
+	close(l.wake)
+	l.wake = make(chan struct{})
+}
+
+// IsBackgroundTraffic reports whether ctx was issued through a BackgroundLane.
+func IsBackgroundTraffic(ctx context.Context) bool {
+	return usage.BackgroundLaneFromContext(ctx) != ""
+}
+
+// BackgroundLane returns the lane used for the manager's internal traffic.
+func (m *Manager) BackgroundLane() *BackgroundLane {
+	if m == nil {
+		return nil
+	}
+	m.backgroundLaneOnce.Do(func() {
+		if m.backgroundLane == nil {
+			m.backgroundLane = NewBackgroundLane(BackgroundLaneOptions{})
+		}
+	})
+	return m.backgroundLane
+}
+
+// RunBackground runs fn through the manager's background lane.
+func (m *Manager) RunBackground(ctx context.Context, kind string, fn func(context.Context) error) error {
+	return m.BackgroundLane().Run(ctx, kind, fn)
+}
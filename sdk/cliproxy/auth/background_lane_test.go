@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func TestBackgroundLaneBoundsConcurrencyAndTagsContext(t *testing.T) {
+	lane := NewBackgroundLane(BackgroundLaneOptions{MaxConcurrency: 2, MinInterval: -1})
+
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = lane.Run(context.Background(), BackgroundKindProbe, func(ctx context.Context) error {
+				if kind := usage.BackgroundLaneFromContext(ctx); kind != BackgroundKindProbe {
+					t.Errorf("lane kind = %q, want %q", kind, BackgroundKindProbe)
+				}
+				current := running.Add(1)
+				for {
+					previous := peak.Load()
+					if current <= previous || peak.CompareAndSwap(previous, current) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				running.Add(-1)
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	if got := peak.Load(); got > 2 {
+		t.Fatalf("peak concurrency = %d, want <= 2", got)
+	}
+	if stats := lane.Stats(); stats.Completed != 6 || stats.Active != 0 || stats.Waiting != 0 {
+		t.Fatalf("stats = %+v, want 6 completed and idle", stats)
+	}
+}
+
+func TestBackgroundLanePacesStartsAndHonoursCancel(t *testing.T) {
+	lane := NewBackgroundLane(BackgroundLaneOptions{MaxConcurrency: 4, MinInterval: 30 * time.Millisecond})
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if errRun := lane.Run(context.Background(), BackgroundKindWarmup, func(context.Context) error { return nil }); errRun != nil {
+			t.Fatalf("Run() error = %v", errRun)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Fatalf("three paced runs took %s, want >= 60ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	called := false
+	errRun := lane.Run(ctx, BackgroundKindWarmup, func(context.Context) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(errRun, context.Canceled) || called {
+		t.Fatalf("Run() with cancelled context = %v (called=%t), want context.Canceled", errRun, called)
+	}
+}
+
+func TestManagerMarkResultSkipsMetricsForBackgroundTraffic(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	if _, errRegister := manager.Register(context.Background(), &Auth{ID: "bg-auth", Provider: "claude"}); errRegister != nil {
+		t.Fatalf("Register() error = %v", errRegister)
+	}
+
+	ctx := usage.WithBackgroundLane(context.Background(), BackgroundKindProbe)
+	manager.MarkResult(ctx, Result{AuthID: "bg-auth", Provider: "claude", Model: "m1", Success: true})
+	manager.MarkResult(context.Background(), Result{AuthID: "bg-auth", Provider: "claude", Model: "m1", Success: true})
+
+	auth, ok := manager.GetByID("bg-auth")
+	if !ok {
+		t.Fatalf("auth not found")
+	}
+	if auth.Success != 1 {
+		t.Fatalf("Success = %d, want only the client request counted", auth.Success)
+	}
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+// capabilityFilter skips auths whose registered model lacks a capability the request
+// needs, e.g. image input sent to a text-only model. The request is only inspected once an
+// auth with declared capabilities is met, so models without metadata cost a registry lookup.
+type capabilityFilter struct {
+	registry *registry.ModelRegistry
+	opts     cliproxyexecutor.Options
+	model    string
+	resolved bool
+	required registry.Capability
+}
+
+func newCapabilityFilter(model string, opts cliproxyexecutor.Options) *capabilityFilter {
+	model = strings.TrimSpace(model)
+	if parsed := thinking.ParseSuffix(model); parsed.ModelName != "" {
+		model = strings.TrimSpace(parsed.ModelName)
+	}
+	return &capabilityFilter{registry: registry.GetGlobalRegistry(), opts: opts, model: model}
+}
+
+// allows reports whether auth's model supports everything the request requires.
+func (f *capabilityFilter) allows(auth *Auth) bool {
+	if f == nil || f.model == "" || auth == nil {
+		return true
+	}
+	caps := f.registry.ClientModelCapabilities(auth.ID, f.model)
+	if caps == nil {
+		return true
+	}
+	if !f.resolved {
+		f.required = requiredModelCapabilities(f.opts)
+		f.resolved = true
+	}
+	return caps.Missing(f.required) == 0
+}
+
+// requiredModelCapabilities inspects the inbound request for features the selected model
+// has to support. It understands the OpenAI chat, OpenAI responses, Claude and Gemini shapes.
+func requiredModelCapabilities(opts cliproxyexecutor.Options) registry.Capability {
+	var required registry.Capability
+	if opts.Stream {
+		required |= registry.CapabilityStreaming
+	}
+	if len(opts.OriginalRequest) == 0 || !gjson.ValidBytes(opts.OriginalRequest) {
+		return required
+	}
+	root := gjson.ParseBytes(opts.OriginalRequest)
+	if tools := root.Get("tools"); tools.IsArray() && len(tools.Array()) > 0 {
+		required |= registry.CapabilityTools
+	}
+	if requestWantsJSONSchema(root) {
+		required |= registry.CapabilityJSONSchema
+	}
+	if requestHasImageInput(root) {
+		required |= registry.CapabilityVision
+	}
+	return required
+}
+
+func requestWantsJSONSchema(root gjson.Result) bool {
+	for _, path := range []string{"response_format.type", "text.format.type", "output_format.type"} {
+		if root.Get(path).String() == "json_schema" {
+			return true
+		}
+	}
+	for _, path := range []string{"generationConfig.responseSchema", "generationConfig.responseJsonSchema"} {
+		if root.Get(path).Exists() {
+			return true
+		}
+	}
+	return false
+}
+
+func requestHasImageInput(root gjson.Result) bool {
+	for _, messagesPath := range []string{"messages", "input", "contents"} {
+		messages := root.Get(messagesPath)
+		if !messages.IsArray() {
+			continue
+		}
+		for _, message := range messages.Array() {
+			for _, partsPath := range []string{"content", "parts"} {
+				parts := message.Get(partsPath)
+				if !parts.IsArray() {
+					continue
+				}
+				for _, part := range parts.Array() {
+					if contentPartIsImage(part) {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
+
+func contentPartIsImage(part gjson.Result) bool {
+	switch part.Get("type").String() {
+	case "image_url", "input_image", "image":
+		return true
+	}
+	for _, path := range []string{"inlineData.mimeType", "inline_data.mime_type", "fileData.mimeType", "file_data.mime_type"} {
+		if strings.HasPrefix(part.Get(path).String(), "image/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestRequiredModelCapabilities(t *testing.T) {
+	cases := []struct {
+		name    string
+		payload string
+		stream  bool
+		want    registry.Capability
+	}{
+		{name: "plain", payload: `{"messages":[{"role":"user","content":"hi"}]}`},
+		{name: "stream", payload: `{}`, stream: true, want: registry.CapabilityStreaming},
+		{name: "openai image", payload: `{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"data:"}}]}]}`, want: registry.CapabilityVision},
+		{name: "responses image", payload: `{"input":[{"role":"user","content":[{"type":"input_image","image_url":"x"}]}]}`, want: registry.CapabilityVision},
+		{name: "gemini image", payload: `{"contents":[{"parts":[{"inlineData":{"mimeType":"image/png","data":"x"}}]}]}`, want: registry.CapabilityVision},
+		{name: "tools", payload: `{"tools":[{"type":"function"}]}`, want: registry.CapabilityTools},
+		{name: "empty tools", payload: `{"tools":[]}`},
+		{name: "json schema", payload: `{"response_format":{"type":"json_schema"}}`, want: registry.CapabilityJSONSchema},
+		{name: "gemini schema", payload: `{"generationConfig":{"responseSchema":{}}}`, want: registry.CapabilityJSONSchema},
+	}
+	for _, tc := range cases {
+		opts := cliproxyexecutor.Options{Stream: tc.stream, OriginalRequest: []byte(tc.payload)}
+		if got := requiredModelCapabilities(opts); got != tc.want {
+			t.Errorf("%s: required = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestPickNextSkipsAuthLackingCapability(t *testing.T) {
+	const model = "capability-filter-model"
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.RegisterExecutor(conductorBenchmarkExecutor{schedulerBenchmarkExecutor{id: "gemini"}})
+	ctx := context.Background()
+	reg := registry.GetGlobalRegistry()
+	for id, caps := range map[string]*registry.ModelCapabilities{
+		"caps-text-only": {SupportsTools: true, SupportsStreaming: true},
+		"caps-vision":    {SupportsTools: true, SupportsVision: true, SupportsStreaming: true},
+	} {
+		if _, errRegister := manager.Register(ctx, &Auth{ID: id, Provider: "gemini"}); errRegister != nil {
+			t.Fatalf("Register(%s) error = %v", id, errRegister)
+		}
+		reg.RegisterClient(id, "gemini", []*registry.ModelInfo{{ID: model, Capabilities: caps}})
+	}
+	t.Cleanup(func() {
+		reg.UnregisterClient("caps-text-only")
+		reg.UnregisterClient("caps-vision")
+	})
+
+	opts := cliproxyexecutor.Options{OriginalRequest: []byte(`{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"x"}}]}]}`)}
+	for i := 0; i < 4; i++ {
+		auth, _, errPick := manager.pickNext(ctx, "gemini", model, opts, map[string]struct{}{})
+		if errPick != nil {
+			t.Fatalf("pickNext error = %v", errPick)
+		}
+		if auth.ID != "caps-vision" {
+			t.Fatalf("picked %s for an image request, want caps-vision", auth.ID)
+		}
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < 4; i++ {
+		auth, _, errPick := manager.pickNext(ctx, "gemini", model, cliproxyexecutor.Options{OriginalRequest: []byte(`{"messages":[]}`)}, map[string]struct{}{})
+		if errPick != nil {
+			t.Fatalf("pickNext error = %v", errPick)
+		}
+		seen[auth.ID] = true
+	}
+	if !seen["caps-text-only"] {
+		t.Fatal("text request never picked the text-only auth")
+	}
+}
@@ -14,11 +14,13 @@ const (
 	AuthSourcePostgres    = "postgres"
 
 	AttributeAPIKey        = "api_key"
+	AttributeAPIKeyRef     = "api_key_ref"
 	AttributeAuthKind      = "auth_kind"
 	AttributePath          = "path"
 	AttributeRuntimeOnly   = "runtime_only"
 	AttributeSource        = "source"
 	AttributeSourceBackend = "source_backend"
+	AttributeSourceAddress = "source_address"
 )
 
 // AuthKind returns the credential kind using explicit metadata first and legacy
@@ -42,6 +44,16 @@ func (a *Auth) AuthKind() string {
 	return ""
 }
 
+// ConfigAPIKey returns the api-key value as written in the config: the secret
+// reference for keys resolved from a secrets backend, otherwise the key itself. Use it
+// when matching an auth back to its config entry.
+func (a *Auth) ConfigAPIKey() string {
+	if ref := authAttribute(a, AttributeAPIKeyRef); ref != "" {
+		return ref
+	}
+	return authAttribute(a, AttributeAPIKey)
+}
+
 // AuthSourceKind returns where the Auth entry came from at runtime.
 func (a *Auth) AuthSourceKind() string {
 	if a == nil {
@@ -32,6 +32,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/pluginapi"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
 	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
@@ -241,6 +242,10 @@ type Hook interface {
 	OnAuthUpdated(ctx context.Context, auth *Auth)
 	// OnResult fires when execution result is recorded.
 	OnResult(ctx context.Context, result Result)
+	// OnRefreshSucceeded fires after a credential refresh stored new tokens.
+	OnRefreshSucceeded(ctx context.Context, auth *Auth)
+	// OnRefreshFailed fires when a credential refresh returns an error.
+	OnRefreshFailed(ctx context.Context, auth *Auth, err error)
 }
 
 // NoopHook provides optional hook defaults.
@@ -255,6 +260,12 @@ func (NoopHook) OnAuthUpdated(context.Context, *Auth) {}
 // OnResult implements Hook.
 func (NoopHook) OnResult(context.Context, Result) {}
 
+// OnRefreshSucceeded implements Hook.
+func (NoopHook) OnRefreshSucceeded(context.Context, *Auth) {}
+
+// OnRefreshFailed implements Hook.
+func (NoopHook) OnRefreshFailed(context.Context, *Auth, error) {}
+
 // Manager orchestrates auth lifecycle, selection, execution, and persistence.
 type Manager struct {
 	store                     Store
@@ -266,9 +277,43 @@ type Manager struct {
 	mu                        sync.RWMutex
 	configCooldownMu          sync.Mutex
 	auths                     map[string]*Auth
-	scheduler                 *authScheduler
+	// authView publishes a copy-on-write snapshot of auths for lock-free selection scans;
+	// authGeneration is bumped under m.mu whenever the snapshot becomes stale.
+	authView       atomic.Pointer[authSnapshot]
+	authGeneration atomic.Uint64
+	scheduler      *authScheduler
 	// pluginScheduler runs outside m.mu before falling back to native selection.
 	pluginScheduler PluginScheduler
+	// backgroundLane paces internal traffic such as warmup, probes, and model list refreshes.
+	backgroundLane     *BackgroundLane
+	backgroundLaneOnce sync.Once
+	// priorityGate orders client requests waiting for an upstream slot by priority class.
+	priorityGate     *PriorityGate
+	priorityGateOnce sync.Once
+
+	// costSpend tracks per-auth daily spend for cost-aware selectors across selector rebuilds.
+	costSpend     *CostTracker
+	costSpendOnce sync.Once
+	// selectionAudit records per-request selection decisions when enabled.
+	selectionAudit atomic.Pointer[SelectionAuditLog]
+	// hookDispatcher delivers hook.OnResult off the request path when set; hookErrorPolicy
+	// and hookDisabled apply to every hook call (see callHook).
+	hookDispatcher  atomic.Pointer[hookDispatcher]
+	hookErrorPolicy atomic.Value
+	hookDisabled    atomic.Bool
+	// webhookHook receives lifecycle events alongside hook when configured.
+	webhookHook atomic.Pointer[WebhookHook]
+	// healthProbeCancel and healthProbeDone control the blocked-auth health probe loop.
+	healthProbeMu     sync.Mutex
+	healthProbeCancel context.CancelFunc
+	healthProbeDone   chan struct{}
+	// warmupCancel and warmupDone control the OAuth warmup scheduler.
+	warmupMu     sync.Mutex
+	warmupCancel context.CancelFunc
+	warmupDone   chan struct{}
+	// persistQueue batches request-result writes when write-behind persistence is on;
+	// nil means results are saved synchronously.
+	persistQueue atomic.Pointer[authPersistQueue]
 	// homeRuntimeAuths retains legacy session auth lookups for non-execution callers.
 	homeRuntimeAuths map[string]map[string]*Auth
 	// homeRuntimeAuthOwners prevents a stale selection from clearing a replacement auth.
@@ -302,17 +347,16 @@ type Manager struct {
 	// It is initialized in NewManager; never Load() before first Store().
 	runtimeConfig atomic.Value
 
+	// retryBackoffs caches the parsed retry-backoff rules of runtimeConfig, keyed by provider.
+	retryBackoffs atomic.Value
+
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
-	// fallbackModels stores model fallback mappings (original -> fallback).
-	fallbackModels atomic.Value
-
-	// fallbackChain stores the general fallback chain for models not in fallbackModels.
-	fallbackChain atomic.Value
-
-	// fallbackMaxDepth limits the number of fallback attempts.
-	fallbackMaxDepth atomic.Int32
+	// fallback stores the model fallback mappings, general fallback chain and depth
+	// limit as one immutable snapshot so reloads swap them together.
+	fallback   atomic.Pointer[fallbackSettings]
+	fallbackMu sync.Mutex
 
 	// Auto refresh state
 	refreshCancel context.CancelFunc
@@ -322,6 +366,8 @@ type Manager struct {
 	// refreshLocks serializes credential refresh per auth ID so concurrent
 	// 401 recoveries and auto-refresh workers do not race the same refresh_token.
 	refreshLocks sync.Map
+	// refreshLocker coordinates refreshes of shared credentials across replicas.
+	refreshLocker RefreshLocker
 }
 
 // NewManager constructs a manager with optional custom selector and hook.
@@ -348,6 +394,7 @@ func NewManager(store Store, selector Selector, hook Hook) *Manager {
 	// atomic.Value requires non-nil initial value.
 	manager.runtimeConfig.Store(&internalconfig.Config{})
 	manager.apiKeyModelAlias.Store(apiKeyModelAliasTable(nil))
+	manager.retryBackoffs.Store(retryBackoffTable(nil))
 	defaultInFlightConfig, errInFlightConfig := HomeInFlightPublisherConfigFromConfig(internalconfig.DefaultCredentialInFlightConfig())
 	if errInFlightConfig == nil {
 		manager.ApplyHomeInFlightPublisherConfig(defaultInFlightConfig)
@@ -733,6 +780,7 @@ func (m *Manager) setConfigSnapshotLocked(cfg *internalconfig.Config) bool {
 	oldCooldownStore := m.cooldownStore
 	m.mu.RUnlock()
 	m.runtimeConfig.Store(cfg)
+	m.retryBackoffs.Store(parseRetryBackoffRules(cfg.RetryBackoff))
 	clearedCooldowns := m.clearDisabledCooldownStates(cfg)
 	if clearedCooldowns && oldCooldownStore != nil {
 		m.mu.Lock()
@@ -1496,14 +1544,7 @@ func (m *Manager) executionModelCandidates(auth *Auth, routeModel string) []stri
 }
 
 func (m *Manager) selectionModelForAuth(auth *Auth, routeModel string) string {
-	requestedModel := rewriteModelForAuth(routeModel, auth)
-	if strings.TrimSpace(requestedModel) == "" {
-		requestedModel = strings.TrimSpace(routeModel)
-	}
-	resolvedModel := m.applyOAuthModelAlias(auth, requestedModel)
-	if strings.TrimSpace(resolvedModel) == "" {
-		resolvedModel = requestedModel
-	}
+	requestedModel, resolvedModel := m.aliasSelectionModelForAuth(auth, routeModel)
 	if canonicalModelKey(resolvedModel) == canonicalModelKey(requestedModel) {
 		if blocked, _, _ := isAuthBlockedForModel(auth, requestedModel, time.Now()); blocked {
 			if fallback := m.resolveBlockedForkAliasTarget(auth, requestedModel); strings.TrimSpace(fallback) != "" {
@@ -1514,6 +1555,22 @@ func (m *Manager) selectionModelForAuth(auth *Auth, routeModel string) string {
 	return resolvedModel
 }
 
+// aliasSelectionModelForAuth returns the route model with the auth prefix stripped and the
+// model it resolves to through OAuth aliases. Unlike selectionModelForAuth it ignores the
+// auth's runtime block state, so the result only changes with the auth, the alias table
+// and the model registry.
+func (m *Manager) aliasSelectionModelForAuth(auth *Auth, routeModel string) (requestedModel, resolvedModel string) {
+	requestedModel = rewriteModelForAuth(routeModel, auth)
+	if strings.TrimSpace(requestedModel) == "" {
+		requestedModel = strings.TrimSpace(routeModel)
+	}
+	resolvedModel = m.applyOAuthModelAlias(auth, requestedModel)
+	if strings.TrimSpace(resolvedModel) == "" {
+		resolvedModel = requestedModel
+	}
+	return requestedModel, resolvedModel
+}
+
 func (m *Manager) selectionModelKeyForAuth(auth *Auth, routeModel string) string {
 	return canonicalModelKey(m.selectionModelForAuth(auth, routeModel))
 }
@@ -2328,6 +2385,8 @@ func (m *Manager) pickViaBuiltinScheduler(ctx context.Context, strategy schedule
 	}
 	providerKey := strings.ToLower(strings.TrimSpace(provider))
 	disallowFreeAuth := disallowFreeAuthFromMetadata(opts.Metadata)
+	capabilities := newCapabilityFilter(model, opts)
+	groups := m.newGroupFilter(model, opts)
 	for {
 		var selected *Auth
 		var errPick error
@@ -2350,7 +2409,7 @@ func (m *Manager) pickViaBuiltinScheduler(ctx context.Context, strategy schedule
 		if selected == nil {
 			return nil, true, &Error{Code: "auth_not_found", Message: "selector returned no auth"}
 		}
-		if disallowFreeAuth && isFreeCodexAuth(selected) {
+		if (disallowFreeAuth && isFreeCodexAuth(selected)) || !capabilities.allows(selected) || !groups.allows(selected) || !selected.Availability.Allows(time.Now()) {
 			if tried == nil {
 				tried = make(map[string]struct{})
 			}
@@ -2681,24 +2740,69 @@ func streamErrorResult(headers http.Header, err error) *cliproxyexecutor.StreamR
 	}
 }
 
-func readStreamBootstrap(ctx context.Context, ch <-chan cliproxyexecutor.StreamChunk) ([]cliproxyexecutor.StreamChunk, bool, error) {
+// streamIdleTimeout returns the configured upstream stream idle timeout; 0 disables it.
+func (m *Manager) streamIdleTimeout() time.Duration {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || cfg.Streaming.IdleTimeoutSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(cfg.Streaming.IdleTimeoutSeconds) * time.Second
+}
+
+// streamFirstChunkTimeout returns how long to wait for the first chunk of a stream before
+// failing over; it defaults to the idle timeout and 0 disables it.
+func (m *Manager) streamFirstChunkTimeout() time.Duration {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg != nil && cfg.Streaming.FirstChunkTimeoutSeconds > 0 {
+		return time.Duration(cfg.Streaming.FirstChunkTimeoutSeconds) * time.Second
+	}
+	return m.streamIdleTimeout()
+}
+
+// newStreamIdleError reports an upstream stream that went silent. It is retryable so the
+// stall fails over to another credential when no bytes were sent yet.
+func newStreamIdleError(idle time.Duration) *Error {
+	return &Error{
+		Code:       "stream_idle_timeout",
+		Message:    fmt.Sprintf("upstream stream produced no data for %s", idle),
+		Retryable:  true,
+		HTTPStatus: http.StatusGatewayTimeout,
+	}
+}
+
+// idleTimer returns a channel firing after idle, or nil when the timeout is disabled.
+func idleTimer(idle time.Duration) (*time.Timer, <-chan time.Time) {
+	if idle <= 0 {
+		return nil, nil
+	}
+	timer := time.NewTimer(idle)
+	return timer, timer.C
+}
+
+func readStreamBootstrap(ctx context.Context, ch <-chan cliproxyexecutor.StreamChunk, idle time.Duration) ([]cliproxyexecutor.StreamChunk, bool, error) {
 	if ch == nil {
 		return nil, true, nil
 	}
+	timer, timeout := idleTimer(idle)
+	if timer != nil {
+		defer timer.Stop()
+	}
+	var done <-chan struct{}
+	if ctx != nil {
+		done = ctx.Done()
+	}
 	buffered := make([]cliproxyexecutor.StreamChunk, 0, 1)
 	for {
 		var (
 			chunk cliproxyexecutor.StreamChunk
 			ok    bool
 		)
-		if ctx != nil {
-			select {
-			case <-ctx.Done():
-				return nil, false, ctx.Err()
-			case chunk, ok = <-ch:
-			}
-		} else {
-			chunk, ok = <-ch
+		select {
+		case <-done:
+			return nil, false, ctx.Err()
+		case <-timeout:
+			return nil, false, newStreamIdleError(idle)
+		case chunk, ok = <-ch:
 		}
 		if !ok {
 			return buffered, true, nil
@@ -2706,6 +2810,11 @@ func readStreamBootstrap(ctx context.Context, ch <-chan cliproxyexecutor.StreamC
 		if chunk.Err != nil {
 			return nil, false, chunk.Err
 		}
+		if errPayload := streamErrorPayload(chunk.Payload); errPayload != nil {
+			// An error delivered as the first event of a 200 stream has not reached the
+			// client yet, so it is failed over like a failed request.
+			return nil, false, errPayload
+		}
 		buffered = append(buffered, chunk)
 		if len(chunk.Payload) > 0 {
 			return buffered, false, nil
@@ -2713,20 +2822,95 @@ func readStreamBootstrap(ctx context.Context, ch <-chan cliproxyexecutor.StreamC
 	}
 }
 
-func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, resultModel string, headers http.Header, buffered []cliproxyexecutor.StreamChunk, remaining <-chan cliproxyexecutor.StreamChunk, aliasResult OAuthModelAliasResult, ephemeralResult bool) *cliproxyexecutor.StreamResult {
+// streamErrorPayload returns the error carried by a stream chunk whose only content is an
+// upstream error object (OpenAI/Gemini {"error":...} or a Claude error event), or nil.
+func streamErrorPayload(payload []byte) *Error {
+	var data []byte
+	for _, line := range bytes.Split(payload, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || bytes.HasPrefix(line, []byte(":")) || bytes.HasPrefix(line, []byte("event:")) {
+			continue
+		}
+		if bytes.HasPrefix(line, []byte("data:")) {
+			line = bytes.TrimSpace(line[len("data:"):])
+		}
+		if data != nil {
+			// More than one data line is a regular event sequence.
+			return nil
+		}
+		data = line
+	}
+	if len(data) == 0 || data[0] != '{' || !gjson.ValidBytes(data) {
+		return nil
+	}
+	errNode := gjson.GetBytes(data, "error")
+	if !errNode.IsObject() {
+		return nil
+	}
+	if typ := gjson.GetBytes(data, "type").String(); typ != "" && typ != "error" {
+		return nil
+	}
+	if gjson.GetBytes(data, "choices").Exists() || gjson.GetBytes(data, "candidates").Exists() {
+		return nil
+	}
+	message := errNode.Get("message").String()
+	if message == "" {
+		message = string(data)
+	}
+	status := http.StatusBadGateway
+	if code := int(errNode.Get("code").Int()); code >= http.StatusBadRequest && code < 600 {
+		status = code
+	} else {
+		switch errNode.Get("type").String() {
+		case "rate_limit_error":
+			status = http.StatusTooManyRequests
+		case "overloaded_error":
+			status = 529
+		case "authentication_error":
+			status = http.StatusUnauthorized
+		case "permission_error":
+			status = http.StatusForbidden
+		case "invalid_request_error":
+			status = http.StatusBadRequest
+		}
+	}
+	return &Error{
+		Code:       "stream_error_payload",
+		Message:    message,
+		Retryable:  status >= http.StatusInternalServerError || status == http.StatusTooManyRequests,
+		HTTPStatus: status,
+	}
+}
+
+// wrapStreamResult forwards the upstream chunks of a successful attempt. cancelUpstream,
+// when set, cancels the attempt's executor context; it runs once the stream ends and
+// before draining a stream that went idle so the executor releases its connection.
+func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, resultModel string, headers http.Header, buffered []cliproxyexecutor.StreamChunk, remaining <-chan cliproxyexecutor.StreamChunk, aliasResult OAuthModelAliasResult, ephemeralResult bool, cancelUpstream context.CancelFunc) *cliproxyexecutor.StreamResult {
 	out := make(chan cliproxyexecutor.StreamChunk)
 	go func() {
 		defer close(out)
+		if cancelUpstream != nil {
+			defer cancelUpstream()
+		}
 		var failed bool
 		forward := true
 		var rewriter *StreamRewriter
 		if aliasResult.ForceMapping && strings.TrimSpace(aliasResult.OriginalAlias) != "" {
 			rewriter = NewStreamRewriter(StreamRewriteOptions{RewriteModel: aliasResult.OriginalAlias})
 		}
+		recordCancelled := func() {
+			if !failed {
+				failed = true
+				m.recordExecutionResult(ctx, Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: false, Error: clientCancelledError(ctx, context.Canceled)}, auth, ephemeralResult)
+			}
+		}
 		emit := func(chunk cliproxyexecutor.StreamChunk) bool {
 			if chunk.Err != nil && !failed {
 				failed = true
-				rerr := resultErrorFromError(chunk.Err)
+				rerr := clientCancelledError(ctx, chunk.Err)
+				if rerr == nil {
+					rerr = resultErrorFromError(chunk.Err)
+				}
 				m.recordExecutionResult(ctx, Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: false, Error: rerr}, auth, ephemeralResult)
 			}
 			if !forward {
@@ -2767,12 +2951,39 @@ func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, re
 		}
 		for _, chunk := range buffered {
 			if ok := emit(chunk); !ok {
+				recordCancelled()
 				discardStreamChunks(remaining)
 				return
 			}
 		}
-		for chunk := range remaining {
+		idle := m.streamIdleTimeout()
+		timer, timeout := idleTimer(idle)
+		if timer != nil {
+			defer timer.Stop()
+		}
+		for {
+			var chunk cliproxyexecutor.StreamChunk
+			var open bool
+			select {
+			case chunk, open = <-remaining:
+			case <-timeout:
+				// The upstream went silent: cancel it so the executor closes the connection,
+				// then end the stream with a retryable error.
+				if cancelUpstream != nil {
+					cancelUpstream()
+				}
+				discardStreamChunks(remaining)
+				emit(cliproxyexecutor.StreamChunk{Err: newStreamIdleError(idle)})
+				return
+			}
+			if !open {
+				break
+			}
+			if timer != nil {
+				timer.Reset(idle)
+			}
 			if ok := emit(chunk); !ok {
+				recordCancelled()
 				discardStreamChunks(remaining)
 				return
 			}
@@ -2780,6 +2991,7 @@ func (m *Manager) wrapStreamResult(ctx context.Context, auth *Auth, provider, re
 		if tail := finishForceMappedStreamChunks(rewriter); len(tail) > 0 {
 			tailChunk := cliproxyexecutor.StreamChunk{Payload: tail}
 			if !emit(tailChunk) {
+				recordCancelled()
 				return
 			}
 		}
@@ -2796,8 +3008,22 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 	}
 	ctx = contextWithRequestedModelAlias(ctx, opts, routeModel)
 	var lastErr error
+	var attempts modelAttempts
 	didRefreshOnUnauthorized := false
+	// Each attempt runs the executor under its own context so an abandoned stream can be
+	// cancelled; the context of the returned stream is handed to wrapStreamResult.
+	var cancelAttempt context.CancelFunc
+	defer func() {
+		if cancelAttempt != nil {
+			cancelAttempt()
+		}
+	}()
 	for idx, execModel := range execModels {
+		if cancelAttempt != nil {
+			cancelAttempt()
+		}
+		attemptCtx, cancel := context.WithCancel(ctx)
+		cancelAttempt = cancel
 		resultModel := m.stateModelForExecution(auth, routeModel, execModel, pooled)
 		execReq := req
 		execReq.Model = execModel
@@ -2813,24 +3039,27 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 		if errCtx := ctx.Err(); errCtx != nil {
 			return nil, errCtx
 		}
-		streamResult, errStream := executor.ExecuteStream(ctx, auth, execReq, execOpts)
+		streamResult, errStream := executeProviderStream(attemptCtx, executor, auth, execReq, execOpts)
 		if errStream != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
+				m.markClientCancelled(execCtx, auth, provider, resultModel, ephemeralResult)
 				return nil, errCtx
 			}
 			if allowRetry {
 				if refreshed, okRefresh := m.tryRefreshAfterUnauthorized(ctx, auth, errStream, didRefreshOnUnauthorized); okRefresh {
 					auth = refreshed
 					didRefreshOnUnauthorized = true
-					streamResult, errStream = executor.ExecuteStream(ctx, auth, execReq, execOpts)
+					streamResult, errStream = executeProviderStream(attemptCtx, executor, auth, execReq, execOpts)
 					if errStream != nil {
 						if errCtx := ctx.Err(); errCtx != nil {
+							m.markClientCancelled(ctx, auth, provider, resultModel, ephemeralResult)
 							return nil, errCtx
 						}
 					}
 				}
 			}
 		}
+		errStream = m.classifyForbiddenError(provider, errStream)
 		if errStream == nil && (streamResult == nil || streamResult.Chunks == nil) {
 			errStream = &Error{Code: "empty_stream", Message: "upstream stream has no source", Retryable: true}
 		}
@@ -2842,13 +3071,15 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 			if isRequestInvalidError(errStream) {
 				return nil, errStream
 			}
+			attempts.add(execModel, errStream)
 			lastErr = errStream
 			continue
 		}
 
-		buffered, closed, bootstrapErr := readStreamBootstrap(execCtx, streamResult.Chunks)
+		buffered, closed, bootstrapErr := readStreamBootstrap(execCtx, streamResult.Chunks, m.streamFirstChunkTimeout())
 		if bootstrapErr != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
+				m.markClientCancelled(execCtx, auth, provider, resultModel, ephemeralResult)
 				discardStreamChunks(streamResult.Chunks)
 				return nil, errCtx
 			}
@@ -2857,20 +3088,22 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 					discardStreamChunks(streamResult.Chunks)
 					auth = refreshed
 					didRefreshOnUnauthorized = true
-					retryStream, retryErr := executor.ExecuteStream(ctx, auth, execReq, execOpts)
+					retryStream, retryErr := executeProviderStream(attemptCtx, executor, auth, execReq, execOpts)
 					if retryErr != nil {
 						if errCtx := ctx.Err(); errCtx != nil {
+							m.markClientCancelled(ctx, auth, provider, resultModel, ephemeralResult)
 							return nil, errCtx
 						}
 						bootstrapErr = retryErr
 						streamResult = &cliproxyexecutor.StreamResult{}
 					} else {
 						streamResult = retryStream
-						buffered, closed, bootstrapErr = readStreamBootstrap(ctx, streamResult.Chunks)
+						buffered, closed, bootstrapErr = readStreamBootstrap(ctx, streamResult.Chunks, m.streamFirstChunkTimeout())
 					}
 				}
 			}
 		}
+		bootstrapErr = m.classifyForbiddenError(provider, bootstrapErr)
 		if bootstrapErr != nil {
 			if isRequestInvalidError(bootstrapErr) {
 				rerr := resultErrorFromError(bootstrapErr)
@@ -2886,6 +3119,7 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 				result.RetryAfter = retryAfterFromError(bootstrapErr)
 				m.recordExecutionResult(ctx, result, auth, ephemeralResult)
 				discardStreamChunks(streamResult.Chunks)
+				attempts.add(execModel, bootstrapErr)
 				lastErr = bootstrapErr
 				continue
 			}
@@ -2894,18 +3128,20 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 			result.RetryAfter = retryAfterFromError(bootstrapErr)
 			m.recordExecutionResult(ctx, result, auth, ephemeralResult)
 			discardStreamChunks(streamResult.Chunks)
-			return nil, newStreamBootstrapError(bootstrapErr, streamResult.Headers)
+			attempts.add(execModel, bootstrapErr)
+			return nil, newStreamBootstrapError(attempts.wrap(bootstrapErr), streamResult.Headers)
 		}
 
 		if closed && len(buffered) == 0 {
 			emptyErr := &Error{Code: "empty_stream", Message: "upstream stream closed before first payload", Retryable: true}
 			result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: false, Error: emptyErr}
 			m.recordExecutionResult(ctx, result, auth, ephemeralResult)
+			attempts.add(execModel, emptyErr)
 			if idx < len(execModels)-1 {
 				lastErr = emptyErr
 				continue
 			}
-			return nil, newStreamBootstrapError(emptyErr, streamResult.Headers)
+			return nil, newStreamBootstrapError(attempts.wrap(emptyErr), streamResult.Headers)
 		}
 
 		remaining := streamResult.Chunks
@@ -2914,12 +3150,14 @@ func (m *Manager) executeStreamWithModelPool(ctx context.Context, executor Provi
 			close(closedCh)
 			remaining = closedCh
 		}
-		return m.wrapStreamResult(ctx, auth.Clone(), provider, resultModel, streamResult.Headers, buffered, remaining, aliasResult, ephemeralResult), nil
+		cancelUpstream := cancelAttempt
+		cancelAttempt = nil
+		return m.wrapStreamResult(ctx, auth.readView(), provider, resultModel, streamResult.Headers, buffered, remaining, aliasResult, ephemeralResult, cancelUpstream), nil
 	}
 	if lastErr == nil {
 		lastErr = &Error{Code: "auth_not_found", Message: "no upstream model available"}
 	}
-	return nil, lastErr
+	return nil, attempts.wrap(lastErr)
 }
 
 func (m *Manager) rebuildAPIKeyModelAliasFromRuntimeConfig() {
@@ -3083,85 +3321,110 @@ func (m *Manager) SetRetryConfig(retry int, maxRetryInterval time.Duration, maxR
 	m.maxRetryInterval.Store(maxRetryInterval.Nanoseconds())
 }
 
-func (m *Manager) SetFallbackModels(models map[string]string) {
-	if m == nil {
-		return
+// fallbackSettings is an immutable snapshot of the route-model fallback configuration.
+type fallbackSettings struct {
+	// models maps original model names to fallback model names.
+	models map[string]string
+	// chain is the general fallback chain for models not in models.
+	chain []string
+	// maxDepth limits the number of fallback attempts.
+	maxDepth int
+}
+
+func newFallbackSettings(models map[string]string, chain []string, maxDepth int) *fallbackSettings {
+	settings := &fallbackSettings{
+		models:   make(map[string]string, len(models)),
+		chain:    append([]string{}, chain...),
+		maxDepth: maxDepth,
 	}
-	if models == nil {
-		models = make(map[string]string)
+	for from, to := range models {
+		settings.models[from] = to
 	}
-	m.fallbackModels.Store(models)
+	if settings.maxDepth <= 0 {
+		settings.maxDepth = 3
+	}
+	return settings
 }
 
-func (m *Manager) getFallbackModel(originalModel string) (string, bool) {
+func (m *Manager) fallbackSnapshot() *fallbackSettings {
 	if m == nil {
-		return "", false
+		return nil
 	}
-	models, ok := m.fallbackModels.Load().(map[string]string)
-	if !ok || models == nil {
-		return "", false
+	return m.fallback.Load()
+}
+
+// SetFallbackConfig replaces the fallback-models map, fallback chain and depth limit
+// in one step. Requests already resolving fallbacks keep the snapshot they started with.
+func (m *Manager) SetFallbackConfig(models map[string]string, chain []string, maxDepth int) {
+	if m == nil {
+		return
 	}
-	fallback, exists := models[originalModel]
-	return fallback, exists && fallback != ""
+	m.fallbackMu.Lock()
+	m.fallback.Store(newFallbackSettings(models, chain, maxDepth))
+	m.fallbackMu.Unlock()
 }
 
-func (m *Manager) SetFallbackChain(chain []string, maxDepth int) {
+func (m *Manager) SetFallbackModels(models map[string]string) {
 	if m == nil {
 		return
 	}
-	if chain == nil {
-		chain = []string{}
+	m.fallbackMu.Lock()
+	defer m.fallbackMu.Unlock()
+	var chain []string
+	maxDepth := 0
+	if current := m.fallback.Load(); current != nil {
+		chain, maxDepth = current.chain, current.maxDepth
 	}
-	m.fallbackChain.Store(chain)
-	if maxDepth <= 0 {
-		maxDepth = 3
+	m.fallback.Store(newFallbackSettings(models, chain, maxDepth))
+}
+
+func (m *Manager) getFallbackModel(originalModel string) (string, bool) {
+	return m.fallbackSnapshot().fallbackModel(originalModel)
+}
+
+func (s *fallbackSettings) fallbackModel(originalModel string) (string, bool) {
+	if s == nil {
+		return "", false
 	}
-	m.fallbackMaxDepth.Store(int32(maxDepth))
+	fallback, exists := s.models[originalModel]
+	return fallback, exists && fallback != ""
 }
 
-func (m *Manager) getFallbackChain() []string {
+func (m *Manager) SetFallbackChain(chain []string, maxDepth int) {
 	if m == nil {
-		return nil
+		return
 	}
-	chain, ok := m.fallbackChain.Load().([]string)
-	if !ok {
-		return nil
+	m.fallbackMu.Lock()
+	defer m.fallbackMu.Unlock()
+	var models map[string]string
+	if current := m.fallback.Load(); current != nil {
+		models = current.models
 	}
-	return chain
+	m.fallback.Store(newFallbackSettings(models, chain, maxDepth))
 }
 
 // FallbackChain returns the current fallback chain for logging/diagnostics.
 func (m *Manager) FallbackChain() []string {
-	return m.getFallbackChain()
+	settings := m.fallbackSnapshot()
+	if settings == nil {
+		return nil
+	}
+	return settings.chain
 }
 
 // FallbackModels returns the current fallback-models map for logging/diagnostics.
 func (m *Manager) FallbackModels() map[string]string {
-	if m == nil {
+	settings := m.fallbackSnapshot()
+	if settings == nil || settings.models == nil {
 		return nil
 	}
-	models, ok := m.fallbackModels.Load().(map[string]string)
-	if !ok || models == nil {
-		return nil
-	}
-	out := make(map[string]string, len(models))
-	for k, v := range models {
-		out[k] = v
+	out := make(map[string]string, len(settings.models))
+	for from, to := range settings.models {
+		out[from] = to
 	}
 	return out
 }
 
-func (m *Manager) getFallbackMaxDepth() int {
-	if m == nil {
-		return 3
-	}
-	depth := m.fallbackMaxDepth.Load()
-	if depth <= 0 {
-		return 3
-	}
-	return int(depth)
-}
-
 // RegisterExecutor registers a provider executor with the manager.
 func (m *Manager) RegisterExecutor(executor ProviderExecutor) {
 	if executor == nil {
@@ -3209,6 +3472,7 @@ func (m *Manager) Register(ctx context.Context, auth *Auth) (*Auth, error) {
 	authClone := auth.Clone()
 	m.mu.Lock()
 	m.auths[auth.ID] = authClone
+	m.bumpAuthGenerationLocked()
 	m.mu.Unlock()
 	if !shouldDeferAPIKeyModelAliasRebuild(ctx) {
 		m.rebuildAPIKeyModelAliasFromRuntimeConfig()
@@ -3218,7 +3482,7 @@ func (m *Manager) Register(ctx context.Context, auth *Auth) (*Auth, error) {
 	}
 	m.queueRefreshReschedule(auth.ID)
 	_ = m.persist(ctx, auth)
-	m.hook.OnAuthRegistered(ctx, auth.Clone())
+	m.notifyAuthRegistered(ctx, auth)
 	return auth.Clone(), nil
 }
 
@@ -3240,6 +3504,14 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 	auth.Success = existing.Success
 	auth.Failed = existing.Failed
 	auth.recentRequests = existing.recentRequests
+	if auth.LastRefreshedAt.Equal(existing.LastRefreshedAt) && auth.RefreshFailures < existing.RefreshFailures {
+		// Reloads of the stored auth do not reset the failure streak; a successful refresh does.
+		auth.RefreshFailures = existing.RefreshFailures
+	}
+	if auth.BenchedUntil.IsZero() {
+		// Benches are runtime state; reloading the stored auth keeps them.
+		auth.BenchedUntil, auth.BenchReason = existing.BenchedUntil, existing.BenchReason
+	}
 	if !existing.Disabled && existing.Status != StatusDisabled && !auth.Disabled && auth.Status != StatusDisabled {
 		if len(auth.ModelStates) == 0 && len(existing.ModelStates) > 0 {
 			auth.ModelStates = existing.ModelStates
@@ -3248,6 +3520,7 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 	auth.EnsureIndex()
 	authClone := auth.Clone()
 	m.auths[auth.ID] = authClone
+	m.bumpAuthGenerationLocked()
 	m.mu.Unlock()
 	if !shouldDeferAPIKeyModelAliasRebuild(ctx) {
 		m.rebuildAPIKeyModelAliasFromRuntimeConfig()
@@ -3257,7 +3530,7 @@ func (m *Manager) Update(ctx context.Context, auth *Auth) (*Auth, error) {
 	}
 	m.queueRefreshReschedule(auth.ID)
 	_ = m.persist(ctx, auth)
-	m.hook.OnAuthUpdated(ctx, auth.Clone())
+	m.notifyAuthUpdated(ctx, auth)
 	return auth.Clone(), nil
 }
 
@@ -3281,6 +3554,7 @@ func (m *Manager) Remove(ctx context.Context, id string) {
 	}
 	provider := strings.TrimSpace(existing.Provider)
 	delete(m.auths, id)
+	m.bumpAuthGenerationLocked()
 	if m.modelPoolOffsets != nil {
 		delete(m.modelPoolOffsets, id)
 	}
@@ -3342,6 +3616,7 @@ func (m *Manager) Load(ctx context.Context) error {
 		auth.EnsureIndex()
 		m.auths[auth.ID] = auth.Clone()
 	}
+	m.bumpAuthGenerationLocked()
 	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
 	if cfg == nil {
 		cfg = &internalconfig.Config{}
@@ -3354,11 +3629,13 @@ func (m *Manager) Load(ctx context.Context) error {
 
 // Execute performs a non-streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
-func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
+	ctx, auditTrace := m.beginSelectionAudit(ctx, "execute", normalized, authSelectionModelFromOptions(opts, req.Model))
+	defer func() { m.finishSelectionAudit(auditTrace, err) }()
 	if m.HomeEnabled() {
 		return m.executeHome(ctx, normalized, req, opts, false)
 	}
@@ -3368,7 +3645,12 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 	var lastErr error
 	retryModel := authSelectionModelFromOptions(opts, req.Model)
 	for attempt := 0; ; attempt++ {
+		release, errAcquire := m.acquireRequestSlot(ctx, opts)
+		if errAcquire != nil {
+			return cliproxyexecutor.Response{}, errAcquire
+		}
 		resp, errExec := m.executeMixedOnce(ctx, normalized, req, opts, maxRetryCredentials)
+		releaseRequestSlot(release)
 		if errExec == nil {
 			return resp, nil
 		}
@@ -3377,6 +3659,9 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 		if !shouldRetry {
 			break
 		}
+		if requestDeadlineBlocksAttempt(ctx, opts.Metadata, wait) {
+			break
+		}
 		if errWait := waitForCooldown(ctx, wait, maxWait); errWait != nil {
 			return cliproxyexecutor.Response{}, errWait
 		}
@@ -3388,11 +3673,13 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 }
 
 // It supports multiple providers for the same model and round-robins the starting provider per model.
-func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
 	normalized := m.normalizeProviders(providers)
 	if len(normalized) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
+	ctx, auditTrace := m.beginSelectionAudit(ctx, "count", normalized, authSelectionModelFromOptions(opts, req.Model))
+	defer func() { m.finishSelectionAudit(auditTrace, err) }()
 	if m.HomeEnabled() {
 		return m.executeHome(ctx, normalized, req, opts, true)
 	}
@@ -3402,7 +3689,12 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 	var lastErr error
 	retryModel := authSelectionModelFromOptions(opts, req.Model)
 	for attempt := 0; ; attempt++ {
+		release, errAcquire := m.acquireRequestSlot(ctx, opts)
+		if errAcquire != nil {
+			return cliproxyexecutor.Response{}, errAcquire
+		}
 		resp, errExec := m.executeCountMixedOnce(ctx, normalized, req, opts, maxRetryCredentials)
+		releaseRequestSlot(release)
 		if errExec == nil {
 			return resp, nil
 		}
@@ -3411,6 +3703,9 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 		if !shouldRetry {
 			break
 		}
+		if requestDeadlineBlocksAttempt(ctx, opts.Metadata, wait) {
+			break
+		}
 		if errWait := waitForCooldown(ctx, wait, maxWait); errWait != nil {
 			return cliproxyexecutor.Response{}, errWait
 		}
@@ -3433,10 +3728,12 @@ func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cli
 	if len(normalized) == 0 {
 		return nil, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
+	ctx, auditTrace := m.beginSelectionAudit(ctx, "stream", normalized, authSelectionModelFromOptions(opts, req.Model))
 	result, err := m.executeStreamWithRouteFallback(ctx, normalized, req, opts, m.executeStreamMixedOnce)
 	if err == nil {
-		return result, nil
+		return m.finishSelectionAuditWhenStreamDone(ctx, auditTrace, result), nil
 	}
+	m.finishSelectionAudit(auditTrace, err)
 	if hasAntigravityProvider(normalized) && shouldAttemptAntigravityCreditsFallback(m, err, normalized) {
 		if fallbackResult, ok, _ := m.tryAntigravityCreditsExecuteStream(ctx, req, opts); ok {
 			return fallbackResult, nil
@@ -3460,6 +3757,9 @@ func (m *Manager) executeHome(ctx context.Context, providers []string, req clipr
 	tried := make(map[string]struct{})
 	var lastErr error
 	for homeAuthCount := 1; ; homeAuthCount++ {
+		if lastErr != nil && requestDeadlineBlocksAttempt(ctx, opts.Metadata, 0) {
+			return cliproxyexecutor.Response{}, lastErr
+		}
 		selection, errSelection := m.pickHomeDispatchSelection(ctx, routeModel, withHomeAuthCount(opts, homeAuthCount))
 		if errSelection != nil {
 			if lastErr != nil && isHomeRequestRetryExceededError(errSelection) {
@@ -3538,9 +3838,9 @@ func (m *Manager) executeHome(ctx context.Context, providers []string, req clipr
 			var response cliproxyexecutor.Response
 			var errExecute error
 			if countTokens {
-				response, errExecute = selection.Executor.CountTokens(execCtx, preparedAuth, execReq, execOpts)
+				response, errExecute = countProviderTokens(execCtx, selection.Executor, preparedAuth, execReq, execOpts)
 			} else {
-				response, errExecute = selection.Executor.Execute(execCtx, preparedAuth, execReq, execOpts)
+				response, errExecute = executeProvider(execCtx, selection.Executor, preparedAuth, execReq, execOpts)
 			}
 			result := Result{AuthID: preparedAuth.ID, Provider: selection.Provider, Model: resultModel, Success: errExecute == nil}
 			if errExecute == nil {
@@ -3608,7 +3908,7 @@ func requestToFormat(provider string, executor ProviderExecutor, req cliproxyexe
 		}
 	}
 	source := opts.SourceFormat.String()
-	if source == "openai-image" || source == "openai-video" {
+	if source == ImagesSourceFormat || source == "openai-video" || source == EmbeddingsSourceFormat {
 		return opts.SourceFormat
 	}
 	if opts.Alt == "responses/compact" && !opts.Stream {
@@ -3673,10 +3973,13 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 	providers = m.filterProvidersForThreshold(routeModel, providers, opts)
 	homeMode := m.HomeEnabled()
 	homeAuthCount := 1
-	tried := make(map[string]struct{})
+	tried := excludedAuthIDsFromMetadata(opts.Metadata)
 	attempted := make(map[string]struct{})
 	var lastErr error
 	for {
+		if lastErr != nil && requestDeadlineBlocksAttempt(ctx, opts.Metadata, 0) {
+			return cliproxyexecutor.Response{}, lastErr
+		}
 		if !homeMode && maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -3726,6 +4029,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 		}
 
 		var authErr error
+		var attempts modelAttempts
 		didRefreshOnUnauthorized := false
 		for _, upstreamModel := range models {
 			resultModel := m.stateModelForExecution(auth, routeModel, upstreamModel, pooled)
@@ -3743,22 +4047,25 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 			}
 			execOpts := opts
 			execReq, execOpts = applyRequestAfterAuthInterceptor(execCtx, executor, provider, execReq, execOpts, requestedModelAliasFromOptions(execOpts, routeModel))
-			resp, errExec := executor.Execute(execCtx, auth, execReq, execOpts)
+			resp, errExec := executeProvider(execCtx, executor, auth, execReq, execOpts)
 			if errExec != nil {
 				if errCtx := attemptCtx.Err(); errCtx != nil {
+					m.markClientCancelled(attemptCtx, auth, provider, resultModel, false)
 					return cliproxyexecutor.Response{}, errCtx
 				}
 				if refreshed, okRefresh := m.tryRefreshAfterUnauthorized(execCtx, auth, errExec, didRefreshOnUnauthorized); okRefresh {
 					auth = refreshed
 					didRefreshOnUnauthorized = true
-					resp, errExec = executor.Execute(execCtx, auth, execReq, execOpts)
+					resp, errExec = executeProvider(execCtx, executor, auth, execReq, execOpts)
 					if errExec != nil {
 						if errCtx := execCtx.Err(); errCtx != nil {
+							m.markClientCancelled(execCtx, auth, provider, resultModel, false)
 							return cliproxyexecutor.Response{}, errCtx
 						}
 					}
 				}
 			}
+			errExec = m.classifyForbiddenError(provider, errExec)
 			result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: errExec == nil}
 			if errExec != nil {
 				result.Error = resultErrorFromError(errExec)
@@ -3769,6 +4076,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 				if isRequestInvalidError(errExec) {
 					return cliproxyexecutor.Response{}, errExec
 				}
+				attempts.add(upstreamModel, errExec)
 				authErr = errExec
 				continue
 			}
@@ -3777,6 +4085,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 			rewriteForceMappedResponse(&resp, aliasResult)
 			return resp, nil
 		}
+		authErr = attempts.wrap(authErr)
 		countBudget := m.shouldCountAttemptBudget(authErr, provider, providers, tried)
 		if countBudget {
 			attempted[auth.ID] = struct{}{}
@@ -3808,10 +4117,13 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 	providers = m.filterProvidersForThreshold(routeModel, providers, opts)
 	homeMode := m.HomeEnabled()
 	homeAuthCount := 1
-	tried := make(map[string]struct{})
+	tried := excludedAuthIDsFromMetadata(opts.Metadata)
 	attempted := make(map[string]struct{})
 	var lastErr error
 	for {
+		if lastErr != nil && requestDeadlineBlocksAttempt(ctx, opts.Metadata, 0) {
+			return cliproxyexecutor.Response{}, lastErr
+		}
 		if !homeMode && maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -3861,6 +4173,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 		}
 
 		var authErr error
+		var attempts modelAttempts
 		didRefreshOnUnauthorized := false
 		for _, upstreamModel := range models {
 			resultModel := m.stateModelForExecution(auth, routeModel, upstreamModel, pooled)
@@ -3875,22 +4188,25 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 			}
 			execOpts := opts
 			execReq, execOpts = applyRequestAfterAuthInterceptor(execCtx, executor, provider, execReq, execOpts, requestedModelAliasFromOptions(execOpts, routeModel))
-			resp, errExec := executor.CountTokens(execCtx, auth, execReq, execOpts)
+			resp, errExec := countProviderTokens(execCtx, executor, auth, execReq, execOpts)
 			if errExec != nil {
 				if errCtx := attemptCtx.Err(); errCtx != nil {
+					m.markClientCancelled(attemptCtx, auth, provider, resultModel, false)
 					return cliproxyexecutor.Response{}, errCtx
 				}
 				if refreshed, okRefresh := m.tryRefreshAfterUnauthorized(execCtx, auth, errExec, didRefreshOnUnauthorized); okRefresh {
 					auth = refreshed
 					didRefreshOnUnauthorized = true
-					resp, errExec = executor.CountTokens(execCtx, auth, execReq, execOpts)
+					resp, errExec = countProviderTokens(execCtx, executor, auth, execReq, execOpts)
 					if errExec != nil {
 						if errCtx := execCtx.Err(); errCtx != nil {
+							m.markClientCancelled(execCtx, auth, provider, resultModel, false)
 							return cliproxyexecutor.Response{}, errCtx
 						}
 					}
 				}
 			}
+			errExec = m.classifyForbiddenError(provider, errExec)
 			result := Result{AuthID: auth.ID, Provider: provider, Model: resultModel, Success: errExec == nil}
 			if errExec != nil {
 				result.Error = resultErrorFromError(errExec)
@@ -3909,6 +4225,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 				if isRequestInvalidError(errExec) {
 					return cliproxyexecutor.Response{}, errExec
 				}
+				attempts.add(upstreamModel, errExec)
 				authErr = errExec
 				continue
 			}
@@ -3917,6 +4234,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 			rewriteForceMappedResponse(&resp, aliasResult)
 			return resp, nil
 		}
+		authErr = attempts.wrap(authErr)
 		countBudget := m.shouldCountAttemptBudget(authErr, provider, providers, tried)
 		if countBudget {
 			attempted[auth.ID] = struct{}{}
@@ -4152,10 +4470,13 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 	opts = ensureRequestedModelMetadata(opts, routeModel)
 	homeMode := m.HomeEnabled()
 	homeAuthCount := 1
-	tried := make(map[string]struct{})
+	tried := excludedAuthIDsFromMetadata(opts.Metadata)
 	attempted := make(map[string]struct{})
 	var lastErr error
 	for {
+		if lastErr != nil && requestDeadlineBlocksAttempt(ctx, opts.Metadata, 0) {
+			return nil, lastErr
+		}
 		if !homeMode && maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials {
 			if lastErr != nil {
 				return nil, lastErr
@@ -4737,11 +5058,32 @@ func pinnedAuthIDFromMetadata(meta map[string]any) string {
 	}
 }
 
+// excludedAuthIDsFromMetadata returns the auths listed under ExcludedAuthsMetadataKey as a
+// pre-filled tried set, so selection skips them like credentials that already failed.
+func excludedAuthIDsFromMetadata(meta map[string]any) map[string]struct{} {
+	tried := make(map[string]struct{})
+	ids, _ := meta[cliproxyexecutor.ExcludedAuthsMetadataKey].([]string)
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" {
+			tried[id] = struct{}{}
+		}
+	}
+	return tried
+}
+
 func disallowFreeAuthFromMetadata(meta map[string]any) bool {
+	return boolFromMetadata(meta, cliproxyexecutor.DisallowFreeAuthMetadataKey)
+}
+
+func noFallbackFromMetadata(meta map[string]any) bool {
+	return boolFromMetadata(meta, cliproxyexecutor.NoFallbackMetadataKey)
+}
+
+func boolFromMetadata(meta map[string]any, key string) bool {
 	if len(meta) == 0 {
 		return false
 	}
-	raw, ok := meta[cliproxyexecutor.DisallowFreeAuthMetadataKey]
+	raw, ok := meta[key]
 	if !ok || raw == nil {
 		return false
 	}
@@ -4870,7 +5212,7 @@ func resolveAPIKeyConfig[T APIKeyConfigEntry](entries []T, auth *Auth) *T {
 	}
 	attrKey, attrBase := "", ""
 	if auth.Attributes != nil {
-		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrKey = auth.ConfigAPIKey()
 		attrBase = strings.TrimSpace(auth.Attributes["base_url"])
 	}
 	for i := range entries {
@@ -5317,6 +5659,20 @@ func (m *Manager) shouldRetryAfterError(err error, attempt int, providers []stri
 		return 0, false
 	}
 	wait, found := m.closestCooldownWait(providers, model, attempt)
+	if backoff := m.retryBackoffFor(providers); backoff != nil && isTransientRetryError(err, status) && m.retryAllowed(attempt, providers) {
+		if found && wait > maxWait {
+			return 0, false
+		}
+		// Back off independently of cooldowns, but never retry before a credential recovers.
+		delay := backoff.delay(attempt)
+		if delay < wait {
+			delay = wait
+		}
+		if delay > maxWait {
+			delay = maxWait
+		}
+		return delay, true
+	}
 	if found {
 		if wait > maxWait {
 			return 0, false
@@ -5381,6 +5737,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 	if result.AuthID == "" {
 		return
 	}
+	selectionAuditTraceFromContext(ctx).recordResult(result)
 
 	shouldResumeModel := false
 	shouldSuspendModel := false
@@ -5408,14 +5765,24 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 				failureReason = fmt.Sprintf("[%s] %s", result.Error.Code, result.Error.Message)
 			}
 		}
-		auth.recordRecentRequest(now, result.Success, failureReason)
-		if !result.Success && result.Error != nil {
+		background := IsBackgroundTraffic(ctx)
+		// Client cancellations say nothing about the credential: keep them out of its metrics.
+		cancelled := !result.Success && result.Error.IsClientCancelled()
+		if !background && !cancelled {
+			auth.recordRecentRequest(now, result.Success, failureReason)
+		}
+		if cancelled {
+			logEntryWithRequestID(ctx).WithFields(resultFailureLogFields(ctx, result, auth)).Debug("request cancelled by client")
+		} else if !result.Success && result.Error != nil {
 			logEntryWithRequestID(ctx).WithFields(resultFailureLogFields(ctx, result, auth)).WithError(result.Error).Warn("request failed")
 		}
-		if result.Success {
-			auth.Success++
-		} else {
-			auth.Failed++
+		// Internal traffic still drives cooldowns below but stays out of success metrics.
+		if !background && !cancelled {
+			if result.Success {
+				auth.Success++
+			} else {
+				auth.Failed++
+			}
 		}
 
 		if result.Success {
@@ -5522,6 +5889,7 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 									next = now.Add(*result.RetryAfter)
 								} else {
 									next, backoffLevel = quotaCooldownAfterFailure(state.Quota, now)
+									next = alignQuotaCooldown(m.quotaResetFor(auth), result.Error, now, next)
 								}
 							}
 							state.NextRetryAfter = next
@@ -5562,10 +5930,14 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 				}
 			} else {
 				applyAuthFailureState(auth, result.Error, result.RetryAfter, now)
+				if result.RetryAfter == nil && auth.Quota.Exceeded && statusCodeFromResult(result.Error) == http.StatusTooManyRequests {
+					auth.NextRetryAfter = alignQuotaCooldown(m.quotaResetFor(auth), result.Error, now, auth.NextRetryAfter)
+					auth.Quota.NextRecoverAt = auth.NextRetryAfter
+				}
 			}
 		}
 
-		_ = m.persist(ctx, auth)
+		m.persistResult(ctx, auth)
 		authSnapshot = auth.Clone()
 		if trackCooldownState {
 			cooldownRecordsAfter := m.cooldownStateRecordsForAuthLocked(auth, now)
@@ -5600,10 +5972,20 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 		registry.GetGlobalRegistry().SuspendClientModel(result.AuthID, result.Model, suspendReason)
 	}
 
-	m.hook.OnResult(ctx, result)
+	m.notifyResult(ctx, result)
 	m.publishErrorEvent(result, authSnapshot)
 }
 
+// markClientCancelled records an attempt abandoned because the client disconnected. Other
+// context errors, such as deadlines, are left unrecorded.
+func (m *Manager) markClientCancelled(ctx context.Context, auth *Auth, provider, model string, ephemeral bool) {
+	if auth == nil || ctx == nil || !errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
+	result := Result{AuthID: auth.ID, Provider: provider, Model: model, Error: clientCancelledError(ctx, nil)}
+	m.recordExecutionResult(ctx, result, auth, ephemeral)
+}
+
 func (m *Manager) recordExecutionResult(ctx context.Context, result Result, auth *Auth, ephemeral bool) {
 	if !ephemeral {
 		m.MarkResult(ctx, result)
@@ -5621,7 +6003,7 @@ func (m *Manager) reportHomeResult(ctx context.Context, result Result, auth *Aut
 	if auth != nil {
 		snapshot = auth.Clone()
 	}
-	m.hook.OnResult(ctx, result)
+	m.notifyResult(ctx, result)
 	m.publishErrorEvent(result, snapshot)
 }
 
@@ -5633,19 +6015,20 @@ func (m *Manager) recordAvailabilityNeutralResult(ctx context.Context, result Re
 	var authSnapshot *Auth
 	m.mu.Lock()
 	if auth, ok := m.auths[result.AuthID]; ok && auth != nil {
-		now := time.Now()
-		auth.recordRecentRequest(now, result.Success, result.Model)
-		if result.Success {
-			auth.Success++
-		} else {
-			auth.Failed++
+		if !IsBackgroundTraffic(ctx) {
+			auth.recordRecentRequest(time.Now(), result.Success, result.Model)
+			if result.Success {
+				auth.Success++
+			} else {
+				auth.Failed++
+			}
 		}
-		_ = m.persist(ctx, auth)
+		m.persistResult(ctx, auth)
 		authSnapshot = auth.Clone()
 	}
 	m.mu.Unlock()
 
-	m.hook.OnResult(ctx, result)
+	m.notifyResult(ctx, result)
 	m.publishErrorEvent(result, authSnapshot)
 }
 
@@ -5984,6 +6367,7 @@ func (m *Manager) promoteNextAntigravityPrimary(ctx context.Context, currentAuth
 
 	current := allAntigravity[currentIdx]
 	now := time.Now()
+	m.bumpAuthGenerationLocked()
 	current.Disabled = true
 	current.Status = StatusDisabled
 	current.PrimaryInfo.IsPrimary = false
@@ -6146,7 +6530,16 @@ func isRequestScopedNotFoundResultError(err *Error) bool {
 }
 
 func isRequestScopedResultError(err *Error) bool {
-	return err != nil && (err.IsRequestScoped() || isRequestScopedNotFoundResultError(err))
+	return err != nil && (err.IsRequestScoped() || err.IsClientCancelled() || isRequestScopedNotFoundResultError(err))
+}
+
+// clientCancelledError returns the result error for an attempt cut short by the client
+// disconnecting, or nil when err is a genuine upstream failure.
+func clientCancelledError(ctx context.Context, err error) *Error {
+	if !errors.Is(err, context.Canceled) && (ctx == nil || !errors.Is(ctx.Err(), context.Canceled)) {
+		return nil
+	}
+	return &Error{Code: ClientCancelledErrorCode, Message: "client cancelled the request", HTTPStatus: statusClientClosedRequest}
 }
 
 func isCountTokensEndpointNotFoundError(err error, requestedModel string) bool {
@@ -6690,6 +7083,8 @@ func (m *Manager) pickNextLegacy(ctx context.Context, provider, model string, op
 
 	pinnedAuthID := pinnedAuthIDFromMetadata(opts.Metadata)
 	disallowFreeAuth := disallowFreeAuthFromMetadata(opts.Metadata)
+	capabilities := newCapabilityFilter(model, opts)
+	groups := m.newGroupFilter(model, opts)
 
 	m.mu.RLock()
 	selector := m.selector
@@ -6728,6 +7123,12 @@ func (m *Manager) pickNextLegacy(ctx context.Context, provider, model string, op
 		if disallowFreeAuth && isFreeCodexAuth(candidate) {
 			continue
 		}
+		if !capabilities.allows(candidate) {
+			continue
+		}
+		if !groups.allows(candidate) {
+			continue
+		}
 		if _, used := tried[candidate.ID]; used {
 			continue
 		}
@@ -6914,30 +7315,16 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 	if _, isWeightedRobin := unwrapWeightedRobin(m.selector); isWeightedRobin {
 		return m.pickNextLegacy(ctx, provider, model, opts, tried)
 	}
-	if strings.TrimSpace(model) != "" {
-		m.mu.RLock()
-		for _, candidate := range m.auths {
-			if candidate == nil || candidate.Disabled {
-				continue
-			}
-			if executorKeyFromAuth(candidate) != provider {
-				continue
-			}
-			if _, used := tried[candidate.ID]; used {
-				continue
-			}
-			if m.routeAwareSelectionRequired(candidate, model) {
-				m.mu.RUnlock()
-				return m.pickNextLegacy(ctx, provider, model, opts, tried)
-			}
-		}
-		m.mu.RUnlock()
+	if strings.TrimSpace(model) != "" && m.routeAwareCandidatePending(m.currentAuthSnapshot(), provider, model, tried) {
+		return m.pickNextLegacy(ctx, provider, model, opts, tried)
 	}
 	executor, okExecutor := m.Executor(provider)
 	if !okExecutor {
 		return nil, nil, &Error{Code: "executor_not_found", Message: "executor not registered"}
 	}
 	disallowFreeAuth := disallowFreeAuthFromMetadata(opts.Metadata)
+	capabilities := newCapabilityFilter(model, opts)
+	groups := m.newGroupFilter(model, opts)
 	for {
 		selected, errPick := m.scheduler.pickSingle(ctx, provider, model, opts, tried)
 		if errPick != nil && model != "" && shouldRetrySchedulerPick(errPick) {
@@ -6950,14 +7337,15 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		if selected == nil {
 			return nil, nil, &Error{Code: "auth_not_found", Message: "selector returned no auth"}
 		}
-		if disallowFreeAuth && isFreeCodexAuth(selected) {
+		if (disallowFreeAuth && isFreeCodexAuth(selected)) || !capabilities.allows(selected) || !groups.allows(selected) || !selected.Availability.Allows(time.Now()) {
 			if tried == nil {
 				tried = make(map[string]struct{})
 			}
 			tried[selected.ID] = struct{}{}
 			continue
 		}
-		authCopy := selected.Clone()
+		// Scheduler entries are immutable snapshots, so a read view is enough.
+		authCopy := selected.readView()
 		if !selected.indexAssigned {
 			m.mu.Lock()
 			if current := m.auths[authCopy.ID]; current != nil && !current.indexAssigned {
@@ -6977,6 +7365,8 @@ func (m *Manager) pickNextMixedLegacy(ctx context.Context, providers []string, m
 
 	pinnedAuthID := pinnedAuthIDFromMetadata(opts.Metadata)
 	disallowFreeAuth := disallowFreeAuthFromMetadata(opts.Metadata)
+	capabilities := newCapabilityFilter(model, opts)
+	groups := m.newGroupFilter(model, opts)
 
 	providerSet := make(map[string]struct{}, len(providers))
 	for _, provider := range providers {
@@ -7020,6 +7410,12 @@ func (m *Manager) pickNextMixedLegacy(ctx context.Context, providers []string, m
 		if disallowFreeAuth && isFreeCodexAuth(candidate) {
 			continue
 		}
+		if !capabilities.allows(candidate) {
+			continue
+		}
+		if !groups.allows(candidate) {
+			continue
+		}
 		providerKey := executorKeyFromAuth(candidate)
 		if providerKey == "" {
 			continue
@@ -7107,6 +7503,17 @@ func (m *Manager) pickNextMixedLegacy(ctx context.Context, providers []string, m
 }
 
 func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, error) {
+	trace := selectionAuditTraceFromContext(ctx)
+	if trace == nil {
+		return m.pickNextMixedSelect(ctx, providers, model, opts, tried)
+	}
+	candidates := m.auditCandidates(providers, model, tried)
+	auth, executor, provider, errPick := m.pickNextMixedSelect(ctx, providers, model, opts, tried)
+	trace.recordPick(model, candidates, auth, provider, errPick)
+	return auth, executor, provider, errPick
+}
+
+func (m *Manager) pickNextMixedSelect(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, error) {
 	if m.HomeEnabled() {
 		return m.pickNextViaHome(ctx, model, opts, tried)
 	}
@@ -7162,26 +7569,17 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 				providerSet[p] = struct{}{}
 			}
 		}
-		m.mu.RLock()
-		for _, candidate := range m.auths {
-			if candidate == nil || candidate.Disabled {
-				continue
-			}
-			if _, ok := providerSet[executorKeyFromAuth(candidate)]; !ok {
-				continue
-			}
-			if _, used := tried[candidate.ID]; used {
-				continue
-			}
-			if m.routeAwareSelectionRequired(candidate, model) {
-				m.mu.RUnlock()
+		snapshot := m.currentAuthSnapshot()
+		for providerKey := range providerSet {
+			if m.routeAwareCandidatePending(snapshot, providerKey, model, tried) {
 				return m.pickNextMixedLegacy(ctx, providers, model, opts, tried)
 			}
 		}
-		m.mu.RUnlock()
 	}
 
 	disallowFreeAuth := disallowFreeAuthFromMetadata(opts.Metadata)
+	capabilities := newCapabilityFilter(model, opts)
+	groups := m.newGroupFilter(model, opts)
 	for {
 		selected, providerKey, errPick := m.scheduler.pickMixed(ctx, eligibleProviders, model, opts, tried)
 		if errPick != nil && model != "" && shouldRetrySchedulerPick(errPick) {
@@ -7197,7 +7595,7 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		if selected == nil {
 			return nil, nil, "", &Error{Code: "auth_not_found", Message: "selector returned no auth"}
 		}
-		if disallowFreeAuth && isFreeCodexAuth(selected) {
+		if (disallowFreeAuth && isFreeCodexAuth(selected)) || !capabilities.allows(selected) || !groups.allows(selected) || !selected.Availability.Allows(time.Now()) {
 			if tried == nil {
 				tried = make(map[string]struct{})
 			}
@@ -7208,7 +7606,8 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		if !okExecutor {
 			return nil, nil, "", &Error{Code: "executor_not_found", Message: "executor not registered"}
 		}
-		authCopy := selected.Clone()
+		// Scheduler entries are immutable snapshots, so a read view is enough.
+		authCopy := selected.readView()
 		if !selected.indexAssigned {
 			m.mu.Lock()
 			if current := m.auths[authCopy.ID]; current != nil && !current.indexAssigned {
@@ -7236,13 +7635,9 @@ func (m *Manager) hasOpenAICompatAuthProvider(providers []string) bool {
 		return false
 	}
 
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	for _, auth := range m.auths {
-		if auth == nil || !strings.EqualFold(strings.TrimSpace(auth.Provider), "openai-compatibility") {
-			continue
-		}
-		if _, ok := providerSet[executorKeyFromAuth(auth)]; ok {
+	compatKeys := m.currentAuthSnapshot().openAICompatKeys
+	for providerKey := range providerSet {
+		if _, ok := compatKeys[providerKey]; ok {
 			return true
 		}
 	}
@@ -8188,7 +8583,8 @@ func (m *Manager) tryAntigravityCreditsExecute(ctx context.Context, req cliproxy
 			resultModel := m.stateModelForExecution(c.auth, routeModel, upstreamModel, pooled)
 			execReq := req
 			execReq.Model = upstreamModel
-			resp, errExec := c.executor.Execute(creditsCtx, c.auth, execReq, creditsOpts)
+			resp, errExec := executeProvider(creditsCtx, c.executor, c.auth, execReq, creditsOpts)
+			errExec = m.classifyForbiddenError(c.provider, errExec)
 			result := Result{AuthID: c.auth.ID, Provider: c.provider, Model: resultModel, Success: errExec == nil}
 			if errExec != nil {
 				result.Error = resultErrorFromError(errExec)
@@ -8255,29 +8651,36 @@ func antigravityCreditsKVUnavailableError(cause error) error {
 }
 
 func (m *Manager) persist(ctx context.Context, auth *Auth) error {
-	if m.store == nil || auth == nil {
+	if !m.shouldPersist(ctx, auth) {
 		return nil
 	}
+	if queue := m.persistQueue.Load(); queue != nil {
+		queue.supersede(auth.ID)
+	}
+	_, err := m.store.Save(ctx, auth)
+	return err
+}
+
+func (m *Manager) shouldPersist(ctx context.Context, auth *Auth) bool {
+	if m.store == nil || auth == nil {
+		return false
+	}
 	if shouldSkipPersist(ctx) {
-		return nil
+		return false
 	}
 	if IsConfigAPIKeyAuth(auth) {
-		return nil
+		return false
 	}
 	if auth.Attributes != nil {
 		if v := strings.ToLower(strings.TrimSpace(auth.Attributes["runtime_only"])); v == "true" {
-			return nil
+			return false
 		}
 	}
 	if IsPluginVirtualAuth(auth) {
-		return nil
+		return false
 	}
 	// Skip persistence when metadata is absent (e.g., runtime-only auths).
-	if auth.Metadata == nil {
-		return nil
-	}
-	_, err := m.store.Save(ctx, auth)
-	return err
+	return auth.Metadata != nil
 }
 
 // StartAutoRefresh launches a background loop that evaluates auth freshness
@@ -8686,8 +9089,24 @@ func (m *Manager) refreshAuthForRequest(ctx context.Context, id, failedAccessTok
 		}
 	}
 
+	m.mu.RLock()
+	locker := m.refreshLocker
+	m.mu.RUnlock()
+	if locker != nil {
+		unlock, acquired, errLock := locker.TryLock(ctx, id)
+		switch {
+		case errLock != nil:
+			log.Warnf("refresh lock for %s (%s) unavailable, refreshing locally: %v", auth.Provider, auth.ID, errLock)
+		case !acquired:
+			log.Debugf("refresh of %s (%s) skipped: locked by another instance", auth.Provider, auth.ID)
+			return nil, ErrRefreshLocked
+		case unlock != nil:
+			defer unlock()
+		}
+	}
+
 	cloned := auth.Clone()
-	updated, err := exec.Refresh(ctx, cloned)
+	updated, err := refreshProvider(ctx, exec, cloned)
 	if err != nil && errors.Is(err, context.Canceled) {
 		log.Debugf("refresh canceled for %s, %s", auth.Provider, auth.ID)
 		return nil, err
@@ -8697,9 +9116,11 @@ func (m *Manager) refreshAuthForRequest(ctx context.Context, id, failedAccessTok
 	if err != nil {
 		unauthorized := isUnauthorizedError(err)
 		shouldReschedule := false
+		var failedSnapshot *Auth
 		m.mu.Lock()
 		if current := m.auths[id]; current != nil {
 			current.LastError = refreshErrorFromError(err)
+			current.RefreshFailures++
 			if unauthorized {
 				current.NextRefreshAfter = time.Time{}
 				current.Unavailable = true
@@ -8710,14 +9131,18 @@ func (m *Manager) refreshAuthForRequest(ctx context.Context, id, failedAccessTok
 			}
 			m.auths[id] = current
 			shouldReschedule = true
+			failedSnapshot = current.Clone()
 			if m.scheduler != nil {
-				m.scheduler.upsertAuth(current.Clone())
+				m.scheduler.upsertAuth(failedSnapshot)
 			}
 		}
 		m.mu.Unlock()
 		if shouldReschedule {
 			m.queueRefreshReschedule(id)
 		}
+		if failedSnapshot != nil {
+			m.notifyRefreshFailed(ctx, failedSnapshot, err)
+		}
 		return nil, err
 	}
 	if updated == nil {
@@ -8729,6 +9154,7 @@ func (m *Manager) refreshAuthForRequest(ctx context.Context, id, failedAccessTok
 		updated.Runtime = auth.Runtime
 	}
 	updated.LastRefreshedAt = now
+	updated.RefreshFailures = 0
 	// Preserve NextRefreshAfter set by the Authenticator
 	// If the Authenticator set a reasonable refresh time, it should not be overwritten
 	// If the Authenticator did not set it (zero value), shouldRefresh will use default logic
@@ -8750,10 +9176,11 @@ func (m *Manager) refreshAuthForRequest(ctx context.Context, id, failedAccessTok
 	if errUpdate != nil {
 		log.Debugf("persist refreshed auth %s (%s) failed: %v", auth.Provider, auth.ID, errUpdate)
 	}
-	if saved != nil {
-		return saved, nil
+	if saved == nil {
+		saved = updated.Clone()
 	}
-	return updated.Clone(), nil
+	m.notifyRefreshSucceeded(ctx, saved)
+	return saved, nil
 }
 
 func (m *Manager) executorFor(provider string) ProviderExecutor {
@@ -9060,26 +9487,29 @@ func (m *Manager) HttpRequest(ctx context.Context, auth *Auth, req *http.Request
 }
 
 func (m *Manager) resolveFallbackModels(originalModel string) []string {
+	settings := m.fallbackSnapshot()
+	if settings == nil {
+		return nil
+	}
 	var candidates []string
 	seen := map[string]struct{}{originalModel: {}}
 
-	if fb, ok := m.getFallbackModel(originalModel); ok && fb != "" {
+	if fb, ok := settings.fallbackModel(originalModel); ok && fb != "" {
 		if _, dup := seen[fb]; !dup {
 			candidates = append(candidates, fb)
 			seen[fb] = struct{}{}
 		}
 	}
 
-	for _, chainModel := range m.getFallbackChain() {
+	for _, chainModel := range settings.chain {
 		if _, dup := seen[chainModel]; !dup {
 			candidates = append(candidates, chainModel)
 			seen[chainModel] = struct{}{}
 		}
 	}
 
-	maxDepth := m.getFallbackMaxDepth()
-	if len(candidates) > maxDepth {
-		candidates = candidates[:maxDepth]
+	if len(candidates) > settings.maxDepth {
+		candidates = candidates[:settings.maxDepth]
 	}
 
 	return candidates
@@ -9150,11 +9580,14 @@ func (m *Manager) executeWithRouteFallback(
 	}
 	lastErr = err
 
-	if !m.shouldAllowRouteModelFallback(err) {
+	if !m.shouldAllowRouteModelFallback(err) || noFallbackFromMetadata(opts.Metadata) {
 		return cliproxyexecutor.Response{}, lastErr
 	}
 
 	for _, fbModel := range m.resolveFallbackModels(originalModel) {
+		if requestDeadlineBlocksAttempt(ctx, opts.Metadata, 0) {
+			break
+		}
 		if _, dup := attempted[fbModel]; dup {
 			continue
 		}
@@ -9209,11 +9642,14 @@ func (m *Manager) executeStreamWithRouteFallback(
 	}
 	lastErr = err
 
-	if !m.shouldAllowRouteModelFallback(err) {
+	if !m.shouldAllowRouteModelFallback(err) || noFallbackFromMetadata(opts.Metadata) {
 		return nil, lastErr
 	}
 
 	for _, fbModel := range m.resolveFallbackModels(originalModel) {
+		if requestDeadlineBlocksAttempt(ctx, opts.Metadata, 0) {
+			break
+		}
 		if _, dup := attempted[fbModel]; dup {
 			continue
 		}
@@ -9284,7 +9720,12 @@ func (m *Manager) executeWithRetry(
 ) (cliproxyexecutor.Response, error) {
 	var lastErr error
 	for attempt := 0; ; attempt++ {
+		release, errAcquire := m.acquireRequestSlot(ctx, opts)
+		if errAcquire != nil {
+			return cliproxyexecutor.Response{}, errAcquire
+		}
 		resp, errExec := execOnce(ctx, providers, req, opts, maxRetryCredentials)
+		releaseRequestSlot(release)
 		if errExec == nil {
 			return resp, nil
 		}
@@ -9293,6 +9734,9 @@ func (m *Manager) executeWithRetry(
 		if !shouldRetry {
 			break
 		}
+		if requestDeadlineBlocksAttempt(ctx, opts.Metadata, wait) {
+			break
+		}
 		if errWait := waitForCooldown(ctx, wait, maxWait); errWait != nil {
 			return cliproxyexecutor.Response{}, errWait
 		}
@@ -9312,15 +9756,23 @@ func (m *Manager) executeStreamWithRetry(
 	var lastErr error
 	for attempt := 0; ; attempt++ {
 		filtered := m.filterProvidersForThreshold(req.Model, providers, opts)
+		release, errAcquire := m.acquireRequestSlot(ctx, opts)
+		if errAcquire != nil {
+			return nil, errAcquire
+		}
 		result, errStream := execOnce(ctx, filtered, req, opts, maxRetryCredentials)
 		if errStream == nil {
-			return result, nil
+			return releaseWhenStreamDone(ctx, result, release), nil
 		}
+		releaseRequestSlot(release)
 		lastErr = errStream
 		wait, shouldRetry := m.shouldRetryAfterError(errStream, attempt, providers, req.Model, maxWait)
 		if !shouldRetry {
 			break
 		}
+		if requestDeadlineBlocksAttempt(ctx, opts.Metadata, wait) {
+			break
+		}
 		if errWait := waitForCooldown(ctx, wait, maxWait); errWait != nil {
 			return nil, errWait
 		}
@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+// pickNextP99BudgetEnv overrides the pickNext p99 latency budget, e.g. "2ms". CI runners
+// with noisy neighbours can raise it; profiling runs can lower it.
+const pickNextP99BudgetEnv = "CLIPROXY_PICKNEXT_P99_BUDGET"
+
+const defaultPickNextP99Budget = 10 * time.Millisecond
+
+// conductorBenchmarkExecutor answers immediately so benchmarks measure the conductor only.
+type conductorBenchmarkExecutor struct {
+	schedulerBenchmarkExecutor
+}
+
+func (e conductorBenchmarkExecutor) Execute(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{Payload: []byte(`{"ok":true}`)}, nil
+}
+
+func (e conductorBenchmarkExecutor) ExecuteStream(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	chunks := make(chan cliproxyexecutor.StreamChunk, 2)
+	chunks <- cliproxyexecutor.StreamChunk{Payload: []byte(`data: {"ok":true}`)}
+	chunks <- cliproxyexecutor.StreamChunk{Payload: []byte(`data: [DONE]`)}
+	close(chunks)
+	return &cliproxyexecutor.StreamResult{Headers: http.Header{}, Chunks: chunks}, nil
+}
+
+func conductorBenchmarkSetup(b testing.TB, total int) (*Manager, string) {
+	b.Helper()
+	manager, _, model := benchmarkManagerSetup(b, total, false, false)
+	manager.RegisterExecutor(conductorBenchmarkExecutor{schedulerBenchmarkExecutor{id: "gemini"}})
+	return manager, model
+}
+
+func BenchmarkManagerExecute1000(b *testing.B) {
+	manager, model := conductorBenchmarkSetup(b, 1000)
+	ctx := context.Background()
+	req := cliproxyexecutor.Request{Model: model}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, errExec := manager.Execute(ctx, []string{"gemini"}, req, cliproxyexecutor.Options{}); errExec != nil {
+			b.Fatalf("Execute error = %v", errExec)
+		}
+	}
+}
+
+func BenchmarkManagerExecuteParallel5000(b *testing.B) {
+	manager, model := conductorBenchmarkSetup(b, 5000)
+	req := cliproxyexecutor.Request{Model: model}
+
+	b.ReportAllocs()
+	b.SetParallelism(8)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		ctx := context.Background()
+		for pb.Next() {
+			if _, errExec := manager.Execute(ctx, []string{"gemini"}, req, cliproxyexecutor.Options{}); errExec != nil {
+				b.Errorf("Execute error = %v", errExec)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkManagerExecuteStreamParallel5000(b *testing.B) {
+	manager, model := conductorBenchmarkSetup(b, 5000)
+	req := cliproxyexecutor.Request{Model: model}
+
+	b.ReportAllocs()
+	b.SetParallelism(8)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		ctx := context.Background()
+		for pb.Next() {
+			result, errExec := manager.ExecuteStream(ctx, []string{"gemini"}, req, cliproxyexecutor.Options{})
+			if errExec != nil {
+				b.Errorf("ExecuteStream error = %v", errExec)
+				return
+			}
+			for range result.Chunks {
+			}
+		}
+	})
+}
+
+func BenchmarkManagerPickNextParallel5000(b *testing.B) {
+	manager, model := conductorBenchmarkSetup(b, 5000)
+	opts := cliproxyexecutor.Options{}
+
+	b.ReportAllocs()
+	b.SetParallelism(8)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		ctx := context.Background()
+		tried := map[string]struct{}{}
+		for pb.Next() {
+			if _, _, errPick := manager.pickNext(ctx, "gemini", model, opts, tried); errPick != nil {
+				b.Errorf("pickNext error = %v", errPick)
+				return
+			}
+		}
+	})
+}
+
+// benchmarkAuthWithModelStates builds an auth shaped like a long-running OAuth credential:
+// token metadata plus per-model runtime state for every model it has served.
+func benchmarkAuthWithModelStates(models int) *Auth {
+	auth := &Auth{
+		ID:          "bench-model-states",
+		Provider:    "gemini",
+		Attributes:  map[string]string{"auth_kind": "oauth", "priority": "10"},
+		Metadata:    map[string]any{"access_token": "token", "refresh_token": "refresh", "email": "bench@example.com"},
+		ModelStates: make(map[string]*ModelState, models),
+	}
+	for i := 0; i < models; i++ {
+		auth.ModelStates[fmt.Sprintf("model-%02d", i)] = &ModelState{
+			Status:    StatusError,
+			LastError: &Error{Code: "rate_limited", Message: "quota exhausted", HTTPStatus: http.StatusTooManyRequests},
+		}
+	}
+	return auth
+}
+
+var benchmarkAuthSink *Auth
+
+// BenchmarkAuthPerRequestCopy compares the deep copy pickNext used to make of every
+// scheduler entry with the read view it hands out now.
+func BenchmarkAuthPerRequestCopy(b *testing.B) {
+	auth := benchmarkAuthWithModelStates(32)
+	b.Run("clone", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchmarkAuthSink = auth.Clone()
+		}
+	})
+	b.Run("read-view", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			benchmarkAuthSink = auth.readView()
+		}
+	})
+}
+
+func BenchmarkManagerExecuteWithModelStates1000(b *testing.B) {
+	manager, model := conductorBenchmarkSetup(b, 1000)
+	template := benchmarkAuthWithModelStates(32)
+	manager.mu.Lock()
+	for _, auth := range manager.auths {
+		auth.Metadata = template.Clone().Metadata
+		auth.ModelStates = template.Clone().ModelStates
+	}
+	manager.bumpAuthGenerationLocked()
+	manager.mu.Unlock()
+	manager.syncScheduler()
+	ctx := context.Background()
+	req := cliproxyexecutor.Request{Model: model}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, errExec := manager.Execute(ctx, []string{"gemini"}, req, cliproxyexecutor.Options{}); errExec != nil {
+			b.Fatalf("Execute error = %v", errExec)
+		}
+	}
+}
+
+// TestManagerPickNextLatencyBudget guards selection overhead: the p99 latency of pickNext
+// over 1000 auths must stay within the budget (see pickNextP99BudgetEnv).
+func TestManagerPickNextLatencyBudget(t *testing.T) {
+	if testing.Short() {
+		t.Skip("latency budget check skipped in short mode")
+	}
+	budget := defaultPickNextP99Budget
+	if raw := os.Getenv(pickNextP99BudgetEnv); raw != "" {
+		parsed, errParse := time.ParseDuration(raw)
+		if errParse != nil {
+			t.Fatalf("%s = %q: %v", pickNextP99BudgetEnv, raw, errParse)
+		}
+		budget = parsed
+	}
+
+	manager, model := conductorBenchmarkSetup(t, 1000)
+	ctx := context.Background()
+	opts := cliproxyexecutor.Options{}
+	tried := map[string]struct{}{}
+	for i := 0; i < 100; i++ {
+		if _, _, errPick := manager.pickNext(ctx, "gemini", model, opts, tried); errPick != nil {
+			t.Fatalf("warmup pickNext error = %v", errPick)
+		}
+	}
+
+	const samples = 2000
+	latencies := make([]time.Duration, 0, samples)
+	for i := 0; i < samples; i++ {
+		start := time.Now()
+		if _, _, errPick := manager.pickNext(ctx, "gemini", model, opts, tried); errPick != nil {
+			t.Fatalf("pickNext error = %v", errPick)
+		}
+		latencies = append(latencies, time.Since(start))
+	}
+	slices.Sort(latencies)
+	p99 := latencies[samples*99/100]
+	t.Logf("pickNext over 1000 auths: p50=%s p99=%s budget=%s", latencies[samples/2], p99, budget)
+	if p99 > budget {
+		t.Fatalf("pickNext p99 = %s, exceeds budget %s", p99, budget)
+	}
+}
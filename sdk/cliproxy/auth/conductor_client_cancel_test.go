@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func registerClientCancelAuth(t *testing.T, hook Hook) (*Manager, *Auth) {
+	t.Helper()
+	mgr := NewManager(nil, nil, hook)
+	auth := &Auth{ID: "auth-1", Provider: "claude"}
+	if _, err := mgr.Register(WithSkipPersist(context.Background()), auth); err != nil {
+		t.Fatalf("Register returned error: %v", err)
+	}
+	return mgr, auth
+}
+
+func assertAuthNotPenalized(t *testing.T, mgr *Manager) {
+	t.Helper()
+	got, ok := mgr.GetByID("auth-1")
+	if !ok {
+		t.Fatal("auth not found")
+	}
+	if got.Failed != 0 || got.Unavailable || got.Status == StatusError {
+		t.Fatalf("auth penalized for a client cancellation: failed=%d unavailable=%v status=%s", got.Failed, got.Unavailable, got.Status)
+	}
+	if state := got.ModelStates["claude-sonnet-4"]; state != nil && (state.Unavailable || !state.NextRetryAfter.IsZero()) {
+		t.Fatalf("model cooled for a client cancellation: %+v", state)
+	}
+	for _, bucket := range got.RecentRequestsSnapshot(time.Now()) {
+		if bucket.Failed != 0 {
+			t.Fatalf("recent requests recorded a failure: %+v", bucket)
+		}
+	}
+}
+
+func TestMarkResultClientCancelledDoesNotPenalizeAuth(t *testing.T) {
+	mgr, _ := registerClientCancelAuth(t, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	mgr.MarkResult(ctx, Result{AuthID: "auth-1", Provider: "claude", Model: "claude-sonnet-4", Error: clientCancelledError(ctx, nil)})
+
+	assertAuthNotPenalized(t, mgr)
+}
+
+func TestWrapStreamResultRecordsClientCancellation(t *testing.T) {
+	cases := []struct {
+		name string
+		// upstreamErr is sent by the executor after the client disconnects; nil means
+		// the wrapper notices the cancellation while forwarding.
+		upstreamErr error
+	}{
+		{name: "cancelled while forwarding"},
+		{name: "upstream read cancelled", upstreamErr: context.Canceled},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			hook := &resultCaptureHook{}
+			mgr, auth := registerClientCancelAuth(t, hook)
+			ctx, cancel := context.WithCancel(context.Background())
+			remaining := make(chan cliproxyexecutor.StreamChunk)
+			stream := mgr.wrapStreamResult(ctx, auth, "claude", "claude-sonnet-4", nil, nil, remaining, OAuthModelAliasResult{}, false, nil)
+
+			remaining <- cliproxyexecutor.StreamChunk{Payload: []byte("data: {}\n\n")}
+			<-stream.Chunks
+			cancel()
+			if tc.upstreamErr != nil {
+				remaining <- cliproxyexecutor.StreamChunk{Err: tc.upstreamErr}
+			} else {
+				remaining <- cliproxyexecutor.StreamChunk{Payload: []byte("data: {}\n\n")}
+			}
+			close(remaining)
+			// Stream.Chunks is not read until the result is in, so the wrapper can only
+			// observe the cancelled context.
+			deadline := time.Now().Add(2 * time.Second)
+			for len(hook.Results()) == 0 && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			for range stream.Chunks {
+			}
+
+			results := hook.Results()
+			if len(results) != 1 || results[0].Success || !results[0].Error.IsClientCancelled() {
+				t.Fatalf("results = %+v, want one client_cancelled result", results)
+			}
+			assertAuthNotPenalized(t, mgr)
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestManagerExecuteStream_SkipsExcludedAuths(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	executor := &authFallbackExecutor{id: "claude"}
+	m.RegisterExecutor(executor)
+
+	model := "claude-sonnet-4"
+	failed := &Auth{ID: "aa-failed-auth", Provider: "claude"}
+	other := &Auth{ID: "bb-other-auth", Provider: "claude"}
+	reg := registry.GetGlobalRegistry()
+	for _, auth := range []*Auth{failed, other} {
+		reg.RegisterClient(auth.ID, "claude", []*registry.ModelInfo{{ID: model}})
+		if _, errRegister := m.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register %s: %v", auth.ID, errRegister)
+		}
+	}
+	t.Cleanup(func() {
+		reg.UnregisterClient(failed.ID)
+		reg.UnregisterClient(other.ID)
+	})
+
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{
+		cliproxyexecutor.ExcludedAuthsMetadataKey: []string{failed.ID},
+	}}
+	for i := 0; i < 3; i++ {
+		result, errStream := m.ExecuteStream(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: model}, opts)
+		if errStream != nil {
+			t.Fatalf("stream %d error = %v", i, errStream)
+		}
+		for range result.Chunks {
+		}
+	}
+	for _, id := range executor.StreamCalls() {
+		if id == failed.ID {
+			t.Fatalf("excluded auth was selected: %v", executor.StreamCalls())
+		}
+	}
+
+	opts.Metadata[cliproxyexecutor.ExcludedAuthsMetadataKey] = []string{failed.ID, other.ID}
+	if _, errStream := m.ExecuteStream(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: model}, opts); errStream == nil {
+		t.Fatal("expected an error when every auth is excluded")
+	}
+}
@@ -96,3 +96,21 @@ func TestManagerExecuteWithRouteFallback_AllowsConsoleUpstreamRequestFailed(t *t
 		}
 	}
 }
+
+func TestManagerSetFallbackConfigSwapsSnapshot(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.SetFallbackConfig(map[string]string{"model-a": "model-b"}, []string{"model-c", "model-d"}, 2)
+	if got := m.resolveFallbackModels("model-a"); len(got) != 2 || got[0] != "model-b" || got[1] != "model-c" {
+		t.Fatalf("resolveFallbackModels() = %v, want [model-b model-c]", got)
+	}
+
+	m.SetFallbackChain([]string{"model-e"}, 0)
+	if got := m.resolveFallbackModels("model-a"); len(got) != 2 || got[0] != "model-b" || got[1] != "model-e" {
+		t.Fatalf("resolveFallbackModels() after chain update = %v, want [model-b model-e]", got)
+	}
+
+	m.SetFallbackConfig(nil, nil, 0)
+	if got := m.resolveFallbackModels("model-a"); len(got) != 0 {
+		t.Fatalf("resolveFallbackModels() after clear = %v, want none", got)
+	}
+}
@@ -90,6 +90,55 @@ func TestManager_RefreshAuthUnauthorizedFailureStopsAutoRefreshRetry(t *testing.
 	}
 }
 
+type flakyRefreshTestExecutor struct {
+	schedulerProviderTestExecutor
+	fail *bool
+}
+
+func (e flakyRefreshTestExecutor) Refresh(ctx context.Context, auth *Auth) (*Auth, error) {
+	if *e.fail {
+		return nil, errors.New("token refresh failed with status 500")
+	}
+	return auth, nil
+}
+
+func TestManager_RefreshAuthCountsConsecutiveFailures(t *testing.T) {
+	ctx := context.Background()
+	fail := true
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.RegisterExecutor(flakyRefreshTestExecutor{
+		schedulerProviderTestExecutor: schedulerProviderTestExecutor{provider: "codex"},
+		fail:                          &fail,
+	})
+	auth := &Auth{ID: "flaky-refresh", Provider: "codex", Metadata: map[string]any{"email": "x@example.com"}}
+	if _, errRegister := manager.Register(ctx, auth); errRegister != nil {
+		t.Fatalf("register auth: %v", errRegister)
+	}
+
+	manager.refreshAuth(ctx, auth.ID)
+	manager.refreshAuth(ctx, auth.ID)
+	updated, _ := manager.GetByID(auth.ID)
+	if updated.RefreshFailures != 2 {
+		t.Fatalf("RefreshFailures = %d, want 2", updated.RefreshFailures)
+	}
+
+	reloaded := &Auth{ID: auth.ID, Provider: "codex", Metadata: map[string]any{"email": "x@example.com"}}
+	if _, errUpdate := manager.Update(ctx, reloaded); errUpdate != nil {
+		t.Fatalf("update auth: %v", errUpdate)
+	}
+	updated, _ = manager.GetByID(auth.ID)
+	if updated.RefreshFailures != 2 {
+		t.Fatalf("RefreshFailures after reload = %d, want 2", updated.RefreshFailures)
+	}
+
+	fail = false
+	manager.refreshAuth(ctx, auth.ID)
+	updated, _ = manager.GetByID(auth.ID)
+	if updated.RefreshFailures != 0 {
+		t.Fatalf("RefreshFailures after success = %d, want 0", updated.RefreshFailures)
+	}
+}
+
 func TestManager_RefreshSchedulerEntry_RebuildsSupportedModelSetAfterModelRegistration(t *testing.T) {
 	ctx := context.Background()
 
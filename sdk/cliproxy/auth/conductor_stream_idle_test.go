@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestReadStreamBootstrapIdleTimeout(t *testing.T) {
+	stalled := make(chan cliproxyexecutor.StreamChunk)
+	_, _, err := readStreamBootstrap(context.Background(), stalled, 20*time.Millisecond)
+	var idleErr *Error
+	if !errors.As(err, &idleErr) || idleErr.Code != "stream_idle_timeout" || !idleErr.Retryable {
+		t.Fatalf("err = %v, want a retryable stream_idle_timeout", err)
+	}
+}
+
+func TestWrapStreamResultIdleTimeout(t *testing.T) {
+	hook := &resultCaptureHook{}
+	mgr := NewManager(nil, nil, hook)
+	cfg := &internalconfig.Config{}
+	cfg.Streaming.IdleTimeoutSeconds = 1
+	mgr.SetConfig(cfg)
+	auth := &Auth{ID: "auth-1", Provider: "claude"}
+	if _, err := mgr.Register(WithSkipPersist(context.Background()), auth); err != nil {
+		t.Fatalf("Register returned error: %v", err)
+	}
+
+	remaining := make(chan cliproxyexecutor.StreamChunk)
+	defer close(remaining)
+	stream := mgr.wrapStreamResult(context.Background(), auth, "claude", "claude-sonnet-4", nil, []cliproxyexecutor.StreamChunk{{Payload: []byte("data: {}\n\n")}}, remaining, OAuthModelAliasResult{}, false, nil)
+
+	var got []cliproxyexecutor.StreamChunk
+	deadline := time.After(5 * time.Second)
+	for done := false; !done; {
+		select {
+		case chunk, ok := <-stream.Chunks:
+			if !ok {
+				done = true
+				break
+			}
+			got = append(got, chunk)
+		case <-deadline:
+			t.Fatal("stalled stream was not ended")
+		}
+	}
+	if len(got) != 2 || got[1].Err == nil {
+		t.Fatalf("chunks = %+v, want the payload followed by an idle error", got)
+	}
+	results := hook.Results()
+	if len(results) != 1 || results[0].Success || results[0].Error.Code != "stream_idle_timeout" {
+		t.Fatalf("results = %+v, want one stream_idle_timeout failure", results)
+	}
+}
+
+func TestStreamErrorPayload(t *testing.T) {
+	cases := []struct {
+		name    string
+		payload string
+		status  int
+	}{
+		{name: "openai error", payload: `{"error":{"message":"upstream overloaded","type":"server_error"}}`, status: http.StatusBadGateway},
+		{name: "gemini error", payload: "data: {\"error\":{\"code\":429,\"message\":\"quota\"}}\n\n", status: http.StatusTooManyRequests},
+		{name: "claude error event", payload: "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n", status: 529},
+		{name: "openai chunk", payload: `{"id":"c","choices":[{"delta":{"content":"hi"}}]}`},
+		{name: "claude message start", payload: "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{}}\n\n"},
+		{name: "several events", payload: "data: {\"error\":{\"message\":\"x\"}}\n\ndata: {}\n\n"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := streamErrorPayload([]byte(tc.payload))
+			if tc.status == 0 {
+				if err != nil {
+					t.Fatalf("err = %v, want none", err)
+				}
+				return
+			}
+			if err == nil || err.HTTPStatus != tc.status {
+				t.Fatalf("err = %+v, want status %d", err, tc.status)
+			}
+		})
+	}
+}
+
+// firstChunkExecutor streams a fixed first payload per auth, or stalls when none is set.
+type firstChunkExecutor struct {
+	authFallbackExecutor
+	payloads map[string]string
+}
+
+func (e *firstChunkExecutor) ExecuteStream(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	e.mu.Lock()
+	e.streamCalls = append(e.streamCalls, auth.ID)
+	e.mu.Unlock()
+	ch := make(chan cliproxyexecutor.StreamChunk, 1)
+	payload, ok := e.payloads[auth.ID]
+	if !ok {
+		go func() {
+			<-ctx.Done()
+			close(ch)
+		}()
+		return &cliproxyexecutor.StreamResult{Chunks: ch}, nil
+	}
+	ch <- cliproxyexecutor.StreamChunk{Payload: []byte(payload)}
+	close(ch)
+	return &cliproxyexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func TestManagerExecuteStream_FirstChunkFailover(t *testing.T) {
+	cases := []struct {
+		name     string
+		payloads map[string]string
+	}{
+		{name: "error payload", payloads: map[string]string{"aa-bad": `{"error":{"message":"overloaded","code":503}}`, "bb-good": "ok"}},
+		{name: "first chunk timeout", payloads: map[string]string{"bb-good": "ok"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := NewManager(nil, nil, nil)
+			cfg := &internalconfig.Config{}
+			cfg.Streaming.FirstChunkTimeoutSeconds = 1
+			m.SetConfig(cfg)
+			executor := &firstChunkExecutor{authFallbackExecutor: authFallbackExecutor{id: "claude"}, payloads: tc.payloads}
+			m.RegisterExecutor(executor)
+
+			model := "claude-first-chunk"
+			reg := registry.GetGlobalRegistry()
+			for _, id := range []string{"aa-bad", "bb-good"} {
+				reg.RegisterClient(id, "claude", []*registry.ModelInfo{{ID: model}})
+				if _, errRegister := m.Register(context.Background(), &Auth{ID: id, Provider: "claude"}); errRegister != nil {
+					t.Fatalf("register %s: %v", id, errRegister)
+				}
+			}
+			t.Cleanup(func() {
+				reg.UnregisterClient("aa-bad")
+				reg.UnregisterClient("bb-good")
+			})
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			result, errStream := m.ExecuteStream(ctx, []string{"claude"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{})
+			if errStream != nil {
+				t.Fatalf("ExecuteStream error = %v", errStream)
+			}
+			var got []string
+			for chunk := range result.Chunks {
+				got = append(got, string(chunk.Payload))
+			}
+			if len(got) != 1 || got[0] != "ok" {
+				t.Fatalf("chunks = %v, want the healthy auth's stream", got)
+			}
+			if calls := executor.StreamCalls(); len(calls) != 2 || calls[0] != "aa-bad" {
+				t.Fatalf("stream calls = %v, want the failing auth first", calls)
+			}
+		})
+	}
+}
+
+// stallingStreamExecutor sends one payload and then stalls until its context is cancelled.
+type stallingStreamExecutor struct {
+	authFallbackExecutor
+	cancelled chan struct{}
+}
+
+func (e *stallingStreamExecutor) ExecuteStream(ctx context.Context, _ *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	ch := make(chan cliproxyexecutor.StreamChunk, 1)
+	ch <- cliproxyexecutor.StreamChunk{Payload: []byte("data: {}\n\n")}
+	go func() {
+		defer close(ch)
+		<-ctx.Done()
+		close(e.cancelled)
+	}()
+	return &cliproxyexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func TestManagerExecuteStream_IdleTimeoutCancelsUpstream(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	cfg := &internalconfig.Config{}
+	cfg.Streaming.IdleTimeoutSeconds = 1
+	m.SetConfig(cfg)
+	executor := &stallingStreamExecutor{authFallbackExecutor: authFallbackExecutor{id: "claude"}, cancelled: make(chan struct{})}
+	m.RegisterExecutor(executor)
+
+	model := "claude-idle-cancel"
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("idle-auth", "claude", []*registry.ModelInfo{{ID: model}})
+	t.Cleanup(func() { reg.UnregisterClient("idle-auth") })
+	if _, errRegister := m.Register(context.Background(), &Auth{ID: "idle-auth", Provider: "claude"}); errRegister != nil {
+		t.Fatalf("register: %v", errRegister)
+	}
+
+	result, errStream := m.ExecuteStream(context.Background(), []string{"claude"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{})
+	if errStream != nil {
+		t.Fatalf("ExecuteStream error = %v", errStream)
+	}
+	var got []cliproxyexecutor.StreamChunk
+	for chunk := range result.Chunks {
+		got = append(got, chunk)
+	}
+	if len(got) != 2 || got[1].Err == nil {
+		t.Fatalf("chunks = %+v, want the payload followed by an idle error", got)
+	}
+	select {
+	case <-executor.cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("executor context was not cancelled after the idle timeout")
+	}
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
+	log "github.com/sirupsen/logrus"
+)
+
+// EncryptingStore wraps a Store so auth metadata is encrypted before Save and
+// decrypted on List. Records written before encryption was enabled are still read;
+// they are encrypted the next time they are saved.
+type EncryptingStore struct {
+	inner  Store
+	cipher MetadataCipher
+}
+
+// NewEncryptingStore wraps inner with metadata encryption.
+func NewEncryptingStore(inner Store, c MetadataCipher) (*EncryptingStore, error) {
+	if inner == nil {
+		return nil, errors.New("auth encryption: inner store is nil")
+	}
+	if c == nil {
+		return nil, ErrMetadataCipherMissing
+	}
+	return &EncryptingStore{inner: inner, cipher: c}, nil
+}
+
+// Unwrap returns the wrapped store.
+func (s *EncryptingStore) Unwrap() Store { return s.inner }
+
+// List implements Store. Records that cannot be decrypted are skipped.
+func (s *EncryptingStore) List(ctx context.Context) ([]*Auth, error) {
+	auths, err := s.inner.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := auths[:0]
+	for _, auth := range auths {
+		if auth == nil {
+			continue
+		}
+		decrypted, errDecrypt := DecryptMetadata(s.cipher, auth.Metadata)
+		if errDecrypt != nil {
+			log.Warnf("auth encryption: skip %s: %v", auth.ID, errDecrypt)
+			continue
+		}
+		auth.Metadata = decrypted
+		out = append(out, auth)
+	}
+	return out, nil
+}
+
+// Save implements Store. Token storage is serialized the same way the file store
+// writes it, merged with metadata, then encrypted as one record.
+func (s *EncryptingStore) Save(ctx context.Context, auth *Auth) (string, error) {
+	if auth == nil {
+		return s.inner.Save(ctx, auth)
+	}
+	if auth.Metadata == nil && auth.Storage == nil {
+		return s.inner.Save(ctx, auth)
+	}
+	if auth.Metadata == nil {
+		auth.Metadata = make(map[string]any)
+	}
+	SyncPrimaryInfoMetadata(auth)
+	auth.Metadata["disabled"] = auth.Disabled
+
+	plain := auth.Metadata
+	if auth.Storage != nil {
+		if setter, ok := auth.Storage.(interface{ SetMetadata(map[string]any) }); ok {
+			setter.SetMetadata(auth.Metadata)
+		}
+		merged, errMerge := misc.MergeMetadata(auth.Storage, auth.Metadata)
+		if errMerge != nil {
+			return "", errMerge
+		}
+		plain = merged
+	}
+	if typ, _ := plain["type"].(string); strings.TrimSpace(typ) == "" {
+		plain["type"] = encryptedRecordType(auth.Provider)
+	}
+	encrypted, errEncrypt := EncryptMetadata(s.cipher, plain)
+	if errEncrypt != nil {
+		return "", errEncrypt
+	}
+
+	record := auth.Clone()
+	record.Storage = nil
+	record.Metadata = encrypted
+	path, errSave := s.inner.Save(ctx, record)
+	if errSave != nil {
+		return "", errSave
+	}
+	auth.Attributes = record.Attributes
+	if strings.TrimSpace(auth.FileName) == "" {
+		auth.FileName = record.FileName
+	}
+	return path, nil
+}
+
+// Delete implements Store.
+func (s *EncryptingStore) Delete(ctx context.Context, id string) error {
+	return s.inner.Delete(ctx, id)
+}
+
+// SetBaseDir forwards to the wrapped store when supported.
+func (s *EncryptingStore) SetBaseDir(dir string) {
+	if setter, ok := s.inner.(interface{ SetBaseDir(string) }); ok {
+		setter.SetBaseDir(dir)
+	}
+}
+
+// AuthDir forwards to the wrapped store when supported.
+func (s *EncryptingStore) AuthDir() string {
+	if provider, ok := s.inner.(interface{ AuthDir() string }); ok {
+		return provider.AuthDir()
+	}
+	return ""
+}
+
+// PersistConfig forwards to the wrapped store when supported.
+func (s *EncryptingStore) PersistConfig(ctx context.Context) error {
+	if persister, ok := s.inner.(interface{ PersistConfig(context.Context) error }); ok {
+		return persister.PersistConfig(ctx)
+	}
+	return nil
+}
+
+// PersistAuthFiles forwards to the wrapped store when supported.
+func (s *EncryptingStore) PersistAuthFiles(ctx context.Context, message string, paths ...string) error {
+	if persister, ok := s.inner.(interface {
+		PersistAuthFiles(context.Context, string, ...string) error
+	}); ok {
+		return persister.PersistAuthFiles(ctx, message, paths...)
+	}
+	return nil
+}
+
+// encryptedRecordType maps a runtime provider back to the auth file "type".
+func encryptedRecordType(provider string) string {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "gemini-cli" {
+		return "gemini"
+	}
+	return provider
+}
@@ -0,0 +1,355 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// EncryptedMetadataKey holds the encrypted envelope inside an encrypted auth record.
+const EncryptedMetadataKey = "encrypted_metadata"
+
+const encryptedMetadataVersion = 1
+
+// Passphrase key derivation, matching the auth bundle export.
+const (
+	passphraseKDF      = "scrypt"
+	passphraseSaltSize = 16
+	passphraseScryptN  = 1 << 15
+	passphraseScryptR  = 8
+	passphraseScryptP  = 1
+)
+
+// encryptedClearFields stay readable in an encrypted record so listings and file
+// watchers can identify the credential without the key.
+var encryptedClearFields = []string{"type", "email", "disabled"}
+
+// ErrMetadataCipherMissing is returned when an encrypted auth record is read without a cipher.
+var ErrMetadataCipherMissing = errors.New("auth metadata is encrypted but no cipher is configured")
+
+// MetadataCipher encrypts and decrypts serialized auth metadata.
+type MetadataCipher interface {
+	Encrypt(plaintext []byte) (nonce, ciphertext []byte, err error)
+	Decrypt(nonce, ciphertext []byte) ([]byte, error)
+	// Algorithm names the scheme recorded in the envelope.
+	Algorithm() string
+}
+
+// AESGCMCipher is a MetadataCipher using AES-256-GCM.
+type AESGCMCipher struct {
+	aead cipher.AEAD
+}
+
+// NewAESGCMCipher creates an AES-256-GCM cipher from a 32-byte key.
+func NewAESGCMCipher(key []byte) (*AESGCMCipher, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("auth encryption: key must be 32 bytes, got %d", len(key))
+	}
+	block, errBlock := aes.NewCipher(key)
+	if errBlock != nil {
+		return nil, fmt.Errorf("auth encryption: %w", errBlock)
+	}
+	aead, errGCM := cipher.NewGCM(block)
+	if errGCM != nil {
+		return nil, fmt.Errorf("auth encryption: %w", errGCM)
+	}
+	return &AESGCMCipher{aead: aead}, nil
+}
+
+// NewAESGCMCipherFromKeyFunc creates an AES-256-GCM cipher from a key returned by
+// keyFunc, e.g. a data key decrypted through a KMS.
+func NewAESGCMCipherFromKeyFunc(keyFunc func() ([]byte, error)) (*AESGCMCipher, error) {
+	if keyFunc == nil {
+		return nil, errors.New("auth encryption: key function is nil")
+	}
+	key, errKey := keyFunc()
+	if errKey != nil {
+		return nil, fmt.Errorf("auth encryption: load key: %w", errKey)
+	}
+	return NewAESGCMCipher(key)
+}
+
+// ParseEncryptionKey decodes a base64 32-byte key. Any other value is treated as a
+// passphrase and stretched with scrypt over salt.
+func ParseEncryptionKey(raw string, salt []byte) ([]byte, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil, errors.New("auth encryption: key is empty")
+	}
+	if decoded, errDecode := base64.StdEncoding.DecodeString(raw); errDecode == nil && len(decoded) == 32 {
+		return decoded, nil
+	}
+	if len(salt) == 0 {
+		return nil, errors.New("auth encryption: passphrase requires a salt")
+	}
+	key, errKDF := scrypt.Key([]byte(raw), salt, passphraseScryptN, passphraseScryptR, passphraseScryptP, 32)
+	if errKDF != nil {
+		return nil, fmt.Errorf("auth encryption: derive key: %w", errKDF)
+	}
+	return key, nil
+}
+
+// NewMetadataCipher creates the cipher for raw: an AES-256-GCM cipher for a base64
+// 32-byte key, or a PassphraseCipher for any other value.
+func NewMetadataCipher(raw string) (MetadataCipher, error) {
+	raw = strings.TrimSpace(raw)
+	if decoded, errDecode := base64.StdEncoding.DecodeString(raw); errDecode == nil && len(decoded) == 32 {
+		return NewAESGCMCipher(decoded)
+	}
+	return NewPassphraseCipher(raw)
+}
+
+// SaltedMetadataCipher is a MetadataCipher whose key is derived from a salt recorded in
+// every envelope it writes.
+type SaltedMetadataCipher interface {
+	MetadataCipher
+	// Salt returns the salt of the key used by Encrypt.
+	Salt() []byte
+	// ForSalt returns the cipher for records written with salt.
+	ForSalt(salt []byte) (MetadataCipher, error)
+}
+
+// PassphraseCipher is an AES-256-GCM SaltedMetadataCipher keyed by a passphrase
+// stretched with scrypt. It writes with a random salt and reads records of any salt.
+type PassphraseCipher struct {
+	passphrase string
+	salt       []byte
+	write      *AESGCMCipher
+
+	mu     sync.Mutex
+	bySalt map[string]*AESGCMCipher
+}
+
+// NewPassphraseCipher creates a passphrase cipher with a fresh random salt.
+func NewPassphraseCipher(passphrase string) (*PassphraseCipher, error) {
+	salt := make([]byte, passphraseSaltSize)
+	if _, errRand := rand.Read(salt); errRand != nil {
+		return nil, fmt.Errorf("auth encryption: salt: %w", errRand)
+	}
+	c := &PassphraseCipher{passphrase: passphrase, salt: salt, bySalt: make(map[string]*AESGCMCipher)}
+	write, errWrite := c.cipherFor(salt)
+	if errWrite != nil {
+		return nil, errWrite
+	}
+	c.write = write
+	return c, nil
+}
+
+func (c *PassphraseCipher) cipherFor(salt []byte) (*AESGCMCipher, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached := c.bySalt[string(salt)]; cached != nil {
+		return cached, nil
+	}
+	key, errKey := ParseEncryptionKey(c.passphrase, salt)
+	if errKey != nil {
+		return nil, errKey
+	}
+	derived, errCipher := NewAESGCMCipher(key)
+	if errCipher != nil {
+		return nil, errCipher
+	}
+	c.bySalt[string(salt)] = derived
+	return derived, nil
+}
+
+// Encrypt implements MetadataCipher.
+func (c *PassphraseCipher) Encrypt(plaintext []byte) ([]byte, []byte, error) {
+	return c.write.Encrypt(plaintext)
+}
+
+// Decrypt implements MetadataCipher for records written with Salt.
+func (c *PassphraseCipher) Decrypt(nonce, ciphertext []byte) ([]byte, error) {
+	return c.write.Decrypt(nonce, ciphertext)
+}
+
+// Algorithm implements MetadataCipher.
+func (c *PassphraseCipher) Algorithm() string { return c.write.Algorithm() }
+
+// Salt implements SaltedMetadataCipher.
+func (c *PassphraseCipher) Salt() []byte { return c.salt }
+
+// ForSalt implements SaltedMetadataCipher.
+func (c *PassphraseCipher) ForSalt(salt []byte) (MetadataCipher, error) {
+	return c.cipherFor(salt)
+}
+
+// Encrypt implements MetadataCipher.
+func (c *AESGCMCipher) Encrypt(plaintext []byte) ([]byte, []byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, errRand := rand.Read(nonce); errRand != nil {
+		return nil, nil, fmt.Errorf("auth encryption: nonce: %w", errRand)
+	}
+	return nonce, c.aead.Seal(nil, nonce, plaintext, nil), nil
+}
+
+// Decrypt implements MetadataCipher.
+func (c *AESGCMCipher) Decrypt(nonce, ciphertext []byte) ([]byte, error) {
+	if len(nonce) != c.aead.NonceSize() {
+		return nil, errors.New("auth encryption: invalid nonce")
+	}
+	plaintext, errOpen := c.aead.Open(nil, nonce, ciphertext, nil)
+	if errOpen != nil {
+		return nil, fmt.Errorf("auth encryption: decrypt: %w", errOpen)
+	}
+	return plaintext, nil
+}
+
+// Algorithm implements MetadataCipher.
+func (c *AESGCMCipher) Algorithm() string { return "AES-256-GCM" }
+
+type encryptedEnvelope struct {
+	Version   int    `json:"v"`
+	Algorithm string `json:"alg"`
+	// KDF and Salt are set when the key is derived from a passphrase.
+	KDF        string `json:"kdf,omitempty"`
+	Salt       string `json:"salt,omitempty"`
+	Nonce      string `json:"nonce"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+var (
+	metadataCipherMu sync.RWMutex
+	metadataCipher   MetadataCipher
+)
+
+// RegisterMetadataCipher sets the cipher used by file loaders to read encrypted auth
+// records. Nil unregisters it.
+func RegisterMetadataCipher(c MetadataCipher) {
+	metadataCipherMu.Lock()
+	metadataCipher = c
+	metadataCipherMu.Unlock()
+}
+
+// RegisteredMetadataCipher returns the cipher set by RegisterMetadataCipher, or nil.
+func RegisteredMetadataCipher() MetadataCipher {
+	metadataCipherMu.RLock()
+	defer metadataCipherMu.RUnlock()
+	return metadataCipher
+}
+
+// IsEncryptedMetadata reports whether metadata is an encrypted record.
+func IsEncryptedMetadata(metadata map[string]any) bool {
+	_, ok := metadata[EncryptedMetadataKey].(map[string]any)
+	return ok
+}
+
+// EncryptMetadata returns the encrypted record for metadata. Already encrypted records
+// are returned unchanged.
+func EncryptMetadata(c MetadataCipher, metadata map[string]any) (map[string]any, error) {
+	if c == nil {
+		return nil, ErrMetadataCipherMissing
+	}
+	if IsEncryptedMetadata(metadata) {
+		return metadata, nil
+	}
+	plaintext, errMarshal := json.Marshal(metadata)
+	if errMarshal != nil {
+		return nil, fmt.Errorf("auth encryption: marshal metadata: %w", errMarshal)
+	}
+	nonce, ciphertext, errEncrypt := c.Encrypt(plaintext)
+	if errEncrypt != nil {
+		return nil, errEncrypt
+	}
+	out := make(map[string]any, len(encryptedClearFields)+1)
+	for _, field := range encryptedClearFields {
+		if value, ok := metadata[field]; ok {
+			out[field] = value
+		}
+	}
+	envelope := map[string]any{
+		"v":          encryptedMetadataVersion,
+		"alg":        c.Algorithm(),
+		"nonce":      base64.StdEncoding.EncodeToString(nonce),
+		"ciphertext": base64.StdEncoding.EncodeToString(ciphertext),
+	}
+	if salted, ok := c.(SaltedMetadataCipher); ok {
+		envelope["kdf"] = passphraseKDF
+		envelope["salt"] = base64.StdEncoding.EncodeToString(salted.Salt())
+	}
+	out[EncryptedMetadataKey] = envelope
+	return out, nil
+}
+
+// DecryptMetadata returns the plaintext metadata of an encrypted record. Plaintext
+// records are returned unchanged.
+func DecryptMetadata(c MetadataCipher, metadata map[string]any) (map[string]any, error) {
+	rawEnvelope, ok := metadata[EncryptedMetadataKey].(map[string]any)
+	if !ok {
+		return metadata, nil
+	}
+	if c == nil {
+		return nil, ErrMetadataCipherMissing
+	}
+	encoded, errMarshal := json.Marshal(rawEnvelope)
+	if errMarshal != nil {
+		return nil, fmt.Errorf("auth encryption: read envelope: %w", errMarshal)
+	}
+	var envelope encryptedEnvelope
+	if errUnmarshal := json.Unmarshal(encoded, &envelope); errUnmarshal != nil {
+		return nil, fmt.Errorf("auth encryption: read envelope: %w", errUnmarshal)
+	}
+	if envelope.Version != encryptedMetadataVersion || envelope.Algorithm != c.Algorithm() {
+		return nil, fmt.Errorf("auth encryption: unsupported envelope %d/%s", envelope.Version, envelope.Algorithm)
+	}
+	if envelope.Salt != "" || envelope.KDF != "" {
+		salted, ok := c.(SaltedMetadataCipher)
+		if !ok || envelope.KDF != passphraseKDF {
+			return nil, fmt.Errorf("auth encryption: record needs a %q passphrase key", envelope.KDF)
+		}
+		salt, errSalt := base64.StdEncoding.DecodeString(envelope.Salt)
+		if errSalt != nil {
+			return nil, fmt.Errorf("auth encryption: decode salt: %w", errSalt)
+		}
+		derived, errDerive := salted.ForSalt(salt)
+		if errDerive != nil {
+			return nil, errDerive
+		}
+		c = derived
+	} else if _, ok := c.(SaltedMetadataCipher); ok {
+		return nil, errors.New("auth encryption: record was written with a raw key, not a passphrase")
+	}
+	nonce, errNonce := base64.StdEncoding.DecodeString(envelope.Nonce)
+	if errNonce != nil {
+		return nil, fmt.Errorf("auth encryption: decode nonce: %w", errNonce)
+	}
+	ciphertext, errCiphertext := base64.StdEncoding.DecodeString(envelope.Ciphertext)
+	if errCiphertext != nil {
+		return nil, fmt.Errorf("auth encryption: decode ciphertext: %w", errCiphertext)
+	}
+	plaintext, errDecrypt := c.Decrypt(nonce, ciphertext)
+	if errDecrypt != nil {
+		return nil, errDecrypt
+	}
+	decrypted := make(map[string]any)
+	if errUnmarshal := json.Unmarshal(plaintext, &decrypted); errUnmarshal != nil {
+		return nil, fmt.Errorf("auth encryption: unmarshal metadata: %w", errUnmarshal)
+	}
+	return decrypted, nil
+}
+
+// DecryptAuthJSON returns the plaintext JSON of an auth file using the registered
+// cipher. Plaintext files are returned unchanged.
+func DecryptAuthJSON(data []byte) ([]byte, error) {
+	if !bytes.Contains(data, []byte(EncryptedMetadataKey)) {
+		return data, nil
+	}
+	var metadata map[string]any
+	if errUnmarshal := json.Unmarshal(data, &metadata); errUnmarshal != nil || !IsEncryptedMetadata(metadata) {
+		return data, nil
+	}
+	decrypted, errDecrypt := DecryptMetadata(RegisteredMetadataCipher(), metadata)
+	if errDecrypt != nil {
+		return nil, errDecrypt
+	}
+	return json.Marshal(decrypted)
+}
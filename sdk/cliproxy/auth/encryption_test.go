@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type memoryStore struct {
+	mu    sync.Mutex
+	saved map[string]*Auth
+}
+
+func (s *memoryStore) List(context.Context) ([]*Auth, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*Auth, 0, len(s.saved))
+	for _, auth := range s.saved {
+		out = append(out, auth.Clone())
+	}
+	return out, nil
+}
+
+func (s *memoryStore) Save(_ context.Context, auth *Auth) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.saved == nil {
+		s.saved = make(map[string]*Auth)
+	}
+	s.saved[auth.ID] = auth.Clone()
+	return auth.ID, nil
+}
+
+func (s *memoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.saved, id)
+	return nil
+}
+
+func newTestCipher(t *testing.T, passphrase string) MetadataCipher {
+	t.Helper()
+	c, errCipher := NewMetadataCipher(passphrase)
+	if errCipher != nil {
+		t.Fatalf("NewMetadataCipher: %v", errCipher)
+	}
+	return c
+}
+
+func TestEncryptMetadataRoundTrip(t *testing.T) {
+	c := newTestCipher(t, "correct horse battery staple")
+	meta := map[string]any{"type": "claude", "email": "a@example.com", "access_token": "secret-token"}
+
+	encrypted, err := EncryptMetadata(c, meta)
+	if err != nil {
+		t.Fatalf("EncryptMetadata: %v", err)
+	}
+	raw, _ := json.Marshal(encrypted)
+	if strings.Contains(string(raw), "secret-token") {
+		t.Fatalf("encrypted record leaks token: %s", raw)
+	}
+	if encrypted["type"] != "claude" || encrypted["email"] != "a@example.com" {
+		t.Fatalf("clear fields missing: %v", encrypted)
+	}
+
+	decrypted, err := DecryptMetadata(c, encrypted)
+	if err != nil {
+		t.Fatalf("DecryptMetadata: %v", err)
+	}
+	if decrypted["access_token"] != "secret-token" {
+		t.Fatalf("access_token = %v, want secret-token", decrypted["access_token"])
+	}
+
+	restarted := newTestCipher(t, "correct horse battery staple")
+	if decrypted, err = DecryptMetadata(restarted, encrypted); err != nil || decrypted["access_token"] != "secret-token" {
+		t.Fatalf("DecryptMetadata with a freshly salted cipher = %v, %v", decrypted, err)
+	}
+	envelope := encrypted[EncryptedMetadataKey].(map[string]any)
+	if envelope["kdf"] != "scrypt" || envelope["salt"] == "" {
+		t.Fatalf("passphrase envelope should record its salt, got %v", envelope)
+	}
+
+	if _, err = DecryptMetadata(newTestCipher(t, "wrong"), encrypted); err == nil {
+		t.Fatal("expected wrong key to fail")
+	}
+	if _, err = DecryptMetadata(nil, encrypted); err != ErrMetadataCipherMissing {
+		t.Fatalf("err = %v, want ErrMetadataCipherMissing", err)
+	}
+}
+
+func TestDecryptMetadataPassesPlaintextThrough(t *testing.T) {
+	meta := map[string]any{"type": "codex", "access_token": "plain"}
+	out, err := DecryptMetadata(nil, meta)
+	if err != nil {
+		t.Fatalf("DecryptMetadata: %v", err)
+	}
+	if out["access_token"] != "plain" {
+		t.Fatalf("plaintext metadata changed: %v", out)
+	}
+}
+
+func TestEncryptingStoreSaveAndList(t *testing.T) {
+	inner := &memoryStore{}
+	store, err := NewEncryptingStore(inner, newTestCipher(t, "k"))
+	if err != nil {
+		t.Fatalf("NewEncryptingStore: %v", err)
+	}
+	auth := &Auth{
+		ID:       "claude-1.json",
+		Provider: "claude",
+		Metadata: map[string]any{"type": "claude", "email": "a@example.com", "access_token": "tok"},
+	}
+	if _, err = store.Save(context.Background(), auth); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if !IsEncryptedMetadata(inner.saved["claude-1.json"].Metadata) {
+		t.Fatalf("inner store received plaintext: %v", inner.saved["claude-1.json"].Metadata)
+	}
+	if auth.Metadata["access_token"] != "tok" {
+		t.Fatalf("caller metadata was replaced: %v", auth.Metadata)
+	}
+
+	inner.saved["legacy.json"] = &Auth{ID: "legacy.json", Provider: "codex", Metadata: map[string]any{"type": "codex", "access_token": "old"}}
+	auths, err := store.List(context.Background())
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	got := make(map[string]string, len(auths))
+	for _, listed := range auths {
+		got[listed.ID], _ = listed.Metadata["access_token"].(string)
+	}
+	if got["claude-1.json"] != "tok" || got["legacy.json"] != "old" {
+		t.Fatalf("listed tokens = %v", got)
+	}
+}
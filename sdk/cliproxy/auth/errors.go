@@ -2,6 +2,13 @@ package auth
 
 const requestScopedErrorCode = "request_scoped"
 
+// ClientCancelledErrorCode marks results of attempts abandoned because the client went
+// away. They reach result hooks but never count against the credential.
+const ClientCancelledErrorCode = "client_cancelled"
+
+// statusClientClosedRequest is the non-standard status recorded for client cancellations.
+const statusClientClosedRequest = 499
+
 // Error describes an authentication related failure in a provider agnostic format.
 type Error struct {
 	// Code is a short machine readable identifier.
@@ -38,3 +45,8 @@ func (e *Error) StatusCode() int {
 func (e *Error) IsRequestScoped() bool {
 	return e != nil && e.Code == requestScopedErrorCode
 }
+
+// IsClientCancelled reports whether the attempt ended because the client disconnected.
+func (e *Error) IsClientCancelled() bool {
+	return e != nil && e.Code == ClientCancelledErrorCode
+}
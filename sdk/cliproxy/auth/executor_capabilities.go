@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+// EmbeddingsSourceFormat is the source format of requests entering through the
+// OpenAI-compatible /v1/embeddings endpoint.
+const EmbeddingsSourceFormat = "openai-embedding"
+
+// ImagesSourceFormat is the source format of requests entering through the
+// OpenAI-compatible image endpoints.
+const ImagesSourceFormat = "openai-image"
+
+// ImagesExecutor is implemented by provider executors that map OpenAI image generation
+// requests onto a native image API. Responses use the OpenAI images shape with base64
+// data; the handler applies the client's response_format. Executors without it receive
+// image requests through Execute.
+type ImagesExecutor interface {
+	GenerateImages(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error)
+}
+
+// EmbeddingsExecutor is implemented by provider executors that can create embeddings.
+// Requests carry the OpenAI embeddings body and responses must use the OpenAI
+// embeddings response shape.
+type EmbeddingsExecutor interface {
+	Embeddings(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error)
+}
+
+// UnsupportedEmbeddings is the default adapter used for executors that do not
+// implement EmbeddingsExecutor.
+type UnsupportedEmbeddings struct {
+	Provider string
+}
+
+// Embeddings always fails with 501. The error is request scoped so the credential
+// is not cooled down for a capability its provider lacks.
+func (u UnsupportedEmbeddings) Embeddings(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, &Error{
+		Code:       requestScopedErrorCode,
+		Message:    fmt.Sprintf("provider %s does not support embeddings", u.Provider),
+		HTTPStatus: http.StatusNotImplemented,
+	}
+}
+
+// embeddingsExecutorFor returns the embeddings implementation of executor, falling
+// back to UnsupportedEmbeddings.
+func embeddingsExecutorFor(executor ProviderExecutor) EmbeddingsExecutor {
+	if embedder, ok := executor.(EmbeddingsExecutor); ok && embedder != nil {
+		return embedder
+	}
+	provider := ""
+	if executor != nil {
+		provider = executor.Identifier()
+	}
+	return UnsupportedEmbeddings{Provider: provider}
+}
+
+// executeProvider runs a non-streaming request, routing embeddings and image generation
+// requests to the matching executor capability. Executor panics become errors.
+func executeProvider(ctx context.Context, executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	defer recoverExecutorPanic(executor, "Execute", auth, &err)
+	switch opts.SourceFormat.String() {
+	case EmbeddingsSourceFormat:
+		return embeddingsExecutorFor(executor).Embeddings(ctx, auth, req, opts)
+	case ImagesSourceFormat:
+		if generator, ok := executor.(ImagesExecutor); ok && generator != nil {
+			return generator.GenerateImages(ctx, auth, req, opts)
+		}
+	}
+	return executor.Execute(ctx, auth, req, opts)
+}
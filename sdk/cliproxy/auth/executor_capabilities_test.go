@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+)
+
+type embeddingsTestExecutor struct {
+	replaceAwareExecutor
+}
+
+func (e *embeddingsTestExecutor) Embeddings(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{Payload: []byte(`{"object":"list"}`)}, nil
+}
+
+func TestExecuteProviderRoutesEmbeddings(t *testing.T) {
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString(EmbeddingsSourceFormat)}
+
+	resp, err := executeProvider(context.Background(), &embeddingsTestExecutor{replaceAwareExecutor{id: "embed"}}, nil, cliproxyexecutor.Request{}, opts)
+	if err != nil || string(resp.Payload) != `{"object":"list"}` {
+		t.Fatalf("executeProvider = %s, %v; want embeddings response", resp.Payload, err)
+	}
+
+	_, err = executeProvider(context.Background(), &replaceAwareExecutor{id: "chat-only"}, nil, cliproxyexecutor.Request{}, opts)
+	var authErr *Error
+	if !errors.As(err, &authErr) || authErr.HTTPStatus != http.StatusNotImplemented {
+		t.Fatalf("executeProvider error = %v, want 501", err)
+	}
+	if !isRequestScopedResultError(resultErrorFromError(err)) {
+		t.Fatal("unsupported embeddings error should not penalize the credential")
+	}
+}
+
+type imagesTestExecutor struct {
+	replaceAwareExecutor
+}
+
+func (e *imagesTestExecutor) GenerateImages(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{Payload: []byte(`{"data":[]}`)}, nil
+}
+
+func TestExecuteProviderRoutesImages(t *testing.T) {
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString(ImagesSourceFormat)}
+
+	resp, err := executeProvider(context.Background(), &imagesTestExecutor{replaceAwareExecutor{id: "images"}}, nil, cliproxyexecutor.Request{}, opts)
+	if err != nil || string(resp.Payload) != `{"data":[]}` {
+		t.Fatalf("executeProvider = %s, %v; want GenerateImages response", resp.Payload, err)
+	}
+
+	// Executors without the capability keep receiving image requests through Execute.
+	resp, err = executeProvider(context.Background(), &replaceAwareExecutor{id: "legacy"}, nil, cliproxyexecutor.Request{}, opts)
+	if err != nil || resp.Payload != nil {
+		t.Fatalf("executeProvider = %s, %v; want Execute response", resp.Payload, err)
+	}
+}
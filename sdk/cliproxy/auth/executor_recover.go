@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// executorPanicErrorCode marks errors converted from a panicking executor.
+const executorPanicErrorCode = "executor_panic"
+
+// recoverExecutorPanic turns a panic of an executor call into a retryable 500 stored in
+// *err, so one faulty executor fails the attempt instead of the process and selection
+// moves on to the next auth. It must be deferred directly by the calling function.
+func recoverExecutorPanic(executor ProviderExecutor, call string, auth *Auth, err *error) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	provider := ""
+	if executor != nil {
+		provider = executor.Identifier()
+	}
+	authID := ""
+	if auth != nil {
+		authID = auth.ID
+	}
+	log.Errorf("%s executor panicked in %s for auth %s: %v\n%s", provider, call, authID, recovered, debug.Stack())
+	*err = &Error{
+		Code:       executorPanicErrorCode,
+		Message:    fmt.Sprintf("%s executor %s panicked: %v", provider, call, recovered),
+		Retryable:  true,
+		HTTPStatus: http.StatusInternalServerError,
+	}
+}
+
+// executeProviderStream starts a streaming request, converting executor panics to errors.
+// Panics raised later in the executor's stream goroutine are turned into an error chunk
+// by the executor itself (see helps.RecoverStreamPanic).
+func executeProviderStream(ctx context.Context, executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (result *cliproxyexecutor.StreamResult, err error) {
+	defer recoverExecutorPanic(executor, "ExecuteStream", auth, &err)
+	return executor.ExecuteStream(ctx, auth, req, opts)
+}
+
+// countProviderTokens runs a token count, converting executor panics to errors.
+func countProviderTokens(ctx context.Context, executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	defer recoverExecutorPanic(executor, "CountTokens", auth, &err)
+	return executor.CountTokens(ctx, auth, req, opts)
+}
+
+// refreshProvider refreshes auth credentials, converting executor panics to errors.
+func refreshProvider(ctx context.Context, executor ProviderExecutor, auth *Auth) (updated *Auth, err error) {
+	defer recoverExecutorPanic(executor, "Refresh", auth, &err)
+	return executor.Refresh(ctx, auth)
+}
@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+type panickingExecutor struct {
+	*providerFallbackExecutor
+}
+
+func (e panickingExecutor) Execute(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	panic("execute boom")
+}
+
+func (e panickingExecutor) ExecuteStream(context.Context, *Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	panic("stream boom")
+}
+
+func (e panickingExecutor) Refresh(context.Context, *Auth) (*Auth, error) {
+	panic("refresh boom")
+}
+
+func TestManagerExecuteRecoversExecutorPanic(t *testing.T) {
+	const model = "glm-5.1"
+	m, _, second := newProviderFallbackTestManager(t, model)
+	m.RegisterExecutor(panickingExecutor{&providerFallbackExecutor{id: "first"}})
+	m.SetRetryConfig(0, 0, 2)
+	_, restoreLogger := captureStandardLogger(t)
+	defer restoreLogger()
+
+	resp, err := m.Execute(context.Background(), []string{"first", "second"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("execute error = %v, want fallback success", err)
+	}
+	if got := string(resp.Payload); got != "second:"+t.Name()+"-second:"+model {
+		t.Fatalf("payload = %q, want second provider success", got)
+	}
+	if got := second.ExecuteCalls(); len(got) != 1 {
+		t.Fatalf("second execute calls = %v", got)
+	}
+	first, _ := m.GetByID(t.Name() + "-first")
+	state := first.ModelStates[model]
+	if state == nil || state.LastError == nil || state.LastError.Code != executorPanicErrorCode || state.LastError.HTTPStatus != http.StatusInternalServerError {
+		t.Fatalf("first model state = %+v, want recorded executor panic", state)
+	}
+}
+
+func TestManagerExecuteStreamRecoversExecutorPanic(t *testing.T) {
+	const model = "glm-5.1"
+	m, _, second := newProviderFallbackTestManager(t, model)
+	m.RegisterExecutor(panickingExecutor{&providerFallbackExecutor{id: "first"}})
+	m.SetRetryConfig(0, 0, 2)
+	_, restoreLogger := captureStandardLogger(t)
+	defer restoreLogger()
+
+	result, err := m.ExecuteStream(context.Background(), []string{"first", "second"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("execute stream error = %v, want fallback success", err)
+	}
+	for range result.Chunks {
+	}
+	if got := second.StreamCalls(); len(got) != 1 {
+		t.Fatalf("second stream calls = %v", got)
+	}
+}
+
+func TestRefreshProviderRecoversExecutorPanic(t *testing.T) {
+	_, restoreLogger := captureStandardLogger(t)
+	defer restoreLogger()
+
+	updated, err := refreshProvider(context.Background(), panickingExecutor{&providerFallbackExecutor{id: "first"}}, &Auth{ID: "a"})
+	var authErr *Error
+	if updated != nil || !errors.As(err, &authErr) || authErr.Code != executorPanicErrorCode {
+		t.Fatalf("refreshProvider = %v, %v; want executor panic error", updated, err)
+	}
+}
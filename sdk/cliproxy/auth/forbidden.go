@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// Built-in 403 body patterns. Key-blocked patterns win over request-rejected ones so an
+// account suspended "for usage policy violations" still benches the key; a 403 that
+// matches neither list keeps the legacy behaviour and cools the key down.
+var (
+	defaultForbiddenKeyBlockedPatterns = []string{
+		"api key", "api_key", "apikey", "account", "organization", "suspended", "deactivated",
+		"disabled", "banned", "billing", "subscription", "not allowed to use", "region",
+	}
+	defaultForbiddenRequestRejectedPatterns = map[string][]string{
+		"": {
+			"content policy", "content_policy", "content filter", "content_filter", "usage policy",
+			"usage_policy", "safety", "prohibited content", "prohibited_content", "harmful", "violates",
+		},
+		"gemini":      {"blockreason", "harm_category"},
+		"gemini-cli":  {"blockreason", "harm_category"},
+		"vertex":      {"blockreason", "harm_category"},
+		"aistudio":    {"blockreason", "harm_category"},
+		"antigravity": {"blockreason", "harm_category"},
+		"codex":       {"invalid_prompt", "flagged"},
+		"claude":      {"output blocked", "request blocked"},
+	}
+)
+
+// forbiddenRequestRejectedError marks a 403 that rejected the request content rather than
+// the credential. It is request scoped, so the key is not cooled down and other keys are
+// not tried with the same content.
+type forbiddenRequestRejectedError struct {
+	err error
+}
+
+func (e *forbiddenRequestRejectedError) Error() string { return e.err.Error() }
+
+func (e *forbiddenRequestRejectedError) Unwrap() error { return e.err }
+
+func (e *forbiddenRequestRejectedError) StatusCode() int { return http.StatusForbidden }
+
+func (e *forbiddenRequestRejectedError) IsRequestScoped() bool { return true }
+
+func (e *forbiddenRequestRejectedError) Headers() http.Header {
+	if he, ok := e.err.(interface{ Headers() http.Header }); ok && he != nil {
+		return he.Headers()
+	}
+	return nil
+}
+
+// classifyForbiddenError wraps a 403 whose body matches a request-rejected pattern for
+// provider so it is handled as request scoped. Other errors are returned unchanged.
+func (m *Manager) classifyForbiddenError(provider string, err error) error {
+	if err == nil || statusCodeFromError(err) != http.StatusForbidden || isRequestScopedError(err) {
+		return err
+	}
+	var rules []internalconfig.ForbiddenRule
+	if cfg, ok := m.runtimeConfig.Load().(*internalconfig.Config); ok && cfg != nil {
+		rules = cfg.ForbiddenRules
+	}
+	if classifyForbiddenMessage(provider, err.Error(), rules) {
+		return &forbiddenRequestRejectedError{err: err}
+	}
+	return err
+}
+
+// classifyForbiddenMessage reports whether a 403 body means "request rejected" for provider.
+// Configured rules are checked before the built-in patterns; within each set key-blocked
+// patterns take precedence.
+func classifyForbiddenMessage(provider, message string, rules []internalconfig.ForbiddenRule) bool {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	message = strings.ToLower(message)
+	var keyBlocked, requestRejected []string
+	for _, rule := range rules {
+		ruleProvider := strings.ToLower(strings.TrimSpace(rule.Provider))
+		if ruleProvider != "" && ruleProvider != provider {
+			continue
+		}
+		keyBlocked = append(keyBlocked, rule.KeyBlocked...)
+		requestRejected = append(requestRejected, rule.RequestRejected...)
+	}
+	if containsAnyPattern(message, keyBlocked) {
+		return false
+	}
+	if containsAnyPattern(message, requestRejected) {
+		return true
+	}
+	if containsAnyPattern(message, defaultForbiddenKeyBlockedPatterns) {
+		return false
+	}
+	return containsAnyPattern(message, defaultForbiddenRequestRejectedPatterns[""]) ||
+		containsAnyPattern(message, defaultForbiddenRequestRejectedPatterns[provider])
+}
+
+func containsAnyPattern(message string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern != "" && strings.Contains(message, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestClassifyForbiddenMessage(t *testing.T) {
+	rules := []internalconfig.ForbiddenRule{
+		{Provider: "claude", RequestRejected: []string{"custom refusal"}},
+		{KeyBlocked: []string{"content policy for this key"}},
+	}
+	cases := []struct {
+		name     string
+		provider string
+		message  string
+		rejected bool
+	}{
+		{name: "content policy", provider: "codex", message: "Your request was rejected: content policy violation", rejected: true},
+		{name: "provider pattern", provider: "gemini", message: `{"promptFeedback":{"blockReason":"SAFETY"}}`, rejected: true},
+		{name: "provider pattern other provider", provider: "claude", message: "harm_category_dangerous", rejected: false},
+		{name: "suspended account", provider: "claude", message: "account suspended for usage policy violations", rejected: false},
+		{name: "unmatched", provider: "claude", message: "forbidden", rejected: false},
+		{name: "configured rejected", provider: "claude", message: "Custom Refusal from upstream", rejected: true},
+		{name: "configured rejected wrong provider", provider: "codex", message: "custom refusal", rejected: false},
+		{name: "configured key blocked wins", provider: "codex", message: "violates content policy for this key", rejected: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := classifyForbiddenMessage(tc.provider, tc.message, rules); got != tc.rejected {
+				t.Fatalf("classifyForbiddenMessage(%q, %q) = %v, want %v", tc.provider, tc.message, got, tc.rejected)
+			}
+		})
+	}
+}
+
+func TestManagerExecute_ContentPolicy403DoesNotCoolDownAuth(t *testing.T) {
+	const model = "glm-5.1"
+	m, first, second := newProviderFallbackTestManager(t, model)
+	first.executeErr = &Error{HTTPStatus: http.StatusForbidden, Message: "request violates content policy"}
+
+	_, err := m.Execute(context.Background(), []string{"first", "second"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{})
+	if err == nil {
+		t.Fatal("execute error = nil, want 403")
+	}
+	if got := statusCodeFromError(err); got != http.StatusForbidden {
+		t.Fatalf("status = %d, want %d", got, http.StatusForbidden)
+	}
+	if len(second.executeCalls) != 0 {
+		t.Fatalf("second executor calls = %v, want none", second.executeCalls)
+	}
+	auth, _ := m.GetByID(t.Name() + "-first")
+	if state := auth.ModelStates[model]; state != nil && state.Unavailable {
+		t.Fatalf("model state unavailable = true, want auth left routable")
+	}
+	if auth.Unavailable {
+		t.Fatal("auth unavailable = true, want false")
+	}
+}
+
+func TestManagerExecute_KeyBlocked403CoolsDownAuth(t *testing.T) {
+	const model = "glm-5.1"
+	m, first, _ := newProviderFallbackTestManager(t, model)
+	first.executeErr = &Error{HTTPStatus: http.StatusForbidden, Message: "organization has been disabled"}
+
+	_, _ = m.Execute(context.Background(), []string{"first"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{})
+	auth, _ := m.GetByID(t.Name() + "-first")
+	state := auth.ModelStates[model]
+	if state == nil || !state.Unavailable {
+		t.Fatalf("model state = %+v, want cooled down", state)
+	}
+}
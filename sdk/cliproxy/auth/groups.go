@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+// ParseGroups normalizes auth groups stored as a comma-separated string or a list.
+// Names are trimmed, lower-cased and deduplicated; nil is returned when none remain.
+func ParseGroups(value any) []string {
+	var raw []string
+	switch v := value.(type) {
+	case string:
+		raw = strings.Split(v, ",")
+	case []string:
+		raw = v
+	case []any:
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				raw = append(raw, s)
+			}
+		}
+	}
+	groups := make([]string, 0, len(raw))
+	seen := make(map[string]struct{}, len(raw))
+	for _, group := range raw {
+		group = strings.ToLower(strings.TrimSpace(group))
+		if group == "" {
+			continue
+		}
+		if _, dup := seen[group]; dup {
+			continue
+		}
+		seen[group] = struct{}{}
+		groups = append(groups, group)
+	}
+	if len(groups) == 0 {
+		return nil
+	}
+	return groups
+}
+
+// InGroup reports whether the auth belongs to any of groups.
+func (a *Auth) InGroup(groups ...string) bool {
+	if a == nil {
+		return false
+	}
+	for _, group := range groups {
+		group = strings.TrimSpace(group)
+		for _, own := range a.Groups {
+			if strings.EqualFold(own, group) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ListByGroup returns the auth entries belonging to group.
+func (m *Manager) ListByGroup(group string) []*Auth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	list := make([]*Auth, 0)
+	for _, auth := range m.auths {
+		if auth.InGroup(group) {
+			list = append(list, auth.Clone())
+		}
+	}
+	return list
+}
+
+// groupFilter restricts selection to the auth groups required by the request and by the
+// routing.model-groups rule of its model. An auth must belong to one group of every
+// restriction that applies.
+type groupFilter struct {
+	restrictions [][]string
+}
+
+func (m *Manager) newGroupFilter(model string, opts cliproxyexecutor.Options) *groupFilter {
+	filter := &groupFilter{}
+	if groups := authGroupsFromMetadata(opts.Metadata); len(groups) > 0 {
+		filter.restrictions = append(filter.restrictions, groups)
+	}
+	if m != nil {
+		cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+		if cfg != nil {
+			if groups := modelGroupsFor(cfg.Routing.ModelGroups, model); len(groups) > 0 {
+				filter.restrictions = append(filter.restrictions, groups)
+			}
+		}
+	}
+	return filter
+}
+
+// allows reports whether auth satisfies every group restriction of the request.
+func (f *groupFilter) allows(auth *Auth) bool {
+	if f == nil {
+		return true
+	}
+	for _, groups := range f.restrictions {
+		if !auth.InGroup(groups...) {
+			return false
+		}
+	}
+	return true
+}
+
+func authGroupsFromMetadata(meta map[string]any) []string {
+	if len(meta) == 0 {
+		return nil
+	}
+	return ParseGroups(meta[cliproxyexecutor.AuthGroupsMetadataKey])
+}
+
+// modelGroupsFor returns the groups allowed to serve model. An exact entry wins; otherwise
+// the groups of every matching pattern are combined.
+func modelGroupsFor(rules map[string][]string, model string) []string {
+	if len(rules) == 0 {
+		return nil
+	}
+	model = strings.TrimSpace(model)
+	if parsed := thinking.ParseSuffix(model); parsed.ModelName != "" {
+		model = strings.TrimSpace(parsed.ModelName)
+	}
+	if model == "" {
+		return nil
+	}
+	if groups, ok := rules[model]; ok {
+		return ParseGroups(groups)
+	}
+	patterns := make([]string, 0, len(rules))
+	for pattern := range rules {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+	var combined []string
+	for _, pattern := range patterns {
+		if matched, errMatch := filepath.Match(strings.TrimSpace(pattern), model); errMatch == nil && matched {
+			combined = append(combined, rules[pattern]...)
+		}
+	}
+	return ParseGroups(combined)
+}
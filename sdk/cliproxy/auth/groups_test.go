@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestParseGroups(t *testing.T) {
+	cases := []struct {
+		value any
+		want  []string
+	}{
+		{value: "Work, personal,work", want: []string{"work", "personal"}},
+		{value: []any{"work", 3, " Team "}, want: []string{"work", "team"}},
+		{value: []string{"", " "}},
+		{value: nil},
+	}
+	for _, tc := range cases {
+		if got := ParseGroups(tc.value); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("ParseGroups(%#v) = %v, want %v", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestModelGroupsFor(t *testing.T) {
+	rules := map[string][]string{
+		"claude-opus-4":  {"vip"},
+		"claude-opus-*":  {"work"},
+		"claude-*":       {"personal"},
+		"gemini-2.5-pro": {"work"},
+	}
+	if got := modelGroupsFor(rules, "claude-opus-4"); !reflect.DeepEqual(got, []string{"vip"}) {
+		t.Fatalf("exact match = %v, want [vip]", got)
+	}
+	if got := modelGroupsFor(rules, "claude-opus-5(high)"); !reflect.DeepEqual(got, []string{"personal", "work"}) {
+		t.Fatalf("pattern match = %v, want [personal work]", got)
+	}
+	if got := modelGroupsFor(rules, "gpt-5"); got != nil {
+		t.Fatalf("unmatched model = %v, want nil", got)
+	}
+}
+
+func TestPickNextHonorsAuthGroups(t *testing.T) {
+	const model = "group-filter-model"
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.RegisterExecutor(conductorBenchmarkExecutor{schedulerBenchmarkExecutor{id: "gemini"}})
+	ctx := context.Background()
+	reg := registry.GetGlobalRegistry()
+	for id, groups := range map[string][]string{
+		"group-personal": {"personal"},
+		"group-work":     {"work"},
+		"group-none":     nil,
+	} {
+		if _, errRegister := manager.Register(ctx, &Auth{ID: id, Provider: "gemini", Groups: groups}); errRegister != nil {
+			t.Fatalf("Register(%s) error = %v", id, errRegister)
+		}
+		reg.RegisterClient(id, "gemini", []*registry.ModelInfo{{ID: model}})
+	}
+	t.Cleanup(func() {
+		for _, id := range []string{"group-personal", "group-work", "group-none"} {
+			reg.UnregisterClient(id)
+		}
+	})
+
+	pickAll := func(opts cliproxyexecutor.Options) map[string]bool {
+		seen := map[string]bool{}
+		for i := 0; i < 6; i++ {
+			auth, _, errPick := manager.pickNext(ctx, "gemini", model, opts, map[string]struct{}{})
+			if errPick != nil {
+				t.Fatalf("pickNext error = %v", errPick)
+			}
+			seen[auth.ID] = true
+		}
+		return seen
+	}
+
+	requested := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.AuthGroupsMetadataKey: []string{"work"}}}
+	if seen := pickAll(requested); len(seen) != 1 || !seen["group-work"] {
+		t.Fatalf("picked %v for a work-only request, want only group-work", seen)
+	}
+
+	manager.SetConfig(&internalconfig.Config{Routing: internalconfig.RoutingConfig{ModelGroups: map[string][]string{"group-*": {"personal"}}}})
+	if seen := pickAll(cliproxyexecutor.Options{}); len(seen) != 1 || !seen["group-personal"] {
+		t.Fatalf("picked %v under model-groups, want only group-personal", seen)
+	}
+	if _, _, errPick := manager.pickNext(ctx, "gemini", model, requested, map[string]struct{}{}); errPick == nil {
+		t.Fatal("expected no auth when the request and model-groups rule do not overlap")
+	}
+
+	if got := manager.ListByGroup("WORK"); len(got) != 1 || got[0].ID != "group-work" {
+		t.Fatalf("ListByGroup(work) = %v", got)
+	}
+}
@@ -0,0 +1,281 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultHealthProbeInterval is how often blocked auths are scanned when unset.
+	DefaultHealthProbeInterval = 30 * time.Second
+	// DefaultHealthProbeLead probes auths whose cooldown ends within this window when unset.
+	DefaultHealthProbeLead = time.Minute
+	// DefaultHealthProbeTimeout bounds a single probe when unset.
+	DefaultHealthProbeTimeout = 15 * time.Second
+	// DefaultHealthProbeExtend is the cooldown extension applied after a failed probe when unset.
+	DefaultHealthProbeExtend = 5 * time.Minute
+)
+
+// ErrHealthProbeUnsupported is returned by a HealthProber that cannot probe an auth.
+// Unsupported probes leave the cooldown untouched.
+var ErrHealthProbeUnsupported = errors.New("health probe not supported")
+
+// HealthProber is implemented by executors that can ping their provider upstream.
+// Executors without it are never probed: CountTokens is not used as a fallback because
+// many executors count tokens locally, which would report every auth as recovered.
+type HealthProber interface {
+	ProbeHealth(ctx context.Context, auth *Auth, model string) error
+}
+
+// HealthProbeOptions configures the background prober for blocked auths.
+type HealthProbeOptions struct {
+	// Interval is the scan period (<= 0 uses DefaultHealthProbeInterval).
+	Interval time.Duration
+	// Lead selects auths whose NextRetryAfter falls within this window (<= 0 uses DefaultHealthProbeLead).
+	Lead time.Duration
+	// Timeout bounds a single probe (<= 0 uses DefaultHealthProbeTimeout).
+	Timeout time.Duration
+	// Extend pushes the cooldown out by this much when a probe fails (<= 0 uses DefaultHealthProbeExtend).
+	Extend time.Duration
+	// Providers limits probing to these providers; empty probes every provider.
+	Providers []string
+}
+
+func (o HealthProbeOptions) withDefaults() HealthProbeOptions {
+	if o.Interval <= 0 {
+		o.Interval = DefaultHealthProbeInterval
+	}
+	if o.Lead <= 0 {
+		o.Lead = DefaultHealthProbeLead
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultHealthProbeTimeout
+	}
+	if o.Extend <= 0 {
+		o.Extend = DefaultHealthProbeExtend
+	}
+	return o
+}
+
+// healthProbeTarget is one blocked auth/model pair due for a probe.
+type healthProbeTarget struct {
+	authID   string
+	provider string
+	model    string
+	// authLevel marks a target whose cooldown is tracked on the auth rather than a model state.
+	authLevel bool
+}
+
+// StartHealthProbe starts (or restarts with new options) the loop that probes blocked
+// auths shortly before their cooldown ends. A successful probe clears the cooldown; a
+// failed probe extends it so real traffic is not routed to an auth that is still failing.
+// Probes run through the background lane.
+func (m *Manager) StartHealthProbe(parent context.Context, opts HealthProbeOptions) {
+	if m == nil {
+		return
+	}
+	if parent == nil {
+		parent = context.Background()
+	}
+	opts = opts.withDefaults()
+	m.StopHealthProbe()
+
+	ctx, cancel := context.WithCancel(parent)
+	done := make(chan struct{})
+	m.healthProbeMu.Lock()
+	m.healthProbeCancel = cancel
+	m.healthProbeDone = done
+	m.healthProbeMu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(opts.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				m.runHealthProbeRound(ctx, opts)
+			}
+		}
+	}()
+}
+
+// StopHealthProbe stops the health probe loop and waits for in-flight probes to finish.
+func (m *Manager) StopHealthProbe() {
+	if m == nil {
+		return
+	}
+	m.healthProbeMu.Lock()
+	cancel, done := m.healthProbeCancel, m.healthProbeDone
+	m.healthProbeCancel, m.healthProbeDone = nil, nil
+	m.healthProbeMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if done != nil {
+		<-done
+	}
+}
+
+func (m *Manager) runHealthProbeRound(ctx context.Context, opts HealthProbeOptions) {
+	targets := m.healthProbeTargets(time.Now(), opts)
+	var wg sync.WaitGroup
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target healthProbeTarget) {
+			defer wg.Done()
+			m.probeTarget(ctx, target, opts)
+		}(target)
+	}
+	wg.Wait()
+}
+
+// healthProbeTargets lists blocked auth/model pairs whose cooldown ends within opts.Lead.
+func (m *Manager) healthProbeTargets(now time.Time, opts HealthProbeOptions) []healthProbeTarget {
+	allowed := make(map[string]struct{}, len(opts.Providers))
+	for _, provider := range opts.Providers {
+		if key := strings.ToLower(strings.TrimSpace(provider)); key != "" {
+			allowed[key] = struct{}{}
+		}
+	}
+	due := func(until time.Time) bool {
+		return !until.IsZero() && until.After(now) && until.Sub(now) <= opts.Lead
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	targets := make([]healthProbeTarget, 0)
+	for _, auth := range m.auths {
+		if auth == nil || auth.Disabled || auth.Status == StatusDisabled {
+			continue
+		}
+		provider := executorKeyFromAuth(auth)
+		if len(allowed) > 0 {
+			if _, ok := allowed[strings.ToLower(provider)]; !ok {
+				continue
+			}
+		}
+		if m.executors[provider] == nil {
+			continue
+		}
+		probedModel := false
+		for model, state := range auth.ModelStates {
+			if state == nil || !state.Unavailable || !due(state.NextRetryAfter) || isUnauthorizedModelState(state) {
+				continue
+			}
+			targets = append(targets, healthProbeTarget{authID: auth.ID, provider: provider, model: model})
+			probedModel = true
+		}
+		if !probedModel && len(auth.ModelStates) == 0 && auth.Unavailable && due(auth.NextRetryAfter) {
+			if models := modelsForRegisteredAuth(auth.ID); len(models) > 0 {
+				targets = append(targets, healthProbeTarget{authID: auth.ID, provider: provider, model: models[0], authLevel: true})
+			}
+		}
+	}
+	return targets
+}
+
+func isUnauthorizedModelState(state *ModelState) bool {
+	return state != nil && state.LastError != nil && state.LastError.HTTPStatus == 401
+}
+
+func (m *Manager) probeTarget(ctx context.Context, target healthProbeTarget, opts HealthProbeOptions) {
+	auth, ok := m.GetByID(target.authID)
+	if !ok || auth == nil {
+		return
+	}
+	exec := m.executorFor(target.provider)
+	if exec == nil {
+		return
+	}
+	errProbe := m.RunBackground(ctx, BackgroundKindProbe, func(probeCtx context.Context) error {
+		probeCtx, cancel := context.WithTimeout(probeCtx, opts.Timeout)
+		defer cancel()
+		return runHealthProbe(probeCtx, exec, auth, target.model)
+	})
+	if ctx.Err() != nil {
+		return
+	}
+	switch {
+	case errProbe == nil:
+		clearModel := target.model
+		if target.authLevel {
+			clearModel = ""
+		}
+		if _, errClear := m.ClearCooldown(ctx, target.authID, clearModel); errClear != nil {
+			log.Debugf("health probe: clear cooldown for %s/%s: %v", target.authID, target.model, errClear)
+			return
+		}
+		log.Infof("health probe: %s (%s) recovered for model %s; cooldown cleared", target.authID, target.provider, target.model)
+	case errors.Is(errProbe, ErrHealthProbeUnsupported):
+		log.Debugf("health probe: %s (%s) cannot be probed: %v", target.authID, target.provider, errProbe)
+	default:
+		m.extendCooldown(ctx, target, opts.Extend, errProbe)
+		log.Infof("health probe: %s (%s) still failing for model %s: %v; cooldown extended by %s", target.authID, target.provider, target.model, errProbe, opts.Extend)
+	}
+}
+
+// runHealthProbe pings the provider with the executor's HealthProber. Executors without
+// one report ErrHealthProbeUnsupported.
+func runHealthProbe(ctx context.Context, exec ProviderExecutor, auth *Auth, model string) error {
+	prober, ok := exec.(HealthProber)
+	if !ok {
+		return ErrHealthProbeUnsupported
+	}
+	return prober.ProbeHealth(ctx, auth, model)
+}
+
+// extendCooldown pushes the cooldown of a still-failing target out by extend.
+func (m *Manager) extendCooldown(ctx context.Context, target healthProbeTarget, extend time.Duration, errProbe error) {
+	now := time.Now()
+	until := now.Add(extend)
+	probeErr := &Error{Code: "health_probe_failed", Message: errProbe.Error(), Retryable: true, HTTPStatus: statusCodeFromError(errProbe)}
+
+	m.mu.Lock()
+	auth := m.auths[target.authID]
+	if auth == nil {
+		m.mu.Unlock()
+		return
+	}
+	if target.authLevel {
+		if !auth.Unavailable {
+			m.mu.Unlock()
+			return
+		}
+		if auth.NextRetryAfter.Before(until) {
+			auth.NextRetryAfter = until
+		}
+		auth.LastError = probeErr
+	} else {
+		state := auth.ModelStates[target.model]
+		if state == nil || !state.Unavailable {
+			m.mu.Unlock()
+			return
+		}
+		if state.NextRetryAfter.Before(until) {
+			state.NextRetryAfter = until
+		}
+		if state.Quota.Exceeded && state.Quota.NextRecoverAt.Before(until) {
+			state.Quota.NextRecoverAt = until
+		}
+		state.LastError = probeErr
+		state.UpdatedAt = now
+		updateAggregatedAvailability(auth, now)
+	}
+	auth.UpdatedAt = now
+	_ = m.persist(ctx, auth)
+	snapshot := auth.Clone()
+	m.mu.Unlock()
+
+	if m.scheduler != nil {
+		m.scheduler.upsertAuth(snapshot)
+	}
+	m.persistCooldownStates(ctx)
+}
@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// healthProbingExecutor adds an upstream ping to a test executor.
+type healthProbingExecutor struct {
+	*providerFallbackExecutor
+	probeErr error
+}
+
+func (e *healthProbingExecutor) ProbeHealth(context.Context, *Auth, string) error {
+	return e.probeErr
+}
+
+func TestHealthProbeClearsRecoveredAndExtendsFailingCooldowns(t *testing.T) {
+	const model = "probe-model"
+	m, firstExec, secondExec := newProviderFallbackTestManager(t, model)
+	m.BackgroundLane().SetOptions(BackgroundLaneOptions{MinInterval: -1})
+	m.RegisterExecutor(&healthProbingExecutor{providerFallbackExecutor: firstExec})
+	m.RegisterExecutor(&healthProbingExecutor{
+		providerFallbackExecutor: secondExec,
+		probeErr:                 &Error{HTTPStatus: http.StatusTooManyRequests, Message: "still limited"},
+	})
+
+	soon := time.Now().Add(20 * time.Second)
+	later := time.Now().Add(time.Hour)
+	block := func(id string, until time.Time) {
+		auth, _ := m.GetByID(id)
+		auth.ModelStates = map[string]*ModelState{model: {Status: StatusError, Unavailable: true, NextRetryAfter: until}}
+		if _, errUpdate := m.Update(context.Background(), auth); errUpdate != nil {
+			t.Fatalf("Update(%s) error = %v", id, errUpdate)
+		}
+	}
+	firstID, secondID := t.Name()+"-first", t.Name()+"-second"
+	block(firstID, soon)
+	block(secondID, soon)
+
+	opts := HealthProbeOptions{Lead: time.Minute, Extend: 10 * time.Minute}.withDefaults()
+	m.runHealthProbeRound(context.Background(), opts)
+
+	first, _ := m.GetByID(firstID)
+	if state := first.ModelStates[model]; state.Unavailable || !state.NextRetryAfter.IsZero() {
+		t.Fatalf("recovered auth state = %+v, want cleared", state)
+	}
+	secondAuth, _ := m.GetByID(secondID)
+	state := secondAuth.ModelStates[model]
+	if !state.Unavailable || state.NextRetryAfter.Before(time.Now().Add(9*time.Minute)) {
+		t.Fatalf("failing auth state = %+v, want cooldown extended", state)
+	}
+	if state.LastError == nil || state.LastError.HTTPStatus != http.StatusTooManyRequests {
+		t.Fatalf("failing auth last error = %+v", state.LastError)
+	}
+
+	block(firstID, later)
+	if targets := m.healthProbeTargets(time.Now(), opts); len(targets) != 0 {
+		t.Fatalf("targets = %+v, want none outside the lead window", targets)
+	}
+}
+
+func TestHealthProbeLeavesCooldownOfExecutorsWithoutProber(t *testing.T) {
+	const model = "probe-model"
+	m, _, _ := newProviderFallbackTestManager(t, model)
+	m.BackgroundLane().SetOptions(BackgroundLaneOptions{MinInterval: -1})
+
+	id := t.Name() + "-first"
+	until := time.Now().Add(20 * time.Second)
+	auth, _ := m.GetByID(id)
+	auth.ModelStates = map[string]*ModelState{model: {Status: StatusError, Unavailable: true, NextRetryAfter: until}}
+	if _, errUpdate := m.Update(context.Background(), auth); errUpdate != nil {
+		t.Fatalf("Update error = %v", errUpdate)
+	}
+
+	m.runHealthProbeRound(context.Background(), HealthProbeOptions{Lead: time.Minute}.withDefaults())
+
+	auth, _ = m.GetByID(id)
+	if state := auth.ModelStates[model]; !state.Unavailable || !state.NextRetryAfter.Equal(until) {
+		t.Fatalf("state = %+v, want cooldown untouched", state)
+	}
+}
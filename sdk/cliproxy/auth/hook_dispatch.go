@@ -0,0 +1,252 @@
+package auth
+
+import (
+	"context"
+	"hash/fnv"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// HookErrorPolicy decides what happens when a Hook method panics. A panicking hook never
+// breaks request execution under any policy.
+type HookErrorPolicy string
+
+const (
+	// HookErrorPolicyLog recovers the panic, logs it with a stack trace and keeps calling the hook.
+	HookErrorPolicyLog HookErrorPolicy = "log"
+	// HookErrorPolicyIgnore recovers the panic silently.
+	HookErrorPolicyIgnore HookErrorPolicy = "ignore"
+	// HookErrorPolicyDisable recovers and logs the panic, then stops calling the hook.
+	HookErrorPolicyDisable HookErrorPolicy = "disable"
+)
+
+const (
+	// DefaultHookDispatchWorkers is the OnResult worker count when unset.
+	DefaultHookDispatchWorkers = 4
+	// DefaultHookDispatchQueueSize is the total OnResult queue capacity when unset.
+	DefaultHookDispatchQueueSize = 1024
+)
+
+// HookDispatchOptions configures asynchronous delivery of Hook.OnResult.
+type HookDispatchOptions struct {
+	// Workers is the number of delivery goroutines (<= 0 uses DefaultHookDispatchWorkers).
+	// Results for one auth always go to the same worker, so they are delivered in order.
+	Workers int
+	// QueueSize bounds the queued results across all workers (<= 0 uses
+	// DefaultHookDispatchQueueSize). When a worker's share is full the oldest result is dropped.
+	QueueSize int
+	// ErrorPolicy handles hook panics (empty uses HookErrorPolicyLog).
+	ErrorPolicy HookErrorPolicy
+}
+
+type hookResultEvent struct {
+	ctx    context.Context
+	result Result
+}
+
+// hookResultShard is a bounded FIFO with drop-oldest semantics served by one worker.
+type hookResultShard struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	ring   []hookResultEvent
+	head   int
+	size   int
+	closed bool
+}
+
+func newHookResultShard(capacity int) *hookResultShard {
+	shard := &hookResultShard{ring: make([]hookResultEvent, capacity)}
+	shard.cond = sync.NewCond(&shard.mu)
+	return shard
+}
+
+func (s *hookResultShard) push(event hookResultEvent) (dropped bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+	if s.size == len(s.ring) {
+		s.ring[s.head] = hookResultEvent{}
+		s.head = (s.head + 1) % len(s.ring)
+		s.size--
+		dropped = true
+	}
+	s.ring[(s.head+s.size)%len(s.ring)] = event
+	s.size++
+	s.cond.Signal()
+	return dropped
+}
+
+// pop blocks until an event is available. It returns false once the shard is closed and drained.
+func (s *hookResultShard) pop() (hookResultEvent, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.size == 0 && !s.closed {
+		s.cond.Wait()
+	}
+	if s.size == 0 {
+		return hookResultEvent{}, false
+	}
+	event := s.ring[s.head]
+	s.ring[s.head] = hookResultEvent{}
+	s.head = (s.head + 1) % len(s.ring)
+	s.size--
+	return event, true
+}
+
+func (s *hookResultShard) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.cond.Broadcast()
+	s.mu.Unlock()
+}
+
+// hookDispatcher delivers OnResult calls off the request path.
+type hookDispatcher struct {
+	shards  []*hookResultShard
+	wg      sync.WaitGroup
+	dropped atomic.Uint64
+}
+
+func newHookDispatcher(m *Manager, opts HookDispatchOptions) *hookDispatcher {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultHookDispatchWorkers
+	}
+	queueSize := opts.QueueSize
+	if queueSize <= 0 {
+		queueSize = DefaultHookDispatchQueueSize
+	}
+	perShard := max(queueSize/workers, 1)
+	d := &hookDispatcher{shards: make([]*hookResultShard, workers)}
+	for i := range d.shards {
+		shard := newHookResultShard(perShard)
+		d.shards[i] = shard
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			for {
+				event, ok := shard.pop()
+				if !ok {
+					return
+				}
+				m.callHook("OnResult", func() { m.hook.OnResult(event.ctx, event.result) })
+			}
+		}()
+	}
+	return d
+}
+
+func (d *hookDispatcher) enqueue(ctx context.Context, result Result) {
+	hasher := fnv.New32a()
+	_, _ = hasher.Write([]byte(result.AuthID))
+	shard := d.shards[int(hasher.Sum32()%uint32(len(d.shards)))]
+	if shard.push(hookResultEvent{ctx: ctx, result: result}) {
+		if dropped := d.dropped.Add(1); dropped == 1 || dropped%1000 == 0 {
+			log.Warnf("hook dispatch: queue full, dropped %d OnResult events so far", dropped)
+		}
+	}
+}
+
+// stop closes the queues and waits for queued events to be delivered.
+func (d *hookDispatcher) stop() {
+	for _, shard := range d.shards {
+		shard.close()
+	}
+	d.wg.Wait()
+}
+
+// StartHookDispatcher delivers Hook.OnResult asynchronously on a bounded worker pool so a
+// slow hook cannot slow the request path. Calling it again replaces the pool after the
+// previous one drained. Without a dispatcher OnResult runs inline.
+func (m *Manager) StartHookDispatcher(opts HookDispatchOptions) {
+	if m == nil {
+		return
+	}
+	m.SetHookErrorPolicy(opts.ErrorPolicy)
+	next := newHookDispatcher(m, opts)
+	if previous := m.hookDispatcher.Swap(next); previous != nil {
+		previous.stop()
+	}
+}
+
+// StopHookDispatcher delivers queued results and returns to inline OnResult calls.
+func (m *Manager) StopHookDispatcher() {
+	if m == nil {
+		return
+	}
+	if previous := m.hookDispatcher.Swap(nil); previous != nil {
+		previous.stop()
+	}
+}
+
+// HookDispatchDropped returns how many OnResult events were dropped because the
+// dispatcher queue was full.
+func (m *Manager) HookDispatchDropped() uint64 {
+	if m == nil {
+		return 0
+	}
+	if d := m.hookDispatcher.Load(); d != nil {
+		return d.dropped.Load()
+	}
+	return 0
+}
+
+// SetHookErrorPolicy sets how hook panics are handled (empty uses HookErrorPolicyLog) and
+// re-enables a hook disabled by HookErrorPolicyDisable.
+func (m *Manager) SetHookErrorPolicy(policy HookErrorPolicy) {
+	if m == nil {
+		return
+	}
+	switch policy {
+	case HookErrorPolicyLog, HookErrorPolicyIgnore, HookErrorPolicyDisable:
+	case "":
+		policy = HookErrorPolicyLog
+	default:
+		log.Warnf("hook dispatch: unknown error policy %q, using %s", policy, HookErrorPolicyLog)
+		policy = HookErrorPolicyLog
+	}
+	m.hookErrorPolicy.Store(policy)
+	m.hookDisabled.Store(false)
+}
+
+func (m *Manager) dispatchResultHook(ctx context.Context, result Result) {
+	if m.hookDisabled.Load() {
+		return
+	}
+	if d := m.hookDispatcher.Load(); d != nil {
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		d.enqueue(context.WithoutCancel(ctx), result)
+		return
+	}
+	m.callHook("OnResult", func() { m.hook.OnResult(ctx, result) })
+}
+
+// callHook runs one Hook method, applying the error policy to a panic.
+func (m *Manager) callHook(method string, call func()) {
+	if m.hookDisabled.Load() {
+		return
+	}
+	defer func() {
+		recovered := recover()
+		if recovered == nil {
+			return
+		}
+		policy, _ := m.hookErrorPolicy.Load().(HookErrorPolicy)
+		switch policy {
+		case HookErrorPolicyIgnore:
+		case HookErrorPolicyDisable:
+			m.hookDisabled.Store(true)
+			log.Errorf("hook %s panicked, disabling hook: %v\n%s", method, recovered, debug.Stack())
+		default:
+			log.Errorf("hook %s panicked: %v\n%s", method, recovered, debug.Stack())
+		}
+	}()
+	call()
+}
@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+type blockingResultHook struct {
+	resultCaptureHook
+	release chan struct{}
+}
+
+func (h *blockingResultHook) OnResult(ctx context.Context, result Result) {
+	<-h.release
+	h.resultCaptureHook.OnResult(ctx, result)
+}
+
+type panickingHook struct {
+	NoopHook
+	calls int
+}
+
+func (h *panickingHook) OnResult(context.Context, Result) {
+	h.calls++
+	panic("hook failure")
+}
+
+func TestHookDispatcherKeepsSlowHookOffRequestPath(t *testing.T) {
+	hook := &blockingResultHook{release: make(chan struct{})}
+	manager := NewManager(nil, nil, hook)
+	manager.StartHookDispatcher(HookDispatchOptions{Workers: 1, QueueSize: 2})
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			manager.MarkResult(context.Background(), Result{AuthID: "slow", Model: fmt.Sprintf("m%d", i), Success: true})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("MarkResult blocked on a slow hook")
+	}
+
+	close(hook.release)
+	dropped := manager.HookDispatchDropped()
+	manager.StopHookDispatcher()
+	results := hook.Results()
+	if uint64(len(results))+dropped != 5 {
+		t.Fatalf("results = %d, dropped = %d, want 5 in total", len(results), dropped)
+	}
+	if dropped == 0 {
+		t.Fatal("queue of 2 behind a blocked hook dropped nothing")
+	}
+	if len(results) == 0 || results[len(results)-1].Model != "m4" {
+		t.Fatalf("results = %+v, want the newest result kept", results)
+	}
+	for i := 1; i < len(results); i++ {
+		if results[i-1].Model >= results[i].Model {
+			t.Fatalf("results delivered out of order: %+v", results)
+		}
+	}
+}
+
+func TestHookResultShardDropsOldest(t *testing.T) {
+	shard := newHookResultShard(2)
+	for i := 0; i < 3; i++ {
+		shard.push(hookResultEvent{result: Result{Model: fmt.Sprintf("m%d", i)}})
+	}
+	shard.close()
+	var got []string
+	for {
+		event, ok := shard.pop()
+		if !ok {
+			break
+		}
+		got = append(got, event.result.Model)
+	}
+	if fmt.Sprint(got) != "[m1 m2]" {
+		t.Fatalf("drained = %v, want [m1 m2]", got)
+	}
+}
+
+func TestHookErrorPolicyDisableStopsCallingPanickingHook(t *testing.T) {
+	hook := &panickingHook{}
+	manager := NewManager(nil, nil, hook)
+	manager.SetHookErrorPolicy(HookErrorPolicyDisable)
+
+	manager.MarkResult(context.Background(), Result{AuthID: "a", Model: "m", Success: true})
+	manager.MarkResult(context.Background(), Result{AuthID: "a", Model: "m", Success: true})
+	if hook.calls != 1 {
+		t.Fatalf("hook calls = %d, want 1 before being disabled", hook.calls)
+	}
+
+	manager.SetHookErrorPolicy(HookErrorPolicyIgnore)
+	manager.MarkResult(context.Background(), Result{AuthID: "a", Model: "m", Success: true})
+	manager.MarkResult(context.Background(), Result{AuthID: "a", Model: "m", Success: true})
+	if hook.calls != 3 {
+		t.Fatalf("hook calls = %d, want 3 with the ignore policy", hook.calls)
+	}
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+)
+
+// ErrAuthNotFound is returned by lifecycle operations targeting an unknown auth ID.
+var ErrAuthNotFound = errors.New("auth not found")
+
+// ClearCooldown lifts cooldown and quota state for an auth. When model is empty every
+// model state is reset (see ResetQuota); otherwise only that model's state is cleared
+// and the auth-level availability is recomputed from the remaining models.
+func (m *Manager) ClearCooldown(ctx context.Context, id, model string) (*Auth, error) {
+	if m == nil {
+		return nil, ErrAuthNotFound
+	}
+	id = strings.TrimSpace(id)
+	model = strings.TrimSpace(model)
+	if model == "" {
+		updated, _, errReset := m.ResetQuota(ctx, id)
+		if errReset != nil {
+			return nil, errReset
+		}
+		if updated == nil {
+			return nil, ErrAuthNotFound
+		}
+		return updated, nil
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	auth, ok := m.auths[id]
+	if !ok || auth == nil {
+		m.mu.Unlock()
+		return nil, ErrAuthNotFound
+	}
+	var cooldownRecordsBefore []CooldownStateRecord
+	trackCooldownState := m.cooldownStore != nil
+	if trackCooldownState {
+		cooldownRecordsBefore = m.cooldownStateRecordsForAuthLocked(auth, now)
+	}
+	if state := auth.ModelStates[model]; state != nil {
+		resetModelState(state, now)
+	}
+	updateAggregatedAvailability(auth, now)
+	if !auth.Disabled && auth.Status != StatusDisabled && !hasModelError(auth, now) {
+		auth.LastError = nil
+		auth.StatusMessage = ""
+		auth.Status = StatusActive
+	}
+	auth.UpdatedAt = now
+	if errPersist := m.persist(ctx, auth); errPersist != nil {
+		m.mu.Unlock()
+		return nil, errPersist
+	}
+	snapshot := auth.Clone()
+	cooldownStateChanged := false
+	if trackCooldownState {
+		cooldownStateChanged = !cooldownStateRecordsEqual(cooldownRecordsBefore, m.cooldownStateRecordsForAuthLocked(auth, now))
+	}
+	m.mu.Unlock()
+
+	registry.GetGlobalRegistry().ClearModelQuotaExceeded(id, model)
+	registry.GetGlobalRegistry().ResumeClientModel(id, model)
+	if m.scheduler != nil {
+		m.scheduler.upsertAuth(snapshot)
+	}
+	if cooldownStateChanged {
+		m.persistCooldownStates(ctx)
+	}
+	return snapshot, nil
+}
+
+// ForceRefresh refreshes an auth's credentials immediately, regardless of its refresh
+// schedule, and returns the updated auth.
+func (m *Manager) ForceRefresh(ctx context.Context, id string) (*Auth, error) {
+	if m == nil {
+		return nil, ErrAuthNotFound
+	}
+	id = strings.TrimSpace(id)
+	if _, ok := m.GetByID(id); !ok {
+		return nil, ErrAuthNotFound
+	}
+	return m.refreshAuthForRequest(ctx, id, "")
+}
+
+// Bench keeps an auth out of selection until until, e.g. once it reached a daily cap.
+// The bench lifts on its own at until; Unbench lifts it earlier.
+func (m *Manager) Bench(_ context.Context, id string, until time.Time, reason string) (*Auth, error) {
+	return m.setBench(id, until, strings.TrimSpace(reason))
+}
+
+// Unbench lifts the bench set by Bench.
+func (m *Manager) Unbench(_ context.Context, id string) (*Auth, error) {
+	return m.setBench(id, time.Time{}, "")
+}
+
+func (m *Manager) setBench(id string, until time.Time, reason string) (*Auth, error) {
+	if m == nil {
+		return nil, ErrAuthNotFound
+	}
+	id = strings.TrimSpace(id)
+	m.mu.Lock()
+	auth, ok := m.auths[id]
+	if !ok || auth == nil {
+		m.mu.Unlock()
+		return nil, ErrAuthNotFound
+	}
+	auth.BenchedUntil = until
+	auth.BenchReason = reason
+	auth.UpdatedAt = time.Now()
+	snapshot := auth.Clone()
+	m.mu.Unlock()
+
+	if m.scheduler != nil {
+		m.scheduler.upsertAuth(snapshot)
+	}
+	return snapshot, nil
+}
+
+// Disable marks an auth disabled so it is no longer selected. reason is stored as the
+// status message; an empty reason uses a generic message.
+func (m *Manager) Disable(ctx context.Context, id, reason string) (*Auth, error) {
+	return m.setDisabled(ctx, id, true, reason)
+}
+
+// Enable clears the disabled flag set by Disable.
+func (m *Manager) Enable(ctx context.Context, id string) (*Auth, error) {
+	return m.setDisabled(ctx, id, false, "")
+}
+
+func (m *Manager) setDisabled(ctx context.Context, id string, disabled bool, reason string) (*Auth, error) {
+	if m == nil {
+		return nil, ErrAuthNotFound
+	}
+	auth, ok := m.GetByID(strings.TrimSpace(id))
+	if !ok || auth == nil {
+		return nil, ErrAuthNotFound
+	}
+	auth.Disabled = disabled
+	if disabled {
+		auth.Status = StatusDisabled
+		auth.StatusMessage = strings.TrimSpace(reason)
+		if auth.StatusMessage == "" {
+			auth.StatusMessage = "disabled"
+		}
+	} else {
+		restoreEnabledStatus(auth, time.Now())
+	}
+	auth.UpdatedAt = time.Now()
+	if auth.Metadata == nil {
+		auth.Metadata = make(map[string]any)
+	}
+	auth.Metadata["disabled"] = disabled
+	updated, errUpdate := m.Update(ctx, auth)
+	if errUpdate != nil {
+		return nil, errUpdate
+	}
+	if updated == nil {
+		return nil, ErrAuthNotFound
+	}
+	return updated, nil
+}
+
+// restoreEnabledStatus re-derives the status of a re-enabled auth from the cooldown and
+// quota state it kept while disabled.
+func restoreEnabledStatus(auth *Auth, now time.Time) {
+	updateAggregatedAvailability(auth, now)
+	cooling := auth.Unavailable && auth.NextRetryAfter.After(now)
+	quotaExceeded := auth.Quota.Exceeded && (auth.Quota.NextRecoverAt.IsZero() || auth.Quota.NextRecoverAt.After(now))
+	if !cooling && !quotaExceeded && !hasModelError(auth, now) {
+		auth.Status = StatusActive
+		auth.StatusMessage = ""
+		return
+	}
+	auth.Status = StatusError
+	switch {
+	case auth.LastError != nil && auth.LastError.Message != "":
+		auth.StatusMessage = auth.LastError.Message
+	case auth.Quota.Reason != "":
+		auth.StatusMessage = auth.Quota.Reason
+	default:
+		auth.StatusMessage = ""
+	}
+}
@@ -0,0 +1,118 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestManagerClearCooldownSingleModel(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	next := time.Now().Add(time.Hour)
+	blocked := func() *ModelState {
+		return &ModelState{Status: StatusError, Unavailable: true, NextRetryAfter: next, Quota: QuotaState{Exceeded: true, NextRecoverAt: next}}
+	}
+	auth := &Auth{ID: "cooldown-auth", Provider: "claude", ModelStates: map[string]*ModelState{"m1": blocked(), "m2": blocked()}}
+	if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("Register() error = %v", errRegister)
+	}
+
+	updated, errClear := manager.ClearCooldown(context.Background(), "cooldown-auth", "m1")
+	if errClear != nil {
+		t.Fatalf("ClearCooldown() error = %v", errClear)
+	}
+	if state := updated.ModelStates["m1"]; state.Unavailable || !state.NextRetryAfter.IsZero() || state.Quota.Exceeded {
+		t.Fatalf("m1 state = %+v, want cleared", state)
+	}
+	if state := updated.ModelStates["m2"]; !state.Unavailable {
+		t.Fatalf("m2 state = %+v, want still blocked", state)
+	}
+
+	if _, errClear = manager.ClearCooldown(context.Background(), "missing", ""); !errors.Is(errClear, ErrAuthNotFound) {
+		t.Fatalf("ClearCooldown(missing) error = %v, want ErrAuthNotFound", errClear)
+	}
+}
+
+func TestManagerDisableEnable(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	if _, errRegister := manager.Register(context.Background(), &Auth{ID: "toggle-auth", Provider: "claude", Status: StatusActive}); errRegister != nil {
+		t.Fatalf("Register() error = %v", errRegister)
+	}
+
+	disabled, errDisable := manager.Disable(context.Background(), "toggle-auth", "rotated key")
+	if errDisable != nil {
+		t.Fatalf("Disable() error = %v", errDisable)
+	}
+	if !disabled.Disabled || disabled.Status != StatusDisabled || disabled.StatusMessage != "rotated key" || disabled.Metadata["disabled"] != true {
+		t.Fatalf("disabled auth = %+v", disabled)
+	}
+
+	enabled, errEnable := manager.Enable(context.Background(), "toggle-auth")
+	if errEnable != nil {
+		t.Fatalf("Enable() error = %v", errEnable)
+	}
+	if enabled.Disabled || enabled.Status != StatusActive || enabled.StatusMessage != "" {
+		t.Fatalf("enabled auth = %+v", enabled)
+	}
+
+	next := time.Now().Add(time.Hour)
+	cooling := &Auth{
+		ID:       "cooling-auth",
+		Provider: "claude",
+		ModelStates: map[string]*ModelState{
+			"m1": {Status: StatusError, Unavailable: true, NextRetryAfter: next, Quota: QuotaState{Exceeded: true, Reason: "quota", NextRecoverAt: next}},
+		},
+	}
+	if _, errRegister := manager.Register(context.Background(), cooling); errRegister != nil {
+		t.Fatalf("Register() error = %v", errRegister)
+	}
+	if _, errDisable = manager.Disable(context.Background(), "cooling-auth", ""); errDisable != nil {
+		t.Fatalf("Disable() error = %v", errDisable)
+	}
+	reenabled, errEnable := manager.Enable(context.Background(), "cooling-auth")
+	if errEnable != nil {
+		t.Fatalf("Enable() error = %v", errEnable)
+	}
+	if reenabled.Status != StatusError || !reenabled.Unavailable || !reenabled.Quota.Exceeded || reenabled.ModelStates["m1"].NextRetryAfter.IsZero() {
+		t.Fatalf("re-enabled auth = %+v, want its cooldown and quota state kept", reenabled)
+	}
+
+	if _, errForce := manager.ForceRefresh(context.Background(), "missing"); !errors.Is(errForce, ErrAuthNotFound) {
+		t.Fatalf("ForceRefresh(missing) error = %v, want ErrAuthNotFound", errForce)
+	}
+}
+
+func TestManagerBenchBlocksEveryModelUntilLifted(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	if _, errRegister := manager.Register(context.Background(), &Auth{ID: "bench-auth", Provider: "claude", Status: StatusActive}); errRegister != nil {
+		t.Fatalf("Register() error = %v", errRegister)
+	}
+
+	until := time.Now().Add(time.Hour)
+	benched, errBench := manager.Bench(context.Background(), "bench-auth", until, "daily cap reached")
+	if errBench != nil {
+		t.Fatalf("Bench() error = %v", errBench)
+	}
+	blocked, reason, next := isAuthBlockedForModel(benched, "claude-sonnet", time.Now())
+	if !blocked || reason != blockReasonCooldown || !next.Equal(until) {
+		t.Fatalf("isAuthBlockedForModel = %v, %v, %v; want cooldown until %v", blocked, reason, next, until)
+	}
+
+	reloaded := benched.Clone()
+	reloaded.BenchedUntil, reloaded.BenchReason = time.Time{}, ""
+	if updated, _ := manager.Update(context.Background(), reloaded); !updated.BenchedUntil.Equal(until) {
+		t.Fatalf("Update() dropped the bench: %+v", updated)
+	}
+
+	unbenched, errUnbench := manager.Unbench(context.Background(), "bench-auth")
+	if errUnbench != nil {
+		t.Fatalf("Unbench() error = %v", errUnbench)
+	}
+	if blocked, _, _ = isAuthBlockedForModel(unbenched, "claude-sonnet", time.Now()); blocked {
+		t.Fatal("auth still blocked after Unbench")
+	}
+	if _, errBench = manager.Bench(context.Background(), "missing", until, ""); !errors.Is(errBench, ErrAuthNotFound) {
+		t.Fatalf("Bench(missing) error = %v, want ErrAuthNotFound", errBench)
+	}
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxModelAttemptMessageLength bounds the provider message kept per failed model.
+const maxModelAttemptMessageLength = 256
+
+// ModelAttempt describes the failure of one upstream model tried for an alias.
+type ModelAttempt struct {
+	// Model is the upstream model that was tried.
+	Model string `json:"model"`
+	// StatusCode is the upstream HTTP status, when known.
+	StatusCode int `json:"status_code,omitempty"`
+	// RetryAfterSeconds is the upstream retry hint rounded up to whole seconds.
+	RetryAfterSeconds int64 `json:"retry_after_seconds,omitempty"`
+	// Message is the provider message, truncated to 256 bytes.
+	Message string `json:"message,omitempty"`
+}
+
+// ModelAttemptsError reports that every upstream model of an alias failed. It wraps the
+// last failure, so status codes, headers and messages keep their previous behavior, and
+// adds the per-model detail in Attempts.
+type ModelAttemptsError struct {
+	Attempts []ModelAttempt
+	cause    error
+}
+
+// Error returns the message of the last failure.
+func (e *ModelAttemptsError) Error() string {
+	if e == nil || e.cause == nil {
+		return ""
+	}
+	return e.cause.Error()
+}
+
+// Unwrap returns the last failure.
+func (e *ModelAttemptsError) Unwrap() error {
+	if e == nil {
+		return nil
+	}
+	return e.cause
+}
+
+// StatusCode returns the status of the last failure.
+func (e *ModelAttemptsError) StatusCode() int {
+	if e == nil {
+		return 0
+	}
+	return statusCodeFromError(e.cause)
+}
+
+// Headers returns the upstream headers of the last failure, if any.
+func (e *ModelAttemptsError) Headers() http.Header {
+	if e == nil {
+		return nil
+	}
+	var withHeaders interface{ Headers() http.Header }
+	if errors.As(e.cause, &withHeaders) && withHeaders != nil {
+		return withHeaders.Headers()
+	}
+	return nil
+}
+
+// ModelAttemptsFromError returns the per-model failures carried by err, or nil.
+func ModelAttemptsFromError(err error) []ModelAttempt {
+	var attemptsErr *ModelAttemptsError
+	if !errors.As(err, &attemptsErr) || attemptsErr == nil {
+		return nil
+	}
+	return attemptsErr.Attempts
+}
+
+// modelAttempts collects failures while the upstream models of an alias are tried in turn.
+type modelAttempts []ModelAttempt
+
+func (a *modelAttempts) add(model string, err error) {
+	if a == nil || err == nil {
+		return
+	}
+	attempt := ModelAttempt{Model: strings.TrimSpace(model), StatusCode: statusCodeFromError(err)}
+	if retryAfter := retryAfterFromError(err); retryAfter != nil && *retryAfter > 0 {
+		seconds := int64(*retryAfter / time.Second)
+		if *retryAfter%time.Second != 0 {
+			seconds++
+		}
+		attempt.RetryAfterSeconds = seconds
+	}
+	if rerr := resultErrorFromError(err); rerr != nil {
+		attempt.Message = truncateAttemptMessage(strings.TrimSpace(rerr.Message))
+	}
+	*a = append(*a, attempt)
+}
+
+// wrap returns err annotated with the collected failures. A single attempt adds nothing
+// over err itself, so it is returned unchanged.
+func (a modelAttempts) wrap(err error) error {
+	if err == nil || len(a) < 2 {
+		return err
+	}
+	attempts := make([]ModelAttempt, len(a))
+	copy(attempts, a)
+	return &ModelAttemptsError{Attempts: attempts, cause: err}
+}
+
+func truncateAttemptMessage(message string) string {
+	if len(message) <= maxModelAttemptMessageLength {
+		return message
+	}
+	cut := maxModelAttemptMessageLength
+	for cut > 0 && !utf8.RuneStart(message[cut]) {
+		cut--
+	}
+	return message[:cut] + "..."
+}
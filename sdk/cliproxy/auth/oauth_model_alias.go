@@ -94,6 +94,8 @@ func (m *Manager) SetOAuthModelAlias(aliases map[string][]internalconfig.OAuthMo
 		table = &oauthModelAliasTable{}
 	}
 	m.oauthModelAlias.Store(table)
+	// Route-aware indexes in the auth snapshot are derived from the alias table.
+	m.authGeneration.Add(1)
 }
 
 // applyOAuthModelAlias resolves the upstream model from OAuth model alias.
@@ -960,3 +960,40 @@ func TestManagerExecuteStream_OpenAICompatAliasPoolStopsOnInvalidBootstrap(t *te
 		t.Fatalf("stream calls = %v, want only first upstream model", got)
 	}
 }
+
+func TestManagerExecute_OpenAICompatAliasPoolReportsPerModelFailures(t *testing.T) {
+	alias := "claude-opus-4.66"
+	executor := &openAICompatPoolExecutor{
+		id: openAICompatPoolProviderKey,
+		executeErrors: map[string]error{
+			"deepseek-v3.1": &Error{HTTPStatus: http.StatusBadGateway, Message: strings.Repeat("x", 300)},
+			"glm-5":         &Error{HTTPStatus: http.StatusInternalServerError, Message: "upstream exploded"},
+		},
+	}
+	m := newOpenAICompatPoolTestManager(t, alias, []internalconfig.OpenAICompatibilityModel{
+		{Name: "deepseek-v3.1", Alias: alias},
+		{Name: "glm-5", Alias: alias},
+	}, executor)
+
+	_, err := m.Execute(context.Background(), []string{openAICompatPoolProviderKey}, cliproxyexecutor.Request{Model: alias}, cliproxyexecutor.Options{})
+	if err == nil {
+		t.Fatal("execute error = nil, want failure")
+	}
+	attempts := ModelAttemptsFromError(err)
+	if len(attempts) != 2 {
+		t.Fatalf("attempts = %+v, want 2", attempts)
+	}
+	byModel := make(map[string]ModelAttempt, len(attempts))
+	for _, attempt := range attempts {
+		byModel[attempt.Model] = attempt
+	}
+	if got := byModel["deepseek-v3.1"]; got.StatusCode != http.StatusBadGateway || len(got.Message) != maxModelAttemptMessageLength+len("...") {
+		t.Fatalf("deepseek attempt = %+v", got)
+	}
+	if got := byModel["glm-5"]; got.StatusCode != http.StatusInternalServerError || got.Message != "upstream exploded" {
+		t.Fatalf("glm attempt = %+v", got)
+	}
+	if status := statusCodeFromError(err); status != attempts[1].StatusCode {
+		t.Fatalf("status = %d, want last attempt status %d", status, attempts[1].StatusCode)
+	}
+}
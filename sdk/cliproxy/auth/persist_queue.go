@@ -0,0 +1,174 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultPersistFlushInterval is how often queued auth writes are flushed when unset.
+const DefaultPersistFlushInterval = time.Second
+
+// PersistQueueOptions configures write-behind persistence of request results.
+type PersistQueueOptions struct {
+	// FlushInterval is how often queued writes are flushed (<= 0 uses DefaultPersistFlushInterval).
+	FlushInterval time.Duration
+}
+
+// authPersistQueue records which auths have unsaved request-result state. It stores IDs
+// only: a flush saves the auth as it is at flush time, so any number of results between
+// flushes collapse into one write.
+//
+// Synchronous saves (token refreshes, Update, ...) bump the auth's sequence through
+// supersede. A flush skips an auth whose sequence moved since the flush started and holds
+// saveMu while writing, so a later synchronous save cannot be overwritten by an older copy.
+type authPersistQueue struct {
+	// mu guards pending; it is taken under m.mu and never held across I/O.
+	mu      sync.Mutex
+	pending map[string]struct{}
+	// saveMu guards seq and serializes queued writes with synchronous saves.
+	saveMu sync.Mutex
+	seq    map[string]uint64
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func (q *authPersistQueue) enqueue(authID string) {
+	q.mu.Lock()
+	q.pending[authID] = struct{}{}
+	q.mu.Unlock()
+}
+
+// supersede drops a queued write for authID because a synchronous save is about to
+// persist its current state. It waits for an in-flight queued write to finish.
+func (q *authPersistQueue) supersede(authID string) {
+	q.mu.Lock()
+	delete(q.pending, authID)
+	q.mu.Unlock()
+	q.saveMu.Lock()
+	q.seq[authID]++
+	q.saveMu.Unlock()
+}
+
+// StartPersistQueue switches request-result persistence to write-behind: MarkResult only
+// marks the auth dirty and a background loop saves dirty auths every FlushInterval.
+// Calling it again restarts the loop with the new options after flushing pending writes.
+func (m *Manager) StartPersistQueue(parent context.Context, opts PersistQueueOptions) {
+	if m == nil {
+		return
+	}
+	if parent == nil {
+		parent = context.Background()
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultPersistFlushInterval
+	}
+	m.StopPersistQueue()
+
+	ctx, cancel := context.WithCancel(parent)
+	queue := &authPersistQueue{
+		pending: make(map[string]struct{}),
+		seq:     make(map[string]uint64),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+	m.persistQueue.Store(queue)
+
+	go func() {
+		defer close(queue.done)
+		ticker := time.NewTicker(opts.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				m.flushPersistQueue(context.Background(), queue)
+				return
+			case <-ticker.C:
+				m.flushPersistQueue(ctx, queue)
+			}
+		}
+	}()
+}
+
+// StopPersistQueue flushes pending writes and returns to synchronous persistence.
+func (m *Manager) StopPersistQueue() {
+	if m == nil {
+		return
+	}
+	queue := m.persistQueue.Swap(nil)
+	if queue == nil {
+		return
+	}
+	queue.cancel()
+	<-queue.done
+}
+
+// FlushPersistQueue saves every auth with queued state now. It is a no-op when
+// write-behind persistence is off.
+func (m *Manager) FlushPersistQueue(ctx context.Context) {
+	if m == nil {
+		return
+	}
+	if queue := m.persistQueue.Load(); queue != nil {
+		m.flushPersistQueue(ctx, queue)
+	}
+}
+
+// persistResult saves auth state changed by a request result, or queues it when
+// write-behind persistence is on. Callers hold m.mu.
+func (m *Manager) persistResult(ctx context.Context, auth *Auth) {
+	queue := m.persistQueue.Load()
+	if queue == nil {
+		_ = m.persist(ctx, auth)
+		return
+	}
+	if m.shouldPersist(ctx, auth) {
+		queue.enqueue(auth.ID)
+	}
+}
+
+func (m *Manager) flushPersistQueue(ctx context.Context, queue *authPersistQueue) {
+	queue.mu.Lock()
+	if len(queue.pending) == 0 {
+		queue.mu.Unlock()
+		return
+	}
+	ids := make([]string, 0, len(queue.pending))
+	for id := range queue.pending {
+		ids = append(ids, id)
+	}
+	clear(queue.pending)
+	queue.mu.Unlock()
+	sort.Strings(ids)
+
+	queue.saveMu.Lock()
+	seqs := make(map[string]uint64, len(ids))
+	for _, id := range ids {
+		seqs[id] = queue.seq[id]
+	}
+	queue.saveMu.Unlock()
+
+	for _, id := range ids {
+		m.mu.RLock()
+		var auth *Auth
+		if current := m.auths[id]; current != nil {
+			auth = current.Clone()
+		}
+		m.mu.RUnlock()
+		if auth == nil {
+			continue
+		}
+
+		queue.saveMu.Lock()
+		if queue.seq[id] == seqs[id] {
+			if _, errSave := m.store.Save(ctx, auth); errSave != nil {
+				log.Warnf("auth persist queue: save %s failed: %v", id, errSave)
+				queue.enqueue(id)
+			}
+		}
+		queue.saveMu.Unlock()
+	}
+}
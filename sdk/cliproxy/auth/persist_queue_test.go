@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPersistQueueCoalescesResultWrites(t *testing.T) {
+	store := &countingStore{}
+	manager := NewManager(store, nil, nil)
+	ctx := context.Background()
+	auth := &Auth{ID: "persist-queue", Provider: "gemini", Metadata: map[string]any{"type": "gemini"}}
+	if _, errRegister := manager.Register(ctx, auth); errRegister != nil {
+		t.Fatalf("Register error = %v", errRegister)
+	}
+	base := store.saveCount.Load()
+
+	manager.StartPersistQueue(ctx, PersistQueueOptions{FlushInterval: time.Hour})
+	for i := 0; i < 50; i++ {
+		manager.MarkResult(ctx, Result{AuthID: auth.ID, Provider: "gemini", Model: "m", Success: true})
+	}
+	if got := store.saveCount.Load() - base; got != 0 {
+		t.Fatalf("saves before flush = %d, want 0", got)
+	}
+	manager.FlushPersistQueue(ctx)
+	if got := store.saveCount.Load() - base; got != 1 {
+		t.Fatalf("saves after flush = %d, want 1", got)
+	}
+
+	manager.MarkResult(ctx, Result{AuthID: auth.ID, Provider: "gemini", Model: "m", Success: true})
+	manager.StopPersistQueue()
+	if got := store.saveCount.Load() - base; got != 2 {
+		t.Fatalf("saves after stop = %d, want 2 (stop flushes)", got)
+	}
+	manager.MarkResult(ctx, Result{AuthID: auth.ID, Provider: "gemini", Model: "m", Success: true})
+	if got := store.saveCount.Load() - base; got != 3 {
+		t.Fatalf("saves after stop = %d, want 3 (sync after stop)", got)
+	}
+}
+
+func TestPersistQueueSynchronousSaveSupersedesQueuedWrite(t *testing.T) {
+	store := &memoryStore{}
+	manager := NewManager(store, nil, nil)
+	ctx := context.Background()
+	auth := &Auth{ID: "persist-supersede", Provider: "gemini", Metadata: map[string]any{"access_token": "old"}}
+	if _, errRegister := manager.Register(ctx, auth); errRegister != nil {
+		t.Fatalf("Register error = %v", errRegister)
+	}
+	manager.StartPersistQueue(ctx, PersistQueueOptions{FlushInterval: time.Hour})
+	defer manager.StopPersistQueue()
+
+	manager.MarkResult(ctx, Result{AuthID: auth.ID, Provider: "gemini", Model: "m", Success: true})
+	queue := manager.persistQueue.Load()
+	queue.mu.Lock()
+	_, queued := queue.pending[auth.ID]
+	queue.mu.Unlock()
+	if !queued {
+		t.Fatal("result was not queued")
+	}
+
+	updated, _ := manager.GetByID(auth.ID)
+	updated.Metadata["access_token"] = "new"
+	if _, errUpdate := manager.Update(ctx, updated); errUpdate != nil {
+		t.Fatalf("Update error = %v", errUpdate)
+	}
+	queue.mu.Lock()
+	_, queued = queue.pending[auth.ID]
+	queue.mu.Unlock()
+	if queued {
+		t.Fatal("synchronous save left the queued write in place")
+	}
+	if got := store.saved[auth.ID].Metadata["access_token"]; got != "new" {
+		t.Fatalf("stored access_token = %v, want new", got)
+	}
+}
@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+// RequestPriority orders client requests waiting for an upstream slot.
+type RequestPriority int
+
+// Request priority classes, lowest first.
+const (
+	RequestPriorityBatch RequestPriority = iota
+	RequestPriorityNormal
+	RequestPriorityInteractive
+)
+
+// DefaultPriorityGateAgingInterval is how long a request waits before it is promoted one
+// class when the aging interval is unset.
+const DefaultPriorityGateAgingInterval = 10 * time.Second
+
+// ParseRequestPriority parses "interactive", "normal" or "batch" (case-insensitive).
+func ParseRequestPriority(raw string) (RequestPriority, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "interactive", "high":
+		return RequestPriorityInteractive, true
+	case "normal", "default":
+		return RequestPriorityNormal, true
+	case "batch", "low":
+		return RequestPriorityBatch, true
+	default:
+		return RequestPriorityNormal, false
+	}
+}
+
+func (p RequestPriority) String() string {
+	switch p {
+	case RequestPriorityInteractive:
+		return "interactive"
+	case RequestPriorityBatch:
+		return "batch"
+	default:
+		return "normal"
+	}
+}
+
+// PriorityGateOptions configures the admission of client requests to upstream attempts.
+type PriorityGateOptions struct {
+	// MaxConcurrency bounds concurrent upstream attempts of client requests (<= 0 disables the gate).
+	MaxConcurrency int
+	// AgingInterval promotes a waiting request one class per interval waited so batch
+	// traffic is not starved (<= 0 uses the default).
+	AgingInterval time.Duration
+}
+
+// PriorityGateStats is a point-in-time view of the gate.
+type PriorityGateStats struct {
+	Active  int            `json:"active"`
+	Waiting map[string]int `json:"waiting"`
+}
+
+// PriorityGate admits client requests to upstream attempts under a concurrency limit.
+// When the limit is reached, waiting requests are admitted by priority class and then in
+// arrival order; the class of a waiting request rises with the time it has waited.
+type PriorityGate struct {
+	mu      sync.Mutex
+	opts    PriorityGateOptions
+	active  int
+	waiters []*priorityWaiter
+}
+
+type priorityWaiter struct {
+	priority RequestPriority
+	enqueued time.Time
+	ready    chan struct{}
+	granted  bool
+}
+
+// NewPriorityGate creates a gate with the given options.
+func NewPriorityGate(opts PriorityGateOptions) *PriorityGate {
+	gate := &PriorityGate{}
+	gate.SetOptions(opts)
+	return gate
+}
+
+// SetOptions updates the gate limits. Admitted requests are not interrupted.
+func (g *PriorityGate) SetOptions(opts PriorityGateOptions) {
+	if g == nil {
+		return
+	}
+	if opts.AgingInterval <= 0 {
+		opts.AgingInterval = DefaultPriorityGateAgingInterval
+	}
+	g.mu.Lock()
+	g.opts = opts
+	g.dispatchLocked(time.Now())
+	g.mu.Unlock()
+}
+
+// Stats returns the admitted request count and the waiting requests per class.
+func (g *PriorityGate) Stats() PriorityGateStats {
+	stats := PriorityGateStats{Waiting: make(map[string]int)}
+	if g == nil {
+		return stats
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	stats.Active = g.active
+	for _, w := range g.waiters {
+		stats.Waiting[w.priority.String()]++
+	}
+	return stats
+}
+
+// Acquire waits for an upstream slot and returns the function that frees it, or nil when
+// the gate is disabled. It returns ctx.Err() if the context ends while waiting.
+func (g *PriorityGate) Acquire(ctx context.Context, priority RequestPriority) (func(), error) {
+	if g == nil {
+		return nil, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	g.mu.Lock()
+	if g.opts.MaxConcurrency <= 0 {
+		g.mu.Unlock()
+		return nil, nil
+	}
+	if g.active < g.opts.MaxConcurrency && len(g.waiters) == 0 {
+		g.active++
+		g.mu.Unlock()
+		return g.releaseFunc(), nil
+	}
+	w := &priorityWaiter{priority: priority, enqueued: time.Now(), ready: make(chan struct{})}
+	g.waiters = append(g.waiters, w)
+	g.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return g.releaseFunc(), nil
+	case <-ctx.Done():
+		g.mu.Lock()
+		if w.granted {
+			g.active--
+			g.dispatchLocked(time.Now())
+		} else {
+			g.removeWaiterLocked(w)
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+func (g *PriorityGate) releaseFunc() func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			g.mu.Lock()
+			g.active--
+			g.dispatchLocked(time.Now())
+			g.mu.Unlock()
+		})
+	}
+}
+
+// dispatchLocked admits waiting requests while slots are free. A disabled gate admits
+// every waiter.
+func (g *PriorityGate) dispatchLocked(now time.Time) {
+	for len(g.waiters) > 0 && (g.opts.MaxConcurrency <= 0 || g.active < g.opts.MaxConcurrency) {
+		best := 0
+		bestPriority := g.effectivePriority(g.waiters[0], now)
+		for i := 1; i < len(g.waiters); i++ {
+			if p := g.effectivePriority(g.waiters[i], now); p > bestPriority {
+				best, bestPriority = i, p
+			}
+		}
+		w := g.waiters[best]
+		g.waiters = append(g.waiters[:best], g.waiters[best+1:]...)
+		w.granted = true
+		g.active++
+		close(w.ready)
+	}
+}
+
+func (g *PriorityGate) effectivePriority(w *priorityWaiter, now time.Time) RequestPriority {
+	p := w.priority + RequestPriority(now.Sub(w.enqueued)/g.opts.AgingInterval)
+	if p > RequestPriorityInteractive {
+		p = RequestPriorityInteractive
+	}
+	return p
+}
+
+func (g *PriorityGate) removeWaiterLocked(w *priorityWaiter) {
+	for i, candidate := range g.waiters {
+		if candidate == w {
+			g.waiters = append(g.waiters[:i], g.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+// PriorityGate returns the gate client requests pass before each upstream attempt.
+func (m *Manager) PriorityGate() *PriorityGate {
+	if m == nil {
+		return nil
+	}
+	m.priorityGateOnce.Do(func() {
+		if m.priorityGate == nil {
+			m.priorityGate = NewPriorityGate(PriorityGateOptions{})
+		}
+	})
+	return m.priorityGate
+}
+
+// acquireRequestSlot waits for an upstream slot for a client request. Background traffic
+// is paced by the background lane instead and passes straight through. The returned
+// release function is nil when no slot is held.
+func (m *Manager) acquireRequestSlot(ctx context.Context, opts cliproxyexecutor.Options) (func(), error) {
+	if m == nil || IsBackgroundTraffic(ctx) {
+		return nil, nil
+	}
+	return m.PriorityGate().Acquire(ctx, requestPriorityFromMetadata(opts.Metadata))
+}
+
+func releaseRequestSlot(release func()) {
+	if release != nil {
+		release()
+	}
+}
+
+func requestPriorityFromMetadata(meta map[string]any) RequestPriority {
+	if len(meta) == 0 {
+		return RequestPriorityNormal
+	}
+	raw, _ := meta[cliproxyexecutor.RequestPriorityMetadataKey].(string)
+	priority, _ := ParseRequestPriority(raw)
+	return priority
+}
+
+// releaseWhenStreamDone holds release until the stream ends or ctx is cancelled.
+func releaseWhenStreamDone(ctx context.Context, result *cliproxyexecutor.StreamResult, release func()) *cliproxyexecutor.StreamResult {
+	if release == nil {
+		return result
+	}
+	if result == nil || result.Chunks == nil {
+		release()
+		return result
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	in := result.Chunks
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		defer release()
+		for chunk := range in {
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				discardStreamChunks(in)
+				return
+			}
+		}
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: result.Headers, Chunks: out}
+}
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func waitForPriorityWaiters(t *testing.T, gate *PriorityGate, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		waiting := 0
+		for _, count := range gate.Stats().Waiting {
+			waiting += count
+		}
+		if waiting == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d waiters: %+v", n, gate.Stats())
+}
+
+func acquireInOrder(t *testing.T, gate *PriorityGate, priorities []RequestPriority, gap time.Duration) <-chan RequestPriority {
+	t.Helper()
+	order := make(chan RequestPriority, len(priorities))
+	for i, priority := range priorities {
+		go func(priority RequestPriority) {
+			release, err := gate.Acquire(context.Background(), priority)
+			if err != nil {
+				t.Errorf("Acquire(%s) error = %v", priority, err)
+				return
+			}
+			order <- priority
+			release()
+		}(priority)
+		waitForPriorityWaiters(t, gate, i+1)
+		time.Sleep(gap)
+	}
+	return order
+}
+
+func TestPriorityGateAdmitsHigherClassesFirst(t *testing.T) {
+	gate := NewPriorityGate(PriorityGateOptions{MaxConcurrency: 1, AgingInterval: time.Hour})
+	release, err := gate.Acquire(context.Background(), RequestPriorityNormal)
+	if err != nil || release == nil {
+		t.Fatalf("Acquire() error = %v, want a slot", err)
+	}
+	order := acquireInOrder(t, gate, []RequestPriority{RequestPriorityBatch, RequestPriorityNormal, RequestPriorityInteractive}, 0)
+	release()
+
+	for _, want := range []RequestPriority{RequestPriorityInteractive, RequestPriorityNormal, RequestPriorityBatch} {
+		if got := <-order; got != want {
+			t.Fatalf("admitted %s, want %s", got, want)
+		}
+	}
+}
+
+func TestPriorityGateAgingPreventsStarvation(t *testing.T) {
+	gate := NewPriorityGate(PriorityGateOptions{MaxConcurrency: 1, AgingInterval: 20 * time.Millisecond})
+	release, _ := gate.Acquire(context.Background(), RequestPriorityNormal)
+	order := acquireInOrder(t, gate, []RequestPriority{RequestPriorityBatch, RequestPriorityInteractive}, 60*time.Millisecond)
+	release()
+
+	if got := <-order; got != RequestPriorityBatch {
+		t.Fatalf("admitted %s first, want the aged batch request", got)
+	}
+	<-order
+}
+
+func TestPriorityGateCancelledWaiterLeavesQueue(t *testing.T) {
+	gate := NewPriorityGate(PriorityGateOptions{MaxConcurrency: 1})
+	release, _ := gate.Acquire(context.Background(), RequestPriorityNormal)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := gate.Acquire(ctx, RequestPriorityInteractive)
+		done <- err
+	}()
+	waitForPriorityWaiters(t, gate, 1)
+	cancel()
+	if err := <-done; err == nil {
+		t.Fatal("expected cancelled Acquire to fail")
+	}
+	release()
+	if stats := gate.Stats(); stats.Active != 0 || len(stats.Waiting) != 0 {
+		t.Fatalf("stats = %+v, want an idle gate", stats)
+	}
+}
+
+func TestPriorityGateDisabledPassesThrough(t *testing.T) {
+	gate := NewPriorityGate(PriorityGateOptions{})
+	release, err := gate.Acquire(context.Background(), RequestPriorityBatch)
+	if err != nil || release != nil {
+		t.Fatalf("Acquire() error = %v, held slot = %t; want no slot for a disabled gate", err, release != nil)
+	}
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"strings"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// quotaReset describes when an exhausted provider quota comes back: after a rolling window
+// of fixed length, or daily at a wall-clock time.
+type quotaReset struct {
+	window   time.Duration
+	daily    bool
+	hour     int
+	minute   int
+	location *time.Location
+}
+
+var pacificTime = loadQuotaResetLocation("America/Los_Angeles", time.FixedZone("PST", -8*60*60))
+
+func loadQuotaResetLocation(name string, fallback *time.Location) *time.Location {
+	location, errLoad := time.LoadLocation(name)
+	if errLoad != nil {
+		return fallback
+	}
+	return location
+}
+
+// defaultQuotaResets encodes the known reset semantics of provider quotas: subscription
+// usage windows of Claude and Codex roll over five hours, while Gemini daily quotas reset
+// at midnight Pacific time.
+var defaultQuotaResets = map[string]quotaReset{
+	"claude":     {window: 5 * time.Hour},
+	"codex":      {window: 5 * time.Hour},
+	"gemini":     {daily: true, location: pacificTime},
+	"gemini-cli": {daily: true, location: pacificTime},
+	"aistudio":   {daily: true, location: pacificTime},
+}
+
+// quotaResetFor returns the reset schedule of the auth's provider, from the quota-resets
+// config or the built-in defaults; nil when none is known.
+func (m *Manager) quotaResetFor(auth *Auth) *quotaReset {
+	if auth == nil {
+		return nil
+	}
+	provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+	var cfg *internalconfig.Config
+	if m != nil {
+		cfg, _ = m.runtimeConfig.Load().(*internalconfig.Config)
+	}
+	if cfg != nil {
+		if rule, ok := cfg.QuotaResets[provider]; ok {
+			return parseQuotaResetRule(provider, rule)
+		}
+	}
+	if reset, ok := defaultQuotaResets[provider]; ok {
+		return &reset
+	}
+	return nil
+}
+
+func parseQuotaResetRule(provider string, rule internalconfig.QuotaResetRule) *quotaReset {
+	if rule.Disabled {
+		return nil
+	}
+	if raw := strings.TrimSpace(rule.Window); raw != "" {
+		window, errParse := time.ParseDuration(raw)
+		if errParse != nil || window <= 0 {
+			log.Warnf("invalid quota-resets.%s.window %q, ignoring", provider, raw)
+			return nil
+		}
+		return &quotaReset{window: window}
+	}
+	raw := strings.TrimSpace(rule.DailyAt)
+	if raw == "" {
+		return nil
+	}
+	at, errParse := time.Parse("15:04", raw)
+	if errParse != nil {
+		log.Warnf("invalid quota-resets.%s.daily-at %q, ignoring", provider, raw)
+		return nil
+	}
+	reset := &quotaReset{daily: true, hour: at.Hour(), minute: at.Minute(), location: time.UTC}
+	if timezone := strings.TrimSpace(rule.Timezone); timezone != "" {
+		location, errLoad := time.LoadLocation(timezone)
+		if errLoad != nil {
+			log.Warnf("invalid quota-resets.%s.timezone %q, using UTC", provider, timezone)
+		} else {
+			reset.location = location
+		}
+	}
+	return reset
+}
+
+// next returns the latest time the quota exhausted at now can come back.
+func (r *quotaReset) next(now time.Time) time.Time {
+	if !r.daily {
+		return now.Add(r.window)
+	}
+	local := now.In(r.location)
+	reset := time.Date(local.Year(), local.Month(), local.Day(), r.hour, r.minute, 0, 0, r.location)
+	if !reset.After(now) {
+		reset = time.Date(local.Year(), local.Month(), local.Day()+1, r.hour, r.minute, 0, 0, r.location)
+	}
+	return reset
+}
+
+// alignQuotaCooldown bounds a quota cooldown ending at next by the provider's reset: the
+// backoff never outlasts the reset, and a daily quota reported exhausted waits for the
+// reset directly instead of retrying before it. A zero next (cooling disabled) is kept.
+func alignQuotaCooldown(reset *quotaReset, resultErr *Error, now, next time.Time) time.Time {
+	if reset == nil || next.IsZero() {
+		return next
+	}
+	bound := reset.next(now)
+	if reset.daily && resultErr != nil && isDailyQuotaMessage(resultErr.Message) {
+		return bound
+	}
+	if next.After(bound) {
+		return bound
+	}
+	return next
+}
+
+func isDailyQuotaMessage(message string) bool {
+	lower := strings.ToLower(message)
+	return strings.Contains(lower, "per day") ||
+		strings.Contains(lower, "perday") ||
+		strings.Contains(lower, "daily")
+}
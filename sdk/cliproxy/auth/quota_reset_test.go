@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestAlignQuotaCooldownBoundsBackoffByReset(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	rolling := &quotaReset{window: 5 * time.Hour}
+	if got := alignQuotaCooldown(rolling, nil, now, now.Add(10*time.Minute)); !got.Equal(now.Add(10 * time.Minute)) {
+		t.Fatalf("short backoff changed to %v", got)
+	}
+	if got := alignQuotaCooldown(rolling, nil, now, now.Add(24*time.Hour)); !got.Equal(now.Add(5 * time.Hour)) {
+		t.Fatalf("rolling window bound = %v, want %v", got, now.Add(5*time.Hour))
+	}
+	if got := alignQuotaCooldown(rolling, nil, now, time.Time{}); !got.IsZero() {
+		t.Fatalf("disabled cooldown changed to %v", got)
+	}
+
+	daily := &quotaReset{daily: true, location: time.UTC, hour: 7}
+	reset := time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)
+	if got := alignQuotaCooldown(daily, nil, now, now.Add(24*time.Hour)); !got.Equal(reset) {
+		t.Fatalf("daily bound = %v, want %v", got, reset)
+	}
+	exhausted := &Error{Message: "Quota exceeded for quota metric 'Requests per day'"}
+	if got := alignQuotaCooldown(daily, exhausted, now, now.Add(5*time.Minute)); !got.Equal(reset) {
+		t.Fatalf("daily quota exhausted cooldown = %v, want %v", got, reset)
+	}
+}
+
+func TestQuotaResetForAppliesConfigOverrides(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	if reset := manager.quotaResetFor(&Auth{Provider: "gemini-cli"}); reset == nil || !reset.daily {
+		t.Fatalf("gemini-cli default reset = %+v, want daily", reset)
+	}
+	if reset := manager.quotaResetFor(&Auth{Provider: "mistral"}); reset != nil {
+		t.Fatalf("unknown provider reset = %+v, want nil", reset)
+	}
+
+	manager.SetConfig(&internalconfig.Config{QuotaResets: map[string]internalconfig.QuotaResetRule{
+		"codex":   {Disabled: true},
+		"mistral": {DailyAt: "01:30", Timezone: "Europe/Paris"},
+	}})
+	if reset := manager.quotaResetFor(&Auth{Provider: "codex"}); reset != nil {
+		t.Fatalf("disabled codex reset = %+v, want nil", reset)
+	}
+	reset := manager.quotaResetFor(&Auth{Provider: "mistral"})
+	if reset == nil || !reset.daily || reset.hour != 1 || reset.minute != 30 || reset.location.String() != "Europe/Paris" {
+		t.Fatalf("mistral reset = %+v", reset)
+	}
+}
+
+func TestMarkResultDailyQuotaWaitsForProviderReset(t *testing.T) {
+	withQuotaCooldownEnabled(t)
+
+	manager := NewManager(nil, nil, nil)
+	manager.SetConfig(&internalconfig.Config{QuotaResets: map[string]internalconfig.QuotaResetRule{
+		"gemini-cli": {DailyAt: "00:00", Timezone: "UTC"},
+	}})
+	auth := &Auth{ID: "auth-daily-quota", Provider: "gemini-cli"}
+	if _, errRegister := manager.Register(WithSkipPersist(context.Background()), auth); errRegister != nil {
+		t.Fatalf("Register returned error: %v", errRegister)
+	}
+
+	manager.MarkResult(context.Background(), Result{
+		AuthID:   auth.ID,
+		Provider: "gemini-cli",
+		Model:    "gemini-2.5-pro",
+		Error: &Error{
+			Message:    "Quota exceeded for quota metric 'Gemini 2.5 Pro Requests' and limit 'Gemini 2.5 Pro Requests per day per user'",
+			HTTPStatus: http.StatusTooManyRequests,
+		},
+	})
+	updated, ok := manager.GetByID(auth.ID)
+	if !ok || updated.ModelStates["gemini-2.5-pro"] == nil {
+		t.Fatal("expected model state after quota failure")
+	}
+	now := time.Now().UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	if got := updated.ModelStates["gemini-2.5-pro"].NextRetryAfter; !got.Equal(midnight) {
+		t.Fatalf("NextRetryAfter = %v, want next UTC midnight %v", got, midnight)
+	}
+}
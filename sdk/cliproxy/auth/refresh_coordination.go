@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// refreshProviderBusyDelay is how long a due refresh waits when its provider is at the
+// configured concurrency cap.
+const refreshProviderBusyDelay = 2 * time.Second
+
+// ErrRefreshLocked is returned when another replica holds the refresh lock for an auth.
+var ErrRefreshLocked = errors.New("auth refresh locked by another instance")
+
+// RefreshLocker coordinates credential refresh across replicas that share credentials.
+// TryLock must not block for long; when it reports acquired=false the local refresh is
+// skipped and retried later, by which time the other replica's token has usually synced.
+type RefreshLocker interface {
+	TryLock(ctx context.Context, authID string) (unlock func(), acquired bool, err error)
+}
+
+// SetRefreshLocker installs the distributed refresh lock hook. Nil disables it.
+func (m *Manager) SetRefreshLocker(locker RefreshLocker) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	m.refreshLocker = locker
+	m.mu.Unlock()
+}
+
+// refreshSchedulingSettings returns the jitter and per-provider refresh cap from the
+// runtime config.
+func (m *Manager) refreshSchedulingSettings() (time.Duration, int) {
+	cfg, ok := m.runtimeConfig.Load().(*internalconfig.Config)
+	if !ok || cfg == nil {
+		return 0, 0
+	}
+	var jitter time.Duration
+	if raw := strings.TrimSpace(cfg.AuthAutoRefreshJitter); raw != "" {
+		if parsed, errParse := time.ParseDuration(raw); errParse == nil && parsed > 0 {
+			jitter = parsed
+		}
+	}
+	return jitter, cfg.AuthAutoRefreshProviderLimit
+}
+
+// jitterRefreshTime delays next by a random duration in [0, jitter).
+func jitterRefreshTime(next time.Time, jitter time.Duration) time.Time {
+	if jitter <= 0 || next.IsZero() {
+		return next
+	}
+	return next.Add(rand.N(jitter))
+}
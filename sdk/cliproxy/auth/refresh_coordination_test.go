@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+type stubRefreshLocker struct {
+	acquired bool
+	calls    []string
+	unlocked int
+}
+
+func (l *stubRefreshLocker) TryLock(_ context.Context, authID string) (func(), bool, error) {
+	l.calls = append(l.calls, authID)
+	if !l.acquired {
+		return nil, false, nil
+	}
+	return func() { l.unlocked++ }, true, nil
+}
+
+func registerRefreshCoordinationAuths(t *testing.T, m *Manager, provider string, ids ...string) {
+	t.Helper()
+	for _, id := range ids {
+		auth := &Auth{ID: id, Provider: provider, Status: StatusActive, Metadata: map[string]any{"refresh_interval_seconds": 60}}
+		if _, err := m.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register %s: %v", id, err)
+		}
+	}
+}
+
+func TestRefreshAuthForRequest_SkipsWhenLockedElsewhere(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	exec := &unauthorizedRefreshExecutor{id: "locked"}
+	m.RegisterExecutor(exec)
+	registerRefreshCoordinationAuths(t, m, "locked", "shared")
+	locker := &stubRefreshLocker{}
+	m.SetRefreshLocker(locker)
+
+	if _, err := m.refreshAuthForRequest(context.Background(), "shared", ""); !errors.Is(err, ErrRefreshLocked) {
+		t.Fatalf("refresh error = %v, want ErrRefreshLocked", err)
+	}
+	if exec.refreshCalls != 0 {
+		t.Fatalf("refresh calls = %d, want 0", exec.refreshCalls)
+	}
+
+	locker.acquired = true
+	if _, err := m.refreshAuthForRequest(context.Background(), "shared", ""); err != nil {
+		t.Fatalf("refresh error = %v", err)
+	}
+	if exec.refreshCalls != 1 || locker.unlocked != 1 {
+		t.Fatalf("refresh calls = %d, unlocked = %d, want 1 and 1", exec.refreshCalls, locker.unlocked)
+	}
+}
+
+func TestAutoRefreshLoop_ProviderLimitDefersExcessRefreshes(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	m.SetConfig(&internalconfig.Config{AuthAutoRefreshProviderLimit: 1})
+	m.RegisterExecutor(&unauthorizedRefreshExecutor{id: "capped"})
+	registerRefreshCoordinationAuths(t, m, "capped", "first", "second")
+
+	loop := newAuthAutoRefreshLoop(m, time.Minute, 4)
+	now := time.Now()
+	loop.handleDueAuth(context.Background(), now, "first")
+	loop.handleDueAuth(context.Background(), now, "second")
+
+	if got := len(loop.jobs); got != 1 {
+		t.Fatalf("dispatched jobs = %d, want 1", got)
+	}
+	item := loop.index["second"]
+	if item == nil || !item.next.After(now) {
+		t.Fatalf("second auth schedule = %+v, want deferred", item)
+	}
+
+	loop.releaseProvider(<-loop.jobs)
+	if !loop.tryAcquireProvider("second", "capped", 1) {
+		t.Fatal("provider slot not released")
+	}
+}
+
+func TestJitterRefreshTime(t *testing.T) {
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if got := jitterRefreshTime(base, 0); !got.Equal(base) {
+		t.Fatalf("zero jitter = %s, want %s", got, base)
+	}
+	for i := 0; i < 50; i++ {
+		got := jitterRefreshTime(base, time.Second)
+		if got.Before(base) || !got.Before(base.Add(time.Second)) {
+			t.Fatalf("jittered time = %s, want within [%s, %s)", got, base, base.Add(time.Second))
+		}
+	}
+}
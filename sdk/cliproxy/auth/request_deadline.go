@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+// requestDeadlineFromMetadata returns the overall deadline the handler set for the request.
+func requestDeadlineFromMetadata(meta map[string]any) (time.Time, bool) {
+	if len(meta) == 0 {
+		return time.Time{}, false
+	}
+	deadline, ok := meta[cliproxyexecutor.RequestDeadlineMetadataKey].(time.Time)
+	if !ok || deadline.IsZero() {
+		return time.Time{}, false
+	}
+	return deadline, true
+}
+
+// requestDeadlineBlocksAttempt reports whether an attempt started after waiting wait would
+// begin past the request deadline. Callers then return the best error seen so far instead
+// of spending more of a budget the client no longer waits for.
+func requestDeadlineBlocksAttempt(ctx context.Context, meta map[string]any, wait time.Duration) bool {
+	deadline, ok := requestDeadlineFromMetadata(meta)
+	if !ok {
+		return false
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	if time.Now().Add(wait).Before(deadline) {
+		return false
+	}
+	logEntryWithRequestID(ctx).Debugf("request deadline %s reached, not starting another attempt", deadline.Format(time.RFC3339))
+	return true
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestManagerExecuteStopsAtRequestDeadline(t *testing.T) {
+	const model = "glm-5.1"
+	m, _, second := newProviderFallbackTestManager(t, model)
+	m.RegisterExecutor(panickingExecutor{&providerFallbackExecutor{id: "first"}})
+	m.SetRetryConfig(3, 30*time.Second, 2)
+	_, restoreLogger := captureStandardLogger(t)
+	defer restoreLogger()
+
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{
+		cliproxyexecutor.RequestDeadlineMetadataKey: time.Now().Add(-time.Second),
+	}}
+	_, err := m.Execute(context.Background(), []string{"first", "second"}, cliproxyexecutor.Request{Model: model}, opts)
+	var authErr *Error
+	if !errors.As(err, &authErr) || authErr.Code != executorPanicErrorCode {
+		t.Fatalf("execute error = %v, want the first attempt's error", err)
+	}
+	if got := second.ExecuteCalls(); len(got) != 0 {
+		t.Fatalf("second execute calls = %v, want none after the deadline", got)
+	}
+}
+
+func TestRequestDeadlineBlocksAttempt(t *testing.T) {
+	ctx := context.Background()
+	if requestDeadlineBlocksAttempt(ctx, nil, time.Hour) {
+		t.Fatal("attempt blocked without a deadline")
+	}
+	meta := map[string]any{cliproxyexecutor.RequestDeadlineMetadataKey: time.Now().Add(time.Minute)}
+	if requestDeadlineBlocksAttempt(ctx, meta, time.Second) {
+		t.Fatal("attempt blocked well before the deadline")
+	}
+	if !requestDeadlineBlocksAttempt(ctx, meta, 2*time.Minute) {
+		t.Fatal("cooldown wait past the deadline should block the retry")
+	}
+}
@@ -0,0 +1,121 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultRetryBackoffMultiplier = 2.0
+	defaultRetryBackoffJitter     = 0.2
+	retryBackoffDefaultProvider   = "*"
+)
+
+// retryBackoff is a parsed retry-backoff rule.
+type retryBackoff struct {
+	base       time.Duration
+	multiplier float64
+	jitter     float64
+	cap        time.Duration
+}
+
+// retryBackoffTable maps lower-cased provider keys (and "*") to parsed backoff rules.
+type retryBackoffTable map[string]*retryBackoff
+
+// parseRetryBackoffRules parses the retry-backoff config once per config apply; invalid
+// rules are warned about here and left out of the table.
+func parseRetryBackoffRules(rules map[string]internalconfig.RetryBackoffRule) retryBackoffTable {
+	table := make(retryBackoffTable, len(rules))
+	for provider, rule := range rules {
+		key := strings.ToLower(strings.TrimSpace(provider))
+		if backoff := parseRetryBackoffRule(key, rule); backoff != nil {
+			table[key] = backoff
+		}
+	}
+	return table
+}
+
+// retryBackoffFor returns the backoff policy of the first provider with a retry-backoff
+// entry, falling back to the "*" entry; nil when none applies.
+func (m *Manager) retryBackoffFor(providers []string) *retryBackoff {
+	if m == nil {
+		return nil
+	}
+	table, _ := m.retryBackoffs.Load().(retryBackoffTable)
+	if len(table) == 0 {
+		return nil
+	}
+	for _, provider := range providers {
+		if backoff, ok := table[strings.ToLower(strings.TrimSpace(provider))]; ok {
+			return backoff
+		}
+	}
+	return table[retryBackoffDefaultProvider]
+}
+
+func parseRetryBackoffRule(provider string, rule internalconfig.RetryBackoffRule) *retryBackoff {
+	raw := strings.TrimSpace(rule.Base)
+	if raw == "" {
+		return nil
+	}
+	base, errParse := time.ParseDuration(raw)
+	if errParse != nil || base <= 0 {
+		log.Warnf("invalid retry-backoff.%s.base %q, ignoring", provider, raw)
+		return nil
+	}
+	backoff := &retryBackoff{base: base, multiplier: rule.Multiplier, jitter: rule.Jitter}
+	if backoff.multiplier < 1 {
+		backoff.multiplier = defaultRetryBackoffMultiplier
+	}
+	if backoff.jitter <= 0 || backoff.jitter > 1 {
+		backoff.jitter = defaultRetryBackoffJitter
+	}
+	if rawCap := strings.TrimSpace(rule.Cap); rawCap != "" {
+		capDelay, errCap := time.ParseDuration(rawCap)
+		if errCap != nil || capDelay <= 0 {
+			log.Warnf("invalid retry-backoff.%s.cap %q, ignoring", provider, rawCap)
+		} else {
+			backoff.cap = capDelay
+		}
+	}
+	return backoff
+}
+
+// delay returns the wait before retry attempt+1. The upper jitter fraction of the delay is
+// randomised so concurrent requests failing together do not retry in lockstep.
+func (b *retryBackoff) delay(attempt int) time.Duration {
+	if attempt < 0 {
+		attempt = 0
+	}
+	delay := float64(b.base) * math.Pow(b.multiplier, float64(attempt))
+	if b.cap > 0 && delay > float64(b.cap) {
+		delay = float64(b.cap)
+	}
+	if delay > float64(math.MaxInt64) {
+		delay = float64(math.MaxInt64)
+	}
+	return time.Duration(delay * (1 - b.jitter*rand.Float64()))
+}
+
+// isTransientRetryError reports whether err is a transient upstream failure (408/5xx or a
+// network timeout) that a retry-backoff policy applies to.
+func isTransientRetryError(err error, status int) bool {
+	switch status {
+	case http.StatusRequestTimeout, http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestRetryBackoffDelayGrowsWithinJitterAndCap(t *testing.T) {
+	backoff := parseRetryBackoffRule("codex", internalconfig.RetryBackoffRule{Base: "1s", Multiplier: 3, Jitter: 0.5, Cap: "5s"})
+	if backoff == nil {
+		t.Fatal("expected a parsed backoff")
+	}
+	cases := []struct {
+		attempt int
+		max     time.Duration
+	}{
+		{0, time.Second},
+		{1, 3 * time.Second},
+		{2, 5 * time.Second},
+		{10, 5 * time.Second},
+	}
+	for _, tc := range cases {
+		for i := 0; i < 20; i++ {
+			if got := backoff.delay(tc.attempt); got > tc.max || got < tc.max/2 {
+				t.Fatalf("delay(%d) = %v, want within [%v, %v]", tc.attempt, got, tc.max/2, tc.max)
+			}
+		}
+	}
+
+	defaults := parseRetryBackoffRule("*", internalconfig.RetryBackoffRule{Base: "100ms"})
+	if defaults.multiplier != defaultRetryBackoffMultiplier || defaults.jitter != defaultRetryBackoffJitter {
+		t.Fatalf("defaults = %+v", defaults)
+	}
+	if parseRetryBackoffRule("*", internalconfig.RetryBackoffRule{Base: "soon"}) != nil {
+		t.Fatal("invalid base should disable the rule")
+	}
+}
+
+func TestShouldRetryAfterErrorAppliesProviderBackoff(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	manager.SetRetryConfig(2, 30*time.Second, 0)
+	if _, errRegister := manager.Register(WithSkipPersist(context.Background()), &Auth{ID: "codex-1", Provider: "codex"}); errRegister != nil {
+		t.Fatalf("Register returned error: %v", errRegister)
+	}
+	unavailable := &Error{HTTPStatus: http.StatusServiceUnavailable, Message: "upstream overloaded"}
+
+	if _, retry := manager.shouldRetryAfterError(unavailable, 0, []string{"codex"}, "gpt-5", 30*time.Second); retry {
+		t.Fatal("503 without cooldown or backoff should not be retried")
+	}
+
+	manager.SetConfig(&internalconfig.Config{RetryBackoff: map[string]internalconfig.RetryBackoffRule{
+		"*":     {Base: "1ms"},
+		"codex": {Base: "2s", Jitter: 0.01},
+	}})
+	if first, second := manager.retryBackoffFor([]string{"codex"}), manager.retryBackoffFor([]string{"codex"}); first == nil || first != second {
+		t.Fatal("retry-backoff rules should be parsed once per config apply")
+	}
+	if got := manager.retryBackoffFor([]string{"claude"}); got == nil || got.base != time.Millisecond {
+		t.Fatalf("claude backoff = %+v, want the \"*\" rule", got)
+	}
+	wait, retry := manager.shouldRetryAfterError(unavailable, 1, []string{"codex"}, "gpt-5", 30*time.Second)
+	if !retry || wait < 3900*time.Millisecond || wait > 4*time.Second {
+		t.Fatalf("shouldRetryAfterError = %v, %v; want about 4s backoff", wait, retry)
+	}
+	if wait, retry = manager.shouldRetryAfterError(unavailable, 1, []string{"codex"}, "gpt-5", time.Second); !retry || wait != time.Second {
+		t.Fatalf("shouldRetryAfterError = %v, %v; want backoff capped by max wait", wait, retry)
+	}
+	if _, retry = manager.shouldRetryAfterError(unavailable, 2, []string{"codex"}, "gpt-5", 30*time.Second); retry {
+		t.Fatal("backoff must not retry past request-retry")
+	}
+	badRequest := &Error{HTTPStatus: http.StatusBadRequest, Message: "invalid"}
+	if _, retry = manager.shouldRetryAfterError(badRequest, 0, []string{"codex"}, "gpt-5", 30*time.Second); retry {
+		t.Fatal("backoff must only apply to transient errors")
+	}
+}
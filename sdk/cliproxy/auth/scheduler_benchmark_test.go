@@ -36,7 +36,7 @@ func (e schedulerBenchmarkExecutor) HttpRequest(ctx context.Context, auth *Auth,
 	return nil, nil
 }
 
-func benchmarkManagerSetup(b *testing.B, total int, mixed bool, withPriority bool) (*Manager, []string, string) {
+func benchmarkManagerSetup(b testing.TB, total int, mixed bool, withPriority bool) (*Manager, []string, string) {
 	b.Helper()
 	manager := NewManager(nil, &RoundRobinSelector{}, nil)
 	providers := []string{"gemini"}
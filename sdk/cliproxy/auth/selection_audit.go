@@ -0,0 +1,398 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Candidate rejection reasons recorded by the selection audit.
+const (
+	AuditRejectTried            = "already_tried"
+	AuditRejectDisabled         = "disabled"
+	AuditRejectModelUnsupported = "model_unsupported"
+	AuditRejectCooldown         = "cooldown"
+	AuditRejectUnavailable      = "unavailable"
+	AuditRejectOutsideWindow    = "outside_availability_window"
+)
+
+// SelectionAuditCandidate describes one credential considered for a pick.
+type SelectionAuditCandidate struct {
+	AuthID   string `json:"auth_id"`
+	Provider string `json:"provider"`
+	// Rejected is empty for eligible candidates, otherwise one of the AuditReject* reasons.
+	Rejected string     `json:"rejected,omitempty"`
+	RetryAt  *time.Time `json:"retry_at,omitempty"`
+}
+
+// SelectionAuditAttempt is the outcome of one upstream call made with a selected credential.
+type SelectionAuditAttempt struct {
+	Model      string `json:"model,omitempty"`
+	Success    bool   `json:"success"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SelectionAuditHop records one selection round: the candidates seen, the credential
+// chosen, and the attempts made with it.
+type SelectionAuditHop struct {
+	Model      string                    `json:"model"`
+	Candidates []SelectionAuditCandidate `json:"candidates"`
+	Selected   string                    `json:"selected,omitempty"`
+	Provider   string                    `json:"provider,omitempty"`
+	PickError  string                    `json:"pick_error,omitempty"`
+	Attempts   []SelectionAuditAttempt   `json:"attempts,omitempty"`
+}
+
+// SelectionAuditEntry is one JSONL line describing every selection decision made for a request.
+type SelectionAuditEntry struct {
+	Time      time.Time           `json:"time"`
+	RequestID string              `json:"request_id,omitempty"`
+	Kind      string              `json:"kind"`
+	Model     string              `json:"model"`
+	Providers []string            `json:"providers"`
+	Hops      []SelectionAuditHop `json:"hops"`
+	// FallbackModels lists route models tried after the requested one, in order.
+	FallbackModels []string `json:"fallback_models,omitempty"`
+	Outcome        string   `json:"outcome"`
+	Error          string   `json:"error,omitempty"`
+	DurationMs     int64    `json:"duration_ms"`
+}
+
+// SelectionAuditOptions configures the JSONL audit writer.
+type SelectionAuditOptions struct {
+	// Path is the JSONL file; rotated files are kept next to it.
+	Path string
+	// MaxSizeMB rotates the file once it reaches this size (default 50).
+	MaxSizeMB int
+	// MaxBackups bounds how many rotated files are kept (0 keeps all).
+	MaxBackups int
+	// MaxAgeDays removes rotated files older than this many days (0 keeps all).
+	MaxAgeDays int
+}
+
+// SelectionAuditLog writes selection audit entries as JSONL with size-based rotation.
+type SelectionAuditLog struct {
+	mu     sync.Mutex
+	writer io.WriteCloser
+	path   string
+}
+
+// NewSelectionAuditLog opens (or creates) the audit file described by opts.
+func NewSelectionAuditLog(opts SelectionAuditOptions) (*SelectionAuditLog, error) {
+	path := strings.TrimSpace(opts.Path)
+	if path == "" {
+		return nil, fmt.Errorf("selection audit: path is required")
+	}
+	if errMkdir := os.MkdirAll(filepath.Dir(path), 0o700); errMkdir != nil {
+		return nil, fmt.Errorf("selection audit: create directory: %w", errMkdir)
+	}
+	maxSize := opts.MaxSizeMB
+	if maxSize <= 0 {
+		maxSize = 50
+	}
+	return &SelectionAuditLog{
+		path: path,
+		writer: &lumberjack.Logger{
+			Filename:   path,
+			MaxSize:    maxSize,
+			MaxBackups: opts.MaxBackups,
+			MaxAge:     opts.MaxAgeDays,
+		},
+	}, nil
+}
+
+// Path returns the audit file path.
+func (l *SelectionAuditLog) Path() string {
+	if l == nil {
+		return ""
+	}
+	return l.path
+}
+
+// Write appends entry as one JSON line.
+func (l *SelectionAuditLog) Write(entry SelectionAuditEntry) error {
+	if l == nil {
+		return nil
+	}
+	data, errMarshal := json.Marshal(entry)
+	if errMarshal != nil {
+		return fmt.Errorf("selection audit: encode entry: %w", errMarshal)
+	}
+	data = append(data, '\n')
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.writer == nil {
+		return nil
+	}
+	if _, errWrite := l.writer.Write(data); errWrite != nil {
+		return fmt.Errorf("selection audit: write entry: %w", errWrite)
+	}
+	return nil
+}
+
+// Close flushes and closes the audit file.
+func (l *SelectionAuditLog) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.writer == nil {
+		return nil
+	}
+	err := l.writer.Close()
+	l.writer = nil
+	return err
+}
+
+// SetSelectionAudit installs (or with nil, removes) the selection audit log.
+// The previous log is closed.
+func (m *Manager) SetSelectionAudit(auditLog *SelectionAuditLog) {
+	if m == nil {
+		return
+	}
+	previous := m.selectionAudit.Swap(auditLog)
+	if previous != nil && previous != auditLog {
+		if errClose := previous.Close(); errClose != nil {
+			log.Warnf("selection audit: close %s: %v", previous.Path(), errClose)
+		}
+	}
+}
+
+// SelectionAudit returns the installed selection audit log, or nil.
+func (m *Manager) SelectionAudit() *SelectionAuditLog {
+	if m == nil {
+		return nil
+	}
+	return m.selectionAudit.Load()
+}
+
+type selectionAuditContextKey struct{}
+
+// selectionAuditTrace accumulates the decisions for one request.
+type selectionAuditTrace struct {
+	mu      sync.Mutex
+	started time.Time
+	entry   SelectionAuditEntry
+}
+
+func selectionAuditTraceFromContext(ctx context.Context) *selectionAuditTrace {
+	if ctx == nil {
+		return nil
+	}
+	trace, _ := ctx.Value(selectionAuditContextKey{}).(*selectionAuditTrace)
+	return trace
+}
+
+// beginSelectionAudit attaches a trace to ctx when auditing is enabled. Nested calls
+// (e.g. route-model fallbacks) reuse the outer trace and return a nil trace so only
+// the outermost caller finishes the entry.
+func (m *Manager) beginSelectionAudit(ctx context.Context, kind string, providers []string, model string) (context.Context, *selectionAuditTrace) {
+	if m.SelectionAudit() == nil {
+		return ctx, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if existing := selectionAuditTraceFromContext(ctx); existing != nil {
+		return ctx, nil
+	}
+	now := time.Now()
+	trace := &selectionAuditTrace{
+		started: now,
+		entry: SelectionAuditEntry{
+			Time:      now.UTC(),
+			RequestID: logging.GetRequestID(ctx),
+			Kind:      kind,
+			Model:     model,
+			Providers: append([]string(nil), providers...),
+		},
+	}
+	return context.WithValue(ctx, selectionAuditContextKey{}, trace), trace
+}
+
+// finishSelectionAudit records the final outcome and writes the entry.
+func (m *Manager) finishSelectionAudit(trace *selectionAuditTrace, err error) {
+	if trace == nil {
+		return
+	}
+	auditLog := m.SelectionAudit()
+	if auditLog == nil {
+		return
+	}
+	trace.mu.Lock()
+	entry := trace.entry
+	entry.Hops = append([]SelectionAuditHop(nil), trace.entry.Hops...)
+	trace.mu.Unlock()
+	entry.DurationMs = time.Since(trace.started).Milliseconds()
+	entry.Outcome = "success"
+	if err != nil {
+		entry.Outcome = "error"
+		entry.Error = err.Error()
+	}
+	if errWrite := auditLog.Write(entry); errWrite != nil {
+		log.Warn(errWrite)
+	}
+}
+
+// finishSelectionAuditWhenStreamDone defers finishSelectionAudit until the stream's chunk
+// channel closes, so the entry reports the stream's final error rather than the outcome of
+// the bootstrap alone. A stream abandoned by the client is recorded with the context error.
+func (m *Manager) finishSelectionAuditWhenStreamDone(ctx context.Context, trace *selectionAuditTrace, result *cliproxyexecutor.StreamResult) *cliproxyexecutor.StreamResult {
+	if trace == nil {
+		return result
+	}
+	if result == nil || result.Chunks == nil {
+		m.finishSelectionAudit(trace, nil)
+		return result
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	in := result.Chunks
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		var streamErr error
+		defer func() { m.finishSelectionAudit(trace, streamErr) }()
+		defer close(out)
+		for chunk := range in {
+			if chunk.Err != nil {
+				streamErr = chunk.Err
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				streamErr = ctx.Err()
+				discardStreamChunks(in)
+				return
+			}
+		}
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: result.Headers, Chunks: out}
+}
+
+// recordPick appends a hop for one selection round.
+func (t *selectionAuditTrace) recordPick(model string, candidates []SelectionAuditCandidate, selected *Auth, provider string, errPick error) {
+	if t == nil {
+		return
+	}
+	hop := SelectionAuditHop{Model: model, Candidates: candidates, Provider: provider}
+	if selected != nil {
+		hop.Selected = selected.ID
+	}
+	if errPick != nil {
+		hop.PickError = errPick.Error()
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if model != "" && model != t.entry.Model && !containsString(t.entry.FallbackModels, model) {
+		t.entry.FallbackModels = append(t.entry.FallbackModels, model)
+	}
+	t.entry.Hops = append(t.entry.Hops, hop)
+}
+
+// recordResult attaches an upstream attempt outcome to the hop that selected the credential.
+func (t *selectionAuditTrace) recordResult(result Result) {
+	if t == nil || result.AuthID == "" {
+		return
+	}
+	attempt := SelectionAuditAttempt{Model: result.Model, Success: result.Success}
+	if result.Error != nil {
+		attempt.StatusCode = result.Error.HTTPStatus
+		attempt.Error = result.Error.Message
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := len(t.entry.Hops) - 1; i >= 0; i-- {
+		if t.entry.Hops[i].Selected == result.AuthID {
+			t.entry.Hops[i].Attempts = append(t.entry.Hops[i].Attempts, attempt)
+			return
+		}
+	}
+}
+
+// auditCandidates classifies every credential serving providers for model.
+func (m *Manager) auditCandidates(providers []string, model string, tried map[string]struct{}) []SelectionAuditCandidate {
+	providerSet := make(map[string]struct{}, len(providers))
+	for _, provider := range providers {
+		key := strings.TrimSpace(strings.ToLower(provider))
+		if key == "" {
+			continue
+		}
+		providerSet[key] = struct{}{}
+		providerSet[util.OpenAICompatibleProviderKey(key)] = struct{}{}
+	}
+	now := time.Now()
+	registryRef := registry.GetGlobalRegistry()
+	candidates := make([]SelectionAuditCandidate, 0)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, auth := range m.auths {
+		if auth == nil {
+			continue
+		}
+		providerKey := executorKeyFromAuth(auth)
+		if _, ok := providerSet[providerKey]; !ok {
+			continue
+		}
+		candidate := SelectionAuditCandidate{AuthID: auth.ID, Provider: providerKey}
+		switch {
+		case auth.Disabled || auth.Status == StatusDisabled:
+			candidate.Rejected = AuditRejectDisabled
+		case isTried(tried, auth.ID):
+			candidate.Rejected = AuditRejectTried
+		case strings.TrimSpace(model) != "" && !m.authSupportsRouteModel(registryRef, auth, model):
+			candidate.Rejected = AuditRejectModelUnsupported
+		default:
+			if blocked, reason, next := isAuthBlockedForModel(auth, model, now); blocked {
+				switch reason {
+				case blockReasonDisabled:
+					candidate.Rejected = AuditRejectDisabled
+				case blockReasonCooldown:
+					candidate.Rejected = AuditRejectCooldown
+				case blockReasonOutsideWindow:
+					candidate.Rejected = AuditRejectOutsideWindow
+				default:
+					candidate.Rejected = AuditRejectUnavailable
+				}
+				if !next.IsZero() {
+					retryAt := next.UTC()
+					candidate.RetryAt = &retryAt
+				}
+			}
+		}
+		candidates = append(candidates, candidate)
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].AuthID < candidates[j].AuthID })
+	return candidates
+}
+
+func isTried(tried map[string]struct{}, authID string) bool {
+	_, ok := tried[authID]
+	return ok
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func readSelectionAuditEntries(t *testing.T, path string) []SelectionAuditEntry {
+	t.Helper()
+	file, errRead := os.Open(path)
+	if errRead != nil {
+		if os.IsNotExist(errRead) {
+			return nil
+		}
+		t.Fatalf("open audit file: %v", errRead)
+	}
+	defer func() { _ = file.Close() }()
+	scanner := bufio.NewScanner(file)
+	var entries []SelectionAuditEntry
+	for scanner.Scan() {
+		var entry SelectionAuditEntry
+		if errDecode := json.Unmarshal(scanner.Bytes(), &entry); errDecode != nil {
+			t.Fatalf("decode audit line: %v", errDecode)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestSelectionAuditRecordsCandidatesHopsAndOutcome(t *testing.T) {
+	const model = "audit-model"
+	m, first, _ := newProviderFallbackTestManager(t, model)
+	first.executeErr = &Error{HTTPStatus: http.StatusTooManyRequests, Message: "rate limited"}
+
+	path := filepath.Join(t.TempDir(), "audit", "selection.jsonl")
+	auditLog, errOpen := NewSelectionAuditLog(SelectionAuditOptions{Path: path})
+	if errOpen != nil {
+		t.Fatalf("NewSelectionAuditLog() error = %v", errOpen)
+	}
+	m.SetSelectionAudit(auditLog)
+
+	if _, errExec := m.Execute(context.Background(), []string{"first", "second"}, cliproxyexecutor.Request{Model: model}, cliproxyexecutor.Options{}); errExec != nil {
+		t.Fatalf("Execute() error = %v", errExec)
+	}
+	m.SetSelectionAudit(nil)
+
+	entries := readSelectionAuditEntries(t, path)
+	if len(entries) != 1 {
+		t.Fatalf("entries = %d, want 1", len(entries))
+	}
+	entry := entries[0]
+	if entry.Kind != "execute" || entry.Model != model || entry.Outcome != "success" {
+		t.Fatalf("entry = %+v", entry)
+	}
+	if len(entry.Hops) != 2 {
+		t.Fatalf("hops = %+v, want 2", entry.Hops)
+	}
+	firstHop, secondHop := entry.Hops[0], entry.Hops[1]
+	if firstHop.Selected != t.Name()+"-first" || len(firstHop.Attempts) != 1 || firstHop.Attempts[0].Success || firstHop.Attempts[0].StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("first hop = %+v", firstHop)
+	}
+	if len(firstHop.Candidates) != 2 {
+		t.Fatalf("first hop candidates = %+v, want both auths", firstHop.Candidates)
+	}
+	if secondHop.Selected != t.Name()+"-second" || len(secondHop.Attempts) != 1 || !secondHop.Attempts[0].Success {
+		t.Fatalf("second hop = %+v", secondHop)
+	}
+	rejected := map[string]string{}
+	for _, candidate := range secondHop.Candidates {
+		rejected[candidate.AuthID] = candidate.Rejected
+	}
+	if rejected[t.Name()+"-first"] == "" {
+		t.Fatalf("second hop candidates = %+v, want first auth rejected", secondHop.Candidates)
+	}
+}
+
+func TestSelectionAuditDisabledLeavesContextUntouched(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	ctx, trace := m.beginSelectionAudit(context.Background(), "execute", []string{"p"}, "m")
+	if trace != nil || selectionAuditTraceFromContext(ctx) != nil {
+		t.Fatalf("trace attached without an audit log")
+	}
+}
+
+func TestSelectionAuditFinishesStreamsWithTheirFinalError(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	path := filepath.Join(t.TempDir(), "selection.jsonl")
+	auditLog, errOpen := NewSelectionAuditLog(SelectionAuditOptions{Path: path})
+	if errOpen != nil {
+		t.Fatalf("NewSelectionAuditLog() error = %v", errOpen)
+	}
+	m.SetSelectionAudit(auditLog)
+	defer m.SetSelectionAudit(nil)
+
+	ctx, trace := m.beginSelectionAudit(context.Background(), "stream", []string{"p"}, "m")
+	chunks := make(chan cliproxyexecutor.StreamChunk, 2)
+	chunks <- cliproxyexecutor.StreamChunk{Payload: []byte("partial")}
+	chunks <- cliproxyexecutor.StreamChunk{Err: &Error{HTTPStatus: http.StatusBadGateway, Message: "stream broke"}}
+	close(chunks)
+	result := m.finishSelectionAuditWhenStreamDone(ctx, trace, &cliproxyexecutor.StreamResult{Chunks: chunks})
+
+	if entries := readSelectionAuditEntries(t, path); len(entries) != 0 {
+		t.Fatalf("entries before the stream was consumed = %+v", entries)
+	}
+	for range result.Chunks {
+	}
+	deadline := time.Now().Add(time.Second)
+	var entries []SelectionAuditEntry
+	for time.Now().Before(deadline) {
+		if entries = readSelectionAuditEntries(t, path); len(entries) > 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(entries) != 1 || entries[0].Outcome != "error" || entries[0].Error != "stream broke" {
+		t.Fatalf("entries = %+v, want one error entry", entries)
+	}
+}
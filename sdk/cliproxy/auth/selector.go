@@ -144,6 +144,7 @@ const (
 	blockReasonNone blockReason = iota
 	blockReasonCooldown
 	blockReasonDisabled
+	blockReasonOutsideWindow
 	blockReasonOther
 )
 
@@ -1002,6 +1003,12 @@ func isAuthBlockedForModel(auth *Auth, model string, now time.Time) (bool, block
 	if auth.Disabled || auth.Status == StatusDisabled {
 		return true, blockReasonDisabled, time.Time{}
 	}
+	if !auth.Availability.Allows(now) {
+		return true, blockReasonOutsideWindow, auth.Availability.NextOpen(now)
+	}
+	if auth.BenchedUntil.After(now) {
+		return true, blockReasonCooldown, auth.BenchedUntil
+	}
 	if model != "" {
 		if len(auth.ModelStates) > 0 {
 			state, ok := auth.ModelStates[model]
@@ -0,0 +1,295 @@
+package auth
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+// dailyBudgetAttribute overrides the configured per-auth daily budget (USD).
+const dailyBudgetAttribute = "daily_budget"
+
+// CostAwareSelector prefers the cheapest available auth for the requested model.
+// Prices come from the pricing registry; auths without a known price rank last.
+// Auths whose spend reached their daily budget are skipped. Ties are broken round-robin.
+type CostAwareSelector struct {
+	// Pricing resolves model prices; nil uses the global pricing registry.
+	Pricing *registry.PricingRegistry
+	// DailyBudget is the default per-auth USD ceiling per UTC day; 0 disables it.
+	DailyBudget float64
+	// Spend tracks per-auth daily spend. Selectors rebuilt on config reload share the
+	// manager's tracker so spend survives the reload; nil uses a tracker of the selector's own.
+	Spend *CostTracker
+
+	mu   sync.Mutex
+	own  *CostTracker
+	ties RoundRobinSelector
+	now  func() time.Time
+}
+
+// NewCostAwareSelector constructs a cost-aware selector with the given default daily budget.
+func NewCostAwareSelector(dailyBudget float64) *CostAwareSelector {
+	return &CostAwareSelector{DailyBudget: dailyBudget}
+}
+
+func (s *CostAwareSelector) pricing() *registry.PricingRegistry {
+	if s.Pricing != nil {
+		return s.Pricing
+	}
+	return registry.GetGlobalPricingRegistry()
+}
+
+func (s *CostAwareSelector) currentTime() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+func (s *CostAwareSelector) spend() *CostTracker {
+	if s.Spend != nil {
+		return s.Spend
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.own == nil {
+		s.own = NewCostTracker()
+	}
+	return s.own
+}
+
+func spendDay(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// Pick selects the cheapest available auth that has not exhausted its daily budget.
+func (s *CostAwareSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
+	now := s.currentTime()
+	available, err := availableAuthsForSelector(auths, provider, model, opts, now)
+	if err != nil {
+		return nil, err
+	}
+
+	withinBudget := make([]*Auth, 0, len(available))
+	for _, candidate := range available {
+		if budget := s.budgetFor(candidate); budget > 0 && s.SpentToday(candidate.ID) >= budget {
+			continue
+		}
+		withinBudget = append(withinBudget, candidate)
+	}
+	if len(withinBudget) == 0 {
+		return nil, &Error{Code: "auth_budget_exhausted", Message: "all credentials reached their daily budget", HTTPStatus: 429}
+	}
+
+	pricing := s.pricing()
+	modelKey := canonicalModelKey(model)
+	cheapest := math.Inf(1)
+	var tied []*Auth
+	for _, candidate := range withinBudget {
+		cost := math.Inf(1)
+		if price, ok := pricing.Lookup(candidate.Provider, modelKey); ok {
+			cost = price.UnitCost()
+		}
+		switch {
+		case cost < cheapest:
+			cheapest = cost
+			tied = append(tied[:0], candidate)
+		case cost == cheapest:
+			tied = append(tied, candidate)
+		}
+	}
+	return s.ties.Pick(ctx, provider, model, markAuthCandidatesPrefiltered(opts), tied)
+}
+
+// HandleUsage implements coreusage.Plugin and charges the record to the selector's tracker
+// at the selector's prices.
+func (s *CostAwareSelector) HandleUsage(_ context.Context, record coreusage.Record) {
+	if s == nil {
+		return
+	}
+	s.spend().charge(record, s.pricing(), s.currentTime())
+}
+
+// SpentToday returns the estimated USD spend recorded for authID in the current UTC day.
+func (s *CostAwareSelector) SpentToday(authID string) float64 {
+	return s.spend().spentOn(authID, spendDay(s.currentTime()))
+}
+
+func (s *CostAwareSelector) budgetFor(auth *Auth) float64 {
+	if auth != nil && auth.Attributes != nil {
+		if raw := strings.TrimSpace(auth.Attributes[dailyBudgetAttribute]); raw != "" {
+			if parsed, errParse := strconv.ParseFloat(raw, 64); errParse == nil && parsed >= 0 {
+				return parsed
+			}
+		}
+	}
+	return s.DailyBudget
+}
+
+// CostSpend returns the manager's daily spend tracker shared by cost-aware selectors.
+func (m *Manager) CostSpend() *CostTracker {
+	if m == nil {
+		return nil
+	}
+	m.costSpendOnce.Do(func() {
+		if m.costSpend == nil {
+			m.costSpend = NewCostTracker()
+		}
+	})
+	return m.costSpend
+}
+
+// CostTracker accumulates estimated USD spend per auth and UTC day from usage records.
+// A Manager owns one tracker so spend is kept when the routing selector is rebuilt.
+type CostTracker struct {
+	// Pricing resolves model prices; nil uses the global pricing registry.
+	Pricing *registry.PricingRegistry
+
+	mu    sync.Mutex
+	spend map[string]authDailySpend
+	// counters seeds spend after a restart; nil uses the default usage manager's counters.
+	counters *coreusage.Counters
+	now      func() time.Time
+}
+
+type authDailySpend struct {
+	day string
+	usd float64
+}
+
+// NewCostTracker creates an empty spend tracker.
+func NewCostTracker() *CostTracker {
+	return &CostTracker{spend: make(map[string]authDailySpend)}
+}
+
+func (t *CostTracker) pricing() *registry.PricingRegistry {
+	if t.Pricing != nil {
+		return t.Pricing
+	}
+	return registry.GetGlobalPricingRegistry()
+}
+
+func (t *CostTracker) currentTime() time.Time {
+	if t.now != nil {
+		return t.now()
+	}
+	return time.Now()
+}
+
+func (t *CostTracker) countersStore() *coreusage.Counters {
+	if t.counters != nil {
+		return t.counters
+	}
+	return coreusage.DefaultManager().Counters()
+}
+
+// HandleUsage implements coreusage.Plugin and accumulates per-auth daily spend.
+func (t *CostTracker) HandleUsage(_ context.Context, record coreusage.Record) {
+	if t == nil {
+		return
+	}
+	t.charge(record, t.pricing(), t.currentTime())
+}
+
+func (t *CostTracker) charge(record coreusage.Record, pricing *registry.PricingRegistry, now time.Time) {
+	if record.AuthID == "" {
+		return
+	}
+	price, ok := pricing.Lookup(record.Provider, canonicalModelKey(record.Model))
+	if !ok && record.Alias != "" {
+		price, ok = pricing.Lookup(record.Provider, canonicalModelKey(record.Alias))
+	}
+	if !ok {
+		return
+	}
+	cost := price.Cost(record.Detail.InputTokens, record.Detail.OutputTokens)
+	if cost <= 0 {
+		return
+	}
+	at := record.RequestedAt
+	if at.IsZero() {
+		at = now
+	}
+	t.addSpend(record.AuthID, spendDay(at), cost)
+}
+
+func (t *CostTracker) addSpend(authID, day string, usd float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.spend == nil {
+		t.spend = make(map[string]authDailySpend)
+	}
+	current := t.spend[authID]
+	if current.day != day {
+		if current.day > day {
+			// Late record for a day that has already rolled over.
+			return
+		}
+		current = authDailySpend{day: day}
+	}
+	current.usd += usd
+	t.spend[authID] = current
+}
+
+// SpentToday returns the estimated USD spend recorded for authID in the current UTC day.
+func (t *CostTracker) SpentToday(authID string) float64 {
+	if t == nil {
+		return 0
+	}
+	return t.spentOn(authID, spendDay(t.currentTime()))
+}
+
+func (t *CostTracker) spentOn(authID, day string) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	current, ok := t.spend[authID]
+	if !ok || current.day != day {
+		return 0
+	}
+	return current.usd
+}
+
+// Seed loads today's spend of auths not tracked yet from the persisted usage counters,
+// so daily budgets hold across restarts.
+func (t *CostTracker) Seed(auths []*Auth) {
+	if t == nil {
+		return
+	}
+	counters := t.countersStore()
+	if counters == nil {
+		return
+	}
+	pricing := t.pricing()
+	day := spendDay(t.currentTime())
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.spend == nil {
+		t.spend = make(map[string]authDailySpend)
+	}
+	for _, auth := range auths {
+		if auth == nil {
+			continue
+		}
+		if current, ok := t.spend[auth.ID]; ok && current.day >= day {
+			continue
+		}
+		seeded := authDailySpend{day: day}
+		for _, row := range counters.Query(coreusage.CounterFilter{AuthID: auth.ID, From: day, To: day}) {
+			provider := row.Provider
+			if provider == "" {
+				provider = auth.Provider
+			}
+			if price, ok := pricing.Lookup(provider, canonicalModelKey(row.Model)); ok {
+				seeded.usd += price.Cost(row.InputTokens, row.OutputTokens)
+			}
+		}
+		t.spend[auth.ID] = seeded
+	}
+}
@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func newTestCostSelector(budget float64) *CostAwareSelector {
+	pricing := registry.NewPricingRegistry()
+	pricing.SetPrices([]registry.ModelPrice{
+		{Provider: "claude", Model: "claude-sonnet-*", PromptPerMillion: 3, CompletionPerMillion: 15},
+		{Provider: "openrouter", Model: "claude-sonnet-4", PromptPerMillion: 2, CompletionPerMillion: 10},
+	})
+	selector := NewCostAwareSelector(budget)
+	selector.Pricing = pricing
+	return selector
+}
+
+func TestCostAwareSelectorPick_PrefersCheapestProvider(t *testing.T) {
+	t.Parallel()
+
+	selector := newTestCostSelector(0)
+	auths := []*Auth{
+		{ID: "claude-1", Provider: "claude"},
+		{ID: "unpriced", Provider: "gemini"},
+		{ID: "router-1", Provider: "openrouter"},
+	}
+	for i := 0; i < 3; i++ {
+		got, err := selector.Pick(context.Background(), "mixed", "claude-sonnet-4", cliproxyexecutor.Options{}, auths)
+		if err != nil {
+			t.Fatalf("Pick() error = %v", err)
+		}
+		if got.ID != "router-1" {
+			t.Fatalf("Pick() auth.ID = %q, want %q", got.ID, "router-1")
+		}
+	}
+}
+
+func TestCostAwareSelectorPick_SkipsAuthOverDailyBudget(t *testing.T) {
+	t.Parallel()
+
+	selector := newTestCostSelector(1)
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	selector.now = func() time.Time { return now }
+	auths := []*Auth{
+		{ID: "claude-1", Provider: "claude"},
+		{ID: "router-1", Provider: "openrouter"},
+	}
+
+	selector.HandleUsage(context.Background(), coreusage.Record{
+		Provider:    "openrouter",
+		Model:       "claude-sonnet-4",
+		AuthID:      "router-1",
+		RequestedAt: now,
+		Detail:      coreusage.Detail{InputTokens: 500_000},
+	})
+	if spent := selector.SpentToday("router-1"); spent != 1 {
+		t.Fatalf("SpentToday() = %v, want 1", spent)
+	}
+	got, err := selector.Pick(context.Background(), "mixed", "claude-sonnet-4", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "claude-1" {
+		t.Fatalf("Pick() auth.ID = %q, want %q", got.ID, "claude-1")
+	}
+
+	// A per-auth attribute raises the ceiling for that credential only.
+	auths[1].Attributes = map[string]string{dailyBudgetAttribute: "5"}
+	got, err = selector.Pick(context.Background(), "mixed", "claude-sonnet-4", cliproxyexecutor.Options{}, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "router-1" {
+		t.Fatalf("Pick() auth.ID = %q, want %q", got.ID, "router-1")
+	}
+
+	// Spend resets with the UTC day.
+	now = now.Add(24 * time.Hour)
+	if spent := selector.SpentToday("router-1"); spent != 0 {
+		t.Fatalf("SpentToday() after rollover = %v, want 0", spent)
+	}
+}
+
+func TestCostAwareSelectorPick_AllBudgetsExhausted(t *testing.T) {
+	t.Parallel()
+
+	selector := newTestCostSelector(0.5)
+	auths := []*Auth{{ID: "claude-1", Provider: "claude"}}
+	selector.HandleUsage(context.Background(), coreusage.Record{
+		Provider: "claude",
+		Model:    "claude-sonnet-4-5",
+		AuthID:   "claude-1",
+		Detail:   coreusage.Detail{OutputTokens: 100_000},
+	})
+
+	_, err := selector.Pick(context.Background(), "claude", "claude-sonnet-4-5", cliproxyexecutor.Options{}, auths)
+	var authErr *Error
+	if !errors.As(err, &authErr) || authErr.Code != "auth_budget_exhausted" || authErr.StatusCode() != 429 {
+		t.Fatalf("Pick() error = %v, want auth_budget_exhausted", err)
+	}
+}
+
+func TestPricingRegistryLookup_Precedence(t *testing.T) {
+	t.Parallel()
+
+	pricing := registry.NewPricingRegistry()
+	pricing.SetPrices([]registry.ModelPrice{
+		{Model: "gpt-*", PromptPerMillion: 1},
+		{Model: "gpt-5", PromptPerMillion: 2},
+		{Provider: "codex", Model: "gpt-5", PromptPerMillion: 3},
+	})
+	cases := []struct {
+		provider, model string
+		want            float64
+	}{
+		{"codex", "gpt-5", 3},
+		{"openai", "gpt-5", 2},
+		{"openai", "GPT-5-mini", 1},
+	}
+	for _, tc := range cases {
+		price, ok := pricing.Lookup(tc.provider, tc.model)
+		if !ok || price.PromptPerMillion != tc.want {
+			t.Fatalf("Lookup(%q, %q) = %+v, %v; want prompt %v", tc.provider, tc.model, price, ok, tc.want)
+		}
+	}
+	if _, ok := pricing.Lookup("openai", "o3"); ok {
+		t.Fatalf("Lookup(openai, o3) unexpectedly matched")
+	}
+}
+
+func TestCostTrackerSharedAcrossSelectorsAndSeededFromCounters(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	pricing := registry.NewPricingRegistry()
+	pricing.SetPrices([]registry.ModelPrice{{Provider: "openrouter", Model: "claude-sonnet-4", PromptPerMillion: 2}})
+	record := coreusage.Record{
+		Provider:    "openrouter",
+		Model:       "claude-sonnet-4",
+		AuthID:      "router-1",
+		RequestedAt: now,
+		Detail:      coreusage.Detail{InputTokens: 500_000},
+	}
+
+	tracker := NewCostTracker()
+	tracker.Pricing = pricing
+	tracker.now = func() time.Time { return now }
+	tracker.HandleUsage(context.Background(), record)
+
+	// A selector rebuilt on reload keeps the spend recorded before it existed.
+	rebuilt := NewCostAwareSelector(1)
+	rebuilt.Pricing = pricing
+	rebuilt.Spend = tracker
+	rebuilt.now = tracker.now
+	if spent := rebuilt.SpentToday("router-1"); spent != 1 {
+		t.Fatalf("SpentToday() after rebuild = %v, want 1", spent)
+	}
+
+	// After a restart the tracker is empty and picks today's spend up from the counters.
+	counters := coreusage.NewCounters(coreusage.CountersOptions{})
+	counters.HandleUsage(context.Background(), record)
+	restarted := NewCostTracker()
+	restarted.Pricing = pricing
+	restarted.counters = counters
+	restarted.now = tracker.now
+	restarted.Seed([]*Auth{{ID: "router-1", Provider: "openrouter"}})
+	if spent := restarted.SpentToday("router-1"); spent != 1 {
+		t.Fatalf("SpentToday() after seed = %v, want 1", spent)
+	}
+}
@@ -59,6 +59,11 @@ type Auth struct {
 	Storage baseauth.TokenStorage `json:"-"`
 	// Label is an optional human readable label for logging.
 	Label string `json:"label,omitempty"`
+	// Groups names the routing groups of the auth (e.g. "personal", "work"). Requests
+	// restricted to groups only select auths belonging to one of them.
+	Groups []string `json:"groups,omitempty"`
+	// Availability limits the auth to recurring time windows; nil allows any time.
+	Availability *Availability `json:"availability,omitempty"`
 	// Status is the lifecycle status managed by the AuthManager.
 	Status Status `json:"status"`
 	// StatusMessage holds a short description for the current status.
@@ -83,10 +88,17 @@ type Auth struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	// LastRefreshedAt records the last successful refresh time in UTC.
 	LastRefreshedAt time.Time `json:"last_refreshed_at"`
+	// RefreshFailures counts refreshes failed since the last successful one.
+	RefreshFailures int `json:"refresh_failures,omitempty"`
 	// NextRefreshAfter is the earliest time a refresh should retrigger.
 	NextRefreshAfter time.Time `json:"next_refresh_after"`
 	// NextRetryAfter is the earliest time a retry should retrigger.
 	NextRetryAfter time.Time `json:"next_retry_after"`
+	// BenchedUntil keeps the auth out of selection for every model until this time, e.g.
+	// after it reached a daily cap.
+	BenchedUntil time.Time `json:"benched_until,omitempty"`
+	// BenchReason explains the current bench.
+	BenchReason string `json:"bench_reason,omitempty"`
 	// ModelStates tracks per-model runtime availability data.
 	ModelStates map[string]*ModelState `json:"model_states,omitempty"`
 
@@ -292,6 +304,9 @@ func (a *Auth) Clone() *Auth {
 		return nil
 	}
 	copyAuth := *a
+	if len(a.Groups) > 0 {
+		copyAuth.Groups = append([]string(nil), a.Groups...)
+	}
 	if len(a.Attributes) > 0 {
 		copyAuth.Attributes = make(map[string]string, len(a.Attributes))
 		for key, value := range a.Attributes {
@@ -320,6 +335,31 @@ func (a *Auth) Clone() *Auth {
 	return &copyAuth
 }
 
+// readView returns a per-request copy of an immutable auth snapshot, such as the ones held
+// by the scheduler. Attributes and Metadata are duplicated because executors may update
+// them (for example during an inline token refresh). ModelStates and PrimaryInfo are
+// shared with a and must be treated as read-only: runtime state is only changed by the
+// manager on its own entries (MarkResult and friends), which are never handed out.
+func (a *Auth) readView() *Auth {
+	if a == nil {
+		return nil
+	}
+	view := *a
+	if len(a.Attributes) > 0 {
+		view.Attributes = make(map[string]string, len(a.Attributes))
+		for key, value := range a.Attributes {
+			view.Attributes[key] = value
+		}
+	}
+	if len(a.Metadata) > 0 {
+		view.Metadata = make(map[string]any, len(a.Metadata))
+		for key, value := range a.Metadata {
+			view.Metadata[key] = value
+		}
+	}
+	return &view
+}
+
 // SyncPrimaryInfoMetadata keeps persisted metadata aligned with the canonical
 // PrimaryInfo state for providers that serialize handoff information.
 func SyncPrimaryInfoMetadata(auth *Auth) {
@@ -481,6 +521,19 @@ func (a *Auth) ProxyInfo() string {
 	return "via proxy"
 }
 
+// SourceAddress returns the local IP address or network interface this auth's outbound
+// connections bind to. Config keys set it as an attribute; auth files use the
+// "source_address" metadata field.
+func (a *Auth) SourceAddress() string {
+	if a == nil {
+		return ""
+	}
+	if source := authAttribute(a, AttributeSourceAddress); source != "" {
+		return source
+	}
+	return authMetadataString(a, AttributeSourceAddress)
+}
+
 // DisableCoolingOverride returns the auth scoped disable_cooling override when present.
 // The value is read from metadata key "disable_cooling" (or legacy "disable-cooling").
 //
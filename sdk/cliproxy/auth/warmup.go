@@ -0,0 +1,215 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultWarmupTimeout bounds a single warmup request when unset.
+	DefaultWarmupTimeout = 30 * time.Second
+	// DefaultWarmupPrompt is the user message sent by warmup requests when unset.
+	DefaultWarmupPrompt = "hi"
+)
+
+// WarmupSchedule sends a tiny request through every OAuth auth of Provider on a schedule.
+type WarmupSchedule struct {
+	// Provider selects the auths to warm.
+	Provider string
+	// Model is the model requested by the warmup call.
+	Model string
+	// Every runs the schedule at a fixed interval.
+	Every time.Duration
+	// At runs the schedule daily at these offsets from local midnight. Used when Every is zero.
+	At []time.Duration
+	// Prompt overrides DefaultWarmupPrompt.
+	Prompt string
+}
+
+// WarmupOptions configures the warmup scheduler.
+type WarmupOptions struct {
+	// Schedules lists the provider/model pairs to warm.
+	Schedules []WarmupSchedule
+	// Timeout bounds a single warmup request (<= 0 uses DefaultWarmupTimeout).
+	Timeout time.Duration
+}
+
+// next returns the first run time strictly after after, or zero when the schedule never runs.
+func (s WarmupSchedule) next(after time.Time) time.Time {
+	if s.Every > 0 {
+		return after.Add(s.Every)
+	}
+	if len(s.At) == 0 {
+		return time.Time{}
+	}
+	offsets := append([]time.Duration(nil), s.At...)
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	midnight := time.Date(after.Year(), after.Month(), after.Day(), 0, 0, 0, 0, after.Location())
+	for day := 0; day < 2; day++ {
+		base := midnight.AddDate(0, 0, day)
+		for _, offset := range offsets {
+			if candidate := base.Add(offset); candidate.After(after) {
+				return candidate
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// StartWarmup starts (or restarts with new options) the warmup scheduler. Each due
+// schedule sends a minimal request pinned to every active OAuth auth of its provider
+// through the background lane. Results go through the normal result path, so a silently
+// invalidated session is refreshed or cooled down before client traffic reaches it.
+func (m *Manager) StartWarmup(parent context.Context, opts WarmupOptions) {
+	if m == nil {
+		return
+	}
+	if parent == nil {
+		parent = context.Background()
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultWarmupTimeout
+	}
+	m.StopWarmup()
+	if len(opts.Schedules) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(parent)
+	done := make(chan struct{})
+	m.warmupMu.Lock()
+	m.warmupCancel = cancel
+	m.warmupDone = done
+	m.warmupMu.Unlock()
+
+	go func() {
+		defer close(done)
+		var wg sync.WaitGroup
+		defer wg.Wait()
+
+		now := time.Now()
+		nextRuns := make([]time.Time, len(opts.Schedules))
+		for i, schedule := range opts.Schedules {
+			nextRuns[i] = schedule.next(now)
+		}
+		for {
+			wake := time.Time{}
+			for _, next := range nextRuns {
+				if !next.IsZero() && (wake.IsZero() || next.Before(wake)) {
+					wake = next
+				}
+			}
+			if wake.IsZero() {
+				return
+			}
+			timer := time.NewTimer(time.Until(wake))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			now = time.Now()
+			for i, schedule := range opts.Schedules {
+				if nextRuns[i].IsZero() || nextRuns[i].After(now) {
+					continue
+				}
+				nextRuns[i] = schedule.next(now)
+				wg.Add(1)
+				go func(schedule WarmupSchedule) {
+					defer wg.Done()
+					m.runWarmupSchedule(ctx, schedule, opts.Timeout)
+				}(schedule)
+			}
+		}
+	}()
+}
+
+// StopWarmup stops the warmup scheduler and waits for in-flight warmups to finish.
+func (m *Manager) StopWarmup() {
+	if m == nil {
+		return
+	}
+	m.warmupMu.Lock()
+	cancel, done := m.warmupCancel, m.warmupDone
+	m.warmupCancel, m.warmupDone = nil, nil
+	m.warmupMu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if done != nil {
+		<-done
+	}
+}
+
+func (m *Manager) runWarmupSchedule(ctx context.Context, schedule WarmupSchedule, timeout time.Duration) {
+	for _, authID := range m.warmupTargets(schedule.Provider, time.Now()) {
+		if ctx.Err() != nil {
+			return
+		}
+		errWarmup := m.RunBackground(ctx, BackgroundKindWarmup, func(warmCtx context.Context) error {
+			warmCtx, cancel := context.WithTimeout(warmCtx, timeout)
+			defer cancel()
+			return m.warmupAuth(warmCtx, authID, schedule)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if errWarmup != nil {
+			log.Warnf("warmup: %s (%s) model %s failed: %v", authID, schedule.Provider, schedule.Model, errWarmup)
+			continue
+		}
+		log.Debugf("warmup: %s (%s) model %s ok", authID, schedule.Provider, schedule.Model)
+	}
+}
+
+// warmupTargets lists active OAuth auths of provider that are not cooling down.
+func (m *Manager) warmupTargets(provider string, now time.Time) []string {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ids := make([]string, 0)
+	for _, auth := range m.auths {
+		if auth == nil || auth.Disabled || auth.Status == StatusDisabled {
+			continue
+		}
+		if !strings.EqualFold(strings.TrimSpace(auth.Provider), provider) || auth.AuthKind() != AuthKindOAuth {
+			continue
+		}
+		if auth.Unavailable && auth.NextRetryAfter.After(now) {
+			continue
+		}
+		ids = append(ids, auth.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// warmupAuth sends one minimal chat request pinned to authID.
+func (m *Manager) warmupAuth(ctx context.Context, authID string, schedule WarmupSchedule) error {
+	prompt := strings.TrimSpace(schedule.Prompt)
+	if prompt == "" {
+		prompt = DefaultWarmupPrompt
+	}
+	payload, errMarshal := json.Marshal(map[string]any{
+		"model":      schedule.Model,
+		"messages":   []map[string]string{{"role": "user", "content": prompt}},
+		"max_tokens": 1,
+	})
+	if errMarshal != nil {
+		return errMarshal
+	}
+	_, err := m.Execute(ctx, []string{schedule.Provider}, cliproxyexecutor.Request{Model: schedule.Model, Payload: payload}, cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("openai"),
+		Metadata:     map[string]any{cliproxyexecutor.PinnedAuthMetadataKey: authID},
+	})
+	return err
+}
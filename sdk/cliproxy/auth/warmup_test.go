@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+)
+
+func TestWarmupScheduleNext(t *testing.T) {
+	base := time.Date(2026, 3, 10, 9, 30, 0, 0, time.UTC)
+
+	every := WarmupSchedule{Every: 4 * time.Hour}
+	if got, want := every.next(base), base.Add(4*time.Hour); !got.Equal(want) {
+		t.Fatalf("every next = %s, want %s", got, want)
+	}
+
+	daily := WarmupSchedule{At: []time.Duration{13 * time.Hour, 8 * time.Hour}}
+	if got, want := daily.next(base), time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("daily next = %s, want %s", got, want)
+	}
+	afterLast := time.Date(2026, 3, 10, 13, 0, 0, 0, time.UTC)
+	if got, want := daily.next(afterLast), time.Date(2026, 3, 11, 8, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Fatalf("daily next after last slot = %s, want %s", got, want)
+	}
+
+	if got := (WarmupSchedule{}).next(base); !got.IsZero() {
+		t.Fatalf("empty schedule next = %s, want zero", got)
+	}
+}
+
+func TestManagerRunWarmupSchedule_PinsEachOAuthAuth(t *testing.T) {
+	const model = "warm-model"
+	m := NewManager(nil, &FillFirstSelector{}, nil)
+	exec := &providerFallbackExecutor{id: "warm"}
+	m.RegisterExecutor(exec)
+
+	reg := registry.GetGlobalRegistry()
+	auths := []*Auth{
+		{ID: t.Name() + "-a", Provider: "warm", Status: StatusActive, Metadata: map[string]any{AttributeAuthKind: AuthKindOAuth}},
+		{ID: t.Name() + "-b", Provider: "warm", Status: StatusActive, Metadata: map[string]any{AttributeAuthKind: AuthKindOAuth}},
+		{ID: t.Name() + "-key", Provider: "warm", Status: StatusActive, Attributes: map[string]string{AttributeAPIKey: "sk-test"}},
+		{ID: t.Name() + "-off", Provider: "warm", Status: StatusDisabled, Disabled: true, Metadata: map[string]any{AttributeAuthKind: AuthKindOAuth}},
+	}
+	for _, auth := range auths {
+		if _, err := m.Register(context.Background(), auth); err != nil {
+			t.Fatalf("register %s: %v", auth.ID, err)
+		}
+		reg.RegisterClient(auth.ID, "warm", []*registry.ModelInfo{{ID: model}})
+	}
+	t.Cleanup(func() {
+		for _, auth := range auths {
+			reg.UnregisterClient(auth.ID)
+		}
+	})
+
+	m.runWarmupSchedule(context.Background(), WarmupSchedule{Provider: "warm", Model: model, Every: time.Hour}, time.Second)
+
+	want := []string{"warm:" + t.Name() + "-a:" + model, "warm:" + t.Name() + "-b:" + model}
+	if len(exec.executeCalls) != len(want) {
+		t.Fatalf("execute calls = %v, want %v", exec.executeCalls, want)
+	}
+	for i := range want {
+		if exec.executeCalls[i] != want[i] {
+			t.Fatalf("execute calls = %v, want %v", exec.executeCalls, want)
+		}
+	}
+	if stats := m.BackgroundLane().Stats(); stats.Completed != 2 {
+		t.Fatalf("background lane completed = %d, want 2", stats.Completed)
+	}
+}
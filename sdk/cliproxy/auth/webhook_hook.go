@@ -0,0 +1,369 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Lifecycle events delivered by WebhookHook.
+const (
+	WebhookEventRegistered    = "registered"
+	WebhookEventRefreshed     = "refreshed"
+	WebhookEventRefreshFailed = "refresh_failed"
+	WebhookEventBlocked       = "blocked"
+	WebhookEventRecovered     = "recovered"
+)
+
+const (
+	// DefaultWebhookTimeout bounds a single webhook POST when unset.
+	DefaultWebhookTimeout = 10 * time.Second
+	webhookQueueSize      = 256
+)
+
+// WebhookHookOptions configures a WebhookHook.
+type WebhookHookOptions struct {
+	// URL receives one JSON POST per event.
+	URL string
+	// Headers are added to every request (e.g. an Authorization token for the receiver).
+	Headers map[string]string
+	// Timeout bounds a single POST (<= 0 uses DefaultWebhookTimeout).
+	Timeout time.Duration
+	// Events limits delivery to these event names; empty delivers every event.
+	Events []string
+	// Lookup returns the current auth state. It is required for blocked/recovered events
+	// and is filled in by Manager.SetWebhookHook when nil.
+	Lookup func(id string) (*Auth, bool)
+	// Client overrides the HTTP client used for delivery.
+	Client *http.Client
+}
+
+// WebhookEvent is the JSON body POSTed for each lifecycle event.
+type WebhookEvent struct {
+	Event          string     `json:"event"`
+	Timestamp      time.Time  `json:"timestamp"`
+	AuthID         string     `json:"auth_id"`
+	AuthIndex      string     `json:"auth_index,omitempty"`
+	Provider       string     `json:"provider,omitempty"`
+	Label          string     `json:"label,omitempty"`
+	Model          string     `json:"model,omitempty"`
+	Status         Status     `json:"status,omitempty"`
+	StatusMessage  string     `json:"status_message,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	HTTPStatus     int        `json:"http_status,omitempty"`
+	NextRetryAfter *time.Time `json:"next_retry_after,omitempty"`
+}
+
+// WebhookHook is a Hook that POSTs auth lifecycle events (registered, refreshed,
+// refresh_failed, blocked, recovered) to a URL for alerting integrations. Delivery is
+// asynchronous and best effort: events are dropped when the queue is full.
+type WebhookHook struct {
+	NoopHook
+
+	opts   WebhookHookOptions
+	client *http.Client
+	events map[string]struct{}
+
+	queue chan WebhookEvent
+	stop  chan struct{}
+	done  chan struct{}
+	once  sync.Once
+
+	mu      sync.Mutex
+	blocked map[string]struct{}
+}
+
+// NewWebhookHook creates a webhook hook and starts its delivery goroutine.
+func NewWebhookHook(opts WebhookHookOptions) *WebhookHook {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultWebhookTimeout
+	}
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{Timeout: opts.Timeout}
+	}
+	h := &WebhookHook{
+		opts:    opts,
+		client:  client,
+		queue:   make(chan WebhookEvent, webhookQueueSize),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		blocked: make(map[string]struct{}),
+	}
+	if len(opts.Events) > 0 {
+		h.events = make(map[string]struct{}, len(opts.Events))
+		for _, event := range opts.Events {
+			if event = strings.ToLower(strings.TrimSpace(event)); event != "" {
+				h.events[event] = struct{}{}
+			}
+		}
+	}
+	go h.run()
+	return h
+}
+
+// URL returns the configured webhook URL.
+func (h *WebhookHook) URL() string {
+	if h == nil {
+		return ""
+	}
+	return h.opts.URL
+}
+
+// Close stops delivery after the queued events are sent.
+func (h *WebhookHook) Close() {
+	if h == nil {
+		return
+	}
+	h.once.Do(func() { close(h.stop) })
+	<-h.done
+}
+
+// OnAuthRegistered implements Hook.
+func (h *WebhookHook) OnAuthRegistered(_ context.Context, auth *Auth) {
+	h.emit(WebhookEventRegistered, auth, "", nil)
+}
+
+// OnAuthUpdated implements Hook. It reports recoveries caused by cleared cooldowns.
+func (h *WebhookHook) OnAuthUpdated(_ context.Context, auth *Auth) {
+	if h == nil || auth == nil {
+		return
+	}
+	now := time.Now()
+	for _, model := range h.blockedModels(auth.ID) {
+		if !authBlocked(auth, model, now) {
+			h.markRecovered(auth, model)
+		}
+	}
+}
+
+// OnResult implements Hook. Failed results that left the auth cooling down emit
+// "blocked"; the next success for a blocked auth/model emits "recovered".
+func (h *WebhookHook) OnResult(_ context.Context, result Result) {
+	if h == nil || result.AuthID == "" {
+		return
+	}
+	key := webhookBlockKey(result.AuthID, result.Model)
+	if result.Success {
+		h.mu.Lock()
+		_, wasBlocked := h.blocked[key]
+		h.mu.Unlock()
+		if wasBlocked {
+			auth, _ := h.lookup(result.AuthID)
+			if auth == nil {
+				auth = &Auth{ID: result.AuthID, Provider: result.Provider}
+			}
+			h.markRecovered(auth, result.Model)
+		}
+		return
+	}
+	auth, ok := h.lookup(result.AuthID)
+	if !ok || auth == nil || !authBlocked(auth, result.Model, time.Now()) {
+		return
+	}
+	h.mu.Lock()
+	_, wasBlocked := h.blocked[key]
+	h.blocked[key] = struct{}{}
+	h.mu.Unlock()
+	if !wasBlocked {
+		h.emit(WebhookEventBlocked, auth, result.Model, result.Error)
+	}
+}
+
+// OnRefreshSucceeded implements Hook.
+func (h *WebhookHook) OnRefreshSucceeded(_ context.Context, auth *Auth) {
+	h.emit(WebhookEventRefreshed, auth, "", nil)
+}
+
+// OnRefreshFailed implements Hook.
+func (h *WebhookHook) OnRefreshFailed(_ context.Context, auth *Auth, err error) {
+	h.emit(WebhookEventRefreshFailed, auth, "", resultErrorFromError(err))
+}
+
+func (h *WebhookHook) lookup(id string) (*Auth, bool) {
+	if h.opts.Lookup == nil {
+		return nil, false
+	}
+	return h.opts.Lookup(id)
+}
+
+func (h *WebhookHook) blockedModels(authID string) []string {
+	prefix := authID + "|"
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var models []string
+	for key := range h.blocked {
+		if strings.HasPrefix(key, prefix) {
+			models = append(models, strings.TrimPrefix(key, prefix))
+		}
+	}
+	return models
+}
+
+func (h *WebhookHook) markRecovered(auth *Auth, model string) {
+	key := webhookBlockKey(auth.ID, model)
+	h.mu.Lock()
+	_, wasBlocked := h.blocked[key]
+	delete(h.blocked, key)
+	h.mu.Unlock()
+	if wasBlocked {
+		h.emit(WebhookEventRecovered, auth, model, nil)
+	}
+}
+
+func webhookBlockKey(authID, model string) string {
+	return authID + "|" + model
+}
+
+// authBlocked reports whether auth (or its model state when model is set) is cooling down.
+func authBlocked(auth *Auth, model string, now time.Time) bool {
+	if model != "" {
+		if state := auth.ModelStates[model]; state != nil {
+			return state.Unavailable && state.NextRetryAfter.After(now)
+		}
+	}
+	return auth.Unavailable && auth.NextRetryAfter.After(now)
+}
+
+func (h *WebhookHook) emit(event string, auth *Auth, model string, errInfo *Error) {
+	if h == nil || auth == nil {
+		return
+	}
+	if h.events != nil {
+		if _, ok := h.events[event]; !ok {
+			return
+		}
+	}
+	auth.EnsureIndex()
+	payload := WebhookEvent{
+		Event:         event,
+		Timestamp:     time.Now().UTC(),
+		AuthID:        auth.ID,
+		AuthIndex:     auth.Index,
+		Provider:      auth.Provider,
+		Label:         auth.Label,
+		Model:         model,
+		Status:        auth.Status,
+		StatusMessage: auth.StatusMessage,
+	}
+	if errInfo != nil {
+		payload.Error = errInfo.Message
+		payload.HTTPStatus = errInfo.HTTPStatus
+	}
+	retryAfter := auth.NextRetryAfter
+	if state := auth.ModelStates[model]; model != "" && state != nil {
+		retryAfter = state.NextRetryAfter
+	}
+	if event == WebhookEventBlocked && !retryAfter.IsZero() {
+		utc := retryAfter.UTC()
+		payload.NextRetryAfter = &utc
+	}
+	select {
+	case <-h.stop:
+	case h.queue <- payload:
+	default:
+		log.Warnf("auth webhook: queue full, dropping %s event for %s", event, auth.ID)
+	}
+}
+
+func (h *WebhookHook) run() {
+	defer close(h.done)
+	for {
+		select {
+		case event := <-h.queue:
+			h.deliver(event)
+		case <-h.stop:
+			for {
+				select {
+				case event := <-h.queue:
+					h.deliver(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+func (h *WebhookHook) deliver(event WebhookEvent) {
+	body, errMarshal := json.Marshal(event)
+	if errMarshal != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), h.opts.Timeout)
+	defer cancel()
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, h.opts.URL, bytes.NewReader(body))
+	if errReq != nil {
+		log.Warnf("auth webhook: build request: %v", errReq)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range h.opts.Headers {
+		req.Header.Set(key, value)
+	}
+	resp, errDo := h.client.Do(req)
+	if errDo != nil {
+		log.Warnf("auth webhook: deliver %s event for %s: %v", event.Event, event.AuthID, errDo)
+		return
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		log.Warnf("auth webhook: deliver %s event for %s: status %d", event.Event, event.AuthID, resp.StatusCode)
+	}
+}
+
+// SetWebhookHook installs (or with nil, removes) the lifecycle webhook. The previous
+// webhook is closed.
+func (m *Manager) SetWebhookHook(hook *WebhookHook) {
+	if m == nil {
+		return
+	}
+	if hook != nil && hook.opts.Lookup == nil {
+		hook.opts.Lookup = m.GetByID
+	}
+	previous := m.webhookHook.Swap(hook)
+	if previous != nil && previous != hook {
+		previous.Close()
+	}
+}
+
+func (m *Manager) notifyAuthRegistered(ctx context.Context, auth *Auth) {
+	m.callHook("OnAuthRegistered", func() { m.hook.OnAuthRegistered(ctx, auth.Clone()) })
+	if webhook := m.webhookHook.Load(); webhook != nil {
+		webhook.OnAuthRegistered(ctx, auth.Clone())
+	}
+}
+
+func (m *Manager) notifyAuthUpdated(ctx context.Context, auth *Auth) {
+	m.callHook("OnAuthUpdated", func() { m.hook.OnAuthUpdated(ctx, auth.Clone()) })
+	if webhook := m.webhookHook.Load(); webhook != nil {
+		webhook.OnAuthUpdated(ctx, auth.Clone())
+	}
+}
+
+func (m *Manager) notifyResult(ctx context.Context, result Result) {
+	m.dispatchResultHook(ctx, result)
+	if webhook := m.webhookHook.Load(); webhook != nil {
+		webhook.OnResult(ctx, result)
+	}
+}
+
+func (m *Manager) notifyRefreshSucceeded(ctx context.Context, auth *Auth) {
+	m.callHook("OnRefreshSucceeded", func() { m.hook.OnRefreshSucceeded(ctx, auth.Clone()) })
+	if webhook := m.webhookHook.Load(); webhook != nil {
+		webhook.OnRefreshSucceeded(ctx, auth.Clone())
+	}
+}
+
+func (m *Manager) notifyRefreshFailed(ctx context.Context, auth *Auth, err error) {
+	m.callHook("OnRefreshFailed", func() { m.hook.OnRefreshFailed(ctx, auth.Clone(), err) })
+	if webhook := m.webhookHook.Load(); webhook != nil {
+		webhook.OnRefreshFailed(ctx, auth.Clone(), err)
+	}
+}
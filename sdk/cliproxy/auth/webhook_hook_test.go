@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestWebhookHook_DeliversLifecycleEvents(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []WebhookEvent
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer hook" {
+			t.Errorf("Authorization = %q, want Bearer hook", got)
+		}
+		var event WebhookEvent
+		if errDecode := json.NewDecoder(r.Body).Decode(&event); errDecode != nil {
+			t.Errorf("decode event: %v", errDecode)
+		}
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	m := NewManager(nil, nil, nil)
+	m.RegisterExecutor(&unauthorizedRefreshExecutor{id: "hooked"})
+	hook := NewWebhookHook(WebhookHookOptions{URL: server.URL, Headers: map[string]string{"Authorization": "Bearer hook"}})
+	m.SetWebhookHook(hook)
+
+	ctx := context.Background()
+	if _, err := m.Register(ctx, &Auth{ID: "hooked-auth", Provider: "hooked", Status: StatusActive}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, err := m.refreshAuthForRequest(ctx, "hooked-auth", ""); err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	m.MarkResult(ctx, Result{AuthID: "hooked-auth", Provider: "hooked", Model: "m1", Error: &Error{HTTPStatus: http.StatusTooManyRequests, Message: "slow down"}})
+	m.MarkResult(ctx, Result{AuthID: "hooked-auth", Provider: "hooked", Model: "m1", Error: &Error{HTTPStatus: http.StatusTooManyRequests, Message: "slow down"}})
+	m.MarkResult(ctx, Result{AuthID: "hooked-auth", Provider: "hooked", Model: "m1", Success: true})
+	m.SetWebhookHook(nil)
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{WebhookEventRegistered, WebhookEventRefreshed, WebhookEventBlocked, WebhookEventRecovered}
+	if len(events) != len(want) {
+		t.Fatalf("events = %+v, want %v", events, want)
+	}
+	for i, event := range events {
+		if event.Event != want[i] || event.AuthID != "hooked-auth" {
+			t.Fatalf("event[%d] = %+v, want %s for hooked-auth", i, event, want[i])
+		}
+	}
+	blocked := events[2]
+	if blocked.Model != "m1" || blocked.HTTPStatus != http.StatusTooManyRequests || blocked.NextRetryAfter == nil {
+		t.Fatalf("blocked event = %+v, want model m1, status 429 and next_retry_after", blocked)
+	}
+}
+
+func TestWebhookHook_EventFilter(t *testing.T) {
+	hook := NewWebhookHook(WebhookHookOptions{URL: "http://127.0.0.1:0", Events: []string{"Blocked"}})
+	defer hook.Close()
+	hook.OnAuthRegistered(context.Background(), &Auth{ID: "filtered"})
+	if got := len(hook.queue); got != 0 {
+		t.Fatalf("queued events = %d, want 0", got)
+	}
+}
@@ -14,6 +14,7 @@ import (
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v7/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
 )
 
@@ -56,6 +57,18 @@ type Builder struct {
 
 	// serverOptions contains additional server configuration options.
 	serverOptions []api.ServerOption
+
+	// selector overrides the config-driven routing selector when set.
+	selector coreauth.Selector
+
+	// store overrides the default token store used by the core manager.
+	store coreauth.Store
+
+	// coreHook receives core auth manager lifecycle events.
+	coreHook coreauth.Hook
+
+	// configOverrides are reapplied to every loaded or reloaded configuration.
+	configOverrides []func(*config.Config)
 }
 
 // Hooks allows callers to plug into service lifecycle stages.
@@ -152,6 +165,13 @@ func (b *Builder) WithPluginHost(host *pluginhost.Host) *Builder {
 	return b
 }
 
+// WithTransformMiddleware registers middlewares that rewrite requests before translation and
+// filter responses after executor output, for both streaming and non-streaming requests.
+func (b *Builder) WithTransformMiddleware(middlewares ...TransformMiddleware) *Builder {
+	b.serverOptions = append(b.serverOptions, api.WithTransformMiddleware(middlewares...))
+	return b
+}
+
 // WithServerOptions appends server configuration options used during construction.
 func (b *Builder) WithServerOptions(opts ...api.ServerOption) *Builder {
 	b.serverOptions = append(b.serverOptions, opts...)
@@ -177,6 +197,59 @@ func (b *Builder) WithPostAuthHook(hook coreauth.PostAuthHook) *Builder {
 	return b
 }
 
+// WithSelector pins the credential selector used by the core manager.
+// A pinned selector is kept across config reloads; routing.strategy is ignored.
+//
+// Parameters:
+//   - selector: The selector implementation
+//
+// Returns:
+//   - *Builder: The builder instance for method chaining
+func (b *Builder) WithSelector(selector coreauth.Selector) *Builder {
+	b.selector = selector
+	return b
+}
+
+// WithStore sets the auth persistence backend used when the builder creates the core manager.
+//
+// Parameters:
+//   - store: The auth store implementation
+//
+// Returns:
+//   - *Builder: The builder instance for method chaining
+func (b *Builder) WithStore(store coreauth.Store) *Builder {
+	b.store = store
+	return b
+}
+
+// WithCoreHook registers a hook for core auth manager events (registration, updates, results).
+// It is only used when the builder creates the core manager.
+//
+// Parameters:
+//   - hook: The hook implementation
+//
+// Returns:
+//   - *Builder: The builder instance for method chaining
+func (b *Builder) WithCoreHook(hook coreauth.Hook) *Builder {
+	b.coreHook = hook
+	return b
+}
+
+// WithConfigOverride registers a function applied to the initial configuration and
+// to every configuration reloaded from disk. Overrides must be idempotent.
+//
+// Parameters:
+//   - override: The function that adjusts the configuration in place
+//
+// Returns:
+//   - *Builder: The builder instance for method chaining
+func (b *Builder) WithConfigOverride(override func(*config.Config)) *Builder {
+	if override != nil {
+		b.configOverrides = append(b.configOverrides, override)
+	}
+	return b
+}
+
 // Build validates inputs, applies defaults, and returns a ready-to-run service.
 func (b *Builder) Build() (*Service, error) {
 	if b.cfg == nil {
@@ -185,6 +258,7 @@ func (b *Builder) Build() (*Service, error) {
 	if b.configPath == "" {
 		return nil, fmt.Errorf("cliproxy: configuration path is required")
 	}
+	applyConfigOverrides(b.cfg, b.configOverrides)
 	b.cfg.NormalizePluginsConfig()
 	if errResolvePluginsDir := b.cfg.ResolvePluginsDir(); errResolvePluginsDir != nil && b.cfg.Plugins.Enabled {
 		return nil, fmt.Errorf("cliproxy: %w", errResolvePluginsDir)
@@ -228,16 +302,29 @@ func (b *Builder) Build() (*Service, error) {
 
 	coreManager := b.coreManager
 	var appliedRoutingState *routingRuntimeState
+	applyPricingConfig(b.cfg)
+	applyOutboundTLSConfig(b.cfg)
+	applyProxyPoolConfig(b.cfg)
 	if coreManager == nil {
-		tokenStore := sdkAuth.GetTokenStore()
-		if dirSetter, ok := tokenStore.(interface{ SetBaseDir(string) }); ok && b.cfg != nil {
-			dirSetter.SetBaseDir(b.cfg.AuthDir)
+		var tokenStore coreauth.Store = b.store
+		if tokenStore == nil {
+			defaultStore := sdkAuth.GetTokenStore()
+			if dirSetter, ok := defaultStore.(interface{ SetBaseDir(string) }); ok && b.cfg != nil {
+				dirSetter.SetBaseDir(b.cfg.AuthDir)
+			}
+			tokenStore = defaultStore
 		}
 
-		routingState := normalizedRoutingRuntimeState(b.cfg)
-		coreManager = coreauth.NewManager(tokenStore, newRoutingSelector(routingState), nil)
-		appliedRoutingState = &routingState
+		coreManager = coreauth.NewManager(tokenStore, b.selector, b.coreHook)
+		if b.selector == nil {
+			routingState := normalizedRoutingRuntimeState(b.cfg)
+			coreManager.SetSelector(newRoutingSelector(routingState, coreManager.CostSpend()))
+			appliedRoutingState = &routingState
+		}
+	} else if b.selector != nil {
+		coreManager.SetSelector(b.selector)
 	}
+	usage.RegisterNamedPlugin(costSelectorUsagePluginName, coreManager.CostSpend())
 	// Attach a default RoundTripper provider so providers can opt-in per-auth transports.
 	coreManager.SetRoundTripperProvider(newDefaultRoundTripperProvider())
 	coreManager.SetConfig(b.cfg)
@@ -258,6 +345,8 @@ func (b *Builder) Build() (*Service, error) {
 		coreManager:         coreManager,
 		pluginHost:          pluginHost,
 		appliedRoutingState: appliedRoutingState,
+		selectorPinned:      b.selector != nil,
+		configOverrides:     append([]func(*config.Config){}, b.configOverrides...),
 		serverOptions:       append([]api.ServerOption(nil), b.serverOptions...),
 	}
 	if b.postAuthHook != nil {
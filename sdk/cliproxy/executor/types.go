@@ -34,6 +34,18 @@ const GenerateMetadataKey = "generate"
 const (
 	// PinnedAuthMetadataKey locks execution to a specific auth ID.
 	PinnedAuthMetadataKey = "pinned_auth_id"
+	// ExcludedAuthsMetadataKey lists auth IDs ([]string) that must not be selected.
+	ExcludedAuthsMetadataKey = "excluded_auth_ids"
+	// AuthGroupsMetadataKey lists the auth groups ([]string) allowed to serve the request.
+	AuthGroupsMetadataKey = "auth_groups"
+	// NoFallbackMetadataKey disables fallback to other models when the requested model fails.
+	NoFallbackMetadataKey = "no_fallback"
+	// RequestPriorityMetadataKey stores the priority class ("interactive", "normal", "batch")
+	// used to order requests waiting for an upstream slot.
+	RequestPriorityMetadataKey = "request_priority"
+	// RequestDeadlineMetadataKey stores the overall deadline (time.Time) after which no further
+	// retries, cooldown waits or fallbacks are started for the request.
+	RequestDeadlineMetadataKey = "request_deadline"
 	// EstimatedInputTokensMetadataKey stores a preflight estimated input token count.
 	EstimatedInputTokensMetadataKey = "estimated_input_tokens"
 	// SelectedAuthMetadataKey stores the auth ID selected by the scheduler.
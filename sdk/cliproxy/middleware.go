@@ -0,0 +1,11 @@
+package cliproxy
+
+import "github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+
+// TransformMiddleware re-exports the handler transformation middleware interface. Request
+// mutators see the client payload before translation; response filters see translated
+// executor output for non-streaming responses and for every stream chunk.
+type TransformMiddleware = handlers.TransformMiddleware
+
+// TransformFuncs re-exports the function adapter for TransformMiddleware.
+type TransformFuncs = handlers.TransformFuncs
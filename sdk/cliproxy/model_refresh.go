@@ -0,0 +1,174 @@
+package cliproxy
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// defaultModelRefreshTTL is how long a dynamic model list is trusted when model-refresh.ttl is unset.
+const defaultModelRefreshTTL = time.Hour
+
+// minModelRefreshTTL keeps a misconfigured TTL from hammering provider model endpoints.
+const minModelRefreshTTL = time.Minute
+
+// defaultModelRefreshProviders are the providers whose model lists are fetched at runtime.
+var defaultModelRefreshProviders = []string{"kilo", "cursor", "github-copilot"}
+
+// applyModelRefreshConfig starts, restarts, or stops the dynamic model list refresher when
+// the model-refresh settings change.
+func (s *Service) applyModelRefreshConfig(cfg *config.Config) {
+	if s == nil || s.coreManager == nil || cfg == nil {
+		return
+	}
+	if !cfg.ModelRefresh.Enabled {
+		if s.appliedModelRefresh != nil {
+			s.stopModelRefresh()
+			s.appliedModelRefresh = nil
+			log.Info("model refresh stopped")
+		}
+		return
+	}
+	if s.appliedModelRefresh != nil && reflect.DeepEqual(*s.appliedModelRefresh, cfg.ModelRefresh) {
+		return
+	}
+	s.stopModelRefresh()
+	ttl := modelRefreshTTL(cfg.ModelRefresh.TTL)
+	providers := modelRefreshProviders(cfg.ModelRefresh.Providers)
+	ctx, cancel := context.WithCancel(context.Background())
+	s.modelRefreshCancel = cancel
+	go s.runModelRefresh(ctx, ttl, providers)
+	settings := cfg.ModelRefresh
+	settings.Providers = append([]string(nil), cfg.ModelRefresh.Providers...)
+	s.appliedModelRefresh = &settings
+	log.Infof("model refresh started (ttl %s, providers %s)", ttl, strings.Join(sortedKeys(providers), ", "))
+}
+
+func (s *Service) stopModelRefresh() {
+	if s.modelRefreshCancel != nil {
+		s.modelRefreshCancel()
+		s.modelRefreshCancel = nil
+	}
+}
+
+func (s *Service) runModelRefresh(ctx context.Context, ttl time.Duration, providers map[string]struct{}) {
+	ticker := time.NewTicker(ttl)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for _, auth := range s.coreManager.List() {
+			if ctx.Err() != nil {
+				return
+			}
+			if auth == nil || auth.Disabled {
+				continue
+			}
+			if _, ok := providers[strings.ToLower(strings.TrimSpace(auth.Provider))]; !ok {
+				continue
+			}
+			s.refreshModelsForAuth(ctx, auth)
+		}
+	}
+}
+
+// refreshModelsForAuth fetches the model list of one auth again and reports what changed.
+// Removed models that no client serves anymore are checked against the OAuth model aliases
+// so a dangling alias is flagged instead of failing silently at request time.
+func (s *Service) refreshModelsForAuth(ctx context.Context, auth *coreauth.Auth) {
+	reg := registry.GetGlobalRegistry()
+	before := modelIDSet(reg.GetModelsForClient(auth.ID))
+	s.registerModelsForAuth(ctx, auth)
+	after := modelIDSet(reg.GetModelsForClient(auth.ID))
+
+	var added, removed []string
+	for id := range after {
+		if _, ok := before[id]; !ok {
+			added = append(added, id)
+		}
+	}
+	for id := range before {
+		if _, ok := after[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	log.Infof("model refresh: %s (%s) models changed: added %v, removed %v", auth.ID, auth.Provider, added, removed)
+
+	s.cfgMu.RLock()
+	cfg := s.cfg
+	s.cfgMu.RUnlock()
+	if cfg == nil || len(removed) == 0 {
+		return
+	}
+	aliases := cfg.OAuthModelAlias[strings.ToLower(strings.TrimSpace(auth.Provider))]
+	for _, id := range removed {
+		if len(reg.ClientsForModel(id)) > 0 {
+			continue
+		}
+		for _, alias := range aliases {
+			if strings.EqualFold(strings.TrimSpace(alias.Name), id) {
+				log.Warnf("model refresh: oauth-model-alias %s: %q -> %q targets a model no %s auth serves anymore", auth.Provider, alias.Alias, alias.Name, auth.Provider)
+			}
+		}
+	}
+}
+
+func modelIDSet(models []*ModelInfo) map[string]struct{} {
+	out := make(map[string]struct{}, len(models))
+	for _, model := range models {
+		if model != nil && model.ID != "" {
+			out[model.ID] = struct{}{}
+		}
+	}
+	return out
+}
+
+func modelRefreshTTL(raw string) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return defaultModelRefreshTTL
+	}
+	ttl, errParse := time.ParseDuration(raw)
+	if errParse != nil || ttl < minModelRefreshTTL {
+		log.Warnf("invalid model-refresh.ttl %q (minimum %s), using %s", raw, minModelRefreshTTL, defaultModelRefreshTTL)
+		return defaultModelRefreshTTL
+	}
+	return ttl
+}
+
+func modelRefreshProviders(configured []string) map[string]struct{} {
+	if len(configured) == 0 {
+		configured = defaultModelRefreshProviders
+	}
+	out := make(map[string]struct{}, len(configured))
+	for _, provider := range configured {
+		if provider = strings.ToLower(strings.TrimSpace(provider)); provider != "" {
+			out[provider] = struct{}{}
+		}
+	}
+	return out
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	out := make([]string, 0, len(set))
+	for key := range set {
+		out = append(out, key)
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,56 @@
+package cliproxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestModelRefreshTTL(t *testing.T) {
+	cases := map[string]time.Duration{
+		"":     defaultModelRefreshTTL,
+		"30m":  30 * time.Minute,
+		"10s":  defaultModelRefreshTTL,
+		"soon": defaultModelRefreshTTL,
+	}
+	for raw, want := range cases {
+		if got := modelRefreshTTL(raw); got != want {
+			t.Errorf("modelRefreshTTL(%q) = %s, want %s", raw, got, want)
+		}
+	}
+}
+
+func TestModelRefreshProvidersDefaults(t *testing.T) {
+	got := modelRefreshProviders(nil)
+	for _, provider := range defaultModelRefreshProviders {
+		if _, ok := got[provider]; !ok {
+			t.Fatalf("default providers missing %s: %v", provider, got)
+		}
+	}
+	got = modelRefreshProviders([]string{" Kilo ", ""})
+	if _, ok := got["kilo"]; !ok || len(got) != 1 {
+		t.Fatalf("providers = %v, want only kilo", got)
+	}
+}
+
+func TestApplyModelRefreshConfigStartsAndStops(t *testing.T) {
+	s := &Service{coreManager: coreauth.NewManager(nil, nil, nil)}
+	cfg := &config.Config{ModelRefresh: config.ModelRefreshConfig{Enabled: true, TTL: "1h"}}
+
+	s.applyModelRefreshConfig(cfg)
+	if s.appliedModelRefresh == nil || s.modelRefreshCancel == nil {
+		t.Fatal("refresher not started")
+	}
+	applied := s.appliedModelRefresh
+	s.applyModelRefreshConfig(cfg)
+	if s.appliedModelRefresh != applied {
+		t.Fatal("unchanged settings restarted the refresher")
+	}
+
+	s.applyModelRefreshConfig(&config.Config{})
+	if s.appliedModelRefresh != nil || s.modelRefreshCancel != nil {
+		t.Fatal("refresher not stopped")
+	}
+}
@@ -8,6 +8,9 @@ type ModelInfo = registry.ModelInfo
 // ModelRegistryHook re-exports the registry hook interface for external integrations.
 type ModelRegistryHook = registry.ModelRegistryHook
 
+// ModelChangeHook re-exports the optional hook extension notified when a client's model set changes.
+type ModelChangeHook = registry.ModelChangeHook
+
 // ModelRegistry describes registry operations consumed by external callers.
 type ModelRegistry interface {
 	RegisterClient(clientID, clientProvider string, models []*ModelInfo)
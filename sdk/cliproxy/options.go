@@ -0,0 +1,244 @@
+package cliproxy
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/api"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+// DefaultConfigFile is the configuration file NewServer loads when WithConfigFile is not used.
+const DefaultConfigFile = "config.yaml"
+
+// Option configures a Service constructed by NewServer.
+//
+// Options are the stable embedding surface: an Option's name and meaning are kept
+// across minor releases even when the underlying configuration schema changes.
+// Options that adjust configuration values are reapplied on every hot reload, so
+// programmatic settings always win over the configuration file.
+type Option func(*serverSettings)
+
+type serverSettings struct {
+	configFile   string
+	builder      *Builder
+	usagePlugins []usage.Plugin
+}
+
+// NewServer builds a Service from functional options.
+// The configuration file is optional: when it does not exist the service starts from
+// defaults and the options supply every setting.
+//
+// Example:
+//
+//	svc, err := cliproxy.NewServer(
+//		cliproxy.WithConfigFile("/etc/cliproxy/config.yaml"),
+//		cliproxy.WithPort(8317),
+//		cliproxy.WithRetry(3, 30*time.Second),
+//		cliproxy.WithFallbackModels(map[string]string{"gpt-5": "gpt-5-mini"}),
+//	)
+func NewServer(opts ...Option) (*Service, error) {
+	settings := &serverSettings{
+		configFile: DefaultConfigFile,
+		builder:    NewBuilder(),
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(settings)
+		}
+	}
+
+	configFile := strings.TrimSpace(settings.configFile)
+	if absolute, errAbs := filepath.Abs(configFile); errAbs == nil {
+		configFile = absolute
+	}
+	cfg, errLoad := config.LoadConfigOptional(configFile, true)
+	if errLoad != nil {
+		return nil, fmt.Errorf("cliproxy: load config: %w", errLoad)
+	}
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+
+	service, errBuild := settings.builder.WithConfig(cfg).WithConfigPath(configFile).Build()
+	if errBuild != nil {
+		return nil, errBuild
+	}
+	for _, plugin := range settings.usagePlugins {
+		service.RegisterUsagePlugin(plugin)
+	}
+	return service, nil
+}
+
+// WithConfigFile sets the configuration file loaded at startup and watched for hot reloads.
+func WithConfigFile(path string) Option {
+	return func(s *serverSettings) {
+		if path = strings.TrimSpace(path); path != "" {
+			s.configFile = path
+		}
+	}
+}
+
+// WithConfigOverride adjusts the loaded configuration in place. The function is
+// reapplied on every reload and must be idempotent. Prefer the typed options below;
+// this is an escape hatch for settings without a dedicated Option.
+func WithConfigOverride(override func(*config.Config)) Option {
+	return func(s *serverSettings) {
+		s.builder.WithConfigOverride(override)
+	}
+}
+
+// WithHost sets the address the HTTP server binds to ("" binds all interfaces).
+func WithHost(host string) Option {
+	return WithConfigOverride(func(cfg *config.Config) {
+		cfg.Host = host
+	})
+}
+
+// WithPort sets the HTTP server port.
+func WithPort(port int) Option {
+	return WithConfigOverride(func(cfg *config.Config) {
+		cfg.Port = port
+	})
+}
+
+// WithAuthDir sets the directory holding credential files.
+func WithAuthDir(dir string) Option {
+	return WithConfigOverride(func(cfg *config.Config) {
+		cfg.AuthDir = dir
+	})
+}
+
+// WithAPIKeys sets the client API keys accepted by the proxy.
+func WithAPIKeys(keys ...string) Option {
+	keys = append([]string(nil), keys...)
+	return WithConfigOverride(func(cfg *config.Config) {
+		cfg.APIKeys = append([]string(nil), keys...)
+	})
+}
+
+// WithRetry sets how many times a failed request is retried and the longest
+// time to wait for a cooled-down credential before retrying.
+func WithRetry(attempts int, maxInterval time.Duration) Option {
+	return WithConfigOverride(func(cfg *config.Config) {
+		cfg.RequestRetry = attempts
+		cfg.MaxRetryInterval = int(maxInterval / time.Second)
+	})
+}
+
+// WithMaxRetryCredentials limits how many credentials a single request may try (0 = unlimited).
+func WithMaxRetryCredentials(limit int) Option {
+	return WithConfigOverride(func(cfg *config.Config) {
+		cfg.MaxRetryCredentials = limit
+	})
+}
+
+// WithFallbackModels maps requested models to the model retried when every credential fails.
+func WithFallbackModels(models map[string]string) Option {
+	copied := make(map[string]string, len(models))
+	for from, to := range models {
+		copied[from] = to
+	}
+	return WithConfigOverride(func(cfg *config.Config) {
+		cfg.Routing.FallbackModels = make(map[string]string, len(copied))
+		for from, to := range copied {
+			cfg.Routing.FallbackModels[from] = to
+		}
+	})
+}
+
+// WithFallbackChain sets the general fallback chain and its maximum depth (0 keeps the default).
+func WithFallbackChain(maxDepth int, models ...string) Option {
+	models = append([]string(nil), models...)
+	return WithConfigOverride(func(cfg *config.Config) {
+		cfg.Routing.FallbackChain = append([]string(nil), models...)
+		cfg.Routing.FallbackMaxDepth = maxDepth
+	})
+}
+
+// WithRoutingStrategy selects a built-in routing strategy by name
+// ("round-robin", "fill-first", "weight-robin", "cost").
+func WithRoutingStrategy(strategy string) Option {
+	return WithConfigOverride(func(cfg *config.Config) {
+		cfg.Routing.Strategy = strategy
+	})
+}
+
+// WithSelector installs a custom credential selector. It takes precedence over
+// WithRoutingStrategy and is kept across config reloads.
+func WithSelector(selector coreauth.Selector) Option {
+	return func(s *serverSettings) {
+		s.builder.WithSelector(selector)
+	}
+}
+
+// WithStore sets the backend used to persist credentials.
+func WithStore(store coreauth.Store) Option {
+	return func(s *serverSettings) {
+		s.builder.WithStore(store)
+	}
+}
+
+// WithCoreAuthManager supplies a pre-built core auth manager.
+// WithStore and WithAuthHook are ignored when a manager is supplied.
+func WithCoreAuthManager(manager *coreauth.Manager) Option {
+	return func(s *serverSettings) {
+		s.builder.WithCoreAuthManager(manager)
+	}
+}
+
+// WithAuthHook registers a hook for credential registration, update, and result events.
+func WithAuthHook(hook coreauth.Hook) Option {
+	return func(s *serverSettings) {
+		s.builder.WithCoreHook(hook)
+	}
+}
+
+// WithPostAuthHook registers a hook called after an auth record is created and before it is persisted.
+func WithPostAuthHook(hook coreauth.PostAuthHook) Option {
+	return func(s *serverSettings) {
+		s.builder.WithPostAuthHook(hook)
+	}
+}
+
+// WithLifecycleHooks registers service start callbacks.
+func WithLifecycleHooks(hooks Hooks) Option {
+	return func(s *serverSettings) {
+		s.builder.WithHooks(hooks)
+	}
+}
+
+// WithUsagePlugin registers a usage plugin that receives a record for every upstream request.
+func WithUsagePlugin(plugin usage.Plugin) Option {
+	return func(s *serverSettings) {
+		if plugin != nil {
+			s.usagePlugins = append(s.usagePlugins, plugin)
+		}
+	}
+}
+
+// WithTransformMiddleware registers request mutators and response filters, e.g. PII
+// redaction, system-prompt injection or stop-sequence enforcement.
+func WithTransformMiddleware(middlewares ...TransformMiddleware) Option {
+	return func(s *serverSettings) {
+		s.builder.WithTransformMiddleware(middlewares...)
+	}
+}
+
+// WithServerOptions passes additional options to the HTTP server.
+func WithServerOptions(opts ...api.ServerOption) Option {
+	return func(s *serverSettings) {
+		s.builder.WithServerOptions(opts...)
+	}
+}
+
+// WithLocalManagementPassword enables the management API for localhost clients with the given password.
+func WithLocalManagementPassword(password string) Option {
+	return func(s *serverSettings) {
+		s.builder.WithLocalManagementPassword(password)
+	}
+}
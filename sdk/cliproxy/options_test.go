@@ -0,0 +1,61 @@
+package cliproxy
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestNewServerAppliesTypedOptions(t *testing.T) {
+	dir := t.TempDir()
+	selector := &coreauth.FillFirstSelector{}
+	svc, err := NewServer(
+		WithConfigFile(filepath.Join(dir, "missing.yaml")),
+		WithPort(18317),
+		WithAuthDir(filepath.Join(dir, "auths")),
+		WithAPIKeys("client-key"),
+		WithRetry(4, 45*time.Second),
+		WithFallbackModels(map[string]string{"gpt-5": "gpt-5-mini"}),
+		WithFallbackChain(2, "model-a", "model-b"),
+		WithSelector(selector),
+		WithStore(nil),
+	)
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	cfg := svc.cfg
+	if cfg.Port != 18317 || cfg.AuthDir != filepath.Join(dir, "auths") {
+		t.Fatalf("port/auth-dir = %d/%q", cfg.Port, cfg.AuthDir)
+	}
+	if len(cfg.APIKeys) != 1 || cfg.APIKeys[0] != "client-key" {
+		t.Fatalf("api keys = %v", cfg.APIKeys)
+	}
+	if cfg.RequestRetry != 4 || cfg.MaxRetryInterval != 45 {
+		t.Fatalf("retry = %d/%d, want 4/45", cfg.RequestRetry, cfg.MaxRetryInterval)
+	}
+	if cfg.Routing.FallbackModels["gpt-5"] != "gpt-5-mini" || cfg.Routing.FallbackMaxDepth != 2 || len(cfg.Routing.FallbackChain) != 2 {
+		t.Fatalf("fallback routing = %+v", cfg.Routing)
+	}
+	if svc.coreManager.Selector() != selector {
+		t.Fatalf("selector not installed")
+	}
+
+	// A reload from disk keeps programmatic settings and the pinned selector.
+	reloaded := &config.Config{Port: 9999}
+	reloaded.Routing.Strategy = "round-robin"
+	commit := svc.commitConfigUpdate(reloaded)
+	if !svc.applyManagerConfig(context.Background(), commit) {
+		t.Fatalf("applyManagerConfig() = false")
+	}
+	if reloaded.Port != 18317 || reloaded.RequestRetry != 4 {
+		t.Fatalf("override not reapplied: port=%d retry=%d", reloaded.Port, reloaded.RequestRetry)
+	}
+	if svc.coreManager.Selector() != selector {
+		t.Fatalf("pinned selector replaced on reload")
+	}
+}
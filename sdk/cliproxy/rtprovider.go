@@ -11,7 +11,9 @@ import (
 )
 
 // defaultRoundTripperProvider returns a per-auth HTTP RoundTripper based on
-// the Auth.ProxyURL value. It caches transports per proxy URL string.
+// the Auth.ProxyURL value, including "pool://<name>" proxy pools, the auth's source address
+// and the outbound TLS configuration of its provider. It caches transports per proxy URL,
+// source address and TLS configuration.
 type defaultRoundTripperProvider struct {
 	mu    sync.RWMutex
 	cache map[string]http.RoundTripper
@@ -27,16 +29,25 @@ func (p *defaultRoundTripperProvider) RoundTripperFor(auth *coreauth.Auth) http.
 		return nil
 	}
 	proxyStr := strings.TrimSpace(auth.ProxyURL)
-	if proxyStr == "" {
+	source := auth.SourceAddress()
+	tlsKey := proxyutil.TLSConfigKey(auth.Provider)
+	if proxyStr == "" && source == "" && tlsKey == "" {
 		return nil
 	}
+	key := proxyStr
+	if source != "" {
+		key += "|" + source
+	}
+	if tlsKey != "" {
+		key += "|" + tlsKey
+	}
 	p.mu.RLock()
-	rt := p.cache[proxyStr]
+	rt := p.cache[key]
 	p.mu.RUnlock()
 	if rt != nil {
 		return rt
 	}
-	transport, _, errBuild := proxyutil.BuildHTTPTransport(proxyStr)
+	transport, _, errBuild := proxyutil.BuildProviderRoundTripper(proxyStr, source, auth.Provider)
 	if errBuild != nil {
 		log.Errorf("%v", errBuild)
 		return nil
@@ -45,7 +56,7 @@ func (p *defaultRoundTripperProvider) RoundTripperFor(auth *coreauth.Auth) http.
 		return nil
 	}
 	p.mu.Lock()
-	p.cache[proxyStr] = transport
+	p.cache[key] = transport
 	p.mu.Unlock()
 	return transport
 }
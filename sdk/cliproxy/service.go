@@ -8,6 +8,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -15,8 +17,10 @@ import (
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/api"
 	kiroauth "github.com/router-for-me/CLIProxyAPI/v7/internal/auth/kiro"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/authexpiry"
 	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/dailycap"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/home"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/homeplugins"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
@@ -24,6 +28,8 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/redisqueue"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/spendalert"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/tenant"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/watcher"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/watcher/diff"
@@ -36,6 +42,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
 	sdkpluginstore "github.com/router-for-me/CLIProxyAPI/v7/sdk/pluginstore"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/proxyutil"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
 	log "github.com/sirupsen/logrus"
 )
@@ -58,6 +65,31 @@ type Service struct {
 	configSequence      uint64
 	appliedRoutingState *routingRuntimeState
 
+	// appliedUsageCounters records the usage counters settings currently installed.
+	appliedUsageCounters *config.UsageCountersConfig
+	// appliedSelectionAudit records the selection audit settings currently installed.
+	appliedSelectionAudit *config.SelectionAuditConfig
+	// appliedHealthProbe records the health probe options currently running, or nil when stopped.
+	appliedHealthProbe *coreauth.HealthProbeOptions
+	// appliedWarmup records the warmup settings currently running, or nil when stopped.
+	appliedWarmup *config.WarmupConfig
+	// appliedAuthPersistence records the auth persistence settings currently installed.
+	appliedAuthPersistence *config.AuthPersistenceConfig
+	// appliedHookDispatch records the hook dispatch settings currently installed.
+	appliedHookDispatch *config.HookDispatchConfig
+	// appliedModelRefresh records the model refresh settings currently running, or nil when stopped.
+	appliedModelRefresh *config.ModelRefreshConfig
+	// modelRefreshCancel stops the running model refresh loop.
+	modelRefreshCancel context.CancelFunc
+	// appliedAuthWebhook records the auth webhook settings currently installed, or nil when off.
+	appliedAuthWebhook *config.AuthWebhookConfig
+
+	// selectorPinned keeps a caller-supplied selector across config reloads.
+	selectorPinned bool
+
+	// configOverrides are programmatic adjustments reapplied to every committed config.
+	configOverrides []func(*config.Config)
+
 	// configPath is the path to the configuration file.
 	configPath string
 
@@ -929,6 +961,297 @@ func (s *Service) applyRetryConfig(cfg *config.Config) {
 	maxInterval := time.Duration(cfg.MaxRetryInterval) * time.Second
 	s.coreManager.SetRetryConfig(cfg.RequestRetry, maxInterval, cfg.MaxRetryCredentials)
 	coreauth.SetTransientErrorCooldownSeconds(cfg.TransientErrorCooldownSeconds)
+	coreauth.SetQuotaCooldownDisabled(cfg.DisableCooling)
+}
+
+// applyUsageCountersConfig installs, replaces, or removes the persistent usage counters
+// on the default usage manager when the usage-counters settings change.
+// Callers must hold configRuntimeMu.
+func (s *Service) applyUsageCountersConfig(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	settings := cfg.UsageCounters
+	if s.appliedUsageCounters != nil && *s.appliedUsageCounters == settings {
+		return
+	}
+	s.appliedUsageCounters = &settings
+	if !settings.Enabled {
+		usage.DefaultManager().SetCounters(nil)
+		return
+	}
+	flushInterval := usage.DefaultCountersFlushInterval
+	if raw := strings.TrimSpace(settings.FlushInterval); raw != "" {
+		if parsed, errParse := time.ParseDuration(raw); errParse == nil && parsed > 0 {
+			flushInterval = parsed
+		} else {
+			log.Warnf("invalid usage-counters.flush-interval %q, using %s", raw, flushInterval)
+		}
+	}
+	// Persist the running totals before the replacement loads the snapshot, otherwise
+	// everything counted since the last flush is lost on reload.
+	if errFlush := usage.DefaultManager().Counters().Flush(); errFlush != nil {
+		log.Warnf("usage counters: %v", errFlush)
+	}
+	counters := usage.NewCounters(usage.CountersOptions{
+		Path:          settings.PersistPath,
+		FlushInterval: flushInterval,
+		RetentionDays: settings.RetentionDays,
+	})
+	counters.StartFlusher()
+	usage.DefaultManager().SetCounters(counters)
+}
+
+// applyHealthProbeConfig starts, restarts, or stops the blocked-auth health probe loop
+// when the health-probe settings change.
+func (s *Service) applyHealthProbeConfig(cfg *config.Config) {
+	if s == nil || s.coreManager == nil || cfg == nil {
+		return
+	}
+	if !cfg.HealthProbe.Enabled || cfg.Home.Enabled {
+		if s.appliedHealthProbe != nil {
+			s.coreManager.StopHealthProbe()
+			s.appliedHealthProbe = nil
+			log.Info("health probe stopped")
+		}
+		return
+	}
+	opts := healthProbeOptions(cfg)
+	if s.appliedHealthProbe != nil && reflect.DeepEqual(*s.appliedHealthProbe, opts) {
+		return
+	}
+	s.coreManager.StartHealthProbe(context.Background(), opts)
+	s.appliedHealthProbe = &opts
+	log.Infof("health probe started (interval=%s, lead=%s)", opts.Interval, opts.Lead)
+}
+
+// healthProbeOptions converts the health-probe settings into probe options.
+func healthProbeOptions(cfg *config.Config) coreauth.HealthProbeOptions {
+	settings := cfg.HealthProbe
+	parse := func(field, raw string, fallback time.Duration) time.Duration {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			return fallback
+		}
+		parsed, errParse := time.ParseDuration(raw)
+		if errParse != nil || parsed <= 0 {
+			log.Warnf("invalid health-probe.%s %q, using %s", field, raw, fallback)
+			return fallback
+		}
+		return parsed
+	}
+	return coreauth.HealthProbeOptions{
+		Interval:  parse("interval", settings.Interval, coreauth.DefaultHealthProbeInterval),
+		Lead:      parse("lead", settings.Lead, coreauth.DefaultHealthProbeLead),
+		Timeout:   parse("timeout", settings.Timeout, coreauth.DefaultHealthProbeTimeout),
+		Extend:    parse("extend", settings.Extend, coreauth.DefaultHealthProbeExtend),
+		Providers: append([]string(nil), settings.Providers...),
+	}
+}
+
+// applyWarmupConfig starts, restarts, or stops the OAuth warmup scheduler when the
+// warmup settings change.
+func (s *Service) applyWarmupConfig(cfg *config.Config) {
+	if s == nil || s.coreManager == nil || cfg == nil {
+		return
+	}
+	if !cfg.Warmup.Enabled || cfg.Home.Enabled {
+		if s.appliedWarmup != nil {
+			s.coreManager.StopWarmup()
+			s.appliedWarmup = nil
+			log.Info("warmup scheduler stopped")
+		}
+		return
+	}
+	if s.appliedWarmup != nil && reflect.DeepEqual(*s.appliedWarmup, cfg.Warmup) {
+		return
+	}
+	opts := warmupOptions(cfg)
+	s.coreManager.StartWarmup(context.Background(), opts)
+	settings := cfg.Warmup
+	settings.Schedules = append([]config.WarmupSchedule(nil), cfg.Warmup.Schedules...)
+	s.appliedWarmup = &settings
+	log.Infof("warmup scheduler started (%d schedules)", len(opts.Schedules))
+}
+
+// applyAuthPersistenceConfig switches request-result persistence between write-behind and
+// synchronous writes when the auth-persistence settings change.
+func (s *Service) applyAuthPersistenceConfig(cfg *config.Config) {
+	if s == nil || s.coreManager == nil || cfg == nil {
+		return
+	}
+	settings := cfg.AuthPersistence
+	if s.appliedAuthPersistence != nil && *s.appliedAuthPersistence == settings {
+		return
+	}
+	s.appliedAuthPersistence = &settings
+	switch mode := strings.ToLower(strings.TrimSpace(settings.Mode)); mode {
+	case config.AuthPersistenceModeSync:
+		s.coreManager.StopPersistQueue()
+		log.Info("auth persistence: synchronous writes")
+		return
+	case "", config.AuthPersistenceModeAsync:
+	default:
+		log.Warnf("invalid auth-persistence.mode %q, using %s", settings.Mode, config.AuthPersistenceModeAsync)
+	}
+	interval := coreauth.DefaultPersistFlushInterval
+	if raw := strings.TrimSpace(settings.FlushInterval); raw != "" {
+		parsed, errParse := time.ParseDuration(raw)
+		if errParse != nil || parsed <= 0 {
+			log.Warnf("invalid auth-persistence.flush-interval %q, using %s", raw, interval)
+		} else {
+			interval = parsed
+		}
+	}
+	s.coreManager.StartPersistQueue(context.Background(), coreauth.PersistQueueOptions{FlushInterval: interval})
+	log.Infof("auth persistence: write-behind (flush every %s)", interval)
+}
+
+// applyHookDispatchConfig switches Hook.OnResult delivery between the worker pool and
+// inline calls when the hook-dispatch settings change.
+func (s *Service) applyHookDispatchConfig(cfg *config.Config) {
+	if s == nil || s.coreManager == nil || cfg == nil {
+		return
+	}
+	settings := cfg.HookDispatch
+	if s.appliedHookDispatch != nil && *s.appliedHookDispatch == settings {
+		return
+	}
+	s.appliedHookDispatch = &settings
+	policy := coreauth.HookErrorPolicy(strings.ToLower(strings.TrimSpace(settings.ErrorPolicy)))
+	// Inline delivery stays the default so existing hooks keep ordered, lossless results;
+	// the worker pool, which may reorder and drop results, is opt-in.
+	switch mode := strings.ToLower(strings.TrimSpace(settings.Mode)); mode {
+	case config.HookDispatchModeAsync:
+	case "", config.HookDispatchModeSync:
+		s.coreManager.StopHookDispatcher()
+		s.coreManager.SetHookErrorPolicy(policy)
+		return
+	default:
+		log.Warnf("invalid hook-dispatch.mode %q, using %s", settings.Mode, config.HookDispatchModeSync)
+		s.coreManager.StopHookDispatcher()
+		s.coreManager.SetHookErrorPolicy(policy)
+		return
+	}
+	s.coreManager.StartHookDispatcher(coreauth.HookDispatchOptions{
+		Workers:     settings.Workers,
+		QueueSize:   settings.QueueSize,
+		ErrorPolicy: policy,
+	})
+}
+
+// warmupOptions converts the warmup settings into scheduler options, dropping invalid schedules.
+func warmupOptions(cfg *config.Config) coreauth.WarmupOptions {
+	settings := cfg.Warmup
+	opts := coreauth.WarmupOptions{Timeout: coreauth.DefaultWarmupTimeout}
+	if raw := strings.TrimSpace(settings.Timeout); raw != "" {
+		if parsed, errParse := time.ParseDuration(raw); errParse == nil && parsed > 0 {
+			opts.Timeout = parsed
+		} else {
+			log.Warnf("invalid warmup.timeout %q, using %s", raw, opts.Timeout)
+		}
+	}
+	for i, entry := range settings.Schedules {
+		provider := strings.ToLower(strings.TrimSpace(entry.Provider))
+		model := strings.TrimSpace(entry.Model)
+		if provider == "" || model == "" {
+			log.Warnf("warmup.schedules[%d]: provider and model are required, skipping", i)
+			continue
+		}
+		schedule := coreauth.WarmupSchedule{Provider: provider, Model: model, Prompt: entry.Prompt}
+		if raw := strings.TrimSpace(entry.Every); raw != "" {
+			parsed, errParse := time.ParseDuration(raw)
+			if errParse != nil || parsed < time.Minute {
+				log.Warnf("warmup.schedules[%d]: invalid every %q (minimum 1m), skipping", i, raw)
+				continue
+			}
+			schedule.Every = parsed
+		}
+		for _, raw := range entry.At {
+			clock, errParse := time.Parse("15:04", strings.TrimSpace(raw))
+			if errParse != nil {
+				log.Warnf("warmup.schedules[%d]: invalid at %q (want HH:MM), ignoring", i, raw)
+				continue
+			}
+			schedule.At = append(schedule.At, time.Duration(clock.Hour())*time.Hour+time.Duration(clock.Minute())*time.Minute)
+		}
+		if schedule.Every == 0 && len(schedule.At) == 0 {
+			log.Warnf("warmup.schedules[%d]: every or at is required, skipping", i)
+			continue
+		}
+		opts.Schedules = append(opts.Schedules, schedule)
+	}
+	return opts
+}
+
+// applyAuthWebhookConfig installs, replaces, or removes the auth lifecycle webhook when
+// the auth-webhook settings change.
+func (s *Service) applyAuthWebhookConfig(cfg *config.Config) {
+	if s == nil || s.coreManager == nil || cfg == nil {
+		return
+	}
+	settings := cfg.AuthWebhook
+	url := strings.TrimSpace(settings.URL)
+	if !settings.Enabled || url == "" {
+		if s.appliedAuthWebhook != nil {
+			s.coreManager.SetWebhookHook(nil)
+			s.appliedAuthWebhook = nil
+			log.Info("auth webhook disabled")
+		}
+		return
+	}
+	if s.appliedAuthWebhook != nil && reflect.DeepEqual(*s.appliedAuthWebhook, settings) {
+		return
+	}
+	timeout := coreauth.DefaultWebhookTimeout
+	if raw := strings.TrimSpace(settings.Timeout); raw != "" {
+		if parsed, errParse := time.ParseDuration(raw); errParse == nil && parsed > 0 {
+			timeout = parsed
+		} else {
+			log.Warnf("invalid auth-webhook.timeout %q, using %s", raw, timeout)
+		}
+	}
+	s.coreManager.SetWebhookHook(coreauth.NewWebhookHook(coreauth.WebhookHookOptions{
+		URL:     url,
+		Headers: settings.Headers,
+		Timeout: timeout,
+		Events:  settings.Events,
+	}))
+	s.appliedAuthWebhook = &settings
+	log.Info("auth webhook enabled")
+}
+
+// applySelectionAuditConfig opens, replaces, or closes the selection audit log when
+// the selection-audit settings change.
+func (s *Service) applySelectionAuditConfig(cfg *config.Config) {
+	if s == nil || s.coreManager == nil || cfg == nil {
+		return
+	}
+	settings := cfg.SelectionAudit
+	if s.appliedSelectionAudit != nil && *s.appliedSelectionAudit == settings {
+		return
+	}
+	s.appliedSelectionAudit = &settings
+	if !settings.Enabled {
+		s.coreManager.SetSelectionAudit(nil)
+		return
+	}
+	path := strings.TrimSpace(settings.Path)
+	if path == "" {
+		path = filepath.Join(logging.ResolveLogDirectory(cfg), "selection-audit.jsonl")
+	}
+	auditLog, errOpen := coreauth.NewSelectionAuditLog(coreauth.SelectionAuditOptions{
+		Path:       path,
+		MaxSizeMB:  settings.MaxSizeMB,
+		MaxBackups: settings.MaxBackups,
+		MaxAgeDays: settings.MaxAgeDays,
+	})
+	if errOpen != nil {
+		log.Warnf("failed to enable selection audit: %v", errOpen)
+		s.coreManager.SetSelectionAudit(nil)
+		return
+	}
+	s.coreManager.SetSelectionAudit(auditLog)
 }
 
 func (s *Service) configureCooldownStateStore(cfg *config.Config) {
@@ -1278,6 +1601,8 @@ func (s *Service) registerExecutorForAuth(a *coreauth.Auth, forceReplace bool) {
 		s.coreManager.RegisterExecutor(executor.NewCommandCodeExecutor(s.cfg))
 	case "mistral":
 		s.coreManager.RegisterExecutor(executor.NewMistralExecutor(s.cfg))
+	case executor.MockProviderKey:
+		s.coreManager.RegisterExecutor(executor.NewMockExecutor(s.cfg))
 	default:
 		providerKey := strings.ToLower(strings.TrimSpace(a.Provider))
 		if providerKey == "" {
@@ -1461,6 +1786,7 @@ type routingRuntimeState struct {
 	strategy           string
 	sessionAffinity    bool
 	sessionAffinityTTL time.Duration
+	authDailyBudget    float64
 }
 
 func normalizedRoutingRuntimeState(cfg *config.Config) routingRuntimeState {
@@ -1477,6 +1803,11 @@ func normalizedRoutingRuntimeState(cfg *config.Config) routingRuntimeState {
 		state.strategy = "fill-first"
 	case "weight-robin", "weightrobin", "wr":
 		state.strategy = "weight-robin"
+	case "cost", "cost-aware", "cheapest":
+		state.strategy = "cost"
+		if cfg.Routing.AuthDailyBudget > 0 {
+			state.authDailyBudget = cfg.Routing.AuthDailyBudget
+		}
 	}
 	state.sessionAffinity = cfg.Routing.SessionAffinity
 	if ttl := strings.TrimSpace(cfg.Routing.SessionAffinityTTL); ttl != "" {
@@ -1487,13 +1818,124 @@ func normalizedRoutingRuntimeState(cfg *config.Config) routingRuntimeState {
 	return state
 }
 
-func newRoutingSelector(state routingRuntimeState) coreauth.Selector {
+func applyConfigOverrides(cfg *config.Config, overrides []func(*config.Config)) {
+	if cfg == nil {
+		return
+	}
+	for _, override := range overrides {
+		override(cfg)
+	}
+}
+
+// costSelectorUsagePluginName is the usage plugin slot feeding the manager's spend tracker.
+const costSelectorUsagePluginName = "routing:cost"
+
+// backgroundLaneOptions converts the background-lane settings into lane options.
+func backgroundLaneOptions(cfg *config.Config) coreauth.BackgroundLaneOptions {
+	opts := coreauth.BackgroundLaneOptions{}
+	if cfg == nil {
+		return opts
+	}
+	opts.MaxConcurrency = cfg.BackgroundLane.MaxConcurrency
+	if raw := strings.TrimSpace(cfg.BackgroundLane.MinInterval); raw != "" {
+		if parsed, errParse := time.ParseDuration(raw); errParse == nil {
+			opts.MinInterval = parsed
+			if parsed == 0 {
+				opts.MinInterval = -1
+			}
+		} else {
+			log.Warnf("invalid background-lane.min-interval %q, using %s", raw, coreauth.DefaultBackgroundLaneMinInterval)
+		}
+	}
+	return opts
+}
+
+// priorityGateOptions converts the request-priority settings into gate options.
+func priorityGateOptions(cfg *config.Config) coreauth.PriorityGateOptions {
+	opts := coreauth.PriorityGateOptions{}
+	if cfg == nil {
+		return opts
+	}
+	opts.MaxConcurrency = cfg.RequestPriority.MaxConcurrency
+	if raw := strings.TrimSpace(cfg.RequestPriority.AgingInterval); raw != "" {
+		if parsed, errParse := time.ParseDuration(raw); errParse == nil && parsed > 0 {
+			opts.AgingInterval = parsed
+		} else {
+			log.Warnf("invalid request-priority.aging-interval %q, using %s", raw, coreauth.DefaultPriorityGateAgingInterval)
+		}
+	}
+	return opts
+}
+
+// applyPricingConfig publishes the configured price table to the global pricing registry.
+func applyPricingConfig(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	prices := make([]registry.ModelPrice, 0, len(cfg.Routing.Pricing))
+	for _, entry := range cfg.Routing.Pricing {
+		prices = append(prices, registry.ModelPrice{
+			Provider:             entry.Provider,
+			Model:                entry.Model,
+			PromptPerMillion:     entry.PromptPerMillion,
+			CompletionPerMillion: entry.CompletionPerMillion,
+		})
+	}
+	registry.GetGlobalPricingRegistry().SetPrices(prices)
+}
+
+// applyOutboundTLSConfig loads the CA bundles and client certificates used for outbound HTTPS.
+func applyOutboundTLSConfig(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	toOptions := func(opts config.OutboundTLSOptions) proxyutil.TLSOptions {
+		return proxyutil.TLSOptions{CAFile: opts.CAFile, CertFile: opts.CertFile, KeyFile: opts.KeyFile}
+	}
+	var providers map[string]proxyutil.TLSOptions
+	if len(cfg.OutboundTLS.Providers) > 0 {
+		providers = make(map[string]proxyutil.TLSOptions, len(cfg.OutboundTLS.Providers))
+		for provider, opts := range cfg.OutboundTLS.Providers {
+			providers[provider] = toOptions(opts)
+		}
+	}
+	if errTLS := proxyutil.ConfigureTLS(toOptions(cfg.OutboundTLS.OutboundTLSOptions), providers); errTLS != nil {
+		log.Warnf("%v", errTLS)
+	}
+}
+
+// applyProxyPoolConfig registers the configured proxy pools referenced by "pool://" proxy URLs.
+func applyProxyPoolConfig(cfg *config.Config) {
+	if cfg == nil {
+		return
+	}
+	options := make([]proxyutil.PoolOptions, 0, len(cfg.ProxyPools))
+	for _, pool := range cfg.ProxyPools {
+		options = append(options, proxyutil.PoolOptions{
+			Name:                pool.Name,
+			Proxies:             pool.Proxies,
+			HealthCheckURL:      strings.TrimSpace(pool.HealthCheckURL),
+			HealthCheckInterval: time.Duration(pool.HealthCheckIntervalSeconds) * time.Second,
+		})
+	}
+	if errPools := proxyutil.ConfigurePools(options); errPools != nil {
+		log.Warnf("proxy pools: %v", errPools)
+	}
+}
+
+// newRoutingSelector builds the selector for state. A cost selector charges spend to the
+// given tracker so daily budgets carry over when the selector is rebuilt on reload.
+func newRoutingSelector(state routingRuntimeState, spend *coreauth.CostTracker) coreauth.Selector {
 	var selector coreauth.Selector
 	switch state.strategy {
 	case "fill-first":
 		selector = &coreauth.FillFirstSelector{}
 	case "weight-robin":
 		selector = &coreauth.WeightedRobinSelector{}
+	case "cost":
+		costSelector := coreauth.NewCostAwareSelector(state.authDailyBudget)
+		costSelector.Spend = spend
+		selector = costSelector
 	default:
 		selector = &coreauth.RoundRobinSelector{}
 	}
@@ -1532,6 +1974,7 @@ func (s *Service) commitConfigUpdate(newCfg *config.Config) configCommit {
 	if newCfg == nil {
 		return configCommit{}
 	}
+	applyConfigOverrides(newCfg, s.configOverrides)
 
 	s.cfgMu.Lock()
 	s.cfg = newCfg
@@ -1570,6 +2013,7 @@ func (s *Service) applyConfigRuntime(ctx context.Context, commit configCommit, s
 	if !s.applyManagerConfig(ctx, commit) {
 		return false
 	}
+	s.applyUsageCountersConfig(cfg)
 	if errContext := ctx.Err(); errContext != nil {
 		return false
 	}
@@ -1631,12 +2075,31 @@ func (s *Service) applyManagerConfig(ctx context.Context, commit configCommit) b
 	if errContext := ctx.Err(); errContext != nil {
 		return false
 	}
+	applyPricingConfig(commit.cfg)
+	applyOutboundTLSConfig(commit.cfg)
+	applyProxyPoolConfig(commit.cfg)
 	routingState := normalizedRoutingRuntimeState(commit.cfg)
-	if s.appliedRoutingState == nil || *s.appliedRoutingState != routingState {
-		s.coreManager.SetSelector(newRoutingSelector(routingState))
+	if !s.selectorPinned && (s.appliedRoutingState == nil || *s.appliedRoutingState != routingState) {
+		s.coreManager.SetSelector(newRoutingSelector(routingState, s.coreManager.CostSpend()))
 		s.appliedRoutingState = &routingState
 	}
 	s.applyRetryConfig(commit.cfg)
+	s.coreManager.SetFallbackConfig(commit.cfg.Routing.FallbackModels, commit.cfg.Routing.FallbackChain, commit.cfg.Routing.FallbackMaxDepth)
+	s.coreManager.BackgroundLane().SetOptions(backgroundLaneOptions(commit.cfg))
+	s.coreManager.PriorityGate().SetOptions(priorityGateOptions(commit.cfg))
+	s.applySelectionAuditConfig(commit.cfg)
+	s.applyHealthProbeConfig(commit.cfg)
+	s.applyWarmupConfig(commit.cfg)
+	s.applyAuthPersistenceConfig(commit.cfg)
+	s.applyHookDispatchConfig(commit.cfg)
+	s.applyModelRefreshConfig(commit.cfg)
+	s.applyAuthWebhookConfig(commit.cfg)
+	spendalert.Default().Configure(commit.cfg.SpendAlerts, s.coreManager)
+	authexpiry.Default().Configure(commit.cfg.ExpiryReport, s.coreManager)
+	dailycap.Default().Configure(commit.cfg.DailyCaps, s.coreManager)
+	if routingState.strategy == "cost" {
+		s.coreManager.CostSpend().Seed(s.coreManager.List())
+	}
 	store := s.resolveCooldownStateStore(commit.cfg)
 	if !s.coreManager.ApplyConfigWithCooldownStateStore(ctx, commit.cfg, store) {
 		return false
@@ -2377,15 +2840,19 @@ func (s *Service) Run(ctx context.Context) error {
 	}()
 
 	usage.StartDefault(ctx)
+	s.configRuntimeMu.Lock()
+	s.applyUsageCountersConfig(s.cfg)
+	s.configRuntimeMu.Unlock()
 	homeEnabled := s.cfg != nil && s.cfg.Home.Enabled
 	if homeEnabled {
 		forceHomeRuntimeConfig(s.cfg)
 		redisqueue.SetUsageStatisticsEnabled(true)
 	}
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer shutdownCancel()
 	defer func() {
+		// The deadline starts when shutdown begins and covers the drain plus cleanup.
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownDrainTimeout(s.cfg)+30*time.Second)
+		defer shutdownCancel()
 		if err := s.Shutdown(shutdownCtx); err != nil {
 			log.Errorf("service shutdown returned error: %v", err)
 		}
@@ -2547,6 +3014,15 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 }
 
+// Handoff passes the API listener to a newly started copy of the binary and returns once
+// it serves requests. Cancel the Run context afterwards to drain and stop this process.
+func (s *Service) Handoff(ctx context.Context) error {
+	if s == nil || s.server == nil {
+		return fmt.Errorf("cliproxy: server not started")
+	}
+	return s.server.Handoff(ctx)
+}
+
 // Shutdown gracefully stops background workers and the HTTP server.
 // It ensures all resources are properly cleaned up and connections are closed.
 // The shutdown is idempotent and can be called multiple times safely.
@@ -2566,6 +3042,19 @@ func (s *Service) Shutdown(ctx context.Context) error {
 			ctx = context.Background()
 		}
 
+		// Drain first: stop taking requests and let in-flight ones, including SSE streams,
+		// finish before the runtime they depend on is torn down.
+		if s.server != nil {
+			drainTimeout := shutdownDrainTimeout(s.cfg)
+			log.Infof("draining in-flight requests (up to %s)", drainTimeout)
+			drainCtx, drainCancel := context.WithTimeout(ctx, drainTimeout)
+			if err := s.server.Stop(drainCtx); err != nil {
+				log.Errorf("error stopping API server: %v", err)
+				shutdownErr = err
+			}
+			drainCancel()
+		}
+
 		s.homeLifecycleMu.Lock()
 		if supervisor := s.homeSupervisor; supervisor != nil {
 			s.homeConfigCommitMu.Lock()
@@ -2619,6 +3108,12 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
+			s.coreManager.StopHealthProbe()
+			s.coreManager.StopWarmup()
+			s.stopModelRefresh()
+			s.coreManager.StopPersistQueue()
+			s.coreManager.StopHookDispatcher()
+			s.coreManager.SetWebhookHook(nil)
 		}
 		if s.watcher != nil {
 			if err := s.watcher.Stop(); err != nil {
@@ -2646,17 +3141,18 @@ func (s *Service) Shutdown(ctx context.Context) error {
 			}
 		}
 
-		// no legacy clients to persist
-
-		if s.server != nil {
-			shutdownCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
-			defer cancel()
-			if err := s.server.Stop(shutdownCtx); err != nil {
-				log.Errorf("error stopping API server: %v", err)
-				if shutdownErr == nil {
-					shutdownErr = err
-				}
-			}
+		// Deliver the usage of drained requests before persisting what depends on it.
+		if errDrain := usage.DefaultManager().Drain(ctx); errDrain != nil {
+			log.Warnf("failed to deliver pending usage records: %v", errDrain)
+		}
+		if errFlush := usage.DefaultManager().Counters().Close(); errFlush != nil {
+			log.Warnf("failed to persist usage counters: %v", errFlush)
+		}
+		if errFlush := tenant.Default().Flush(); errFlush != nil {
+			log.Warnf("failed to persist tenants: %v", errFlush)
+		}
+		if s.coreManager != nil {
+			s.coreManager.PersistCooldownStates(ctx)
 		}
 
 		if s.pluginHost != nil {
@@ -2677,11 +3173,32 @@ func (s *Service) Shutdown(ctx context.Context) error {
 			}
 		}
 
+		if s.coreManager != nil {
+			s.coreManager.SetSelectionAudit(nil)
+		}
 		usage.StopDefault()
 	})
 	return shutdownErr
 }
 
+// shutdownDrainTimeout returns the configured drain deadline, defaulting to 30s.
+func shutdownDrainTimeout(cfg *config.Config) time.Duration {
+	const defaultTimeout = 30 * time.Second
+	if cfg == nil {
+		return defaultTimeout
+	}
+	raw := strings.TrimSpace(cfg.ShutdownDrainTimeout)
+	if raw == "" {
+		return defaultTimeout
+	}
+	parsed, errParse := time.ParseDuration(raw)
+	if errParse != nil || parsed < 0 {
+		log.Warnf("invalid shutdown-drain-timeout %q, using %s", raw, defaultTimeout)
+		return defaultTimeout
+	}
+	return parsed
+}
+
 func (s *Service) ensureAuthDir() error {
 	info, err := os.Stat(s.cfg.AuthDir)
 	if err != nil {
@@ -2801,7 +3318,9 @@ func (s *Service) registerModelsForAuthWithCache(ctx context.Context, a *coreaut
 			}
 		}
 		if authKind != "apikey" {
-			models = mergeClaudeFetchedModels(models, s.fetchClaudeModelsForAuth(ctx, a))
+			s.runModelListFetch(ctx, func(fetchCtx context.Context) {
+				models = mergeClaudeFetchedModels(models, s.fetchClaudeModelsForAuth(fetchCtx, a))
+			})
 		}
 		models = applyExcludedModels(models, excluded)
 	case "codex":
@@ -2867,20 +3386,26 @@ func (s *Service) registerModelsForAuthWithCache(ctx context.Context, a *coreaut
 		}
 		models = applyExcludedModels(models, excluded)
 	case "cursor":
-		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-		defer cancel()
-		models = executor.FetchCursorModels(ctx, a, s.cfg)
+		s.runModelListFetch(context.Background(), func(fetchCtx context.Context) {
+			fetchCtx, cancel := context.WithTimeout(fetchCtx, 15*time.Second)
+			defer cancel()
+			models = executor.FetchCursorModels(fetchCtx, a, s.cfg)
+		})
 		models = applyExcludedModels(models, excluded)
 	case "github-copilot":
-		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-		defer cancel()
-		models = executor.FetchGitHubCopilotModels(ctx, a, s.cfg)
+		s.runModelListFetch(context.Background(), func(fetchCtx context.Context) {
+			fetchCtx, cancel := context.WithTimeout(fetchCtx, 15*time.Second)
+			defer cancel()
+			models = executor.FetchGitHubCopilotModels(fetchCtx, a, s.cfg)
+		})
 		models = applyExcludedModels(models, excluded)
 	case "kiro":
 		models = s.fetchKiroModels(a)
 		models = applyExcludedModels(models, excluded)
 	case "kilo":
-		models = executor.FetchKiloModels(context.Background(), a, s.cfg)
+		s.runModelListFetch(context.Background(), func(fetchCtx context.Context) {
+			models = executor.FetchKiloModels(fetchCtx, a, s.cfg)
+		})
 		models = applyExcludedModels(models, excluded)
 	case "gitlab":
 		models = executor.GitLabModelsFromAuth(a)
@@ -2888,6 +3413,8 @@ func (s *Service) registerModelsForAuthWithCache(ctx context.Context, a *coreaut
 	case "codebuddy":
 		models = registry.GetCodeBuddyModels()
 		models = applyExcludedModels(models, excluded)
+	case executor.MockProviderKey:
+		models = buildMockConfigModels(s.cfg)
 	default:
 		// Handle OpenAI-compatibility providers by name using config
 		if s.cfg != nil {
@@ -3082,7 +3609,7 @@ func (s *Service) resolveConfigClaudeKey(auth *coreauth.Auth) *config.ClaudeKey
 	}
 	var attrKey, attrBase string
 	if auth.Attributes != nil {
-		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrKey = auth.ConfigAPIKey()
 		attrBase = strings.TrimSpace(auth.Attributes["base_url"])
 	}
 	for i := range s.cfg.ClaudeKey {
@@ -3135,7 +3662,7 @@ func (s *Service) resolveConfigGeminiKeyEntry(auth *coreauth.Auth, entries []con
 	}
 	var attrKey, attrBase string
 	if auth.Attributes != nil {
-		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrKey = auth.ConfigAPIKey()
 		attrBase = strings.TrimSpace(auth.Attributes["base_url"])
 	}
 	for i := range entries {
@@ -3161,7 +3688,7 @@ func (s *Service) resolveConfigVertexCompatKey(auth *coreauth.Auth) *config.Vert
 	}
 	var attrKey, attrBase string
 	if auth.Attributes != nil {
-		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrKey = auth.ConfigAPIKey()
 		attrBase = strings.TrimSpace(auth.Attributes["base_url"])
 	}
 	for i := range s.cfg.VertexCompatAPIKey {
@@ -3209,7 +3736,7 @@ func resolveConfigCodexStyleKey(auth *coreauth.Auth, entries []config.CodexKey)
 	}
 	var attrKey, attrBase string
 	if auth.Attributes != nil {
-		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrKey = auth.ConfigAPIKey()
 		attrBase = strings.TrimSpace(auth.Attributes["base_url"])
 	}
 	for i := range entries {
@@ -3235,7 +3762,7 @@ func (s *Service) resolveConfigCommandCodeKey(auth *coreauth.Auth) *config.Comma
 	}
 	var attrKey, attrBase string
 	if auth.Attributes != nil {
-		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrKey = auth.ConfigAPIKey()
 		attrBase = strings.TrimSpace(auth.Attributes["base_url"])
 	}
 	for i := range s.cfg.CommandCodeKey {
@@ -3615,7 +4142,7 @@ func (s *Service) resolveConfigMistralKey(auth *coreauth.Auth) *config.MistralKe
 	}
 	var attrKey, attrBase string
 	if auth.Attributes != nil {
-		attrKey = strings.TrimSpace(auth.Attributes["api_key"])
+		attrKey = auth.ConfigAPIKey()
 		attrBase = strings.TrimSpace(auth.Attributes["base_url"])
 	}
 	for i := range s.cfg.MistralKey {
@@ -3642,6 +4169,37 @@ func buildMistralConfigModels(entry *config.MistralKey) []*ModelInfo {
 	return buildConfigModels(entry.Models, "mistral", "mistral")
 }
 
+// buildMockConfigModels lists the models scripted in mock-provider.
+func buildMockConfigModels(cfg *config.Config) []*ModelInfo {
+	if cfg == nil || !cfg.MockProvider.Enabled {
+		return nil
+	}
+	now := time.Now().Unix()
+	out := make([]*ModelInfo, 0, len(cfg.MockProvider.Models))
+	seen := make(map[string]struct{}, len(cfg.MockProvider.Models))
+	for _, entry := range cfg.MockProvider.Models {
+		name := strings.TrimSpace(entry.Name)
+		key := strings.ToLower(name)
+		if name == "" {
+			continue
+		}
+		if _, exists := seen[key]; exists {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, &ModelInfo{
+			ID:          name,
+			Object:      "model",
+			Created:     now,
+			OwnedBy:     executor.MockProviderKey,
+			Type:        executor.MockProviderKey,
+			DisplayName: name,
+			UserDefined: true,
+		})
+	}
+	return out
+}
+
 func rewriteModelInfoName(name, oldID, newID string) string {
 	trimmed := strings.TrimSpace(name)
 	if trimmed == "" {
@@ -3885,6 +4443,19 @@ func (s *Service) fetchKiroModels(a *coreauth.Auth) []*ModelInfo {
 	return models
 }
 
+// runModelListFetch runs an upstream model-list request through the core manager's
+// background lane so registration bursts do not compete with client traffic.
+func (s *Service) runModelListFetch(ctx context.Context, fetch func(context.Context)) {
+	if s == nil || s.coreManager == nil {
+		fetch(usage.WithBackgroundLane(ctx, coreauth.BackgroundKindModelList))
+		return
+	}
+	_ = s.coreManager.RunBackground(ctx, coreauth.BackgroundKindModelList, func(laneCtx context.Context) error {
+		fetch(laneCtx)
+		return nil
+	})
+}
+
 // extractKiroTokenData extracts KiroTokenData from auth attributes and metadata.
 // It supports both config-based tokens (stored in Attributes) and file-based tokens (stored in Metadata).
 func (s *Service) extractKiroTokenData(a *coreauth.Auth) *kiroauth.KiroTokenData {
@@ -0,0 +1,40 @@
+package cliproxy
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestApplyManagerConfigHotReloadsFallbackConfig(t *testing.T) {
+	dir := t.TempDir()
+	svc, err := NewServer(WithConfigFile(filepath.Join(dir, "missing.yaml")), WithAuthDir(filepath.Join(dir, "auths")))
+	if err != nil {
+		t.Fatalf("NewServer() error = %v", err)
+	}
+
+	first := &config.Config{}
+	first.Routing.FallbackModels = map[string]string{"model-a": "model-b"}
+	first.Routing.FallbackChain = []string{"model-c"}
+	if !svc.applyManagerConfig(context.Background(), svc.commitConfigUpdate(first)) {
+		t.Fatalf("applyManagerConfig(first) = false")
+	}
+	if got := svc.coreManager.FallbackModels()["model-a"]; got != "model-b" {
+		t.Fatalf("fallback model = %q, want model-b", got)
+	}
+
+	reloaded := &config.Config{}
+	reloaded.Routing.FallbackChain = []string{"model-d", "model-e"}
+	reloaded.Routing.FallbackMaxDepth = 1
+	if !svc.applyManagerConfig(context.Background(), svc.commitConfigUpdate(reloaded)) {
+		t.Fatalf("applyManagerConfig(reloaded) = false")
+	}
+	if models := svc.coreManager.FallbackModels(); len(models) != 0 {
+		t.Fatalf("fallback models after reload = %v, want empty", models)
+	}
+	if chain := svc.coreManager.FallbackChain(); len(chain) != 2 || chain[0] != "model-d" {
+		t.Fatalf("fallback chain after reload = %v, want [model-d model-e]", chain)
+	}
+}
@@ -0,0 +1,421 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultCountersFlushInterval is how often dirty counters are written to disk.
+	DefaultCountersFlushInterval = 30 * time.Second
+
+	countersDayLayout = "2006-01-02"
+)
+
+// CounterKey identifies one aggregation bucket: a credential, a model, and a UTC day.
+type CounterKey struct {
+	AuthID string `json:"auth_id"`
+	Model  string `json:"model"`
+	// Day is the UTC day in YYYY-MM-DD form.
+	Day string `json:"day"`
+}
+
+// CounterValues holds request and token totals for one bucket.
+type CounterValues struct {
+	Requests        int64 `json:"requests"`
+	Failed          int64 `json:"failed"`
+	InputTokens     int64 `json:"input_tokens"`
+	OutputTokens    int64 `json:"output_tokens"`
+	ReasoningTokens int64 `json:"reasoning_tokens"`
+	CachedTokens    int64 `json:"cached_tokens"`
+	TotalTokens     int64 `json:"total_tokens"`
+}
+
+// Add accumulates other into v.
+func (v *CounterValues) Add(other CounterValues) {
+	v.Requests += other.Requests
+	v.Failed += other.Failed
+	v.InputTokens += other.InputTokens
+	v.OutputTokens += other.OutputTokens
+	v.ReasoningTokens += other.ReasoningTokens
+	v.CachedTokens += other.CachedTokens
+	v.TotalTokens += other.TotalTokens
+}
+
+// CounterRow is one bucket returned by Query.
+type CounterRow struct {
+	CounterKey
+	Provider string `json:"provider,omitempty"`
+	// ExemplarRequestID is the request ID of the latest record counted in the bucket.
+	ExemplarRequestID string `json:"exemplar_request_id,omitempty"`
+	CounterValues
+}
+
+// CounterFilter narrows a Query. Empty fields match everything.
+type CounterFilter struct {
+	AuthID   string
+	Model    string
+	Provider string
+	// From and To are inclusive UTC days in YYYY-MM-DD form.
+	From string
+	To   string
+}
+
+// CountersOptions configures a Counters aggregator.
+type CountersOptions struct {
+	// Path is the JSON snapshot file; empty keeps counters in memory only.
+	Path string
+	// FlushInterval bounds how long updates may stay unpersisted (default 30s).
+	FlushInterval time.Duration
+	// RetentionDays drops buckets older than this many days; 0 keeps everything.
+	RetentionDays int
+}
+
+type counterEntry struct {
+	Provider          string        `json:"provider,omitempty"`
+	ExemplarRequestID string        `json:"exemplar_request_id,omitempty"`
+	Values            CounterValues `json:"values"`
+}
+
+type countersSnapshot struct {
+	Buckets []countersSnapshotRow `json:"buckets"`
+}
+
+type countersSnapshotRow struct {
+	CounterKey
+	counterEntry
+}
+
+// Counters aggregates usage records into per-auth, per-model, per-day counters and
+// persists them to a JSON snapshot so totals survive restarts.
+type Counters struct {
+	opts CountersOptions
+
+	mu        sync.Mutex
+	buckets   map[CounterKey]*counterEntry
+	dirty     bool
+	lastFlush time.Time
+
+	flushMu sync.Mutex
+	now     func() time.Time
+
+	stopFlusher chan struct{}
+	closeOnce   sync.Once
+}
+
+// NewCounters creates an aggregator and loads the existing snapshot when present.
+func NewCounters(opts CountersOptions) *Counters {
+	opts.Path = strings.TrimSpace(opts.Path)
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = DefaultCountersFlushInterval
+	}
+	c := &Counters{
+		opts:    opts,
+		buckets: make(map[CounterKey]*counterEntry),
+	}
+	c.load()
+	c.lastFlush = c.currentTime()
+	return c
+}
+
+// Path returns the snapshot path ("" when counters are memory-only).
+func (c *Counters) Path() string {
+	if c == nil {
+		return ""
+	}
+	return c.opts.Path
+}
+
+func (c *Counters) currentTime() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (c *Counters) load() {
+	if c.opts.Path == "" {
+		return
+	}
+	data, errRead := os.ReadFile(c.opts.Path)
+	if errRead != nil {
+		if !errors.Is(errRead, os.ErrNotExist) {
+			log.Warnf("usage counters: failed to read snapshot %s: %v", c.opts.Path, errRead)
+		}
+		return
+	}
+	var snapshot countersSnapshot
+	if errUnmarshal := json.Unmarshal(data, &snapshot); errUnmarshal != nil {
+		log.Warnf("usage counters: failed to parse snapshot %s: %v", c.opts.Path, errUnmarshal)
+		return
+	}
+	for _, row := range snapshot.Buckets {
+		entry := row.counterEntry
+		c.buckets[row.CounterKey] = &entry
+	}
+	c.pruneLocked(c.currentTime())
+}
+
+// HandleUsage implements Plugin.
+func (c *Counters) HandleUsage(_ context.Context, record Record) {
+	if c == nil || record.Background != "" {
+		return
+	}
+	at := record.RequestedAt
+	if at.IsZero() {
+		at = c.currentTime()
+	}
+	model := strings.TrimSpace(record.Model)
+	if model == "" {
+		model = "unknown"
+	}
+	key := CounterKey{AuthID: strings.TrimSpace(record.AuthID), Model: model, Day: at.UTC().Format(countersDayLayout)}
+	values := CounterValues{
+		Requests:        1,
+		InputTokens:     record.Detail.InputTokens,
+		OutputTokens:    record.Detail.OutputTokens,
+		ReasoningTokens: record.Detail.ReasoningTokens,
+		CachedTokens:    record.Detail.CachedTokens,
+		TotalTokens:     record.Detail.TotalTokens,
+	}
+	if record.Failed {
+		values.Failed = 1
+	}
+
+	now := c.currentTime()
+	c.mu.Lock()
+	entry, ok := c.buckets[key]
+	if !ok {
+		entry = &counterEntry{}
+		c.buckets[key] = entry
+	}
+	if provider := strings.TrimSpace(record.Provider); provider != "" {
+		entry.Provider = provider
+	}
+	if requestID := strings.TrimSpace(record.RequestID); requestID != "" {
+		entry.ExemplarRequestID = requestID
+	}
+	entry.Values.Add(values)
+	c.dirty = true
+	flushDue := c.opts.Path != "" && now.Sub(c.lastFlush) >= c.opts.FlushInterval
+	c.mu.Unlock()
+
+	if flushDue {
+		if errFlush := c.Flush(); errFlush != nil {
+			log.Warnf("usage counters: %v", errFlush)
+		}
+	}
+}
+
+// Query returns the buckets matching filter ordered by day, auth, and model.
+func (c *Counters) Query(filter CounterFilter) []CounterRow {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	rows := make([]CounterRow, 0, len(c.buckets))
+	for key, entry := range c.buckets {
+		if filter.AuthID != "" && key.AuthID != filter.AuthID {
+			continue
+		}
+		if filter.Model != "" && !strings.EqualFold(key.Model, filter.Model) {
+			continue
+		}
+		if filter.Provider != "" && !strings.EqualFold(entry.Provider, filter.Provider) {
+			continue
+		}
+		if filter.From != "" && key.Day < filter.From {
+			continue
+		}
+		if filter.To != "" && key.Day > filter.To {
+			continue
+		}
+		rows = append(rows, CounterRow{CounterKey: key, Provider: entry.Provider, ExemplarRequestID: entry.ExemplarRequestID, CounterValues: entry.Values})
+	}
+	c.mu.Unlock()
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Day != rows[j].Day {
+			return rows[i].Day < rows[j].Day
+		}
+		if rows[i].AuthID != rows[j].AuthID {
+			return rows[i].AuthID < rows[j].AuthID
+		}
+		return rows[i].Model < rows[j].Model
+	})
+	return rows
+}
+
+// Totals sums the buckets matching filter.
+func (c *Counters) Totals(filter CounterFilter) CounterValues {
+	var total CounterValues
+	for _, row := range c.Query(filter) {
+		total.Add(row.CounterValues)
+	}
+	return total
+}
+
+func (c *Counters) pruneLocked(now time.Time) {
+	if c.opts.RetentionDays <= 0 {
+		return
+	}
+	cutoff := now.UTC().AddDate(0, 0, -c.opts.RetentionDays).Format(countersDayLayout)
+	for key := range c.buckets {
+		if key.Day < cutoff {
+			delete(c.buckets, key)
+			c.dirty = true
+		}
+	}
+}
+
+// Flush writes the snapshot when counters changed since the last write.
+func (c *Counters) Flush() error {
+	if c == nil || c.opts.Path == "" {
+		return nil
+	}
+	c.flushMu.Lock()
+	defer c.flushMu.Unlock()
+
+	now := c.currentTime()
+	c.mu.Lock()
+	c.pruneLocked(now)
+	if !c.dirty {
+		c.lastFlush = now
+		c.mu.Unlock()
+		return nil
+	}
+	snapshot := countersSnapshot{Buckets: make([]countersSnapshotRow, 0, len(c.buckets))}
+	for key, entry := range c.buckets {
+		snapshot.Buckets = append(snapshot.Buckets, countersSnapshotRow{CounterKey: key, counterEntry: *entry})
+	}
+	c.dirty = false
+	c.lastFlush = now
+	c.mu.Unlock()
+
+	data, errMarshal := json.Marshal(snapshot)
+	if errMarshal != nil {
+		c.markDirty()
+		return fmt.Errorf("encode snapshot: %w", errMarshal)
+	}
+	if errMkdir := os.MkdirAll(filepath.Dir(c.opts.Path), 0o700); errMkdir != nil {
+		c.markDirty()
+		return fmt.Errorf("create snapshot directory: %w", errMkdir)
+	}
+	tmpPath := c.opts.Path + ".tmp"
+	if errWrite := os.WriteFile(tmpPath, data, 0o600); errWrite != nil {
+		c.markDirty()
+		return fmt.Errorf("write snapshot: %w", errWrite)
+	}
+	if errRename := os.Rename(tmpPath, c.opts.Path); errRename != nil {
+		c.markDirty()
+		return fmt.Errorf("replace snapshot: %w", errRename)
+	}
+	return nil
+}
+
+// StartFlusher persists dirty counters every FlushInterval in the background, so updates
+// are saved even when traffic stops and a crash loses at most one interval. Close stops it.
+func (c *Counters) StartFlusher() {
+	if c == nil || c.opts.Path == "" {
+		return
+	}
+	c.mu.Lock()
+	if c.stopFlusher != nil {
+		c.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	c.stopFlusher = stop
+	c.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(c.opts.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if errFlush := c.Flush(); errFlush != nil {
+					log.Warnf("usage counters: %v", errFlush)
+				}
+			}
+		}
+	}()
+}
+
+// Close stops the background flusher and writes pending updates.
+func (c *Counters) Close() error {
+	if c == nil {
+		return nil
+	}
+	c.closeOnce.Do(func() {
+		c.mu.Lock()
+		stop := c.stopFlusher
+		c.mu.Unlock()
+		if stop != nil {
+			close(stop)
+		}
+	})
+	return c.Flush()
+}
+
+func (c *Counters) markDirty() {
+	c.mu.Lock()
+	c.dirty = true
+	c.mu.Unlock()
+}
+
+const countersPluginName = "usage:counters"
+
+// SetCounters installs (or with nil, removes) the persistent counters aggregator.
+// The previous aggregator is flushed before it is replaced.
+func (m *Manager) SetCounters(counters *Counters) {
+	if m == nil {
+		return
+	}
+	m.pluginsMu.Lock()
+	previous := m.counters
+	m.counters = counters
+	m.pluginsMu.Unlock()
+	if previous != nil && previous != counters {
+		if errFlush := previous.Close(); errFlush != nil {
+			log.Warnf("usage counters: %v", errFlush)
+		}
+	}
+	if counters != nil {
+		m.RegisterNamed(countersPluginName, counters)
+	} else {
+		m.RegisterNamed(countersPluginName, noopPlugin{})
+	}
+}
+
+// Counters returns the installed counters aggregator, or nil.
+func (m *Manager) Counters() *Counters {
+	if m == nil {
+		return nil
+	}
+	m.pluginsMu.RLock()
+	defer m.pluginsMu.RUnlock()
+	return m.counters
+}
+
+// QueryCounters returns persisted usage counters matching filter.
+// It returns nil when no aggregator is installed.
+func (m *Manager) QueryCounters(filter CounterFilter) []CounterRow {
+	return m.Counters().Query(filter)
+}
+
+type noopPlugin struct{}
+
+func (noopPlugin) HandleUsage(context.Context, Record) {}
@@ -0,0 +1,86 @@
+package usage
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCountersAggregatePerAuthModelDay(t *testing.T) {
+	counters := NewCounters(CountersOptions{})
+	day1 := time.Date(2026, 3, 1, 23, 0, 0, 0, time.UTC)
+	day2 := day1.Add(2 * time.Hour)
+
+	counters.HandleUsage(context.Background(), Record{Provider: "claude", Model: "m1", AuthID: "a", RequestedAt: day1, Detail: Detail{InputTokens: 10, OutputTokens: 5, TotalTokens: 15}})
+	counters.HandleUsage(context.Background(), Record{Provider: "claude", Model: "m1", AuthID: "a", RequestedAt: day1, Failed: true, RequestID: "req-2"})
+	counters.HandleUsage(context.Background(), Record{Provider: "claude", Model: "m1", AuthID: "a", RequestedAt: day2, Detail: Detail{InputTokens: 1}})
+	counters.HandleUsage(context.Background(), Record{Provider: "codex", Model: "m2", AuthID: "b", RequestedAt: day2, Detail: Detail{OutputTokens: 7}})
+
+	rows := counters.Query(CounterFilter{AuthID: "a"})
+	if len(rows) != 2 {
+		t.Fatalf("rows = %+v, want 2 buckets for auth a", rows)
+	}
+	first := rows[0]
+	if first.Day != "2026-03-01" || first.Requests != 2 || first.Failed != 1 || first.InputTokens != 10 || first.TotalTokens != 15 || first.ExemplarRequestID != "req-2" {
+		t.Fatalf("first bucket = %+v", first)
+	}
+	if got := counters.Query(CounterFilter{From: "2026-03-02", Provider: "codex"}); len(got) != 1 || got[0].OutputTokens != 7 {
+		t.Fatalf("filtered rows = %+v", got)
+	}
+	if totals := counters.Totals(CounterFilter{}); totals.Requests != 4 || totals.OutputTokens != 12 {
+		t.Fatalf("totals = %+v", totals)
+	}
+}
+
+func TestCountersPersistAcrossRestartsAndPrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counters.json")
+	now := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	counters := NewCounters(CountersOptions{Path: path, RetentionDays: 7})
+	counters.now = func() time.Time { return now }
+	counters.HandleUsage(context.Background(), Record{Model: "m1", AuthID: "a", RequestedAt: now, Detail: Detail{InputTokens: 3}})
+	counters.HandleUsage(context.Background(), Record{Model: "m1", AuthID: "a", RequestedAt: now.AddDate(0, 0, -30)})
+	if errFlush := counters.Flush(); errFlush != nil {
+		t.Fatalf("Flush() error = %v", errFlush)
+	}
+
+	restored := NewCounters(CountersOptions{Path: path})
+	rows := restored.Query(CounterFilter{})
+	if len(rows) != 1 || rows[0].Day != "2026-03-10" || rows[0].InputTokens != 3 {
+		t.Fatalf("restored rows = %+v, want only the recent bucket", rows)
+	}
+}
+
+func TestManagerQueryCountersWithoutAggregator(t *testing.T) {
+	manager := NewManager(0)
+	if rows := manager.QueryCounters(CounterFilter{}); rows != nil {
+		t.Fatalf("QueryCounters() = %+v, want nil", rows)
+	}
+	counters := NewCounters(CountersOptions{})
+	manager.SetCounters(counters)
+	if manager.Counters() != counters {
+		t.Fatalf("Counters() did not return the installed aggregator")
+	}
+	manager.SetCounters(nil)
+	if manager.Counters() != nil {
+		t.Fatalf("Counters() after removal should be nil")
+	}
+}
+
+func TestCountersFlusherPersistsWithoutFurtherTraffic(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counters.json")
+	counters := NewCounters(CountersOptions{Path: path, FlushInterval: 10 * time.Millisecond})
+	counters.StartFlusher()
+	defer func() { _ = counters.Close() }()
+	counters.HandleUsage(context.Background(), Record{Model: "m1", AuthID: "a", RequestedAt: time.Now(), Detail: Detail{InputTokens: 3}})
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if rows := NewCounters(CountersOptions{Path: path}).Query(CounterFilter{}); len(rows) == 1 {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("background flusher did not persist the counters")
+}
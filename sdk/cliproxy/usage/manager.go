@@ -30,6 +30,8 @@ type Record struct {
 	AuthIndex    string
 	AuthType     string
 	Source       string
+	// RequestID correlates the record with the downstream request and its log lines.
+	RequestID string
 	// ReasoningEffort stores the translated upstream thinking level for request event logs.
 	ReasoningEffort string
 	// ServiceTier stores the client-requested service tier.
@@ -42,7 +44,10 @@ type Record struct {
 	// Generate reports whether the client requested actual generation.
 	// nil or true means generation is enabled; only an explicit false disables generation.
 	// Use GenerateFlag to set the value and GenerateEnabled to read it with the default.
-	Generate    *bool
+	Generate *bool
+	// Background names the internal lane (e.g. "warmup", "probe") that issued the request.
+	// Empty for client traffic; user-facing metrics skip background records.
+	Background  string
 	RequestedAt time.Time
 	Latency     time.Duration
 	TTFT        time.Duration
@@ -76,6 +81,7 @@ type requestedModelAliasContextKey struct{}
 type reasoningEffortContextKey struct{}
 type serviceTierContextKey struct{}
 type generateContextKey struct{}
+type backgroundLaneContextKey struct{}
 
 // WithRequestedModelAlias stores the client-requested model name for usage sinks.
 func WithRequestedModelAlias(ctx context.Context, alias string) context.Context {
@@ -193,6 +199,27 @@ func GenerateFromContext(ctx context.Context) bool {
 	}
 }
 
+// WithBackgroundLane marks ctx as internal background traffic of the given kind.
+func WithBackgroundLane(ctx context.Context, kind string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	kind = strings.TrimSpace(kind)
+	if kind == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, backgroundLaneContextKey{}, kind)
+}
+
+// BackgroundLaneFromContext returns the background lane kind stored in ctx, or "" for client traffic.
+func BackgroundLaneFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	kind, _ := ctx.Value(backgroundLaneContextKey{}).(string)
+	return kind
+}
+
 // GenerateFlag returns a pointer suitable for Record.Generate.
 func GenerateFlag(generate bool) *bool {
 	return &generate
@@ -223,14 +250,16 @@ type Manager struct {
 	stopOnce sync.Once
 	cancel   context.CancelFunc
 
-	mu     sync.Mutex
-	cond   *sync.Cond
-	queue  []queueItem
-	closed bool
+	mu          sync.Mutex
+	cond        *sync.Cond
+	queue       []queueItem
+	closed      bool
+	dispatching bool
 
 	pluginsMu sync.RWMutex
 	plugins   []Plugin
 	named     map[string]int
+	counters  *Counters
 }
 
 // NewManager constructs a manager with a buffered queue.
@@ -331,12 +360,43 @@ func (m *Manager) run(ctx context.Context) {
 		}
 		if len(m.queue) == 0 && m.closed {
 			m.mu.Unlock()
+			// Persist counters once the remaining records have been delivered.
+			if errFlush := m.Counters().Flush(); errFlush != nil {
+				log.Warnf("usage counters: %v", errFlush)
+			}
 			return
 		}
 		item := m.queue[0]
 		m.queue = m.queue[1:]
+		m.dispatching = true
 		m.mu.Unlock()
 		m.dispatch(item)
+		m.mu.Lock()
+		m.dispatching = false
+		m.mu.Unlock()
+	}
+}
+
+// Drain waits until every queued record has been delivered to the plugins or ctx is done.
+// Unlike Stop it keeps the dispatcher running.
+func (m *Manager) Drain(ctx context.Context) error {
+	if m == nil {
+		return nil
+	}
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		m.mu.Lock()
+		idle := len(m.queue) == 0 && !m.dispatching
+		m.mu.Unlock()
+		if idle {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
 	}
 }
 
@@ -382,5 +442,8 @@ func PublishRecord(ctx context.Context, record Record) { DefaultManager().Publis
 // StartDefault starts the default manager's dispatcher.
 func StartDefault(ctx context.Context) { DefaultManager().Start(ctx) }
 
+// QueryCounters queries the persistent counters installed on the default manager.
+func QueryCounters(filter CounterFilter) []CounterRow { return DefaultManager().QueryCounters(filter) }
+
 // StopDefault stops the default manager's dispatcher.
 func StopDefault() { DefaultManager().Stop() }
@@ -3,6 +3,7 @@ package usage
 import (
 	"context"
 	"testing"
+	"time"
 )
 
 func TestGenerateEnabledDefaultsNilToTrue(t *testing.T) {
@@ -50,3 +51,28 @@ func TestRecordOmittedGenerateIsEnabled(t *testing.T) {
 		t.Fatalf("GenerateEnabled(omitted) = false, want true")
 	}
 }
+
+type slowPlugin struct {
+	delivered chan struct{}
+}
+
+func (p *slowPlugin) HandleUsage(context.Context, Record) {
+	time.Sleep(20 * time.Millisecond)
+	p.delivered <- struct{}{}
+}
+
+func TestManagerDrainWaitsForDelivery(t *testing.T) {
+	manager := NewManager(8)
+	plugin := &slowPlugin{delivered: make(chan struct{}, 3)}
+	manager.Register(plugin)
+	for i := 0; i < 3; i++ {
+		manager.Publish(context.Background(), Record{AuthID: "a"})
+	}
+	if err := manager.Drain(context.Background()); err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	if got := len(plugin.delivered); got != 3 {
+		t.Fatalf("delivered %d records before Drain returned, want 3", got)
+	}
+	manager.Stop()
+}
@@ -11,7 +11,50 @@ type SDKConfig = internalconfig.SDKConfig
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
+type IdempotencyConfig = internalconfig.IdempotencyConfig
+type CostCeilingConfig = internalconfig.CostCeilingConfig
+type RequestDeadlineConfig = internalconfig.RequestDeadlineConfig
+type RequestCoalescingConfig = internalconfig.RequestCoalescingConfig
+type ResponseCacheConfig = internalconfig.ResponseCacheConfig
+type BatchConfig = internalconfig.BatchConfig
+type ReasoningPolicyConfig = internalconfig.ReasoningPolicyConfig
+type APIKeyPolicy = internalconfig.APIKeyPolicy
+type TenantsConfig = internalconfig.TenantsConfig
+type UsageCountersConfig = internalconfig.UsageCountersConfig
+type BackgroundLaneConfig = internalconfig.BackgroundLaneConfig
+type RequestPriorityConfig = internalconfig.RequestPriorityConfig
+type HeaderProfile = internalconfig.HeaderProfile
+type OAuthCallbackConfig = internalconfig.OAuthCallbackConfig
+type SelectionAuditConfig = internalconfig.SelectionAuditConfig
+type HealthProbeConfig = internalconfig.HealthProbeConfig
+type ForbiddenRule = internalconfig.ForbiddenRule
+type WarmupConfig = internalconfig.WarmupConfig
+type WarmupSchedule = internalconfig.WarmupSchedule
+type AuthPersistenceConfig = internalconfig.AuthPersistenceConfig
+type HookDispatchConfig = internalconfig.HookDispatchConfig
+type ModelRefreshConfig = internalconfig.ModelRefreshConfig
+type AuthWebhookConfig = internalconfig.AuthWebhookConfig
+type ExpiryReportConfig = internalconfig.ExpiryReportConfig
+type DailyCapsConfig = internalconfig.DailyCapsConfig
+type QuotaResetRule = internalconfig.QuotaResetRule
+type RetryBackoffRule = internalconfig.RetryBackoffRule
+type DailyCapRule = internalconfig.DailyCapRule
+type AuthEncryptionConfig = internalconfig.AuthEncryptionConfig
+type SecretsConfig = internalconfig.SecretsConfig
 type TLSConfig = internalconfig.TLSConfig
+type ACMEConfig = internalconfig.ACMEConfig
+type ProxyPool = internalconfig.ProxyPool
+type OutboundTLSConfig = internalconfig.OutboundTLSConfig
+type OutboundTLSOptions = internalconfig.OutboundTLSOptions
+type RequestIDConfig = internalconfig.RequestIDConfig
+type CassetteConfig = internalconfig.CassetteConfig
+type ModelResolutionConfig = internalconfig.ModelResolutionConfig
+type ModelMapping = internalconfig.ModelMapping
+type RoutingHeadersConfig = internalconfig.RoutingHeadersConfig
+type ContextPreflightConfig = internalconfig.ContextPreflightConfig
+type ContextTruncationConfig = internalconfig.ContextTruncationConfig
+type HistoryCompressionConfig = internalconfig.HistoryCompressionConfig
+type SessionsConfig = internalconfig.SessionsConfig
 type RemoteManagement = internalconfig.RemoteManagement
 type OAuthModelAlias = internalconfig.OAuthModelAlias
 type PayloadConfig = internalconfig.PayloadConfig
@@ -28,6 +71,9 @@ type CommandCodeKey = internalconfig.CommandCodeKey
 type CommandCodeModel = internalconfig.CommandCodeModel
 type MistralKey = internalconfig.MistralKey
 type MistralModel = internalconfig.MistralModel
+type MockProviderConfig = internalconfig.MockProviderConfig
+type MockModel = internalconfig.MockModel
+type MockStep = internalconfig.MockStep
 type VertexCompatKey = internalconfig.VertexCompatKey
 type VertexCompatModel = internalconfig.VertexCompatModel
 type OpenAICompatibility = internalconfig.OpenAICompatibility
@@ -38,6 +84,16 @@ type TLS = internalconfig.TLSConfig
 
 const (
 	DefaultPanelGitHubRepository = internalconfig.DefaultPanelGitHubRepository
+
+	ReasoningPolicyExpose  = internalconfig.ReasoningPolicyExpose
+	ReasoningPolicyStrip   = internalconfig.ReasoningPolicyStrip
+	ReasoningPolicyConvert = internalconfig.ReasoningPolicyConvert
+
+	AuthPersistenceModeAsync = internalconfig.AuthPersistenceModeAsync
+	AuthPersistenceModeSync  = internalconfig.AuthPersistenceModeSync
+
+	HookDispatchModeAsync = internalconfig.HookDispatchModeAsync
+	HookDispatchModeSync  = internalconfig.HookDispatchModeSync
 )
 
 func LoadConfig(configFile string) (*Config, error) { return internalconfig.LoadConfig(configFile) }
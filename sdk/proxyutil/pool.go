@@ -0,0 +1,392 @@
+package proxyutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/net/proxy"
+)
+
+// PoolScheme prefixes proxy settings that reference a named pool, e.g. "pool://residential".
+const PoolScheme = "pool://"
+
+const (
+	defaultPoolCheckInterval = 30 * time.Second
+	poolCheckTimeout         = 10 * time.Second
+)
+
+// PoolOptions describes a named pool of outbound proxies.
+type PoolOptions struct {
+	Name    string
+	Proxies []string
+	// HealthCheckURL is fetched through every member each HealthCheckInterval. Without it,
+	// a member that failed to connect is retried once the interval has passed.
+	HealthCheckURL      string
+	HealthCheckInterval time.Duration
+}
+
+// MemberStatus is the health of one pool member.
+type MemberStatus struct {
+	Proxy     string    `json:"proxy"`
+	Healthy   bool      `json:"healthy"`
+	DownUntil time.Time `json:"down_until,omitempty"`
+	LastError string    `json:"last_error,omitempty"`
+}
+
+// Pool rotates outbound connections across proxies round-robin and skips members whose
+// connections recently failed.
+type Pool struct {
+	opts     PoolOptions
+	tlsKey   string
+	interval time.Duration
+	members  []*poolMember
+	next     atomic.Uint64
+	stop     chan struct{}
+}
+
+type poolMember struct {
+	raw       string
+	dialer    proxy.Dialer
+	transport *http.Transport
+	downUntil atomic.Int64
+	lastError atomic.Value
+}
+
+// poolDialError marks a failure to reach the target through a member, which is safe to
+// retry on the next member because nothing was sent upstream.
+type poolDialError struct {
+	member *poolMember
+	err    error
+}
+
+func (e *poolDialError) Error() string {
+	return fmt.Sprintf("proxy %s: %v", Redact(e.member.raw), e.err)
+}
+
+func (e *poolDialError) Unwrap() error { return e.err }
+
+var (
+	poolsMu sync.RWMutex
+	pools   = make(map[string]*Pool)
+)
+
+// ConfigurePools replaces the registered pools. Pools whose options and outbound TLS
+// configuration did not change keep their connections and health state.
+func ConfigurePools(options []PoolOptions) error {
+	next := make(map[string]*Pool, len(options))
+	var errs []error
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+	for _, opt := range options {
+		name := strings.TrimSpace(opt.Name)
+		if name == "" {
+			continue
+		}
+		if existing := pools[name]; existing != nil && reflect.DeepEqual(existing.opts, opt) && existing.tlsKey == TLSConfigKey("") {
+			next[name] = existing
+			continue
+		}
+		pool, errPool := newPool(opt)
+		if errPool != nil {
+			errs = append(errs, fmt.Errorf("proxy pool %q: %w", name, errPool))
+			continue
+		}
+		next[name] = pool
+	}
+	for name, pool := range pools {
+		if next[name] != pool {
+			pool.close()
+		}
+	}
+	pools = next
+	for _, pool := range pools {
+		pool.start()
+	}
+	return errors.Join(errs...)
+}
+
+// LookupPool returns the registered pool with the given name.
+func LookupPool(name string) (*Pool, bool) {
+	poolsMu.RLock()
+	defer poolsMu.RUnlock()
+	pool, ok := pools[name]
+	return pool, ok
+}
+
+// PoolName returns the pool referenced by a "pool://<name>" proxy setting.
+func PoolName(raw string) (string, bool) {
+	trimmed := strings.TrimSpace(raw)
+	if len(trimmed) <= len(PoolScheme) || !strings.EqualFold(trimmed[:len(PoolScheme)], PoolScheme) {
+		return "", false
+	}
+	return strings.TrimSpace(trimmed[len(PoolScheme):]), true
+}
+
+// BuildRoundTripper is BuildHTTPTransport extended with "pool://<name>" settings. Pool
+// round trippers resolve the pool on every request, so they stay valid across reloads.
+func BuildRoundTripper(raw string) (http.RoundTripper, Mode, error) {
+	if name, ok := PoolName(raw); ok {
+		if _, exists := LookupPool(name); !exists {
+			return nil, ModeInvalid, fmt.Errorf("proxy pool %q is not configured", name)
+		}
+		return poolRoundTripper{name: name}, ModeProxy, nil
+	}
+	transport, mode, errBuild := BuildHTTPTransport(raw)
+	if transport == nil {
+		return nil, mode, errBuild
+	}
+	return transport, mode, errBuild
+}
+
+func newPool(opts PoolOptions) (*Pool, error) {
+	if len(opts.Proxies) == 0 {
+		return nil, fmt.Errorf("no proxies configured")
+	}
+	pool := &Pool{opts: opts, tlsKey: TLSConfigKey(""), interval: opts.HealthCheckInterval}
+	tlsConfig := TLSConfig("")
+	if pool.interval <= 0 {
+		pool.interval = defaultPoolCheckInterval
+	}
+	for _, raw := range opts.Proxies {
+		if _, nested := PoolName(raw); nested {
+			return nil, fmt.Errorf("pools cannot contain other pools")
+		}
+		dialer, mode, errDialer := BuildDialer(raw)
+		if errDialer != nil {
+			return nil, fmt.Errorf("%s: %w", Redact(raw), errDialer)
+		}
+		if mode != ModeProxy && mode != ModeDirect {
+			continue
+		}
+		member := &poolMember{raw: strings.TrimSpace(raw), dialer: dialer}
+		transport := withTransportOptions(cloneDefaultTransport(), nil, tlsConfig)
+		transport.Proxy = nil
+		transport.DialContext = member.dialContext
+		member.transport = transport
+		pool.members = append(pool.members, member)
+	}
+	if len(pool.members) == 0 {
+		return nil, fmt.Errorf("no usable proxies configured")
+	}
+	return pool, nil
+}
+
+func (m *poolMember) dialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	var (
+		conn    net.Conn
+		errDial error
+	)
+	if contextDialer, ok := m.dialer.(proxy.ContextDialer); ok {
+		conn, errDial = contextDialer.DialContext(ctx, network, addr)
+	} else {
+		conn, errDial = m.dialer.Dial(network, addr)
+	}
+	if errDial != nil {
+		return nil, &poolDialError{member: m, err: errDial}
+	}
+	return conn, nil
+}
+
+func (m *poolMember) healthy(now time.Time) bool {
+	return now.UnixNano() >= m.downUntil.Load()
+}
+
+func (m *poolMember) markDown(err error, interval time.Duration) {
+	m.downUntil.Store(time.Now().Add(interval).UnixNano())
+	m.lastError.Store(err.Error())
+}
+
+func (m *poolMember) markUp() {
+	m.downUntil.Store(0)
+}
+
+// order returns the members to try for one connection: healthy members round-robin,
+// followed by the members marked down as a last resort.
+func (p *Pool) order() []*poolMember {
+	start := int(p.next.Add(1)-1) % len(p.members)
+	now := time.Now()
+	out := make([]*poolMember, 0, len(p.members))
+	var down []*poolMember
+	for i := range p.members {
+		member := p.members[(start+i)%len(p.members)]
+		if member.healthy(now) {
+			out = append(out, member)
+		} else {
+			down = append(down, member)
+		}
+	}
+	return append(out, down...)
+}
+
+// RoundTrip sends the request through the next healthy member, moving on to the
+// following member when the proxy cannot be reached.
+func (p *Pool) RoundTrip(req *http.Request) (*http.Response, error) {
+	var lastErr error
+	for i, member := range p.order() {
+		attempt := req
+		if i > 0 {
+			if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+				return nil, lastErr
+			}
+			attempt = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, errBody := req.GetBody()
+				if errBody != nil {
+					return nil, lastErr
+				}
+				attempt.Body = body
+			}
+		}
+		resp, errRoundTrip := member.transport.RoundTrip(attempt)
+		if errRoundTrip == nil {
+			member.markUp()
+			return resp, nil
+		}
+		var dialErr *poolDialError
+		if !errors.As(errRoundTrip, &dialErr) || req.Context().Err() != nil {
+			return nil, errRoundTrip
+		}
+		member.markDown(dialErr.err, p.interval)
+		log.Warnf("proxy pool %s: %v, trying the next proxy", p.opts.Name, dialErr)
+		lastErr = errRoundTrip
+	}
+	return nil, lastErr
+}
+
+// Dial connects through the next healthy member, for connection-level clients.
+func (p *Pool) Dial(network, addr string) (net.Conn, error) {
+	var lastErr error
+	for _, member := range p.order() {
+		conn, errDial := member.dialContext(context.Background(), network, addr)
+		if errDial == nil {
+			member.markUp()
+			return conn, nil
+		}
+		member.markDown(errDial, p.interval)
+		log.Warnf("proxy pool %s: %v, trying the next proxy", p.opts.Name, errDial)
+		lastErr = errDial
+	}
+	return nil, lastErr
+}
+
+// Status reports the health of every member.
+func (p *Pool) Status() []MemberStatus {
+	now := time.Now()
+	out := make([]MemberStatus, 0, len(p.members))
+	for _, member := range p.members {
+		status := MemberStatus{Proxy: Redact(member.raw), Healthy: member.healthy(now)}
+		if !status.Healthy {
+			status.DownUntil = time.Unix(0, member.downUntil.Load())
+		}
+		if lastErr, ok := member.lastError.Load().(string); ok {
+			status.LastError = lastErr
+		}
+		out = append(out, status)
+	}
+	return out
+}
+
+func (p *Pool) start() {
+	if p.stop != nil || strings.TrimSpace(p.opts.HealthCheckURL) == "" {
+		return
+	}
+	p.stop = make(chan struct{})
+	go p.checkLoop(p.stop)
+}
+
+func (p *Pool) close() {
+	if p.stop != nil {
+		close(p.stop)
+	}
+	for _, member := range p.members {
+		member.transport.CloseIdleConnections()
+	}
+}
+
+func (p *Pool) checkLoop(stop <-chan struct{}) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		p.checkMembers()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Pool) checkMembers() {
+	var wg sync.WaitGroup
+	for _, member := range p.members {
+		wg.Add(1)
+		go func(member *poolMember) {
+			defer wg.Done()
+			if errCheck := p.checkMember(member); errCheck != nil {
+				if member.healthy(time.Now()) {
+					log.Warnf("proxy pool %s: health check failed for %s: %v", p.opts.Name, Redact(member.raw), errCheck)
+				}
+				member.markDown(errCheck, p.interval)
+				return
+			}
+			member.markUp()
+		}(member)
+	}
+	wg.Wait()
+}
+
+func (p *Pool) checkMember(member *poolMember) error {
+	ctx, cancel := context.WithTimeout(context.Background(), poolCheckTimeout)
+	defer cancel()
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodGet, p.opts.HealthCheckURL, nil)
+	if errReq != nil {
+		return errReq
+	}
+	resp, errDo := member.transport.RoundTrip(req)
+	if errDo != nil {
+		return errDo
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("health check returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// poolRoundTripper resolves its pool per request so cached clients follow reloads.
+type poolRoundTripper struct {
+	name string
+}
+
+func (t poolRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	pool, ok := LookupPool(t.name)
+	if !ok {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, fmt.Errorf("proxy pool %q is not configured", t.name)
+	}
+	return pool.RoundTrip(req)
+}
+
+// poolDialer resolves its pool per connection.
+type poolDialer struct {
+	name string
+}
+
+func (d poolDialer) Dial(network, addr string) (net.Conn, error) {
+	pool, ok := LookupPool(d.name)
+	if !ok {
+		return nil, fmt.Errorf("proxy pool %q is not configured", d.name)
+	}
+	return pool.Dial(network, addr)
+}
@@ -0,0 +1,116 @@
+package proxyutil
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// closedProxyURL returns an HTTP proxy URL whose port refuses connections.
+func closedProxyURL(t *testing.T) string {
+	t.Helper()
+
+	listener, errListen := net.Listen("tcp", "127.0.0.1:0")
+	if errListen != nil {
+		t.Fatalf("listen: %v", errListen)
+	}
+	addr := listener.Addr().String()
+	_ = listener.Close()
+	return "http://" + addr
+}
+
+func configureTestPools(t *testing.T, options ...PoolOptions) {
+	t.Helper()
+
+	if errConfigure := ConfigurePools(options); errConfigure != nil {
+		t.Fatalf("ConfigurePools returned error: %v", errConfigure)
+	}
+	t.Cleanup(func() { _ = ConfigurePools(nil) })
+}
+
+func TestPoolRotatesPastUnreachableProxy(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		_, _ = w.Write(body)
+	}))
+	defer upstream.Close()
+
+	configureTestPools(t, PoolOptions{Name: "test", Proxies: []string{closedProxyURL(t), "direct"}})
+	transport, mode, errBuild := BuildRoundTripper("pool://test")
+	if errBuild != nil || mode != ModeProxy {
+		t.Fatalf("BuildRoundTripper = mode %d, err %v", mode, errBuild)
+	}
+	client := &http.Client{Transport: transport}
+
+	// Both rotation orders are covered: the unreachable member is first on one of the requests.
+	for i := 0; i < 2; i++ {
+		resp, errPost := client.Post(upstream.URL, "text/plain", strings.NewReader("ping"))
+		if errPost != nil {
+			t.Fatalf("request %d failed: %v", i, errPost)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if string(body) != "ping" {
+			t.Fatalf("request %d body = %q, want the replayed request body", i, body)
+		}
+	}
+
+	pool, _ := LookupPool("test")
+	status := pool.Status()
+	if len(status) != 2 || status[0].Healthy || status[0].LastError == "" || !status[1].Healthy {
+		t.Fatalf("status = %+v, want the unreachable member marked down", status)
+	}
+}
+
+func TestBuildRoundTripperUnknownPool(t *testing.T) {
+	if _, mode, errBuild := BuildRoundTripper("pool://missing"); errBuild == nil || mode != ModeInvalid {
+		t.Fatalf("BuildRoundTripper = mode %d, err %v, want an error", mode, errBuild)
+	}
+	if _, _, errDialer := BuildDialer("pool://missing"); errDialer == nil {
+		t.Fatal("BuildDialer accepted an unknown pool")
+	}
+}
+
+func TestConfigurePoolsRejectsInvalidPools(t *testing.T) {
+	defer func() { _ = ConfigurePools(nil) }()
+
+	errConfigure := ConfigurePools([]PoolOptions{
+		{Name: "empty"},
+		{Name: "nested", Proxies: []string{"pool://other"}},
+		{Name: "ok", Proxies: []string{"direct"}},
+	})
+	if errConfigure == nil {
+		t.Fatal("expected errors for the empty and nested pools")
+	}
+	if _, ok := LookupPool("ok"); !ok {
+		t.Fatal("valid pool was not registered")
+	}
+	if _, ok := LookupPool("nested"); ok {
+		t.Fatal("nested pool was registered")
+	}
+}
+
+func TestPoolHealthCheck(t *testing.T) {
+	health := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer health.Close()
+
+	pool, errPool := newPool(PoolOptions{Name: "health", Proxies: []string{closedProxyURL(t), "direct"}, HealthCheckURL: health.URL})
+	if errPool != nil {
+		t.Fatalf("newPool returned error: %v", errPool)
+	}
+	defer pool.close()
+
+	pool.checkMembers()
+	status := pool.Status()
+	if status[0].Healthy || !status[1].Healthy {
+		t.Fatalf("status = %+v, want only the direct member healthy", status)
+	}
+	if order := pool.order(); order[0] != pool.members[1] {
+		t.Fatal("down member was not moved behind the healthy one")
+	}
+}
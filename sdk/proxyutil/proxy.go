@@ -86,7 +86,15 @@ func NewDirectTransport() *http.Transport {
 }
 
 // BuildHTTPTransport constructs an HTTP transport for the provided proxy setting.
+// The global outbound TLS configuration, if any, applies to the transport.
 func BuildHTTPTransport(raw string) (*http.Transport, Mode, error) {
+	return buildHTTPTransport(raw, nil, TLSConfig(""))
+}
+
+// buildHTTPTransport builds the transport for raw. A non-nil local dialer is used for every
+// outbound connection, including the connection to the proxy. A non-nil local dialer or TLS
+// configuration forces a transport even when raw inherits the defaults.
+func buildHTTPTransport(raw string, local *net.Dialer, tlsConfig *tls.Config) (*http.Transport, Mode, error) {
 	setting, errParse := Parse(raw)
 	if errParse != nil {
 		return nil, setting.Mode, errParse
@@ -94,9 +102,12 @@ func BuildHTTPTransport(raw string) (*http.Transport, Mode, error) {
 
 	switch setting.Mode {
 	case ModeInherit:
-		return nil, setting.Mode, nil
+		if local == nil && tlsConfig == nil {
+			return nil, setting.Mode, nil
+		}
+		return withTransportOptions(cloneDefaultTransport(), local, tlsConfig), setting.Mode, nil
 	case ModeDirect:
-		return NewDirectTransport(), setting.Mode, nil
+		return withTransportOptions(NewDirectTransport(), local, tlsConfig), setting.Mode, nil
 	case ModeProxy:
 		if setting.URL.Scheme == "socks5" || setting.URL.Scheme == "socks5h" {
 			var proxyAuth *proxy.Auth
@@ -105,18 +116,18 @@ func BuildHTTPTransport(raw string) (*http.Transport, Mode, error) {
 				password, _ := setting.URL.User.Password()
 				proxyAuth = &proxy.Auth{User: username, Password: password}
 			}
-			dialer, errSOCKS5 := proxy.SOCKS5("tcp", setting.URL.Host, proxyAuth, proxy.Direct)
+			dialer, errSOCKS5 := proxy.SOCKS5("tcp", setting.URL.Host, proxyAuth, forwardDialer(local))
 			if errSOCKS5 != nil {
 				return nil, setting.Mode, fmt.Errorf("create SOCKS5 dialer failed: %w", errSOCKS5)
 			}
-			transport := cloneDefaultTransport()
+			transport := withTransportOptions(cloneDefaultTransport(), nil, tlsConfig)
 			transport.Proxy = nil
 			transport.DialContext = func(_ context.Context, network, addr string) (net.Conn, error) {
 				return dialer.Dial(network, addr)
 			}
 			return transport, setting.Mode, nil
 		}
-		transport := cloneDefaultTransport()
+		transport := withTransportOptions(cloneDefaultTransport(), local, tlsConfig)
 		transport.Proxy = http.ProxyURL(setting.URL)
 		return transport, setting.Mode, nil
 	default:
@@ -126,6 +137,19 @@ func BuildHTTPTransport(raw string) (*http.Transport, Mode, error) {
 
 // BuildDialer constructs a proxy dialer for settings that operate at the connection layer.
 func BuildDialer(raw string) (proxy.Dialer, Mode, error) {
+	return buildDialer(raw, nil)
+}
+
+func buildDialer(raw string, local *net.Dialer) (proxy.Dialer, Mode, error) {
+	if name, ok := PoolName(raw); ok {
+		if local != nil {
+			return nil, ModeInvalid, fmt.Errorf("proxy pool %q cannot be combined with a source address", name)
+		}
+		if _, exists := LookupPool(name); !exists {
+			return nil, ModeInvalid, fmt.Errorf("proxy pool %q is not configured", name)
+		}
+		return poolDialer{name: name}, ModeProxy, nil
+	}
 	setting, errParse := Parse(raw)
 	if errParse != nil {
 		return nil, setting.Mode, errParse
@@ -133,14 +157,17 @@ func BuildDialer(raw string) (proxy.Dialer, Mode, error) {
 
 	switch setting.Mode {
 	case ModeInherit:
-		return nil, setting.Mode, nil
+		if local == nil {
+			return nil, setting.Mode, nil
+		}
+		return local, setting.Mode, nil
 	case ModeDirect:
-		return proxy.Direct, setting.Mode, nil
+		return forwardDialer(local), setting.Mode, nil
 	case ModeProxy:
 		if setting.URL.Scheme == "http" || setting.URL.Scheme == "https" {
-			return &httpConnectDialer{proxyURL: setting.URL, dialer: proxy.Direct}, setting.Mode, nil
+			return &httpConnectDialer{proxyURL: setting.URL, dialer: forwardDialer(local), tlsConfig: TLSConfig("")}, setting.Mode, nil
 		}
-		dialer, errDialer := proxy.FromURL(setting.URL, proxy.Direct)
+		dialer, errDialer := proxy.FromURL(setting.URL, forwardDialer(local))
 		if errDialer != nil {
 			return nil, setting.Mode, fmt.Errorf("create proxy dialer failed: %w", errDialer)
 		}
@@ -150,9 +177,27 @@ func BuildDialer(raw string) (proxy.Dialer, Mode, error) {
 	}
 }
 
+func withTransportOptions(transport *http.Transport, local *net.Dialer, tlsConfig *tls.Config) *http.Transport {
+	if local != nil {
+		transport.DialContext = local.DialContext
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport
+}
+
+func forwardDialer(local *net.Dialer) proxy.Dialer {
+	if local == nil {
+		return proxy.Direct
+	}
+	return local
+}
+
 type httpConnectDialer struct {
-	proxyURL *url.URL
-	dialer   proxy.Dialer
+	proxyURL  *url.URL
+	dialer    proxy.Dialer
+	tlsConfig *tls.Config
 }
 
 func (d *httpConnectDialer) Dial(network, addr string) (net.Conn, error) {
@@ -163,7 +208,12 @@ func (d *httpConnectDialer) Dial(network, addr string) (net.Conn, error) {
 
 	conn := proxyConn
 	if d.proxyURL.Scheme == "https" {
-		tlsConn := tls.Client(conn, &tls.Config{ServerName: d.proxyURL.Hostname()})
+		tlsConfig := &tls.Config{}
+		if d.tlsConfig != nil {
+			tlsConfig = d.tlsConfig.Clone()
+		}
+		tlsConfig.ServerName = d.proxyURL.Hostname()
+		tlsConn := tls.Client(conn, tlsConfig)
 		if errHandshake := tlsConn.Handshake(); errHandshake != nil {
 			if errClose := conn.Close(); errClose != nil {
 				return nil, fmt.Errorf("HTTPS proxy TLS handshake failed: %w; close failed: %v", errHandshake, errClose)
@@ -0,0 +1,101 @@
+package proxyutil
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// LocalDialer returns a dialer whose connections originate from source, which is either a
+// local IP address or the name of a network interface. Interfaces use their first IPv4
+// address, falling back to the first IPv6 address.
+func LocalDialer(source string) (*net.Dialer, error) {
+	source = strings.TrimSpace(source)
+	ip := net.ParseIP(source)
+	if ip == nil {
+		resolved, errResolve := interfaceIP(source)
+		if errResolve != nil {
+			return nil, errResolve
+		}
+		ip = resolved
+	}
+	return &net.Dialer{
+		LocalAddr: &net.TCPAddr{IP: ip},
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}, nil
+}
+
+func interfaceIP(name string) (net.IP, error) {
+	iface, errIface := net.InterfaceByName(name)
+	if errIface != nil {
+		return nil, fmt.Errorf("source address %q is neither an IP address nor a network interface", name)
+	}
+	addrs, errAddrs := iface.Addrs()
+	if errAddrs != nil {
+		return nil, fmt.Errorf("read addresses of interface %s: %w", name, errAddrs)
+	}
+	var fallback net.IP
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			return ipNet.IP, nil
+		}
+		if fallback == nil {
+			fallback = ipNet.IP
+		}
+	}
+	if fallback == nil {
+		return nil, fmt.Errorf("interface %s has no usable address", name)
+	}
+	return fallback, nil
+}
+
+// BuildSourceRoundTripper is BuildRoundTripper with outbound connections bound to source.
+// An empty source behaves exactly like BuildRoundTripper; proxy pools cannot be bound.
+func BuildSourceRoundTripper(raw, source string) (http.RoundTripper, Mode, error) {
+	return BuildProviderRoundTripper(raw, source, "")
+}
+
+// BuildProviderRoundTripper is BuildSourceRoundTripper using the outbound TLS configuration
+// of provider instead of the global one. Proxy pools always use the global configuration.
+func BuildProviderRoundTripper(raw, source, provider string) (http.RoundTripper, Mode, error) {
+	source = strings.TrimSpace(source)
+	if name, ok := PoolName(raw); ok {
+		if source != "" {
+			return nil, ModeInvalid, fmt.Errorf("proxy pool %q cannot be combined with a source address", name)
+		}
+		return BuildRoundTripper(raw)
+	}
+	var local *net.Dialer
+	if source != "" {
+		var errLocal error
+		if local, errLocal = LocalDialer(source); errLocal != nil {
+			return nil, ModeInvalid, errLocal
+		}
+	}
+	transport, mode, errBuild := buildHTTPTransport(raw, local, TLSConfig(provider))
+	if transport == nil {
+		return nil, mode, errBuild
+	}
+	return transport, mode, errBuild
+}
+
+// BuildSourceDialer is BuildDialer with outbound connections bound to source.
+func BuildSourceDialer(raw, source string) (proxy.Dialer, Mode, error) {
+	if strings.TrimSpace(source) == "" {
+		return BuildDialer(raw)
+	}
+	local, errLocal := LocalDialer(source)
+	if errLocal != nil {
+		return nil, ModeInvalid, errLocal
+	}
+	return buildDialer(raw, local)
+}
@@ -0,0 +1,45 @@
+package proxyutil
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBuildSourceRoundTripperBindsLocalAddress(t *testing.T) {
+	remote := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remote <- r.RemoteAddr
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	for _, raw := range []string{"", "direct"} {
+		transport, _, errBuild := BuildSourceRoundTripper(raw, "127.0.0.1")
+		if errBuild != nil || transport == nil {
+			t.Fatalf("BuildSourceRoundTripper(%q) = %v, %v", raw, transport, errBuild)
+		}
+		resp, errGet := (&http.Client{Transport: transport}).Get(server.URL)
+		if errGet != nil {
+			t.Fatalf("request through %q failed: %v", raw, errGet)
+		}
+		_ = resp.Body.Close()
+		host, _, _ := net.SplitHostPort(<-remote)
+		if host != "127.0.0.1" {
+			t.Fatalf("remote address = %s, want 127.0.0.1", host)
+		}
+	}
+}
+
+func TestBuildSourceRoundTripperErrors(t *testing.T) {
+	if _, _, errBuild := BuildSourceRoundTripper("", "no-such-interface0"); errBuild == nil {
+		t.Fatal("expected an error for an unknown interface")
+	}
+	if _, _, errBuild := BuildSourceRoundTripper("pool://any", "127.0.0.1"); errBuild == nil {
+		t.Fatal("expected an error when binding a proxy pool")
+	}
+	if transport, _, errBuild := BuildSourceRoundTripper("", ""); transport != nil || errBuild != nil {
+		t.Fatalf("empty settings = %v, %v, want no transport", transport, errBuild)
+	}
+}
@@ -0,0 +1,154 @@
+package proxyutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync/atomic"
+)
+
+// TLSOptions adds trust anchors and a client certificate to outbound TLS connections.
+type TLSOptions struct {
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string
+	// CertFile and KeyFile hold the PEM client certificate presented for mutual TLS.
+	CertFile string
+	KeyFile  string
+}
+
+func (o TLSOptions) empty() bool {
+	return strings.TrimSpace(o.CAFile) == "" && strings.TrimSpace(o.CertFile) == "" && strings.TrimSpace(o.KeyFile) == ""
+}
+
+// merge returns o with unset fields taken from base.
+func (o TLSOptions) merge(base TLSOptions) TLSOptions {
+	if strings.TrimSpace(o.CAFile) == "" {
+		o.CAFile = base.CAFile
+	}
+	if strings.TrimSpace(o.CertFile) == "" && strings.TrimSpace(o.KeyFile) == "" {
+		o.CertFile, o.KeyFile = base.CertFile, base.KeyFile
+	}
+	return o
+}
+
+type tlsState struct {
+	generation uint64
+	options    TLSOptions
+	overrides  map[string]TLSOptions
+	global     *tls.Config
+	providers  map[string]*tls.Config
+}
+
+var (
+	currentTLS    atomic.Pointer[tlsState]
+	tlsGeneration atomic.Uint64
+)
+
+// LoadTLSConfig builds a client TLS configuration from opts. It returns nil when opts is empty.
+func LoadTLSConfig(opts TLSOptions) (*tls.Config, error) {
+	if opts.empty() {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile := strings.TrimSpace(opts.CAFile); caFile != "" {
+		pem, errRead := os.ReadFile(caFile)
+		if errRead != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", errRead)
+		}
+		pool, errPool := x509.SystemCertPool()
+		if errPool != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("CA bundle %s contains no certificates", caFile)
+		}
+		cfg.RootCAs = pool
+	}
+	certFile, keyFile := strings.TrimSpace(opts.CertFile), strings.TrimSpace(opts.KeyFile)
+	if certFile != "" || keyFile != "" {
+		if certFile == "" || keyFile == "" {
+			return nil, errors.New("client certificate requires both cert-file and key-file")
+		}
+		cert, errCert := tls.LoadX509KeyPair(certFile, keyFile)
+		if errCert != nil {
+			return nil, fmt.Errorf("load client certificate: %w", errCert)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// ConfigureTLS replaces the outbound TLS settings. Provider entries inherit unset fields
+// from the global options. Entries that fail to load are reported and left out, so the
+// affected provider falls back to the global settings. Calls with unchanged options keep
+// the loaded configuration.
+func ConfigureTLS(global TLSOptions, providers map[string]TLSOptions) error {
+	if previous := currentTLS.Load(); previous != nil && previous.options == global && reflect.DeepEqual(previous.overrides, providers) {
+		return nil
+	}
+	state := &tlsState{
+		generation: tlsGeneration.Add(1),
+		options:    global,
+		overrides:  providers,
+		providers:  make(map[string]*tls.Config),
+	}
+	var errs []error
+	globalCfg, errGlobal := LoadTLSConfig(global)
+	if errGlobal != nil {
+		errs = append(errs, fmt.Errorf("outbound TLS: %w", errGlobal))
+	} else {
+		state.global = globalCfg
+	}
+	for provider, opts := range providers {
+		key := strings.ToLower(strings.TrimSpace(provider))
+		if key == "" || opts.empty() {
+			continue
+		}
+		providerCfg, errProvider := LoadTLSConfig(opts.merge(global))
+		if errProvider != nil {
+			errs = append(errs, fmt.Errorf("outbound TLS for %s: %w", key, errProvider))
+			continue
+		}
+		state.providers[key] = providerCfg
+	}
+	currentTLS.Store(state)
+	return errors.Join(errs...)
+}
+
+// TLSConfig returns the outbound TLS configuration for provider, falling back to the
+// global configuration. It returns nil when neither is configured.
+func TLSConfig(provider string) *tls.Config {
+	state := currentTLS.Load()
+	if state == nil {
+		return nil
+	}
+	if cfg := state.providers[strings.ToLower(strings.TrimSpace(provider))]; cfg != nil {
+		return cfg.Clone()
+	}
+	if state.global != nil {
+		return state.global.Clone()
+	}
+	return nil
+}
+
+// TLSConfigKey identifies the TLS configuration TLSConfig(provider) returns, for callers
+// that cache transports. It is empty when no TLS configuration applies and changes on
+// every ConfigureTLS call.
+func TLSConfigKey(provider string) string {
+	state := currentTLS.Load()
+	if state == nil {
+		return ""
+	}
+	key := strings.ToLower(strings.TrimSpace(provider))
+	if state.providers[key] != nil {
+		return fmt.Sprintf("tls%d:%s", state.generation, key)
+	}
+	if state.global != nil {
+		return fmt.Sprintf("tls%d", state.generation)
+	}
+	return ""
+}
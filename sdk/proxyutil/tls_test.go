@@ -0,0 +1,143 @@
+package proxyutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if errWrite := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); errWrite != nil {
+		t.Fatalf("write %s: %v", name, errWrite)
+	}
+	return path
+}
+
+// writeClientCertificate writes a self-signed client certificate and its key.
+func writeClientCertificate(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+
+	key, errKey := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if errKey != nil {
+		t.Fatalf("generate key: %v", errKey)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, errCert := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if errCert != nil {
+		t.Fatalf("create certificate: %v", errCert)
+	}
+	keyDER, errMarshal := x509.MarshalECPrivateKey(key)
+	if errMarshal != nil {
+		t.Fatalf("marshal key: %v", errMarshal)
+	}
+	return writePEM(t, "client.pem", "CERTIFICATE", der), writePEM(t, "client-key.pem", "EC PRIVATE KEY", keyDER)
+}
+
+func configureTestTLS(t *testing.T, global TLSOptions, providers map[string]TLSOptions) {
+	t.Helper()
+
+	if errConfigure := ConfigureTLS(global, providers); errConfigure != nil {
+		t.Fatalf("ConfigureTLS returned error: %v", errConfigure)
+	}
+	t.Cleanup(func() { _ = ConfigureTLS(TLSOptions{}, nil) })
+}
+
+func TestOutboundTLSCustomCAAndClientCertificate(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+	certFile, keyFile := writeClientCertificate(t)
+
+	// Without the CA the server certificate is rejected.
+	if resp, errGet := (&http.Client{Transport: NewDirectTransport()}).Get(server.URL); errGet == nil {
+		_ = resp.Body.Close()
+		t.Fatal("request succeeded without trusting the server CA")
+	}
+
+	configureTestTLS(t, TLSOptions{CAFile: caFile, CertFile: certFile, KeyFile: keyFile}, nil)
+	transport, mode, errBuild := BuildHTTPTransport("")
+	if errBuild != nil || transport == nil || mode != ModeInherit {
+		t.Fatalf("BuildHTTPTransport = %v, %d, %v", transport, mode, errBuild)
+	}
+	resp, errGet := (&http.Client{Transport: transport}).Get(server.URL)
+	if errGet != nil {
+		t.Fatalf("request failed: %v", errGet)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Fatalf("status = %d, want the client certificate to be presented", resp.StatusCode)
+	}
+}
+
+func TestOutboundTLSProviderOverrides(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {}))
+	defer server.Close()
+	caFile := writePEM(t, "ca.pem", "CERTIFICATE", server.Certificate().Raw)
+
+	configureTestTLS(t, TLSOptions{}, map[string]TLSOptions{"Claude": {CAFile: caFile}})
+	if TLSConfig("claude") == nil || TLSConfigKey("claude") == "" {
+		t.Fatal("provider override was not applied")
+	}
+	if TLSConfig("gemini") != nil || TLSConfigKey("gemini") != "" {
+		t.Fatal("providers without an override must keep the defaults")
+	}
+
+	transport, _, errBuild := BuildProviderRoundTripper("", "", "claude")
+	if errBuild != nil || transport == nil {
+		t.Fatalf("BuildProviderRoundTripper = %v, %v", transport, errBuild)
+	}
+	resp, errGet := (&http.Client{Transport: transport}).Get(server.URL)
+	if errGet != nil {
+		t.Fatalf("request failed: %v", errGet)
+	}
+	_ = resp.Body.Close()
+
+	key := TLSConfigKey("claude")
+	configureTestTLS(t, TLSOptions{}, map[string]TLSOptions{"Claude": {CAFile: caFile}})
+	if TLSConfigKey("claude") != key {
+		t.Fatal("unchanged options must keep the loaded configuration")
+	}
+}
+
+func TestConfigureTLSErrors(t *testing.T) {
+	defer func() { _ = ConfigureTLS(TLSOptions{}, nil) }()
+
+	if errConfigure := ConfigureTLS(TLSOptions{CAFile: filepath.Join(t.TempDir(), "missing.pem")}, nil); errConfigure == nil {
+		t.Fatal("expected an error for a missing CA bundle")
+	}
+	if TLSConfig("") != nil {
+		t.Fatal("a failed global configuration must not be applied")
+	}
+	if errConfigure := ConfigureTLS(TLSOptions{CertFile: "client.pem"}, nil); errConfigure == nil {
+		t.Fatal("expected an error for a certificate without a key")
+	}
+}
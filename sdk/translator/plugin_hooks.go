@@ -10,3 +10,10 @@ type PluginHooks interface {
 	TranslateResponse(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON, body []byte, stream bool) ([]byte, bool)
 	NormalizeResponseAfter(ctx context.Context, from, to Format, model string, originalRequestRawJSON, requestRawJSON, body []byte, stream bool) []byte
 }
+
+// TranslatorHookReporter is optionally implemented by PluginHooks to report whether any
+// translator hook is currently active. Hooks that do not implement it are assumed active,
+// which disables translation passthrough.
+type TranslatorHookReporter interface {
+	HasTranslatorHooks() bool
+}
@@ -11,17 +11,19 @@ import (
 
 // Registry manages translation functions across schemas.
 type Registry struct {
-	mu        sync.RWMutex
-	requests  map[Format]map[Format]RequestTransform
-	responses map[Format]map[Format]ResponseTransform
-	hooks     PluginHooks
+	mu          sync.RWMutex
+	requests    map[Format]map[Format]RequestTransform
+	responses   map[Format]map[Format]ResponseTransform
+	passthrough map[Format]struct{}
+	hooks       PluginHooks
 }
 
 // NewRegistry constructs an empty translator registry.
 func NewRegistry() *Registry {
 	return &Registry{
-		requests:  make(map[Format]map[Format]RequestTransform),
-		responses: make(map[Format]map[Format]ResponseTransform),
+		requests:    make(map[Format]map[Format]RequestTransform),
+		responses:   make(map[Format]map[Format]ResponseTransform),
+		passthrough: make(map[Format]struct{}),
 	}
 }
 
@@ -43,6 +45,38 @@ func (r *Registry) Register(from, to Format, request RequestTransform, response
 	r.responses[from][to] = response
 }
 
+// RegisterPassthrough declares that the translators registered from format to itself only
+// normalize the model field and the SSE framing, so executors may skip them and forward
+// payloads unchanged (see CanPassthrough).
+func (r *Registry) RegisterPassthrough(format Format) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.passthrough[format] = struct{}{}
+}
+
+// CanPassthrough reports whether a payload in from format can be forwarded to a to format
+// upstream without translation: the formats match, the format was registered with
+// RegisterPassthrough, and no plugin translator hook would observe or rewrite it.
+func (r *Registry) CanPassthrough(from, to Format) bool {
+	if from != to {
+		return false
+	}
+	r.mu.RLock()
+	_, ok := r.passthrough[from]
+	hooks := r.hooks
+	r.mu.RUnlock()
+
+	if !ok {
+		return false
+	}
+	if hooks == nil {
+		return true
+	}
+	reporter, isReporter := hooks.(TranslatorHookReporter)
+	return isReporter && !reporter.HasTranslatorHooks()
+}
+
 // SetPluginHooks stores translator plugin hooks for this registry.
 func (r *Registry) SetPluginHooks(hooks PluginHooks) {
 	r.mu.Lock()
@@ -228,6 +262,16 @@ func Register(from, to Format, request RequestTransform, response ResponseTransf
 	defaultRegistry.Register(from, to, request, response)
 }
 
+// RegisterPassthrough declares a passthrough format on the default registry.
+func RegisterPassthrough(format Format) {
+	defaultRegistry.RegisterPassthrough(format)
+}
+
+// CanPassthrough inspects the default registry.
+func CanPassthrough(from, to Format) bool {
+	return defaultRegistry.CanPassthrough(from, to)
+}
+
 // SetPluginHooks stores plugin hooks on the default registry.
 func SetPluginHooks(hooks PluginHooks) {
 	defaultRegistry.SetPluginHooks(hooks)
@@ -402,3 +402,39 @@ func TestPluginNormalizersChainAfterNative(t *testing.T) {
 		t.Fatalf("plugin translators should not run when native transformers exist, calls=%v", hooks.calls)
 	}
 }
+
+type reportingPluginHooks struct {
+	fakePluginHooks
+	active bool
+}
+
+func (h *reportingPluginHooks) HasTranslatorHooks() bool { return h.active }
+
+func TestCanPassthroughRequiresMatchingRegisteredFormatWithoutHooks(t *testing.T) {
+	r := NewRegistry()
+	openai := FromString("openai")
+	if r.CanPassthrough(openai, openai) {
+		t.Fatal("unregistered format allowed passthrough")
+	}
+	r.RegisterPassthrough(openai)
+	if !r.CanPassthrough(openai, openai) {
+		t.Fatal("registered format without hooks denied passthrough")
+	}
+	if r.CanPassthrough(openai, FromString("claude")) {
+		t.Fatal("different formats allowed passthrough")
+	}
+
+	r.SetPluginHooks(&fakePluginHooks{})
+	if r.CanPassthrough(openai, openai) {
+		t.Fatal("hooks without a reporter allowed passthrough")
+	}
+	hooks := &reportingPluginHooks{}
+	r.SetPluginHooks(hooks)
+	if !r.CanPassthrough(openai, openai) {
+		t.Fatal("inactive translator hooks denied passthrough")
+	}
+	hooks.active = true
+	if r.CanPassthrough(openai, openai) {
+		t.Fatal("active translator hooks allowed passthrough")
+	}
+}
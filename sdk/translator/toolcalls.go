@@ -0,0 +1,321 @@
+package translator
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ToolCallNormalizer rewrites OpenAI Chat Completions stream chunks so tool calls look the
+// same regardless of the upstream provider. Each call gets a stable index and ID, the ID,
+// type and name are sent only on the first delta of a call, argument fragments are
+// accumulated, and when a choice finishes the accumulated arguments are validated and,
+// where a closing suffix makes them valid JSON, completed with an extra argument delta.
+//
+// A normalizer holds per-stream state and must not be shared between streams.
+type ToolCallNormalizer struct {
+	choices  map[int64]*toolCallChoice
+	template []byte
+}
+
+type toolCallChoice struct {
+	calls   []*toolCallState
+	byIndex map[int64]*toolCallState
+	byID    map[string]*toolCallState
+	last    *toolCallState
+}
+
+type toolCallState struct {
+	index     int64
+	id        string
+	name      string
+	announced bool
+	arguments strings.Builder
+}
+
+// NewToolCallNormalizer returns a normalizer for a single OpenAI Chat Completions stream.
+func NewToolCallNormalizer() *ToolCallNormalizer {
+	return &ToolCallNormalizer{choices: make(map[int64]*toolCallChoice)}
+}
+
+// NormalizeChunk normalizes one chat.completion.chunk JSON payload. It usually returns the
+// rewritten chunk alone; when a choice finishes with incomplete tool-call arguments, an
+// additional chunk completing them is returned before it. Payloads that are not JSON are
+// returned unchanged.
+func (n *ToolCallNormalizer) NormalizeChunk(chunk []byte) [][]byte {
+	if n == nil || !gjson.ValidBytes(chunk) {
+		return [][]byte{chunk}
+	}
+	choices := gjson.GetBytes(chunk, "choices")
+	if !choices.IsArray() {
+		return [][]byte{chunk}
+	}
+	if n.template == nil {
+		n.template = append([]byte(nil), chunk...)
+	}
+
+	var out [][]byte
+	for position, choice := range choices.Array() {
+		choiceIndex := int64(position)
+		if idx := choice.Get("index"); idx.Exists() {
+			choiceIndex = idx.Int()
+		}
+		prefix := "choices." + strconv.Itoa(position)
+
+		if toolCalls := choice.Get("delta.tool_calls"); toolCalls.IsArray() && len(toolCalls.Array()) > 0 {
+			state := n.choice(choiceIndex)
+			normalized := []byte(`[]`)
+			for _, call := range toolCalls.Array() {
+				normalized, _ = sjson.SetRawBytes(normalized, "-1", state.normalizeDelta(call))
+			}
+			chunk, _ = sjson.SetRawBytes(chunk, prefix+".delta.tool_calls", normalized)
+		}
+
+		finish := choice.Get("finish_reason")
+		if finish.Type != gjson.String || finish.String() == "" {
+			continue
+		}
+		state, ok := n.choices[choiceIndex]
+		if !ok || len(state.calls) == 0 {
+			continue
+		}
+		if repair := n.repairChunk(choiceIndex, state); repair != nil {
+			out = append(out, repair)
+		}
+		if finish.String() == "stop" {
+			chunk, _ = sjson.SetBytes(chunk, prefix+".finish_reason", "tool_calls")
+		}
+		delete(n.choices, choiceIndex)
+	}
+	return append(out, chunk)
+}
+
+// Flush returns chunks completing the arguments of tool calls whose choice never reported
+// a finish_reason. It should be called once when the upstream stream ends.
+func (n *ToolCallNormalizer) Flush() [][]byte {
+	if n == nil {
+		return nil
+	}
+	var out [][]byte
+	for choiceIndex, state := range n.choices {
+		if repair := n.repairChunk(choiceIndex, state); repair != nil {
+			out = append(out, repair)
+		}
+		delete(n.choices, choiceIndex)
+	}
+	return out
+}
+
+func (n *ToolCallNormalizer) choice(index int64) *toolCallChoice {
+	state, ok := n.choices[index]
+	if !ok {
+		state = &toolCallChoice{byIndex: make(map[int64]*toolCallState), byID: make(map[string]*toolCallState)}
+		n.choices[index] = state
+	}
+	return state
+}
+
+// normalizeDelta maps one upstream tool-call fragment onto its call and returns the
+// OpenAI-style delta for it.
+func (c *toolCallChoice) normalizeDelta(call gjson.Result) []byte {
+	id := call.Get("id").String()
+	name := call.Get("function.name").String()
+
+	var state *toolCallState
+	switch idx := call.Get("index"); {
+	case id != "" && c.byID[id] != nil:
+		state = c.byID[id]
+	case idx.Exists() && c.byIndex[idx.Int()] != nil && (id == "" || c.byIndex[idx.Int()].id == id):
+		state = c.byIndex[idx.Int()]
+	case idx.Exists() && c.byIndex[idx.Int()] == nil:
+		state = c.add(idx.Int(), id)
+	case id == "" && name == "" && c.last != nil:
+		// Providers that omit indexes send continuation fragments without an id.
+		state = c.last
+	default:
+		// A new call reusing an index or without one gets the next free index.
+		state = c.add(int64(len(c.calls)), id)
+	}
+	c.last = state
+
+	delta := []byte(`{}`)
+	delta, _ = sjson.SetBytes(delta, "index", state.index)
+	if !state.announced {
+		state.announced = true
+		delta, _ = sjson.SetBytes(delta, "id", state.id)
+		delta, _ = sjson.SetBytes(delta, "type", "function")
+	}
+	if name != "" && state.name == "" {
+		state.name = name
+		delta, _ = sjson.SetBytes(delta, "function.name", name)
+	}
+	arguments := call.Get("function.arguments")
+	fragment := arguments.String()
+	if arguments.IsObject() || arguments.IsArray() {
+		fragment = arguments.Raw
+	}
+	if fragment != "" {
+		state.arguments.WriteString(fragment)
+		delta, _ = sjson.SetBytes(delta, "function.arguments", fragment)
+	}
+	return delta
+}
+
+func (c *toolCallChoice) add(index int64, id string) *toolCallState {
+	for c.byIndex[index] != nil {
+		index++
+	}
+	if id == "" {
+		id = NewToolCallID()
+	}
+	state := &toolCallState{index: index, id: id}
+	c.calls = append(c.calls, state)
+	c.byIndex[index] = state
+	c.byID[id] = state
+	return state
+}
+
+// repairChunk builds a chunk carrying the argument suffixes needed to make every call of
+// the choice valid JSON, or nil when nothing needs completing.
+func (n *ToolCallNormalizer) repairChunk(choiceIndex int64, state *toolCallChoice) []byte {
+	repairs := []byte(`[]`)
+	for _, call := range state.calls {
+		suffix, ok := ToolCallArgumentsSuffix(call.arguments.String())
+		if !ok {
+			log.Warnf("translator: tool call %s (%s) has invalid JSON arguments", call.id, call.name)
+			continue
+		}
+		if suffix == "" {
+			continue
+		}
+		repair := []byte(`{}`)
+		repair, _ = sjson.SetBytes(repair, "index", call.index)
+		repair, _ = sjson.SetBytes(repair, "function.arguments", suffix)
+		repairs, _ = sjson.SetRawBytes(repairs, "-1", repair)
+	}
+	if len(gjson.ParseBytes(repairs).Array()) == 0 {
+		return nil
+	}
+	chunk := []byte(`{}`)
+	for _, key := range []string{"id", "object", "created", "model", "system_fingerprint"} {
+		if value := gjson.GetBytes(n.template, key); value.Exists() {
+			chunk, _ = sjson.SetRawBytes(chunk, key, []byte(value.Raw))
+		}
+	}
+	choice := []byte(`{"delta":{},"finish_reason":null}`)
+	choice, _ = sjson.SetBytes(choice, "index", choiceIndex)
+	choice, _ = sjson.SetRawBytes(choice, "delta.tool_calls", repairs)
+	chunk, _ = sjson.SetRawBytes(chunk, "choices", []byte(`[]`))
+	chunk, _ = sjson.SetRawBytes(chunk, "choices.-1", choice)
+	return chunk
+}
+
+// NormalizeToolCallsNonStream normalizes the tool calls of a non-streaming OpenAI Chat
+// Completions response: missing IDs are assigned, type defaults to "function", object
+// arguments are encoded as strings, incomplete arguments are completed when possible,
+// and a "stop" finish_reason becomes "tool_calls" when the message carries tool calls.
+func NormalizeToolCallsNonStream(body []byte) []byte {
+	if !gjson.ValidBytes(body) {
+		return body
+	}
+	for i, choice := range gjson.GetBytes(body, "choices").Array() {
+		toolCalls := choice.Get("message.tool_calls")
+		if !toolCalls.IsArray() || len(toolCalls.Array()) == 0 {
+			continue
+		}
+		prefix := "choices." + strconv.Itoa(i)
+		for j, call := range toolCalls.Array() {
+			path := prefix + ".message.tool_calls." + strconv.Itoa(j)
+			if call.Get("id").String() == "" {
+				body, _ = sjson.SetBytes(body, path+".id", NewToolCallID())
+			}
+			if call.Get("type").String() == "" {
+				body, _ = sjson.SetBytes(body, path+".type", "function")
+			}
+			arguments := call.Get("function.arguments")
+			value := arguments.String()
+			if arguments.IsObject() || arguments.IsArray() {
+				value = arguments.Raw
+			}
+			suffix, ok := ToolCallArgumentsSuffix(value)
+			if !ok {
+				log.Warnf("translator: tool call %s has invalid JSON arguments", call.Get("id").String())
+			}
+			if value+suffix != arguments.Str || arguments.Type != gjson.String {
+				body, _ = sjson.SetBytes(body, path+".function.arguments", value+suffix)
+			}
+		}
+		if choice.Get("finish_reason").String() == "stop" {
+			body, _ = sjson.SetBytes(body, prefix+".finish_reason", "tool_calls")
+		}
+	}
+	return body
+}
+
+// NewToolCallID returns a fresh OpenAI-style tool call identifier.
+func NewToolCallID() string {
+	return "call_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:24]
+}
+
+// ToolCallArgumentsSuffix returns the text that must be appended to streamed tool-call
+// arguments to make them valid JSON: "{}" for empty arguments, the missing closing quote
+// and brackets for truncated ones, and "" for arguments that are already valid. ok is
+// false when no suffix can repair the arguments.
+func ToolCallArgumentsSuffix(arguments string) (suffix string, ok bool) {
+	trimmed := strings.TrimSpace(arguments)
+	if trimmed == "" {
+		return "{}", true
+	}
+	if gjson.Valid(trimmed) {
+		return "", true
+	}
+
+	var closers []byte
+	inString, escaped := false, false
+	for i := 0; i < len(trimmed); i++ {
+		ch := trimmed[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case ch == '\\':
+				escaped = true
+			case ch == '"':
+				inString = false
+			}
+			continue
+		}
+		switch ch {
+		case '"':
+			inString = true
+		case '{':
+			closers = append(closers, '}')
+		case '[':
+			closers = append(closers, ']')
+		case '}', ']':
+			if len(closers) == 0 || closers[len(closers)-1] != ch {
+				return "", false
+			}
+			closers = closers[:len(closers)-1]
+		}
+	}
+
+	var b strings.Builder
+	if escaped {
+		b.WriteByte('\\')
+	}
+	if inString {
+		b.WriteByte('"')
+	}
+	for i := len(closers) - 1; i >= 0; i-- {
+		b.WriteByte(closers[i])
+	}
+	if b.Len() == 0 || !gjson.Valid(trimmed+b.String()) {
+		return "", false
+	}
+	return b.String(), true
+}
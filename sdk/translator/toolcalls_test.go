@@ -0,0 +1,109 @@
+package translator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestToolCallNormalizerAssignsIDsAndRepairsArguments(t *testing.T) {
+	n := NewToolCallNormalizer()
+	chunks := []string{
+		// No id, and the name repeated on every fragment.
+		`{"id":"c1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"name":"lookup","arguments":"{\"q\":"}}]},"finish_reason":null}]}`,
+		`{"id":"c1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"name":"lookup","arguments":"\"go"}}]},"finish_reason":null}]}`,
+		// Second call reuses index 0 with a different id.
+		`{"id":"c1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"id":"call_b","function":{"name":"other","arguments":{"x":1}}}]},"finish_reason":null}]}`,
+		`{"id":"c1","object":"chat.completion.chunk","model":"m","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`,
+	}
+
+	var out [][]byte
+	for _, chunk := range chunks {
+		out = append(out, n.NormalizeChunk([]byte(chunk))...)
+	}
+	if len(out) != 5 {
+		t.Fatalf("chunks = %d, want 5 (one repair chunk)", len(out))
+	}
+
+	first := gjson.GetBytes(out[0], "choices.0.delta.tool_calls.0")
+	if !strings.HasPrefix(first.Get("id").String(), "call_") || first.Get("type").String() != "function" {
+		t.Fatalf("first delta missing id/type: %s", first.Raw)
+	}
+	second := gjson.GetBytes(out[1], "choices.0.delta.tool_calls.0")
+	if second.Get("id").Exists() || second.Get("function.name").Exists() {
+		t.Fatalf("continuation should carry only arguments: %s", second.Raw)
+	}
+	third := gjson.GetBytes(out[2], "choices.0.delta.tool_calls.0")
+	if third.Get("index").Int() != 1 || third.Get("id").String() != "call_b" || third.Get("function.arguments").String() != `{"x":1}` {
+		t.Fatalf("second call not normalized: %s", third.Raw)
+	}
+
+	repair := gjson.GetBytes(out[3], "choices.0.delta.tool_calls")
+	if len(repair.Array()) != 1 || repair.Get("0.index").Int() != 0 || repair.Get("0.function.arguments").String() != `"}` {
+		t.Fatalf("unexpected repair chunk: %s", out[3])
+	}
+	if gjson.GetBytes(out[3], "id").String() != "c1" {
+		t.Fatalf("repair chunk should reuse the stream id: %s", out[3])
+	}
+	if gjson.GetBytes(out[4], "choices.0.finish_reason").String() != "tool_calls" {
+		t.Fatalf("finish_reason not normalized: %s", out[4])
+	}
+	if extra := n.Flush(); len(extra) != 0 {
+		t.Fatalf("finished choice should not be flushed again: %d", len(extra))
+	}
+}
+
+func TestToolCallNormalizerFlushCompletesUnfinishedCalls(t *testing.T) {
+	n := NewToolCallNormalizer()
+	n.NormalizeChunk([]byte(`{"id":"c2","choices":[{"index":0,"delta":{"tool_calls":[{"id":"call_a","function":{"name":"f"}}]}}]}`))
+	flushed := n.Flush()
+	if len(flushed) != 1 || gjson.GetBytes(flushed[0], "choices.0.delta.tool_calls.0.function.arguments").String() != "{}" {
+		t.Fatalf("expected empty arguments to be completed with {}, got %q", flushed)
+	}
+}
+
+func TestNormalizeToolCallsNonStream(t *testing.T) {
+	body := []byte(`{"choices":[{"index":0,"message":{"role":"assistant","tool_calls":[{"function":{"name":"f","arguments":{"a":[1,2]}}},{"id":"call_x","type":"function","function":{"name":"g","arguments":"{\"b\":[1"}}]},"finish_reason":"stop"}]}`)
+	out := NormalizeToolCallsNonStream(body)
+
+	calls := gjson.GetBytes(out, "choices.0.message.tool_calls")
+	if !strings.HasPrefix(calls.Get("0.id").String(), "call_") || calls.Get("0.type").String() != "function" {
+		t.Fatalf("missing id/type: %s", calls.Raw)
+	}
+	if calls.Get("0.function.arguments").String() != `{"a":[1,2]}` {
+		t.Fatalf("object arguments not encoded: %s", calls.Get("0.function.arguments").Raw)
+	}
+	if calls.Get("1.function.arguments").String() != `{"b":[1]}` {
+		t.Fatalf("truncated arguments not repaired: %s", calls.Get("1.function.arguments").Raw)
+	}
+	if gjson.GetBytes(out, "choices.0.finish_reason").String() != "tool_calls" {
+		t.Fatalf("finish_reason = %s", gjson.GetBytes(out, "choices.0.finish_reason").Raw)
+	}
+
+	plain := []byte(`{"choices":[{"message":{"content":"hi"},"finish_reason":"stop"}]}`)
+	if got := NormalizeToolCallsNonStream(plain); string(got) != string(plain) {
+		t.Fatalf("responses without tool calls must be unchanged: %s", got)
+	}
+}
+
+func TestToolCallArgumentsSuffix(t *testing.T) {
+	cases := map[string]struct {
+		suffix string
+		ok     bool
+	}{
+		``:              {"{}", true},
+		`{"a":1}`:       {"", true},
+		`{"a":"x`:       {`"}`, true},
+		`{"a":[{"b":2}`: {"]}", true},
+		`{"a":"x\`:      {`\"}`, true},
+		`{"a":1,`:       {"", false},
+		`{"a":1]`:       {"", false},
+	}
+	for input, want := range cases {
+		suffix, ok := ToolCallArgumentsSuffix(input)
+		if suffix != want.suffix || ok != want.ok {
+			t.Errorf("ToolCallArgumentsSuffix(%q) = %q, %v; want %q, %v", input, suffix, ok, want.suffix, want.ok)
+		}
+	}
+}